@@ -22,6 +22,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/featurebasedb/featurebase/v3/audit"
 	fbcontext "github.com/featurebasedb/featurebase/v3/context"
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/computer"
@@ -64,6 +65,17 @@ type API struct {
 	// isComputeNode is set to true if this node is running as a DAX compute
 	// node.
 	isComputeNode bool
+
+	// shardReplicaInterval is how often shards held as read-only replicas
+	// are brought up to date with their write logs. Zero disables it.
+	shardReplicaInterval time.Duration
+
+	// directiveMu serializes applying directives with refreshing replicas,
+	// since both load data into the same shard resources.
+	directiveMu sync.Mutex
+
+	closing     chan struct{}
+	replicaDone chan struct{}
 }
 
 func (api *API) Holder() *Holder {
@@ -115,6 +127,13 @@ func OptAPIIsComputeNode(is bool) apiOption {
 	}
 }
 
+func OptAPIShardReplicaInterval(d time.Duration) apiOption {
+	return func(a *API) error {
+		a.shardReplicaInterval = d
+		return nil
+	}
+}
+
 // NewAPI returns a new API instance.
 func NewAPI(opts ...apiOption) (*API, error) {
 	api := &API{
@@ -141,6 +160,12 @@ func NewAPI(opts ...apiOption) (*API, error) {
 
 	api.tracker = newQueryTracker(api.server.queryHistoryLength)
 
+	api.closing = make(chan struct{})
+	if api.isComputeNode && api.serverlessStorage != nil && api.shardReplicaInterval > 0 {
+		api.replicaDone = make(chan struct{})
+		go api.refreshShardReplicas()
+	}
+
 	return api, nil
 }
 
@@ -180,6 +205,13 @@ func (api *API) validate(f apiMethod) error {
 		return errors.Wrap(err, "getting cluster state")
 	}
 	if _, ok := validAPIMethods[state][f]; ok {
+		// Read replicas only allow the methods a degraded cluster does,
+		// which excludes writes.
+		if api.server != nil && api.server.readReplica != nil {
+			if _, ok := readReplicaAPIMethods[f]; !ok {
+				return newAPIMethodNotAllowedError(errors.Wrapf(ErrReadReplica, "api method %s not allowed", f))
+			}
+		}
 		return nil
 	}
 	return newAPIMethodNotAllowedError(errors.Errorf("api method %s not allowed in state %s", f, state))
@@ -195,6 +227,11 @@ func (api *API) Close() error {
 	}
 	api.closed = true
 
+	close(api.closing)
+	if api.replicaDone != nil {
+		<-api.replicaDone
+	}
+
 	close(api.importWork)
 	api.importWorkersWG.Wait()
 	api.tracker.Stop()
@@ -228,6 +265,9 @@ func (api *API) query(ctx context.Context, req *QueryRequest) (QueryResponse, er
 	if err != nil {
 		return QueryResponse{}, errors.Wrap(err, "parsing")
 	}
+	if api.server.readReplica != nil && q.WriteCallN() > 0 {
+		return QueryResponse{}, ErrReadReplica
+	}
 
 	// TODO can we get rid of exec options and pass the QueryRequest directly to executor?
 	execOpts := &ExecOptions{
@@ -251,9 +291,10 @@ func (api *API) query(ctx context.Context, req *QueryRequest) (QueryResponse, er
 }
 
 // CreateIndex makes a new Pilosa index.
-func (api *API) CreateIndex(ctx context.Context, indexName string, options IndexOptions) (*Index, error) {
+func (api *API) CreateIndex(ctx context.Context, indexName string, options IndexOptions) (_ *Index, err error) {
 	span, _ := tracing.StartSpanFromContext(ctx, "API.CreateIndex")
 	defer span.Finish()
+	defer func() { api.server.auditLogger.Log(ctx, audit.EventCreateTable, indexName, "", err) }()
 
 	// get the requestUserID from the context -- assumes the http handler has populated this from
 	// authN/Z info
@@ -325,16 +366,17 @@ func (api *API) DeleteDataframe(ctx context.Context, indexName string) error {
 
 // DeleteIndex removes the named index. If the index is not found it does
 // nothing and returns no error.
-func (api *API) DeleteIndex(ctx context.Context, indexName string) error {
+func (api *API) DeleteIndex(ctx context.Context, indexName string) (err error) {
 	span, _ := tracing.StartSpanFromContext(ctx, "API.DeleteIndex")
 	defer span.Finish()
+	defer func() { api.server.auditLogger.Log(ctx, audit.EventDropTable, indexName, "", err) }()
 
 	if err := api.validate(apiDeleteIndex); err != nil {
 		return errors.Wrap(err, "validating api method")
 	}
 
 	// Delete index from the holder.
-	err := api.holder.DeleteIndex(indexName)
+	err = api.holder.DeleteIndex(indexName)
 	if err != nil {
 		return errors.Wrap(err, "deleting index")
 	}
@@ -369,9 +411,10 @@ func (api *API) DeleteIndex(ctx context.Context, indexName string) error {
 // CreateField makes the named field in the named index with the given options.
 //
 // The resulting field will always have TrackExistence set.
-func (api *API) CreateField(ctx context.Context, indexName string, fieldName string, opts ...FieldOption) (*Field, error) {
+func (api *API) CreateField(ctx context.Context, indexName string, fieldName string, opts ...FieldOption) (_ *Field, err error) {
 	span, _ := tracing.StartSpanFromContext(ctx, "API.CreateField")
 	defer span.Finish()
+	defer func() { api.server.auditLogger.Log(ctx, audit.EventCreateField, indexName+"."+fieldName, "", err) }()
 
 	if err := api.validate(apiCreateField); err != nil {
 		return nil, errors.Wrap(err, "validating api method")
@@ -632,8 +675,7 @@ func (api *API) ImportRoaring(ctx context.Context, indexName, fieldName string,
 
 	// This node only handles the shard(s) that it owns.
 	if api.isComputeNode {
-		directive := api.holder.Directive()
-		if !shardInShards(dax.ShardNum(shard), directive.ComputeShards(dax.TableKey(index.Name()))) {
+		if !api.computeShardWritable(index.Name(), shard, req.SuppressLog) {
 			return errors.Errorf("import request shard is not supported (roaring): %d", shard)
 		}
 	}
@@ -727,9 +769,10 @@ func (api *API) ImportRoaring(ctx context.Context, indexName, fieldName string,
 // DeleteField removes the named field from the named index. If the index is not
 // found, an error is returned. If the field is not found, it is ignored and no
 // action is taken.
-func (api *API) DeleteField(ctx context.Context, indexName string, fieldName string) error {
+func (api *API) DeleteField(ctx context.Context, indexName string, fieldName string) (err error) {
 	span, _ := tracing.StartSpanFromContext(ctx, "API.DeleteField")
 	defer span.Finish()
+	defer func() { api.server.auditLogger.Log(ctx, audit.EventDropField, indexName+"."+fieldName, "", err) }()
 
 	if err := api.validate(apiDeleteField); err != nil {
 		return errors.Wrap(err, "validating api method")
@@ -747,7 +790,7 @@ func (api *API) DeleteField(ctx context.Context, indexName string, fieldName str
 	}
 
 	// Send the delete field message to all nodes.
-	err := api.server.SendSync(
+	err = api.server.SendSync(
 		&DeleteFieldMessage{
 			Index: indexName,
 			Field: fieldName,
@@ -1078,6 +1121,30 @@ func (api *API) RecalculateCaches(ctx context.Context) error {
 	return nil
 }
 
+// Compact compacts the local RBF databases whose ratio of free pages is at
+// least threshold, returning free space to the filesystem. If indexName is
+// non-blank, only that index is compacted.
+func (api *API) Compact(ctx context.Context, indexName string, threshold float64) ([]ShardCompaction, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "API.Compact")
+	defer span.Finish()
+
+	if indexName != "" && api.holder.Index(indexName) == nil {
+		return nil, newNotFoundError(ErrIndexNotFound, indexName)
+	}
+
+	start := time.Now()
+	defer func() { SummaryRBFCompactionDurationSeconds.Observe(time.Since(start).Seconds()) }()
+	return api.holder.compactShards(ctx, indexName, threshold)
+}
+
+// Fragmentation returns the combined fragmentation of the local RBF
+// databases. If indexName is non-blank, only that index is included.
+func (api *API) Fragmentation(ctx context.Context, indexName string) (Fragmentation, error) {
+	span, _ := tracing.StartSpanFromContext(ctx, "API.Fragmentation")
+	defer span.Finish()
+	return api.holder.fragmentation(indexName)
+}
+
 // ClusterMessage is for internal use. It decodes a protobuf message out of
 // the body and forwards it to the BroadcastHandler.
 func (api *API) ClusterMessage(ctx context.Context, reqBody io.Reader) error {
@@ -1527,8 +1594,7 @@ func (api *API) ImportWithTx(ctx context.Context, qcx *Qcx, req *ImportRequest,
 
 	// This node only handles the shard(s) that it owns.
 	if api.isComputeNode {
-		directive := api.holder.Directive()
-		if !shardInShards(dax.ShardNum(req.Shard), directive.ComputeShards(dax.TableKey(idx.Name()))) {
+		if !api.computeShardWritable(idx.Name(), req.Shard, options.suppressLog) {
 			return errors.Errorf("import request shard is not supported (with tx): %d", req.Shard)
 		}
 	}
@@ -1864,8 +1930,7 @@ func (api *API) ImportValueWithTx(ctx context.Context, qcx *Qcx, req *ImportValu
 
 	// This node only handles the shard(s) that it owns.
 	if api.isComputeNode {
-		directive := api.holder.Directive()
-		if !shardInShards(dax.ShardNum(req.Shard), directive.ComputeShards(dax.TableKey(idx.Name()))) {
+		if !api.computeShardWritable(idx.Name(), req.Shard, options.suppressLog) {
 			return errors.Errorf("import request shard is not supported (value with tx): %d", req.Shard)
 		}
 	}
@@ -2550,14 +2615,47 @@ func (api *API) TranslateFieldDB(ctx context.Context, indexName, fieldName strin
 	return err
 }
 
+// ShardVersions returns the version of every local shard of an index. A
+// shard's version changes whenever it is written.
+func (api *API) ShardVersions(ctx context.Context, indexName string) (map[uint64]int64, error) {
+	span, _ := tracing.StartSpanFromContext(ctx, "API.ShardVersions")
+	defer span.Finish()
+
+	if api.holder.Index(indexName) == nil {
+		return nil, newNotFoundError(ErrIndexNotFound, indexName)
+	}
+	return api.holder.shardVersions(indexName)
+}
+
+// ReadReplicaStatus returns the freshness of the local node's data if it is a
+// read replica, or nil otherwise.
+func (api *API) ReadReplicaStatus() *ReadReplicaStatus {
+	if api.server == nil || api.server.readReplica == nil {
+		return nil
+	}
+	return api.server.readReplica.status()
+}
+
 // RestoreShard is used by the restore tool to restore previously backed up data. This call is specific to RBF data for a shard.
-func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64, rd io.Reader) error {
+func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64, rd io.Reader) (err error) {
+	defer func() {
+		api.server.auditLogger.Log(ctx, audit.EventRestore, indexName, fmt.Sprintf("shard %d", shard), err)
+	}()
+
 	snap := api.cluster.NewSnapshot()
 	if !snap.OwnsShard(api.server.nodeID, indexName, shard) {
 		return ErrClusterDoesNotOwnShard // TODO (twg)really just node doesn't own shard but leave for now
 	}
+	return api.restoreShard(ctx, indexName, shard, rd)
+}
 
+// restoreShard replaces the RBF data for a shard with the snapshot in rd,
+// regardless of whether the node owns the shard.
+func (api *API) restoreShard(ctx context.Context, indexName string, shard uint64, rd io.Reader) error {
 	idx := api.holder.Index(indexName)
+	if idx == nil {
+		return newNotFoundError(ErrIndexNotFound, indexName)
+	}
 	// need to get a dbShard
 	dbs, err := api.holder.Txf().dbPerShard.GetDBShard(indexName, shard, idx)
 	if err != nil {
@@ -2596,6 +2694,17 @@ func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64
 		_ = os.Remove(tempPath)
 		return err
 	}
+	// The WAL, page checksums and encryption entries describe the replaced
+	// data file, so they must not be applied to the restored one, which is a
+	// plaintext snapshot. Checksums are rebuilt and pages encrypted on open.
+	for _, path := range []string{
+		db.Path() + "/wal", db.Path() + "/wal" + rbf.ChecksumFileExt, db.Path() + "/wal" + rbf.EncryptionFileExt,
+		finalPath + rbf.ChecksumFileExt, finalPath + rbf.EncryptionFileExt,
+	} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
 	err = db.OpenDB()
 	if err != nil {
 		return err
@@ -2609,9 +2718,11 @@ func (api *API) RestoreShard(ctx context.Context, indexName string, shard uint64
 	// are ignored
 	flvs, err := tx.GetSortedFieldViewList(idx, shard)
 	if err != nil {
-		return nil
+		return err
 	}
 
+	// Create the fragment of each view in the snapshot, which makes the shard
+	// available locally for queries of its fields.
 	for _, flv := range flvs {
 		fld := idx.field(flv.Field)
 		view := fld.view(flv.View)
@@ -3057,6 +3168,12 @@ func (api *API) DirectiveApplied(ctx context.Context) (bool, error) {
 	return api.holder.DirectiveApplied(), nil
 }
 
+// DirectiveLoad returns the load on each of the jobs in the computer's current
+// Directive.
+func (api *API) DirectiveLoad(ctx context.Context) (*dax.WorkerLoad, error) {
+	return api.holder.WorkerLoad(), nil
+}
+
 // SnapshotShardData triggers the node to perform a shard snapshot based on the
 // provided SnapshotShardDataRequest.
 func (api *API) SnapshotShardData(ctx context.Context, req *dax.SnapshotShardDataRequest) error {
@@ -3249,6 +3366,9 @@ var methodsDegraded = map[apiMethod]struct{}{
 	apiPartitionNodes:    {},
 }
 
+// readReplicaAPIMethods are the methods allowed on a read replica.
+var readReplicaAPIMethods = appendMap(methodsCommon, methodsDegraded)
+
 var methodsNormal = map[apiMethod]struct{}{
 	apiCreateField:          {},
 	apiCreateIndex:          {},
@@ -3286,6 +3406,18 @@ var methodsNormal = map[apiMethod]struct{}{
 	apiDeleteDataframe:      {},
 }
 
+// computeShardWritable returns true if this compute node may write to the
+// given shard. Only the compute node responsible for writes to the shard
+// accepts new writes, but read-only replicas of the shard apply the writes
+// which they replay from its write log.
+func (api *API) computeShardWritable(index string, shard uint64, replay bool) bool {
+	directive := api.holder.Directive()
+	if shardInShards(dax.ShardNum(shard), directive.ComputeShards(dax.TableKey(index))) {
+		return true
+	}
+	return replay && shardInShards(dax.ShardNum(shard), directive.ReplicaShardsMap()[dax.TableKey(index)])
+}
+
 func shardInShards(i dax.ShardNum, s dax.ShardNums) bool {
 	for _, o := range s {
 		if i == o {
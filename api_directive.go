@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"sync"
+	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/computer"
@@ -17,6 +18,9 @@ import (
 // ApplyDirective applies a Directive received, from the Controller, at the
 // /directive endpoint.
 func (api *API) ApplyDirective(ctx context.Context, d *dax.Directive) error {
+	api.directiveMu.Lock()
+	defer api.directiveMu.Unlock()
+
 	// Get the current directive for comparison.
 	previousDirective := api.holder.Directive()
 
@@ -131,6 +135,9 @@ type directiveJobShards struct {
 	directiveJobType
 	tkey  dax.TableKey
 	shard dax.ShardNum
+
+	// replica is true if the shard is to be loaded as a read-only replica.
+	replica bool
 }
 
 // directiveWorker is a worker in a worker pool which handles portions of a
@@ -153,7 +160,11 @@ func (api *API) directiveWorker(ctx context.Context, jobs <-chan directiveJobTyp
 				errs <- errors.Wrapf(err, "loading field keys: %s, %s", job.tkey, job.field)
 			}
 		case directiveJobShards:
-			if err := api.loadShard(ctx, job.tkey, job.shard); err != nil {
+			if job.replica {
+				if err := api.loadShardReplica(ctx, job.tkey, job.shard); err != nil {
+					errs <- errors.Wrapf(err, "loading shard replica: %s, %s", job.tkey, job.shard)
+				}
+			} else if err := api.loadShard(ctx, job.tkey, job.shard); err != nil {
 				errs <- errors.Wrapf(err, "loading shard: %s, %s", job.tkey, job.shard)
 			}
 		default:
@@ -529,9 +540,12 @@ func (api *API) loadFieldKeys(ctx context.Context, tkey dax.TableKey, field dax.
 func (api *API) pushJobsShards(ctx context.Context, jobs chan<- directiveJobType, fromD, toD *dax.Directive) {
 	// Put shards into a map by table.
 	shardMap := toD.ComputeShardsMap()
+	replicaMap := toD.ReplicaShardsMap()
 
-	// Get the diff between from/to directive shards.
+	// Get the diff between from/to directive shards, both for the shards
+	// this worker writes and for those it holds as read-only replicas.
 	shardComp := newShardsComparer(fromD.ComputeShardsMap(), shardMap)
+	replicaComp := newShardsComparer(fromD.ReplicaShardsMap(), replicaMap)
 
 	// Remove any shards which are no longer assigned to this worker.
 	// TODO(tlt): currently, this is just removing the file lock on the
@@ -540,6 +554,28 @@ func (api *API) pushJobsShards(ctx context.Context, jobs chan<- directiveJobType
 	for tkey, shards := range shardComp.removed() {
 		qtid := tkey.QualifiedTableID()
 		for _, shard := range shards {
+			partition := dax.PartitionNum(disco.ShardToShardPartition(string(tkey), uint64(shard), disco.DefaultPartitionN))
+			// A shard which has become a replica keeps its data, but gives
+			// up the lock so that the new writer can take it.
+			if shardInShards(shard, replicaMap[tkey]) {
+				resource := api.serverlessStorage.GetShardResource(qtid, partition, shard)
+				if resource.IsLocked() {
+					if err := resource.Unlock(); err != nil {
+						api.logger().Printf("unlocking shard resource which became a replica: %v", err)
+					}
+				}
+				continue
+			}
+			api.serverlessStorage.RemoveShardResource(qtid, partition, shard)
+		}
+	}
+	for tkey, shards := range replicaComp.removed() {
+		qtid := tkey.QualifiedTableID()
+		for _, shard := range shards {
+			// A replica which has become the writer is handled by loadShard.
+			if shardInShards(shard, shardMap[tkey]) {
+				continue
+			}
 			partition := dax.PartitionNum(disco.ShardToShardPartition(string(tkey), uint64(shard), disco.DefaultPartitionN))
 			api.serverlessStorage.RemoveShardResource(qtid, partition, shard)
 		}
@@ -554,6 +590,15 @@ func (api *API) pushJobsShards(ctx context.Context, jobs chan<- directiveJobType
 			}
 		}
 	}
+	for tkey, shards := range replicaComp.added() {
+		for _, shard := range shards {
+			jobs <- directiveJobShards{
+				tkey:    tkey,
+				shard:   shard,
+				replica: true,
+			}
+		}
+	}
 }
 
 func (api *API) loadShard(ctx context.Context, tkey dax.TableKey, shard dax.ShardNum) error {
@@ -567,133 +612,193 @@ func (api *API) loadShard(ctx context.Context, tkey dax.TableKey, shard dax.Shar
 		return nil
 	}
 
-	if rc, err := resource.LoadLatestSnapshot(); err != nil {
-		return errors.Wrap(err, "reading latest snapshot for shard")
-	} else if rc != nil {
-		defer rc.Close()
-		if err := api.RestoreShard(ctx, string(tkey), uint64(shard), rc); err != nil {
-			return errors.Wrap(err, "restoring shard data")
-		}
+	// 1st load. If this worker already held the shard as a replica, this
+	// only loads what the replica hasn't yet seen.
+	if err := api.refreshShard(ctx, tkey, shard, resource); err != nil {
+		return err
 	}
 
-	// define write log loading in a func because we do it twice.
-	loadWriteLog := func() error {
-		writelog, err := resource.LoadWriteLog()
-		if err != nil {
-			return errors.Wrap(err, "")
-		}
-		if writelog == nil {
-			return nil
-		}
+	// acquire lock on this partition's keys
+	if err := resource.Lock(); err != nil {
+		return errors.Wrap(err, "locking field key partition")
+	}
 
-		reader := storage.NewShardReader(qtid, partition, shard, writelog)
-		defer reader.Close()
-		for logMsg, err := reader.Read(); err != io.EOF; logMsg, err = reader.Read() {
-			if err != nil {
-				return errors.Wrap(err, "reading from log reader")
-			}
+	// reload writelog in case of changes between last load and
+	// lock. The resource object takes care of only loading new data.
+	return api.loadShardWriteLog(ctx, tkey, shard, resource)
+}
 
-			switch msg := logMsg.(type) {
-			case *computer.ImportRoaringMessage:
-				req := &ImportRoaringRequest{
-					Clear:           msg.Clear,
-					Action:          msg.Action,
-					Block:           msg.Block,
-					Views:           msg.Views,
-					UpdateExistence: msg.UpdateExistence,
-					SuppressLog:     true,
-				}
-				if err := api.ImportRoaring(ctx, msg.Table, msg.Field, msg.Shard, true, req); err != nil {
-					return errors.Wrapf(err, "import roaring, table: %s, field: %s, shard: %d", msg.Table, msg.Field, msg.Shard)
-				}
+// refreshShardReplicas periodically brings the shards which this node holds
+// as read-only replicas up to date, until the API is closed.
+func (api *API) refreshShardReplicas() {
+	defer close(api.replicaDone)
 
-			case *computer.ImportMessage:
-				req := &ImportRequest{
-					Index:      msg.Table,
-					Field:      msg.Field,
-					Shard:      msg.Shard,
-					RowIDs:     msg.RowIDs,
-					ColumnIDs:  msg.ColumnIDs,
-					RowKeys:    msg.RowKeys,
-					ColumnKeys: msg.ColumnKeys,
-					Timestamps: msg.Timestamps,
-					Clear:      msg.Clear,
-				}
+	ticker := time.NewTicker(api.shardReplicaInterval)
+	defer ticker.Stop()
 
-				qcx := api.Txf().NewQcx()
-				defer qcx.Abort()
+	for {
+		select {
+		case <-api.closing:
+			return
+		case <-ticker.C:
+		}
 
-				opts := []ImportOption{
-					OptImportOptionsClear(msg.Clear),
-					OptImportOptionsIgnoreKeyCheck(msg.IgnoreKeyCheck),
-					OptImportOptionsPresorted(msg.Presorted),
-					OptImportOptionsSuppressLog(true),
-				}
-				if err := api.Import(ctx, qcx, req, opts...); err != nil {
-					return errors.Wrapf(err, "import, table: %s, field: %s, shard: %d", msg.Table, msg.Field, msg.Shard)
+		api.directiveMu.Lock()
+		d := api.holder.Directive()
+		for tkey, shards := range d.ReplicaShardsMap() {
+			for _, shard := range shards {
+				if err := api.loadShardReplica(context.Background(), tkey, shard); err != nil {
+					api.logger().Printf("refreshing shard replica: %s, %s: %v", tkey, shard, err)
 				}
+			}
+		}
+		api.directiveMu.Unlock()
+	}
+}
 
-			case *computer.ImportValueMessage:
-				req := &ImportValueRequest{
-					Index:           msg.Table,
-					Field:           msg.Field,
-					Shard:           msg.Shard,
-					ColumnIDs:       msg.ColumnIDs,
-					ColumnKeys:      msg.ColumnKeys,
-					Values:          msg.Values,
-					FloatValues:     msg.FloatValues,
-					TimestampValues: msg.TimestampValues,
-					StringValues:    msg.StringValues,
-					Clear:           msg.Clear,
-				}
+// loadShardReplica loads a shard as a read-only replica. Unlike loadShard, it
+// doesn't take the lock on the shard, since another worker writes to it.
+// The replica is kept up to date by refreshShardReplicas.
+func (api *API) loadShardReplica(ctx context.Context, tkey dax.TableKey, shard dax.ShardNum) error {
+	qtid := tkey.QualifiedTableID()
 
-				qcx := api.Txf().NewQcx()
-				defer qcx.Abort()
+	partition := dax.PartitionNum(disco.ShardToShardPartition(string(tkey), uint64(shard), disco.DefaultPartitionN))
 
-				opts := []ImportOption{
-					OptImportOptionsClear(msg.Clear),
-					OptImportOptionsIgnoreKeyCheck(msg.IgnoreKeyCheck),
-					OptImportOptionsPresorted(msg.Presorted),
-					OptImportOptionsSuppressLog(true),
-				}
-				if err := api.ImportValue(ctx, qcx, req, opts...); err != nil {
-					return errors.Wrapf(err, "import value, table: %s, field: %s, shard: %d", msg.Table, msg.Field, msg.Shard)
-				}
-			case *computer.ImportRoaringShardMessage:
-				req := &ImportRoaringShardRequest{
-					Remote:      true,
-					Views:       make([]RoaringUpdate, len(msg.Views)),
-					SuppressLog: true,
-				}
-				for i, view := range msg.Views {
-					req.Views[i] = RoaringUpdate{
-						Field:        view.Field,
-						View:         view.View,
-						Clear:        view.Clear,
-						Set:          view.Set,
-						ClearRecords: view.ClearRecords,
-					}
-				}
-				if err := api.ImportRoaringShard(ctx, msg.Table, msg.Shard, req); err != nil {
-					return errors.Wrapf(err, "import roaring shard table: %s, shard: %d", msg.Table, msg.Shard)
-				}
+	return api.refreshShard(ctx, tkey, shard, api.serverlessStorage.GetShardResource(qtid, partition, shard))
+}
+
+// refreshShard brings the local copy of a shard up to date with its snapshot
+// and write log. The shard is restored from the latest snapshot if it has
+// never been loaded, or if a snapshot has been written since it was.
+func (api *API) refreshShard(ctx context.Context, tkey dax.TableKey, shard dax.ShardNum, resource *storage.Resource) error {
+	advanced, err := resource.SnapshotAdvanced()
+	if err != nil {
+		return errors.Wrap(err, "checking for new snapshot for shard")
+	}
+
+	if advanced {
+		if rc, err := resource.LoadLatestSnapshot(); err != nil {
+			return errors.Wrap(err, "reading latest snapshot for shard")
+		} else if rc != nil {
+			defer rc.Close()
+			if err := api.RestoreShard(ctx, string(tkey), uint64(shard), rc); err != nil {
+				return errors.Wrap(err, "restoring shard data")
 			}
 		}
-		return nil
-	}
-	// 1st write log load
-	if err := loadWriteLog(); err != nil {
-		return err
 	}
 
-	// acquire lock on this partition's keys
-	if err := resource.Lock(); err != nil {
-		return errors.Wrap(err, "locking field key partition")
+	return api.loadShardWriteLog(ctx, tkey, shard, resource)
+}
+
+// loadShardWriteLog applies the writes in the shard's write log which haven't
+// yet been applied locally.
+func (api *API) loadShardWriteLog(ctx context.Context, tkey dax.TableKey, shard dax.ShardNum, resource *storage.Resource) error {
+	qtid := tkey.QualifiedTableID()
+
+	partition := dax.PartitionNum(disco.ShardToShardPartition(string(tkey), uint64(shard), disco.DefaultPartitionN))
+
+	writelog, err := resource.LoadWriteLog()
+	if err != nil {
+		return errors.Wrap(err, "")
+	}
+	if writelog == nil {
+		return nil
 	}
 
-	// reload writelog in case of changes between last load and
-	// lock. The resource object takes care of only loading new data.
-	return loadWriteLog()
+	reader := storage.NewShardReader(qtid, partition, shard, writelog)
+	defer reader.Close()
+	for logMsg, err := reader.Read(); err != io.EOF; logMsg, err = reader.Read() {
+		if err != nil {
+			return errors.Wrap(err, "reading from log reader")
+		}
+
+		switch msg := logMsg.(type) {
+		case *computer.ImportRoaringMessage:
+			req := &ImportRoaringRequest{
+				Clear:           msg.Clear,
+				Action:          msg.Action,
+				Block:           msg.Block,
+				Views:           msg.Views,
+				UpdateExistence: msg.UpdateExistence,
+				SuppressLog:     true,
+			}
+			if err := api.ImportRoaring(ctx, msg.Table, msg.Field, msg.Shard, true, req); err != nil {
+				return errors.Wrapf(err, "import roaring, table: %s, field: %s, shard: %d", msg.Table, msg.Field, msg.Shard)
+			}
+
+		case *computer.ImportMessage:
+			req := &ImportRequest{
+				Index:      msg.Table,
+				Field:      msg.Field,
+				Shard:      msg.Shard,
+				RowIDs:     msg.RowIDs,
+				ColumnIDs:  msg.ColumnIDs,
+				RowKeys:    msg.RowKeys,
+				ColumnKeys: msg.ColumnKeys,
+				Timestamps: msg.Timestamps,
+				Clear:      msg.Clear,
+			}
+
+			qcx := api.Txf().NewQcx()
+			defer qcx.Abort()
+
+			opts := []ImportOption{
+				OptImportOptionsClear(msg.Clear),
+				OptImportOptionsIgnoreKeyCheck(msg.IgnoreKeyCheck),
+				OptImportOptionsPresorted(msg.Presorted),
+				OptImportOptionsSuppressLog(true),
+			}
+			if err := api.Import(ctx, qcx, req, opts...); err != nil {
+				return errors.Wrapf(err, "import, table: %s, field: %s, shard: %d", msg.Table, msg.Field, msg.Shard)
+			}
+
+		case *computer.ImportValueMessage:
+			req := &ImportValueRequest{
+				Index:           msg.Table,
+				Field:           msg.Field,
+				Shard:           msg.Shard,
+				ColumnIDs:       msg.ColumnIDs,
+				ColumnKeys:      msg.ColumnKeys,
+				Values:          msg.Values,
+				FloatValues:     msg.FloatValues,
+				TimestampValues: msg.TimestampValues,
+				StringValues:    msg.StringValues,
+				Clear:           msg.Clear,
+			}
+
+			qcx := api.Txf().NewQcx()
+			defer qcx.Abort()
+
+			opts := []ImportOption{
+				OptImportOptionsClear(msg.Clear),
+				OptImportOptionsIgnoreKeyCheck(msg.IgnoreKeyCheck),
+				OptImportOptionsPresorted(msg.Presorted),
+				OptImportOptionsSuppressLog(true),
+			}
+			if err := api.ImportValue(ctx, qcx, req, opts...); err != nil {
+				return errors.Wrapf(err, "import value, table: %s, field: %s, shard: %d", msg.Table, msg.Field, msg.Shard)
+			}
+		case *computer.ImportRoaringShardMessage:
+			req := &ImportRoaringShardRequest{
+				Remote:      true,
+				Views:       make([]RoaringUpdate, len(msg.Views)),
+				SuppressLog: true,
+			}
+			for i, view := range msg.Views {
+				req.Views[i] = RoaringUpdate{
+					Field:        view.Field,
+					View:         view.View,
+					Clear:        view.Clear,
+					Set:          view.Set,
+					ClearRecords: view.ClearRecords,
+				}
+			}
+			if err := api.ImportRoaringShard(ctx, msg.Table, msg.Shard, req); err != nil {
+				return errors.Wrapf(err, "import roaring shard table: %s, shard: %d", msg.Table, msg.Shard)
+			}
+		}
+	}
+	return nil
 }
 
 //////////////////////////////////////////////////////////////
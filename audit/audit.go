@@ -0,0 +1,191 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+// Package audit records security relevant events, such as changes to the
+// schema, permissions or data, as structured JSON lines written to one or
+// more sinks.
+package audit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/featurebasedb/featurebase/v3/authn"
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"google.golang.org/grpc/peer"
+)
+
+// EventType is the kind of action an Event records.
+type EventType string
+
+const (
+	EventLogin        EventType = "login"
+	EventLogout       EventType = "logout"
+	EventAuthFailure  EventType = "auth-failure"
+	EventCreateTable  EventType = "create-table"
+	EventDropTable    EventType = "drop-table"
+	EventCreateField  EventType = "create-field"
+	EventDropField    EventType = "drop-field"
+	EventCreateRole   EventType = "create-role"
+	EventDropRole     EventType = "drop-role"
+	EventGrant        EventType = "grant"
+	EventRevoke       EventType = "revoke"
+	EventCreateAPIKey EventType = "create-api-key"
+	EventRevokeAPIKey EventType = "revoke-api-key"
+	EventDelete       EventType = "delete"
+	EventRestore      EventType = "restore"
+)
+
+// Outcome is whether the action an Event records succeeded.
+type Outcome string
+
+const (
+	OutcomeSuccess Outcome = "success"
+	OutcomeFailure Outcome = "failure"
+)
+
+// Event is a single entry in the audit log.
+type Event struct {
+	Time     time.Time `json:"time"`
+	Type     EventType `json:"type"`
+	UserID   string    `json:"user-id,omitempty"`
+	UserName string    `json:"user-name,omitempty"`
+	SourceIP string    `json:"source-ip,omitempty"`
+	// Object is what the action was applied to, such as a table, a field
+	// (as table.field) or a role.
+	Object  string  `json:"object,omitempty"`
+	Detail  string  `json:"detail,omitempty"`
+	Outcome Outcome `json:"outcome"`
+	Error   string  `json:"error,omitempty"`
+
+	// PrevHash and Hash chain the events written to a FileSink together, so
+	// that removing or changing an event can be detected. They're empty in
+	// events written to other sinks.
+	PrevHash string `json:"prev-hash,omitempty"`
+	Hash     string `json:"hash,omitempty"`
+}
+
+// Sink is somewhere audit events are written to.
+type Sink interface {
+	Write(e *Event) error
+	Close() error
+}
+
+// Logger writes audit events to its sinks. A nil *Logger is valid and
+// discards every event, so callers don't need to check whether auditing is
+// enabled.
+type Logger struct {
+	mu     sync.Mutex
+	sinks  []Sink
+	logger logger.Logger
+}
+
+// NewLogger returns a Logger which writes events to sinks. Errors writing to
+// a sink are logged to log, so that a failing sink doesn't fail the action
+// being audited.
+func NewLogger(log logger.Logger, sinks ...Sink) *Logger {
+	if log == nil {
+		log = logger.NopLogger
+	}
+	return &Logger{
+		sinks:  sinks,
+		logger: log,
+	}
+}
+
+// Log records an event of type typ applied to object. The user and source IP
+// are taken from ctx. If err is not nil, the event's outcome is a failure.
+func (l *Logger) Log(ctx context.Context, typ EventType, object, detail string, err error) {
+	if l == nil {
+		return
+	}
+
+	e := &Event{
+		Type:    typ,
+		Object:  object,
+		Detail:  detail,
+		Outcome: OutcomeSuccess,
+	}
+	if uinfo, ok := authn.GetUserInfo(ctx); ok && uinfo != nil {
+		e.UserID = uinfo.UserID
+		e.UserName = uinfo.UserName
+	} else if userID, ok := fbcontext.UserID(ctx); ok {
+		e.UserID = userID
+	}
+	if ip, ok := fbcontext.SourceIP(ctx); ok {
+		e.SourceIP = ip
+	} else if p, ok := peer.FromContext(ctx); ok {
+		e.SourceIP = p.Addr.String()
+	}
+	if err != nil {
+		e.Outcome = OutcomeFailure
+		e.Error = err.Error()
+	}
+	l.LogEvent(e)
+}
+
+// maxDetailSize is the most of an event's detail or error which is recorded.
+// Longer values, such as the queries of large deletes, are truncated.
+const maxDetailSize = 4096
+
+// LogEvent writes e to every sink, setting its time if it isn't set, and
+// truncating its detail and error if they're too long.
+func (l *Logger) LogEvent(e *Event) {
+	if l == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now().UTC()
+	}
+	e.Detail = truncate(e.Detail)
+	e.Error = truncate(e.Error)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		// each sink gets its own copy, since sinks may set fields
+		ev := *e
+		if err := s.Write(&ev); err != nil {
+			l.logger.Errorf("writing audit event %s: %v", e.Type, err)
+		}
+	}
+}
+
+// truncate returns s if it's at most maxDetailSize bytes long. Otherwise it
+// returns the start of s, cut at a rune boundary, followed by the size and
+// SHA-256 hash of s, which identify it.
+func truncate(s string) string {
+	if len(s) <= maxDetailSize {
+		return s
+	}
+	n := maxDetailSize
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	sum := sha256.Sum256([]byte(s))
+	return fmt.Sprintf("%s... (%d bytes, sha256 %s)", s[:n], len(s), hex.EncodeToString(sum[:]))
+}
+
+// Close closes every sink.
+func (l *Logger) Close() error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var firstErr error
+	for _, s := range l.sinks {
+		if err := s.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	l.sinks = nil
+	return firstErr
+}
@@ -0,0 +1,382 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package audit_test
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/audit"
+	"github.com/featurebasedb/featurebase/v3/authn"
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
+)
+
+// readEvents returns the events in the audit log at path.
+func readEvents(t *testing.T, path string) []audit.Event {
+	t.Helper()
+	b, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var events []audit.Event
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for scanner.Scan() {
+		var e audit.Event
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("unmarshaling %q: %v", scanner.Text(), err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// testKey is the key the audit logs in tests are signed with.
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+func verifyFile(t *testing.T, path, prevHash string) (string, error) {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	return audit.Verify(f, testKey, prevHash)
+}
+
+func TestLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := audit.NewFileSink(path, testKey, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := audit.NewLogger(nil, sink)
+
+	ctx := authn.WithUserInfo(context.Background(), &authn.UserInfo{UserID: "uid", UserName: "alice"})
+	ctx = fbcontext.WithSourceIP(ctx, "10.0.0.1")
+	l.Log(ctx, audit.EventCreateTable, "t", "", nil)
+	l.Log(fbcontext.WithUserID(context.Background(), "bob"), audit.EventDropField, "t.f", "", errors.New("field not found"))
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	events := readEvents(t, path)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	if e := events[0]; e.Type != audit.EventCreateTable || e.UserID != "uid" || e.UserName != "alice" || e.SourceIP != "10.0.0.1" || e.Object != "t" || e.Outcome != audit.OutcomeSuccess || e.Error != "" || e.Time.IsZero() {
+		t.Fatalf("unexpected first event: %+v", e)
+	}
+	if e := events[1]; e.Type != audit.EventDropField || e.UserID != "bob" || e.Outcome != audit.OutcomeFailure || e.Error != "field not found" {
+		t.Fatalf("unexpected second event: %+v", e)
+	}
+
+	// a nil Logger discards events
+	var nl *audit.Logger
+	nl.Log(ctx, audit.EventLogin, "", "", nil)
+	if err := nl.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFileSink_HashChain(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := audit.NewFileSink(path, testKey, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := audit.NewLogger(nil, sink)
+	l.Log(context.Background(), audit.EventGrant, "etl", "", nil)
+	l.Log(context.Background(), audit.EventRevoke, "etl", "", nil)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopening the file continues the chain
+	sink, err = audit.NewFileSink(path, testKey, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l = audit.NewLogger(nil, sink)
+	l.Log(context.Background(), audit.EventDelete, "t", "", nil)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	events := readEvents(t, path)
+	if len(events) != 3 {
+		t.Fatalf("expected 3 events, got %d", len(events))
+	}
+	if events[0].PrevHash != "" || events[1].PrevHash != events[0].Hash || events[2].PrevHash != events[1].Hash {
+		t.Fatalf("events aren't chained: %+v", events)
+	}
+	last, err := verifyFile(t, path, "")
+	if err != nil {
+		t.Fatal(err)
+	} else if last != events[2].Hash {
+		t.Fatalf("expected last hash %s, got %s", events[2].Hash, last)
+	}
+
+	t.Run("Tampered", func(t *testing.T) {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.SplitAfter(string(b), "\n")
+
+		// changing an event
+		changed := strings.Replace(string(b), `"object":"t"`, `"object":"u"`, 1)
+		if err := os.WriteFile(path, []byte(changed), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := verifyFile(t, path, ""); err == nil || !strings.Contains(err.Error(), "line 3") {
+			t.Fatalf("expected error on line 3, got %v", err)
+		}
+
+		// removing an event
+		removed := lines[0] + lines[2]
+		if err := os.WriteFile(path, []byte(removed), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := verifyFile(t, path, ""); err == nil || !strings.Contains(err.Error(), "line 2") {
+			t.Fatalf("expected error on line 2, got %v", err)
+		}
+
+		// changing an event and recomputing the chain without the key
+		rehash := func(t *testing.T, hash func(b []byte) string) {
+			t.Helper()
+			var out []byte
+			var prevHash string
+			for _, e := range events {
+				e.Object = "u"
+				e.PrevHash = prevHash
+				e.Hash = ""
+				b, err := json.Marshal(&e)
+				if err != nil {
+					t.Fatal(err)
+				}
+				e.Hash = hash(b)
+				prevHash = e.Hash
+				line, err := json.Marshal(&e)
+				if err != nil {
+					t.Fatal(err)
+				}
+				out = append(append(out, line...), '\n')
+			}
+			if err := os.WriteFile(path, out, 0o600); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := verifyFile(t, path, ""); err == nil || !strings.Contains(err.Error(), "line 1") {
+				t.Fatalf("expected error on line 1, got %v", err)
+			}
+		}
+		rehash(t, func(b []byte) string {
+			sum := sha256.Sum256(b)
+			return hex.EncodeToString(sum[:])
+		})
+		rehash(t, func(b []byte) string {
+			mac := hmac.New(sha256.New, []byte("not the audit key"))
+			mac.Write(b)
+			return hex.EncodeToString(mac.Sum(nil))
+		})
+	})
+}
+
+func TestFileSink_LongEvent(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := audit.NewFileSink(path, testKey, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// an event longer than is read at a time from the end of the file
+	long := "Delete(ConstRow(columns=[" + strings.Repeat("1234567,", 20000) + "]))"
+	if err := sink.Write(&audit.Event{Type: audit.EventDelete, Object: "t", Detail: long}); err != nil {
+		t.Fatal(err)
+	} else if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// reopening the file continues the chain after the long event
+	sink, err = audit.NewFileSink(path, testKey, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := audit.NewLogger(nil, sink)
+	l.Log(context.Background(), audit.EventDelete, "t", long, nil)
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := verifyFile(t, path, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// the logger truncates the detail, so the file can be read by line
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	dec := json.NewDecoder(f)
+	var events []audit.Event
+	for dec.More() {
+		var e audit.Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatal(err)
+		}
+		events = append(events, e)
+	}
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	} else if events[0].Detail != long {
+		t.Fatal("expected the first event's detail to be written in full")
+	} else if d := events[1].Detail; len(d) > 5000 || !strings.HasPrefix(d, "Delete(ConstRow(columns=[1234567,") || !strings.Contains(d, fmt.Sprintf("(%d bytes, sha256 ", len(long))) {
+		t.Fatalf("unexpected truncated detail: %s", d)
+	}
+}
+
+func TestFileSink_Rotate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	// small enough that every event rotates the file
+	sink, err := audit.NewFileSink(path, testKey, 10, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := audit.NewLogger(nil, sink)
+	for _, object := range []string{"a", "b", "c", "d"} {
+		l.Log(context.Background(), audit.EventCreateTable, object, "", nil)
+	}
+	if err := l.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	// only two backups are kept
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Fatalf("expected %s.3 not to exist, got %v", path, err)
+	}
+
+	// the chain continues across files, oldest first
+	var prevHash string
+	for i, p := range []string{path + ".2", path + ".1", path} {
+		events := readEvents(t, p)
+		if len(events) != 1 {
+			t.Fatalf("expected 1 event in %s, got %d", p, len(events))
+		}
+		if exp := string(rune('b' + i)); events[0].Object != exp {
+			t.Fatalf("expected %s to contain %s, got %s", p, exp, events[0].Object)
+		}
+		// the oldest file kept follows a file which was dropped
+		if i == 0 {
+			prevHash = events[0].PrevHash
+		}
+		if prevHash, err = verifyFile(t, p, prevHash); err != nil {
+			t.Fatalf("verifying %s: %v", p, err)
+		}
+	}
+}
+
+func TestSyslogSink(t *testing.T) {
+	t.Run("UDP", func(t *testing.T) {
+		conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+
+		sink, err := audit.NewSyslogSink("udp", conn.LocalAddr().String(), "featurebase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := audit.NewLogger(nil, sink)
+		defer l.Close()
+		l.Log(context.Background(), audit.EventAuthFailure, "", "", errors.New("invalid token"))
+
+		buf := make([]byte, 4096)
+		if err := conn.SetReadDeadline(time.Now().Add(5 * time.Second)); err != nil {
+			t.Fatal(err)
+		}
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg := string(buf[:n])
+
+		// authpriv facility (10), warning severity (4)
+		if !strings.HasPrefix(msg, "<84>1 ") {
+			t.Fatalf("unexpected header: %s", msg)
+		}
+		fields := strings.SplitN(msg, " ", 8)
+		if len(fields) != 8 || fields[3] != "featurebase" || fields[5] != string(audit.EventAuthFailure) || fields[6] != "-" {
+			t.Fatalf("unexpected message: %s", msg)
+		}
+		var e audit.Event
+		if err := json.Unmarshal([]byte(fields[7]), &e); err != nil {
+			t.Fatal(err)
+		} else if e.Type != audit.EventAuthFailure || e.Outcome != audit.OutcomeFailure || e.Error != "invalid token" {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	})
+
+	t.Run("TCP", func(t *testing.T) {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer ln.Close()
+
+		sink, err := audit.NewSyslogSink("tcp", ln.Addr().String(), "featurebase")
+		if err != nil {
+			t.Fatal(err)
+		}
+		l := audit.NewLogger(nil, sink)
+		l.Log(context.Background(), audit.EventLogin, "", "", nil)
+		l.Log(context.Background(), audit.EventLogout, "", "", nil)
+		if err := l.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		conn, err := ln.Accept()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		r := bufio.NewReader(conn)
+
+		// messages are framed with their length
+		for _, typ := range []audit.EventType{audit.EventLogin, audit.EventLogout} {
+			var n int
+			if _, err := fmt.Fscan(r, &n); err != nil {
+				t.Fatal(err)
+			}
+			msg := make([]byte, n)
+			if _, err := r.Discard(1); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := io.ReadFull(r, msg); err != nil {
+				t.Fatal(err)
+			}
+			// authpriv facility (10), informational severity (6)
+			if !strings.HasPrefix(string(msg), "<86>1 ") || !strings.Contains(string(msg), " "+string(typ)+" - {") {
+				t.Fatalf("unexpected message: %s", msg)
+			}
+		}
+	})
+
+	t.Run("UnsupportedNetwork", func(t *testing.T) {
+		if _, err := audit.NewSyslogSink("ip", "127.0.0.1", "featurebase"); err == nil {
+			t.Fatal("expected error")
+		}
+	})
+}
@@ -0,0 +1,246 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// readChunkSize is how much of an existing audit log is read at a time,
+// working back from its end, to find its last event.
+const readChunkSize = 1 << 16
+
+// Ensure type implements interface.
+var _ Sink = (*FileSink)(nil)
+
+// FileSink writes events as JSON lines to a file. Each event includes the
+// hash of the previous one, so that removing or changing an event breaks the
+// chain, which Verify detects. The hashes are HMACs keyed with the audit key,
+// so that the chain can't be recomputed after tampering without it. The file
+// is rotated once it reaches a maximum size; the chain continues across
+// rotated files.
+type FileSink struct {
+	path       string
+	key        []byte
+	maxSize    int64
+	maxBackups int
+
+	f        *os.File
+	size     int64
+	prevHash string
+}
+
+// NewFileSink opens the audit log at path, creating it if it doesn't exist,
+// which is signed with key. If maxSize is greater than zero, the file is renamed to path.1 (and any
+// existing backups to path.2 and so on) once writing an event would make it
+// larger than maxSize, keeping at most maxBackups of them.
+func NewFileSink(path string, key []byte, maxSize int64, maxBackups int) (*FileSink, error) {
+	if len(key) == 0 {
+		return nil, errors.New("audit log key required")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o750); err != nil {
+		return nil, errors.Wrap(err, "creating audit log directory")
+	}
+	s := &FileSink{
+		path:       path,
+		key:        key,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+
+	// continue the chain from the last event already in the file
+	prevHash, err := lastHash(s.f, s.size)
+	if err != nil {
+		s.f.Close()
+		return nil, errors.Wrap(err, "reading last audit event")
+	}
+	s.prevHash = prevHash
+	return s, nil
+}
+
+// Write appends e to the file, setting its hashes.
+func (s *FileSink) Write(e *Event) error {
+	e.PrevHash = s.prevHash
+	hash, err := hashEvent(s.key, e)
+	if err != nil {
+		return err
+	}
+	e.Hash = hash
+
+	line, err := json.Marshal(e)
+	if err != nil {
+		return errors.Wrap(err, "marshaling audit event")
+	}
+	line = append(line, '\n')
+
+	if s.maxSize > 0 && s.size > 0 && s.size+int64(len(line)) > s.maxSize {
+		if err := s.rotate(); err != nil {
+			return errors.Wrap(err, "rotating audit log")
+		}
+	}
+
+	n, err := s.f.Write(line)
+	s.size += int64(n)
+	if err != nil {
+		return errors.Wrap(err, "writing audit event")
+	}
+	if err := s.f.Sync(); err != nil {
+		return errors.Wrap(err, "syncing audit log")
+	}
+	s.prevHash = hash
+	return nil
+}
+
+// Close closes the file.
+func (s *FileSink) Close() error {
+	return s.f.Close()
+}
+
+func (s *FileSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "opening audit log")
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return errors.Wrap(err, "getting audit log size")
+	}
+	s.f = f
+	s.size = fi.Size()
+	return nil
+}
+
+// rotate shifts the backups along by one, dropping the oldest, moves the
+// current file to path.1 and opens a new one.
+func (s *FileSink) rotate() error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	if s.maxBackups > 0 {
+		if err := os.Remove(backupPath(s.path, s.maxBackups)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		for i := s.maxBackups - 1; i > 0; i-- {
+			if err := os.Rename(backupPath(s.path, i), backupPath(s.path, i+1)); err != nil && !os.IsNotExist(err) {
+				return err
+			}
+		}
+		if err := os.Rename(s.path, backupPath(s.path, 1)); err != nil {
+			return err
+		}
+	} else if err := os.Remove(s.path); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.%d", path, i)
+}
+
+// hashEvent returns the hex encoded HMAC-SHA256 of e with key, not including
+// its own hash.
+func hashEvent(key []byte, e *Event) (string, error) {
+	ev := *e
+	ev.Hash = ""
+	b, err := json.Marshal(&ev)
+	if err != nil {
+		return "", errors.Wrap(err, "marshaling audit event")
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(b)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// lastHash returns the hash of the last event in f, which is size bytes long,
+// or "" if it's empty.
+func lastHash(f *os.File, size int64) (string, error) {
+	if size == 0 {
+		return "", nil
+	}
+
+	// read chunks back from the end of the file until the start of the last
+	// event is found
+	var buf, line []byte
+	for off := size; ; {
+		n := int64(readChunkSize)
+		if n > off {
+			n = off
+		}
+		off -= n
+		chunk := make([]byte, n, n+int64(len(buf)))
+		if _, err := f.ReadAt(chunk, off); err != nil {
+			return "", err
+		}
+		buf = append(chunk, buf...)
+
+		line = bytes.TrimRight(buf, "\n")
+		if i := bytes.LastIndexByte(line, '\n'); i >= 0 {
+			line = line[i+1:]
+			break
+		} else if off == 0 {
+			break
+		}
+	}
+	var e Event
+	if err := json.Unmarshal(line, &e); err != nil {
+		return "", errors.Wrap(err, "unmarshaling last audit event")
+	}
+	return e.Hash, nil
+}
+
+// Verify checks the hash chain of the audit log read from r, which was signed
+// with key and must follow the event with hash prevHash. To verify the first file in a chain,
+// prevHash is "". It returns the hash of the last event, so that rotated files
+// can be verified in order, oldest first, each with the hash returned for the
+// one before it.
+func Verify(r io.Reader, key []byte, prevHash string) (string, error) {
+	if len(key) == 0 {
+		return "", errors.New("audit log key required")
+	}
+	br := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		// lines are read whole, however long they are
+		b, readErr := br.ReadBytes('\n')
+		if readErr == io.EOF && len(b) == 0 {
+			break
+		} else if readErr != nil && readErr != io.EOF {
+			return "", errors.Wrap(readErr, "reading audit log")
+		}
+
+		var e Event
+		if err := json.Unmarshal(b, &e); err != nil {
+			return "", errors.Wrapf(err, "line %d", line)
+		}
+		if e.PrevHash != prevHash {
+			return "", errors.Errorf("line %d: previous hash %q doesn't match %q", line, e.PrevHash, prevHash)
+		}
+		hash, err := hashEvent(key, &e)
+		if err != nil {
+			return "", errors.Wrapf(err, "line %d", line)
+		}
+		if !hmac.Equal([]byte(hash), []byte(e.Hash)) {
+			return "", errors.Errorf("line %d: event doesn't match its hash", line)
+		}
+		prevHash = e.Hash
+		if readErr == io.EOF {
+			break
+		}
+	}
+	return prevHash, nil
+}
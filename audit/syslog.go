@@ -0,0 +1,134 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// syslogFacilityAuthPriv is the syslog facility for security and
+	// authorization messages which should be kept private.
+	syslogFacilityAuthPriv = 10
+
+	syslogSeverityWarning = 4
+	syslogSeverityInfo    = 6
+
+	syslogDialTimeout = 5 * time.Second
+)
+
+// Ensure type implements interface.
+var _ Sink = (*SyslogSink)(nil)
+
+// SyslogSink sends events to a syslog daemon over a socket as RFC 5424
+// messages, with the event as the message's JSON body. Events which record a
+// failure are sent with warning severity, others with informational.
+type SyslogSink struct {
+	network  string
+	addr     string
+	appName  string
+	hostname string
+
+	conn net.Conn
+}
+
+// NewSyslogSink connects to the syslog daemon listening on addr. The network
+// is one of "udp", "tcp", "unix" or "unixgram". Messages are sent with
+// appName as their APP-NAME.
+func NewSyslogSink(network, addr, appName string) (*SyslogSink, error) {
+	switch network {
+	case "udp", "tcp", "unix", "unixgram":
+	default:
+		return nil, errors.Errorf("unsupported syslog network: %q", network)
+	}
+
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+	s := &SyslogSink{
+		network:  network,
+		addr:     addr,
+		appName:  appName,
+		hostname: hostname,
+	}
+	if err := s.connect(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Write sends e to the syslog daemon. If sending fails, for example because
+// the daemon was restarted, it reconnects and tries once more.
+func (s *SyslogSink) Write(e *Event) error {
+	msg, err := s.format(e)
+	if err != nil {
+		return err
+	}
+
+	if s.conn != nil {
+		if _, err = s.conn.Write(msg); err == nil {
+			return nil
+		}
+		s.conn.Close()
+		s.conn = nil
+	}
+	if err := s.connect(); err != nil {
+		return err
+	}
+	_, err = s.conn.Write(msg)
+	return errors.Wrap(err, "sending audit event to syslog")
+}
+
+// Close closes the connection to the syslog daemon.
+func (s *SyslogSink) Close() error {
+	if s.conn == nil {
+		return nil
+	}
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
+
+func (s *SyslogSink) connect() error {
+	conn, err := net.DialTimeout(s.network, s.addr, syslogDialTimeout)
+	if err != nil {
+		return errors.Wrap(err, "connecting to syslog")
+	}
+	s.conn = conn
+	return nil
+}
+
+// format returns e as an RFC 5424 message. On stream sockets, messages are
+// framed by prefixing them with their length, as described in RFC 6587.
+func (s *SyslogSink) format(e *Event) ([]byte, error) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshaling audit event")
+	}
+
+	severity := syslogSeverityInfo
+	if e.Outcome == OutcomeFailure {
+		severity = syslogSeverityWarning
+	}
+	msg := fmt.Sprintf("<%d>1 %s %s %s %d %s - %s",
+		syslogFacilityAuthPriv*8+severity,
+		e.Time.UTC().Format(time.RFC3339Nano),
+		s.hostname,
+		s.appName,
+		os.Getpid(),
+		e.Type,
+		body,
+	)
+
+	if s.network == "tcp" || s.network == "unix" {
+		return []byte(fmt.Sprintf("%d %s", len(msg), msg)), nil
+	}
+	return []byte(msg), nil
+}
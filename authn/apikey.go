@@ -0,0 +1,240 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package authn
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// APIKeyPrefix is the prefix of every API key, which distinguishes API
+	// keys from the tokens issued by the IdP.
+	APIKeyPrefix = "fbk_"
+
+	// apiKeyCacheTTL is how long a looked up API key is trusted before it's
+	// looked up again, and so how long it takes other nodes to notice that a
+	// key was revoked.
+	apiKeyCacheTTL = time.Minute
+)
+
+// ErrAPIKeyNotFound is returned by an APIKeyStore when there is no API key
+// with the given ID.
+var ErrAPIKeyNotFound = errors.New("api key not found")
+
+// APIKey is a long-lived credential issued by FeatureBase to a service
+// account. Only a hash of the key's secret is kept, so the key itself can't be
+// recovered once it has been handed out.
+type APIKey struct {
+	ID             string    `json:"id"`
+	ServiceAccount string    `json:"service-account"`
+	Groups         []string  `json:"groups"`
+	Hash           string    `json:"-"`
+	CreatedAt      time.Time `json:"created-at"`
+	// Expiry is the time after which the key is no longer accepted. The zero
+	// value means the key doesn't expire.
+	Expiry time.Time `json:"expiry"`
+}
+
+// Expired returns true if the key has an expiry which has passed.
+func (k *APIKey) Expired() bool {
+	return !k.Expiry.IsZero() && time.Now().After(k.Expiry)
+}
+
+// APIKeyStore persists API keys.
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	APIKey(ctx context.Context, id string) (*APIKey, error)
+	APIKeys(ctx context.Context) ([]*APIKey, error)
+	DeleteAPIKey(ctx context.Context, id string) error
+}
+
+// cachedAPIKey is used to hold an API key and when it was last looked up.
+type cachedAPIKey struct {
+	cacheTime time.Time
+	key       *APIKey
+}
+
+// apiKeys holds the API key store along with a cache of the keys that have
+// been looked up in it.
+type apiKeys struct {
+	mu    sync.Mutex
+	store APIKeyStore
+	cache map[string]cachedAPIKey
+}
+
+// IsAPIKey returns true if token is an API key rather than an IdP token.
+func IsAPIKey(token string) bool {
+	return strings.HasPrefix(token, APIKeyPrefix)
+}
+
+// SetAPIKeyStore sets the store in which API keys are kept. API keys are
+// rejected until a store is set. It must be called before the Auth is used.
+func (a *Auth) SetAPIKeyStore(store APIKeyStore) {
+	a.apiKeys = &apiKeys{
+		store: store,
+		cache: make(map[string]cachedAPIKey),
+	}
+}
+
+func (a *Auth) apiKeyStore() (APIKeyStore, error) {
+	if a.apiKeys == nil {
+		return nil, errors.New("api keys are not supported")
+	}
+	return a.apiKeys.store, nil
+}
+
+// CreateAPIKey issues a new API key for serviceAccount, which will be a member
+// of groups. If expiry isn't zero, the key is not accepted after it. The key
+// returned is the only copy of the key's secret, and must be given to the
+// client.
+func (a *Auth) CreateAPIKey(ctx context.Context, serviceAccount string, groups []string, expiry time.Time) (string, *APIKey, error) {
+	store, err := a.apiKeyStore()
+	if err != nil {
+		return "", nil, err
+	}
+	if serviceAccount == "" {
+		return "", nil, errors.New("service account is required")
+	}
+	if len(groups) == 0 {
+		return "", nil, errors.New("at least one group is required")
+	}
+
+	id, err := randomHex(8)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "generating api key id")
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return "", nil, errors.Wrap(err, "generating api key secret")
+	}
+
+	key := &APIKey{
+		ID:             id,
+		ServiceAccount: serviceAccount,
+		Groups:         groups,
+		Hash:           hashAPIKeySecret(secret),
+		CreatedAt:      time.Now().UTC().Truncate(time.Second),
+	}
+	if !expiry.IsZero() {
+		key.Expiry = expiry.UTC().Truncate(time.Second)
+	}
+	if err := store.CreateAPIKey(ctx, key); err != nil {
+		return "", nil, errors.Wrap(err, "storing api key")
+	}
+	return APIKeyPrefix + id + "_" + secret, key, nil
+}
+
+// APIKeys returns all of the API keys which have been issued and not revoked.
+func (a *Auth) APIKeys(ctx context.Context) ([]*APIKey, error) {
+	store, err := a.apiKeyStore()
+	if err != nil {
+		return nil, err
+	}
+	return store.APIKeys(ctx)
+}
+
+// RevokeAPIKey deletes the API key with the given ID, so that it's no longer
+// accepted.
+func (a *Auth) RevokeAPIKey(ctx context.Context, id string) error {
+	store, err := a.apiKeyStore()
+	if err != nil {
+		return err
+	}
+	if _, err := store.APIKey(ctx, id); err != nil {
+		return err
+	}
+	if err := store.DeleteAPIKey(ctx, id); err != nil {
+		return errors.Wrap(err, "deleting api key")
+	}
+
+	a.apiKeys.mu.Lock()
+	delete(a.apiKeys.cache, id)
+	a.apiKeys.mu.Unlock()
+	return nil
+}
+
+// authenticateAPIKey returns the UserInfo of the service account that token
+// was issued to.
+func (a *Auth) authenticateAPIKey(token string) (*UserInfo, error) {
+	id, secret, ok := strings.Cut(strings.TrimPrefix(token, APIKeyPrefix), "_")
+	if !ok || id == "" || secret == "" {
+		return nil, fmt.Errorf("malformed api key")
+	}
+
+	key, err := a.lookupAPIKey(id)
+	if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare([]byte(hashAPIKeySecret(secret)), []byte(key.Hash)) != 1 {
+		return nil, fmt.Errorf("invalid api key")
+	}
+	if key.Expired() {
+		return nil, fmt.Errorf("api key is expired")
+	}
+
+	groups := make([]Group, len(key.Groups))
+	for i, g := range key.Groups {
+		groups[i] = Group{GroupID: g, GroupName: g}
+	}
+	return &UserInfo{
+		UserID:   key.ServiceAccount,
+		UserName: key.ServiceAccount,
+		Groups:   groups,
+		Expiry:   key.Expiry,
+		Token:    token,
+	}, nil
+}
+
+// lookupAPIKey gets the API key with the given ID from the cache, or from the
+// store if it isn't cached or the cached key is stale.
+func (a *Auth) lookupAPIKey(id string) (*APIKey, error) {
+	store, err := a.apiKeyStore()
+	if err != nil {
+		return nil, err
+	}
+
+	a.apiKeys.mu.Lock()
+	cached, ok := a.apiKeys.cache[id]
+	a.apiKeys.mu.Unlock()
+	if ok && time.Since(cached.cacheTime) < apiKeyCacheTTL {
+		return cached.key, nil
+	}
+
+	key, err := store.APIKey(context.Background(), id)
+	if err != nil {
+		if errors.Is(err, ErrAPIKeyNotFound) {
+			return nil, fmt.Errorf("invalid api key")
+		}
+		return nil, errors.Wrap(err, "getting api key")
+	}
+
+	a.apiKeys.mu.Lock()
+	a.apiKeys.cache[id] = cachedAPIKey{cacheTime: time.Now(), key: key}
+	a.apiKeys.mu.Unlock()
+	return key, nil
+}
+
+// hashAPIKeySecret returns the hex encoded SHA-256 hash of secret. The secret
+// is random, so unlike a password it doesn't need a slow hash.
+func hashAPIKeySecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,136 @@
+package authn
+
+import (
+	"context"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// memAPIKeyStore is an in-memory APIKeyStore.
+type memAPIKeyStore map[string]*APIKey
+
+func (s memAPIKeyStore) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	s[key.ID] = key
+	return nil
+}
+
+func (s memAPIKeyStore) APIKey(ctx context.Context, id string) (*APIKey, error) {
+	key, ok := s[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+func (s memAPIKeyStore) APIKeys(ctx context.Context) ([]*APIKey, error) {
+	keys := make([]*APIKey, 0, len(s))
+	for _, key := range s {
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s memAPIKeyStore) DeleteAPIKey(ctx context.Context, id string) error {
+	delete(s, id)
+	return nil
+}
+
+func TestAuth_APIKeys(t *testing.T) {
+	ctx := context.Background()
+	a := NewTestAuth(t)
+
+	t.Run("NoStore", func(t *testing.T) {
+		if _, _, err := a.CreateAPIKey(ctx, "ingester", []string{"writers"}, time.Time{}); err == nil {
+			t.Fatal("expected error creating api key without a store")
+		}
+		if _, err := a.Authenticate(APIKeyPrefix+"abc_def", ""); err == nil {
+			t.Fatal("expected error authenticating api key without a store")
+		}
+	})
+
+	store := memAPIKeyStore{}
+	a.SetAPIKeyStore(store)
+
+	key, apiKey, err := a.CreateAPIKey(ctx, "ingester", []string{"writers", "readers"}, time.Time{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !IsAPIKey(key) {
+		t.Fatalf("expected api key to have prefix %q, got %q", APIKeyPrefix, key)
+	}
+	if strings.Contains(apiKey.Hash, strings.Split(key, "_")[2]) {
+		t.Fatal("expected secret not to be stored")
+	}
+
+	t.Run("Authenticate", func(t *testing.T) {
+		uinfo, err := a.Authenticate(key, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uinfo.UserID != "ingester" || uinfo.UserName != "ingester" {
+			t.Fatalf("unexpected user: %+v", uinfo)
+		}
+		exp := []Group{{GroupID: "writers", GroupName: "writers"}, {GroupID: "readers", GroupName: "readers"}}
+		if !reflect.DeepEqual(uinfo.Groups, exp) {
+			t.Fatalf("expected groups %v, got %v", exp, uinfo.Groups)
+		}
+	})
+
+	t.Run("NoCookie", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		if err := a.SetCookie(w, key, "", time.Time{}); err != nil {
+			t.Fatal(err)
+		}
+		if cookies := w.Result().Cookies(); len(cookies) != 0 {
+			t.Fatalf("expected no cookies, got %v", cookies)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, tkn := range []string{
+			APIKeyPrefix + "nosecret",
+			APIKeyPrefix + apiKey.ID + "_wrong",
+			APIKeyPrefix + "unknown_" + strings.Split(key, "_")[2],
+		} {
+			if _, err := a.Authenticate(tkn, ""); err == nil {
+				t.Fatalf("expected error authenticating %q", tkn)
+			}
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		key, apiKey, err := a.CreateAPIKey(ctx, "cron", []string{"readers"}, time.Now().Add(time.Hour))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := a.Authenticate(key, ""); err != nil {
+			t.Fatal(err)
+		}
+		apiKey.Expiry = time.Now().Add(-time.Minute)
+		if _, err := a.Authenticate(key, ""); err == nil || !strings.Contains(err.Error(), "expired") {
+			t.Fatalf("expected expired error, got %v", err)
+		}
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		keys, err := a.APIKeys(ctx)
+		if err != nil {
+			t.Fatal(err)
+		} else if len(keys) != 2 {
+			t.Fatalf("expected 2 keys, got %d", len(keys))
+		}
+
+		if err := a.RevokeAPIKey(ctx, apiKey.ID); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := a.Authenticate(key, ""); err == nil {
+			t.Fatal("expected error authenticating revoked api key")
+		}
+		if err := a.RevokeAPIKey(ctx, apiKey.ID); err != ErrAPIKeyNotFound {
+			t.Fatalf("expected %v, got %v", ErrAPIKeyNotFound, err)
+		}
+	})
+}
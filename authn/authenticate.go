@@ -87,6 +87,8 @@ type Auth struct {
 	groupsCache       map[string]cachedGroups // groupsCache is a map of accessToken -> group memberships
 	lastCacheClean    time.Time               // last cache clean is the time that the cache was last cleaned
 	allowedNetworks   []net.IPNet             // list of allowed networks for ingest
+	apiKeys           *apiKeys                // apiKeys is nil unless an APIKeyStore has been set
+	adminGroup        string                  // adminGroup is the group the system token is a member of
 }
 
 // NewAuth instantiates and returns a new Auth struct
@@ -181,6 +183,15 @@ func (a *Auth) Authenticate(access, refresh string) (*UserInfo, error) {
 		return nil, fmt.Errorf("auth token is empty")
 	}
 
+	// API keys and the system token are issued by us rather than the IdP,
+	// and can't be refreshed. The system token is only accepted by
+	// AuthenticateInternal.
+	if IsAPIKey(access) {
+		return a.authenticateAPIKey(access)
+	} else if strings.HasPrefix(access, systemTokenPrefix) {
+		return nil, fmt.Errorf("system token not accepted")
+	}
+
 	// NOTE: we are using ParseUnverified here because the IDP validates the
 	// token's signature when we get the user's groups, we just need to make
 	// sure it's not expired and is well-formed
@@ -254,11 +265,14 @@ func (a *Auth) Login(w http.ResponseWriter, r *http.Request) {
 }
 
 // Logout clears out the user's cookie, removes the token from our cache, and
-// redirects user to IdP's logout endpoint
-func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+// redirects user to IdP's logout endpoint. It returns the user who was logged
+// out, or nil if the request didn't have a token.
+func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) *UserInfo {
+	var userInfo *UserInfo
 	// remove the access token from a.groupsCache
 	if access, err := r.Cookie(a.accessCookieName); err == nil {
 		delete(a.groupsCache, access.Value)
+		userInfo = claimsUserInfo(access.Value)
 	}
 	// clear cookie
 	http.SetCookie(w, &http.Cookie{
@@ -281,20 +295,43 @@ func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) {
 	})
 
 	http.Redirect(w, r, fmt.Sprintf("%s?post_logout_redirect_uri=%s/", a.logoutEndpoint, a.fbURL), http.StatusTemporaryRedirect)
+	return userInfo
 }
 
 // Redirect handles the oAuth /redirect endpoint. It gets an access token and
-// returns it to the user in the form of a cookie
-func (a *Auth) Redirect(w http.ResponseWriter, r *http.Request) {
+// returns it to the user in the form of a cookie. It returns the user who
+// logged in, or an error if the IdP didn't issue a token.
+func (a *Auth) Redirect(w http.ResponseWriter, r *http.Request) (*UserInfo, error) {
 	token, err := a.oAuthConfig.Exchange(r.Context(), r.FormValue("code"), oauth2.AccessTypeOffline)
 	if err != nil {
 		a.logger.Warnf("getting token from IdP: %+v", err)
 		http.Error(w, "Bad Request", http.StatusBadRequest)
-		return
+		return nil, errors.Wrap(err, "getting token from IdP")
 	}
 
 	a.SetCookie(w, token.AccessToken, token.RefreshToken, token.Expiry)
 	http.Redirect(w, r, "/", http.StatusTemporaryRedirect)
+	return claimsUserInfo(token.AccessToken), nil
+}
+
+// claimsUserInfo returns a UserInfo with just the user's ID and name, taken
+// from the claims in access without checking it or getting the user's groups.
+// It's for identifying the user when the token isn't being used to authorize
+// anything, such as when logging in or out.
+func claimsUserInfo(access string) *UserInfo {
+	userInfo := &UserInfo{Token: access}
+	token, _, err := new(jwt.Parser).ParseUnverified(access, &jwt.MapClaims{})
+	if token == nil || token.Claims == nil || err != nil {
+		return userInfo
+	}
+	claims := *token.Claims.(*jwt.MapClaims)
+	if uid, ok := claims["oid"].(string); ok {
+		userInfo.UserID = uid
+	}
+	if name, ok := claims["name"].(string); ok {
+		userInfo.UserName = name
+	}
+	return userInfo
 }
 
 // getGroups gets the group membership for a given token from configured IdP
@@ -344,6 +381,11 @@ func (a *Auth) getGroups(token string) ([]Group, error) {
 }
 
 func (a *Auth) SetCookie(w http.ResponseWriter, access, refresh string, expiry time.Time) error {
+	// API keys are meant for clients that hold on to them already, so
+	// there's no reason to hand them back in a cookie
+	if isIssued(access) {
+		return nil
+	}
 	http.SetCookie(w, &http.Cookie{
 		Name:     a.refreshCookieName,
 		Value:    refresh,
@@ -367,6 +409,9 @@ func (a *Auth) SetCookie(w http.ResponseWriter, access, refresh string, expiry t
 }
 
 func (a *Auth) SetGRPCMetadata(ctx context.Context, md metadata.MD, access, refresh string) (context.Context, error) {
+	if isIssued(access) {
+		return ctx, nil
+	}
 	mCookies := map[string]string{}
 	if c, ok := md["cookie"]; ok {
 		for _, cookie := range c {
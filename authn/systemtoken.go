@@ -0,0 +1,110 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package authn
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// systemTokenPrefix is the prefix of the system token, which
+	// distinguishes it from API keys and the tokens issued by the IdP.
+	systemTokenPrefix = "fbs_"
+
+	// SystemUserID is the user ID of requests authenticated with the system
+	// token.
+	SystemUserID = "featurebase"
+
+	// systemTokenTTL is how long a system token is valid for after it's
+	// issued. System tokens are issued for each system query, so this only
+	// needs to cover the time it takes to run one.
+	systemTokenTTL = 5 * time.Minute
+
+	// systemTokenSkew is how far in the future a system token's issue time
+	// may be, to allow for the clocks of the nodes differing.
+	systemTokenSkew = time.Minute
+)
+
+// SetAdminGroup sets the group which requests authenticated with the system
+// token are a member of. Until it's set, the system token has no groups.
+func (a *Auth) SetAdminGroup(group string) {
+	a.adminGroup = group
+}
+
+// SystemToken returns a token nodes use to authenticate the requests they
+// make to each other on behalf of the system rather than a user, such as
+// reading the API keys. It is signed with the secret key, which every node
+// in the cluster shares, and expires shortly after it's issued. It's only
+// accepted by AuthenticateInternal.
+func (a *Auth) SystemToken() string {
+	return a.systemToken(time.Now())
+}
+
+// systemToken returns the system token issued at t.
+func (a *Auth) systemToken(t time.Time) string {
+	issued := strconv.FormatInt(t.Unix(), 10)
+	return systemTokenPrefix + issued + "_" + a.signSystemToken(issued)
+}
+
+// signSystemToken returns the signature of a system token issued at issued.
+func (a *Auth) signSystemToken(issued string) string {
+	mac := hmac.New(sha256.New, a.secretKey)
+	mac.Write([]byte(SystemUserID + ":" + issued))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// AuthenticateInternal is like Authenticate, but also accepts the system
+// token. It should only be used for the requests nodes make to each other.
+func (a *Auth) AuthenticateInternal(access, refresh string) (*UserInfo, error) {
+	if IsSystemToken(access) {
+		return a.authenticateSystemToken(access)
+	}
+	return a.Authenticate(access, refresh)
+}
+
+// authenticateSystemToken returns the UserInfo of the system if token is a
+// system token which hasn't expired.
+func (a *Auth) authenticateSystemToken(token string) (*UserInfo, error) {
+	issued, sig, ok := strings.Cut(strings.TrimPrefix(token, systemTokenPrefix), "_")
+	if !ok || !hmac.Equal([]byte(sig), []byte(a.signSystemToken(issued))) {
+		return nil, fmt.Errorf("invalid system token")
+	}
+	unix, err := strconv.ParseInt(issued, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid system token")
+	}
+	issuedAt := time.Unix(unix, 0)
+	if age := time.Since(issuedAt); age > systemTokenTTL || age < -systemTokenSkew {
+		return nil, fmt.Errorf("system token has expired")
+	}
+
+	userInfo := &UserInfo{
+		UserID:   SystemUserID,
+		UserName: SystemUserID,
+		Groups:   []Group{},
+		Expiry:   issuedAt.Add(systemTokenTTL),
+		Token:    token,
+	}
+	if a.adminGroup != "" {
+		userInfo.Groups = append(userInfo.Groups, Group{GroupID: a.adminGroup, GroupName: a.adminGroup})
+	}
+	return userInfo, nil
+}
+
+// IsSystemToken returns true if token has the form of a system token. It
+// doesn't check that the token is valid.
+func IsSystemToken(token string) bool {
+	return strings.HasPrefix(token, systemTokenPrefix)
+}
+
+// isIssued returns true if token was issued by FeatureBase rather than the
+// IdP.
+func isIssued(token string) bool {
+	return IsAPIKey(token) || IsSystemToken(token)
+}
@@ -0,0 +1,61 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package authn
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAuth_SystemToken(t *testing.T) {
+	a := NewTestAuth(t)
+	a.SetAdminGroup("admins")
+
+	t.Run("Internal", func(t *testing.T) {
+		uinfo, err := a.AuthenticateInternal(a.SystemToken(), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if uinfo.UserID != SystemUserID {
+			t.Fatalf("expected user %s, got %s", SystemUserID, uinfo.UserID)
+		}
+		if len(uinfo.Groups) != 1 || uinfo.Groups[0].GroupID != "admins" {
+			t.Fatalf("unexpected groups: %v", uinfo.Groups)
+		}
+	})
+
+	t.Run("NotInternal", func(t *testing.T) {
+		if _, err := a.Authenticate(a.SystemToken(), ""); err == nil {
+			t.Fatal("expected error authenticating system token outside of internal requests")
+		}
+	})
+
+	t.Run("Expired", func(t *testing.T) {
+		token := a.systemToken(time.Now().Add(-systemTokenTTL - time.Second))
+		if _, err := a.AuthenticateInternal(token, ""); err == nil || !strings.Contains(err.Error(), "expired") {
+			t.Fatalf("expected expired error, got %v", err)
+		}
+		token = a.systemToken(time.Now().Add(systemTokenSkew + time.Minute))
+		if _, err := a.AuthenticateInternal(token, ""); err == nil || !strings.Contains(err.Error(), "expired") {
+			t.Fatalf("expected expired error for token issued in the future, got %v", err)
+		}
+	})
+
+	t.Run("Forged", func(t *testing.T) {
+		// Moving the issue time of a token forward invalidates its signature.
+		token := a.systemToken(time.Now().Add(-time.Hour))
+		_, sig, _ := strings.Cut(strings.TrimPrefix(token, systemTokenPrefix), "_")
+		forged := systemTokenPrefix + strconv.FormatInt(time.Now().Unix(), 10) + "_" + sig
+		if _, err := a.AuthenticateInternal(forged, ""); err == nil || !strings.Contains(err.Error(), "invalid") {
+			t.Fatalf("expected invalid error, got %v", err)
+		}
+
+		for _, token := range []string{systemTokenPrefix, systemTokenPrefix + "abc", systemTokenPrefix + "abc_def"} {
+			if _, err := a.AuthenticateInternal(token, ""); err == nil {
+				t.Fatalf("expected error authenticating %q", token)
+			}
+		}
+	})
+}
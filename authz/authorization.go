@@ -6,6 +6,7 @@ package authz
 import (
 	"fmt"
 	"io"
+	"sync/atomic"
 
 	"github.com/featurebasedb/featurebase/v3/authn"
 
@@ -14,7 +15,17 @@ import (
 
 type GroupPermissions struct {
 	Permissions map[string]map[string]Permission `yaml:"user-groups"`
-	Admin       string                           `yaml:"admin"`
+
+	// FieldPermissions and RowFilters further restrict, by group and then
+	// by index, the access to an index granted by Permissions. See
+	// Restrictions.
+	FieldPermissions map[string]map[string]FieldPermission `yaml:"field-permissions,omitempty"`
+	RowFilters       map[string]map[string]string          `yaml:"row-filters,omitempty"`
+
+	Admin string `yaml:"admin"`
+
+	// grants holds the Grants set with SetGrants.
+	grants atomic.Value
 }
 
 type Permission string
@@ -53,6 +64,9 @@ func (p *GroupPermissions) ReadPermissionsFile(permsFile io.Reader) (err error)
 		return fmt.Errorf("unmarshalling permissions failed with error: %s", err)
 	}
 
+	if err := p.validateRestrictions(); err != nil {
+		return fmt.Errorf("validating permissions failed with error: %s", err)
+	}
 	return
 }
 
@@ -73,8 +87,8 @@ func (p *GroupPermissions) GetPermissions(user *authn.UserInfo, index string) (p
 
 	var groupsDenied []string
 	for _, group := range groups {
-		if _, ok := p.Permissions[group.GroupID]; ok {
-			if perm, ok := p.Permissions[group.GroupID][index]; ok {
+		if perms, ok := p.groupPermissions(group.GroupID); ok {
+			if perm, ok := perms[index]; ok {
 				allPermissions[perm] = true
 			} else {
 				return None, fmt.Errorf("user %s does not have permission to index %s", user.UserID, index)
@@ -109,17 +123,23 @@ func (p *GroupPermissions) IsAdmin(groups []authn.Group) bool {
 func (p *GroupPermissions) GetAuthorizedIndexList(groups []authn.Group, desiredPermission Permission) (indexList []string) {
 	// if user is admin, find all indexes in permissions file and return them
 	if p.IsAdmin(groups) {
-		for groupId := range p.Permissions {
-			for index := range p.Permissions[groupId] {
-				indexList = append(indexList, index)
+		seen := make(map[string]bool)
+		for _, groups := range []map[string]map[string]Permission{p.Permissions, p.Grants()} {
+			for groupId := range groups {
+				for index := range groups[groupId] {
+					if !seen[index] {
+						seen[index] = true
+						indexList = append(indexList, index)
+					}
+				}
 			}
 		}
 		return indexList
 	}
 
 	for _, group := range groups {
-		if _, ok := p.Permissions[group.GroupID]; ok {
-			for index, permission := range p.Permissions[group.GroupID] {
+		if perms, ok := p.groupPermissions(group.GroupID); ok {
+			for index, permission := range perms {
 				if permission.Satisfies(desiredPermission) {
 					indexList = append(indexList, index)
 				}
@@ -0,0 +1,47 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+package authz
+
+// Grants are the permissions granted to roles through SQL, by role and then by
+// index. They're stored in the cluster's schema rather than the permissions
+// file, which remains the source of the permissions needed to bootstrap a
+// cluster, such as admin. A role applies to the users in the group with the
+// same ID, and where both the file and the grants give a group permission to
+// an index, the greater of the two applies.
+type Grants map[string]map[string]Permission
+
+// SetGrants replaces the grants combined with the permissions from the file.
+// It's safe to call while permissions are being checked.
+func (p *GroupPermissions) SetGrants(g Grants) {
+	p.grants.Store(g)
+}
+
+// Grants returns the grants last set with SetGrants.
+func (p *GroupPermissions) Grants() Grants {
+	g, _ := p.grants.Load().(Grants)
+	return g
+}
+
+// groupPermissions returns the permissions of group to each index, combining
+// the permissions from the file with the grants, and whether group has any.
+func (p *GroupPermissions) groupPermissions(group string) (map[string]Permission, bool) {
+	perms, ok := p.Permissions[group]
+	granted, gok := p.Grants()[group]
+	if !gok {
+		return perms, ok
+	} else if !ok {
+		return granted, true
+	}
+
+	merged := make(map[string]Permission, len(perms)+len(granted))
+	for index, perm := range perms {
+		merged[index] = perm
+	}
+	for index, perm := range granted {
+		if !merged[index].Satisfies(perm) {
+			merged[index] = perm
+		}
+	}
+	return merged, true
+}
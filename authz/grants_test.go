@@ -0,0 +1,70 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package authz_test
+
+import (
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/featurebasedb/featurebase/v3/authz"
+)
+
+func TestAuth_Grants(t *testing.T) {
+	var p authz.GroupPermissions
+	if err := p.ReadPermissionsFile(strings.NewReader(`user-groups:
+  "analysts":
+    "orders": "read"
+    "customers": "write"
+admin: "admins"`)); err != nil {
+		t.Fatal(err)
+	}
+	analyst := &authn.UserInfo{UserID: "a", Groups: []authn.Group{{GroupID: "analysts"}}}
+	auditor := &authn.UserInfo{UserID: "b", Groups: []authn.Group{{GroupID: "auditors"}}}
+
+	if _, err := p.GetPermissions(auditor, "orders"); err == nil {
+		t.Fatal("expected no permission for auditor before grants")
+	}
+
+	p.SetGrants(authz.Grants{
+		"analysts": {"orders": authz.Write, "customers": authz.Read, "events": authz.Read},
+		"auditors": {"orders": authz.Read},
+	})
+
+	tests := []struct {
+		user  *authn.UserInfo
+		index string
+		exp   authz.Permission
+	}{
+		// The greater of the file's and the granted permission applies.
+		{analyst, "orders", authz.Write},
+		{analyst, "customers", authz.Write},
+		{analyst, "events", authz.Read},
+		{auditor, "orders", authz.Read},
+	}
+	for i, test := range tests {
+		if perm, err := p.GetPermissions(test.user, test.index); err != nil {
+			t.Errorf("test %d: %v", i, err)
+		} else if perm != test.exp {
+			t.Errorf("test %d: expected %s, got %s", i, test.exp, perm)
+		}
+	}
+
+	indexes := p.GetAuthorizedIndexList(analyst.Groups, authz.Write)
+	sort.Strings(indexes)
+	if exp := []string{"customers", "orders"}; !reflect.DeepEqual(indexes, exp) {
+		t.Fatalf("expected %v, got %v", exp, indexes)
+	}
+	indexes = p.GetAuthorizedIndexList([]authn.Group{{GroupID: "admins"}}, authz.Admin)
+	sort.Strings(indexes)
+	if exp := []string{"customers", "events", "orders"}; !reflect.DeepEqual(indexes, exp) {
+		t.Fatalf("expected %v, got %v", exp, indexes)
+	}
+
+	p.SetGrants(nil)
+	if _, err := p.GetPermissions(auditor, "orders"); err == nil {
+		t.Fatal("expected no permission for auditor after grants are cleared")
+	}
+}
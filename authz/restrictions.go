@@ -0,0 +1,196 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+package authz
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/featurebasedb/featurebase/v3/pql"
+)
+
+// FieldPermission limits the fields of an index which a group may access. If
+// Allow is set, only the fields it lists may be accessed, and the fields
+// listed in Deny may never be accessed. For example:
+//
+//	field-permissions:
+//	  "marketing-group-id":
+//	    "customers":
+//	      deny: ["ssn", "email"]
+type FieldPermission struct {
+	Allow []string `yaml:"allow,omitempty"`
+	Deny  []string `yaml:"deny,omitempty"`
+}
+
+// allows returns whether f allows access to field.
+func (f FieldPermission) allows(field string) bool {
+	for _, deny := range f.Deny {
+		if deny == field {
+			return false
+		}
+	}
+	if f.Allow == nil {
+		return true
+	}
+	for _, allow := range f.Allow {
+		if allow == field {
+			return true
+		}
+	}
+	return false
+}
+
+// Restrictions limit the fields and records of an index a user may access,
+// beyond the Permission they have for it. They're the combination of the
+// field permissions and row filters of each of the user's groups which has
+// any: a field may only be accessed if every one of those groups allows it,
+// and a record only if it's selected by every one of their row filters, in
+// the same way that every group a user belongs to must have permission to
+// an index for them to access it.
+//
+// Row filters are PQL bitmap calls, such as `Row(region="us")`. For example:
+//
+//	row-filters:
+//	  "marketing-group-id":
+//	    "customers": 'Row(region="us")'
+//
+// A nil *Restrictions allows access to everything.
+type Restrictions struct {
+	fields []FieldPermission
+
+	// RowFilter selects the records of the index which may be accessed,
+	// or is nil if they all may be.
+	RowFilter *pql.Call
+}
+
+// FieldAllowed returns whether the field may be accessed.
+func (r *Restrictions) FieldAllowed(field string) bool {
+	if r == nil {
+		return true
+	}
+	for _, f := range r.fields {
+		if !f.allows(field) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRestrictions returns the restrictions on the access of a user in groups
+// to index, or nil if there are none.
+func (p *GroupPermissions) GetRestrictions(groups []authn.Group, index string) (*Restrictions, error) {
+	if p.IsAdmin(groups) {
+		return nil, nil
+	}
+
+	var r Restrictions
+	var filters []*pql.Call
+	for _, group := range groups {
+		if fp, ok := p.FieldPermissions[group.GroupID][index]; ok {
+			r.fields = append(r.fields, fp)
+		}
+		if filter, ok := p.RowFilters[group.GroupID][index]; ok {
+			call, err := parseRowFilter(filter)
+			if err != nil {
+				return nil, fmt.Errorf("row filter for group %s on index %s: %s", group.GroupID, index, err)
+			}
+			filters = append(filters, call)
+		}
+	}
+
+	switch len(filters) {
+	case 0:
+		if len(r.fields) == 0 {
+			return nil, nil
+		}
+	case 1:
+		r.RowFilter = filters[0]
+	default:
+		r.RowFilter = &pql.Call{Name: "Intersect", Children: filters}
+	}
+	return &r, nil
+}
+
+// validateRestrictions ensures that field permissions and row filters are
+// only given for indexes their groups have permission to, and that row
+// filters are valid.
+func (p *GroupPermissions) validateRestrictions() error {
+	for group, indexes := range p.FieldPermissions {
+		for index := range indexes {
+			if _, ok := p.Permissions[group][index]; !ok {
+				return fmt.Errorf("group %s has field permissions for index %s, but no permission to it", group, index)
+			}
+		}
+	}
+	for group, indexes := range p.RowFilters {
+		for index, filter := range indexes {
+			if _, ok := p.Permissions[group][index]; !ok {
+				return fmt.Errorf("group %s has a row filter for index %s, but no permission to it", group, index)
+			}
+			if _, err := parseRowFilter(filter); err != nil {
+				return fmt.Errorf("row filter for group %s on index %s: %s", group, index, err)
+			}
+		}
+	}
+	return nil
+}
+
+func parseRowFilter(filter string) (*pql.Call, error) {
+	q, err := pql.ParseString(filter)
+	if err != nil {
+		return nil, err
+	} else if len(q.Calls) != 1 {
+		return nil, fmt.Errorf("must be a single call, got %d", len(q.Calls))
+	} else if q.WriteCallN() > 0 {
+		return nil, fmt.Errorf("must not write")
+	}
+	return q.Calls[0], nil
+}
+
+// Access is what a user may access through the groups they belong to. It's
+// carried in the context of a non-admin user's requests, so that it can be
+// enforced where their queries are executed.
+type Access struct {
+	perms *GroupPermissions
+	user  *authn.UserInfo
+}
+
+// NewAccess returns the access of user.
+func (p *GroupPermissions) NewAccess(user *authn.UserInfo) *Access {
+	return &Access{perms: p, user: user}
+}
+
+// IsAdmin returns whether the user is an admin.
+func (a *Access) IsAdmin() bool {
+	return a.perms.IsAdmin(a.user.Groups)
+}
+
+// Satisfies returns whether the user has at least perm for index.
+func (a *Access) Satisfies(index string, perm Permission) bool {
+	p, err := a.perms.GetPermissions(a.user, index)
+	return err == nil && p.Satisfies(perm)
+}
+
+// Restrictions returns the restrictions on the user's access to index, or
+// nil if there are none.
+func (a *Access) Restrictions(index string) (*Restrictions, error) {
+	return a.perms.GetRestrictions(a.user.Groups, index)
+}
+
+type contextKeyAccess struct{}
+
+// WithAccess returns a context carrying a. If a is nil, the returned context
+// doesn't carry any access, even if ctx does, which is useful for work done
+// on behalf of the system rather than the user.
+func WithAccess(ctx context.Context, a *Access) context.Context {
+	return context.WithValue(ctx, contextKeyAccess{}, a)
+}
+
+// AccessFromContext returns the access carried by ctx, or nil if there is
+// none, in which case access isn't restricted.
+func AccessFromContext(ctx context.Context) *Access {
+	a, _ := ctx.Value(contextKeyAccess{}).(*Access)
+	return a
+}
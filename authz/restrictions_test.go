@@ -0,0 +1,174 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package authz_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/featurebasedb/featurebase/v3/authz"
+)
+
+const restrictionsInput = `user-groups:
+  "marketing":
+    "customers": "read"
+  "us":
+    "customers": "read"
+    "orders": "write"
+field-permissions:
+  "marketing":
+    "customers":
+      deny: ["ssn", "email"]
+  "us":
+    "customers":
+      allow: ["name", "region", "email"]
+row-filters:
+  "marketing":
+    "customers": 'Row(opted_in=true)'
+  "us":
+    "customers": 'Row(region="us")'
+admin: "admins"`
+
+func TestAuth_GetRestrictions(t *testing.T) {
+	var p authz.GroupPermissions
+	if err := p.ReadPermissionsFile(strings.NewReader(restrictionsInput)); err != nil {
+		t.Fatal(err)
+	}
+
+	marketing := []authn.Group{{GroupID: "marketing"}}
+	us := []authn.Group{{GroupID: "us"}}
+	both := []authn.Group{{GroupID: "marketing"}, {GroupID: "us"}}
+
+	t.Run("Fields", func(t *testing.T) {
+		tests := []struct {
+			groups  []authn.Group
+			field   string
+			allowed bool
+		}{
+			{marketing, "name", true},
+			{marketing, "ssn", false},
+			{marketing, "email", false},
+			{us, "email", true},
+			{us, "ssn", false},
+			{us, "age", false},
+			{both, "name", true},
+			{both, "email", false},
+			{both, "age", false},
+		}
+		for i, test := range tests {
+			r, err := p.GetRestrictions(test.groups, "customers")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := r.FieldAllowed(test.field); got != test.allowed {
+				t.Errorf("test %d: expected %s allowed to be %v", i, test.field, test.allowed)
+			}
+		}
+	})
+
+	t.Run("RowFilter", func(t *testing.T) {
+		r, err := p.GetRestrictions(us, "customers")
+		if err != nil {
+			t.Fatal(err)
+		} else if got := r.RowFilter.String(); got != `Row(region="us")` {
+			t.Fatalf("unexpected row filter %s", got)
+		}
+
+		r, err = p.GetRestrictions(both, "customers")
+		if err != nil {
+			t.Fatal(err)
+		} else if got := r.RowFilter.String(); got != `Intersect(Row(opted_in=true), Row(region="us"))` {
+			t.Fatalf("unexpected row filter %s", got)
+		}
+	})
+
+	t.Run("None", func(t *testing.T) {
+		if r, err := p.GetRestrictions(us, "orders"); err != nil || r != nil {
+			t.Fatalf("expected no restrictions on orders, got %v, %v", r, err)
+		}
+		if r, err := p.GetRestrictions([]authn.Group{{GroupID: "admins"}}, "customers"); err != nil || r != nil {
+			t.Fatalf("expected no restrictions for admin, got %v, %v", r, err)
+		} else if !r.FieldAllowed("ssn") {
+			t.Fatal("expected nil restrictions to allow every field")
+		}
+	})
+}
+
+func TestAuth_ValidateRestrictions(t *testing.T) {
+	tests := map[string]string{
+		"NoPermission": `user-groups:
+  "g":
+    "a": "read"
+field-permissions:
+  "g":
+    "b":
+      deny: ["x"]
+admin: "admins"`,
+		"InvalidFilter": `user-groups:
+  "g":
+    "a": "read"
+row-filters:
+  "g":
+    "a": 'Row(x='
+admin: "admins"`,
+		"WriteFilter": `user-groups:
+  "g":
+    "a": "read"
+row-filters:
+  "g":
+    "a": 'Set(1, x=1)'
+admin: "admins"`,
+		"MultipleCalls": `user-groups:
+  "g":
+    "a": "read"
+row-filters:
+  "g":
+    "a": 'Row(x=1) Row(y=1)'
+admin: "admins"`,
+	}
+	for name, input := range tests {
+		t.Run(name, func(t *testing.T) {
+			var p authz.GroupPermissions
+			if err := p.ReadPermissionsFile(strings.NewReader(input)); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestAuth_Access(t *testing.T) {
+	var p authz.GroupPermissions
+	if err := p.ReadPermissionsFile(strings.NewReader(restrictionsInput)); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	if authz.AccessFromContext(ctx) != nil {
+		t.Fatal("expected no access in empty context")
+	}
+
+	user := &authn.UserInfo{UserID: "u", Groups: []authn.Group{{GroupID: "us"}}}
+	ctx = authz.WithAccess(ctx, p.NewAccess(user))
+	access := authz.AccessFromContext(ctx)
+	if access == nil {
+		t.Fatal("expected access in context")
+	}
+	if access.IsAdmin() {
+		t.Fatal("expected user not to be admin")
+	} else if !access.Satisfies("orders", authz.Write) {
+		t.Fatal("expected write access to orders")
+	} else if access.Satisfies("customers", authz.Write) {
+		t.Fatal("expected no write access to customers")
+	} else if access.Satisfies("other", authz.Read) {
+		t.Fatal("expected no access to other")
+	}
+	if r, err := access.Restrictions("customers"); err != nil || r == nil || r.FieldAllowed("ssn") {
+		t.Fatalf("expected restrictions on customers, got %v, %v", r, err)
+	}
+
+	if authz.AccessFromContext(authz.WithAccess(ctx, nil)) != nil {
+		t.Fatal("expected access to be cleared")
+	}
+}
@@ -185,6 +185,9 @@ type Batch struct {
 	clearFrags     fragments
 
 	useShardTransactionalEndpoint bool
+
+	// ctx is the context of the requests the batch makes to the importer.
+	ctx context.Context
 }
 
 func (b *Batch) Len() int { return len(b.ids) }
@@ -236,6 +239,16 @@ func OptUseShardTransactionalEndpoint(use bool) BatchOption {
 	}
 }
 
+// OptContext sets the context of the requests the batch makes to the
+// importer, so that they're made on behalf of the same user as the request
+// which is importing the batch.
+func OptContext(ctx context.Context) BatchOption {
+	return func(b *Batch) error {
+		b.ctx = ctx
+		return nil
+	}
+}
+
 func OptImporter(i featurebase.Importer) BatchOption {
 	return func(b *Batch) error {
 		b.importer = i
@@ -299,6 +312,7 @@ func NewBatch(importer featurebase.Importer, size int, tbl *dax.Table, fields []
 	}
 
 	b := &Batch{
+		ctx:                   context.Background(),
 		importer:              importer,
 		header:                fields,
 		headerMap:             headerMap,
@@ -751,7 +765,7 @@ var ErrBatchNowStale = errors.New("batch is stale and needs to be imported (howe
 // continues.  split batch mode DOES NOT CURRENTLY SUPPORT MUTEX
 // OR INT FIELDS!
 func (b *Batch) Import() error {
-	ctx := context.Background()
+	ctx := b.ctx
 	start := time.Now()
 	if !b.useShardTransactionalEndpoint {
 		trns, err := b.importer.StartTransaction(ctx, "", b.prevDuration*10, false, time.Hour)
@@ -824,7 +838,7 @@ func (b *Batch) Import() error {
 // imports the stored data to Pilosa. Otherwise it simply returns
 // nil.
 func (b *Batch) Flush() error {
-	ctx := context.Background()
+	ctx := b.ctx
 
 	if !b.splitBatchMode {
 		return nil
@@ -1082,7 +1096,7 @@ func (b *Batch) doTranslation() error {
 }
 
 func (b *Batch) createIndexKeys(keys ...string) (map[string]uint64, error) {
-	ctx := context.Background()
+	ctx := b.ctx
 
 	batchSize := b.keyTranslateBatchSize
 	if batchSize <= 0 || len(keys) <= batchSize {
@@ -1113,7 +1127,7 @@ func (b *Batch) createIndexKeys(keys ...string) (map[string]uint64, error) {
 }
 
 func (b *Batch) createFieldKeys(field *featurebase.FieldInfo, keys ...string) (map[string]uint64, error) {
-	ctx := context.Background()
+	ctx := b.ctx
 
 	batchSize := b.keyTranslateBatchSize
 	if batchSize <= 0 || len(keys) <= batchSize {
@@ -1144,7 +1158,7 @@ func (b *Batch) createFieldKeys(field *featurebase.FieldInfo, keys ...string) (m
 }
 
 func (b *Batch) doImportShardTransactional(frags, clearFrags fragments) error {
-	ctx := context.Background()
+	ctx := b.ctx
 
 	start := time.Now()
 	requests := make(map[uint64]*featurebase.ImportRoaringShardRequest)
@@ -1218,7 +1232,7 @@ func (b *Batch) doImportShardTransactional(frags, clearFrags fragments) error {
 }
 
 func (b *Batch) doImport(frags, clearFrags fragments) error {
-	ctx := context.Background()
+	ctx := b.ctx
 
 	start := time.Now()
 	eg := egpool.Group{PoolSize: 20}
@@ -1712,7 +1726,7 @@ func (v *valsByIDsSortable) Swap(i, j int) {
 
 // importValueData imports data for int fields.
 func (b *Batch) importValueData() error {
-	ctx := context.Background()
+	ctx := b.ctx
 
 	shardWidth := uint64(featurebase.ShardWidth)
 	eg := egpool.Group{PoolSize: 20}
@@ -1804,7 +1818,7 @@ func (v *rowsByIDsSortable) Swap(i, j int) {
 // TODO this should work for bools as well - just need to support them
 // at batch creation time and when calling Add, I think.
 func (b *Batch) importMutexData() error {
-	ctx := context.Background()
+	ctx := b.ctx
 
 	shardWidth := uint64(featurebase.ShardWidth)
 
@@ -605,8 +605,9 @@ func (cmd *Command) setupClient() error {
 	case featurebaseTypeOnPremClassic:
 		p.Printf("Detected on-prem, classic deployment.\n")
 		cmd.Queryer = &standardQueryer{
-			Host: cmd.host,
-			Port: cmd.port,
+			Host:   cmd.host,
+			Port:   cmd.port,
+			APIKey: cmd.Config.APIKey,
 		}
 	case featurebaseTypeOnPremServerless:
 		p.Printf("Detected on-prem, serverless deployment.\n")
@@ -660,6 +661,13 @@ func hostPort(host, port string) string {
 	return host + ":" + port
 }
 
+// setAPIKey authenticates req with apiKey, if there is one.
+func setAPIKey(req *http.Request, apiKey string) {
+	if apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+apiKey)
+	}
+}
+
 // detectFBType determines if we're talking to standalone FeatureBase
 // or FeatureBase Cloud
 func (cmd *Command) detectFBType() (featurebaseType, error) {
@@ -725,7 +733,12 @@ func (cmd *Command) detectFBType() (featurebaseType, error) {
 	}
 	for _, trial := range trials {
 		url := hostPort(cmd.host, trial.port) + trial.health
-		if resp, err := client.Get(url); err != nil {
+		req, err := http.NewRequest(http.MethodGet, url, nil)
+		if err != nil {
+			continue
+		}
+		setAPIKey(req, cmd.Config.APIKey)
+		if resp, err := client.Do(req); err != nil {
 			continue
 		} else if resp.StatusCode/100 == 2 {
 			cmd.port = trial.port
@@ -8,6 +8,9 @@ type Config struct {
 	OrganizationID string `json:"org-id"`
 	Database       string `json:"db"`
 
+	// APIKey is sent as a bearer token to a FeatureBase with auth enabled.
+	APIKey string `json:"api-key"`
+
 	// CloudAuth
 	CloudAuth CloudAuthConfig `json:"cloud-auth"`
 
@@ -29,14 +29,22 @@ var _ Queryer = (*standardQueryer)(nil)
 // standardQueryer supports a standard featurebase deployment hitting the /sql
 // endpoint with a payload containing only the sql statement.
 type standardQueryer struct {
-	Host string
-	Port string
+	Host   string
+	Port   string
+	APIKey string
 }
 
 func (qryr *standardQueryer) Query(org string, db string, sql io.Reader) (*featurebase.WireQueryResponse, error) {
 	url := fmt.Sprintf("%s/sql", hostPort(qryr.Host, qryr.Port))
 
-	resp, err := http.Post(url, "application/json", sql)
+	req, err := http.NewRequest(http.MethodPost, url, sql)
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	setAPIKey(req, qryr.APIKey)
+
+	resp, err := http.DefaultClient.Do(req)
 	if err != nil {
 		return nil, errors.Wrapf(err, "posting query")
 	}
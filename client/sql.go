@@ -0,0 +1,237 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/pkg/errors"
+)
+
+// QuerySQL runs the given SQL statement and returns its result. The values of
+// the rows in the result are typed according to its schema, as described by
+// featurebase.WireQueryField.DecodeValue. If the statement fails, the error
+// reported by the server is returned.
+func (c *Client) QuerySQL(sql string) (*featurebase.WireQueryResponse, error) {
+	return c.QuerySQLContext(context.Background(), sql)
+}
+
+// QuerySQLContext is like QuerySQL, but the request is canceled when ctx is.
+func (c *Client) QuerySQLContext(ctx context.Context, sql string) (*featurebase.WireQueryResponse, error) {
+	span := c.tracer.StartSpan("Client.QuerySQL")
+	defer span.Finish()
+
+	rows, err := c.QuerySQLRows(ctx, sql)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	resp := &featurebase.WireQueryResponse{
+		Schema: rows.Schema,
+		Data:   [][]interface{}{},
+	}
+	for rows.Next() {
+		resp.Data = append(resp.Data, rows.Row())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	resp.Warnings = rows.Warnings
+	resp.ExecutionTime = rows.ExecutionTime
+	return resp, nil
+}
+
+// QuerySQLRows runs the given SQL statement, and returns its rows as they are
+// received from the server. Unlike QuerySQL, it doesn't retry the request on
+// failure. The returned SQLRows must be closed.
+func (c *Client) QuerySQLRows(ctx context.Context, sql string) (*SQLRows, error) {
+	host, err := c.host(false)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting host")
+	}
+	headers := c.augmentHeaders(map[string]string{
+		"Content-Type": "text/plain",
+		"Accept":       "application/json",
+	})
+	req, err := buildRequest(host, http.MethodPost, c.prefix()+"/sql", headers, []byte(sql))
+	if err != nil {
+		return nil, errors.Wrap(err, "building request")
+	}
+	resp, err := c.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "sending request")
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return nil, errors.Errorf("server error (%d) %s: %s", resp.StatusCode, resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	rows := &SQLRows{
+		body: resp.Body,
+		dec:  json.NewDecoder(resp.Body),
+	}
+	rows.dec.UseNumber()
+	if err := rows.start(); err != nil {
+		rows.Close()
+		return nil, err
+	}
+	return rows, nil
+}
+
+// SQLRows is the result of a SQL statement, read from the server's response
+// as it's iterated over.
+//
+//	rows, err := client.QuerySQLRows(ctx, "SELECT _id, name FROM users")
+//	if err != nil {
+//		return err
+//	}
+//	defer rows.Close()
+//	for rows.Next() {
+//		row := rows.Row()
+//		...
+//	}
+//	return rows.Err()
+type SQLRows struct {
+	// Schema describes the columns of the rows.
+	Schema featurebase.WireQuerySchema
+
+	// Warnings and ExecutionTime are set once all the rows have been read.
+	Warnings      []string
+	ExecutionTime int64
+
+	body   io.ReadCloser
+	dec    *json.Decoder
+	row    []interface{}
+	inData bool
+	err    error
+}
+
+// start reads the response up to its first row.
+func (r *SQLRows) start() error {
+	if err := r.expectDelim('{'); err != nil {
+		return err
+	}
+	if err := r.readKeys(); err != nil {
+		return err
+	}
+	return r.err
+}
+
+// readKeys reads the keys of the response object until either its data,
+// or the end of the object, is reached.
+func (r *SQLRows) readKeys() error {
+	for r.dec.More() {
+		tok, err := r.dec.Token()
+		if err != nil {
+			return errors.Wrap(err, "reading response")
+		}
+		switch key, _ := tok.(string); key {
+		case "schema":
+			if err := r.dec.Decode(&r.Schema); err != nil {
+				return errors.Wrap(err, "decoding schema")
+			}
+			for _, fld := range r.Schema.Fields {
+				fld.Normalize()
+			}
+		case "data":
+			if err := r.expectDelim('['); err != nil {
+				return err
+			}
+			r.inData = true
+			return nil
+		case "error":
+			var msg string
+			if err := r.dec.Decode(&msg); err != nil {
+				return errors.Wrap(err, "decoding error")
+			}
+			r.err = errors.New(msg)
+		case "warnings":
+			if err := r.dec.Decode(&r.Warnings); err != nil {
+				return errors.Wrap(err, "decoding warnings")
+			}
+		case "execution-time":
+			if err := r.dec.Decode(&r.ExecutionTime); err != nil {
+				return errors.Wrap(err, "decoding execution time")
+			}
+		default:
+			var ignored json.RawMessage
+			if err := r.dec.Decode(&ignored); err != nil {
+				return errors.Wrapf(err, "decoding %s", key)
+			}
+		}
+	}
+	return r.expectDelim('}')
+}
+
+func (r *SQLRows) expectDelim(delim json.Delim) error {
+	tok, err := r.dec.Token()
+	if err != nil {
+		return errors.Wrap(err, "reading response")
+	} else if tok != delim {
+		return errors.Errorf("unexpected %v in response, expected %v", tok, delim)
+	}
+	return nil
+}
+
+// Next reads the next row, returning false when there are no more rows or
+// an error occurred, in which case it's returned by Err.
+func (r *SQLRows) Next() bool {
+	r.row = nil
+	if !r.inData || r.err != nil {
+		return false
+	}
+
+	if !r.dec.More() {
+		r.inData = false
+		if err := r.expectDelim(']'); err != nil {
+			r.err = err
+		} else if err := r.readKeys(); err != nil {
+			r.err = err
+		}
+		return false
+	}
+
+	var row []interface{}
+	if err := r.dec.Decode(&row); err != nil {
+		r.err = errors.Wrap(err, "decoding row")
+		return false
+	}
+	if len(row) != len(r.Schema.Fields) {
+		r.err = errors.Errorf("row has %d values, but the schema has %d fields", len(row), len(r.Schema.Fields))
+		return false
+	}
+	for i, fld := range r.Schema.Fields {
+		val, err := fld.DecodeValue(row[i])
+		if err != nil {
+			r.err = errors.Wrapf(err, "decoding %s", fld.Name)
+			return false
+		}
+		row[i] = val
+	}
+	r.row = row
+	return true
+}
+
+// Row returns the row read by the last call to Next.
+func (r *SQLRows) Row() []interface{} {
+	return r.row
+}
+
+// Err returns the error, if any, which occurred while reading the rows, or
+// which was reported by the server while producing them.
+func (r *SQLRows) Err() error {
+	return r.err
+}
+
+// Close closes the response. It's safe to call Close before all the rows
+// have been read.
+func (r *SQLRows) Close() error {
+	return r.body.Close()
+}
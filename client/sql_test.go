@@ -0,0 +1,115 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package client
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/pql"
+)
+
+const testSQLResponse = `{"schema":{"fields":[` +
+	`{"name":"_id","type":"ID","base-type":"ID","type-info":null},` +
+	`{"name":"name","type":"STRING","base-type":"STRING","type-info":null},` +
+	`{"name":"price","type":"DECIMAL(2)","base-type":"DECIMAL","type-info":{"scale":2}},` +
+	`{"name":"tags","type":"STRINGSET","base-type":"STRINGSET","type-info":null},` +
+	`{"name":"seen","type":"TIMESTAMP","base-type":"TIMESTAMP","type-info":null}]},` +
+	`"data":[[1,"a",1.5,["x","y"],"2022-01-02T03:04:05Z"],[2,null,null,null,null]]` +
+	`,"warnings": ["careful"],"execution-time":12}`
+
+// newTestSQLServer returns a client for a server which responds to each SQL
+// statement with resp, and a channel receiving the statements.
+func newTestSQLServer(t *testing.T, resp string) (*Client, chan string) {
+	statements := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/sql" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		statements <- string(body)
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+
+	cli, err := NewClient(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cli.Close() })
+	return cli, statements
+}
+
+func TestQuerySQL(t *testing.T) {
+	cli, statements := newTestSQLServer(t, testSQLResponse)
+
+	resp, err := cli.QuerySQL("SELECT * FROM t")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stmt := <-statements; stmt != "SELECT * FROM t" {
+		t.Fatalf("unexpected statement %q", stmt)
+	}
+
+	if len(resp.Schema.Fields) != 5 {
+		t.Fatalf("expected 5 fields, got %d", len(resp.Schema.Fields))
+	} else if fld := resp.Schema.Fields[2]; fld.BaseType != "decimal" || fld.TypeInfo["scale"] != int64(2) {
+		t.Fatalf("unexpected decimal field: %+v", fld)
+	}
+	exp := [][]interface{}{
+		{int64(1), "a", pql.NewDecimal(150, 2), featurebase.StringSet{"x", "y"}, time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)},
+		{int64(2), nil, nil, nil, nil},
+	}
+	if !reflect.DeepEqual(resp.Data, exp) {
+		t.Fatalf("unexpected data:\n%#v\nexpected:\n%#v", resp.Data, exp)
+	}
+	if !reflect.DeepEqual(resp.Warnings, []string{"careful"}) || resp.ExecutionTime != 12 {
+		t.Fatalf("unexpected warnings %v or execution time %d", resp.Warnings, resp.ExecutionTime)
+	}
+}
+
+func TestQuerySQLError(t *testing.T) {
+	t.Run("Compile", func(t *testing.T) {
+		cli, _ := newTestSQLServer(t, `{"error":"[1:1] unknown table 'nope'","execution-time": 0}`)
+		if _, err := cli.QuerySQL("SELECT * FROM nope"); err == nil || err.Error() != "[1:1] unknown table 'nope'" {
+			t.Fatalf("expected server error, got %v", err)
+		}
+	})
+
+	t.Run("Rows", func(t *testing.T) {
+		cli, _ := newTestSQLServer(t, `{"schema":{"fields":[{"name":"_id","type":"ID","base-type":"ID"}]},"data":[[1],[2]],"error":"shard unavailable","execution-time":0}`)
+		rows, err := cli.QuerySQLRows(context.Background(), "SELECT _id FROM t")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer rows.Close()
+		n := 0
+		for rows.Next() {
+			n++
+		}
+		if n != 2 {
+			t.Fatalf("expected 2 rows, got %d", n)
+		}
+		if err := rows.Err(); err == nil || !strings.Contains(err.Error(), "shard unavailable") {
+			t.Fatalf("expected error after rows, got %v", err)
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		cli, _ := newTestSQLServer(t, testSQLResponse)
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		if _, err := cli.QuerySQLContext(ctx, "SELECT * FROM t"); err == nil {
+			t.Fatal("expected error for canceled context")
+		}
+	})
+}
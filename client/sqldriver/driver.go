@@ -0,0 +1,339 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+// Package sqldriver provides a database/sql driver for FeatureBase, which is
+// registered as "featurebase":
+//
+//	import (
+//		"database/sql"
+//
+//		_ "github.com/featurebasedb/featurebase/v3/client/sqldriver"
+//	)
+//
+//	db, err := sql.Open("featurebase", "http://localhost:10101")
+//	...
+//	rows, err := db.QueryContext(ctx, "SELECT _id, name FROM users WHERE age > ?", 21)
+//
+// The data source name is the address of a FeatureBase node, optionally
+// followed by these parameters:
+//
+//	auth-token       the token used to authenticate requests
+//	path-prefix      a prefix added to the path of requests (e.g. "queryer")
+//	timeout          the timeout of requests (e.g. "30s")
+//	tls-skip-verify  if true, the server's certificate isn't verified
+//
+// for example "https://fb.example.com:10101?auth-token=abc&timeout=1m".
+//
+// FeatureBase doesn't support prepared statements, so the ? placeholders of
+// a statement are replaced with the literal values of their arguments before
+// it is sent to the server. Arguments may be nil, integers, floats, bools,
+// strings, byte slices, time.Time, pql.Decimal, []int64 (for an idset), or
+// []string (for a stringset). Values of idset and stringset columns are
+// scanned as []int64 and []string, and values of decimal columns as
+// pql.Decimal. Transactions aren't supported.
+package sqldriver
+
+import (
+	"context"
+	"crypto/tls"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	featurebase "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/client"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/pkg/errors"
+)
+
+// DriverName is the name under which the driver is registered.
+const DriverName = "featurebase"
+
+func init() {
+	sql.Register(DriverName, &Driver{})
+}
+
+// ErrTransactionsNotSupported is returned when beginning a transaction.
+var ErrTransactionsNotSupported = errors.New("featurebase: transactions are not supported")
+
+// Driver is the FeatureBase database/sql driver.
+type Driver struct{}
+
+var (
+	_ driver.Driver        = &Driver{}
+	_ driver.DriverContext = &Driver{}
+)
+
+// Open returns a new connection to the FeatureBase node named by dsn.
+func (d *Driver) Open(dsn string) (driver.Conn, error) {
+	c, err := d.OpenConnector(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return c.Connect(context.Background())
+}
+
+// OpenConnector returns a Connector for the FeatureBase node named by dsn.
+func (d *Driver) OpenConnector(dsn string) (driver.Connector, error) {
+	addr, params := dsn, ""
+	if i := strings.Index(dsn, "?"); i >= 0 {
+		addr, params = dsn[:i], dsn[i+1:]
+	}
+	vals, err := url.ParseQuery(params)
+	if err != nil {
+		return nil, errors.Wrap(err, "parsing data source name parameters")
+	}
+
+	var opts []client.ClientOption
+	var authToken string
+	for key := range vals {
+		val := vals.Get(key)
+		switch key {
+		case "auth-token":
+			authToken = val
+		case "path-prefix":
+			opts = append(opts, client.OptClientPathPrefix(val))
+		case "timeout":
+			timeout, err := time.ParseDuration(val)
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing timeout")
+			}
+			opts = append(opts, client.OptClientSocketTimeout(timeout))
+		case "tls-skip-verify":
+			skip, err := strconv.ParseBool(val)
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing tls-skip-verify")
+			}
+			opts = append(opts, client.OptClientTLSConfig(&tls.Config{InsecureSkipVerify: skip})) // #nosec G402
+		default:
+			return nil, errors.Errorf("unknown data source name parameter %s", key)
+		}
+	}
+
+	cli, err := client.NewClient(addr, opts...)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating client")
+	}
+	cli.AuthToken = authToken
+	return NewConnector(cli), nil
+}
+
+// NewConnector returns a Connector which runs statements with cli. It may be
+// used with sql.OpenDB, when a client has already been configured.
+func NewConnector(cli *client.Client) *Connector {
+	return &Connector{client: cli}
+}
+
+// Connector is a driver.Connector for FeatureBase. Since requests are made
+// over HTTP, its connections all share the same client.
+type Connector struct {
+	client *client.Client
+}
+
+var _ driver.Connector = &Connector{}
+
+// Connect returns a connection.
+func (c *Connector) Connect(ctx context.Context) (driver.Conn, error) {
+	return &conn{client: c.client}, nil
+}
+
+// Driver returns the FeatureBase Driver.
+func (c *Connector) Driver() driver.Driver {
+	return &Driver{}
+}
+
+// Close closes the client. It's called by sql.DB.Close.
+func (c *Connector) Close() error {
+	return c.client.Close()
+}
+
+// conn is a connection to FeatureBase.
+type conn struct {
+	client *client.Client
+}
+
+var (
+	_ driver.Conn               = &conn{}
+	_ driver.QueryerContext     = &conn{}
+	_ driver.ExecerContext      = &conn{}
+	_ driver.NamedValueChecker  = &conn{}
+	_ driver.ConnPrepareContext = &conn{}
+)
+
+func (c *conn) Prepare(query string) (driver.Stmt, error) {
+	return c.PrepareContext(context.Background(), query)
+}
+
+func (c *conn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return &stmt{conn: c, query: query, numInput: countPlaceholders(query)}, nil
+}
+
+func (c *conn) Close() error {
+	return nil
+}
+
+func (c *conn) Begin() (driver.Tx, error) {
+	return nil, ErrTransactionsNotSupported
+}
+
+func (c *conn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	query, err := interpolate(query, args)
+	if err != nil {
+		return nil, err
+	}
+	sqlRows, err := c.client.QuerySQLRows(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return &rows{rows: sqlRows}, nil
+}
+
+func (c *conn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	rs, err := c.QueryContext(ctx, query, args)
+	if err != nil {
+		return nil, err
+	}
+	r := rs.(*rows)
+	defer r.Close()
+	for r.rows.Next() {
+		// Discard any rows; a statement run with Exec usually has none.
+	}
+	if err := r.rows.Err(); err != nil {
+		return nil, err
+	}
+	// The number of rows affected by a statement isn't reported.
+	return driver.ResultNoRows, nil
+}
+
+// CheckNamedValue accepts the arguments which are converted to FeatureBase
+// types, and leaves the rest to the default converter.
+func (c *conn) CheckNamedValue(nv *driver.NamedValue) error {
+	switch v := nv.Value.(type) {
+	case pql.Decimal, []int64, []string:
+		return nil
+	case featurebase.IDSet:
+		nv.Value = []int64(v)
+		return nil
+	case featurebase.StringSet:
+		nv.Value = []string(v)
+		return nil
+	}
+	return driver.ErrSkip
+}
+
+// stmt is a statement, which is only prepared in the sense that its
+// placeholders have been counted.
+type stmt struct {
+	conn     *conn
+	query    string
+	numInput int
+}
+
+var (
+	_ driver.Stmt             = &stmt{}
+	_ driver.StmtQueryContext = &stmt{}
+	_ driver.StmtExecContext  = &stmt{}
+)
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func (s *stmt) NumInput() int {
+	return s.numInput
+}
+
+func (s *stmt) Exec(args []driver.Value) (driver.Result, error) {
+	return s.ExecContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.QueryContext(context.Background(), namedValues(args))
+}
+
+func (s *stmt) ExecContext(ctx context.Context, args []driver.NamedValue) (driver.Result, error) {
+	return s.conn.ExecContext(ctx, s.query, args)
+}
+
+func (s *stmt) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
+	return s.conn.QueryContext(ctx, s.query, args)
+}
+
+func namedValues(args []driver.Value) []driver.NamedValue {
+	nvs := make([]driver.NamedValue, len(args))
+	for i, arg := range args {
+		nvs[i] = driver.NamedValue{Ordinal: i + 1, Value: arg}
+	}
+	return nvs
+}
+
+// rows streams the rows of a result.
+type rows struct {
+	rows *client.SQLRows
+}
+
+var (
+	_ driver.Rows                           = &rows{}
+	_ driver.RowsColumnTypeDatabaseTypeName = &rows{}
+	_ driver.RowsColumnTypeScanType         = &rows{}
+)
+
+func (r *rows) Columns() []string {
+	cols := make([]string, len(r.rows.Schema.Fields))
+	for i, fld := range r.rows.Schema.Fields {
+		cols[i] = string(fld.Name)
+	}
+	return cols
+}
+
+func (r *rows) Close() error {
+	return r.rows.Close()
+}
+
+func (r *rows) Next(dest []driver.Value) error {
+	if !r.rows.Next() {
+		if err := r.rows.Err(); err != nil {
+			return err
+		}
+		return io.EOF
+	}
+	for i, val := range r.rows.Row() {
+		switch v := val.(type) {
+		case featurebase.IDSet:
+			dest[i] = []int64(v)
+		case featurebase.StringSet:
+			dest[i] = []string(v)
+		default:
+			dest[i] = v
+		}
+	}
+	return nil
+}
+
+func (r *rows) ColumnTypeDatabaseTypeName(index int) string {
+	return strings.ToUpper(string(r.rows.Schema.Fields[index].BaseType))
+}
+
+var scanTypes = map[dax.BaseType]reflect.Type{
+	dax.BaseTypeBool:      reflect.TypeOf(false),
+	dax.BaseTypeDecimal:   reflect.TypeOf(pql.Decimal{}),
+	dax.BaseTypeID:        reflect.TypeOf(int64(0)),
+	dax.BaseTypeIDSet:     reflect.TypeOf([]int64{}),
+	dax.BaseTypeInt:       reflect.TypeOf(int64(0)),
+	dax.BaseTypeString:    reflect.TypeOf(""),
+	dax.BaseTypeStringSet: reflect.TypeOf([]string{}),
+	dax.BaseTypeTimestamp: reflect.TypeOf(time.Time{}),
+}
+
+func (r *rows) ColumnTypeScanType(index int) reflect.Type {
+	if typ, ok := scanTypes[r.rows.Schema.Fields[index].BaseType]; ok {
+		return typ
+	}
+	return reflect.TypeOf((*interface{})(nil)).Elem()
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package sqldriver
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+)
+
+func TestInterpolate(t *testing.T) {
+	ts := time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC)
+	tests := []struct {
+		query string
+		args  []interface{}
+		exp   string
+		err   bool
+	}{
+		{query: "SELECT 1", exp: "SELECT 1"},
+		{
+			query: "INSERT INTO t VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+			args:  []interface{}{int64(-1), 1.5, true, "it's", nil, ts, pql.NewDecimal(1234, 2), []int64{1, 2}, []string{"a", "b'c"}},
+			exp:   "INSERT INTO t VALUES (-1, 1.5, TRUE, 'it''s', NULL, '2022-01-02T03:04:05Z', 12.34, [1, 2], ['a', 'b''c'])",
+		},
+		{
+			query: "SELECT '?', \"a?\" FROM t -- why?\nWHERE a = ? AND b = 'x''?'",
+			args:  []interface{}{"y"},
+			exp:   "SELECT '?', \"a?\" FROM t -- why?\nWHERE a = 'y' AND b = 'x''?'",
+		},
+		{query: "SELECT ?", err: true},
+		{query: "SELECT 1", args: []interface{}{int64(1)}, err: true},
+		{query: "SELECT ?", args: []interface{}{"a\nb"}, err: true},
+	}
+	for i, test := range tests {
+		args := make([]driver.NamedValue, len(test.args))
+		for j, arg := range test.args {
+			args[j] = driver.NamedValue{Ordinal: j + 1, Value: arg}
+		}
+		got, err := interpolate(test.query, args)
+		if test.err {
+			if err == nil {
+				t.Errorf("test %d: expected error, got %q", i, got)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("test %d: %v", i, err)
+		} else if got != test.exp {
+			t.Errorf("test %d: expected:\n%s\ngot:\n%s", i, test.exp, got)
+		}
+		if n := countPlaceholders(test.query); n != len(test.args) {
+			t.Errorf("test %d: expected %d placeholders, counted %d", i, len(test.args), n)
+		}
+	}
+}
+
+func TestDriver(t *testing.T) {
+	statements := make(chan string, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "secret" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		statements <- string(body)
+		_, _ = w.Write([]byte(`{"schema":{"fields":[` +
+			`{"name":"_id","type":"ID","base-type":"ID"},` +
+			`{"name":"tags","type":"IDSET","base-type":"IDSET"},` +
+			`{"name":"price","type":"DECIMAL(2)","base-type":"DECIMAL","type-info":{"scale":2}}]},` +
+			`"data":[[1,[3,4],1.25],[2,null,null]],"execution-time":1}`))
+	}))
+	defer srv.Close()
+
+	db, err := sql.Open(DriverName, srv.URL+"?auth-token=secret&timeout=10s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(context.Background(), "SELECT _id, tags, price FROM t WHERE _id > ?", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stmt := <-statements; stmt != "SELECT _id, tags, price FROM t WHERE _id > 0" {
+		t.Fatalf("unexpected statement %q", stmt)
+	}
+	cols, err := rows.ColumnTypes()
+	if err != nil {
+		t.Fatal(err)
+	} else if cols[1].DatabaseTypeName() != "IDSET" || cols[1].ScanType() != reflect.TypeOf([]int64{}) {
+		t.Fatalf("unexpected column type %s %v", cols[1].DatabaseTypeName(), cols[1].ScanType())
+	}
+
+	type row struct {
+		id    int64
+		tags  interface{}
+		price sql.NullFloat64
+	}
+	var got []row
+	for rows.Next() {
+		var r row
+		if err := rows.Scan(&r.id, &r.tags, &r.price); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	if err := rows.Err(); err != nil {
+		t.Fatal(err)
+	}
+	exp := []row{
+		{id: 1, tags: []int64{3, 4}, price: sql.NullFloat64{Float64: 1.25, Valid: true}},
+		{id: 2},
+	}
+	if !reflect.DeepEqual(got, exp) {
+		t.Fatalf("unexpected rows:\n%+v\nexpected:\n%+v", got, exp)
+	}
+
+	if _, err := db.Exec("DELETE FROM t WHERE _id = ?", 1); err != nil {
+		t.Fatal(err)
+	} else if stmt := <-statements; stmt != "DELETE FROM t WHERE _id = 1" {
+		t.Fatalf("unexpected statement %q", stmt)
+	}
+
+	if _, err := db.Begin(); err != ErrTransactionsNotSupported {
+		t.Fatalf("expected %v, got %v", ErrTransactionsNotSupported, err)
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package sqldriver
+
+import (
+	"database/sql/driver"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/pkg/errors"
+)
+
+// scanPlaceholders calls fn with the position of each ? placeholder in
+// query, skipping those in string literals, quoted identifiers and comments.
+func scanPlaceholders(query string, fn func(pos int)) {
+	for i := 0; i < len(query); i++ {
+		switch query[i] {
+		case '\'', '"':
+			// A quote is escaped by doubling it, which is handled
+			// by treating it as two adjacent literals.
+			if j := strings.IndexByte(query[i+1:], query[i]); j >= 0 {
+				i += j + 1
+			} else {
+				return
+			}
+		case '-':
+			if i+1 < len(query) && query[i+1] == '-' {
+				if j := strings.IndexByte(query[i:], '\n'); j >= 0 {
+					i += j
+				} else {
+					return
+				}
+			}
+		case '?':
+			fn(i)
+		}
+	}
+}
+
+// countPlaceholders returns the number of ? placeholders in query.
+func countPlaceholders(query string) int {
+	n := 0
+	scanPlaceholders(query, func(int) { n++ })
+	return n
+}
+
+// interpolate replaces the ? placeholders in query with the literal values
+// of args, in order.
+func interpolate(query string, args []driver.NamedValue) (string, error) {
+	var positions []int
+	scanPlaceholders(query, func(pos int) { positions = append(positions, pos) })
+	if len(positions) != len(args) {
+		return "", errors.Errorf("statement has %d placeholders, but %d arguments were given", len(positions), len(args))
+	}
+	if len(args) == 0 {
+		return query, nil
+	}
+
+	var sb strings.Builder
+	prev := 0
+	for i, pos := range positions {
+		if args[i].Name != "" {
+			return "", errors.Errorf("named arguments are not supported: %s", args[i].Name)
+		}
+		lit, err := literal(args[i].Value)
+		if err != nil {
+			return "", errors.Wrapf(err, "argument %d", args[i].Ordinal)
+		}
+		sb.WriteString(query[prev:pos])
+		sb.WriteString(lit)
+		prev = pos + 1
+	}
+	sb.WriteString(query[prev:])
+	return sb.String(), nil
+}
+
+// literal returns the SQL literal of val.
+func literal(val interface{}) (string, error) {
+	switch v := val.(type) {
+	case nil:
+		return "NULL", nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", errors.Errorf("%v can't be represented in SQL", v)
+		}
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case bool:
+		if v {
+			return "TRUE", nil
+		}
+		return "FALSE", nil
+	case string:
+		return quote(v)
+	case []byte:
+		return quote(string(v))
+	case time.Time:
+		return quote(v.UTC().Format(time.RFC3339Nano))
+	case pql.Decimal:
+		return v.String(), nil
+	case []int64:
+		members := make([]string, len(v))
+		for i, id := range v {
+			members[i] = strconv.FormatInt(id, 10)
+		}
+		return "[" + strings.Join(members, ", ") + "]", nil
+	case []string:
+		members := make([]string, len(v))
+		for i, s := range v {
+			lit, err := quote(s)
+			if err != nil {
+				return "", err
+			}
+			members[i] = lit
+		}
+		return "[" + strings.Join(members, ", ") + "]", nil
+	}
+	return "", errors.Errorf("unsupported argument type %T", val)
+}
+
+// quote returns s as a string literal.
+func quote(s string) (string, error) {
+	if strings.ContainsRune(s, '\n') {
+		return "", errors.New("string literals can't contain newlines")
+	}
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'", nil
+}
@@ -0,0 +1,32 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package cmd
+
+import (
+	"github.com/featurebasedb/featurebase/v3/ctl"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/spf13/cobra"
+)
+
+func newEncryptCommand(logdest logger.Logger) *cobra.Command {
+	cmd := ctl.NewEncryptCommand(logdest)
+	ccmd := &cobra.Command{
+		Use:   "encrypt",
+		Short: "Encrypt an existing data directory.",
+		Long: `
+Encrypts every RBF database and translate store in a data directory with the
+current key from a key file. With --rotate, a new key is first added to the key
+file and all data is re-encrypted with it. Older keys must remain in the key
+file until this command has completed.
+
+The server must be stopped while this command runs.
+`,
+		RunE: UsageErrorWrapper(cmd),
+	}
+
+	flags := ccmd.Flags()
+	flags.StringVarP(&cmd.DataDir, "data-dir", "d", "", "FeatureBase data directory.")
+	flags.StringVar(&cmd.KeyFile, "key-file", "", "Path to the encryption key file.")
+	flags.BoolVar(&cmd.Rotate, "rotate", false, "Add a new key to the key file before encrypting.")
+	return ccmd
+}
@@ -0,0 +1,123 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/rbf"
+)
+
+// Fragmentation summarizes the unused space within a set of RBF databases.
+type Fragmentation struct {
+	Pages     int64   `json:"pages"`
+	FreePages int64   `json:"freePages"`
+	FreeBytes int64   `json:"freeBytes"`
+	FreeRatio float64 `json:"freeRatio"`
+}
+
+// add includes the totals of other in f.
+func (f *Fragmentation) add(other Fragmentation) {
+	f.Pages += other.Pages
+	f.FreePages += other.FreePages
+	f.FreeBytes = f.FreePages * rbf.PageSize
+	if f.Pages > 0 {
+		f.FreeRatio = float64(f.FreePages) / float64(f.Pages)
+	}
+}
+
+// ShardCompaction describes the result of compacting a shard's RBF database.
+type ShardCompaction struct {
+	Index string `json:"index"`
+	Shard uint64 `json:"shard"`
+
+	// Pages is the number of pages returned to the filesystem.
+	Pages int `json:"pages"`
+}
+
+// fragmentation returns the combined fragmentation of every open RBF database
+// in the holder. If index is non-blank, only that index is included.
+func (h *Holder) fragmentation(index string) (Fragmentation, error) {
+	var frag Fragmentation
+	for _, dbs := range h.dbShards(index) {
+		w, ok := dbs.W.(*RbfDBWrapper)
+		if !ok {
+			continue
+		}
+
+		f, err := w.db.Fragmentation()
+		if err == rbf.ErrClosed {
+			continue
+		} else if err != nil {
+			return frag, err
+		}
+		frag.add(Fragmentation{Pages: int64(f.PageN), FreePages: int64(f.FreePageN)})
+	}
+	return frag, nil
+}
+
+// compactShards compacts every open RBF database in the holder whose ratio
+// of free pages is at least threshold. If index is non-blank, only that index
+// is compacted. Databases which were not shrunk are not included in the
+// results.
+func (h *Holder) compactShards(ctx context.Context, index string, threshold float64) ([]ShardCompaction, error) {
+	var results []ShardCompaction
+	for _, dbs := range h.dbShards(index) {
+		select {
+		case <-ctx.Done():
+			return results, ctx.Err()
+		case <-h.closing:
+			return results, nil
+		default:
+		}
+
+		w, ok := dbs.W.(*RbfDBWrapper)
+		if !ok {
+			continue
+		}
+
+		f, err := w.db.Fragmentation()
+		if err == rbf.ErrClosed {
+			continue
+		} else if err != nil {
+			return results, err
+		} else if f.FreePageN == 0 || f.FreeRatio() < threshold {
+			continue
+		}
+
+		n, err := w.db.Compact()
+		if err == rbf.ErrClosed {
+			continue
+		} else if err != nil {
+			return results, err
+		} else if n == 0 {
+			continue
+		}
+		h.Logger.Infof("compacted shard %s/%d: pages=%d free=%d reclaimed=%d", dbs.Index, dbs.Shard, f.PageN, f.FreePageN, n)
+		CounterRBFCompactedPages.WithLabelValues(dbs.Index).Add(float64(n))
+		results = append(results, ShardCompaction{Index: dbs.Index, Shard: dbs.Shard, Pages: n})
+	}
+	return results, nil
+}
+
+// monitorCompaction periodically compacts every RBF database whose ratio of
+// free pages exceeds the configured threshold.
+func (s *Server) monitorCompaction() {
+	interval := time.Duration(s.holderConfig.RBFConfig.CompactionInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			start := time.Now()
+			if _, err := s.holder.compactShards(context.Background(), "", s.holderConfig.RBFConfig.CompactionThreshold); err != nil {
+				s.logger.Errorf("compacting shards: %v", err)
+			}
+			SummaryRBFCompactionDurationSeconds.Observe(time.Since(start).Seconds())
+		}
+	}
+}
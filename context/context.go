@@ -7,6 +7,8 @@ import "context"
 type contextKeyOriginalIP struct{}
 type contextKeyRequestUserID struct{}
 type contextKeyRequestRequestID struct{}
+type contextKeySourceIP struct{}
+type contextKeyInternal struct{}
 
 // OriginalIP gets the original IP from the context.
 func OriginalIP(ctx context.Context) (originalIP string, ok bool) {
@@ -36,3 +38,29 @@ func RequestID(ctx context.Context) (userID string, ok bool) {
 func WithRequestID(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, contextKeyRequestRequestID{}, userID)
 }
+
+// SourceIP gets the IP of the client which made the request from the context.
+func SourceIP(ctx context.Context) (sourceIP string, ok bool) {
+	sourceIP, ok = ctx.Value(contextKeySourceIP{}).(string)
+	return
+}
+
+// WithSourceIP makes a new context with the IP of the client which made the
+// request in the context. Unlike the original IP, it's not passed on to other
+// nodes.
+func WithSourceIP(ctx context.Context, sourceIP string) context.Context {
+	return context.WithValue(ctx, contextKeySourceIP{}, sourceIP)
+}
+
+// Internal returns true if the context is of a request which was
+// authenticated as coming from another node on behalf of the system.
+func Internal(ctx context.Context) bool {
+	internal, _ := ctx.Value(contextKeyInternal{}).(bool)
+	return internal
+}
+
+// WithInternal makes a new context marked as being of a request which was
+// authenticated as coming from another node on behalf of the system.
+func WithInternal(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKeyInternal{}, true)
+}
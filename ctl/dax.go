@@ -19,6 +19,29 @@ func BuildDAXFlags(cmd *cobra.Command, srv *server.Command) {
 	flags.DurationVar(&srv.Config.Controller.Config.RegistrationBatchTimeout, "controller.config.registration-batch-timeout", srv.Config.Controller.Config.RegistrationBatchTimeout, "Timeout for node registration batches.")
 	flags.StringVar(&srv.Config.Controller.Config.StorageMethod, "controller.config.storage-method", srv.Config.Controller.Config.StorageMethod, "Backing store. boltdb or sqldb.")
 	flags.DurationVar(&srv.Config.Controller.Config.SnappingTurtleTimeout, "controller.config.snapping-turtle-timeout", srv.Config.Controller.Config.SnappingTurtleTimeout, "Period for running automatic snapshotting routine.")
+	flags.Int64Var(&srv.Config.Controller.Config.SnappingTurtleThreshold, "controller.config.snapping-turtle-threshold", srv.Config.Controller.Config.SnappingTurtleThreshold, "Size in bytes beyond which a write log must grow before automatic snapshotting will snapshot it.")
+
+	// Controller.DAXStore
+	flags.StringVar(&srv.Config.Controller.Config.DAXStore.Bucket, "controller.config.dax-store.bucket", srv.Config.Controller.Config.DAXStore.Bucket, "S3 bucket holding append logs and snapshots. Must match the computers' dax-store.")
+	flags.StringVar(&srv.Config.Controller.Config.DAXStore.Prefix, "controller.config.dax-store.prefix", srv.Config.Controller.Config.DAXStore.Prefix, "Prefix of append log and snapshot objects.")
+	flags.StringVar(&srv.Config.Controller.Config.DAXStore.Region, "controller.config.dax-store.region", srv.Config.Controller.Config.DAXStore.Region, "Region of the DAX store bucket.")
+	flags.StringVar(&srv.Config.Controller.Config.DAXStore.Endpoint, "controller.config.dax-store.endpoint", srv.Config.Controller.Config.DAXStore.Endpoint, "Endpoint of an S3-compatible object store.")
+	flags.StringVar(&srv.Config.Controller.Config.DAXStore.AccessKeyID, "controller.config.dax-store.access-key-id", srv.Config.Controller.Config.DAXStore.AccessKeyID, "Access key ID for the DAX store bucket.")
+	flags.StringVar(&srv.Config.Controller.Config.DAXStore.SecretAccessKey, "controller.config.dax-store.secret-access-key", srv.Config.Controller.Config.DAXStore.SecretAccessKey, "Secret access key for the DAX store bucket.")
+
+	// Controller.Autoscaler
+	flags.BoolVar(&srv.Config.Controller.Config.Autoscaler.Enabled, "controller.config.autoscaler.enabled", srv.Config.Controller.Config.Autoscaler.Enabled, "Enable autoscaling of workers.")
+	flags.DurationVar(&srv.Config.Controller.Config.Autoscaler.Interval, "controller.config.autoscaler.interval", srv.Config.Controller.Config.Autoscaler.Interval, "Period on which autoscaling policies are evaluated.")
+	flags.DurationVar(&srv.Config.Controller.Config.Autoscaler.Cooldown, "controller.config.autoscaler.cooldown", srv.Config.Controller.Config.Autoscaler.Cooldown, "Time after adding or removing workers during which no further change is made.")
+	flags.IntVar(&srv.Config.Controller.Config.Autoscaler.MaxWorkers, "controller.config.autoscaler.max-workers", srv.Config.Controller.Config.Autoscaler.MaxWorkers, "Most workers to scale up to. 0=unlimited")
+	flags.IntVar(&srv.Config.Controller.Config.Autoscaler.JobsPerWorker, "controller.config.autoscaler.jobs-per-worker", srv.Config.Controller.Config.Autoscaler.JobsPerWorker, "Target number of jobs per worker. 0 disables the policy.")
+	flags.DurationVar(&srv.Config.Controller.Config.Autoscaler.QueryLatencyHigh, "controller.config.autoscaler.query-latency-high", srv.Config.Controller.Config.Autoscaler.QueryLatencyHigh, "Mean query latency above which compute workers are added. 0 disables the policy.")
+	flags.DurationVar(&srv.Config.Controller.Config.Autoscaler.QueryLatencyLow, "controller.config.autoscaler.query-latency-low", srv.Config.Controller.Config.Autoscaler.QueryLatencyLow, "Mean query latency below which compute workers are removed.")
+	flags.IntVar(&srv.Config.Controller.Config.Autoscaler.FreeWorkersMin, "controller.config.autoscaler.free-workers-min", srv.Config.Controller.Config.Autoscaler.FreeWorkersMin, "Fewest free workers to keep available.")
+	flags.IntVar(&srv.Config.Controller.Config.Autoscaler.FreeWorkersMax, "controller.config.autoscaler.free-workers-max", srv.Config.Controller.Config.Autoscaler.FreeWorkersMax, "Most free workers to keep available. 0=unlimited")
+	flags.StringSliceVar(&srv.Config.Controller.Config.Autoscaler.LocalCommand, "controller.config.autoscaler.local-command", srv.Config.Controller.Config.Autoscaler.LocalCommand, "Command with which to start workers as local processes. {port} and {role} are replaced in each argument.")
+	flags.StringVar(&srv.Config.Controller.Config.Autoscaler.LocalHost, "controller.config.autoscaler.local-host", srv.Config.Controller.Config.Autoscaler.LocalHost, "Host at which local workers register.")
+	flags.IntVar(&srv.Config.Controller.Config.Autoscaler.LocalBasePort, "controller.config.autoscaler.local-base-port", srv.Config.Controller.Config.Autoscaler.LocalBasePort, "First port given to local workers.")
 
 	// Controller.SQLDB
 	flags.StringVar(&srv.Config.Controller.Config.SQLDB.Database, "controller.config.sqldb.database", srv.Config.Controller.Config.SQLDB.Database, "Database name.")
@@ -35,6 +58,7 @@ func BuildDAXFlags(cmd *cobra.Command, srv *server.Command) {
 	// Queryer
 	flags.BoolVar(&srv.Config.Queryer.Run, "queryer.run", srv.Config.Queryer.Run, "Run the Queryer service in process.")
 	flags.StringVar(&srv.Config.Queryer.Config.ControllerAddress, "queryer.config.controller-address", srv.Config.Queryer.Config.ControllerAddress, "Address of remote Controller process.")
+	flags.DurationVar(&srv.Config.Queryer.Config.QueryStatsInterval, "queryer.config.query-stats-interval", srv.Config.Queryer.Config.QueryStatsInterval, "Period on which query stats are reported to the Controller.")
 
 	// Computer
 	flags.BoolVar(&srv.Config.Computer.Run, "computer.run", srv.Config.Computer.Run, "Run the Computer service in process.")
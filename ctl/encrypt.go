@@ -0,0 +1,165 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package ctl
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/encryption"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/rbf"
+	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
+)
+
+// EncryptCommand represents a command for encrypting the RBF databases and
+// translate stores of an existing data directory. It is also used to
+// re-encrypt a data directory with a new key after key rotation.
+type EncryptCommand struct {
+	// Path to the data directory. The server must not be running.
+	DataDir string
+
+	// Path to the key file. It is created if Rotate is set and it does
+	// not exist.
+	KeyFile string
+
+	// If true, a new key is added to the key file before encrypting.
+	Rotate bool
+
+	// Standard input/output
+	stdout  io.Writer
+	logDest logger.Logger
+}
+
+// NewEncryptCommand returns a new instance of EncryptCommand.
+func NewEncryptCommand(logdest logger.Logger) *EncryptCommand {
+	return &EncryptCommand{
+		stdout:  os.Stdout,
+		logDest: logdest,
+	}
+}
+
+// Run encrypts every RBF database & translate store in the data directory
+// with the current key.
+func (cmd *EncryptCommand) Run(ctx context.Context) error {
+	if cmd.DataDir == "" {
+		return fmt.Errorf("data directory required")
+	} else if cmd.KeyFile == "" {
+		return fmt.Errorf("key file required")
+	}
+
+	if cmd.Rotate {
+		id, err := encryption.AddKey(cmd.KeyFile)
+		if err != nil {
+			return fmt.Errorf("adding key: %w", err)
+		}
+		fmt.Fprintf(cmd.stdout, "added key %d to %s\n", id, cmd.KeyFile)
+	}
+	keys, err := encryption.OpenKeyFile(cmd.KeyFile)
+	if err != nil {
+		return err
+	}
+	c := encryption.NewCipher(keys)
+
+	var dbN, storeN int
+	root := filepath.Join(cmd.DataDir, pilosa.IndexesDir)
+	if err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+
+		switch {
+		case isRBFPath(root, path, d):
+			if err := cmd.encryptRBF(path, c); err != nil {
+				return fmt.Errorf("encrypting rbf database %s: %w", path, err)
+			}
+			dbN++
+			return filepath.SkipDir
+		case isTranslateStorePath(root, path, d):
+			if err := cmd.encryptTranslateStore(path, c); err != nil {
+				return fmt.Errorf("encrypting translate store %s: %w", path, err)
+			}
+			storeN++
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(cmd.stdout, "encrypted %d RBF databases and %d translate stores\n", dbN, storeN)
+	return nil
+}
+
+// encryptRBF encrypts all pages of an RBF database with the current key. Any
+// plaintext pages are encrypted when the database is opened.
+func (cmd *EncryptCommand) encryptRBF(path string, c *encryption.Cipher) error {
+	cfg := rbfcfg.NewDefaultConfig()
+	cfg.Cipher = c
+	cfg.Logger = cmd.logDest
+	db := rbf.NewDB(path, cfg)
+	if err := db.Open(); err != nil {
+		return err
+	}
+	if _, err := db.Rekey(); err != nil {
+		db.Close()
+		return err
+	}
+	return db.Close()
+}
+
+// encryptTranslateStore encrypts a translate store with the current key,
+// which is done when the store is opened.
+func (cmd *EncryptCommand) encryptTranslateStore(path string, c *encryption.Cipher) error {
+	s := pilosa.NewBoltTranslateStore("", "", -1, 0, true)
+	s.Path = path
+	s.Cipher = c
+	if err := s.Open(); err != nil {
+		return err
+	}
+	return s.Close()
+}
+
+// isRBFPath returns true if path is an RBF shard directory, which is found
+// at <index>/backends/rbf/shard.NNNN relative to the indexes directory.
+func isRBFPath(root, path string, d fs.DirEntry) bool {
+	parts := relPathParts(root, path)
+	return d.IsDir() && len(parts) == 4 && parts[1] == "backends" && parts[2] == "rbf" && strings.HasPrefix(parts[3], "shard.")
+}
+
+// isTranslateStorePath returns true if path is an index translate store,
+// at <index>/_keys/<partition>, or a field translate store, at
+// <index>/fields/<field>/keys, relative to the indexes directory.
+func isTranslateStorePath(root, path string, d fs.DirEntry) bool {
+	if !d.Type().IsRegular() {
+		return false
+	}
+	parts := relPathParts(root, path)
+	switch len(parts) {
+	case 3:
+		_, err := strconv.Atoi(parts[2])
+		return parts[1] == "_keys" && err == nil
+	case 4:
+		return parts[1] == pilosa.FieldsDir && parts[3] == "keys"
+	}
+	return false
+}
+
+func relPathParts(root, path string) []string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return nil
+	}
+	return strings.Split(rel, string(filepath.Separator))
+}
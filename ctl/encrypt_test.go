@@ -0,0 +1,120 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package ctl
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/encryption"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/rbf"
+	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
+)
+
+func TestEncryptCommand_Run(t *testing.T) {
+	dataDir := t.TempDir()
+	keyFile := filepath.Join(t.TempDir(), "keys")
+	dbPath := filepath.Join(dataDir, "indexes", "i", "backends", "rbf", "shard.0000")
+	storePaths := []string{
+		filepath.Join(dataDir, "indexes", "i", "_keys", "0"),
+		filepath.Join(dataDir, "indexes", "i", "fields", "f", "keys"),
+	}
+
+	// Create a plaintext RBF database & translate stores.
+	db := rbf.NewDB(dbPath, nil)
+	if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+	tx, err := db.Begin(true)
+	if err != nil {
+		t.Fatal(err)
+	} else if _, err := tx.Add("x", 1, 2, 3); err != nil {
+		t.Fatal(err)
+	} else if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range storePaths {
+		s, err := pilosa.OpenTranslateStore(path, "i", "", 0, 8, false)
+		if err != nil {
+			t.Fatal(err)
+		} else if _, err := s.CreateKeys("foo"); err != nil {
+			t.Fatal(err)
+		} else if err := s.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	run := func(tb testing.TB) string {
+		tb.Helper()
+		cmd := NewEncryptCommand(logger.NewStandardLogger(os.Stderr))
+		buf := &bytes.Buffer{}
+		cmd.stdout = buf
+		cmd.DataDir, cmd.KeyFile, cmd.Rotate = dataDir, keyFile, true
+		if err := cmd.Run(context.Background()); err != nil {
+			tb.Fatal(err)
+		}
+		return buf.String()
+	}
+
+	// verify checks the data can be read with the key file but not without.
+	verify := func(tb testing.TB) {
+		tb.Helper()
+		if err := rbf.NewDB(dbPath, nil).Open(); !errors.Is(err, rbf.ErrNoCipher) {
+			tb.Fatalf("unexpected error: %v", err)
+		}
+		keys, err := encryption.OpenKeyFile(keyFile)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		cfg := rbfcfg.NewDefaultConfig()
+		cfg.Cipher = encryption.NewCipher(keys)
+		db := rbf.NewDB(dbPath, cfg)
+		if err := db.Open(); err != nil {
+			tb.Fatal(err)
+		}
+		defer db.Close()
+		tx, err := db.Begin(false)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		defer tx.Rollback()
+		if n, err := tx.Count("x"); err != nil || n != 3 {
+			tb.Fatalf("Count()=%d, %v", n, err)
+		}
+
+		for _, path := range storePaths {
+			if _, err := pilosa.OpenTranslateStore(path, "i", "", 0, 8, false); err != pilosa.ErrTranslateStoreEncrypted {
+				tb.Fatalf("unexpected error: %v", err)
+			}
+			s, err := pilosa.OpenEncryptedTranslateStore(cfg.Cipher)(path, "i", "", 0, 8, false)
+			if err != nil {
+				tb.Fatal(err)
+			}
+			if ids, err := s.FindKeys("foo"); err != nil || len(ids) != 1 {
+				tb.Fatalf("FindKeys()=%v, %v", ids, err)
+			} else if err := s.Close(); err != nil {
+				tb.Fatal(err)
+			}
+		}
+	}
+
+	if out := run(t); !strings.Contains(out, "added key 1") || !strings.Contains(out, "encrypted 1 RBF databases and 2 translate stores") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	verify(t)
+
+	// Rotating re-encrypts everything with the new key.
+	if out := run(t); !strings.Contains(out, "added key 2") {
+		t.Fatalf("unexpected output: %s", out)
+	}
+	verify(t)
+}
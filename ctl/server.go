@@ -36,6 +36,13 @@ func serverFlagSet(srv *server.Config, prefix string) *pflag.FlagSet {
 	flags.StringVar(&srv.ControllerAddress, pre("controller-address"), srv.ControllerAddress, "Controller service to register with.")
 	flags.StringVar(&srv.WriteloggerDir, pre("writelogger-dir"), srv.WriteloggerDir, "Writelogger directory to read/write append logs.")
 	flags.StringVar(&srv.SnapshotterDir, pre("snapshotter-dir"), srv.SnapshotterDir, "Snapshotter directory to read/write snapshots.")
+	flags.StringVar(&srv.DAXStore.Bucket, pre("dax-store.bucket"), srv.DAXStore.Bucket, "S3 bucket to read/write append logs and snapshots. Supersedes writelogger-dir and snapshotter-dir if set.")
+	flags.StringVar(&srv.DAXStore.Prefix, pre("dax-store.prefix"), srv.DAXStore.Prefix, "Prefix of append log and snapshot objects.")
+	flags.StringVar(&srv.DAXStore.Region, pre("dax-store.region"), srv.DAXStore.Region, "Region of the DAX store bucket.")
+	flags.StringVar(&srv.DAXStore.Endpoint, pre("dax-store.endpoint"), srv.DAXStore.Endpoint, "Endpoint of an S3-compatible object store.")
+	flags.StringVar(&srv.DAXStore.AccessKeyID, pre("dax-store.access-key-id"), srv.DAXStore.AccessKeyID, "Access key ID for the DAX store bucket.")
+	flags.StringVar(&srv.DAXStore.SecretAccessKey, pre("dax-store.secret-access-key"), srv.DAXStore.SecretAccessKey, "Secret access key for the DAX store bucket.")
+	flags.DurationVar((*time.Duration)(&srv.ShardReplicaInterval), pre("shard-replica-interval"), time.Duration(srv.ShardReplicaInterval), "Interval at which shards held as read-only replicas are refreshed from their write logs.")
 	flags.StringVarP(&srv.DataDir, pre("data-dir"), short("d"), srv.DataDir, "Directory to store FeatureBase data files.")
 	flags.StringVarP(&srv.Bind, pre("bind"), short("b"), srv.Bind, "Default URI on which FeatureBase should listen.")
 	flags.StringVar(&srv.BindGRPC, pre("bind-grpc"), srv.BindGRPC, "URI on which FeatureBase should listen for gRPC requests.")
@@ -49,6 +56,7 @@ func serverFlagSet(srv *server.Config, prefix string) *pflag.FlagSet {
 	flags.DurationVar((*time.Duration)(&srv.LongQueryTime), pre("long-query-time"), time.Duration(srv.LongQueryTime), "Duration that will trigger log and stat messages for slow queries. Zero to disable.")
 	flags.IntVar(&srv.QueryHistoryLength, pre("query-history-length"), srv.QueryHistoryLength, "Number of queries to remember in history.")
 	flags.Int64Var(&srv.MaxQueryMemory, pre("max-query-memory"), srv.MaxQueryMemory, "Maximum memory allowed per Extract() or SELECT query.")
+	flags.IntVar(&srv.QueryResultCacheSize, pre("query-result-cache-size"), srv.QueryResultCacheSize, "Maximum number of shard results cached for repeated queries. Caching is disabled if 0.")
 	flags.StringVar(&srv.VerChkAddress, pre("verchk-address"), srv.VerChkAddress, "Address to contact to check for latest version.")
 	flags.StringVar(&srv.UUIDFile, pre("uuid-file"), srv.UUIDFile, "File to store UUID used in checking latest version. If this is a relative path, the file will be stored in the server's data directory.")
 
@@ -112,6 +120,23 @@ func serverFlagSet(srv *server.Config, prefix string) *pflag.FlagSet {
 	// RBF specific flags. See pilosa/rbf/cfg/cfg.go for definitions.
 	srv.RBFConfig.DefineFlags(flags, prefix)
 
+	flags.StringVar(&srv.Encryption.KeyFile, pre("encryption.key-file"), srv.Encryption.KeyFile, "Path to a key file used to encrypt data at rest. Data is not encrypted if blank.")
+
+	// Read replica
+	flags.BoolVar(&srv.ReadReplica.Enabled, pre("read-replica.enabled"), srv.ReadReplica.Enabled, "Run the node as a read replica which serves queries from a copy of the cluster's data.")
+	flags.DurationVar((*time.Duration)(&srv.ReadReplica.MaxStaleness), pre("read-replica.max-staleness"), (time.Duration)(srv.ReadReplica.MaxStaleness), "Maximum age of a read replica's data before queries are forwarded to the nodes which own it.")
+	flags.DurationVar((*time.Duration)(&srv.ReadReplica.SyncInterval), pre("read-replica.sync-interval"), (time.Duration)(srv.ReadReplica.SyncInterval), "Interval at which a read replica copies changed shards.")
+
+	// Tiered storage
+	flags.DurationVar((*time.Duration)(&srv.Tiering.Age), pre("tiering.age"), (time.Duration)(srv.Tiering.Age), "Time without writes after which a shard is offloaded to the object store.")
+	flags.DurationVar((*time.Duration)(&srv.Tiering.Interval), pre("tiering.interval"), (time.Duration)(srv.Tiering.Interval), "Interval at which shards are checked for offloading.")
+	flags.StringVar(&srv.Tiering.Store.Bucket, pre("tiering.store.bucket"), srv.Tiering.Store.Bucket, "S3 bucket to offload cold shards to. Tiering is disabled if blank.")
+	flags.StringVar(&srv.Tiering.Store.Prefix, pre("tiering.store.prefix"), srv.Tiering.Store.Prefix, "Prefix of offloaded shard objects. The node name is appended.")
+	flags.StringVar(&srv.Tiering.Store.Region, pre("tiering.store.region"), srv.Tiering.Store.Region, "Region of the tiering bucket.")
+	flags.StringVar(&srv.Tiering.Store.Endpoint, pre("tiering.store.endpoint"), srv.Tiering.Store.Endpoint, "Endpoint of an S3-compatible object store.")
+	flags.StringVar(&srv.Tiering.Store.AccessKeyID, pre("tiering.store.access-key-id"), srv.Tiering.Store.AccessKeyID, "Access key ID for the tiering bucket.")
+	flags.StringVar(&srv.Tiering.Store.SecretAccessKey, pre("tiering.store.secret-access-key"), srv.Tiering.Store.SecretAccessKey, "Secret access key for the tiering bucket.")
+
 	pfalse := false
 	flags.BoolVar(&pfalse, pre("sql.endpoint-enabled"), false, "Enable FeatureBase SQL /sql endpoint (default false)")
 	flags.MarkDeprecated("sql.endpoint-enabled", "sql.endpoint-enabled is deprecated")
@@ -135,6 +160,15 @@ func serverFlagSet(srv *server.Config, prefix string) *pflag.FlagSet {
 	flags.StringVar(&srv.Auth.PermissionsFile, pre("auth.permissions"), srv.Auth.PermissionsFile, "Permissions' file with group authorization.")
 	flags.StringVar(&srv.Auth.QueryLogPath, pre("auth.query-log-path"), srv.Auth.QueryLogPath, "Path to log user queries")
 	flags.StringSliceVar(&srv.Auth.ConfiguredIPs, pre("auth.configured-ips"), srv.Auth.ConfiguredIPs, "List of configured IPs allowed for ingest")
+	flags.DurationVar((*time.Duration)(&srv.Auth.GrantsRefreshInterval), pre("auth.grants-refresh-interval"), time.Duration(srv.Auth.GrantsRefreshInterval), "Interval at which grants made through SQL are reloaded.")
+
+	// Audit log configuration
+	flags.StringVar(&srv.Audit.Path, pre("audit.path"), srv.Audit.Path, "Path of the audit log file. No file is written if blank.")
+	flags.StringVar(&srv.Audit.Key, pre("audit.key"), srv.Audit.Key, "Hex encoded key the audit log file is signed with. Required if audit.path is set.")
+	flags.Int64Var(&srv.Audit.MaxSize, pre("audit.max-size"), srv.Audit.MaxSize, "Size in bytes at which the audit log file is rotated. Zero disables rotation.")
+	flags.IntVar(&srv.Audit.MaxBackups, pre("audit.max-backups"), srv.Audit.MaxBackups, "Number of rotated audit log files to keep.")
+	flags.StringVar(&srv.Audit.SyslogNetwork, pre("audit.syslog-network"), srv.Audit.SyslogNetwork, "Network of the syslog socket audit events are sent to: udp, tcp, unix or unixgram.")
+	flags.StringVar(&srv.Audit.SyslogAddress, pre("audit.syslog-address"), srv.Audit.SyslogAddress, "Address of the syslog socket audit events are sent to. No events are sent if blank.")
 
 	flags.BoolVar(&srv.DataDog.Enable, pre("datadog.enable"), false, "enable continuous profiling with DataDog cloud service, Note you must have DataDog agent installed")
 	flags.BoolVar(&srv.DataDog.EnableTracing, pre("datadog.enable-tracing"), false, "Enable continuous tracing with DataDog cloud service, this flag is mutually exclusive to tracing.* parameters")
@@ -130,30 +130,42 @@ type CommandConfig struct {
 var serviceOffValue = "off"
 
 func newCommand(addr dax.Address, cfg CommandConfig) (*fbserver.Command, error) {
-	// Set up Writelogger.
+	// Set up Writelogger and Snapshotter. If an object store is configured,
+	// it's used for both in place of their directories.
 	var wlSvc computer.WritelogService
-	wlDirToCompare := strings.TrimSpace(strings.ToLower(cfg.ComputerConfig.WriteloggerDir))
-	switch wlDirToCompare {
-	case "":
-		return nil, errors.New(errors.ErrUncoded, "no writelogger directory configured")
-	case serviceOffValue:
-		wlSvc = computer.NewNopWritelogService()
-		cfg.Logger.Warnf("No writelogger configured, dynamic scaling will not function properly.")
-	default:
-		wlSvc = writelogger.New(cfg.ComputerConfig.WriteloggerDir, cfg.Logger)
-	}
-
-	// Set up Snapshotter.
 	var ssSvc computer.SnapshotService
-	ssDirToCompare := strings.TrimSpace(strings.ToLower(cfg.ComputerConfig.SnapshotterDir))
-	switch ssDirToCompare {
-	case "":
-		return nil, errors.New(errors.ErrUncoded, "no snapshotter directory configured")
-	case serviceOffValue:
-		ssSvc = computer.NewNopSnapshotterService()
-		cfg.Logger.Warnf("No snapshotter configured, dynamic scaling will not function properly.")
-	default:
-		ssSvc = snapshotter.New(cfg.ComputerConfig.SnapshotterDir, cfg.Logger)
+	if cfg.ComputerConfig.DAXStore.Bucket != "" {
+		wl, err := writelogger.NewS3(cfg.ComputerConfig.DAXStore, cfg.Logger)
+		if err != nil {
+			return nil, errors.Wrap(err, "setting up writelogger")
+		}
+		ss, err := snapshotter.NewS3(cfg.ComputerConfig.DAXStore, cfg.Logger)
+		if err != nil {
+			return nil, errors.Wrap(err, "setting up snapshotter")
+		}
+		wlSvc, ssSvc = wl, ss
+	} else {
+		wlDirToCompare := strings.TrimSpace(strings.ToLower(cfg.ComputerConfig.WriteloggerDir))
+		switch wlDirToCompare {
+		case "":
+			return nil, errors.New(errors.ErrUncoded, "no writelogger directory configured")
+		case serviceOffValue:
+			wlSvc = computer.NewNopWritelogService()
+			cfg.Logger.Warnf("No writelogger configured, dynamic scaling will not function properly.")
+		default:
+			wlSvc = writelogger.New(cfg.ComputerConfig.WriteloggerDir, cfg.Logger)
+		}
+
+		ssDirToCompare := strings.TrimSpace(strings.ToLower(cfg.ComputerConfig.SnapshotterDir))
+		switch ssDirToCompare {
+		case "":
+			return nil, errors.New(errors.ErrUncoded, "no snapshotter directory configured")
+		case serviceOffValue:
+			ssSvc = computer.NewNopSnapshotterService()
+			cfg.Logger.Warnf("No snapshotter configured, dynamic scaling will not function properly.")
+		default:
+			ssSvc = snapshotter.New(cfg.ComputerConfig.SnapshotterDir, cfg.Logger)
+		}
 	}
 
 	// Set the FeatureBase.Config values based on the top-level Config
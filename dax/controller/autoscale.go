@@ -0,0 +1,113 @@
+package controller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/autoscaler"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// Ensure type implements interface.
+var _ autoscaler.Cluster = (*Controller)(nil)
+var _ dax.QueryStatsRecorder = (*Controller)(nil)
+
+// AutoscalerState returns the workers and jobs for each role type, across all
+// databases, on which the autoscaler bases its decisions.
+func (c *Controller) AutoscalerState(ctx context.Context) (*autoscaler.State, error) {
+	tx, err := c.Transactor.BeginTx(ctx, false)
+	if err != nil {
+		return nil, errors.Wrap(err, "beginning tx")
+	}
+	defer tx.Rollback()
+
+	nodes, err := c.Balancer.Nodes(tx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting nodes")
+	}
+
+	qdbs, err := c.Schemar.Databases(tx, "")
+	if err != nil {
+		return nil, errors.Wrap(err, "getting databases")
+	}
+
+	state := &autoscaler.State{
+		Roles: make(map[dax.RoleType]autoscaler.RoleState),
+	}
+	for _, rt := range supportedRoleTypes {
+		rs := autoscaler.RoleState{
+			FreeWorkers: dax.Addresses{},
+		}
+
+		assigned := NewAddressSet()
+		for _, qdb := range qdbs {
+			qdbid := qdb.QualifiedID()
+
+			workers, err := c.Balancer.CurrentState(tx, rt, qdbid)
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting current state: (%s) %s", rt, qdbid)
+			}
+			freeJobs, err := c.Balancer.FreeJobs(tx, rt, qdbid)
+			if err != nil {
+				return nil, errors.Wrapf(err, "getting free jobs: (%s) %s", rt, qdbid)
+			}
+
+			jobs := len(freeJobs)
+			for _, w := range workers {
+				assigned.Add(w.Address)
+				jobs += len(w.Jobs)
+			}
+			rs.Jobs += jobs
+
+			// Databases aren't assigned workers until they have jobs.
+			if jobs > 0 && qdb.Options.WorkersMin > len(workers) {
+				rs.WorkersNeeded += qdb.Options.WorkersMin - len(workers)
+			}
+		}
+
+		for _, node := range nodes {
+			if !hasRoleType(node, rt) {
+				continue
+			}
+			rs.Workers++
+			if !assigned.Contains(node.Address) {
+				rs.FreeWorkers = append(rs.FreeWorkers, node.Address)
+			}
+		}
+		sort.Sort(rs.FreeWorkers)
+
+		state.Roles[rt] = rs
+	}
+
+	return state, nil
+}
+
+// hasRoleType returns true if the node is able to fill roleType.
+func hasRoleType(node *dax.Node, roleType dax.RoleType) bool {
+	for _, rt := range node.RoleTypes {
+		if rt == roleType {
+			return true
+		}
+	}
+	return false
+}
+
+// RecordQueryStats records the stats reported by a queryer so that the
+// autoscaler can take query latency into account. The stats are dropped if
+// autoscaling isn't enabled.
+func (c *Controller) RecordQueryStats(ctx context.Context, stats dax.QueryStats) error {
+	if c.autoscaler != nil {
+		c.autoscaler.RecordQueryStats(stats)
+	}
+	return nil
+}
+
+// AutoscalerHistory returns the scaling events which the autoscaler has
+// recorded, oldest first.
+func (c *Controller) AutoscalerHistory(ctx context.Context) ([]autoscaler.Event, error) {
+	if c.autoscaler == nil {
+		return nil, errors.New(errors.ErrUncoded, "autoscaler is not enabled")
+	}
+	return c.autoscaler.History(), nil
+}
@@ -0,0 +1,325 @@
+// Package autoscaler provides the Autoscaler, which adds and removes DAX
+// workers according to a set of policies.
+package autoscaler
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+const (
+	defaultInterval    = time.Minute
+	defaultHistorySize = 100
+)
+
+type Config struct {
+	Cluster  Cluster
+	Provider Provider
+	Policies []Policy
+
+	// Interval is the period on which the policies are evaluated.
+	Interval time.Duration
+
+	// Cooldown is the time, after workers have been added or removed, during
+	// which no further change is made. This gives new workers time to
+	// register and be assigned jobs before the policies are applied again.
+	Cooldown time.Duration
+
+	// MaxWorkers, if non-zero, is the most workers of any one role type which
+	// the autoscaler will scale up to.
+	MaxWorkers int
+
+	// HistorySize is the number of scaling events retained by History.
+	HistorySize int
+
+	Logger logger.Logger
+}
+
+// State is the state of the cluster on which the policies base their
+// decisions.
+type State struct {
+	Roles map[dax.RoleType]RoleState `json:"roles"`
+
+	// QueryStats are the stats reported by the queryers since the policies
+	// were last evaluated.
+	QueryStats dax.QueryStats `json:"query-stats"`
+}
+
+// RoleState is the state of the workers for a single role type.
+type RoleState struct {
+	// Workers is the number of workers able to fill the role type.
+	Workers int `json:"workers"`
+
+	// FreeWorkers are the workers which aren't assigned to a database.
+	FreeWorkers dax.Addresses `json:"free-workers"`
+
+	// Jobs is the number of jobs, assigned or not, across all databases.
+	Jobs int `json:"jobs"`
+
+	// WorkersNeeded is the number of workers which databases with jobs need in
+	// order to reach their minimum number of workers.
+	WorkersNeeded int `json:"workers-needed"`
+}
+
+// Decision is a change in the number of workers for a role type.
+type Decision struct {
+	RoleType dax.RoleType `json:"role-type"`
+	Delta    int          `json:"delta"`
+	Reason   string       `json:"reason"`
+}
+
+// Event records a scaling action taken by the autoscaler.
+type Event struct {
+	Time     time.Time    `json:"time"`
+	RoleType dax.RoleType `json:"role-type"`
+	Delta    int          `json:"delta"`
+	Policy   string       `json:"policy"`
+	Reason   string       `json:"reason"`
+
+	// Addresses are the workers which were removed, if any.
+	Addresses dax.Addresses `json:"addresses,omitempty"`
+
+	// Error is set if the Provider failed to make the change.
+	Error string `json:"error,omitempty"`
+}
+
+// Autoscaler periodically evaluates its policies against the state of the
+// cluster, and calls on its Provider to add or remove workers accordingly.
+type Autoscaler struct {
+	mu sync.Mutex
+
+	cluster  Cluster
+	provider Provider
+	policies []Policy
+
+	interval   time.Duration
+	cooldown   time.Duration
+	maxWorkers int
+
+	// lastScaled is the time at which workers were last added or removed.
+	lastScaled time.Time
+
+	// queryStats accumulates the query stats reported since the last
+	// evaluation.
+	queryStats dax.QueryStats
+
+	history     []Event
+	historySize int
+
+	now func() time.Time
+
+	logger logger.Logger
+}
+
+// New returns a new instance of Autoscaler with default values.
+func New(cfg Config) *Autoscaler {
+	a := &Autoscaler{
+		provider:    NewNopProvider(),
+		interval:    defaultInterval,
+		cooldown:    cfg.Cooldown,
+		maxWorkers:  cfg.MaxWorkers,
+		historySize: defaultHistorySize,
+		now:         time.Now,
+		logger:      logger.NopLogger,
+	}
+
+	a.cluster = cfg.Cluster
+	a.policies = cfg.Policies
+	if cfg.Provider != nil {
+		a.provider = cfg.Provider
+	}
+	if cfg.Interval != 0 {
+		a.interval = cfg.Interval
+	}
+	if cfg.HistorySize != 0 {
+		a.historySize = cfg.HistorySize
+	}
+	if cfg.Logger != nil {
+		a.logger = cfg.Logger
+	}
+
+	return a
+}
+
+// Run evaluates the policies on every interval until stopping is closed.
+func (a *Autoscaler) Run(stopping <-chan struct{}) error {
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopping:
+			return nil
+		case <-ticker.C:
+		}
+
+		if err := a.Evaluate(context.Background()); err != nil {
+			a.logger.Printf("autoscaler: %v", err)
+		}
+	}
+}
+
+// RecordQueryStats adds stats reported by a queryer to those which will be
+// considered at the next evaluation.
+func (a *Autoscaler) RecordQueryStats(stats dax.QueryStats) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.queryStats.Merge(stats)
+}
+
+// History returns the scaling events which have occurred, oldest first.
+func (a *Autoscaler) History() []Event {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	out := make([]Event, len(a.history))
+	copy(out, a.history)
+	return out
+}
+
+// Evaluate evaluates the policies once, and adds or removes workers as they
+// call for. Workers typically fill every role type, so workers are added or
+// removed for at most one role type per evaluation. Workers are only ever
+// removed from those which aren't assigned to a database.
+func (a *Autoscaler) Evaluate(ctx context.Context) error {
+	if a.cluster == nil {
+		return errors.New(errors.ErrUncoded, "autoscaler requires a cluster")
+	}
+
+	state, err := a.cluster.AutoscalerState(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting cluster state")
+	}
+
+	a.mu.Lock()
+	state.QueryStats = a.queryStats
+	a.queryStats = dax.QueryStats{}
+	a.mu.Unlock()
+
+	// Gather the decisions for each role type.
+	decisions := make(map[dax.RoleType][]policyDecision)
+	for _, p := range a.policies {
+		for _, d := range p.Evaluate(state) {
+			if d.Delta == 0 {
+				continue
+			}
+			decisions[d.RoleType] = append(decisions[d.RoleType], policyDecision{
+				policy:   p.Name(),
+				Decision: d,
+			})
+		}
+	}
+
+	roleTypes := make([]dax.RoleType, 0, len(decisions))
+	for rt := range decisions {
+		roleTypes = append(roleTypes, rt)
+	}
+	sort.Slice(roleTypes, func(i, j int) bool { return roleTypes[i] < roleTypes[j] })
+
+	now := a.now()
+	if !a.lastScaled.IsZero() && now.Sub(a.lastScaled) < a.cooldown {
+		return nil
+	}
+
+	for _, rt := range roleTypes {
+		pd := combine(decisions[rt])
+		if ev, ok := a.scale(ctx, state.Roles[rt], pd); ok {
+			ev.Time = now
+			a.lastScaled = now
+			a.record(ev)
+			break
+		}
+	}
+
+	return nil
+}
+
+// policyDecision is a Decision along with the policy which made it.
+type policyDecision struct {
+	Decision
+	policy string
+}
+
+// combine chooses a single decision from those made for a role type. Adding
+// workers takes precedence over removing them, so the largest increase is
+// chosen if there is one. Otherwise, the smallest decrease is chosen.
+func combine(pds []policyDecision) policyDecision {
+	best := pds[0]
+	for _, pd := range pds[1:] {
+		switch {
+		case pd.Delta > 0 && pd.Delta > best.Delta:
+			best = pd
+		case pd.Delta < 0 && best.Delta < 0 && pd.Delta > best.Delta:
+			best = pd
+		}
+	}
+	return best
+}
+
+// scale carries out the decision. It returns false if, once limits have been
+// applied, there was nothing to do.
+func (a *Autoscaler) scale(ctx context.Context, rs RoleState, pd policyDecision) (Event, bool) {
+	ev := Event{
+		RoleType: pd.RoleType,
+		Policy:   pd.policy,
+		Reason:   pd.Reason,
+	}
+
+	if pd.Delta > 0 {
+		n := pd.Delta
+		if a.maxWorkers > 0 && rs.Workers+n > a.maxWorkers {
+			n = a.maxWorkers - rs.Workers
+		}
+		if n <= 0 {
+			return ev, false
+		}
+		ev.Delta = n
+
+		if err := a.provider.AddWorkers(ctx, pd.RoleType, n); err != nil {
+			ev.Error = err.Error()
+		}
+		return ev, true
+	}
+
+	// Only remove free workers which no database is waiting for.
+	n := -pd.Delta
+	if removable := len(rs.FreeWorkers) - rs.WorkersNeeded; n > removable {
+		n = removable
+	}
+	if n <= 0 {
+		return ev, false
+	}
+	ev.Delta = -n
+	ev.Addresses = rs.FreeWorkers[len(rs.FreeWorkers)-n:]
+
+	if err := a.provider.RemoveWorkers(ctx, pd.RoleType, ev.Addresses...); err != nil {
+		ev.Error = err.Error()
+	} else if err := a.cluster.DeregisterNodes(ctx, ev.Addresses...); err != nil {
+		ev.Error = errors.Wrap(err, "deregistering removed workers").Error()
+	}
+	return ev, true
+}
+
+// record adds ev to the history, dropping the oldest event if the history is
+// full.
+func (a *Autoscaler) record(ev Event) {
+	if ev.Error != "" {
+		a.logger.Printf("autoscaler: scaling %s by %d (%s): %s", ev.RoleType, ev.Delta, ev.Reason, ev.Error)
+	} else {
+		a.logger.Infof("autoscaler: scaled %s by %d (%s)", ev.RoleType, ev.Delta, ev.Reason)
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.history = append(a.history, ev)
+	if over := len(a.history) - a.historySize; over > 0 {
+		a.history = append(a.history[:0], a.history[over:]...)
+	}
+}
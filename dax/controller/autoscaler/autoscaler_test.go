@@ -0,0 +1,259 @@
+package autoscaler_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/autoscaler"
+)
+
+// testCluster is an autoscaler.Cluster with a fixed state.
+type testCluster struct {
+	state        autoscaler.State
+	deregistered dax.Addresses
+}
+
+func (c *testCluster) AutoscalerState(ctx context.Context) (*autoscaler.State, error) {
+	s := c.state
+	return &s, nil
+}
+
+func (c *testCluster) DeregisterNodes(ctx context.Context, addrs ...dax.Address) error {
+	c.deregistered = append(c.deregistered, addrs...)
+	return nil
+}
+
+// testProvider is an autoscaler.Provider which records the changes asked of
+// it.
+type testProvider struct {
+	added   int
+	removed dax.Addresses
+}
+
+func (p *testProvider) AddWorkers(ctx context.Context, roleType dax.RoleType, n int) error {
+	p.added += n
+	return nil
+}
+
+func (p *testProvider) RemoveWorkers(ctx context.Context, roleType dax.RoleType, addrs ...dax.Address) error {
+	p.removed = append(p.removed, addrs...)
+	return nil
+}
+
+func TestAutoscaler(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("ScaleUp", func(t *testing.T) {
+		cluster := &testCluster{
+			state: autoscaler.State{
+				Roles: map[dax.RoleType]autoscaler.RoleState{
+					dax.RoleTypeCompute: {Workers: 2, Jobs: 10},
+				},
+			},
+		}
+		provider := &testProvider{}
+		a := autoscaler.New(autoscaler.Config{
+			Cluster:  cluster,
+			Provider: provider,
+			Policies: []autoscaler.Policy{
+				&autoscaler.JobsPerWorker{RoleType: dax.RoleTypeCompute, Target: 4},
+				&autoscaler.FreeWorkers{RoleType: dax.RoleTypeCompute, Min: 2},
+			},
+			Cooldown: time.Hour,
+		})
+
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		// The free-workers policy wants 2 more workers, which beats the 1
+		// wanted by jobs-per-worker.
+		if provider.added != 2 {
+			t.Fatalf("expected 2 workers added, got %d", provider.added)
+		}
+		history := a.History()
+		if len(history) != 1 {
+			t.Fatalf("expected 1 event, got %+v", history)
+		} else if ev := history[0]; ev.Delta != 2 || ev.Policy != "free-workers" || ev.RoleType != dax.RoleTypeCompute {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+
+		// Nothing changes during the cooldown.
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		} else if provider.added != 2 || len(a.History()) != 1 {
+			t.Fatalf("expected no change during cooldown, got %d added, %+v", provider.added, a.History())
+		}
+	})
+
+	t.Run("MaxWorkers", func(t *testing.T) {
+		cluster := &testCluster{
+			state: autoscaler.State{
+				Roles: map[dax.RoleType]autoscaler.RoleState{
+					dax.RoleTypeCompute: {Workers: 2, Jobs: 100},
+				},
+			},
+		}
+		provider := &testProvider{}
+		a := autoscaler.New(autoscaler.Config{
+			Cluster:    cluster,
+			Provider:   provider,
+			Policies:   []autoscaler.Policy{&autoscaler.JobsPerWorker{RoleType: dax.RoleTypeCompute, Target: 10}},
+			MaxWorkers: 5,
+		})
+
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		} else if provider.added != 3 {
+			t.Fatalf("expected 3 workers added, got %d", provider.added)
+		}
+
+		// At the maximum, nothing is added or recorded.
+		cluster.state.Roles[dax.RoleTypeCompute] = autoscaler.RoleState{Workers: 5, Jobs: 100}
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		} else if provider.added != 3 || len(a.History()) != 1 {
+			t.Fatalf("expected no change at max workers, got %d added, %+v", provider.added, a.History())
+		}
+	})
+
+	t.Run("ScaleDownFreeWorkersOnly", func(t *testing.T) {
+		cluster := &testCluster{
+			state: autoscaler.State{
+				Roles: map[dax.RoleType]autoscaler.RoleState{
+					dax.RoleTypeCompute: {
+						Workers:       6,
+						FreeWorkers:   dax.Addresses{"w4", "w5", "w6"},
+						Jobs:          2,
+						WorkersNeeded: 1,
+					},
+				},
+			},
+		}
+		provider := &testProvider{}
+		a := autoscaler.New(autoscaler.Config{
+			Cluster:  cluster,
+			Provider: provider,
+			Policies: []autoscaler.Policy{&autoscaler.JobsPerWorker{RoleType: dax.RoleTypeCompute, Target: 2}},
+		})
+
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		}
+
+		// Jobs-per-worker wants 5 fewer workers, but only two of the free
+		// workers aren't needed by a database.
+		exp := dax.Addresses{"w5", "w6"}
+		if !reflect.DeepEqual(provider.removed, exp) {
+			t.Fatalf("expected removed %v, got %v", exp, provider.removed)
+		} else if !reflect.DeepEqual(cluster.deregistered, exp) {
+			t.Fatalf("expected deregistered %v, got %v", exp, cluster.deregistered)
+		} else if ev := a.History()[0]; ev.Delta != -2 || !reflect.DeepEqual(ev.Addresses, exp) {
+			t.Fatalf("unexpected event: %+v", ev)
+		}
+	})
+
+	t.Run("QueryLatency", func(t *testing.T) {
+		cluster := &testCluster{
+			state: autoscaler.State{
+				Roles: map[dax.RoleType]autoscaler.RoleState{
+					dax.RoleTypeCompute: {Workers: 2},
+				},
+			},
+		}
+		provider := &testProvider{}
+		a := autoscaler.New(autoscaler.Config{
+			Cluster:  cluster,
+			Provider: provider,
+			Policies: []autoscaler.Policy{&autoscaler.QueryLatency{High: time.Second, Step: 2}},
+		})
+
+		// No queries, no opinion.
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		} else if provider.added != 0 {
+			t.Fatalf("expected no workers added, got %d", provider.added)
+		}
+
+		a.RecordQueryStats(dax.QueryStats{Queries: 2, Duration: 3 * time.Second, Max: 2 * time.Second})
+		a.RecordQueryStats(dax.QueryStats{Queries: 1, Duration: 3 * time.Second, Max: 3 * time.Second})
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		} else if provider.added != 2 {
+			t.Fatalf("expected 2 workers added, got %d", provider.added)
+		}
+
+		// The stats are consumed by each evaluation.
+		if err := a.Evaluate(ctx); err != nil {
+			t.Fatal(err)
+		} else if provider.added != 2 {
+			t.Fatalf("expected no more workers added, got %d", provider.added)
+		}
+	})
+
+	t.Run("HistorySize", func(t *testing.T) {
+		cluster := &testCluster{
+			state: autoscaler.State{
+				Roles: map[dax.RoleType]autoscaler.RoleState{
+					dax.RoleTypeCompute: {Workers: 1, Jobs: 2},
+				},
+			},
+		}
+		a := autoscaler.New(autoscaler.Config{
+			Cluster:     cluster,
+			Provider:    &testProvider{},
+			Policies:    []autoscaler.Policy{&autoscaler.JobsPerWorker{RoleType: dax.RoleTypeCompute, Target: 1}},
+			HistorySize: 2,
+		})
+
+		for i := 0; i < 3; i++ {
+			if err := a.Evaluate(ctx); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if history := a.History(); len(history) != 2 {
+			t.Fatalf("expected 2 events, got %+v", history)
+		}
+	})
+}
+
+func TestPolicies(t *testing.T) {
+	state := &autoscaler.State{
+		Roles: map[dax.RoleType]autoscaler.RoleState{
+			dax.RoleTypeCompute: {
+				Workers:       4,
+				FreeWorkers:   dax.Addresses{"a", "b", "c"},
+				Jobs:          9,
+				WorkersNeeded: 1,
+			},
+		},
+		QueryStats: dax.QueryStats{Queries: 4, Duration: 400 * time.Millisecond},
+	}
+
+	tests := []struct {
+		policy autoscaler.Policy
+		delta  int
+	}{
+		{&autoscaler.JobsPerWorker{RoleType: dax.RoleTypeCompute, Target: 3}, -1},
+		{&autoscaler.JobsPerWorker{RoleType: dax.RoleTypeCompute, Target: 2}, 1},
+		{&autoscaler.JobsPerWorker{RoleType: dax.RoleTypeCompute, Target: 9}, -3},
+		{&autoscaler.QueryLatency{High: 50 * time.Millisecond}, 1},
+		{&autoscaler.QueryLatency{High: time.Second, Low: 200 * time.Millisecond, Step: 2}, -2},
+		{&autoscaler.QueryLatency{High: time.Second}, 0},
+		{&autoscaler.FreeWorkers{RoleType: dax.RoleTypeCompute, Min: 3}, 1},
+		{&autoscaler.FreeWorkers{RoleType: dax.RoleTypeCompute, Min: 1, Max: 1}, -1},
+		{&autoscaler.FreeWorkers{RoleType: dax.RoleTypeCompute, Min: 2}, 0},
+	}
+	for i, test := range tests {
+		var delta int
+		for _, d := range test.policy.Evaluate(state) {
+			delta += d.Delta
+		}
+		if delta != test.delta {
+			t.Errorf("test %d (%s): expected delta %d, got %d", i, test.policy.Name(), test.delta, delta)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package autoscaler
+
+import (
+	"context"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// Provider is an interface to anything which is able to start and stop
+// workers. Workers started by a Provider are expected to register themselves
+// with the controller, just like any other worker.
+type Provider interface {
+	// AddWorkers starts n new workers capable of filling roleType.
+	AddWorkers(ctx context.Context, roleType dax.RoleType, n int) error
+
+	// RemoveWorkers stops the workers at the given addresses.
+	RemoveWorkers(ctx context.Context, roleType dax.RoleType, addrs ...dax.Address) error
+}
+
+// Cluster is an interface to the cluster being scaled. It is typically
+// implemented by the controller.
+type Cluster interface {
+	// AutoscalerState returns the current worker and job counts for each
+	// role type.
+	AutoscalerState(ctx context.Context) (*State, error)
+
+	// DeregisterNodes removes workers which the autoscaler has stopped from
+	// the cluster.
+	DeregisterNodes(ctx context.Context, addrs ...dax.Address) error
+}
+
+// Policy is an interface to anything which decides, based on the state of the
+// cluster, whether workers should be added or removed.
+type Policy interface {
+	// Name identifies the policy in the autoscaler's history.
+	Name() string
+
+	// Evaluate returns the change in the number of workers, for each role
+	// type, which the policy calls for. A policy which is satisfied with the
+	// current number of workers for a role type returns no Decision for it.
+	Evaluate(s *State) []Decision
+}
+
+// Ensure type implements interface.
+var _ Provider = (*NopProvider)(nil)
+
+// NopProvider is a no-op implementation of the Provider interface.
+type NopProvider struct{}
+
+func NewNopProvider() *NopProvider {
+	return &NopProvider{}
+}
+
+func (p *NopProvider) AddWorkers(ctx context.Context, roleType dax.RoleType, n int) error {
+	return nil
+}
+func (p *NopProvider) RemoveWorkers(ctx context.Context, roleType dax.RoleType, addrs ...dax.Address) error {
+	return nil
+}
@@ -0,0 +1,155 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+// Ensure type implements interface.
+var _ Provider = (*LocalProvider)(nil)
+
+// LocalProvider is a Provider which starts each worker as a process on the
+// local machine. It's intended for testing autoscaling policies rather than
+// for production use.
+//
+// Each worker is started with the provider's command, in which the
+// placeholders "{port}" and "{role}" are replaced by the port on which the
+// worker should listen and the role type it's being started for. Ports are
+// handed out sequentially, starting at the provider's base port, and are
+// assumed to be free.
+type LocalProvider struct {
+	mu sync.Mutex
+
+	command  []string
+	host     string
+	nextPort int
+
+	// procs holds the running workers, by the address at which they will
+	// register.
+	procs map[dax.Address]*exec.Cmd
+
+	logger logger.Logger
+}
+
+// NewLocalProvider returns a LocalProvider which runs command for each worker,
+// giving each worker a port starting at basePort.
+func NewLocalProvider(command []string, host string, basePort int, log logger.Logger) *LocalProvider {
+	if log == nil {
+		log = logger.NopLogger
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	return &LocalProvider{
+		command:  command,
+		host:     host,
+		nextPort: basePort,
+		procs:    make(map[dax.Address]*exec.Cmd),
+		logger:   log,
+	}
+}
+
+// AddWorkers starts n worker processes.
+func (p *LocalProvider) AddWorkers(ctx context.Context, roleType dax.RoleType, n int) error {
+	if len(p.command) == 0 {
+		return errors.New(errors.ErrUncoded, "local provider has no command")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i := 0; i < n; i++ {
+		port := p.nextPort
+		p.nextPort++
+
+		args := make([]string, len(p.command))
+		for j, arg := range p.command {
+			arg = strings.ReplaceAll(arg, "{port}", strconv.Itoa(port))
+			arg = strings.ReplaceAll(arg, "{role}", string(roleType))
+			args[j] = arg
+		}
+
+		cmd := exec.Command(args[0], args[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			return errors.Wrapf(err, "starting worker: %v", args)
+		}
+
+		addr := dax.Address(fmt.Sprintf("%s:%d/%s", p.host, port, dax.ServicePrefixComputer))
+		p.procs[addr] = cmd
+		p.logger.Infof("local provider: started worker %s (pid %d)", addr, cmd.Process.Pid)
+
+		// Reap the process whenever it exits.
+		go func() {
+			err := cmd.Wait()
+			p.logger.Infof("local provider: worker %s exited: %v", addr, err)
+
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			if p.procs[addr] == cmd {
+				delete(p.procs, addr)
+			}
+		}()
+	}
+
+	return nil
+}
+
+// RemoveWorkers interrupts the worker processes at the given addresses. It
+// returns an error for any address which it didn't start, or which has
+// already exited.
+func (p *LocalProvider) RemoveWorkers(ctx context.Context, roleType dax.RoleType, addrs ...dax.Address) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, addr := range addrs {
+		cmd, ok := p.procs[addr]
+		if !ok {
+			return errors.Errorf("no running worker: %s", addr)
+		}
+		if err := cmd.Process.Signal(os.Interrupt); err != nil {
+			return errors.Wrapf(err, "interrupting worker: %s", addr)
+		}
+		delete(p.procs, addr)
+	}
+
+	return nil
+}
+
+// Addresses returns the addresses of the running workers.
+func (p *LocalProvider) Addresses() dax.Addresses {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	addrs := make(dax.Addresses, 0, len(p.procs))
+	for addr := range p.procs {
+		addrs = append(addrs, addr)
+	}
+	sort.Sort(addrs)
+	return addrs
+}
+
+// Close kills any workers which are still running.
+func (p *LocalProvider) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for addr, cmd := range p.procs {
+		if err := cmd.Process.Kill(); err != nil {
+			p.logger.Printf("local provider: killing worker %s: %v", addr, err)
+		}
+		delete(p.procs, addr)
+	}
+	return nil
+}
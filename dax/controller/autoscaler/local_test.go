@@ -0,0 +1,40 @@
+package autoscaler_test
+
+import (
+	"context"
+	"os/exec"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/autoscaler"
+)
+
+func TestLocalProvider(t *testing.T) {
+	if _, err := exec.LookPath("sleep"); err != nil {
+		t.Skip("sleep not available")
+	}
+	ctx := context.Background()
+
+	p := autoscaler.NewLocalProvider([]string{"sleep", "60"}, "", 9100, nil)
+	defer p.Close()
+
+	if err := p.AddWorkers(ctx, dax.RoleTypeCompute, 2); err != nil {
+		t.Fatal(err)
+	}
+	exp := dax.Addresses{"localhost:9100/computer", "localhost:9101/computer"}
+	if addrs := p.Addresses(); len(addrs) != 2 || addrs[0] != exp[0] || addrs[1] != exp[1] {
+		t.Fatalf("expected addresses %v, got %v", exp, addrs)
+	}
+
+	if err := p.RemoveWorkers(ctx, dax.RoleTypeCompute, exp[0]); err != nil {
+		t.Fatal(err)
+	}
+	if addrs := p.Addresses(); len(addrs) != 1 || addrs[0] != exp[1] {
+		t.Fatalf("expected addresses %v, got %v", exp[1:], addrs)
+	}
+
+	// A worker which isn't running can't be removed.
+	if err := p.RemoveWorkers(ctx, dax.RoleTypeCompute, exp[0]); err == nil {
+		t.Fatal("expected error removing worker twice")
+	}
+}
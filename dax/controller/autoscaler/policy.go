@@ -0,0 +1,115 @@
+package autoscaler
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// Ensure type implements interface.
+var _ Policy = (*JobsPerWorker)(nil)
+var _ Policy = (*QueryLatency)(nil)
+var _ Policy = (*FreeWorkers)(nil)
+
+// JobsPerWorker calls for enough workers of a role type that each of them has
+// at most Target jobs.
+type JobsPerWorker struct {
+	RoleType dax.RoleType
+	Target   int
+}
+
+func (p *JobsPerWorker) Name() string {
+	return "jobs-per-worker"
+}
+
+func (p *JobsPerWorker) Evaluate(s *State) []Decision {
+	if p.Target <= 0 {
+		return nil
+	}
+	rs := s.Roles[p.RoleType]
+
+	want := (rs.Jobs + p.Target - 1) / p.Target
+	if delta := want - rs.Workers; delta != 0 {
+		return []Decision{{
+			RoleType: p.RoleType,
+			Delta:    delta,
+			Reason:   fmt.Sprintf("%d jobs need %d workers at %d jobs per worker, have %d", rs.Jobs, want, p.Target, rs.Workers),
+		}}
+	}
+	return nil
+}
+
+// QueryLatency adds Step compute workers when the mean query latency reported
+// by the queryers exceeds High, and removes Step compute workers when it falls
+// below Low. A Low of zero never removes workers.
+type QueryLatency struct {
+	High time.Duration
+	Low  time.Duration
+	Step int
+}
+
+func (p *QueryLatency) Name() string {
+	return "query-latency"
+}
+
+func (p *QueryLatency) Evaluate(s *State) []Decision {
+	if s.QueryStats.Queries == 0 {
+		return nil
+	}
+	step := p.Step
+	if step <= 0 {
+		step = 1
+	}
+
+	mean := s.QueryStats.Mean()
+	switch {
+	case p.High > 0 && mean > p.High:
+		return []Decision{{
+			RoleType: dax.RoleTypeCompute,
+			Delta:    step,
+			Reason:   fmt.Sprintf("mean query latency %s is above %s", mean, p.High),
+		}}
+	case mean < p.Low:
+		return []Decision{{
+			RoleType: dax.RoleTypeCompute,
+			Delta:    -step,
+			Reason:   fmt.Sprintf("mean query latency %s is below %s", mean, p.Low),
+		}}
+	}
+	return nil
+}
+
+// FreeWorkers keeps between Min and Max workers of a role type free, once the
+// workers which databases need in order to reach their minimum have been
+// accounted for. A Max of zero never removes workers.
+type FreeWorkers struct {
+	RoleType dax.RoleType
+	Min      int
+	Max      int
+}
+
+func (p *FreeWorkers) Name() string {
+	return "free-workers"
+}
+
+func (p *FreeWorkers) Evaluate(s *State) []Decision {
+	rs := s.Roles[p.RoleType]
+
+	free := len(rs.FreeWorkers) - rs.WorkersNeeded
+	switch {
+	case free < p.Min:
+		return []Decision{{
+			RoleType: p.RoleType,
+			Delta:    p.Min - free,
+			Reason:   fmt.Sprintf("%d free workers (%d needed by databases), want at least %d", len(rs.FreeWorkers), rs.WorkersNeeded, p.Min),
+		}}
+	case p.Max > 0 && free > p.Max:
+		return []Decision{{
+			RoleType: p.RoleType,
+			Delta:    p.Max - free,
+			Reason:   fmt.Sprintf("%d free workers (%d needed by databases), want at most %d", len(rs.FreeWorkers), rs.WorkersNeeded, p.Max),
+		}}
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/poller"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// Ensure type implements interface.
+var _ poller.LoadRecorder = (*Controller)(nil)
+
+// RecordLoad records the load reported by the worker at addr so that the
+// Balancer can take it into account the next time it balances.
+func (c *Controller) RecordLoad(ctx context.Context, addr dax.Address, load *dax.WorkerLoad) error {
+	if load == nil {
+		return nil
+	}
+
+	// The worker reports load by shard, but it may hold any replica of the
+	// shard, so look up which job it holds for each one.
+	tx, err := c.Transactor.BeginTx(ctx, false)
+	if err != nil {
+		return errors.Wrap(err, "beginning tx")
+	}
+	defer tx.Rollback()
+
+	w, err := c.Balancer.WorkerState(tx, dax.RoleTypeCompute, addr)
+	if err != nil {
+		return errors.Wrapf(err, "getting worker state: %s", addr)
+	}
+	held := make(map[dax.Job]dax.Job, len(w.Jobs))
+	for _, job := range w.Jobs {
+		base, r := job.ReplicaOf()
+		if _, ok := held[base]; !ok || r == 0 {
+			held[base] = job
+		}
+	}
+
+	jobLoads := make([]dax.JobLoad, 0, len(load.Shards))
+	for _, sl := range load.Shards {
+		job := shard(sl.TableKey, sl.Shard).Job()
+		if h, ok := held[job]; ok {
+			job = h
+		}
+		jobLoads = append(jobLoads, dax.JobLoad{
+			Job:     job,
+			Bytes:   sl.Bytes,
+			Queries: sl.Queries,
+		})
+	}
+	c.Balancer.RecordJobLoads(jobLoads...)
+
+	return nil
+}
+
+// BalanceDatabase balances the jobs for the given database across its workers
+// and returns the resulting job moves. If dryRun is true, the moves are only
+// computed; they are neither persisted nor sent to the workers.
+func (c *Controller) BalanceDatabase(ctx context.Context, qdbid dax.QualifiedDatabaseID, dryRun bool) ([]dax.WorkerDiff, error) {
+	if dryRun {
+		// Balance within a transaction which is never committed.
+		tx, err := c.Transactor.BeginTx(ctx, true)
+		if err != nil {
+			return nil, errors.Wrap(err, "beginning tx")
+		}
+		defer tx.Rollback()
+
+		diffs, err := c.Balancer.BalanceDatabase(tx, qdbid)
+		return diffs, errors.Wrapf(err, "balancing database: %s", qdbid)
+	}
+
+	var diffs []dax.WorkerDiff
+	var directives []*dax.Directive
+
+	fn := func(tx dax.Transaction, writable bool) error {
+		var err error
+		diffs, err = c.Balancer.BalanceDatabase(tx, qdbid)
+		if err != nil {
+			return errors.Wrapf(err, "balancing database: %s", qdbid)
+		}
+
+		// workerSet maintains the set of workers which have a job assignment
+		// change and therefore need to be sent an updated Directive.
+		workerSet := NewAddressSet()
+		for _, diff := range diffs {
+			workerSet.Add(diff.Address)
+		}
+
+		// No need to send Directives if nothing has changed.
+		if len(workerSet) == 0 {
+			return nil
+		}
+
+		addrMethods := applyAddressMethod(workerSet.SortedSlice(), dax.DirectiveMethodFull)
+
+		directives, err = c.buildDirectives(ctx, tx, addrMethods)
+		return errors.Wrap(err, "building directives")
+	}
+
+	if err := dax.RetryWithTx(ctx, c.Transactor, fn, true, txRetry); err != nil {
+		return nil, errors.Wrap(err, "retry with tx: write")
+	}
+
+	if err := c.sendDirectives(ctx, directives); err != nil {
+		return nil, NewErrDirectiveSendFailure(err.Error())
+	}
+
+	return diffs, nil
+}
@@ -22,14 +22,17 @@ type Balancer interface {
 	// RemoveJobs removes jobs for the given database.
 	RemoveJobs(tx dax.Transaction, roleType dax.RoleType, qtid dax.QualifiedTableID, jobs ...dax.Job) ([]dax.WorkerDiff, error)
 
-	// BalanceDatabase forces a database balance. TODO(tlt): currently this is
-	// only used in tests, so perhaps we can get rid of it.
+	// BalanceDatabase forces a database balance.
 	BalanceDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID) ([]dax.WorkerDiff, error)
 
 	// CurrentState returns the workers and jobs currently active for the given
 	// database.
 	CurrentState(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) ([]dax.WorkerInfo, error)
 
+	// FreeJobs returns the jobs for the given database which have yet to be
+	// assigned to a worker.
+	FreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) (dax.Jobs, error)
+
 	// WorkerState returns the jobs currently active for the given worker.
 	WorkerState(tx dax.Transaction, roleType dax.RoleType, addr dax.Address) (dax.WorkerInfo, error)
 
@@ -44,6 +47,11 @@ type Balancer interface {
 	// ReadNode returns the node for the given address.
 	ReadNode(tx dax.Transaction, addr dax.Address) (*dax.Node, error)
 
+	// RecordJobLoads records the load reported by a worker for each of its
+	// jobs. The load is used to determine the cost of each job when
+	// balancing.
+	RecordJobLoads(jobLoads ...dax.JobLoad)
+
 	// Nodes returns all nodes known by the Balancer.
 	Nodes(tx dax.Transaction) ([]*dax.Node, error)
 }
@@ -79,6 +87,9 @@ func (b *NopBalancer) BalanceDatabase(tx dax.Transaction, qdbid dax.QualifiedDat
 func (b *NopBalancer) CurrentState(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) ([]dax.WorkerInfo, error) {
 	return []dax.WorkerInfo{}, nil
 }
+func (b *NopBalancer) FreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) (dax.Jobs, error) {
+	return dax.Jobs{}, nil
+}
 func (b *NopBalancer) WorkerState(tx dax.Transaction, roleType dax.RoleType, addr dax.Address) (dax.WorkerInfo, error) {
 	return dax.WorkerInfo{}, nil
 }
@@ -94,3 +105,4 @@ func (b *NopBalancer) ReadNode(tx dax.Transaction, addr dax.Address) (*dax.Node,
 func (b *NopBalancer) Nodes(tx dax.Transaction) ([]*dax.Node, error) {
 	return []*dax.Node{}, nil
 }
+func (b *NopBalancer) RecordJobLoads(jobLoads ...dax.JobLoad) {}
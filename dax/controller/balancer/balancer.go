@@ -20,10 +20,11 @@ var _ controller.Balancer = (*Balancer)(nil)
 
 // Balancer is an implementation of the balancer.Balancer interface which
 // isolates workers and jobs by database. It helps manage the relationships
-// between workers and jobs. The logic it uses to balance jobs across workers is
-// very simple; it bases everything off the number of workers and number of
-// jobs. It does not take anything else (such as job size, worker capabilities,
-// etc) into consideration.
+// between workers and jobs. It balances jobs across workers by cost, where the
+// cost of a job is determined by its CostModel from the size and query load
+// reported for the job. A job for which no load has been reported has a cost
+// of one, so in the absence of load reports, jobs are balanced by count. It
+// does not take worker capabilities into consideration.
 type Balancer struct {
 	// current represents the current state of worker/job assigments.
 	current WorkerJobService
@@ -40,6 +41,11 @@ type Balancer struct {
 
 	schemar schemar.Schemar
 
+	// loads holds the load most recently reported for each job, and
+	// costModel converts that load into a cost.
+	loads     *loads
+	costModel CostModel
+
 	logger logger.Logger
 }
 
@@ -51,10 +57,31 @@ func New(wr controller.WorkerRegistry, fjs FreeJobService, wjs WorkerJobService,
 		freeJobs:       fjs,
 		freeWorkers:    fws,
 		schemar:        schemar,
+		loads:          newLoads(),
+		costModel:      DefaultCostModel,
 		logger:         logger,
 	}
 }
 
+// RecordJobLoads records the load reported by a worker for each of its jobs.
+func (b *Balancer) RecordJobLoads(jobLoads ...dax.JobLoad) {
+	b.loads.record(time.Now(), jobLoads...)
+}
+
+// cost returns the cost of the given job.
+func (b *Balancer) cost(job dax.Job) int64 {
+	return b.loads.cost(b.costModel, job)
+}
+
+// jobsCost returns the total cost of the given jobs.
+func (b *Balancer) jobsCost(jobs []dax.Job) int64 {
+	var total int64
+	for _, job := range jobs {
+		total += b.cost(job)
+	}
+	return total
+}
+
 // AddWorker adds the given Node to the Balancer's available worker pool. Note
 // that a node is used for ALL of the role types specified. In other words,
 // specifying roleTypes = {compute, translate}, does not mean that the node can
@@ -370,39 +397,60 @@ func (b *Balancer) addDatabaseJobs(tx dax.Transaction, roleType dax.RoleType, qd
 	}
 
 	addrs := make(dax.Addresses, 0, len(workerJobs))
-	jobCounts := make(map[dax.Address]int, 0)
+	costs := make(map[dax.Address]int64, 0)
 	for _, v := range workerJobs {
 		addrs = append(addrs, v.Address)
-		jobCounts[v.Address] = len(v.Jobs)
+		costs[v.Address] = b.jobsCost(v.Jobs)
 	}
 
+	holders := newReplicaHolders(workerJobs)
+
 	diffs := NewInternalDiffs()
 
 	jobsToCreate := make(map[dax.Address][]dax.Job)
 
+	// Place the most costly jobs first; this results in a more even spread
+	// than placing them in the order given. The sort is stable so that jobs of
+	// equal cost are placed in the order given.
+	jobs = append([]dax.Job(nil), jobs...)
+	sort.SliceStable(jobs, func(i, j int) bool {
+		return b.cost(jobs[i]) > b.cost(jobs[j])
+	})
+
 	for _, job := range jobs {
 		// Skip any job that already exists.
 		if jset.Contains(job) {
 			continue
 		}
 
-		// Find the worker with the fewest number of jobs and assign it this job.
-		var lowCount int = math.MaxInt
-		var lowWorker dax.Address
+		// Find the worker with the lowest cost and assign it this job. Prefer
+		// workers which don't already hold a replica of the job; if every
+		// worker does, then fall back to the worker with the lowest cost.
+		var lowCost, lowCostHolder int64 = math.MaxInt64, math.MaxInt64
+		var lowWorker, lowWorkerHolder dax.Address
 
-		// We loop over addrs here instead of jobCounts because jobCounts is a
-		// map and it can return results in an unexpected order, which is a
-		// problem for testing.
+		// We loop over addrs here instead of costs because costs is a map and
+		// it can return results in an unexpected order, which is a problem for
+		// testing.
 		for _, addr := range addrs {
-			jobCount := jobCounts[addr]
-			if jobCount < lowCount {
-				lowCount = jobCount
+			cost := costs[addr]
+			if holders.holds(addr, job) {
+				if cost < lowCostHolder {
+					lowCostHolder = cost
+					lowWorkerHolder = addr
+				}
+			} else if cost < lowCost {
+				lowCost = cost
 				lowWorker = addr
 			}
 		}
+		if lowCost == math.MaxInt64 {
+			lowWorker = lowWorkerHolder
+		}
 
 		jobsToCreate[lowWorker] = append(jobsToCreate[lowWorker], job)
-		jobCounts[lowWorker]++
+		costs[lowWorker] += b.cost(job)
+		holders.add(lowWorker, job)
 	}
 
 	for addr, jobs := range jobsToCreate {
@@ -438,6 +486,7 @@ func (b *Balancer) RemoveJobs(tx dax.Transaction, roleType dax.RoleType, qtid da
 			diffs.Merge(diff)
 		}
 	}
+	b.loads.remove(jobs...)
 
 	return diffs.Output(), nil
 }
@@ -450,6 +499,7 @@ func (b *Balancer) removeJobsForTable(tx dax.Transaction, roleType dax.RoleType,
 	if err := b.freeJobs.DeleteJobsForTable(tx, roleType, qtid); err != nil {
 		return nil, errors.Wrapf(err, "deleting free jobs for table: (%s) %s", roleType, qtid)
 	}
+	b.loads.removeByPrefix(string(qtid.Key()))
 	return idiffs, nil
 }
 
@@ -545,6 +595,10 @@ func (b *Balancer) CurrentState(tx dax.Transaction, roleType dax.RoleType, qdbid
 	return b.current.WorkersJobs(tx, roleType, qdbid)
 }
 
+func (b *Balancer) FreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) (dax.Jobs, error) {
+	return b.freeJobs.ListJobs(tx, roleType, qdbid)
+}
+
 func (b *Balancer) WorkerState(tx dax.Transaction, roleType dax.RoleType, addr dax.Address) (dax.WorkerInfo, error) {
 	info := dax.WorkerInfo{
 		Address: addr,
@@ -665,84 +719,109 @@ func (b *Balancer) removeJob(tx dax.Transaction, roleType dax.RoleType, qdbid da
 }
 
 // balanceDatabaseJobs moves jobs among workers with the goal of having an equal
-// number of jobs per worker. This method takes an `internalDiffs` as input for
+// cost of jobs per worker. This method takes an `internalDiffs` as input for
 // cases where some action has preceeded this call which also resulted in
 // `internalDiffs`. Instead of having this method take a value, we could rely on
 // the internalDiffs.merge() method, but we would need to modify that method to
 // be smarter about the order in which it applies the add/remove operations.
 // Until that's in place, we'll pass in a value here.
 func (b *Balancer) balanceDatabaseJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID, diffs InternalDiffs) (InternalDiffs, error) {
-	numWorkers, err := b.current.WorkerCount(tx, roleType, qdbid)
-	if err != nil {
-		return nil, errors.Wrapf(err, "getting worker count: (%s) %s", roleType, qdbid)
-	}
-	numJobs := 0
-	if addrs, err := b.current.ListWorkers(tx, roleType, qdbid); err != nil {
-		return nil, errors.Wrapf(err, "listing workers: (%s) %s", roleType, qdbid)
-	} else {
-		for _, addr := range addrs {
-			jobCounts, err := b.current.JobCounts(tx, roleType, qdbid, addr)
-			if err != nil {
-				return nil, errors.Wrapf(err, "getting job count: (%s) %s, %s", roleType, qdbid, addr)
-			}
-			numJobs += jobCounts[addr]
-		}
-	}
-
-	minJobsPerWorker := numJobs / numWorkers
-	numWorkersAboveMin := numJobs % numWorkers
-
-	// workerInfos is used now in order to guarantee a sort order.
+	// workerInfos is used now in order to guarantee a sort order; workers are
+	// sorted by address, and each worker's jobs are sorted by name.
 	workerInfos, err := b.CurrentState(tx, roleType, qdbid)
 	if err != nil {
 		return nil, errors.Wrapf(err, "getting current state: (%s) %s", roleType, qdbid)
 	}
+	numWorkers := int64(len(workerInfos))
+	if numWorkers == 0 {
+		return diffs, nil
+	}
 
-	// Loop through each worker, and if the number of jobs for the worker
-	// exceeds the target, then remove the job and add it back (which is
-	// effectively how we rebalance a job).
+	costs := make([]int64, len(workerInfos))
+	var totalCost int64
 	for i, workerInfo := range workerInfos {
-		numTargetJobs := minJobsPerWorker
-		if i < numWorkersAboveMin {
-			numTargetJobs += 1
-		}
-
-		jobCounts, err := b.current.JobCounts(tx, roleType, qdbid, workerInfo.Address)
-		if err != nil {
-			return nil, errors.Wrapf(err, "getting job count: (%s) %s, %s", roleType, qdbid, workerInfo.Address)
-		}
-		numCurrentJobs := jobCounts[workerInfo.Address]
-
-		// If we don't need to remove jobs from this worker, then just continue
-		// on to the next worker.
-		if numCurrentJobs <= numTargetJobs {
-			continue
-		}
+		costs[i] = b.jobsCost(workerInfo.Jobs)
+		totalCost += costs[i]
+	}
+
+	minCostPerWorker := totalCost / numWorkers
+	numWorkersAboveMin := totalCost % numWorkers
+
+	holders := newReplicaHolders(workerInfos)
+
+	// Loop through each worker, and if the cost of the jobs for the worker
+	// exceeds the target, then move jobs to the worker with the lowest cost.
+	for i := range workerInfos {
+		targetCost := minCostPerWorker
+		if int64(i) < numWorkersAboveMin {
+			targetCost += 1
+		}
+
+		for costs[i] > targetCost {
+			// Find the most costly job which can be moved without taking the
+			// worker below its target, preferring jobs from the end of the
+			// list.
+			excess := costs[i] - targetCost
+			jobs := workerInfos[i].Jobs
+			move, moveCost := -1, int64(0)
+			for k := len(jobs) - 1; k >= 0; k-- {
+				if c := b.cost(jobs[k]); c <= excess && c > moveCost {
+					move, moveCost = k, c
+				}
+			}
+			if move == -1 {
+				break
+			}
 
-		sortedJobs, err := b.current.ListJobs(tx, roleType, qdbid, workerInfo.Address)
-		if err != nil {
-			return nil, errors.Wrapf(err, "listing jobs: (%s) %s, %s", roleType, qdbid, workerInfo.Address)
-		}
+			// Find the worker with the lowest cost which doesn't already hold
+			// a replica of the job. If moving the job there would leave it
+			// with a higher cost than this worker, then there is nothing to
+			// gain.
+			job := jobs[move]
+			low := -1
+			for j := range costs {
+				if holders.holds(workerInfos[j].Address, job) {
+					continue
+				}
+				if low == -1 || costs[j] < costs[low] {
+					low = j
+				}
+			}
+			if low == -1 || costs[low]+moveCost > costs[i] {
+				break
+			}
 
-		// Remove the extra jobs from the end of the list, and add them back
-		// again (which should place them on a worker with fewer jobs).
-		for i := numCurrentJobs - 1; i >= numTargetJobs; i-- {
-			if rj, err := b.removeJob(tx, roleType, qdbid, sortedJobs[i]); err != nil {
-				return nil, errors.Wrapf(err, "removing job: %s", sortedJobs[i])
-			} else {
-				diffs.Merge(rj)
+			from, to := workerInfos[i].Address, workerInfos[low].Address
+			if err := b.current.DeleteJob(tx, roleType, qdbid, from, job); err != nil {
+				return nil, errors.Wrapf(err, "deleting job: (%s) %s, %s, %s", roleType, qdbid, from, job)
 			}
-			if aj, err := b.addJobs(tx, roleType, qdbid, sortedJobs[i]); err != nil {
-				return nil, errors.Wrapf(err, "adding job: %s", sortedJobs[i])
-			} else {
-				diffs.Merge(aj)
+			diffs.Removed(from, job)
+			if err := b.current.AssignWorkerToJobs(tx, roleType, qdbid, to, job); err != nil {
+				return nil, errors.Wrapf(err, "assigning job: (%s) %s, %s, %s", roleType, qdbid, to, job)
 			}
+			diffs.Added(to, job)
+			holders.remove(from, job)
+			holders.add(to, job)
+
+			workerInfos[i].Jobs = append(jobs[:move:move], jobs[move+1:]...)
+			workerInfos[low].Jobs = insertJob(workerInfos[low].Jobs, job)
+			costs[i] -= moveCost
+			costs[low] += moveCost
 		}
 	}
 
 	return diffs, nil
 }
 
+// insertJob inserts job into the sorted slice of jobs.
+func insertJob(jobs []dax.Job, job dax.Job) []dax.Job {
+	k := sort.Search(len(jobs), func(i int) bool { return jobs[i] >= job })
+	out := make([]dax.Job, 0, len(jobs)+1)
+	out = append(out, jobs[:k]...)
+	out = append(out, job)
+	return append(out, jobs[k:]...)
+}
+
 // processFreeJobs assigns all jobs in the free list to a worker.
 func (b *Balancer) processFreeJobs(tx dax.Transaction, roleType dax.RoleType, qdbid dax.QualifiedDatabaseID) (InternalDiffs, error) {
 	diffs := NewInternalDiffs()
@@ -0,0 +1,128 @@
+package balancer
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// CostModel determines the cost of a job based on the load reported for it.
+// Jobs are placed on, and moved between, workers such that each worker's total
+// cost is as even as possible.
+type CostModel struct {
+	// JobCost is the cost of every job, regardless of its load. A job for
+	// which no load has been reported costs exactly this much.
+	JobCost int64
+
+	// BytesPerCost is the number of bytes of job data which add one to the
+	// job's cost. If zero, job size is ignored.
+	BytesPerCost int64
+
+	// QueriesPerCost is the query rate, in queries per second, which adds one
+	// to the job's cost. If zero, query load is ignored.
+	QueriesPerCost float64
+}
+
+// DefaultCostModel counts every 256MiB of data, and every query per second, as
+// one more job.
+var DefaultCostModel = CostModel{
+	JobCost:        1,
+	BytesPerCost:   1 << 28,
+	QueriesPerCost: 1,
+}
+
+// queryRateAlpha is the weight given to the most recent sample when updating a
+// job's query rate.
+const queryRateAlpha = 0.2
+
+// jobLoad is the most recently reported load for a job.
+type jobLoad struct {
+	bytes     int64
+	queries   uint64
+	queryRate float64
+	at        time.Time
+}
+
+// loads holds the load reported for each job. Job names are unique across role
+// types, so loads are keyed by job alone. Loads are reported by workers on
+// every poll, so they are held in memory rather than persisted.
+type loads struct {
+	mu sync.RWMutex
+	m  map[dax.Job]jobLoad
+}
+
+func newLoads() *loads {
+	return &loads{
+		m: make(map[dax.Job]jobLoad),
+	}
+}
+
+// record updates the load for each job. The query rate is smoothed over
+// successive reports.
+func (l *loads) record(at time.Time, jls ...dax.JobLoad) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, jl := range jls {
+		cur := jobLoad{
+			bytes:   jl.Bytes,
+			queries: jl.Queries,
+			at:      at,
+		}
+		if prev, ok := l.m[jl.Job]; ok {
+			cur.queryRate = prev.queryRate
+			// A counter which went backwards means the job moved to a
+			// different worker (or the worker restarted), in which case
+			// this report is only used as a new baseline.
+			if secs := at.Sub(prev.at).Seconds(); secs > 0 && jl.Queries >= prev.queries {
+				sample := float64(jl.Queries-prev.queries) / secs
+				cur.queryRate += queryRateAlpha * (sample - cur.queryRate)
+			}
+		}
+		l.m[jl.Job] = cur
+	}
+}
+
+// remove removes the loads for the given jobs.
+func (l *loads) remove(jobs ...dax.Job) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, job := range jobs {
+		delete(l.m, job)
+	}
+}
+
+// removeByPrefix removes the loads for every job having the given prefix.
+func (l *loads) removeByPrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for job := range l.m {
+		if strings.HasPrefix(string(job), prefix) {
+			delete(l.m, job)
+		}
+	}
+}
+
+// cost returns the cost of job under the given cost model.
+func (l *loads) cost(cm CostModel, job dax.Job) int64 {
+	l.mu.RLock()
+	jl, ok := l.m[job]
+	l.mu.RUnlock()
+
+	cost := cm.JobCost
+	if ok && cm.BytesPerCost > 0 {
+		cost += jl.bytes / cm.BytesPerCost
+	}
+	if ok && cm.QueriesPerCost > 0 {
+		cost += int64(jl.queryRate / cm.QueriesPerCost)
+	}
+	// Every job has some cost; otherwise balancing could move it endlessly.
+	if cost < 1 {
+		cost = 1
+	}
+	return cost
+}
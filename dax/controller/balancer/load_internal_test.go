@@ -0,0 +1,44 @@
+package balancer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoads(t *testing.T) {
+	cm := CostModel{
+		JobCost:        1,
+		BytesPerCost:   100,
+		QueriesPerCost: 1,
+	}
+	l := newLoads()
+	now := time.Now()
+
+	// A job with no reported load has the base cost.
+	assert.EqualValues(t, 1, l.cost(cm, "j0"))
+
+	// The first report only establishes a baseline for the query rate.
+	l.record(now, dax.JobLoad{Job: "j0", Bytes: 250, Queries: 1000})
+	assert.EqualValues(t, 3, l.cost(cm, "j0"))
+
+	// 100 queries/sec for ten seconds is smoothed toward 100.
+	for i := 1; i <= 10; i++ {
+		l.record(now.Add(time.Duration(i)*time.Second), dax.JobLoad{Job: "j0", Bytes: 250, Queries: 1000 + uint64(i)*100})
+	}
+	assert.EqualValues(t, 3+89, l.cost(cm, "j0"))
+
+	// A counter which went backwards resets the baseline but keeps the rate.
+	l.record(now.Add(11*time.Second), dax.JobLoad{Job: "j0", Bytes: 250, Queries: 5})
+	assert.EqualValues(t, 3+89, l.cost(cm, "j0"))
+
+	// The cost of a job is never less than one.
+	assert.EqualValues(t, 1, l.cost(CostModel{}, "j0"))
+
+	l.record(now, dax.JobLoad{Job: "tbl1|shard_1"}, dax.JobLoad{Job: "tbl2|shard_1"})
+	l.removeByPrefix("tbl1|")
+	l.remove("j0")
+	assert.Equal(t, map[dax.Job]jobLoad{"tbl2|shard_1": {at: now}}, l.m)
+}
@@ -0,0 +1,45 @@
+package balancer
+
+import "github.com/featurebasedb/featurebase/v3/dax"
+
+// replicaHolders tracks how many replicas of each job each worker holds. The
+// Balancer uses it to avoid placing two replicas of the same job on one
+// worker, which would defeat the purpose of having replicas.
+type replicaHolders map[dax.Job]map[dax.Address]int
+
+// newReplicaHolders returns a replicaHolders populated with the jobs in
+// workerInfos.
+func newReplicaHolders(workerInfos []dax.WorkerInfo) replicaHolders {
+	h := make(replicaHolders)
+	for _, workerInfo := range workerInfos {
+		for _, job := range workerInfo.Jobs {
+			h.add(workerInfo.Address, job)
+		}
+	}
+	return h
+}
+
+// add records that the worker at addr holds job.
+func (h replicaHolders) add(addr dax.Address, job dax.Job) {
+	base, _ := job.ReplicaOf()
+	if _, ok := h[base]; !ok {
+		h[base] = make(map[dax.Address]int)
+	}
+	h[base][addr]++
+}
+
+// remove records that the worker at addr no longer holds job.
+func (h replicaHolders) remove(addr dax.Address, job dax.Job) {
+	base, _ := job.ReplicaOf()
+	if h[base][addr] > 1 {
+		h[base][addr]--
+	} else {
+		delete(h[base], addr)
+	}
+}
+
+// holds returns true if the worker at addr holds any replica of job.
+func (h replicaHolders) holds(addr dax.Address, job dax.Job) bool {
+	base, _ := job.ReplicaOf()
+	return h[base][addr] > 0
+}
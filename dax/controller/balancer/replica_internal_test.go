@@ -0,0 +1,39 @@
+package balancer
+
+import (
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReplicaHolders(t *testing.T) {
+	h := newReplicaHolders([]dax.WorkerInfo{
+		{Address: "w1", Jobs: []dax.Job{"tbl|shard_1", "tbl|shard_2|replica_1"}},
+		{Address: "w2", Jobs: []dax.Job{"tbl|shard_1|replica_1"}},
+	})
+
+	// Every replica of a job is considered to be the same job.
+	assert.True(t, h.holds("w1", "tbl|shard_1"))
+	assert.True(t, h.holds("w1", "tbl|shard_1|replica_2"))
+	assert.True(t, h.holds("w1", "tbl|shard_2"))
+	assert.True(t, h.holds("w2", "tbl|shard_1"))
+	assert.False(t, h.holds("w2", "tbl|shard_2"))
+	assert.False(t, h.holds("w3", "tbl|shard_1"))
+
+	h.add("w3", "tbl|shard_3|replica_1")
+	assert.True(t, h.holds("w3", "tbl|shard_3"))
+
+	h.remove("w1", "tbl|shard_1")
+	assert.False(t, h.holds("w1", "tbl|shard_1|replica_1"))
+	assert.True(t, h.holds("w2", "tbl|shard_1"))
+
+	// A worker may hold more than one replica of a job if there are too few
+	// workers to spread them out.
+	h.add("w2", "tbl|shard_1")
+	h.remove("w2", "tbl|shard_1|replica_1")
+	assert.True(t, h.holds("w2", "tbl|shard_1"))
+
+	// Removing a job which was never added is a no-op.
+	h.remove("w1", "tbl|shard_9")
+}
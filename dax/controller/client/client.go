@@ -24,6 +24,7 @@ const (
 // Ensure type implements interface.
 var _ computer.Registrar = (*Client)(nil)
 var _ dax.Schemar = (*Client)(nil)
+var _ dax.QueryStatsRecorder = (*Client)(nil)
 
 // Client is an HTTP client that operates on the Controller endpoints exposed by
 // the main Controller service.
@@ -244,6 +245,69 @@ func (c *Client) SetDatabaseOption(ctx context.Context, qdbid dax.QualifiedDatab
 	return nil
 }
 
+// BalanceDatabase balances the jobs of a database across its workers and
+// returns the resulting job moves. If dryRun is true, the moves are returned
+// without being applied.
+func (c *Client) BalanceDatabase(ctx context.Context, qdbid dax.QualifiedDatabaseID, dryRun bool) ([]dax.WorkerDiff, error) {
+	url := fmt.Sprintf("%s/balance-database", c.address.WithScheme(defaultScheme))
+
+	req := &controllerhttp.BalanceDatabaseRequest{
+		QualifiedDatabaseID: qdbid,
+		DryRun:              dryRun,
+	}
+
+	// Encode the request.
+	postBody, err := json.Marshal(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshalling post request")
+	}
+	responseBody := bytes.NewBuffer(postBody)
+
+	// Post the request.
+	c.logger.Debugf("POST balance-database request: url: %s", url)
+	resp, err := c.httpClient.Post(url, "application/json", responseBody)
+	if err != nil {
+		return nil, errors.Wrap(err, "posting balance-database request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Wrapf(errors.UnmarshalJSON(resp.Body), "status code: %d", resp.StatusCode)
+	}
+
+	var bdr *controllerhttp.BalanceDatabaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&bdr); err != nil {
+		return nil, errors.Wrap(err, "reading response body")
+	}
+
+	return bdr.Diffs, nil
+}
+
+// RecordQueryStats reports the stats for the queries handled by a queryer.
+func (c *Client) RecordQueryStats(ctx context.Context, stats dax.QueryStats) error {
+	url := fmt.Sprintf("%s/query-stats", c.address.WithScheme(defaultScheme))
+
+	// Encode the request.
+	postBody, err := json.Marshal(stats)
+	if err != nil {
+		return errors.Wrap(err, "marshalling post request")
+	}
+	responseBody := bytes.NewBuffer(postBody)
+
+	// Post the request.
+	resp, err := c.httpClient.Post(url, "application/json", responseBody)
+	if err != nil {
+		return errors.Wrap(err, "posting query-stats request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return errors.Wrapf(errors.UnmarshalJSON(resp.Body), "status code: %d", resp.StatusCode)
+	}
+
+	return nil
+}
+
 // TODO(tlt): collapse Table into this
 func (c *Client) TableByID(ctx context.Context, qtid dax.QualifiedTableID) (*dax.QualifiedTable, error) {
 	return c.Table(ctx, qtid)
@@ -3,7 +3,9 @@ package controller
 import (
 	"time"
 
+	"github.com/featurebasedb/featurebase/v3/dax/controller/autoscaler"
 	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
 )
 
 type NewBalancerFn func(string, logger.Logger) Balancer
@@ -26,6 +28,11 @@ type Config struct {
 	SnapshotterDir string `toml:"snapshotter-dir"`
 	WriteloggerDir string `toml:"writelogger-dir"`
 
+	// DAXStore, if a bucket is set, is the S3-compatible object store
+	// holding snapshots and write logs in place of SnapshotterDir and
+	// WriteloggerDir. It must match the computers' configuration.
+	DAXStore objectstore.Config `toml:"dax-store"`
+
 	// RegistrationBatchTimeout is the time that the controller will
 	// wait after a node registers itself to see if any more nodes
 	// will register before sending out directives to all nodes which
@@ -39,9 +46,71 @@ type Config struct {
 	// until the timeout expires to start another round of snapshots.
 	SnappingTurtleTimeout time.Duration
 
+	// SnappingTurtleThreshold is the size, in bytes, beyond which a
+	// resource's write log must grow before the automatic snapshotting
+	// routine will snapshot the resource. Resources which haven't
+	// been written to since their last snapshot are always skipped.
+	SnappingTurtleThreshold int64
+
+	// Autoscaler configures the optional autoscaling of workers.
+	Autoscaler AutoscalerConfig `toml:"autoscaler"`
+
 	Logger logger.Logger `toml:"-"`
 }
 
+// AutoscalerConfig configures the autoscaler and the policies it applies. Each
+// policy is only applied if its settings are non-zero.
+type AutoscalerConfig struct {
+	Enabled bool `toml:"enabled"`
+
+	// Provider adds and removes workers. If nil, and LocalCommand is set,
+	// workers are started as local processes.
+	Provider autoscaler.Provider `toml:"-"`
+
+	Interval   time.Duration `toml:"interval"`
+	Cooldown   time.Duration `toml:"cooldown"`
+	MaxWorkers int           `toml:"max-workers"`
+
+	// JobsPerWorker is the target number of jobs for each worker.
+	JobsPerWorker int `toml:"jobs-per-worker"`
+
+	// QueryLatencyHigh and QueryLatencyLow are the mean query latencies,
+	// as reported by the queryers, above which compute workers are added
+	// and below which they are removed.
+	QueryLatencyHigh time.Duration `toml:"query-latency-high"`
+	QueryLatencyLow  time.Duration `toml:"query-latency-low"`
+
+	// FreeWorkersMin and FreeWorkersMax bound the number of workers kept in
+	// the free worker pool, ready to be assigned to databases.
+	FreeWorkersMin int `toml:"free-workers-min"`
+	FreeWorkersMax int `toml:"free-workers-max"`
+
+	// LocalCommand, LocalHost, and LocalBasePort configure the local
+	// process-spawning provider. See autoscaler.LocalProvider.
+	LocalCommand  []string `toml:"local-command"`
+	LocalHost     string   `toml:"local-host"`
+	LocalBasePort int      `toml:"local-base-port"`
+}
+
+// policies returns the autoscaler policies which are configured.
+func (c AutoscalerConfig) policies() []autoscaler.Policy {
+	var policies []autoscaler.Policy
+	if c.JobsPerWorker > 0 {
+		for _, rt := range supportedRoleTypes {
+			policies = append(policies, &autoscaler.JobsPerWorker{RoleType: rt, Target: c.JobsPerWorker})
+		}
+	}
+	if c.QueryLatencyHigh > 0 {
+		policies = append(policies, &autoscaler.QueryLatency{High: c.QueryLatencyHigh, Low: c.QueryLatencyLow})
+	}
+	if c.FreeWorkersMin > 0 || c.FreeWorkersMax > 0 {
+		for _, rt := range supportedRoleTypes {
+			policies = append(policies, &autoscaler.FreeWorkers{RoleType: rt, Min: c.FreeWorkersMin, Max: c.FreeWorkersMax})
+		}
+	}
+	return policies
+}
+
 type SQLDBConfig struct {
 	// Dialect is the pop dialect to use. Example: "postgres" or "sqlite3" or "mysql"
 	Dialect string
@@ -9,6 +9,7 @@ import (
 
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/computer"
+	"github.com/featurebasedb/featurebase/v3/dax/controller/autoscaler"
 	"github.com/featurebasedb/featurebase/v3/dax/controller/poller"
 	"github.com/featurebasedb/featurebase/v3/dax/controller/schemar"
 	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
@@ -35,8 +36,8 @@ type Controller struct {
 
 	Transactor dax.Transactor
 
-	Snapshotter *snapshotter.Snapshotter
-	Writelogger *writelogger.Writelogger
+	Snapshotter Snapshotter
+	Writelogger Writelogger
 
 	// Director is used to send directives to computer workers.
 	Director Director
@@ -45,10 +46,15 @@ type Controller struct {
 
 	poller *poller.Poller
 
+	// autoscaler is nil unless autoscaling is enabled.
+	autoscaler *autoscaler.Autoscaler
+
 	registrationBatchTimeout time.Duration
 	nodeChan                 chan *dax.Node
 	snappingTurtleTimeout    time.Duration
+	snappingTurtleThreshold  int64
 	snapControl              chan struct{}
+	snapTracker              *snapTracker
 	stopping                 chan struct{}
 
 	backgroundGroup errgroup.Group
@@ -79,7 +85,9 @@ func New(cfg Config) *Controller {
 		registrationBatchTimeout: cfg.RegistrationBatchTimeout,
 		nodeChan:                 make(chan *dax.Node, 10),
 		snappingTurtleTimeout:    cfg.SnappingTurtleTimeout,
+		snappingTurtleThreshold:  cfg.SnappingTurtleThreshold,
 		snapControl:              make(chan struct{}),
+		snapTracker:              newSnapTracker(),
 
 		logger: logr,
 	}
@@ -90,10 +98,28 @@ func New(cfg Config) *Controller {
 		WorkerRegistry: c,
 		NodePoller:     poller.NewHTTPNodePoller(logr),
 		PollInterval:   cfg.PollInterval,
+		LoadRecorder:   c,
 		Logger:         logr,
 	}
 	c.poller = poller.New(pollerCfg)
 
+	// Autoscaler.
+	if cfg.Autoscaler.Enabled {
+		provider := cfg.Autoscaler.Provider
+		if provider == nil && len(cfg.Autoscaler.LocalCommand) > 0 {
+			provider = autoscaler.NewLocalProvider(cfg.Autoscaler.LocalCommand, cfg.Autoscaler.LocalHost, cfg.Autoscaler.LocalBasePort, logr)
+		}
+		c.autoscaler = autoscaler.New(autoscaler.Config{
+			Cluster:    c,
+			Provider:   provider,
+			Policies:   cfg.Autoscaler.policies(),
+			Interval:   cfg.Autoscaler.Interval,
+			Cooldown:   cfg.Autoscaler.Cooldown,
+			MaxWorkers: cfg.Autoscaler.MaxWorkers,
+			Logger:     logr,
+		})
+	}
+
 	// Snapshotter.
 	c.Snapshotter = snapshotter.New(cfg.SnapshotterDir, c.logger)
 
@@ -121,6 +147,11 @@ func (c *Controller) Start() error {
 	c.backgroundGroup.Go(func() error {
 		return c.snappingTurtleRoutine(c.snappingTurtleTimeout, c.snapControl, c.logger.WithPrefix("Snapping Turtle: "))
 	})
+	if c.autoscaler != nil {
+		c.backgroundGroup.Go(func() error {
+			return c.autoscaler.Run(c.stopping)
+		})
+	}
 
 	return nil
 }
@@ -488,27 +519,51 @@ func (c *Controller) nodesTranslateReadOrWrite(ctx context.Context, tx dax.Trans
 }
 
 // nodesComputeReadOrWrite contains the logic for the c.nodesCompute() method,
-// but it supports being called with either a read or write lock.
-func (c *Controller) nodesComputeReadOrWrite(ctx context.Context, tx dax.Transaction, role *dax.ComputeRole, qdbid dax.QualifiedDatabaseID, createMissing bool, asWrite bool) ([]dax.AssignedNode, bool, []*dax.Directive, error) {
+// but it supports being called with either a read or write lock. If
+// withReplicas is true, the returned nodes include every replica of the
+// shards; otherwise they only include the replica which accepts writes.
+func (c *Controller) nodesComputeReadOrWrite(ctx context.Context, tx dax.Transaction, role *dax.ComputeRole, qdbid dax.QualifiedDatabaseID, createMissing bool, asWrite bool, withReplicas bool) ([]dax.AssignedNode, bool, []*dax.Directive, error) {
 	qtid := role.TableKey.QualifiedTableID()
 	roleType := dax.RoleTypeCompute
 
+	replicaN := 1
+	if withReplicas || createMissing {
+		qdb, err := c.Schemar.DatabaseByID(tx, qdbid)
+		if err != nil {
+			return nil, false, nil, errors.Wrap(err, "getting database")
+		}
+		replicaN = qdb.Options.Replicas()
+	}
+
+	// inJobs contains the job for the writable replica of each shard, and
+	// lookupJobs contains the jobs for each replica of each shard that we're
+	// looking for.
 	inJobs := dax.NewSet[dax.Job]()
+	lookupJobs := dax.NewSet[dax.Job]()
 	for _, s := range role.Shards {
-		shardString := shard(role.TableKey, s).String()
-		inJobs.Add(dax.Job(shardString))
+		inJobs.Add(shard(role.TableKey, s).Job())
+		if withReplicas {
+			for _, job := range shardReplicas(role.TableKey, s, replicaN) {
+				lookupJobs.Add(job)
+			}
+		}
+	}
+	if !withReplicas {
+		lookupJobs = inJobs
 	}
 
-	workers, err := c.Balancer.WorkersForJobs(tx, roleType, qdbid, inJobs.Sorted()...)
+	workers, err := c.Balancer.WorkersForJobs(tx, roleType, qdbid, lookupJobs.Sorted()...)
 	if err != nil {
 		return nil, false, nil, errors.Wrap(err, "getting workers for jobs")
 	}
 
-	// figure out if any jobs in the role have no workers assigned
+	// figure out if any jobs in the role have no workers assigned. A shard
+	// with any replica assigned to a worker is not missing.
 	outJobs := dax.NewSet[dax.Job]()
 	for _, worker := range workers {
 		for _, job := range worker.Jobs {
-			outJobs.Add(job)
+			base, _ := job.ReplicaOf()
+			outJobs.Add(base)
 		}
 	}
 
@@ -538,7 +593,9 @@ func (c *Controller) nodesComputeReadOrWrite(ctx context.Context, tx dax.Transac
 			if err != nil {
 				return nil, false, nil, NewErrInternal(err.Error())
 			}
-			diffs, err := c.Balancer.AddJobs(tx, roleType, qtid, j.Job())
+			// Add every replica of the shard at once so that the Balancer
+			// can place them on distinct workers.
+			diffs, err := c.Balancer.AddJobs(tx, roleType, qtid, shardReplicas(j.table(), j.shardNum(), replicaN)...)
 			if err != nil {
 				return nil, false, nil, errors.Wrap(err, "adding job")
 			}
@@ -551,7 +608,7 @@ func (c *Controller) nodesComputeReadOrWrite(ctx context.Context, tx dax.Transac
 		}
 
 		// Re-run WorkersForJobs.
-		workers, err = c.Balancer.WorkersForJobs(tx, roleType, qdbid, inJobs.Sorted()...)
+		workers, err = c.Balancer.WorkersForJobs(tx, roleType, qdbid, lookupJobs.Sorted()...)
 		if err != nil {
 			return nil, false, nil, errors.Wrap(err, "getting workers for jobs")
 		}
@@ -564,14 +621,21 @@ func (c *Controller) nodesComputeReadOrWrite(ctx context.Context, tx dax.Transac
 func (c *Controller) computeWorkersToAssignedNodes(tx dax.Transaction, workers []dax.WorkerInfo) ([]dax.AssignedNode, error) {
 	nodes := []dax.AssignedNode{}
 	for _, worker := range workers {
-		// convert worker.Jobs []string to map[TableName][]Shard
+		// convert worker.Jobs []string to map[TableName][]Shard. A worker
+		// may hold more than one replica of a shard, so dedupe the shards.
 		computeMap := make(map[dax.TableKey]dax.ShardNums)
+		seen := make(map[sUnit]struct{})
 		for _, job := range worker.Jobs {
 			j, err := decodeShard(job)
 			if err != nil {
 				return nil, NewErrInternal(err.Error())
 			}
 
+			if _, ok := seen[shard(j.table(), j.shardNum())]; ok {
+				continue
+			}
+			seen[shard(j.table(), j.shardNum())] = struct{}{}
+
 			computeMap[j.table()] = append(computeMap[j.table()], j.shardNum())
 		}
 
@@ -737,12 +801,24 @@ func (c *Controller) SetDatabaseOption(ctx context.Context, qdbid dax.QualifiedD
 			return errors.Wrapf(err, "setting database option: %s", option)
 		}
 
+		workerSet := NewAddressSet()
+
+		// Changing the number of replicas adds or removes shard jobs.
+		if option == dax.DatabaseOptionReplicaN {
+			diffs, err := c.reconcileReplicas(tx, qdbid)
+			if err != nil {
+				return errors.Wrapf(err, "reconciling replicas: %s", qdbid)
+			}
+			for _, diff := range diffs {
+				workerSet.Add(diff.Address)
+			}
+		}
+
 		diffs, err := c.Balancer.BalanceDatabase(tx, qdbid)
 		if err != nil {
 			return errors.Wrapf(err, "balancing database: %s", qdbid)
 		}
 
-		workerSet := NewAddressSet()
 		for _, diff := range diffs {
 			workerSet.Add(dax.Address(diff.Address))
 		}
@@ -951,6 +1027,7 @@ func (c *Controller) dropTable(tx dax.Transaction, qtid dax.QualifiedTableID) (A
 	if err := c.Snapshotter.DeleteTable(qtid); err != nil {
 		return nil, errors.Wrap(err, "deleting from snapshotter")
 	}
+	c.snapTracker.removeTable(qtid.Key())
 	if err := c.Writelogger.DeleteTable(qtid); err != nil {
 		return nil, errors.Wrap(err, "deleting from writelogger")
 	}
@@ -993,11 +1070,16 @@ func (c *Controller) RemoveShards(ctx context.Context, qtid dax.QualifiedTableID
 		// and therefore need to be sent an updated Directive.
 		workerSet := NewAddressSet()
 
+		qdb, err := c.Schemar.DatabaseByID(tx, qtid.QualifiedDatabaseID)
+		if err != nil {
+			return errors.Wrap(err, "getting database")
+		}
+
 		for _, s := range shards {
 			// We don't currently use the returned diff, other than to determine
 			// which worker was affected, because we send the full Directive every
 			// time.
-			diffs, err := c.Balancer.RemoveJobs(tx, dax.RoleTypeCompute, qtid, shard(qtid.Key(), s).Job())
+			diffs, err := c.Balancer.RemoveJobs(tx, dax.RoleTypeCompute, qtid, shardReplicas(qtid.Key(), s, qdb.Options.Replicas())...)
 			if err != nil {
 				return errors.Wrap(err, "removing job")
 			}
@@ -1010,7 +1092,6 @@ func (c *Controller) RemoveShards(ctx context.Context, qtid dax.QualifiedTableID
 		// the appropriate method.
 		addrMethods := applyAddressMethod(workerSet.SortedSlice(), dax.DirectiveMethodFull)
 
-		var err error
 		directives, err = c.buildDirectives(ctx, tx, addrMethods)
 		if err != nil {
 			return errors.Wrap(err, "building directives")
@@ -1130,6 +1211,10 @@ func (c *Controller) buildDirectives(ctx context.Context, tx dax.Transaction, ad
 		// can contain a mixture of table/shards.
 		computeMap := make(map[dax.TableKey][]dax.ShardNum)
 
+		// replicaMap is the same as computeMap, but for the shards which the
+		// worker holds as read-only replicas.
+		replicaMap := make(map[dax.TableKey][]dax.ShardNum)
+
 		// translateMap maps a table to a list of partitions for that table. We
 		// need to aggregate them here because the list of jobs from
 		// WorkerState() can contain a mixture of table/partitions.
@@ -1158,7 +1243,11 @@ func (c *Controller) buildDirectives(ctx context.Context, tx dax.Transaction, ad
 					}
 
 					tkey := j.table()
-					computeMap[tkey] = append(computeMap[tkey], j.shardNum())
+					if j.replica() > 0 {
+						replicaMap[tkey] = append(replicaMap[tkey], j.shardNum())
+					} else {
+						computeMap[tkey] = append(computeMap[tkey], j.shardNum())
+					}
 					tableSet.Add(tkey)
 				}
 			case dax.RoleTypeTranslate:
@@ -1196,6 +1285,16 @@ func (c *Controller) buildDirectives(ctx context.Context, tx dax.Transaction, ad
 			})
 		}
 
+		// Convert the replicaMap into a list of ComputeRole.
+		for k, v := range replicaMap {
+			sort.Sort(dax.ShardNums(v))
+
+			d.ReplicaRoles = append(d.ReplicaRoles, dax.ComputeRole{
+				TableKey: k,
+				Shards:   v,
+			})
+		}
+
 		// Convert the translateMap into a list of TranslateRole.
 		for k, v := range translateMap {
 			// Because these were encoded as strings in the balancer and may be
@@ -1261,8 +1360,9 @@ func (c *Controller) buildDirectives(ctx context.Context, tx dax.Transaction, ad
 			d.Tables = dTables
 		}
 
-		// Sort ComputeRoles by table.
+		// Sort ComputeRoles and ReplicaRoles by table.
 		sort.Slice(d.ComputeRoles, func(i, j int) bool { return d.ComputeRoles[i].TableKey < d.ComputeRoles[j].TableKey })
+		sort.Slice(d.ReplicaRoles, func(i, j int) bool { return d.ReplicaRoles[i].TableKey < d.ReplicaRoles[j].TableKey })
 
 		// Sort TranslateRoles by table.
 		sort.Slice(d.TranslateRoles, func(i, j int) bool { return d.TranslateRoles[i].TableKey < d.TranslateRoles[j].TableKey })
@@ -1319,6 +1419,11 @@ func (c *Controller) buildDirectivesAsDiffs(ctx context.Context, tx dax.Transact
 		computeMapAdded := make(map[dax.TableKey][]dax.ShardNum)
 		computeMapRemoved := make(map[dax.TableKey][]dax.ShardNum)
 
+		// replicaMapAdded and replicaMapRemoved are the same as
+		// computeMapAdded and computeMapRemoved, but for read-only replicas.
+		replicaMapAdded := make(map[dax.TableKey][]dax.ShardNum)
+		replicaMapRemoved := make(map[dax.TableKey][]dax.ShardNum)
+
 		// translateMapAdded maps a table to a list of partitions added for that
 		// table. We need to aggregate them here because the list of jobs from
 		// WorkerDiff can contain a mixture of table/partitions.
@@ -1340,6 +1445,11 @@ func (c *Controller) buildDirectivesAsDiffs(ctx context.Context, tx dax.Transact
 				}
 
 				tkey := j.table()
+				if j.replica() > 0 {
+					replicaMapAdded[tkey] = append(replicaMapAdded[tkey], j.shardNum())
+					tableSet.Add(tkey)
+					continue
+				}
 				computeMapAdded[tkey] = append(computeMapAdded[tkey], j.shardNum())
 				tableSet.Add(tkey)
 			}
@@ -1350,6 +1460,11 @@ func (c *Controller) buildDirectivesAsDiffs(ctx context.Context, tx dax.Transact
 				}
 
 				tkey := j.table()
+				if j.replica() > 0 {
+					replicaMapRemoved[tkey] = append(replicaMapRemoved[tkey], j.shardNum())
+					tableSet.Add(tkey)
+					continue
+				}
 				computeMapRemoved[tkey] = append(computeMapRemoved[tkey], j.shardNum())
 				tableSet.Add(tkey)
 			}
@@ -1418,6 +1533,23 @@ func (c *Controller) buildDirectivesAsDiffs(ctx context.Context, tx dax.Transact
 			})
 		}
 
+		// Convert the replicaMapAdded and replicaMapRemoved into lists of
+		// ComputeRole.
+		for k, v := range replicaMapAdded {
+			sort.Sort(dax.ShardNums(v))
+			d.ReplicaRolesAdded = append(d.ReplicaRolesAdded, dax.ComputeRole{
+				TableKey: k,
+				Shards:   v,
+			})
+		}
+		for k, v := range replicaMapRemoved {
+			sort.Sort(dax.ShardNums(v))
+			d.ReplicaRolesRemoved = append(d.ReplicaRolesRemoved, dax.ComputeRole{
+				TableKey: k,
+				Shards:   v,
+			})
+		}
+
 		// Convert the translateMapAdded into a list of TranslateRole.
 		for k, v := range translateMapAdded {
 			// Because these were encoded as strings in the balancer and may be
@@ -1532,6 +1664,8 @@ func (c *Controller) buildDirectivesAsDiffs(ctx context.Context, tx dax.Transact
 		// Sort ComputeRolesAdded by table.
 		sort.Slice(d.ComputeRolesAdded, func(i, j int) bool { return d.ComputeRolesAdded[i].TableKey < d.ComputeRolesAdded[j].TableKey })
 		sort.Slice(d.ComputeRolesRemoved, func(i, j int) bool { return d.ComputeRolesRemoved[i].TableKey < d.ComputeRolesRemoved[j].TableKey })
+		sort.Slice(d.ReplicaRolesAdded, func(i, j int) bool { return d.ReplicaRolesAdded[i].TableKey < d.ReplicaRolesAdded[j].TableKey })
+		sort.Slice(d.ReplicaRolesRemoved, func(i, j int) bool { return d.ReplicaRolesRemoved[i].TableKey < d.ReplicaRolesRemoved[j].TableKey })
 
 		// Sort TranslateRolesAdded by table.
 		sort.Slice(d.TranslateRolesAdded, func(i, j int) bool { return d.TranslateRolesAdded[i].TableKey < d.TranslateRolesAdded[j].TableKey })
@@ -1720,7 +1854,7 @@ func (c *Controller) ComputeNodes(ctx context.Context, qtid dax.QualifiedTableID
 		return computeNodes, nil
 	}
 
-	assignedNodes, _, _, err := c.nodesComputeReadOrWrite(ctx, tx, role, qdbid, false, false)
+	assignedNodes, _, _, err := c.nodesComputeReadOrWrite(ctx, tx, role, qdbid, false, false, true)
 	if err != nil {
 		return nil, errors.Wrap(err, "getting compute nodes read or write")
 	}
@@ -1932,7 +2066,7 @@ func (c *Controller) IngestShard(ctx context.Context, qtid dax.QualifiedTableID,
 		}
 
 		var err error
-		nodes, retryAsWrite, directives, err = c.nodesComputeReadOrWrite(ctx, tx, role, qdbid, true, writable)
+		nodes, retryAsWrite, directives, err = c.nodesComputeReadOrWrite(ctx, tx, role, qdbid, true, writable, false)
 		if err != nil {
 			return errors.Wrap(err, "getting compute nodes read or write")
 		}
@@ -956,6 +956,113 @@ func TestController(t *testing.T) {
 		}
 	})
 
+	t.Run("BalanceByLoad", func(t *testing.T) {
+		director := newTestDirector()
+		schemar := sqldb.NewSchemar(logger.StderrLogger)
+		err := trans.Start()
+		require.NoError(t, err, "starting transactor")
+
+		defer func() {
+			trans.TruncateAll()
+		}()
+
+		cfg := controller.Config{}
+		con := controller.New(cfg)
+		con.Schemar = schemar
+		con.Balancer = sqldb.NewBalancer(logger.StderrLogger)
+		con.DirectiveVersion = sqldb.NewDirectiveVersion(logger.StderrLogger)
+		con.Director = director
+		con.Transactor = trans
+
+		// Register two nodes.
+		node0 := &dax.Node{
+			Address: "10.0.0.1:80",
+			RoleTypes: []dax.RoleType{
+				dax.RoleTypeCompute,
+			},
+		}
+		assert.NoError(t, con.RegisterNodes(ctx, node0))
+		node1 := &dax.Node{
+			Address: "10.0.0.1:81",
+			RoleTypes: []dax.RoleType{
+				dax.RoleTypeCompute,
+			},
+		}
+		assert.NoError(t, con.RegisterNodes(ctx, node1))
+
+		// Add a qualified database.
+		dbOptions := dax.DatabaseOptions{
+			WorkersMin: 2,
+			WorkersMax: 2,
+		}
+		qdb1 := daxtest.TestQualifiedDatabaseWithID(t, qdbid.OrganizationID, qdbid.DatabaseID, "dbname1", dbOptions)
+		assert.NoError(t, con.CreateDatabase(ctx, qdb1))
+
+		// Add a non-keyed table.
+		tbl0 := daxtest.TestQualifiedTable(t, qdbid, "foo", 0, false)
+		assert.NoError(t, con.CreateTable(ctx, tbl0))
+
+		// With no load reported, shards are balanced by count: node0 has
+		// shards 0 and 2, and node1 has shards 1 and 3.
+		addShards(t, ctx, con, tbl0.QualifiedID(), 0, 1, 2, 3)
+		director.flush()
+
+		job := func(shard dax.ShardNum) dax.Job {
+			return dax.Job(fmt.Sprintf("%s|shard_%s", tbl0.Key(), shard))
+		}
+
+		// Nothing to move while every shard costs the same.
+		diffs, err := con.BalanceDatabase(ctx, qdbid, true)
+		require.NoError(t, err)
+		assert.Empty(t, diffs)
+
+		// Shard 0 is much larger than the others, so node0 should give up
+		// shard 2.
+		require.NoError(t, con.RecordLoad(ctx, node0.Address, &dax.WorkerLoad{
+			Shards: []dax.ShardLoad{
+				{TableKey: tbl0.Key(), Shard: 0, Bytes: 10 << 30},
+				{TableKey: tbl0.Key(), Shard: 2, Bytes: 1 << 20},
+			},
+		}))
+
+		exp := []dax.WorkerDiff{
+			{
+				Address:     node0.Address,
+				AddedJobs:   []dax.Job{},
+				RemovedJobs: []dax.Job{job(2)},
+			},
+			{
+				Address:     node1.Address,
+				AddedJobs:   []dax.Job{job(2)},
+				RemovedJobs: []dax.Job{},
+			},
+		}
+
+		// A dry run returns the moves without applying them, so running it
+		// again returns the same moves.
+		for i := 0; i < 2; i++ {
+			diffs, err := con.BalanceDatabase(ctx, qdbid, true)
+			require.NoError(t, err)
+			assert.Equal(t, exp, diffs)
+			assert.Empty(t, director.flush())
+		}
+
+		// Apply the moves.
+		diffs, err = con.BalanceDatabase(ctx, qdbid, false)
+		require.NoError(t, err)
+		assert.Equal(t, exp, diffs)
+
+		got := director.flush()
+		require.Equal(t, 2, len(got))
+		assert.Equal(t, dax.NewShardNums(0), got[0].ComputeShards(tbl0.Key()))
+		assert.Equal(t, dax.NewShardNums(1, 2, 3), got[1].ComputeShards(tbl0.Key()))
+
+		// Now that the jobs are balanced, there is nothing more to move.
+		diffs, err = con.BalanceDatabase(ctx, qdbid, true)
+		require.NoError(t, err)
+		assert.Empty(t, diffs)
+	})
+
 	t.Run("GetNodes", func(t *testing.T) {
 		schemar := sqldb.NewSchemar(logger.StderrLogger)
 		err := trans.Start()
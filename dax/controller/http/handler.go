@@ -37,6 +37,12 @@ func Handler(c *controller.Controller) http.Handler {
 	router.HandleFunc("/ingest-partition", server.postIngestPartition).Methods("POST").Name("PostIngestPartition")
 	router.HandleFunc("/ingest-shard", server.postIngestShard).Methods("POST").Name("PostIngestShard")
 
+	router.HandleFunc("/balance-database", server.postBalanceDatabase).Methods("POST").Name("PostBalanceDatabase")
+
+	router.HandleFunc("/query-stats", server.postQueryStats).Methods("POST").Name("PostQueryStats")
+	router.HandleFunc("/autoscaler/state", server.getAutoscalerState).Methods("GET").Name("GetAutoscalerState")
+	router.HandleFunc("/autoscaler/history", server.getAutoscalerHistory).Methods("GET").Name("GetAutoscalerHistory")
+
 	router.HandleFunc("/snapshot", server.postSnapshot).Methods("POST").Name("PostSnapshot")
 	router.HandleFunc("/snapshot/shard-data", server.postSnapshotShardData).Methods("POST").Name("PostShapshotShardData")
 	router.HandleFunc("/snapshot/table-keys", server.postSnapshotTableKeys).Methods("POST").Name("PostShapshotTableKeys")
@@ -216,13 +222,102 @@ func (s *server) patchDatabaseOptions(w http.ResponseWriter, r *http.Request) {
 
 // DatabaseOptionRequest represents a change to a database option. The thinking
 // is to only support changing one database option at a time to keep the
-// implementation sane. At time of writing, only WorkersMin is supported.
+// implementation sane. At time of writing, only WorkersMin and ReplicaN are
+// supported.
 type DatabaseOptionRequest struct {
 	QualifiedDatabaseID dax.QualifiedDatabaseID `json:"qdbid"`
 	Option              string                  `json:"option"`
 	Value               string                  `json:"value"`
 }
 
+// POST /balance-database
+func (s *server) postBalanceDatabase(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer body.Close()
+
+	ctx := r.Context()
+
+	req := BalanceDatabaseRequest{}
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	diffs, err := s.controller.BalanceDatabase(ctx, req.QualifiedDatabaseID, req.DryRun)
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		return
+	}
+
+	resp := BalanceDatabaseResponse{
+		Diffs: diffs,
+	}
+
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+}
+
+// BalanceDatabaseRequest is used to balance the jobs of a database across its
+// workers. If DryRun is true, the moves which balancing would make are
+// returned without being applied.
+type BalanceDatabaseRequest struct {
+	QualifiedDatabaseID dax.QualifiedDatabaseID `json:"qdbid"`
+	DryRun              bool                    `json:"dry-run"`
+}
+
+// BalanceDatabaseResponse contains the job moves, per worker, resulting from a
+// BalanceDatabaseRequest.
+type BalanceDatabaseResponse struct {
+	Diffs []dax.WorkerDiff `json:"diffs"`
+}
+
+// POST /query-stats
+func (s *server) postQueryStats(w http.ResponseWriter, r *http.Request) {
+	body := r.Body
+	defer body.Close()
+
+	ctx := r.Context()
+
+	req := dax.QueryStats{}
+	if err := json.NewDecoder(body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := s.controller.RecordQueryStats(ctx, req); err != nil {
+		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		return
+	}
+}
+
+// GET /autoscaler/state
+func (s *server) getAutoscalerState(w http.ResponseWriter, r *http.Request) {
+	state, err := s.controller.AutoscalerState(r.Context())
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), http.StatusInternalServerError)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(state); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// GET /autoscaler/history
+func (s *server) getAutoscalerHistory(w http.ResponseWriter, r *http.Request) {
+	events, err := s.controller.AutoscalerHistory(r.Context())
+	if err != nil {
+		http.Error(w, errors.MarshalJSON(err), http.StatusBadRequest)
+		return
+	}
+	if err := json.NewEncoder(w).Encode(events); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
 // POST /create-table
 func (s *server) postCreateTable(w http.ResponseWriter, r *http.Request) {
 	body := r.Body
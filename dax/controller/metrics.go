@@ -0,0 +1,47 @@
+package controller
+
+import "github.com/prometheus/client_golang/prometheus"
+
+const (
+	metricSnapshotDecisions = "dax_snapshot_decisions_total"
+	metricWriteLogBytes     = "dax_write_log_bytes"
+)
+
+// Values for the "decision" label of counterSnapshotDecisions.
+const (
+	snapshotDecisionSnapshot = "snapshot"
+	snapshotDecisionSkip     = "skip"
+)
+
+// counterSnapshotDecisions counts, by resource type, the resources which the
+// snapping turtle snapshotted or skipped.
+var counterSnapshotDecisions = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      metricSnapshotDecisions,
+		Help:      "Number of resources snapshotted or skipped by the snapping turtle.",
+	},
+	[]string{
+		"type",
+		"decision",
+	},
+)
+
+// gaugeWriteLogBytes is the combined size, by resource type, of the write logs
+// which had not been incorporated into a snapshot as of the snapping turtle's
+// most recent run.
+var gaugeWriteLogBytes = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      metricWriteLogBytes,
+		Help:      "Size of the write logs not yet incorporated into a snapshot.",
+	},
+	[]string{
+		"type",
+	},
+)
+
+func init() {
+	prometheus.MustRegister(counterSnapshotDecisions)
+	prometheus.MustRegister(gaugeWriteLogBytes)
+}
@@ -12,5 +12,11 @@ type Config struct {
 	WorkerRegistry dax.WorkerRegistry
 	NodePoller     NodePoller
 	PollInterval   time.Duration
-	Logger         logger.Logger
+
+	// LoadRecorder, if set, is given the load reported by each node which
+	// was successfully polled. The NodePoller must also implement
+	// LoadPoller.
+	LoadRecorder LoadRecorder
+
+	Logger logger.Logger
 }
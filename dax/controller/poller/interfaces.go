@@ -1,11 +1,14 @@
 package poller
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
 )
 
@@ -15,9 +18,22 @@ type NodePoller interface {
 	Poll(dax.Address) bool
 }
 
+// LoadPoller is an interface to anything which has the ability to fetch the
+// load reported by a node.
+type LoadPoller interface {
+	PollLoad(dax.Address) (*dax.WorkerLoad, error)
+}
+
+// LoadRecorder is an interface to anything which records the load reported by
+// nodes.
+type LoadRecorder interface {
+	RecordLoad(context.Context, dax.Address, *dax.WorkerLoad) error
+}
+
 // Ensure type implements interface.
 var _ NodePoller = (*NopNodePoller)(nil)
 var _ NodePoller = (*HTTPNodePoller)(nil)
+var _ LoadPoller = (*HTTPNodePoller)(nil)
 
 // NopNodePoller is a no-op implementation of the NodePoller interface.
 type NopNodePoller struct{}
@@ -57,3 +73,25 @@ func (p *HTTPNodePoller) Poll(addr dax.Address) bool {
 
 	return true
 }
+
+// PollLoad fetches the load reported by the node at its /directive/load
+// endpoint.
+func (p *HTTPNodePoller) PollLoad(addr dax.Address) (*dax.WorkerLoad, error) {
+	url := fmt.Sprintf("%s/directive/load", addr.WithScheme("http"))
+
+	resp, err := p.client.Get(url)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting load")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("status code: %d", resp.StatusCode)
+	}
+
+	load := &dax.WorkerLoad{}
+	if err := json.NewDecoder(resp.Body).Decode(load); err != nil {
+		return nil, errors.Wrap(err, "decoding load")
+	}
+	return load, nil
+}
@@ -21,6 +21,8 @@ type Poller struct {
 	nodePoller   NodePoller
 	pollInterval time.Duration
 
+	loadRecorder LoadRecorder
+
 	stopping chan struct{}
 
 	logger logger.Logger
@@ -49,6 +51,9 @@ func New(cfg Config) *Poller {
 	if cfg.PollInterval != 0 {
 		p.pollInterval = cfg.PollInterval
 	}
+	if cfg.LoadRecorder != nil {
+		p.loadRecorder = cfg.LoadRecorder
+	}
 	if cfg.Logger != nil {
 		p.logger = cfg.Logger
 	}
@@ -114,7 +119,9 @@ func (p *Poller) pollAll() {
 		if !up {
 			p.logger.Printf("poller removing %s", addr)
 			toRemove = append(toRemove, addr)
+			continue
 		}
+		p.pollLoad(ctx, addr)
 	}
 
 	if len(toRemove) > 0 {
@@ -128,3 +135,22 @@ func (p *Poller) pollAll() {
 	}
 
 }
+
+// pollLoad fetches the load reported by the node at addr and passes it to the
+// LoadRecorder. Failing to get the load of a node which is up is not fatal to
+// the node; the balancer just continues to use the last load reported.
+func (p *Poller) pollLoad(ctx context.Context, addr dax.Address) {
+	lp, ok := p.nodePoller.(LoadPoller)
+	if !ok || p.loadRecorder == nil {
+		return
+	}
+
+	load, err := lp.PollLoad(addr)
+	if err != nil {
+		p.logger.Debugf("POLLER: getting load for %s: %v", addr, err)
+		return
+	}
+	if err := p.loadRecorder.RecordLoad(ctx, addr, load); err != nil {
+		p.logger.Printf("POLLER: recording load for %s: %v", addr, err)
+	}
+}
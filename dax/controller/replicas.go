@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/errors"
+)
+
+// reconcileReplicas adds or removes shard jobs so that every shard in the
+// database has exactly as many replicas as the database's ReplicaN option
+// specifies. It returns the resulting changes to the workers' jobs.
+func (c *Controller) reconcileReplicas(tx dax.Transaction, qdbid dax.QualifiedDatabaseID) (dax.WorkerDiffs, error) {
+	qdb, err := c.Schemar.DatabaseByID(tx, qdbid)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting database")
+	}
+	replicaN := qdb.Options.Replicas()
+
+	state, err := c.Balancer.CurrentState(tx, dax.RoleTypeCompute, qdbid)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current compute state")
+	}
+
+	// Collect the shards in the database, by table, along with any replicas
+	// which are beyond the desired number of replicas.
+	shards := make(map[dax.TableKey][]sUnit)
+	seen := make(map[sUnit]struct{})
+	extra := make(map[dax.TableKey][]dax.Job)
+	for _, worker := range state {
+		for _, job := range worker.Jobs {
+			j, err := decodeShard(job)
+			if err != nil {
+				return nil, errors.Wrapf(err, "decoding shard job: %s", job)
+			}
+			if j.replica() >= replicaN {
+				extra[j.table()] = append(extra[j.table()], job)
+			}
+			s := shard(j.table(), j.shardNum())
+			if _, ok := seen[s]; ok {
+				continue
+			}
+			seen[s] = struct{}{}
+			shards[j.table()] = append(shards[j.table()], s)
+		}
+	}
+
+	diffs := dax.WorkerDiffs{}
+
+	for tkey, jobs := range extra {
+		d, err := c.Balancer.RemoveJobs(tx, dax.RoleTypeCompute, tkey.QualifiedTableID(), jobs...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "removing replica jobs: %s", tkey)
+		}
+		diffs = diffs.Apply(d)
+	}
+
+	// The Balancer skips any job which already exists, so we can add every
+	// replica of every shard.
+	for tkey, units := range shards {
+		jobs := make([]dax.Job, 0, len(units)*replicaN)
+		for _, s := range units {
+			jobs = append(jobs, shardReplicas(s.table(), s.shardNum(), replicaN)...)
+		}
+		d, err := c.Balancer.AddJobs(tx, dax.RoleTypeCompute, tkey.QualifiedTableID(), jobs...)
+		if err != nil {
+			return nil, errors.Wrapf(err, "adding replica jobs: %s", tkey)
+		}
+		diffs = diffs.Apply(d)
+	}
+
+	return diffs, nil
+}
@@ -8,6 +8,8 @@ import (
 	"github.com/featurebasedb/featurebase/v3/dax/controller"
 	controllerhttp "github.com/featurebasedb/featurebase/v3/dax/controller/http"
 	"github.com/featurebasedb/featurebase/v3/dax/controller/sqldb"
+	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
+	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
 	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
 	fbnet "github.com/featurebasedb/featurebase/v3/net"
@@ -55,6 +57,22 @@ func New(uri *fbnet.URI, cfg controller.Config) *controllerService {
 		os.Exit(1)
 	}
 
+	// Object storage.
+	if cfg.DAXStore.Bucket != "" {
+		wl, err := writelogger.NewS3(cfg.DAXStore, logr)
+		if err != nil {
+			logr.Printf("setting up writelogger: %v", err)
+			os.Exit(1)
+		}
+		ss, err := snapshotter.NewS3(cfg.DAXStore, logr)
+		if err != nil {
+			logr.Printf("setting up snapshotter: %v", err)
+			os.Exit(1)
+		}
+		controller.Writelogger = wl
+		controller.Snapshotter = ss
+	}
+
 	if cfg.Director != nil {
 		controller.Director = cfg.Director
 	}
@@ -2,12 +2,135 @@ package controller
 
 import (
 	"context"
+	"fmt"
+	"path"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
+	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/logger"
 )
 
+// Resource types used to label snapshot metrics.
+const (
+	snapTypeShard     = "shard"
+	snapTypeTableKeys = "table_keys"
+	snapTypeFieldKeys = "field_keys"
+)
+
+// snapTracker remembers the state of each write log as of its most recent
+// snapshot so that the snapping turtle can skip resources which haven't been
+// written to since. Entries are keyed by the write log's bucket/key path.
+type snapTracker struct {
+	mu sync.Mutex
+	m  map[string]writelogger.LogStat
+}
+
+func newSnapTracker() *snapTracker {
+	return &snapTracker{
+		m: make(map[string]writelogger.LogStat),
+	}
+}
+
+func (t *snapTracker) get(k string) (writelogger.LogStat, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	stat, ok := t.m[k]
+	return stat, ok
+}
+
+func (t *snapTracker) set(k string, stat writelogger.LogStat) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.m[k] = stat
+}
+
+// removeTable removes the entries for all of the given table's write logs.
+func (t *snapTracker) removeTable(tkey dax.TableKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	prefix := string(tkey) + "/"
+	for k := range t.m {
+		if strings.HasPrefix(k, prefix) {
+			delete(t.m, k)
+		}
+	}
+}
+
+// snapPass holds the state of a single run of the snapping turtle.
+type snapPass struct {
+	// bytes is the combined size of the write logs seen, by resource type.
+	bytes map[string]int64
+}
+
+func newSnapPass() *snapPass {
+	return &snapPass{
+		bytes: map[string]int64{
+			snapTypeShard:     0,
+			snapTypeTableKeys: 0,
+			snapTypeFieldKeys: 0,
+		},
+	}
+}
+
+// The write log locations below must match those used by the computer's
+// storage resources.
+
+func shardWriteLog(tkey dax.TableKey, shardNum dax.ShardNum) (string, string) {
+	partitionNum := disco.ShardToShardPartition(string(tkey), uint64(shardNum), disco.DefaultPartitionN)
+	return path.Join(string(tkey), "partition", fmt.Sprintf("%d", partitionNum)),
+		path.Join("shard", fmt.Sprintf("%d", shardNum))
+}
+
+func tableKeysWriteLog(tkey dax.TableKey, partitionNum dax.PartitionNum) (string, string) {
+	return path.Join(string(tkey), "partition", fmt.Sprintf("%d", partitionNum)), "keys"
+}
+
+func fieldKeysWriteLog(tkey dax.TableKey, field dax.FieldName) (string, string) {
+	return path.Join(string(tkey), "field", string(field)), "keys"
+}
+
+// shouldSnapshot reports whether the write log at bucket/key has grown beyond
+// the snapshot threshold since the resource was last snapshotted. If it
+// returns true, the caller should pass the returned LogStat to
+// c.snapTracker.set once the snapshot succeeds.
+func (c *Controller) shouldSnapshot(pass *snapPass, typ, bucket, key string, log logger.Logger) (writelogger.LogStat, bool) {
+	decide := func(snap bool) bool {
+		decision := snapshotDecisionSkip
+		if snap {
+			decision = snapshotDecisionSnapshot
+		}
+		counterSnapshotDecisions.WithLabelValues(typ, decision).Inc()
+		return snap
+	}
+
+	stat, err := c.Writelogger.Stat(bucket, key)
+	if err != nil {
+		// Without knowing the size of the write log, err on the side of
+		// snapshotting.
+		log.Printf("couldn't stat write log %s/%s, snapshotting anyway: %v", bucket, key, err)
+		return stat, decide(true)
+	}
+	pass.bytes[typ] += stat.Size
+
+	// Nothing has been written since the last snapshot, or not enough to
+	// be worth snapshotting yet.
+	if stat.Size == 0 || stat.Size <= c.snappingTurtleThreshold {
+		return stat, decide(false)
+	}
+
+	// The write log hasn't changed since we last requested a snapshot of
+	// it, so requesting another one won't accomplish anything.
+	if last, ok := c.snapTracker.get(path.Join(bucket, key)); ok && last == stat {
+		return stat, decide(false)
+	}
+
+	return stat, decide(true)
+}
+
 func (c *Controller) snappingTurtleRoutine(period time.Duration, control chan struct{}, log logger.Logger) error {
 	if period == 0 {
 		return nil
@@ -46,12 +169,20 @@ func (c *Controller) snapAll(log logger.Logger) {
 		log.Printf("couldn't get databases: %v", err)
 	}
 
+	pass := newSnapPass()
 	for _, qdb := range qdbs {
-		c.snapAllForDatabase(tx, qdb.QualifiedID(), log)
+		c.snapAllForDatabase(tx, qdb.QualifiedID(), pass, log)
+	}
+
+	for typ, bytes := range pass.bytes {
+		gaugeWriteLogBytes.WithLabelValues(typ).Set(float64(bytes))
 	}
 }
 
-func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, log logger.Logger) {
+// snapAllForDatabase snapshots the shards, table key partitions, and keyed
+// fields in the given database whose write logs have grown beyond
+// snappingTurtleThreshold bytes since they were last snapshotted.
+func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedDatabaseID, pass *snapPass, log logger.Logger) {
 	log.Debugf("snapAllForDatabase: %s", qdbid)
 	computeNodes, err := c.Balancer.CurrentState(tx, dax.RoleTypeCompute, qdbid)
 	if err != nil {
@@ -74,18 +205,30 @@ func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedD
 			j, err := decodeShard(workerInfo.Jobs[i])
 			if err != nil {
 				log.Printf("couldn't decode a shard out of the job: '%s', err: %v", workerInfo.Jobs[i], err)
+				continue
+			}
+			// Only the replica which accepts writes is snapshotted.
+			if j.replica() > 0 {
+				continue
+			}
+			bucket, key := shardWriteLog(j.table(), j.shardNum())
+			stat, ok := c.shouldSnapshot(pass, snapTypeShard, bucket, key, log)
+			if !ok {
+				continue
 			}
 			if err := c.snapshotShardData(tx, j.t.QualifiedTableID(), j.shardNum()); err != nil {
 				log.Printf("Couldn't snapshot table: %s, shard: %d, error: %v", j.t, j.shardNum(), err)
+				continue
 			}
+			c.snapTracker.set(path.Join(bucket, key), stat)
 		}
 		i++
 	}
 
-	// Get all tables across all orgs/dbs so we can snapshot all keyed
+	// Get all tables in the database so we can snapshot all keyed
 	// fields and look up whether a table is keyed to snapshot its
 	// partitions.
-	tables, err := c.Schemar.Tables(tx, dax.QualifiedDatabaseID{})
+	tables, err := c.Schemar.Tables(tx, qdbid)
 	if err != nil {
 		log.Printf("Couldn't get schema for snapshotting keys: %v", err)
 		return
@@ -95,11 +238,19 @@ func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedD
 	for _, table := range tables {
 		tableMap[table.Key()] = table
 		for _, f := range table.Fields {
-			if f.StringKeys() && !f.IsPrimaryKey() {
-				if err := c.snapshotFieldKeys(tx, table.QualifiedID(), f.Name); err != nil {
-					log.Printf("Couldn't snapshot table: %s, field: %s, error: %v", table, f.Name, err)
-				}
+			if !f.StringKeys() || f.IsPrimaryKey() {
+				continue
+			}
+			bucket, key := fieldKeysWriteLog(table.Key(), f.Name)
+			stat, ok := c.shouldSnapshot(pass, snapTypeFieldKeys, bucket, key, log)
+			if !ok {
+				continue
+			}
+			if err := c.snapshotFieldKeys(tx, table.QualifiedID(), f.Name); err != nil {
+				log.Printf("Couldn't snapshot table: %s, field: %s, error: %v", table, f.Name, err)
+				continue
 			}
+			c.snapTracker.set(path.Join(bucket, key), stat)
 		}
 	}
 
@@ -123,14 +274,23 @@ func (c *Controller) snapAllForDatabase(tx dax.Transaction, qdbid dax.QualifiedD
 			stillWorking = true
 			j, err := decodePartition(workerInfo.Jobs[i])
 			if err != nil {
-				table := tableMap[j.table()]
-				if table.StringKeys() {
-					if err := c.snapshotTableKeys(tx, table.QualifiedID(), j.partitionNum()); err != nil {
-						log.Printf("Couldn't snapshot table: %s, partition: %d, error: %v", table, j.partitionNum(), err)
-					}
-				}
 				log.Printf("couldn't decode a partition out of the job: '%s', err: %v", workerInfo.Jobs[i], err)
+				continue
+			}
+			table, ok := tableMap[j.table()]
+			if !ok || !table.StringKeys() {
+				continue
+			}
+			bucket, key := tableKeysWriteLog(j.table(), j.partitionNum())
+			stat, ok := c.shouldSnapshot(pass, snapTypeTableKeys, bucket, key, log)
+			if !ok {
+				continue
+			}
+			if err := c.snapshotTableKeys(tx, table.QualifiedID(), j.partitionNum()); err != nil {
+				log.Printf("Couldn't snapshot table: %s, partition: %d, error: %v", table, j.partitionNum(), err)
+				continue
 			}
+			c.snapTracker.set(path.Join(bucket, key), stat)
 		}
 		i++
 	}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"path"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestController_shouldSnapshot(t *testing.T) {
+	wl := writelogger.New(t.TempDir(), logger.NopLogger)
+	c := New(Config{
+		SnappingTurtleThreshold: 4,
+		Logger:                  logger.NopLogger,
+	})
+	c.Writelogger = wl
+
+	tkey := dax.TableKey("tbl__tkey")
+	bucket, key := shardWriteLog(tkey, 1)
+
+	decisions := []bool{}
+	check := func() {
+		pass := newSnapPass()
+		stat, ok := c.shouldSnapshot(pass, snapTypeShard, bucket, key, logger.NopLogger)
+		decisions = append(decisions, ok)
+		if ok {
+			c.snapTracker.set(path.Join(bucket, key), stat)
+		}
+	}
+
+	// No write log.
+	check()
+
+	// A write log which hasn't grown beyond the threshold.
+	assert.NoError(t, wl.AppendMessage(bucket, key, 0, []byte("ab")))
+	check()
+
+	// A write log which has.
+	assert.NoError(t, wl.AppendMessage(bucket, key, 0, []byte("cd")))
+	check()
+
+	// The write log is unchanged since it was snapshotted.
+	check()
+
+	// The snapshot was taken and a new write log started.
+	assert.NoError(t, wl.DeleteLog(bucket, key, 0))
+	assert.NoError(t, wl.AppendMessage(bucket, key, 1, []byte("efghij")))
+	check()
+
+	// Dropping the table forgets the write log, so an unchanged write log
+	// is snapshotted again.
+	c.snapTracker.removeTable(tkey)
+	check()
+
+	assert.Equal(t, []bool{false, false, true, false, true, true}, decisions)
+}
@@ -116,6 +116,7 @@ func toModelDatabase(qdb *dax.QualifiedDatabase) *models.Database {
 		Name:        db.Name,
 		WorkersMin:  db.Options.WorkersMin,
 		WorkersMax:  db.Options.WorkersMax,
+		ReplicaN:    db.Options.Replicas(),
 		Description: db.Description,
 		Owner:       db.Owner,
 		UpdatedBy:   db.UpdatedBy,
@@ -135,6 +136,7 @@ func toQualifiedDatabase(db *models.Database) *dax.QualifiedDatabase {
 			Options: dax.DatabaseOptions{
 				WorkersMin: db.WorkersMin,
 				WorkersMax: db.WorkersMax,
+				ReplicaN:   db.ReplicaN,
 			},
 			Description: db.Description,
 			Owner:       db.Owner,
@@ -181,6 +183,14 @@ func (s *Schemar) SetDatabaseOption(tx dax.Transaction, qdbid dax.QualifiedDatab
 		if err != nil {
 			return errors.Wrap(err, "parsing workers max value")
 		}
+	case dax.DatabaseOptionReplicaN:
+		val, err = strconv.ParseInt(value, 0, 64)
+		option = "replica_n" // convert to table column name
+		if err != nil {
+			return errors.Wrap(err, "parsing replica n value")
+		} else if val < 1 {
+			return errors.Errorf("replica n must be at least 1: %d", val)
+		}
 	default:
 		return errors.Errorf("unsupported database option: %s", option)
 	}
@@ -0,0 +1,28 @@
+package controller
+
+import (
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
+	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
+)
+
+// Snapshotter is the snapshot storage which the controller manages on behalf
+// of the computer nodes.
+type Snapshotter interface {
+	DeleteTable(qtid dax.QualifiedTableID) error
+}
+
+// Writelogger is the write log storage which the controller manages on behalf
+// of the computer nodes.
+type Writelogger interface {
+	Stat(bucket, key string) (writelogger.LogStat, error)
+	DeleteTable(qtid dax.QualifiedTableID) error
+}
+
+// Ensure types implement interfaces.
+var (
+	_ Snapshotter = (*snapshotter.Snapshotter)(nil)
+	_ Snapshotter = (*snapshotter.ObjectSnapshotter)(nil)
+	_ Writelogger = (*writelogger.Writelogger)(nil)
+	_ Writelogger = (*writelogger.ObjectWritelogger)(nil)
+)
@@ -66,18 +66,20 @@ func decodePartition(j dax.Job) (pUnit, error) {
 }
 
 // sUnit represents a table/shard combination. As a Stringer, it can be used as
-// a job in the Balancer.
+// a job in the Balancer. A non-zero r indicates a read-only replica of the
+// shard; replica 0 is the compute node which accepts writes for the shard.
 type sUnit struct {
 	t dax.TableKey
 	s dax.ShardNum
+	r int
 }
 
 func (s sUnit) String() string {
-	return fmt.Sprintf("%s|shard_%s", s.t, s.s)
+	return string(s.Job())
 }
 
 func (s sUnit) Job() dax.Job {
-	return dax.Job(fmt.Sprintf("%s|shard_%s", s.t, s.s))
+	return dax.Job(fmt.Sprintf("%s|shard_%s", s.t, s.s)).Replica(s.r)
 }
 
 func (s sUnit) table() dax.TableKey {
@@ -88,15 +90,33 @@ func (s sUnit) shardNum() dax.ShardNum {
 	return s.s
 }
 
+func (s sUnit) replica() int {
+	return s.r
+}
+
 func shard(t dax.TableKey, s dax.ShardNum) sUnit {
-	return sUnit{t, s}
+	return sUnit{t: t, s: s}
+}
+
+// shardReplicas returns the jobs for each of the n replicas of the shard,
+// starting with the job for the replica which accepts writes.
+func shardReplicas(t dax.TableKey, s dax.ShardNum, n int) []dax.Job {
+	if n < 1 {
+		n = 1
+	}
+	jobs := make([]dax.Job, n)
+	for r := range jobs {
+		jobs[r] = sUnit{t: t, s: s, r: r}.Job()
+	}
+	return jobs
 }
 
 func decodeShard(j dax.Job) (sUnit, error) {
-	s := string(j)
+	job, r := j.ReplicaOf()
+	s := string(job)
 	parts := strings.Split(s, "|")
 	if len(parts) != 2 {
-		return sUnit{}, errors.Errorf("cannot decode string to shardV: %s", s)
+		return sUnit{}, errors.Errorf("cannot decode string to shardV: %s", j)
 	}
 	pparts := strings.Split(parts[1], "_")
 	if len(pparts) != 2 {
@@ -110,5 +130,6 @@ func decodeShard(j dax.Job) (sUnit, error) {
 	return sUnit{
 		t: dax.TableKey(parts[0]),
 		s: dax.ShardNum(uint64Var),
+		r: r,
 	}, nil
 }
@@ -0,0 +1,29 @@
+package controller
+
+import (
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeShard(t *testing.T) {
+	jobs := shardReplicas("tbl", 7, 3)
+	assert.Equal(t, []dax.Job{"tbl|shard_7", "tbl|shard_7|replica_1", "tbl|shard_7|replica_2"}, jobs)
+
+	for r, job := range jobs {
+		j, err := decodeShard(job)
+		require.NoError(t, err)
+		assert.Equal(t, dax.TableKey("tbl"), j.table())
+		assert.Equal(t, dax.ShardNum(7), j.shardNum())
+		assert.Equal(t, r, j.replica())
+		assert.Equal(t, job, j.Job())
+	}
+
+	// A replica count less than one still includes the writable replica.
+	assert.Equal(t, []dax.Job{"tbl|shard_7"}, shardReplicas("tbl", 7, 0))
+
+	_, err := decodeShard("tbl|part_7|x")
+	assert.Error(t, err)
+}
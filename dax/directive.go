@@ -18,6 +18,11 @@ type Directive struct {
 	ComputeRoles   []ComputeRole   `json:"compute-roles"`
 	TranslateRoles []TranslateRole `json:"translate-roles"`
 
+	// ReplicaRoles are the shards which the compute node holds as read-only
+	// replicas. It serves queries for them, but another compute node, the one
+	// with the shard in its ComputeRoles, handles writes.
+	ReplicaRoles []ComputeRole `json:"replica-roles,omitempty"`
+
 	// The following members are used by DirectiveMethodDiff. They inlude only
 	// those roles which have changed, as opposed to the entire role set for the
 	// worker.
@@ -25,6 +30,8 @@ type Directive struct {
 	ComputeRolesRemoved   []ComputeRole   `json:"compute-roles-removed"`
 	TranslateRolesAdded   []TranslateRole `json:"translate-roles-added"`
 	TranslateRolesRemoved []TranslateRole `json:"translate-roles-removed"`
+	ReplicaRolesAdded     []ComputeRole   `json:"replica-roles-added,omitempty"`
+	ReplicaRolesRemoved   []ComputeRole   `json:"replica-roles-removed,omitempty"`
 
 	Version uint64 `json:"version"`
 }
@@ -119,16 +126,47 @@ func (d *Directive) ComputeShardsMap() map[TableKey]ShardNums {
 	return m
 }
 
+// ReplicaShardsMap returns a map of table to the shards which this compute
+// node holds as read-only replicas. Shards for which the node is also in the
+// ComputeRoles are excluded; the node holds those as the writer.
+func (d *Directive) ReplicaShardsMap() map[TableKey]ShardNums {
+	m := make(map[TableKey]ShardNums)
+	if d == nil || d.ReplicaRoles == nil {
+		return m
+	}
+
+	primary := shardsMapOfMaps(d.ComputeRoles)
+	for _, rr := range d.ReplicaRoles {
+		shards := make(ShardNums, 0, len(rr.Shards))
+		for _, shardNum := range rr.Shards {
+			if _, ok := primary[rr.TableKey][shardNum]; ok {
+				continue
+			}
+			shards = append(shards, shardNum)
+		}
+		if len(shards) > 0 {
+			m[rr.TableKey] = shards
+		}
+	}
+
+	return m
+}
+
 // computeShardsMapOfMaps returns a map of TableKey to a map of ShardNum in
 // order to support adding and removing shards as distinct values. This map can
 // then be converted back to a slice of ShardNum.
 func (d *Directive) computeShardsMapOfMaps() map[TableKey]map[ShardNum]struct{} {
-	m := make(map[TableKey]map[ShardNum]struct{})
-	if d == nil || d.ComputeRoles == nil {
-		return m
+	if d == nil {
+		return make(map[TableKey]map[ShardNum]struct{})
 	}
+	return shardsMapOfMaps(d.ComputeRoles)
+}
 
-	for _, cr := range d.ComputeRoles {
+// shardsMapOfMaps returns a map of TableKey to a map of ShardNum for the
+// given roles.
+func shardsMapOfMaps(roles []ComputeRole) map[TableKey]map[ShardNum]struct{} {
+	m := make(map[TableKey]map[ShardNum]struct{})
+	for _, cr := range roles {
 		m[cr.TableKey] = make(map[ShardNum]struct{})
 		for _, shardNum := range cr.Shards {
 			m[cr.TableKey][shardNum] = struct{}{}
@@ -239,6 +277,12 @@ func (d *Directive) IsEmpty() bool {
 		}
 	}
 
+	for _, role := range d.ReplicaRoles {
+		if len(role.Shards) > 0 {
+			return false
+		}
+	}
+
 	for _, role := range d.TranslateRoles {
 		if len(role.Partitions) > 0 {
 			return false
@@ -258,6 +302,7 @@ func (d *Directive) Copy() *Directive {
 	ret.Tables = append(ret.Tables, d.Tables...)
 	ret.ComputeRoles = append(ret.ComputeRoles, d.ComputeRoles...)
 	ret.TranslateRoles = append(ret.TranslateRoles, d.TranslateRoles...)
+	ret.ReplicaRoles = append(ret.ReplicaRoles, d.ReplicaRoles...)
 	// We intenionally do not copy the `Added` and `Removed` members because
 	// those are not necessary to keep in the cached Directive (which just needs
 	// to include the full Directive); they are only required when sending the
@@ -275,46 +320,11 @@ func (d *Directive) ApplyDiff(diff *Directive) *Directive {
 		}
 	}
 
-	// cmap is a map of map used to apply the directive diffs. We will convert
-	// the final map to the ComputeRoles member in the returned Directive.
-	cmap := d.computeShardsMapOfMaps()
-
-	// Handle ComputeRolesAdded
-	for _, crole := range diff.ComputeRolesAdded {
-		if _, ok := cmap[crole.TableKey]; !ok {
-			cmap[crole.TableKey] = make(map[ShardNum]struct{})
-		}
-		for _, shardNum := range crole.Shards {
-			cmap[crole.TableKey][shardNum] = struct{}{}
-		}
-	}
-
-	// Handle ComputeRolesRemoved
-	for _, crole := range diff.ComputeRolesRemoved {
-		if _, ok := cmap[crole.TableKey]; !ok {
-			continue
-		}
-		for _, shardNum := range crole.Shards {
-			delete(cmap[crole.TableKey], shardNum)
-		}
-	}
-
-	// Convert cmap back to d.ComputeRoles.
-	croles := make([]ComputeRole, 0, len(cmap))
-	for tkey, smap := range cmap {
-		shards := make([]ShardNum, 0, len(smap))
-		for s := range smap {
-			shards = append(shards, s)
-		}
-		sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
-		croles = append(croles, ComputeRole{
-			TableKey: tkey,
-			Shards:   shards,
-		})
+	d.ComputeRoles = applyComputeRoleDiff(d.ComputeRoles, diff.ComputeRolesAdded, diff.ComputeRolesRemoved)
+	// Leave ReplicaRoles nil for databases without replicas.
+	if len(d.ReplicaRoles) > 0 || len(diff.ReplicaRolesAdded) > 0 {
+		d.ReplicaRoles = applyComputeRoleDiff(d.ReplicaRoles, diff.ReplicaRolesAdded, diff.ReplicaRolesRemoved)
 	}
-	// Sort croles by table.
-	sort.Slice(croles, func(i, j int) bool { return croles[i].TableKey < croles[j].TableKey })
-	d.ComputeRoles = croles
 
 	// tmap is a map of map used to apply the directive diffs. We will convert
 	// the final map to the TranslateRoles member in the returned Directive.
@@ -412,3 +422,48 @@ type Directives []*Directive
 func (d Directives) Len() int           { return len(d) }
 func (d Directives) Less(i, j int) bool { return d[i].Address < d[j].Address }
 func (d Directives) Swap(i, j int)      { d[i], d[j] = d[j], d[i] }
+
+// applyComputeRoleDiff returns roles with the shards in added added and the
+// shards in removed removed. The returned roles are sorted by table.
+func applyComputeRoleDiff(roles, added, removed []ComputeRole) []ComputeRole {
+	// cmap is a map of map used to apply the directive diffs. We will convert
+	// the final map to the returned roles.
+	cmap := shardsMapOfMaps(roles)
+
+	// Handle added.
+	for _, crole := range added {
+		if _, ok := cmap[crole.TableKey]; !ok {
+			cmap[crole.TableKey] = make(map[ShardNum]struct{})
+		}
+		for _, shardNum := range crole.Shards {
+			cmap[crole.TableKey][shardNum] = struct{}{}
+		}
+	}
+
+	// Handle removed.
+	for _, crole := range removed {
+		if _, ok := cmap[crole.TableKey]; !ok {
+			continue
+		}
+		for _, shardNum := range crole.Shards {
+			delete(cmap[crole.TableKey], shardNum)
+		}
+	}
+
+	// Convert cmap back to a slice of ComputeRole.
+	croles := make([]ComputeRole, 0, len(cmap))
+	for tkey, smap := range cmap {
+		shards := make([]ShardNum, 0, len(smap))
+		for s := range smap {
+			shards = append(shards, s)
+		}
+		sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+		croles = append(croles, ComputeRole{
+			TableKey: tkey,
+			Shards:   shards,
+		})
+	}
+	// Sort croles by table.
+	sort.Slice(croles, func(i, j int) bool { return croles[i].TableKey < croles[j].TableKey })
+	return croles
+}
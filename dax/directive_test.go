@@ -0,0 +1,55 @@
+package dax_test
+
+import (
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirective_ApplyDiff(t *testing.T) {
+	d := &dax.Directive{
+		ComputeRoles: []dax.ComputeRole{
+			{TableKey: "tbl", Shards: dax.NewShardNums(1, 2)},
+		},
+	}
+
+	d.ApplyDiff(&dax.Directive{
+		ComputeRolesAdded:   []dax.ComputeRole{{TableKey: "tbl", Shards: dax.NewShardNums(3)}},
+		ComputeRolesRemoved: []dax.ComputeRole{{TableKey: "tbl", Shards: dax.NewShardNums(1)}},
+	})
+	assert.Equal(t, []dax.ComputeRole{{TableKey: "tbl", Shards: dax.NewShardNums(2, 3)}}, d.ComputeRoles)
+	assert.Nil(t, d.ReplicaRoles)
+
+	d.ApplyDiff(&dax.Directive{
+		ReplicaRolesAdded: []dax.ComputeRole{{TableKey: "tbl", Shards: dax.NewShardNums(4, 5)}},
+	})
+	d.ApplyDiff(&dax.Directive{
+		ReplicaRolesRemoved: []dax.ComputeRole{{TableKey: "tbl", Shards: dax.NewShardNums(4)}},
+	})
+	assert.Equal(t, []dax.ComputeRole{{TableKey: "tbl", Shards: dax.NewShardNums(2, 3)}}, d.ComputeRoles)
+	assert.Equal(t, []dax.ComputeRole{{TableKey: "tbl", Shards: dax.NewShardNums(5)}}, d.ReplicaRoles)
+	assert.False(t, d.IsEmpty())
+}
+
+func TestDirective_ReplicaShardsMap(t *testing.T) {
+	d := &dax.Directive{
+		ComputeRoles: []dax.ComputeRole{
+			{TableKey: "tbl1", Shards: dax.NewShardNums(1)},
+		},
+		ReplicaRoles: []dax.ComputeRole{
+			{TableKey: "tbl1", Shards: dax.NewShardNums(1, 2)},
+			{TableKey: "tbl2", Shards: dax.NewShardNums(3)},
+		},
+	}
+
+	// A shard held as both the writer and a replica is only the writer.
+	assert.Equal(t, map[dax.TableKey]dax.ShardNums{
+		"tbl1": dax.NewShardNums(2),
+		"tbl2": dax.NewShardNums(3),
+	}, d.ReplicaShardsMap())
+
+	// Replicas don't count as shards to which the node may write.
+	assert.Equal(t, dax.NewShardNums(1), d.ComputeShards("tbl1"))
+	assert.Nil(t, d.ComputeShards("tbl2"))
+}
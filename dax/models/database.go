@@ -16,6 +16,7 @@ type Database struct {
 	Name           dax.DatabaseName `json:"name" db:"name"`
 	WorkersMin     int              `json:"workers_min" db:"workers_min"`
 	WorkersMax     int              `json:"workers_max" db:"workers_max"`
+	ReplicaN       int              `json:"replica_n" db:"replica_n"`
 	Description    string           `json:"description" db:"description"`
 	Owner          string           `json:"owner" db:"owner"`
 	UpdatedBy      string           `json:"updated_by" db:"updated_by"`
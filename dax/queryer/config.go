@@ -1,9 +1,13 @@
 package queryer
 
 import (
+	"time"
+
 	"github.com/featurebasedb/featurebase/v3/logger"
 )
 
+const defaultQueryStatsInterval = 10 * time.Second
+
 // Config defines the configuration parameters for Queryer. At the moment, it's
 // being used to serve two different purposes. This first is to provide the
 // config parameters for the toml (i.e. human-friendly) file used at server
@@ -12,6 +16,11 @@ import (
 // We initially did that with something called "Injections", but that separation
 // was a bit premature.
 type Config struct {
-	ControllerAddress string        `toml:"controller-address"`
-	Logger            logger.Logger `toml:"-"`
+	ControllerAddress string `toml:"controller-address"`
+
+	// QueryStatsInterval is the period on which the queryer reports the
+	// stats for the queries it has handled to the controller.
+	QueryStatsInterval time.Duration `toml:"query-stats-interval"`
+
+	Logger logger.Logger `toml:"-"`
 }
@@ -2216,22 +2216,40 @@ func (o *orchestrator) mapReduce(ctx context.Context, tableKeyer dax.TableKeyer,
 		return nil, errors.Wrapf(err, "getting nodes/shards for index '%q'", index)
 	}
 
-	// Start mapping across all primary owners.
+	// A shard may be held by more than one node if its table is replicated,
+	// so pick one replica of each shard to query.
+	replicas := newShardReplicas(nodes)
+	nodes, err = replicas.assignAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "assigning shards to replicas")
+	}
+
+	// Start mapping across one replica of each shard.
 	if err = o.mapper(ctx, eg, ch, index, nodes, c, opt, reduceFn); err != nil {
 		return nil, errors.Wrap(err, "starting mapper")
 	}
 
 	// Iterate over all map responses and reduce.
-	expected := 0
-	for _, n := range nodes {
-		expected += len(n.Shards)
-	}
+	expected := replicas.shardN()
 	done := ctx.Done()
 	for expected > 0 {
 		select {
 		case <-done:
 			return nil, ctx.Err()
 		case resp := <-ch:
+			// If the node couldn't be reached, retry its shards against
+			// the other replicas.
+			if resp.err != nil && strings.Contains(resp.err.Error(), errConnectionRefused) {
+				failover, err := replicas.failover(resp.node, resp.shards)
+				if err != nil {
+					cancel()
+					return nil, errors.Wrapf(resp.err, "mapping on node %s: %v", resp.node, err)
+				}
+				if err := o.mapper(ctx, eg, ch, index, failover, c, opt, reduceFn); err != nil {
+					return nil, errors.Wrap(err, "starting mapper on replicas")
+				}
+				continue
+			}
 			if resp.err != nil {
 				cancel() // TODO(jaffee) I added this... seems right, but wasn't there before
 				return nil, errors.Wrap(resp.err, "mapping on primary node")
@@ -41,6 +41,15 @@ type Queryer struct {
 
 	systemLayer *systemlayer.SystemLayer
 
+	// queryStats accumulates the stats for queries handled since they were
+	// last reported to queryStatsRecorder.
+	statsMu            sync.Mutex
+	queryStats         dax.QueryStats
+	queryStatsRecorder dax.QueryStatsRecorder
+	queryStatsInterval time.Duration
+
+	stopping chan struct{}
+
 	logger logger.Logger
 }
 
@@ -51,11 +60,16 @@ func New(cfg Config) *Queryer {
 		orchestrators: make(map[dax.QualifiedDatabaseID]*qualifiedOrchestrator),
 		systemLayer:   systemlayer.NewSystemLayer(),
 		logger:        logger.NopLogger,
+
+		queryStatsInterval: defaultQueryStatsInterval,
 	}
 
 	if cfg.Logger != nil {
 		q.logger = cfg.Logger
 	}
+	if cfg.QueryStatsInterval != 0 {
+		q.queryStatsInterval = cfg.QueryStatsInterval
+	}
 
 	return q
 }
@@ -109,11 +123,22 @@ func (q *Queryer) SetController(controller dax.Controller) error {
 	return nil
 }
 
+// SetQueryStatsRecorder sets the recorder to which the queryer periodically
+// reports the stats for the queries it has handled.
+func (q *Queryer) SetQueryStatsRecorder(recorder dax.QueryStatsRecorder) {
+	q.queryStatsRecorder = recorder
+}
+
 func (q *Queryer) Start() error {
 	if q.controller == nil {
 		return errors.New(errors.ErrUncoded, "queryer requires controller to be configured")
 	}
 
+	q.stopping = make(chan struct{})
+	if q.queryStatsRecorder != nil {
+		go q.reportQueryStats(q.stopping)
+	}
+
 	// fbClient is an instance of internal client. It's used in one place in the
 	// orchestrator (o.client.QueryNode()), but in that case, the host is
 	// replaces with the actual host (another computer node) to connect to.
@@ -131,8 +156,52 @@ func (q *Queryer) Start() error {
 	return nil
 }
 
+// Stop stops the queryer's background routines.
+func (q *Queryer) Stop() error {
+	if q.stopping != nil {
+		close(q.stopping)
+		q.stopping = nil
+	}
+	return nil
+}
+
+// recordQuery adds a query which started at start to the query stats.
+func (q *Queryer) recordQuery(start time.Time) {
+	q.statsMu.Lock()
+	defer q.statsMu.Unlock()
+	q.queryStats.Add(time.Since(start))
+}
+
+// reportQueryStats reports the accumulated query stats to the recorder on
+// every interval until stopping is closed.
+func (q *Queryer) reportQueryStats(stopping chan struct{}) {
+	ticker := time.NewTicker(q.queryStatsInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stopping:
+			return
+		case <-ticker.C:
+		}
+
+		q.statsMu.Lock()
+		stats := q.queryStats
+		q.queryStats = dax.QueryStats{}
+		q.statsMu.Unlock()
+
+		if stats.Queries == 0 {
+			continue
+		}
+		if err := q.queryStatsRecorder.RecordQueryStats(context.Background(), stats); err != nil {
+			q.logger.Printf("reporting query stats: %v", err)
+		}
+	}
+}
+
 func (q *Queryer) QuerySQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, sql io.Reader) (*featurebase.WireQueryResponse, error) {
 	start := time.Now()
+	defer q.recordQuery(start)
 
 	ret := &featurebase.WireQueryResponse{}
 
@@ -297,6 +366,7 @@ func (q *Queryer) convertIndex(ctx context.Context, qdbid dax.QualifiedDatabaseI
 
 func (q *Queryer) QueryPQL(ctx context.Context, qdbid dax.QualifiedDatabaseID, table dax.TableName, pql string) (*featurebase.WireQueryResponse, error) {
 	start := time.Now()
+	defer q.recordQuery(start)
 
 	ret := &featurebase.WireQueryResponse{}
 
@@ -0,0 +1,108 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package queryer
+
+import (
+	"sort"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/pkg/errors"
+)
+
+// shardReplicas keeps track of which compute nodes hold a replica of each
+// shard involved in a query, so that the query can be sent to one replica of
+// each shard, and failed over to another replica if that node is down.
+type shardReplicas struct {
+	table dax.TableKey
+
+	// holders is the list of nodes holding each shard, in the order returned
+	// by the controller.
+	holders map[dax.ShardNum][]dax.Address
+
+	// failed contains the nodes which have failed during the query.
+	failed map[dax.Address]struct{}
+}
+
+// newShardReplicas returns a shardReplicas for the shards held by nodes. A
+// shard may appear on more than one node.
+func newShardReplicas(nodes []dax.ComputeNode) *shardReplicas {
+	r := &shardReplicas{
+		holders: make(map[dax.ShardNum][]dax.Address),
+		failed:  make(map[dax.Address]struct{}),
+	}
+	for _, node := range nodes {
+		r.table = node.Table
+		for _, shard := range node.Shards {
+			r.holders[shard] = append(r.holders[shard], node.Address)
+		}
+	}
+	return r
+}
+
+// shardN returns the number of distinct shards.
+func (r *shardReplicas) shardN() int {
+	return len(r.holders)
+}
+
+// assign returns the shards grouped by the node which should query them. Each
+// shard is assigned to exactly one node which hasn't failed, preferring the
+// node with the fewest shards assigned so far in order to spread the load
+// across replicas.
+func (r *shardReplicas) assign(shards []dax.ShardNum) ([]dax.ComputeNode, error) {
+	sort.Sort(dax.ShardNums(shards))
+
+	assigned := make(map[dax.Address]dax.ShardNums)
+	for _, shard := range shards {
+		var (
+			addr  dax.Address
+			found bool
+		)
+		for _, holder := range r.holders[shard] {
+			if _, ok := r.failed[holder]; ok {
+				continue
+			}
+			if !found || len(assigned[holder]) < len(assigned[addr]) {
+				addr = holder
+				found = true
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("no available replica for shard: %s, %d", r.table, shard)
+		}
+		assigned[addr] = append(assigned[addr], shard)
+	}
+
+	nodes := make([]dax.ComputeNode, 0, len(assigned))
+	for addr, shards := range assigned {
+		nodes = append(nodes, dax.ComputeNode{
+			Address: addr,
+			Table:   r.table,
+			Shards:  shards,
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool {
+		return nodes[i].Address < nodes[j].Address
+	})
+	return nodes, nil
+}
+
+// assignAll is assign for all of the shards.
+func (r *shardReplicas) assignAll() ([]dax.ComputeNode, error) {
+	shards := make([]dax.ShardNum, 0, len(r.holders))
+	for shard := range r.holders {
+		shards = append(shards, shard)
+	}
+	return r.assign(shards)
+}
+
+// failover marks addr as failed, and returns the given shards, which had been
+// assigned to addr, reassigned to the remaining replicas.
+func (r *shardReplicas) failover(addr dax.Address, shards []uint64) ([]dax.ComputeNode, error) {
+	r.failed[addr] = struct{}{}
+
+	daxShards := make([]dax.ShardNum, len(shards))
+	for i, shard := range shards {
+		daxShards[i] = dax.ShardNum(shard)
+	}
+	return r.assign(daxShards)
+}
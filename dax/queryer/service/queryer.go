@@ -38,7 +38,7 @@ func (q *queryerService) Start() error {
 }
 
 func (q *queryerService) Stop() error {
-	return nil
+	return q.queryer.Stop()
 }
 
 func (q *queryerService) Address() dax.Address {
@@ -52,5 +52,6 @@ func (q *queryerService) HTTPHandler() http.Handler {
 func (q *queryerService) SetController(addr dax.Address) error {
 	controllercli := controllerclient.New(addr, q.logger)
 	q.queryer.SetController(controllercli)
+	q.queryer.SetQueryStatsRecorder(controllercli)
 	return nil
 }
@@ -0,0 +1,51 @@
+package dax
+
+import (
+	"context"
+	"time"
+)
+
+// QueryStats summarizes the queries handled by a queryer over a period of
+// time.
+type QueryStats struct {
+	// Queries is the number of queries handled.
+	Queries int64 `json:"queries"`
+
+	// Duration is the combined duration of all the queries.
+	Duration time.Duration `json:"duration"`
+
+	// Max is the duration of the slowest query.
+	Max time.Duration `json:"max"`
+}
+
+// Add adds the stats for a single query taking d to s.
+func (s *QueryStats) Add(d time.Duration) {
+	s.Queries++
+	s.Duration += d
+	if d > s.Max {
+		s.Max = d
+	}
+}
+
+// Merge adds the stats in o to s.
+func (s *QueryStats) Merge(o QueryStats) {
+	s.Queries += o.Queries
+	s.Duration += o.Duration
+	if o.Max > s.Max {
+		s.Max = o.Max
+	}
+}
+
+// Mean returns the mean query duration, or zero if there were no queries.
+func (s QueryStats) Mean() time.Duration {
+	if s.Queries == 0 {
+		return 0
+	}
+	return s.Duration / time.Duration(s.Queries)
+}
+
+// QueryStatsRecorder is an interface to anything which records the query stats
+// reported by queryers.
+type QueryStatsRecorder interface {
+	RecordQueryStats(ctx context.Context, stats QueryStats) error
+}
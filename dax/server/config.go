@@ -73,6 +73,10 @@ func NewConfig() *Config {
 				StorageMethod:            defaultStorageMethod,
 				SQLDB:                    controller.NewSQLDBConfig(),
 				SnappingTurtleTimeout:    time.Minute * 3,
+				Autoscaler: controller.AutoscalerConfig{
+					Interval: time.Minute,
+					Cooldown: time.Minute * 2,
+				},
 			},
 		},
 		Bind: ":" + defaultBindPort,
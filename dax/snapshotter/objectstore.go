@@ -0,0 +1,126 @@
+package snapshotter
+
+import (
+	"context"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/computer"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+)
+
+// Ensure type implements interface.
+var _ computer.SnapshotService = (*ObjectSnapshotter)(nil)
+
+// ObjectSnapshotter is a Snapshotter which keeps snapshots in an object store
+// rather than a local directory, so that they can be shared by nodes without a
+// shared filesystem. Each snapshot is stored as a single object using the same
+// bucket/key/version layout as Snapshotter.
+type ObjectSnapshotter struct {
+	store objectstore.Store
+
+	logger logger.Logger
+}
+
+func NewObjectSnapshotter(store objectstore.Store, log logger.Logger) *ObjectSnapshotter {
+	return &ObjectSnapshotter{
+		store:  store,
+		logger: log,
+	}
+}
+
+// NewS3 returns an ObjectSnapshotter which keeps snapshots under the "snapshot"
+// prefix of the S3 bucket described by cfg. Nodes sharing a bucket must use
+// the same cfg.Prefix.
+func NewS3(cfg objectstore.Config, log logger.Logger) (*ObjectSnapshotter, error) {
+	cfg.Prefix = path.Join(cfg.Prefix, "snapshot")
+	store, err := objectstore.NewS3Store(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating s3 store")
+	}
+	return NewObjectSnapshotter(store, log), nil
+}
+
+// SetLogger sets the logger used for logging messages.
+func (s *ObjectSnapshotter) SetLogger(l logger.Logger) {
+	s.logger = l
+}
+
+func (s *ObjectSnapshotter) Write(bucket string, key string, version int, rc io.ReadCloser) error {
+	defer rc.Close()
+
+	fKey := fullKey(bucket, key, version)
+	if err := s.store.Put(context.Background(), fKey, rc); err != nil {
+		return errors.Wrapf(err, "writing snapshot object: %s", fKey)
+	}
+	return nil
+}
+
+// WriteTo streams the output of wrTo to the object store.
+func (s *ObjectSnapshotter) WriteTo(bucket string, key string, version int, wrTo io.WriterTo) error {
+	pr, pw := io.Pipe()
+	go func() {
+		_, err := wrTo.WriteTo(pw)
+		pw.CloseWithError(err)
+	}()
+	return s.Write(bucket, key, version, pr)
+}
+
+// List returns the snapshots for bucket/key in ascending version order.
+func (s *ObjectSnapshotter) List(bucket, key string) ([]computer.SnapInfo, error) {
+	prefix := path.Join(bucket, key) + "/"
+
+	infos, err := s.store.List(context.Background(), prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing snapshot objects: %s", prefix)
+	}
+
+	snaps := make([]computer.SnapInfo, 0, len(infos))
+	for _, info := range infos {
+		name := strings.TrimPrefix(info.Key, prefix)
+		if strings.Contains(name, "/") {
+			// Belongs to a nested key.
+			continue
+		}
+		version, err := strconv.ParseInt(name, 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "object name '%s' could not be parsed to version number", name)
+		}
+		snaps = append(snaps, computer.SnapInfo{
+			Version: int(version),
+		})
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Version < snaps[j].Version })
+	return snaps, nil
+}
+
+func (s *ObjectSnapshotter) Read(bucket string, key string, version int) (io.ReadCloser, error) {
+	fKey := fullKey(bucket, key, version)
+	rc, err := s.store.Get(context.Background(), fKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading snapshot object: %s", fKey)
+	}
+	return rc, nil
+}
+
+func (s *ObjectSnapshotter) DeleteTable(qtid dax.QualifiedTableID) error {
+	ctx := context.Background()
+	prefix := string(qtid.Key()) + "/"
+
+	infos, err := s.store.List(ctx, prefix)
+	if err != nil {
+		return errors.Wrapf(err, "listing %s in snapshotter", prefix)
+	}
+	for _, info := range infos {
+		if err := s.store.Delete(ctx, info.Key); err != nil {
+			return errors.Wrapf(err, "dropping %s from snapshotter", info.Key)
+		}
+	}
+	return nil
+}
@@ -80,9 +80,11 @@ func (mm *ResourceManager) RemoveShardResource(qtid dax.QualifiedTableID, partit
 	defer mm.mu.Unlock()
 	key := shardK{qtid: qtid, partition: partition, shard: shard}
 	if m, ok := mm.shardResources[key]; ok {
-		err := m.Unlock()
-		if err != nil {
-			mm.Logger.Printf("unlocking shard resource during removal: %v", err)
+		// A read-only replica of the shard never held the lock.
+		if m.IsLocked() {
+			if err := m.Unlock(); err != nil {
+				mm.Logger.Printf("unlocking shard resource during removal: %v", err)
+			}
 		}
 		delete(mm.shardResources, key)
 	}
@@ -275,6 +277,26 @@ func (m *Resource) IsLocked() bool {
 	return m.locked
 }
 
+// SnapshotAdvanced returns true if the resource has never loaded a snapshot,
+// or if a snapshot has been written since it last did. A read-only replica of
+// a resource, which doesn't hold the lock, uses this to find out that the
+// write log it has been reading has been incorporated into a snapshot (and
+// deleted), in which case it must load the latest snapshot again before
+// loading the write log.
+func (m *Resource) SnapshotAdvanced() (bool, error) {
+	if m.loadWLsPastVersion == -2 {
+		return true, nil
+	}
+	snaps, err := m.snapshotter.List(m.bucket, m.key)
+	if err != nil {
+		return false, errors.Wrap(err, "listing snapshots")
+	}
+	if len(snaps) == 0 {
+		return false, nil
+	}
+	return snaps[len(snaps)-1].Version > m.loadWLsPastVersion, nil
+}
+
 // LoadLatestSnapshot finds the most recent snapshot for this resource
 // and returns a ReadCloser for that snapshot data. If there is no
 // snapshot for this resource it returns nil, nil.
@@ -348,6 +370,12 @@ func (m *Resource) LoadWriteLog() (data io.ReadCloser, err error) {
 	if m.locked && m.latestWLVersion != versions[0] {
 		return nil, errors.New(errors.ErrUncoded, "write log version gone since locking")
 	}
+	// Without the lock, the write log we were part way through may have been
+	// incorporated into a snapshot since we last loaded it. In that case our
+	// position applies to a log which is gone.
+	if !m.locked && m.lastWLPos > 0 && m.latestWLVersion != versions[0] {
+		return nil, errors.New(errors.ErrUncoded, "write log version gone since last load")
+	}
 	m.latestWLVersion = versions[0]
 	m.dirty = true
 
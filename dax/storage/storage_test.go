@@ -8,27 +8,48 @@ import (
 	"testing"
 
 	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/computer"
 	"github.com/featurebasedb/featurebase/v3/dax/snapshotter"
 	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
 	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+	"github.com/featurebasedb/featurebase/v3/objectstore/objectstoretest"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestResourceManager(t *testing.T) {
-	sdd, err := os.MkdirTemp("", "snaptest*")
-	assert.NoError(t, err)
-	wdd, err := os.MkdirTemp("", "wltest*")
-	assert.NoError(t, err)
-	defer func() {
-		os.RemoveAll(sdd)
-		os.RemoveAll(wdd)
-	}()
+	t.Run("Local", func(t *testing.T) {
+		sdd, err := os.MkdirTemp("", "snaptest*")
+		assert.NoError(t, err)
+		wdd, err := os.MkdirTemp("", "wltest*")
+		assert.NoError(t, err)
+		defer func() {
+			os.RemoveAll(sdd)
+			os.RemoveAll(wdd)
+		}()
+
+		log := logger.NewStandardLogger(os.Stderr)
+		testResourceManager(t, snapshotter.New(sdd, log), writelogger.New(wdd, log))
+		testResourceReplica(t, snapshotter.New(sdd, log), writelogger.New(wdd, log))
+	})
+
+	t.Run("ObjectStore", func(t *testing.T) {
+		srv := objectstoretest.NewServer(t)
+		ss, err := objectstore.NewS3Store(srv.Config("snapshots"))
+		assert.NoError(t, err)
+		ws, err := objectstore.NewS3Store(srv.Config("writelogs"))
+		assert.NoError(t, err)
+
+		log := logger.NewStandardLogger(os.Stderr)
+		testResourceManager(t, snapshotter.NewObjectSnapshotter(ss, log), writelogger.NewObjectWritelogger(ws, log))
+		testResourceReplica(t, snapshotter.NewObjectSnapshotter(ss, log), writelogger.NewObjectWritelogger(ws, log))
+	})
+}
 
+func testResourceManager(t *testing.T, sn computer.SnapshotService, wl computer.WritelogService) {
+	var err error
 	log := logger.NewStandardLogger(os.Stderr)
 
-	sn := snapshotter.New(sdd, log)
-	wl := writelogger.New(wdd, log)
-
 	mm := NewResourceManager(sn, wl, log)
 
 	qtid := dax.QualifiedTableID{
@@ -134,7 +155,10 @@ func TestResourceManager(t *testing.T) {
 	n, err = d.Read(buf)
 	assert.Equal(t, 6, n)
 	assert.Equal(t, "hahaha", string(buf))
-	assert.Equal(t, nil, err)
+	// An object store may return io.EOF along with the final bytes.
+	if err != io.EOF {
+		assert.Equal(t, nil, err)
+	}
 
 	// load write log on 3rd resource, get previous 2 writes
 	wld, err = resource3.LoadWriteLog()
@@ -158,3 +182,73 @@ func TestResourceManager(t *testing.T) {
 	assert.Equal(t, 0, n)
 	assert.Equal(t, io.EOF, err)
 }
+
+// testResourceReplica tests a resource which follows the writes to another,
+// locked, resource without ever taking the lock itself.
+func testResourceReplica(t *testing.T, sn computer.SnapshotService, wl computer.WritelogService) {
+	log := logger.NewStandardLogger(os.Stderr)
+	qtid := dax.QualifiedTableID{
+		QualifiedDatabaseID: dax.NewQualifiedDatabaseID(
+			dax.OrganizationID("org1"),
+			dax.DatabaseID("db1"),
+		),
+		ID:   dax.TableID("replica"),
+		Name: "replica",
+	}
+
+	readAll := func(rc io.ReadCloser, err error) string {
+		t.Helper()
+		if !assert.NoError(t, err) || rc == nil {
+			return ""
+		}
+		defer rc.Close()
+		b, err := io.ReadAll(rc)
+		assert.NoError(t, err)
+		return string(b)
+	}
+
+	writer := NewResourceManager(sn, wl, log).GetShardResource(qtid, dax.PartitionNum(1), dax.ShardNum(2))
+	readAll(writer.LoadLatestSnapshot())
+	readAll(writer.LoadWriteLog())
+	assert.NoError(t, writer.Lock())
+	assert.NoError(t, writer.Append([]byte("one")))
+
+	rm := NewResourceManager(sn, wl, log)
+	replica := rm.GetShardResource(qtid, dax.PartitionNum(1), dax.ShardNum(2))
+
+	// A replica which has never loaded must load the snapshot.
+	advanced, err := replica.SnapshotAdvanced()
+	assert.NoError(t, err)
+	assert.True(t, advanced)
+	assert.Equal(t, "", readAll(replica.LoadLatestSnapshot()))
+	assert.Equal(t, "one\n", readAll(replica.LoadWriteLog()))
+
+	// Subsequent loads only return new writes.
+	advanced, err = replica.SnapshotAdvanced()
+	assert.NoError(t, err)
+	assert.False(t, advanced)
+	assert.NoError(t, writer.Append([]byte("two")))
+	assert.Equal(t, "two\n", readAll(replica.LoadWriteLog()))
+
+	// Snapshot the writer, which deletes the write log the replica was
+	// reading.
+	ok, err := writer.IncrementWLVersion()
+	assert.True(t, ok)
+	assert.NoError(t, err)
+	assert.NoError(t, writer.Snapshot(io.NopCloser(bytes.NewBufferString("snap"))))
+	assert.NoError(t, writer.Append([]byte("three")))
+
+	_, err = replica.LoadWriteLog()
+	assert.Error(t, err)
+
+	advanced, err = replica.SnapshotAdvanced()
+	assert.NoError(t, err)
+	assert.True(t, advanced)
+	assert.Equal(t, "snap", readAll(replica.LoadLatestSnapshot()))
+	assert.Equal(t, "three\n", readAll(replica.LoadWriteLog()))
+
+	// Removing the replica doesn't touch the writer's lock.
+	assert.False(t, replica.IsLocked())
+	rm.RemoveShardResource(qtid, dax.PartitionNum(1), dax.ShardNum(2))
+	assert.NoError(t, writer.Unlock())
+}
@@ -180,6 +180,20 @@ func (d *Database) CreateID() (DatabaseID, error) {
 type DatabaseOptions struct {
 	WorkersMin int `json:"workers-min"`
 	WorkersMax int `json:"workers-max"`
+
+	// ReplicaN is the number of compute workers on which each shard is
+	// loaded. Only one of them accepts writes; the others serve queries. A
+	// value less than 1 is treated as 1.
+	ReplicaN int `json:"replica-n,omitempty"`
+}
+
+// Replicas returns the number of compute workers on which each shard should
+// be loaded.
+func (opts DatabaseOptions) Replicas() int {
+	if opts.ReplicaN < 1 {
+		return 1
+	}
+	return opts.ReplicaN
 }
 
 // DatabaseOption is a string key representing a database option.
@@ -188,6 +202,7 @@ type DatabaseOption string
 const (
 	DatabaseOptionWorkersMin = "workers-min"
 	DatabaseOptionWorkersMax = "workers-max"
+	DatabaseOptionReplicaN   = "replica-n"
 )
 
 // Set sets the specified option to the provided value.
@@ -204,6 +219,14 @@ func (opts *DatabaseOptions) Set(option string, value string) error {
 		// how to scale between a range, so for now we just keep it set to the
 		// same value as WorkersMin.
 		opts.WorkersMax = min
+	case DatabaseOptionReplicaN:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return errors.Wrapf(err, "converting value to int: %s", value)
+		} else if n < 1 {
+			return errors.Errorf("replica-n must be at least 1: %d", n)
+		}
+		opts.ReplicaN = n
 	default:
 		return errors.Errorf("unsupported database option: %s", option)
 	}
@@ -399,5 +399,22 @@ func TestDatabase(t *testing.T) {
 			// Try setting an unsupported option.
 			assert.Error(t, db.Options.Set("invalid-option", ""))
 		}
+		{
+			db := &dax.Database{
+				Name: databaseName,
+			}
+			assert.Zero(t, db.Options.ReplicaN)
+			assert.Equal(t, 1, db.Options.Replicas())
+
+			// Set ReplicaN to 3.
+			assert.NoError(t, db.Options.Set(dax.DatabaseOptionReplicaN, "3"))
+			assert.Equal(t, 3, db.Options.ReplicaN)
+			assert.Equal(t, 3, db.Options.Replicas())
+
+			// Try setting ReplicaN to invalid values.
+			assert.Error(t, db.Options.Set(dax.DatabaseOptionReplicaN, "0"))
+			assert.Error(t, db.Options.Set(dax.DatabaseOptionReplicaN, "abc"))
+			assert.Equal(t, 3, db.Options.ReplicaN)
+		}
 	})
 }
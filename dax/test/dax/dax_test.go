@@ -153,6 +153,9 @@ func TestDAXIntegration(t *testing.T) {
 			"time_quantum_insert/stringset-rangeq", // orchestrator currently does not support to,from args on Rows()
 			"time_quantum_insert/idset-rangeq",
 			"select-having/string", // fails in DAX because the string isn't translated.
+			"funnel_tests",         // orchestrator does not support Funnel()
+			"retention_tests",      // orchestrator does not support Retention()
+			"sample_tests",         // orchestrator does not support Sample()
 		}
 
 		doSkip := func(name string) bool {
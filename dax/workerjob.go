@@ -1,7 +1,9 @@
 package dax
 
 import (
+	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -14,6 +16,33 @@ func (j Job) Job() Job {
 	return j
 }
 
+// jobReplicaSep separates a job from the number of the replica it represents.
+const jobReplicaSep = "|replica_"
+
+// Replica returns the job which represents replica r of j. Replica 0 is the
+// job itself.
+func (j Job) Replica(r int) Job {
+	base, _ := j.ReplicaOf()
+	if r <= 0 {
+		return base
+	}
+	return Job(fmt.Sprintf("%s%s%d", base, jobReplicaSep, r))
+}
+
+// ReplicaOf returns the job of which j is a replica, along with the replica
+// number. A job which is not a replica returns itself and 0.
+func (j Job) ReplicaOf() (Job, int) {
+	i := strings.LastIndex(string(j), jobReplicaSep)
+	if i < 0 {
+		return j, 0
+	}
+	r, err := strconv.Atoi(string(j[i+len(jobReplicaSep):]))
+	if err != nil || r <= 0 {
+		return j, 0
+	}
+	return j[:i], r
+}
+
 // Jobs is a slice of Job.
 type Jobs []Job
 
@@ -27,6 +56,33 @@ type WorkerInfo struct {
 	Jobs    []Job
 }
 
+// JobLoad represents the load placed on a worker by a single Job.
+type JobLoad struct {
+	Job Job `json:"job"`
+
+	// Bytes is the size of the data held by the worker for the job.
+	Bytes int64 `json:"bytes"`
+
+	// Queries is the number of queries which have read the job's data since
+	// the worker started. It only ever increases, so the rate at which
+	// queries arrive is found by comparing successive values.
+	Queries uint64 `json:"queries"`
+}
+
+// ShardLoad represents the load placed on a compute worker by a single shard.
+type ShardLoad struct {
+	TableKey TableKey `json:"table-key"`
+	Shard    ShardNum `json:"shard"`
+	Bytes    int64    `json:"bytes"`
+	Queries  uint64   `json:"queries"`
+}
+
+// WorkerLoad is the load reported by a worker for each of the jobs in its
+// current Directive.
+type WorkerLoad struct {
+	Shards []ShardLoad `json:"shards"`
+}
+
 // WorkerInfos is a sortable slice of WorkerInfo.
 type WorkerInfos []WorkerInfo
 
@@ -130,3 +130,25 @@ func TestWorkerDiffsApply(t *testing.T) {
 
 	assert.ElementsMatch(t, exp, out)
 }
+
+func TestJobReplica(t *testing.T) {
+	j := Job("tbl|shard_3")
+
+	assert.Equal(t, j, j.Replica(0))
+	assert.Equal(t, Job("tbl|shard_3|replica_2"), j.Replica(2))
+	assert.Equal(t, Job("tbl|shard_3|replica_1"), j.Replica(2).Replica(1))
+	assert.Equal(t, j, j.Replica(2).Replica(0))
+
+	base, r := j.Replica(2).ReplicaOf()
+	assert.Equal(t, j, base)
+	assert.Equal(t, 2, r)
+
+	base, r = j.ReplicaOf()
+	assert.Equal(t, j, base)
+	assert.Equal(t, 0, r)
+
+	// A suffix which isn't a positive number is not a replica.
+	base, r = Job("tbl|shard_3|replica_x").ReplicaOf()
+	assert.Equal(t, Job("tbl|shard_3|replica_x"), base)
+	assert.Equal(t, 0, r)
+}
@@ -0,0 +1,400 @@
+package writelogger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/computer"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+)
+
+// Ensure type implements interface.
+var _ computer.WritelogService = (*ObjectWritelogger)(nil)
+
+// ObjectWritelogger is a Writelogger which keeps write logs in an object
+// store rather than a local directory, so that they can be shared by nodes
+// without a shared filesystem.
+//
+// Object stores can't append to an object, so each message appended to a
+// write log is stored as its own segment object at
+// bucket/key/version/segment, where segment is a zero-padded sequence number.
+// A write log is read by concatenating its segments in order.
+//
+// Locks are objects created with a conditional write, so only one process can
+// hold the lock for a bucket/key at a time. As with the lock files used by
+// Writelogger, a lock held by a process which exits without unlocking must be
+// removed by hand. Segments are also written conditionally, so a process which
+// writes to a log without holding its lock can't overwrite another's messages.
+type ObjectWritelogger struct {
+	store objectstore.Store
+
+	mu sync.Mutex
+	// nextSegments holds the next segment number of each write log this
+	// process has appended to, keyed by bucket/key/version.
+	nextSegments map[string]int
+	// lockTokens holds the contents of each lock object this process has
+	// created, keyed by the lock object's key.
+	lockTokens map[string]string
+
+	logger logger.Logger
+}
+
+func NewObjectWritelogger(store objectstore.Store, log logger.Logger) *ObjectWritelogger {
+	return &ObjectWritelogger{
+		store:        store,
+		nextSegments: make(map[string]int),
+		lockTokens:   make(map[string]string),
+		logger:       log,
+	}
+}
+
+// NewS3 returns an ObjectWritelogger which keeps write logs under the "writelog"
+// prefix of the S3 bucket described by cfg. Nodes sharing a bucket must use
+// the same cfg.Prefix.
+func NewS3(cfg objectstore.Config, log logger.Logger) (*ObjectWritelogger, error) {
+	cfg.Prefix = path.Join(cfg.Prefix, "writelog")
+	store, err := objectstore.NewS3Store(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating s3 store")
+	}
+	return NewObjectWritelogger(store, log), nil
+}
+
+// SetLogger sets the logger used for logging messages.
+func (w *ObjectWritelogger) SetLogger(l logger.Logger) {
+	w.logger = l
+}
+
+func (w *ObjectWritelogger) AppendMessage(bucket string, key string, version int, message []byte) error {
+	ctx := context.Background()
+	fKey := fullKey(bucket, key, version)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	next, ok := w.nextSegments[fKey]
+	if !ok {
+		segs, err := w.segments(ctx, fKey)
+		if err != nil {
+			return errors.Wrapf(err, "listing segments: %s", fKey)
+		}
+		if len(segs) > 0 {
+			last := segs[len(segs)-1]
+			n, err := strconv.Atoi(path.Base(last.Key))
+			if err != nil {
+				return errors.Wrapf(err, "writelog segment '%s' could not be parsed to segment number", last.Key)
+			}
+			next = n + 1
+		}
+	}
+
+	segKey := segmentKey(fKey, next)
+	if err := w.store.PutIfAbsent(ctx, segKey, append(message, "\n"...)); err == objectstore.ErrExists {
+		// Forget the segment number so that it's reloaded next time.
+		delete(w.nextSegments, fKey)
+		return errors.Errorf("writelog segment %s already exists; another process may be writing to the log", segKey)
+	} else if err != nil {
+		return errors.Wrapf(err, "writing writelog segment: %s", segKey)
+	}
+	w.nextSegments[fKey] = next + 1
+	return nil
+}
+
+// List returns the write logs for bucket/key in ascending version order.
+func (w *ObjectWritelogger) List(bucket, key string) ([]computer.WriteLogInfo, error) {
+	infos, err := w.logs(bucket, key)
+	if err != nil {
+		return nil, err
+	}
+
+	var wLogs []computer.WriteLogInfo
+	for _, info := range infos {
+		if len(wLogs) == 0 || wLogs[len(wLogs)-1].Version != info.version {
+			wLogs = append(wLogs, computer.WriteLogInfo{
+				Version: info.version,
+			})
+		}
+	}
+	return wLogs, nil
+}
+
+// Stat returns the latest version and combined size of the write logs for the
+// given bucket/key.
+func (w *ObjectWritelogger) Stat(bucket, key string) (LogStat, error) {
+	stat := LogStat{Version: -1}
+
+	infos, err := w.logs(bucket, key)
+	if err != nil {
+		return stat, err
+	}
+	for _, info := range infos {
+		stat.Version = info.version
+		stat.Size += info.Size
+	}
+	return stat, nil
+}
+
+func (w *ObjectWritelogger) LogReader(bucket, key string, version int) (io.ReadCloser, error) {
+	return w.LogReaderFrom(bucket, key, version, 0)
+}
+
+// LogReaderFrom returns a reader for the write log starting offset bytes in.
+// Segments appended after LogReaderFrom is called are not included.
+func (w *ObjectWritelogger) LogReaderFrom(bucket string, key string, version int, offset int) (io.ReadCloser, error) {
+	fKey := fullKey(bucket, key, version)
+
+	segs, err := w.segments(context.Background(), fKey)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing segments: %s", fKey)
+	}
+	if len(segs) == 0 {
+		return nil, &os.PathError{Op: "open", Path: fKey, Err: os.ErrNotExist}
+	}
+
+	r := &segmentReader{
+		store:  w.store,
+		offset: int64(offset),
+	}
+	for _, seg := range segs {
+		if r.offset >= seg.Size {
+			r.offset -= seg.Size
+			continue
+		}
+		r.keys = append(r.keys, seg.Key)
+	}
+	w.logger.Debugf("ObjectWritelogger LogReader: %s, segments: %d", fKey, len(r.keys))
+
+	return r, nil
+}
+
+func (w *ObjectWritelogger) DeleteLog(bucket string, key string, version int) error {
+	ctx := context.Background()
+	fKey := fullKey(bucket, key, version)
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	segs, err := w.segments(ctx, fKey)
+	if err != nil {
+		return errors.Wrapf(err, "listing segments: %s", fKey)
+	}
+	for _, seg := range segs {
+		if err := w.store.Delete(ctx, seg.Key); err != nil {
+			return errors.Wrapf(err, "deleting writelog segment: %s", seg.Key)
+		}
+	}
+	delete(w.nextSegments, fKey)
+	return nil
+}
+
+func (w *ObjectWritelogger) lockKey(bucket, key string) string {
+	return path.Join(bucket, fmt.Sprintf("_lock_%s", key))
+}
+
+func (w *ObjectWritelogger) Lock(bucket, key string) error {
+	lockKey := w.lockKey(bucket, key)
+
+	token, err := lockToken()
+	if err != nil {
+		return errors.Wrap(err, "generating lock token")
+	}
+
+	if err := retryUntil(10*time.Second, w.logger, func() error {
+		return w.store.PutIfAbsent(context.Background(), lockKey, []byte(token))
+	}); err == objectstore.ErrExists {
+		return errors.Errorf("lock object %s is held by another process", lockKey)
+	} else if err != nil {
+		return errors.Wrapf(err, "creating lock object: %s", lockKey)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.lockTokens[lockKey] = token
+	return nil
+}
+
+func (w *ObjectWritelogger) Unlock(bucket, key string) error {
+	ctx := context.Background()
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// remove all local state associated with this bucket/key
+	keyPrefix := path.Join(bucket, key) + "/"
+	for fKey := range w.nextSegments {
+		if strings.HasPrefix(fKey, keyPrefix) {
+			delete(w.nextSegments, fKey)
+		}
+	}
+
+	lockKey := w.lockKey(bucket, key)
+	token, ok := w.lockTokens[lockKey]
+	if !ok {
+		w.logger.Warnf("unlocking %s did not find cached token to unlock", lockKey)
+		return errors.Errorf("lock object %s is not held by this process", lockKey)
+	}
+	delete(w.lockTokens, lockKey)
+
+	// Make sure the lock is still ours before removing it.
+	rc, err := w.store.Get(ctx, lockKey)
+	if err == objectstore.ErrNotFound {
+		w.logger.Warnf("lock object %s was removed while held", lockKey)
+		return nil
+	} else if err != nil {
+		return errors.Wrapf(err, "reading lock object: %s", lockKey)
+	}
+	held, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		return errors.Wrapf(err, "reading lock object: %s", lockKey)
+	} else if string(held) != token {
+		return errors.Errorf("lock object %s is held by another process", lockKey)
+	}
+
+	return errors.Wrap(w.store.Delete(ctx, lockKey), "removing lock object")
+}
+
+func (w *ObjectWritelogger) DeleteTable(qtid dax.QualifiedTableID) error {
+	ctx := context.Background()
+	prefix := string(qtid.Key()) + "/"
+
+	infos, err := w.store.List(ctx, prefix)
+	if err != nil {
+		return errors.Wrapf(err, "listing %s in writelogger", prefix)
+	}
+	for _, info := range infos {
+		if err := w.store.Delete(ctx, info.Key); err != nil {
+			return errors.Wrapf(err, "dropping %s from writelogger", info.Key)
+		}
+	}
+	return nil
+}
+
+// segmentInfo describes a write log segment.
+type segmentInfo struct {
+	objectstore.ObjectInfo
+	version int
+}
+
+// logs returns the segments of every write log for bucket/key, ordered by
+// version and then by segment.
+func (w *ObjectWritelogger) logs(bucket, key string) ([]segmentInfo, error) {
+	prefix := path.Join(bucket, key) + "/"
+
+	objs, err := w.store.List(context.Background(), prefix)
+	if err != nil {
+		return nil, errors.Wrapf(err, "listing writelog objects: %s", prefix)
+	}
+
+	infos := make([]segmentInfo, 0, len(objs))
+	for _, obj := range objs {
+		// Only objects at exactly version/segment belong to this log.
+		parts := strings.Split(strings.TrimPrefix(obj.Key, prefix), "/")
+		if len(parts) != 2 {
+			continue
+		}
+		version, err := strconv.ParseInt(parts[0], 10, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "writelog name '%s' could not be parsed to version number", parts[0])
+		}
+		infos = append(infos, segmentInfo{
+			ObjectInfo: obj,
+			version:    int(version),
+		})
+	}
+	// Segment names are zero-padded, so they sort correctly as strings.
+	sort.SliceStable(infos, func(i, j int) bool { return infos[i].version < infos[j].version })
+	return infos, nil
+}
+
+// segments returns the segments of the write log at fKey in order.
+func (w *ObjectWritelogger) segments(ctx context.Context, fKey string) ([]objectstore.ObjectInfo, error) {
+	return w.store.List(ctx, fKey+"/")
+}
+
+// segmentKey returns the key of the segment numbered n of the write log at
+// fKey.
+func segmentKey(fKey string, n int) string {
+	return path.Join(fKey, fmt.Sprintf("%020d", n))
+}
+
+// lockToken returns a value which identifies a lock as being held by this
+// process.
+func lockToken() (string, error) {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		return "", err
+	}
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s/%d/%s", host, os.Getpid(), hex.EncodeToString(buf[:])), nil
+}
+
+// segmentReader reads the segments of a write log in order, fetching each
+// one as it's needed. Reads span segments so that, like reads of a local
+// file, they are only short at the end of the log.
+type segmentReader struct {
+	store objectstore.Store
+	keys  []string
+	// offset is the number of bytes to skip in the first segment.
+	offset int64
+
+	cur io.ReadCloser
+}
+
+func (r *segmentReader) Read(p []byte) (n int, err error) {
+	for n < len(p) {
+		if r.cur == nil {
+			if len(r.keys) == 0 {
+				if n == 0 {
+					return 0, io.EOF
+				}
+				return n, nil
+			}
+			rc, err := r.store.Get(context.Background(), r.keys[0])
+			if err != nil {
+				return n, errors.Wrapf(err, "reading writelog segment: %s", r.keys[0])
+			}
+			r.keys = r.keys[1:]
+			if r.offset > 0 {
+				if _, err := io.CopyN(io.Discard, rc, r.offset); err != nil {
+					rc.Close()
+					return n, errors.Wrap(err, "seeking in writelog segment")
+				}
+				r.offset = 0
+			}
+			r.cur = rc
+		}
+
+		m, err := r.cur.Read(p[n:])
+		n += m
+		if err == io.EOF {
+			r.cur.Close()
+			r.cur = nil
+		} else if err != nil {
+			return n, err
+		}
+	}
+	return n, nil
+}
+
+func (r *segmentReader) Close() error {
+	if r.cur == nil {
+		return nil
+	}
+	err := r.cur.Close()
+	r.cur = nil
+	return err
+}
@@ -0,0 +1,91 @@
+package writelogger_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/dax/computer"
+	"github.com/featurebasedb/featurebase/v3/dax/writelogger"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+	"github.com/featurebasedb/featurebase/v3/objectstore/objectstoretest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObjectWritelogger(t *testing.T) {
+	srv := objectstoretest.NewServer(t)
+	store, err := objectstore.NewS3Store(srv.Config("wl"))
+	require.NoError(t, err)
+
+	qtid := dax.QualifiedTableID{
+		QualifiedDatabaseID: dax.NewQualifiedDatabaseID("org1", "db1"),
+		ID:                  "tbl",
+	}
+	bkt := string(qtid.Key()) + "/partition/1"
+	key := "shard/1"
+
+	wl := writelogger.NewObjectWritelogger(store, logger.NopLogger)
+
+	readAll := func(version, offset int) string {
+		rc, err := wl.LogReaderFrom(bkt, key, version, offset)
+		require.NoError(t, err)
+		defer rc.Close()
+		buf, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		return string(buf)
+	}
+
+	t.Run("Append", func(t *testing.T) {
+		_, err := wl.LogReader(bkt, key, 2)
+		assert.Error(t, err)
+
+		for _, msg := range []string{"abc", "de", "fghi"} {
+			require.NoError(t, wl.AppendMessage(bkt, key, 2, []byte(msg)))
+		}
+		require.NoError(t, wl.AppendMessage(bkt, key, 10, []byte("x")))
+
+		assert.Equal(t, "abc\nde\nfghi\n", readAll(2, 0))
+		assert.Equal(t, "e\nfghi\n", readAll(2, 5))
+		assert.Equal(t, "fghi\n", readAll(2, 7))
+		assert.Equal(t, "", readAll(2, 12))
+
+		// Versions are ordered numerically.
+		wLogs, err := wl.List(bkt, key)
+		require.NoError(t, err)
+		assert.Equal(t, []computer.WriteLogInfo{{Version: 2}, {Version: 10}}, wLogs)
+
+		stat, err := wl.Stat(bkt, key)
+		require.NoError(t, err)
+		assert.Equal(t, writelogger.LogStat{Version: 10, Size: 14}, stat)
+
+		// A second writer continues after the existing segments.
+		wl2 := writelogger.NewObjectWritelogger(store, logger.NopLogger)
+		require.NoError(t, wl2.AppendMessage(bkt, key, 2, []byte("j")))
+		assert.Equal(t, "abc\nde\nfghi\nj\n", readAll(2, 0))
+
+		// The first writer's next segment now exists.
+		assert.Error(t, wl.AppendMessage(bkt, key, 2, []byte("k")))
+
+		require.NoError(t, wl.DeleteLog(bkt, key, 2))
+		wLogs, err = wl.List(bkt, key)
+		require.NoError(t, err)
+		assert.Equal(t, []computer.WriteLogInfo{{Version: 10}}, wLogs)
+	})
+
+	t.Run("Lock", func(t *testing.T) {
+		wl2 := writelogger.NewObjectWritelogger(store, logger.NopLogger)
+
+		require.NoError(t, wl.Lock(bkt, key))
+		assert.Error(t, wl2.Unlock(bkt, key))
+		require.NoError(t, wl.Unlock(bkt, key))
+		require.NoError(t, wl2.Lock(bkt, key))
+		require.NoError(t, wl2.Unlock(bkt, key))
+	})
+
+	t.Run("DeleteTable", func(t *testing.T) {
+		require.NoError(t, wl.DeleteTable(qtid))
+		assert.Empty(t, srv.Keys("wl"))
+	})
+}
@@ -83,6 +83,53 @@ func (w *Writelogger) List(bucket, key string) ([]computer.WriteLogInfo, error)
 	return wLogs, nil
 }
 
+// LogStat summarizes the write logs for a bucket/key which have not yet been
+// incorporated into a snapshot.
+type LogStat struct {
+	// Version is the latest write log version, or -1 if there are no write
+	// logs.
+	Version int
+	// Size is the combined size, in bytes, of all the write logs.
+	Size int64
+}
+
+// Stat returns the latest version and combined size of the write logs for the
+// given bucket/key.
+func (w *Writelogger) Stat(bucket, key string) (LogStat, error) {
+	stat := LogStat{Version: -1}
+
+	dirpath := path.Join(w.dataDir, bucket, key)
+
+	entries, err := os.ReadDir(dirpath)
+	if err != nil {
+		if pe, ok := err.(*os.PathError); ok && pe.Err == syscall.ENOENT {
+			return stat, nil
+		}
+		return stat, errors.Wrap(err, "reading directory")
+	}
+
+	for _, entry := range entries {
+		version, err := strconv.ParseInt(entry.Name(), 10, 64)
+		if err != nil {
+			return stat, errors.Wrapf(err, "writelog filename '%s' could not be parsed to version number", entry.Name())
+		}
+		info, err := entry.Info()
+		if err != nil {
+			// The log may have been deleted by a snapshot since we read the
+			// directory.
+			if os.IsNotExist(err) {
+				continue
+			}
+			return stat, errors.Wrapf(err, "getting info for writelog: %s", entry.Name())
+		}
+		if int(version) > stat.Version {
+			stat.Version = int(version)
+		}
+		stat.Size += info.Size()
+	}
+	return stat, nil
+}
+
 func (w *Writelogger) LogReader(bucket, key string, version int) (io.ReadCloser, error) {
 	return w.LogReaderFrom(bucket, key, version, 0)
 }
@@ -147,7 +194,7 @@ func (w *Writelogger) Lock(bucket, key string) error {
 	// that said, it doesn't hurt to leave this retry logic here.
 	var f *os.File
 	var err error
-	if err := w.retryUntil(10*time.Second, func() error {
+	if err := retryUntil(10*time.Second, w.logger, func() error {
 		f, err = os.OpenFile(lockFile, os.O_CREATE|os.O_EXCL|syscall.O_NONBLOCK, 0644)
 		if err != nil {
 			return errors.Wrapf(err, "opening lock file: %s", lockFile)
@@ -172,7 +219,7 @@ func (w *Writelogger) Lock(bucket, key string) error {
 }
 
 // retryUntil repeatedly executes fn until it returns nil or timeout occurs.
-func (w *Writelogger) retryUntil(timeout time.Duration, fn func() error) (err error) {
+func retryUntil(timeout time.Duration, log logger.Logger, fn func() error) (err error) {
 	timer := time.NewTimer(timeout)
 	defer timer.Stop()
 	ticker := time.NewTicker(100 * time.Millisecond)
@@ -184,7 +231,7 @@ func (w *Writelogger) retryUntil(timeout time.Duration, fn func() error) (err er
 			return nil
 		}
 		i++
-		w.logger.Debugf("Writelogger retryUntil try: %d", i)
+		log.Debugf("Writelogger retryUntil try: %d", i)
 
 		select {
 		case <-timer.C:
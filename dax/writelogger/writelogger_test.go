@@ -69,3 +69,37 @@ func bucket(table string, partition int) string {
 	return path.Join(table, fmt.Sprintf("%d", partition))
 
 }
+
+func TestWritelogger_Stat(t *testing.T) {
+	tmpDir, err := os.MkdirTemp("", "testWriteloggerStat-*")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	wl := writelogger.New(tmpDir, logger.NopLogger)
+	bkt := bucket("tbl", 1)
+	key := "shard/1"
+
+	// No write logs.
+	stat, err := wl.Stat(bkt, key)
+	assert.NoError(t, err)
+	assert.Equal(t, writelogger.LogStat{Version: -1}, stat)
+
+	// Each message is followed by a newline.
+	assert.NoError(t, wl.AppendMessage(bkt, key, 0, []byte("abc")))
+	assert.NoError(t, wl.AppendMessage(bkt, key, 0, []byte("defg")))
+	stat, err = wl.Stat(bkt, key)
+	assert.NoError(t, err)
+	assert.Equal(t, writelogger.LogStat{Version: 0, Size: 9}, stat)
+
+	// Sizes are combined across versions.
+	assert.NoError(t, wl.AppendMessage(bkt, key, 1, []byte("hi")))
+	stat, err = wl.Stat(bkt, key)
+	assert.NoError(t, err)
+	assert.Equal(t, writelogger.LogStat{Version: 1, Size: 12}, stat)
+
+	// Deleting a log, as a snapshot does, removes it from the stat.
+	assert.NoError(t, wl.DeleteLog(bkt, key, 0))
+	stat, err = wl.Stat(bkt, key)
+	assert.NoError(t, err)
+	assert.Equal(t, writelogger.LogStat{Version: 1, Size: 3}, stat)
+}
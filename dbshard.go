@@ -146,6 +146,9 @@ type DBPerShard struct {
 
 	StorageConfig *storage.Config
 	RBFConfig     *rbfcfg.Config
+
+	// tiering moves cold shards to an object store, if configured.
+	tiering *shardTiering
 }
 
 func newIndex2Shards() (r map[string]*shardSet) {
@@ -248,6 +251,10 @@ func (per *DBPerShard) LoadExistingDBs() (err error) {
 			return err
 		}
 		for shard := range shardset {
+			// Offloaded shards are opened when they are first used.
+			if per.tierManifest(idx.name, shard) != nil {
+				continue
+			}
 			_, err := per.GetDBShard(idx.name, shard, idx)
 			if err != nil {
 				return errors.Wrap(err, "DBPerShard.LoadExistingDBs GetDBShard()")
@@ -273,6 +280,9 @@ func (txf *TxFactory) NewDBPerShard(typ txtype, holderDir string, holder *Holder
 		StorageConfig: holder.cfg.StorageConfig,
 		RBFConfig:     holder.cfg.RBFConfig,
 	}
+	if holder.cfg.TieredStore != nil {
+		d.tiering = newShardTiering(holder.cfg.TieredStore, holder.cfg.TieredAge, holder.Logger)
+	}
 	return
 }
 
@@ -281,6 +291,10 @@ func (per *DBPerShard) DeleteIndex(index string) (err error) {
 	per.Mu.Lock()
 	defer per.Mu.Unlock()
 
+	if per.tiering != nil {
+		per.deleteTieredShards(index)
+	}
+
 	dbi, ok := per.dbh.Index[index]
 	if !ok {
 		// since we lazily make indexes upon use by a Tx now, we won't
@@ -362,6 +376,11 @@ func (per *DBPerShard) prefixForType(idx *Index, ty txtype) string {
 
 var ErrNoData = fmt.Errorf("no data")
 
+// errShardOffloaded is returned by unprotectedGetDBShard when the shard has
+// been offloaded to the object store and must be fetched before it's opened.
+// The fetch can't be done while holding per.Mu.
+var errShardOffloaded = fmt.Errorf("shard is offloaded")
+
 // keep our cache of shards up-to-date in memory; after the initial
 // directory scan, this is all we should we need. Prevents us from
 // doing additional, expensive, directory scans.
@@ -378,11 +397,28 @@ func (per *DBPerShard) updateIndex2ShardCacheWithNewShard(dbs *DBShard) {
 }
 
 func (per *DBPerShard) GetDBShard(index string, shard uint64, idx *Index) (dbs *DBShard, err error) {
-	per.Mu.Lock()
-	defer per.Mu.Unlock()
-	return per.unprotectedGetDBShard(index, shard, idx)
+	for {
+		// Fetch offloaded shards before taking the lock so other shards can
+		// be used during the download. If the shard is offloaded again
+		// before the lock is taken, fetch it again.
+		if err := per.fetchShard(index, shard); err != nil {
+			return nil, err
+		}
+
+		per.Mu.Lock()
+		dbs, err = per.unprotectedGetDBShard(index, shard, idx)
+		per.Mu.Unlock()
+		if err != errShardOffloaded {
+			return dbs, err
+		}
+	}
 }
 
+// unprotectedGetDBShard returns the DBShard for a shard, opening it if it
+// isn't open. It returns errShardOffloaded if the shard needs to be fetched
+// first.
+//
+// Caller must hold per.Mu.Lock() already.
 func (per *DBPerShard) unprotectedGetDBShard(index string, shard uint64, idx *Index) (dbs *DBShard, err error) {
 
 	dbi, ok := per.dbh.Index[index]
@@ -411,6 +447,15 @@ func (per *DBPerShard) unprotectedGetDBShard(index string, shard uint64, idx *In
 		per.updateIndex2ShardCacheWithNewShard(dbs)
 	}
 	if !dbs.Open {
+		if per.tiering != nil {
+			m, err := per.tiering.manifest(per.shardPath(index, shard))
+			if err != nil {
+				return nil, errors.Wrapf(err, "reading tier manifest for shard %s/%d", index, shard)
+			} else if m.offloaded() {
+				return nil, errShardOffloaded
+			}
+		}
+
 		var registry DBRegistry
 		switch dbs.typ {
 		case rbfTxn:
@@ -504,7 +549,10 @@ func (per *DBPerShard) TypedDBPerShardGetShardsForIndex(ty txtype, idx *Index, r
 		// exclude those without data?
 		hasData := false
 
-		if requireData {
+		if requireData && per.tierManifest(idx.name, shard) != nil {
+			// Offloaded shards only exist if they had data.
+			setOfShards.add(shard)
+		} else if requireData {
 			hasData, err = per.unprotectedTypedIndexShardHasData(ty, idx, shard)
 			if err != nil {
 				return nil, err
@@ -527,7 +575,10 @@ func (per *DBPerShard) unprotectedTypedIndexShardHasData(ty txtype, idx *Index,
 
 	// make the dbs if it doesn't get exist
 	dbs, err := per.unprotectedGetDBShard(idx.name, shard, idx)
-	if err != nil {
+	if err == errShardOffloaded {
+		// Offloaded shards only exist if they had data.
+		return true, nil
+	} else if err != nil {
 		return false, errors.Wrap(err, fmt.Sprintf("DBPerShard.TypedIndexShardHasData() "+
 			"per.GetDBShard(index='%v', shard='%v', ty='%v')", idx.name, shard, ty.String()))
 	}
@@ -675,6 +726,13 @@ func (per *DBPerShard) GetFieldView2ShardsMapForIndex(idx *Index) (vs *FieldView
 		}
 
 		for shard := range shardMap {
+			if m := per.tierManifest(idx.name, shard); m != nil {
+				for _, fv := range m.FieldViews {
+					vs.addShard(fv, shard)
+				}
+				continue
+			}
+
 			dbs, err := per.GetDBShard(idx.name, shard, idx)
 			if err != nil {
 				return nil, errors.Wrap(err, "DBPerShard.GetFieldView2ShardsMapForIndex GetDBShard()")
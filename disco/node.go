@@ -15,6 +15,10 @@ type Node struct {
 	GRPCURI   net.URI   `json:"grpc-uri"`
 	IsPrimary bool      `json:"isPrimary"`
 	State     NodeState `json:"state"`
+
+	// IsReadReplica is true if the node only serves queries from a copy of
+	// the cluster's data. Read replicas do not own any partitions.
+	IsReadReplica bool `json:"isReadReplica,omitempty"`
 }
 
 func (n *Node) Clone() *Node {
@@ -27,6 +31,7 @@ func (n *Node) Clone() *Node {
 	other.GRPCURI = n.GRPCURI
 	other.IsPrimary = n.IsPrimary
 	other.State = n.State
+	other.IsReadReplica = n.IsReadReplica
 	return &other
 }
 
@@ -101,6 +106,25 @@ func (a Nodes) FilterURI(uri net.URI) []*Node {
 	return other
 }
 
+// Owners returns the nodes which own partitions, which excludes read
+// replicas. The order of the nodes is preserved.
+func (a Nodes) Owners() []*Node {
+	for i, n := range a {
+		if !n.IsReadReplica {
+			continue
+		}
+		other := make([]*Node, i, len(a))
+		copy(other, a[:i])
+		for _, n := range a[i+1:] {
+			if !n.IsReadReplica {
+				other = append(other, n)
+			}
+		}
+		return other
+	}
+	return a
+}
+
 // IDs returns a list of all node IDs.
 func (a Nodes) IDs() []string {
 	ids := make([]string, len(a))
@@ -36,9 +36,10 @@ type ClusterSnapshot struct {
 	PartitionAssignment string
 }
 
-// NewClusterSnapshot returns a new instance of ClusterSnapshot.
+// NewClusterSnapshot returns a new instance of ClusterSnapshot. Read replicas
+// are not included in the snapshot since they do not own partitions.
 func NewClusterSnapshot(noder Noder, hasher Hasher, partitionAssignment string, replicas int) *ClusterSnapshot {
-	nodes := noder.Nodes()
+	nodes := Nodes(noder.Nodes()).Owners()
 
 	// Make sure replica count doesn't exceed the number of nodes.
 	nodeN := len(nodes)
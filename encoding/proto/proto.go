@@ -7,6 +7,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"math"
 	"math/big"
 	"time"
 
@@ -561,6 +562,16 @@ func (s Serializer) encodeQueryResponse(m *pilosa.QueryResponse) *pb.QueryRespon
 		case pilosa.ExtractedIDMatrixSorted:
 			resp.Results[i].Type = queryResultTypeExtractedIDMatrixSorted
 			resp.Results[i].ExtractedIDMatrixSorted = s.endcodeExtractedIDMatrixSorted(result)
+		case pilosa.FunnelCounts:
+			resp.Results[i].Type = queryResultTypeFunnelCounts
+			resp.Results[i].RowIDs = result
+		case pilosa.RetentionMatrix:
+			resp.Results[i].Type = queryResultTypeRetentionMatrix
+			resp.Results[i].N = uint64(len(result))
+			resp.Results[i].RowIDs = s.encodeRetentionMatrix(result)
+		case *pilosa.SimilarRows:
+			resp.Results[i].Type = queryResultTypeSimilarRows
+			resp.Results[i].PairsField, resp.Results[i].RowIDs = s.encodeSimilarRows(result)
 		default:
 			panic(fmt.Errorf("unknown type: %T", m.Results[i]))
 		}
@@ -1314,6 +1325,23 @@ func (s Serializer) decodeDistinctTimestamp(pb *pb.DistinctTimestamp) pilosa.Dis
 	}
 }
 
+// decodeRetentionMatrix decodes n cohorts of a retention matrix encoded by
+// encodeRetentionMatrix.
+func (s Serializer) decodeRetentionMatrix(n uint64, vals []uint64) pilosa.RetentionMatrix {
+	matrix := make(pilosa.RetentionMatrix, 0, n)
+	for len(vals) >= 3 {
+		cohort := pilosa.RetentionCohort{
+			Start: time.Unix(int64(vals[0]), 0).UTC(),
+			Size:  vals[1],
+		}
+		k := vals[2]
+		cohort.Retained = append([]uint64{}, vals[3:3+k]...)
+		matrix = append(matrix, cohort)
+		vals = vals[3+k:]
+	}
+	return matrix
+}
+
 func decodeTransaction(pb *pb.Transaction, trns *pilosa.Transaction) {
 	trns.ID = pb.ID
 	trns.Active = pb.Active
@@ -1345,6 +1373,9 @@ const (
 	queryResultTypeDataFrame
 	queryResultTypeArrowTable
 	queryResultTypeExtractedIDMatrixSorted
+	queryResultTypeFunnelCounts
+	queryResultTypeRetentionMatrix
+	queryResultTypeSimilarRows
 )
 
 func (s Serializer) decodeQueryResult(pb *pb.QueryResult) interface{} {
@@ -1389,6 +1420,12 @@ func (s Serializer) decodeQueryResult(pb *pb.QueryResult) interface{} {
 		return s.decodeArrowTable(pb.ArrowTable)
 	case queryResultTypeExtractedIDMatrixSorted:
 		return s.decodeExtractedIDMatrixSorted(pb.ExtractedIDMatrixSorted)
+	case queryResultTypeFunnelCounts:
+		return pilosa.FunnelCounts(pb.RowIDs)
+	case queryResultTypeRetentionMatrix:
+		return s.decodeRetentionMatrix(pb.N, pb.RowIDs)
+	case queryResultTypeSimilarRows:
+		return s.decodeSimilarRows(pb.PairsField, pb.RowIDs)
 	}
 	panic(fmt.Sprintf("unknown type: %d", pb.Type))
 }
@@ -1548,6 +1585,24 @@ func (s Serializer) decodePairsField(a *pb.PairsField) *pilosa.PairsField {
 	return other
 }
 
+// decodeSimilarRows decodes similar rows encoded by encodeSimilarRows.
+func (s Serializer) decodeSimilarRows(a *pb.PairsField, vals []uint64) *pilosa.SimilarRows {
+	other := &pilosa.SimilarRows{
+		Rows:  make([]pilosa.SimilarRow, len(a.Pairs)),
+		Field: a.Field,
+	}
+	for i, p := range a.Pairs {
+		other.Rows[i] = pilosa.SimilarRow{
+			ID:           p.ID,
+			Key:          p.Key,
+			Score:        math.Float64frombits(vals[2*i+1]),
+			Intersection: p.Count,
+			Count:        vals[2*i],
+		}
+	}
+	return other
+}
+
 func (s Serializer) decodePair(pb *pb.Pair) pilosa.Pair {
 	return pilosa.Pair{
 		ID:    pb.ID,
@@ -1688,6 +1743,17 @@ func (s Serializer) encodeDistinctTimestamp(d pilosa.DistinctTimestamp) *pb.Dist
 	}
 }
 
+// encodeRetentionMatrix flattens a retention matrix into the start time, size,
+// number of retained counts and retained counts of each cohort.
+func (s Serializer) encodeRetentionMatrix(matrix pilosa.RetentionMatrix) []uint64 {
+	var vals []uint64
+	for _, cohort := range matrix {
+		vals = append(vals, uint64(cohort.Start.Unix()), cohort.Size, uint64(len(cohort.Retained)))
+		vals = append(vals, cohort.Retained...)
+	}
+	return vals
+}
+
 func (s Serializer) encodeGroupCounts(counts *pilosa.GroupCounts) *pb.GroupCounts {
 	groups := counts.Groups()
 	result := &pb.GroupCounts{
@@ -1833,6 +1899,25 @@ func (s Serializer) encodePairsField(a *pilosa.PairsField) *pb.PairsField {
 	return other
 }
 
+// encodeSimilarRows encodes similar rows as pairs of their IDs or keys and
+// intersections, and a count and score for each of them.
+func (s Serializer) encodeSimilarRows(a *pilosa.SimilarRows) (*pb.PairsField, []uint64) {
+	other := &pb.PairsField{
+		Pairs: make([]*pb.Pair, len(a.Rows)),
+		Field: a.Field,
+	}
+	vals := make([]uint64, 0, 2*len(a.Rows))
+	for i, row := range a.Rows {
+		other.Pairs[i] = &pb.Pair{
+			ID:    row.ID,
+			Key:   row.Key,
+			Count: row.Intersection,
+		}
+		vals = append(vals, row.Count, math.Float64bits(row.Score))
+	}
+	return other, vals
+}
+
 func (s Serializer) encodePair(p pilosa.Pair) *pb.Pair {
 	return &pb.Pair{
 		ID:    p.ID,
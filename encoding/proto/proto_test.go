@@ -5,6 +5,7 @@ package proto
 import (
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/apache/arrow/go/v10/arrow"
 	"github.com/apache/arrow/go/v10/arrow/array"
@@ -85,6 +86,47 @@ func TestDecodeQueryResult(t *testing.T) {
 			t.Errorf("failed to decode DistinctTimestamp. expected %v got %v", piloTime, decoded)
 		}
 	})
+
+	t.Run("FunnelCounts", func(t *testing.T) {
+		s := Serializer{}
+		counts := pilosa.FunnelCounts{10, 4, 1}
+		resp := s.encodeQueryResponse(&pilosa.QueryResponse{Results: []interface{}{counts}})
+		decoded := s.decodeQueryResult(resp.Results[0])
+		if !reflect.DeepEqual(decoded, counts) {
+			t.Errorf("failed to decode FunnelCounts. expected %v got %v", counts, decoded)
+		}
+	})
+
+	t.Run("RetentionMatrix", func(t *testing.T) {
+		s := Serializer{}
+		start := time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC)
+		matrix := pilosa.RetentionMatrix{
+			{Start: start, Size: 10, Retained: []uint64{10, 4, 1}},
+			{Start: start.AddDate(0, 0, 7), Size: 0, Retained: []uint64{0, 0}},
+			{Start: start.AddDate(0, 0, 14), Size: 3, Retained: []uint64{2}},
+		}
+		resp := s.encodeQueryResponse(&pilosa.QueryResponse{Results: []interface{}{matrix}})
+		decoded := s.decodeQueryResult(resp.Results[0])
+		if !reflect.DeepEqual(decoded, matrix) {
+			t.Errorf("failed to decode RetentionMatrix. expected %v got %v", matrix, decoded)
+		}
+	})
+
+	t.Run("SimilarRows", func(t *testing.T) {
+		s := Serializer{}
+		rows := &pilosa.SimilarRows{
+			Rows: []pilosa.SimilarRow{
+				{ID: 3, Key: "c", Score: 0.75, Intersection: 3, Count: 4},
+				{ID: 1, Key: "a", Score: 1.0 / 3, Intersection: 2, Count: 5},
+			},
+			Field: "f",
+		}
+		resp := s.encodeQueryResponse(&pilosa.QueryResponse{Results: []interface{}{rows}})
+		decoded := s.decodeQueryResult(resp.Results[0])
+		if !reflect.DeepEqual(decoded, rows) {
+			t.Errorf("failed to decode SimilarRows. expected %v got %v", rows, decoded)
+		}
+	})
 }
 
 func TestDataFrameQueryResult(t *testing.T) {
@@ -0,0 +1,241 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+// Package encryption provides AES-GCM encryption of data at rest using keys
+// supplied by a KeyProvider.
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const (
+	// KeySize is the size of an encryption key, in bytes. Keys are used
+	// with AES-256.
+	KeySize = 32
+
+	// NonceSize is the size of a GCM nonce, in bytes.
+	NonceSize = 12
+
+	// TagSize is the size of a GCM authentication tag, in bytes.
+	TagSize = 16
+
+	// valueHeaderSize is the size of the key ID & nonce which prefix
+	// every sealed value.
+	valueHeaderSize = 4 + NonceSize
+)
+
+var (
+	// ErrKeyNotFound is returned when a key ID is unknown to a KeyProvider.
+	ErrKeyNotFound = errors.New("encryption: key not found")
+
+	// ErrDecrypt is returned when ciphertext fails authentication, either
+	// because it was modified or because it was sealed with a different key.
+	ErrDecrypt = errors.New("encryption: message authentication failed")
+)
+
+// Config holds the encryption settings for a server.
+type Config struct {
+	// KeyFile is the path to a local key file. See KeyFile for the format.
+	// Data is not encrypted if this is blank.
+	KeyFile string `toml:"key-file"`
+}
+
+// NewDefaultConfig returns a Config with encryption disabled.
+func NewDefaultConfig() *Config {
+	return &Config{}
+}
+
+// KeyProvider supplies encryption keys by ID. Keys are never deleted from a
+// provider while data sealed with them may still exist; rotating keys adds
+// a new current key and existing data is re-encrypted with it over time.
+//
+// Implementations may read keys from a local file, such as KeyFile, or fetch
+// them from an external key management server speaking a protocol such as
+// KMIP. Key IDs must be non-zero.
+type KeyProvider interface {
+	// CurrentKeyID returns the ID of the key used to encrypt new data.
+	CurrentKeyID() (uint32, error)
+
+	// Key returns the key material for id. It returns ErrKeyNotFound if
+	// the key does not exist.
+	Key(id uint32) ([]byte, error)
+}
+
+// Cipher encrypts and decrypts data with AES-256-GCM. It caches the AEAD
+// for each key so that keys are fetched from the provider only once.
+type Cipher struct {
+	provider KeyProvider
+
+	mu    sync.RWMutex
+	aeads map[uint32]*aead
+}
+
+type aead struct {
+	gcm cipher.AEAD
+
+	// nonceKey is derived from the key and used to generate nonces for
+	// deterministic encryption.
+	nonceKey []byte
+}
+
+// NewCipher returns a Cipher using keys from provider.
+func NewCipher(provider KeyProvider) *Cipher {
+	return &Cipher{
+		provider: provider,
+		aeads:    make(map[uint32]*aead),
+	}
+}
+
+// CurrentKeyID returns the ID of the key used to encrypt new data.
+func (c *Cipher) CurrentKeyID() (uint32, error) {
+	return c.provider.CurrentKeyID()
+}
+
+// aead returns the cached AEAD for key id, creating it if needed.
+func (c *Cipher) aead(id uint32) (*aead, error) {
+	c.mu.RLock()
+	a := c.aeads[id]
+	c.mu.RUnlock()
+	if a != nil {
+		return a, nil
+	}
+
+	key, err := c.provider.Key(id)
+	if err != nil {
+		return nil, fmt.Errorf("key %d: %w", id, err)
+	} else if len(key) != KeySize {
+		return nil, fmt.Errorf("key %d: invalid key size %d", id, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("featurebase deterministic nonce"))
+	a = &aead{gcm: gcm, nonceKey: mac.Sum(nil)}
+
+	c.mu.Lock()
+	c.aeads[id] = a
+	c.mu.Unlock()
+	return a, nil
+}
+
+// Seal encrypts src with the current key into dst, which must be the same
+// length as src, authenticating aad as well. A random nonce is used. The
+// key ID, nonce and tag are returned and must be supplied to Open.
+func (c *Cipher) Seal(dst, src, aad []byte) (keyID uint32, nonce [NonceSize]byte, tag [TagSize]byte, err error) {
+	if keyID, err = c.CurrentKeyID(); err != nil {
+		return 0, nonce, tag, err
+	}
+	a, err := c.aead(keyID)
+	if err != nil {
+		return 0, nonce, tag, err
+	}
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return 0, nonce, tag, err
+	}
+
+	out := a.gcm.Seal(make([]byte, 0, len(src)+TagSize), nonce[:], src, aad)
+	copy(dst, out[:len(src)])
+	copy(tag[:], out[len(src):])
+	return keyID, nonce, tag, nil
+}
+
+// Open decrypts src into dst, which must be the same length as src. It
+// returns ErrDecrypt if src, aad or tag have been modified.
+func (c *Cipher) Open(dst, src, aad []byte, keyID uint32, nonce, tag []byte) error {
+	a, err := c.aead(keyID)
+	if err != nil {
+		return err
+	}
+
+	buf := make([]byte, len(src)+TagSize)
+	copy(buf, src)
+	copy(buf[len(src):], tag)
+	out, err := a.gcm.Open(buf[:0], nonce, buf, aad)
+	if err != nil {
+		return ErrDecrypt
+	}
+	copy(dst, out)
+	return nil
+}
+
+// SealValue encrypts a variable-length value with the current key and a
+// random nonce. The result embeds the key ID & nonce and can be passed
+// directly to OpenValue.
+func (c *Cipher) SealValue(plaintext []byte) ([]byte, error) {
+	keyID, err := c.CurrentKeyID()
+	if err != nil {
+		return nil, err
+	}
+	var nonce [NonceSize]byte
+	if _, err := rand.Read(nonce[:]); err != nil {
+		return nil, err
+	}
+	return c.sealValue(keyID, nonce[:], plaintext)
+}
+
+// SealValueDeterministic encrypts a value with key keyID such that the same
+// plaintext always produces the same ciphertext. This allows encrypted values
+// to be used as lookup keys, at the cost of revealing which values are equal.
+// The nonce is a keyed hash of the plaintext so distinct plaintexts never
+// share a nonce.
+func (c *Cipher) SealValueDeterministic(keyID uint32, plaintext []byte) ([]byte, error) {
+	a, err := c.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	mac := hmac.New(sha256.New, a.nonceKey)
+	mac.Write(plaintext)
+	return c.sealValue(keyID, mac.Sum(nil)[:NonceSize], plaintext)
+}
+
+func (c *Cipher) sealValue(keyID uint32, nonce, plaintext []byte) ([]byte, error) {
+	a, err := c.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	buf := make([]byte, valueHeaderSize, valueHeaderSize+len(plaintext)+TagSize)
+	binary.BigEndian.PutUint32(buf[0:4], keyID)
+	copy(buf[4:valueHeaderSize], nonce)
+	return a.gcm.Seal(buf, nonce, plaintext, buf[0:4]), nil
+}
+
+// OpenValue decrypts a value sealed by SealValue or SealValueDeterministic.
+func (c *Cipher) OpenValue(value []byte) ([]byte, error) {
+	keyID, ok := ValueKeyID(value)
+	if !ok {
+		return nil, ErrDecrypt
+	}
+	a, err := c.aead(keyID)
+	if err != nil {
+		return nil, err
+	}
+	out, err := a.gcm.Open(nil, value[4:valueHeaderSize], value[valueHeaderSize:], value[0:4])
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return out, nil
+}
+
+// ValueKeyID returns the ID of the key a sealed value was encrypted with.
+// Returns false if value is too short to be a sealed value.
+func ValueKeyID(value []byte) (uint32, bool) {
+	if len(value) < valueHeaderSize+TagSize {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(value[0:4]), true
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package encryption_test
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/encryption"
+)
+
+// mustOpenKeyFile returns a key file with n keys.
+func mustOpenKeyFile(tb testing.TB, n int) *encryption.KeyFile {
+	tb.Helper()
+	path := filepath.Join(tb.TempDir(), "keys")
+	for i := 0; i < n; i++ {
+		if _, err := encryption.AddKey(path); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	kf, err := encryption.OpenKeyFile(path)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return kf
+}
+
+func TestKeyFile(t *testing.T) {
+	kf := mustOpenKeyFile(t, 2)
+	if id, err := kf.CurrentKeyID(); err != nil {
+		t.Fatal(err)
+	} else if id != 2 {
+		t.Fatalf("CurrentKeyID()=%d, want 2", id)
+	}
+	if key, err := kf.Key(1); err != nil {
+		t.Fatal(err)
+	} else if len(key) != encryption.KeySize {
+		t.Fatalf("unexpected key size: %d", len(key))
+	}
+	if _, err := kf.Key(3); err != encryption.ErrKeyNotFound {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	for name, content := range map[string]string{
+		"Empty":     "# no keys\n",
+		"ZeroID":    "0 " + string(bytes.Repeat([]byte("ab"), encryption.KeySize)) + "\n",
+		"ShortKey":  "1 abcd\n",
+		"Duplicate": "1 " + string(bytes.Repeat([]byte("ab"), encryption.KeySize)) + "\n1 " + string(bytes.Repeat([]byte("cd"), encryption.KeySize)) + "\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "keys")
+			if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+				t.Fatal(err)
+			} else if _, err := encryption.OpenKeyFile(path); err == nil {
+				t.Fatal("expected error")
+			}
+		})
+	}
+}
+
+func TestCipher_SealOpen(t *testing.T) {
+	c := encryption.NewCipher(mustOpenKeyFile(t, 1))
+	plaintext := []byte("the quick brown fox")
+	aad := []byte("aad")
+
+	ciphertext := make([]byte, len(plaintext))
+	keyID, nonce, tag, err := c.Seal(ciphertext, plaintext, aad)
+	if err != nil {
+		t.Fatal(err)
+	} else if keyID != 1 {
+		t.Fatalf("unexpected key id: %d", keyID)
+	} else if bytes.Equal(ciphertext, plaintext) {
+		t.Fatal("expected ciphertext to differ from plaintext")
+	}
+
+	out := make([]byte, len(ciphertext))
+	if err := c.Open(out, ciphertext, aad, keyID, nonce[:], tag[:]); err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(out, plaintext) {
+		t.Fatalf("Open()=%q, want %q", out, plaintext)
+	}
+
+	// Modified data or additional data must fail authentication.
+	if err := c.Open(out, ciphertext, []byte("other"), keyID, nonce[:], tag[:]); !errors.Is(err, encryption.ErrDecrypt) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ciphertext[0] ^= 0xFF
+	if err := c.Open(out, ciphertext, aad, keyID, nonce[:], tag[:]); !errors.Is(err, encryption.ErrDecrypt) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestCipher_SealValue(t *testing.T) {
+	c := encryption.NewCipher(mustOpenKeyFile(t, 2))
+	value := []byte("foo")
+
+	// Random nonces produce distinct ciphertexts.
+	a, err := c.SealValue(value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := c.SealValue(value)
+	if err != nil {
+		t.Fatal(err)
+	} else if bytes.Equal(a, b) {
+		t.Fatal("expected distinct ciphertexts")
+	}
+
+	// Deterministic encryption produces equal ciphertexts per key.
+	d1, err := c.SealValueDeterministic(1, value)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d2, err := c.SealValueDeterministic(1, value)
+	if err != nil {
+		t.Fatal(err)
+	} else if !bytes.Equal(d1, d2) {
+		t.Fatal("expected equal ciphertexts")
+	}
+	if d3, err := c.SealValueDeterministic(2, value); err != nil {
+		t.Fatal(err)
+	} else if bytes.Equal(d1, d3) {
+		t.Fatal("expected distinct ciphertexts for different keys")
+	}
+
+	for _, sealed := range [][]byte{a, b, d1} {
+		if out, err := c.OpenValue(sealed); err != nil {
+			t.Fatal(err)
+		} else if !bytes.Equal(out, value) {
+			t.Fatalf("OpenValue()=%q, want %q", out, value)
+		}
+	}
+	if id, ok := encryption.ValueKeyID(d1); !ok || id != 1 {
+		t.Fatalf("ValueKeyID()=%d, %v", id, ok)
+	}
+	if _, err := c.OpenValue(value); !errors.Is(err, encryption.ErrDecrypt) {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
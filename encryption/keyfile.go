@@ -0,0 +1,157 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package encryption
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Ensure type implements interface.
+var _ KeyProvider = (*KeyFile)(nil)
+
+// KeyFile is a KeyProvider which reads keys from a local file. Each line of
+// the file holds a key ID and a hex-encoded 256-bit key separated by
+// whitespace. Blank lines and lines starting with '#' are ignored. The key
+// with the highest ID is the current key, so keys are rotated by appending
+// a new line with a larger ID.
+type KeyFile struct {
+	mu      sync.RWMutex
+	path    string
+	keys    map[uint32][]byte
+	current uint32
+}
+
+// OpenKeyFile reads the key file at path.
+func OpenKeyFile(path string) (*KeyFile, error) {
+	f := &KeyFile{path: path}
+	if err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Path returns the path of the key file.
+func (f *KeyFile) Path() string { return f.path }
+
+// Reload re-reads the key file so that newly added keys are picked up.
+func (f *KeyFile) Reload() error {
+	buf, err := os.ReadFile(f.path)
+	if err != nil {
+		return fmt.Errorf("read key file: %w", err)
+	}
+	keys, current, err := parseKeyFile(buf)
+	if err != nil {
+		return fmt.Errorf("parse key file %s: %w", f.path, err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys, f.current = keys, current
+	return nil
+}
+
+// CurrentKeyID returns the highest key ID in the file.
+func (f *KeyFile) CurrentKeyID() (uint32, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.current, nil
+}
+
+// Key returns the key with the given ID.
+func (f *KeyFile) Key(id uint32) ([]byte, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	key, ok := f.keys[id]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	return key, nil
+}
+
+// KeyIDs returns the IDs of all keys in the file.
+func (f *KeyFile) KeyIDs() []uint32 {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	ids := make([]uint32, 0, len(f.keys))
+	for id := range f.keys {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+func parseKeyFile(buf []byte) (keys map[uint32][]byte, current uint32, err error) {
+	keys = make(map[uint32][]byte)
+	scanner := bufio.NewScanner(bytes.NewReader(buf))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			return nil, 0, fmt.Errorf("line %d: expected key ID and key", lineNo)
+		}
+		id, err := strconv.ParseUint(fields[0], 10, 32)
+		if err != nil || id == 0 {
+			return nil, 0, fmt.Errorf("line %d: invalid key ID %q", lineNo, fields[0])
+		}
+		key, err := hex.DecodeString(fields[1])
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: invalid key: %w", lineNo, err)
+		} else if len(key) != KeySize {
+			return nil, 0, fmt.Errorf("line %d: key must be %d bytes, got %d", lineNo, KeySize, len(key))
+		} else if _, ok := keys[uint32(id)]; ok {
+			return nil, 0, fmt.Errorf("line %d: duplicate key ID %d", lineNo, id)
+		}
+
+		keys[uint32(id)] = key
+		if uint32(id) > current {
+			current = uint32(id)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	} else if len(keys) == 0 {
+		return nil, 0, fmt.Errorf("no keys found")
+	}
+	return keys, current, nil
+}
+
+// AddKey generates a new random key and appends it to the key file at path,
+// creating the file if it does not exist. The new key becomes the current
+// key and its ID is returned.
+func AddKey(path string) (uint32, error) {
+	var current uint32
+	if buf, err := os.ReadFile(path); err == nil {
+		if _, current, err = parseKeyFile(buf); err != nil {
+			return 0, fmt.Errorf("parse key file %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return 0, fmt.Errorf("read key file: %w", err)
+	}
+
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return 0, err
+	}
+	id := current + 1
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o600)
+	if err != nil {
+		return 0, fmt.Errorf("open key file: %w", err)
+	}
+	defer f.Close()
+	if _, err := fmt.Fprintf(f, "%d %x\n", id, key); err != nil {
+		return 0, fmt.Errorf("write key file: %w", err)
+	}
+	return id, f.Sync()
+}
@@ -502,18 +502,25 @@ func (e *Etcd) ClusterState(ctx context.Context) (out disco.ClusterState, err er
 	)
 	e.nodeMu.Lock()
 	nodes := e.populateNodeStates(ctx)
+	knownN := len(e.knownNodes)
 	e.nodeMu.Unlock()
 	if err != nil {
 		e.logger.Errorf("requesting cluster state %q: getting node states: %v", e.options.Name, err)
 		return disco.ClusterStateUnknown, err
 	}
 	for _, node := range nodes {
+		// Read replicas hold no partitions, so they do not affect the
+		// availability of the cluster.
+		if node.IsReadReplica {
+			knownN--
+			continue
+		}
 		if node.State == disco.NodeStateStarted {
 			heartbeats++
 		}
 	}
-	if heartbeats < len(e.knownNodes) {
-		if len(e.knownNodes)-heartbeats >= e.replicas {
+	if heartbeats < knownN {
+		if knownN-heartbeats >= e.replicas {
 			return disco.ClusterStateDown, nil
 		}
 		return disco.ClusterStateDegraded, nil
@@ -1111,12 +1118,19 @@ func (e *Etcd) PrimaryNodeID(hasher disco.Hasher) string {
 	return disco.PrimaryNodeID(e.NodeIDs(), hasher)
 }
 
-// NodeIDs returns the list of node IDs in the etcd cluster.
+// NodeIDs returns the list of node IDs in the etcd cluster. Read replicas are
+// excluded since they cannot be the primary.
 func (e *Etcd) NodeIDs() []string {
 	peers := e.Peers()
-	ids := make([]string, len(peers))
-	for i, peer := range peers {
-		ids[i] = peer.ID
+
+	e.nodeMu.Lock()
+	defer e.nodeMu.Unlock()
+	ids := make([]string, 0, len(peers))
+	for _, peer := range peers {
+		if data := e.knownNodes[peer.ID]; data != nil && data.node != nil && data.node.IsReadReplica {
+			continue
+		}
+		ids = append(ids, peer.ID)
 	}
 	return ids
 }
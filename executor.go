@@ -17,6 +17,8 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/featurebasedb/featurebase/v3/audit"
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/pql"
@@ -80,6 +82,16 @@ type executor struct {
 	// Temporary flag to be removed when stablized
 	dataframeEnabled   bool
 	datafameUseParquet bool
+
+	// readReplica is set if the node is a read replica.
+	readReplica *readReplica
+
+	// auditLogger records deletes.
+	auditLogger *audit.Logger
+
+	// resultCache memoizes the results of mapping calls over shards. It is
+	// nil if caching is disabled.
+	resultCache *resultCache
 }
 
 // executorOption is a functional option type for pilosa.executor
@@ -106,6 +118,15 @@ func optExecutorMaxMemory(v int64) executorOption {
 	}
 }
 
+func optExecutorResultCache(maxEntries int) executorOption {
+	return func(e *executor) error {
+		if maxEntries > 0 {
+			e.resultCache = newResultCache(maxEntries)
+		}
+		return nil
+	}
+}
+
 func emptyResult(c *pql.Call) interface{} {
 	switch c.Name {
 	case "Clear", "ClearRow":
@@ -180,14 +201,21 @@ func (e *executor) InitStats() {
 }
 
 // Execute executes a PQL query.
-func (e *executor) Execute(ctx context.Context, tableKeyer dax.TableKeyer, q *pql.Query, shards []uint64, opt *ExecOptions) (QueryResponse, error) {
+func (e *executor) Execute(ctx context.Context, tableKeyer dax.TableKeyer, q *pql.Query, shards []uint64, opt *ExecOptions) (resp QueryResponse, err error) {
 	index := string(tableKeyer.Key())
 
 	span, ctx := tracing.StartSpanFromContext(ctx, "executor.Execute")
 	span.LogKV("pql", q.String())
 	defer span.Finish()
 
-	resp := QueryResponse{}
+	// Record deletes in the audit log. Remote calls sent by another node on
+	// behalf of the system are recorded by that node; others are recorded
+	// here too, since the remote flag is set by the client. The query is
+	// formatted now, since executing it may rewrite its calls.
+	if (opt == nil || !opt.Remote || !fbcontext.Internal(ctx)) && q.HasCall("Delete") {
+		query := q.String()
+		defer func() { e.auditLogger.Log(ctx, audit.EventDelete, index, query, err) }()
+	}
 
 	// Check for query cancellation.
 	if err := validateQueryContext(ctx); err != nil {
@@ -224,6 +252,13 @@ func (e *executor) Execute(ctx context.Context, tableKeyer dax.TableKeyer, q *pq
 		opt.MaxMemory = e.maxMemory
 	}
 
+	// Restrict the query to what the user may access. Remote calls are
+	// checked again, since the remote flag is set by the client; users with
+	// restrictions on the index can't send them at all.
+	if err := restrictQuery(ctx, index, q); err != nil {
+		return resp, err
+	}
+
 	if opt.Profile {
 		var prof tracing.ProfiledSpan
 		prof, ctx = tracing.StartProfiledSpanFromContext(ctx, "Execute")
@@ -253,6 +288,10 @@ func (e *executor) Execute(ctx context.Context, tableKeyer dax.TableKeyer, q *pq
 	}
 	resp.Results = results
 
+	// Count the query against each shard it was explicitly sent to; this is
+	// how queries are distributed to DAX compute workers.
+	e.Holder.shardQueries.add(index, shards)
+
 	// Translate response objects from ids to keys, if necessary.
 	// No need to translate a remote call.
 	if !opt.Remote {
@@ -352,6 +391,15 @@ func safeCopy(resp QueryResponse) (out QueryResponse) {
 			out.Results = append(out.Results, x)
 		case ExtractedIDMatrixSorted:
 			out.Results = append(out.Results, x)
+		case FunnelCounts:
+			// no bitmap material, so should be ok to skip Clone()
+			out.Results = append(out.Results, x)
+		case RetentionMatrix:
+			// no bitmap material, so should be ok to skip Clone()
+			out.Results = append(out.Results, x)
+		case *SimilarRows:
+			// no bitmap material, so should be ok to skip Clone()
+			out.Results = append(out.Results, x)
 		default:
 			panic(fmt.Sprintf("handle %T here", v))
 		}
@@ -820,6 +868,22 @@ func (e *executor) executeCall(ctx context.Context, qcx *Qcx, index string, c *p
 		statFn(CounterQueryPercentileTotal)
 		res, err := e.executePercentile(ctx, qcx, index, c, shards, opt)
 		return res, errors.Wrap(err, "executePercentile")
+	case "Funnel":
+		statFn(CounterQueryFunnelTotal)
+		res, err := e.executeFunnel(ctx, qcx, index, c, shards, opt)
+		return res, errors.Wrap(err, "executeFunnel")
+	case "Retention":
+		statFn(CounterQueryRetentionTotal)
+		res, err := e.executeRetention(ctx, qcx, index, c, shards, opt)
+		return res, errors.Wrap(err, "executeRetention")
+	case "Sample":
+		statFn(CounterQuerySampleTotal)
+		res, err := e.executeSample(ctx, qcx, index, c, shards, opt)
+		return res, errors.Wrap(err, "executeSample")
+	case "Similar":
+		statFn(CounterQuerySimilarTotal)
+		res, err := e.executeSimilar(ctx, qcx, index, c, shards, opt)
+		return res, errors.Wrap(err, "executeSimilar")
 	case "Delete":
 		statFn(CounterQueryDeleteTotal)
 		res, err := e.executeDeleteRecords(ctx, qcx, index, c, shards, opt)
@@ -900,6 +964,13 @@ func (e *executor) executeOptionsCall(ctx context.Context, qcx *Qcx, index strin
 			return nil, errors.New("Query(): shards must be a list of unsigned integers")
 		}
 	}
+	if arg, ok := c.Args["cache"]; ok {
+		cache, ok := arg.(bool)
+		if !ok {
+			return nil, errors.New("Query(): cache must be a boolean")
+		}
+		optCopy.NoCache = !cache
+	}
 	return e.executeCall(ctx, qcx, index, c.Children[0], shards, optCopy)
 }
 
@@ -1133,6 +1204,7 @@ func (e *executor) executeSum(ctx context.Context, qcx *Qcx, index string, c *pq
 	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
 		return e.executeSumCountShard(ctx, qcx, index, c, nil, shard)
 	}
+	mapFn = e.resultCache.wrap(qcx, index, c, opt, mapFn)
 
 	// Merge returned results at coordinating node.
 	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
@@ -1238,6 +1310,7 @@ func (e *executor) executeMin(ctx context.Context, qcx *Qcx, index string, c *pq
 	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
 		return e.executeMinShard(ctx, qcx, index, c, shard)
 	}
+	mapFn = e.resultCache.wrap(qcx, index, c, opt, mapFn)
 
 	// Merge returned results at coordinating node.
 	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
@@ -1274,6 +1347,7 @@ func (e *executor) executeMax(ctx context.Context, qcx *Qcx, index string, c *pq
 	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
 		return e.executeMaxShard(ctx, qcx, index, c, shard)
 	}
+	mapFn = e.resultCache.wrap(qcx, index, c, opt, mapFn)
 
 	// Merge returned results at coordinating node.
 	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
@@ -3292,6 +3366,7 @@ func (e *executor) executeGroupBy(ctx context.Context, qcx *Qcx, index string, c
 	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
 		return e.executeGroupByShard(ctx, qcx, index, c, filter, shard, childRows, bases, ignoreLimit)
 	}
+	mapFn = e.resultCache.wrap(qcx, index, c, opt, mapFn)
 
 	// Merge returned results at coordinating node.
 	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
@@ -5875,6 +5950,7 @@ func (e *executor) executeCount(ctx context.Context, qcx *Qcx, index string, c *
 		}
 		return row.Count(), nil
 	}
+	mapFn = e.resultCache.wrap(qcx, index, c, opt, mapFn)
 
 	// Merge returned results at coordinating node.
 	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
@@ -6416,6 +6492,13 @@ func (e *executor) remoteExec(ctx context.Context, node *disco.Node, index strin
 func (e *executor) shardsByNode(nodes []*disco.Node, index string, shards []uint64) (map[*disco.Node][]uint64, error) {
 	m := make(map[*disco.Node][]uint64)
 
+	// A read replica holds a copy of every shard, so it executes queries
+	// locally unless its copy is too stale.
+	if e.readReplica != nil && e.readReplica.fresh() {
+		m[e.Node] = shards
+		return m, nil
+	}
+
 	// Create a snapshot of the cluster to use for node/partition calculations.
 	// We use e.Cluster.Nodes() here instead of e.Cluster.noder because we need
 	// the node states in order to ensure that we don't include an unavailable
@@ -6617,7 +6700,12 @@ func (e *executor) mapper(ctx context.Context, eg *errgroup.Group, ch chan mapRe
 				if opt.EmbeddedData != nil {
 					embeddedRowsForNode = makeEmbeddedDataForShards(opt.EmbeddedData, nodeShards)
 				}
-				results, err := e.remoteExec(ctx, n, index, &pql.Query{Calls: []*pql.Call{c}}, nodeShards, embeddedRowsForNode, memoryAvailable)
+				call := c
+				if opt.NoCache {
+					// Pass the opt-out on to the remote node.
+					call = &pql.Call{Name: "Options", Args: map[string]interface{}{"cache": false}, Children: []*pql.Call{c}}
+				}
+				results, err := e.remoteExec(ctx, n, index, &pql.Query{Calls: []*pql.Call{call}}, nodeShards, embeddedRowsForNode, memoryAvailable)
 				if len(results) > 0 {
 					resp.result = results[0]
 				}
@@ -7791,6 +7879,31 @@ func (e *executor) translateResult(ctx context.Context, index string, idx *Index
 			}
 		}
 
+	case *SimilarRows:
+		field := idx.Field(result.Field)
+		if field == nil {
+			return nil, fmt.Errorf("field %q not found", result.Field)
+		}
+		if field.Keys() && len(result.Rows) > 0 {
+			ids := make([]uint64, len(result.Rows))
+			for i := range result.Rows {
+				ids[i] = result.Rows[i].ID
+			}
+			keys, err := e.Cluster.translateFieldListIDs(ctx, field, ids)
+			if err != nil {
+				return nil, err
+			}
+			other := make([]SimilarRow, len(result.Rows))
+			for i := range result.Rows {
+				other[i] = result.Rows[i]
+				other[i].Key = keys[i]
+			}
+			return &SimilarRows{
+				Rows:  other,
+				Field: result.Field,
+			}, nil
+		}
+
 	case *GroupCounts:
 		fieldIDs := make(map[*Field]map[uint64]struct{})
 		foreignIDs := make(map[*Field]map[uint64]struct{})
@@ -8205,6 +8318,10 @@ type ExecOptions struct {
 	PreTranslated bool
 	EmbeddedData  []*Row
 	MaxMemory     int64
+
+	// NoCache skips the result cache, for queries which must see the
+	// effect of every write, or which won't be repeated.
+	NoCache bool
 }
 
 func needsShards(call *pql.Call) bool {
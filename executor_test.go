@@ -9932,3 +9932,417 @@ func TestExecutorTimeRange(t *testing.T) {
 		}
 	})
 }
+
+func TestExecutor_Execute_Funnel(t *testing.T) {
+	c := test.MustRunCluster(t, 3)
+	defer c.Close()
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "event", pilosa.OptFieldKeys(), pilosa.OptFieldTypeTime(pilosa.TimeQuantum("YMDH"), "0"))
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "plan")
+
+	// Records in several shards, so that every node has some.
+	c.Query(t, c.Idx(), fmt.Sprintf(`
+		Set(1, event="view", 2023-01-01T10:00)
+		Set(1, event="cart", 2023-01-01T12:00)
+		Set(1, event="buy", 2023-01-02T09:00)
+		Set(1, plan=1)
+
+		Set(%[1]d, event="view", 2023-01-01T10:00)
+		Set(%[1]d, event="cart", 2023-01-01T09:00)
+		Set(%[1]d, event="buy", 2023-01-01T11:00)
+
+		Set(%[2]d, event="view", 2023-01-01T10:00)
+		Set(%[2]d, event="cart", 2023-01-03T10:00)
+		Set(%[2]d, event="buy", 2023-01-03T11:00)
+
+		Set(4, event="view", 2023-01-01T10:00)
+		Set(4, event="cart", 2023-01-01T10:00)
+		Set(4, plan=1)
+
+		Set(5, event="cart", 2023-01-01T10:00)
+		Set(%[3]d, event="view", 2023-01-05T10:00)
+	`, ShardWidth+2, 2*ShardWidth+3, 3*ShardWidth+6))
+
+	const steps = `Row(event="view"), Row(event="cart"), Row(event="buy")`
+	const timeRange = `from=2023-01-01T00:00, to=2023-01-04T00:00`
+	for _, tt := range []struct {
+		name  string
+		query string
+		exp   pilosa.FunnelCounts
+	}{
+		{
+			name:  "NoWindow",
+			query: fmt.Sprintf(`Funnel(%s, %s)`, steps, timeRange),
+			exp:   pilosa.FunnelCounts{4, 3, 2},
+		},
+		{
+			name:  "Window",
+			query: fmt.Sprintf(`Funnel(%s, window="24h", %s)`, steps, timeRange),
+			exp:   pilosa.FunnelCounts{4, 2, 1},
+		},
+		{
+			name:  "Filter",
+			query: fmt.Sprintf(`Funnel(%s, filter=Row(plan=1), %s)`, steps, timeRange),
+			exp:   pilosa.FunnelCounts{2, 2, 1},
+		},
+		{
+			name:  "Range",
+			query: fmt.Sprintf(`Funnel(%s, from=2023-01-01T00:00, to=2023-01-06T00:00)`, steps),
+			exp:   pilosa.FunnelCounts{5, 3, 2},
+		},
+		{
+			name:  "MissingKey",
+			query: fmt.Sprintf(`Funnel(Row(event="view"), Row(event="none"), %s)`, timeRange),
+			exp:   pilosa.FunnelCounts{4, 0},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := c.Query(t, c.Idx(), tt.query)
+			if got := resp.Results[0]; !reflect.DeepEqual(got, tt.exp) {
+				t.Fatalf("expected %v, got %v", tt.exp, got)
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, query := range []string{
+			`Funnel(from=2023-01-01T00:00, to=2023-01-04T00:00)`,
+			`Funnel(Row(event="view"), to=2023-01-04T00:00)`,
+			`Funnel(Row(event="view"), from=2023-01-04T00:00, to=2023-01-01T00:00)`,
+			`Funnel(Row(plan=1), from=2023-01-01T00:00, to=2023-01-04T00:00)`,
+			`Funnel(Row(event="view"), window="soon", from=2023-01-01T00:00, to=2023-01-04T00:00)`,
+			`Funnel(Row(event="view", from=2023-01-01T00:00), from=2023-01-01T00:00, to=2023-01-04T00:00)`,
+			// Two months of hours is more buckets than allowed.
+			`Funnel(Row(event="view"), from=2023-01-01T00:00, to=2023-03-01T00:00)`,
+			`Funnel(Row(event="view"), from=2023-01-01T00:00, to=9999-01-01T00:00)`,
+		} {
+			if _, err := c.GetPrimary().API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: query}); err == nil {
+				t.Errorf("expected error for %s", query)
+			}
+		}
+	})
+}
+
+func TestExecutor_Execute_Retention(t *testing.T) {
+	c := test.MustRunCluster(t, 3)
+	defer c.Close()
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "event", pilosa.OptFieldKeys(), pilosa.OptFieldTypeTime(pilosa.TimeQuantum("YMDH"), "0"))
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "plan")
+
+	// Records in several shards, so that every node has some.
+	c.Query(t, c.Idx(), fmt.Sprintf(`
+		Set(1, event="signup", 2023-01-01T10:00)
+		Set(1, event="login", 2023-01-01T12:00)
+		Set(1, event="login", 2023-01-02T09:00)
+		Set(1, plan=1)
+
+		Set(%[1]d, event="signup", 2023-01-01T10:00)
+		Set(%[1]d, event="login", 2023-01-03T09:00)
+
+		Set(%[2]d, event="signup", 2023-01-02T10:00)
+		Set(%[2]d, event="login", 2023-01-02T11:00)
+		Set(%[2]d, event="login", 2023-01-03T11:00)
+		Set(%[2]d, plan=1)
+
+		Set(4, event="signup", 2023-01-02T10:00)
+		Set(4, event="signup", 2023-01-03T10:00)
+
+		Set(5, event="signup", 2022-12-31T10:00)
+		Set(5, event="login", 2023-01-01T10:00)
+
+		Set(%[3]d, event="signup", 2023-01-03T10:00)
+		Set(%[3]d, event="login", 2023-01-03T10:00)
+	`, ShardWidth+2, 2*ShardWidth+3, 3*ShardWidth+6))
+
+	day := func(d int) time.Time { return time.Date(2023, 1, d, 0, 0, 0, 0, time.UTC) }
+	const rows = `cohort=Row(event="signup"), activity=Row(event="login")`
+	for _, tt := range []struct {
+		name  string
+		query string
+		exp   pilosa.RetentionMatrix
+	}{
+		{
+			name:  "Day",
+			query: fmt.Sprintf(`Retention(%s, period="day", periods=3, from=2023-01-01T05:00)`, rows),
+			exp: pilosa.RetentionMatrix{
+				{Start: day(1), Size: 2, Retained: []uint64{1, 1, 1}},
+				{Start: day(2), Size: 2, Retained: []uint64{1, 1}},
+				{Start: day(3), Size: 1, Retained: []uint64{1}},
+			},
+		},
+		{
+			name:  "Week",
+			query: fmt.Sprintf(`Retention(%s, period="week", periods=2, from=2023-01-01T00:00)`, rows),
+			exp: pilosa.RetentionMatrix{
+				{Start: day(1), Size: 5, Retained: []uint64{4, 0}},
+				{Start: day(8), Size: 0, Retained: []uint64{0}},
+			},
+		},
+		{
+			name:  "Filter",
+			query: fmt.Sprintf(`Retention(%s, period="day", periods=3, from=2023-01-01T00:00, filter=Row(plan=1))`, rows),
+			exp: pilosa.RetentionMatrix{
+				{Start: day(1), Size: 1, Retained: []uint64{1, 1, 0}},
+				{Start: day(2), Size: 1, Retained: []uint64{1, 1}},
+				{Start: day(3), Size: 0, Retained: []uint64{0}},
+			},
+		},
+		{
+			name:  "MissingKey",
+			query: `Retention(cohort=Row(event="signup"), activity=Row(event="none"), period="day", periods=2, from=2023-01-01T00:00)`,
+			exp: pilosa.RetentionMatrix{
+				{Start: day(1), Size: 2, Retained: []uint64{0, 0}},
+				{Start: day(2), Size: 2, Retained: []uint64{0}},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := c.Query(t, c.Idx(), tt.query)
+			if got := resp.Results[0]; !reflect.DeepEqual(got, tt.exp) {
+				t.Fatalf("expected %v, got %v", tt.exp, got)
+			}
+		})
+	}
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, query := range []string{
+			`Retention(activity=Row(event="login"), period="day", periods=3, from=2023-01-01T00:00)`,
+			fmt.Sprintf(`Retention(%s, period="fortnight", periods=3, from=2023-01-01T00:00)`, rows),
+			fmt.Sprintf(`Retention(%s, period="day", periods=0, from=2023-01-01T00:00)`, rows),
+			fmt.Sprintf(`Retention(%s, period="day", periods=1001, from=2023-01-01T00:00)`, rows),
+			fmt.Sprintf(`Retention(%s, period="day", periods=1000000000000, from=2023-01-01T00:00)`, rows),
+			fmt.Sprintf(`Retention(%s, period="day", periods=3)`, rows),
+			`Retention(cohort=Row(plan=1), activity=Row(event="login"), period="day", periods=3, from=2023-01-01T00:00)`,
+		} {
+			if _, err := c.GetPrimary().API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: query}); err == nil {
+				t.Errorf("expected error for %s", query)
+			}
+		}
+	})
+}
+
+func TestExecutor_Execute_Sample(t *testing.T) {
+	c := test.MustRunCluster(t, 3)
+	defer c.Close()
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "f")
+
+	// 300 records in the first shard and 100 in each of three more, so that
+	// every node has some.
+	var sets strings.Builder
+	for i := 0; i < 300; i++ {
+		fmt.Fprintf(&sets, "Set(%d, f=1)\n", i*3)
+	}
+	for shard := 1; shard <= 3; shard++ {
+		for i := 0; i < 100; i++ {
+			fmt.Fprintf(&sets, "Set(%d, f=1)\n", shard*ShardWidth+i*7)
+		}
+	}
+	c.Query(t, c.Idx(), sets.String())
+	all := c.Query(t, c.Idx(), `Row(f=1)`).Results[0].(*pilosa.Row)
+
+	sample := func(t *testing.T, query string) *pilosa.Row {
+		t.Helper()
+		return c.Query(t, c.Idx(), query).Results[0].(*pilosa.Row)
+	}
+
+	t.Run("N", func(t *testing.T) {
+		row := sample(t, `Sample(Row(f=1), n=60)`)
+		if n := row.Count(); n != 60 {
+			t.Fatalf("expected 60 records, got %d", n)
+		}
+		if n := row.Intersect(all).Count(); n != 60 {
+			t.Fatalf("expected sample of Row(f=1), got %v", row.Columns())
+		}
+		// Each shard contributes in proportion to its records.
+		perShard := make([]int, 4)
+		for _, col := range row.Columns() {
+			perShard[col/ShardWidth]++
+		}
+		if exp := []int{30, 10, 10, 10}; !reflect.DeepEqual(perShard, exp) {
+			t.Errorf("expected %v records per shard, got %v", exp, perShard)
+		}
+	})
+
+	t.Run("Trim", func(t *testing.T) {
+		// Rounding up per shard gives more than 7, which are trimmed.
+		if n := sample(t, `Sample(Row(f=1), n=7)`).Count(); n != 7 {
+			t.Fatalf("expected 7 records, got %d", n)
+		}
+	})
+
+	t.Run("Fraction", func(t *testing.T) {
+		if n := sample(t, `Sample(Row(f=1), fraction=0.25)`).Count(); n != 150 {
+			t.Fatalf("expected 150 records, got %d", n)
+		}
+		if n := sample(t, `Sample(Row(f=1), fraction=1)`).Count(); n != 600 {
+			t.Fatalf("expected 600 records, got %d", n)
+		}
+	})
+
+	t.Run("MoreThanAll", func(t *testing.T) {
+		if row := sample(t, `Sample(Row(f=1), n=1000)`); !reflect.DeepEqual(row.Columns(), all.Columns()) {
+			t.Fatalf("expected all records, got %d", row.Count())
+		}
+	})
+
+	t.Run("Seed", func(t *testing.T) {
+		a := sample(t, `Sample(Row(f=1), n=25, seed=42)`)
+		b := sample(t, `Sample(Row(f=1), n=25, seed=42)`)
+		if !reflect.DeepEqual(a.Columns(), b.Columns()) {
+			t.Fatalf("expected the same sample for the same seed, got %v and %v", a.Columns(), b.Columns())
+		}
+		other := sample(t, `Sample(Row(f=1), n=25, seed=43)`)
+		if reflect.DeepEqual(a.Columns(), other.Columns()) {
+			t.Fatalf("expected different samples for different seeds, got %v", a.Columns())
+		}
+	})
+
+	t.Run("Nested", func(t *testing.T) {
+		resp := c.Query(t, c.Idx(), `Count(Sample(Row(f=1), n=40)) Count(Intersect(Sample(Row(f=1), n=40, seed=1), Row(f=1)))`)
+		for i, exp := range []uint64{40, 40} {
+			if got := resp.Results[i]; got != exp {
+				t.Errorf("result %d: expected %d, got %v", i, exp, got)
+			}
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for _, query := range []string{
+			`Sample(n=3)`,
+			`Sample(Row(f=1))`,
+			`Sample(Row(f=1), n=3, fraction=0.5)`,
+			`Sample(Row(f=1), fraction=1.5)`,
+			`Sample(Row(f=1), n=-1)`,
+		} {
+			if _, err := c.GetPrimary().API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: query}); err == nil {
+				t.Errorf("expected error for %s", query)
+			}
+		}
+	})
+}
+
+func TestExecutor_Execute_Similar(t *testing.T) {
+	c := test.MustRunCluster(t, 3)
+	defer c.Close()
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "f")
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "g")
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "k", pilosa.OptFieldKeys())
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{}, "i", pilosa.OptFieldTypeInt(0, 100))
+
+	// Row 1 is compared with the others, which are spread over shards on
+	// different nodes.
+	rows := map[uint64][]uint64{
+		1: {0, 1, 2, 3, ShardWidth, ShardWidth + 1},
+		2: {0, 1, 2, ShardWidth, 2 * ShardWidth},
+		3: {3},
+		4: {0, 1, 2, 3, ShardWidth, ShardWidth + 1, 10, 11, 12, 13},
+		5: {100},
+	}
+	var sets strings.Builder
+	for row, cols := range rows {
+		for _, col := range cols {
+			fmt.Fprintf(&sets, "Set(%d, f=%d)\n", col, row)
+		}
+	}
+	for _, col := range []uint64{0, 1, 2, 10} {
+		fmt.Fprintf(&sets, "Set(%d, g=1)\n", col)
+	}
+	sets.WriteString(`Set(0, k="x") Set(1, k="x") Set(1, k="y") Set(2, k="y") Set(2, k="z")`)
+	c.Query(t, c.Idx(), sets.String())
+
+	for _, tt := range []struct {
+		query string
+		exp   []pilosa.SimilarRow
+	}{
+		{
+			query: `Similar(Row(f=1), field=f)`,
+			exp: []pilosa.SimilarRow{
+				{ID: 4, Score: 6.0 / 10, Intersection: 6, Count: 10},
+				{ID: 2, Score: 4.0 / 7, Intersection: 4, Count: 5},
+				{ID: 3, Score: 1.0 / 6, Intersection: 1, Count: 1},
+			},
+		},
+		{
+			query: `Similar(Row(f=1), field=f, metric=overlap)`,
+			exp: []pilosa.SimilarRow{
+				{ID: 4, Score: 1, Intersection: 6, Count: 10},
+				{ID: 3, Score: 1, Intersection: 1, Count: 1},
+				{ID: 2, Score: 4.0 / 5, Intersection: 4, Count: 5},
+			},
+		},
+		{
+			query: `Similar(Row(f=1), field=f, metric="cosine")`,
+			exp: []pilosa.SimilarRow{
+				{ID: 4, Score: 6 / math.Sqrt(60), Intersection: 6, Count: 10},
+				{ID: 2, Score: 4 / math.Sqrt(30), Intersection: 4, Count: 5},
+				{ID: 3, Score: 1 / math.Sqrt(6), Intersection: 1, Count: 1},
+			},
+		},
+		{
+			query: `Similar(Row(f=1), field=f, n=1)`,
+			exp: []pilosa.SimilarRow{
+				{ID: 4, Score: 6.0 / 10, Intersection: 6, Count: 10},
+			},
+		},
+		{
+			// Only the given rows are scored, leaving out those sharing no
+			// records.
+			query: `Similar(Row(f=1), field=f, ids=[3, 5])`,
+			exp: []pilosa.SimilarRow{
+				{ID: 3, Score: 1.0 / 6, Intersection: 1, Count: 1},
+			},
+		},
+		{
+			// The row isn't left out unless it's the one being compared with.
+			query: `Similar(Union(Row(f=1)), field=f, n=2)`,
+			exp: []pilosa.SimilarRow{
+				{ID: 1, Score: 1, Intersection: 6, Count: 6},
+				{ID: 4, Score: 6.0 / 10, Intersection: 6, Count: 10},
+			},
+		},
+		{
+			query: `Similar(Row(f=1), field=f, filter=Row(g=1))`,
+			exp: []pilosa.SimilarRow{
+				{ID: 2, Score: 1, Intersection: 3, Count: 3},
+				{ID: 4, Score: 3.0 / 4, Intersection: 3, Count: 4},
+			},
+		},
+		{
+			query: `Similar(Row(f=5), field=f, filter=Row(g=1))`,
+		},
+	} {
+		t.Run(tt.query, func(t *testing.T) {
+			res := c.Query(t, c.Idx(), tt.query).Results[0].(*pilosa.SimilarRows)
+			if res.Field != "f" {
+				t.Errorf("expected field f, got %q", res.Field)
+			}
+			if !reflect.DeepEqual(res.Rows, tt.exp) {
+				t.Errorf("expected %+v, got %+v", tt.exp, res.Rows)
+			}
+		})
+	}
+
+	t.Run("Keys", func(t *testing.T) {
+		res := c.Query(t, c.Idx(), `Similar(Row(k="x"), field=k)`).Results[0].(*pilosa.SimilarRows)
+		if len(res.Rows) != 1 {
+			t.Fatalf("expected 1 row, got %+v", res.Rows)
+		}
+		row := res.Rows[0]
+		if row.Key != "y" || row.Score != 1.0/3 || row.Intersection != 1 || row.Count != 2 {
+			t.Errorf("unexpected row %+v", row)
+		}
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		for query, msg := range map[string]string{
+			`Similar(field=f)`:                             "requires a single bitmap input",
+			`Similar(Row(f=1))`:                            "field required",
+			`Similar(Row(f=1), field=nope)`:                "field not found",
+			`Similar(Row(f=1), field=i)`:                   "must be a set, mutex or time field",
+			`Similar(Row(f=1), field=f, metric="hamming")`: "metric must be one of",
+		} {
+			if _, err := c.GetPrimary().API.Query(context.Background(), &pilosa.QueryRequest{Index: c.Idx(), Query: query}); err == nil || !strings.Contains(err.Error(), msg) {
+				t.Errorf("%s: expected error containing %q, got %v", query, msg, err)
+			}
+		}
+	})
+}
@@ -147,6 +147,12 @@ func newFragment(holder *Holder, idx *Index, fld *Field, vw *view, shard uint64)
 func (f *fragment) cachePath() string { return f.path() + cacheExt }
 
 func (f *fragment) bitDepth() (uint64, error) {
+	// Use the bit depth recorded when the shard was offloaded to avoid
+	// fetching it.
+	if m := f.holder.txf.dbPerShard.tierManifest(f.index(), f.shard); m != nil {
+		return m.BitDepths[f.field()+"/"+f.view()], nil
+	}
+
 	f.mu.RLock()
 	defer f.mu.RUnlock()
 	tx, err := f.holder.BeginTx(false, f.idx, f.shard)
@@ -228,6 +234,16 @@ func (f *fragment) openCache() error {
 		return nil
 	}
 
+	// Use the row counts recorded when the shard was offloaded to avoid
+	// fetching it.
+	if m := f.idx.holder.txf.dbPerShard.tierManifest(f.index(), f.shard); m != nil {
+		for _, p := range m.RowCounts[f.field()+"/"+f.view()] {
+			f.cache.BulkAdd(p[0], p[1])
+		}
+		f.cache.Invalidate()
+		return nil
+	}
+
 	tx := f.idx.holder.txf.NewTx(Txo{Write: !writable, Index: f.idx, Fragment: f, Shard: f.shard})
 	defer tx.Rollback()
 
@@ -0,0 +1,367 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/proto"
+	"github.com/featurebasedb/featurebase/v3/tracing"
+	"github.com/pkg/errors"
+)
+
+// FunnelCounts is the result of a Funnel() call: the number of records which
+// reached each step of the funnel, in order.
+type FunnelCounts []uint64
+
+var _ proto.ToRowser = FunnelCounts{}
+
+// ToTable implements the ToTabler interface.
+func (fc FunnelCounts) ToTable() (*proto.TableResponse, error) {
+	return proto.RowsToTable(fc, len(fc))
+}
+
+// ToRows implements the ToRowser interface.
+func (fc FunnelCounts) ToRows(callback func(*proto.RowResponse) error) error {
+	ci := []*proto.ColumnInfo{
+		{Name: "step", Datatype: "uint64"},
+		{Name: "count", Datatype: "uint64"},
+	}
+	for i, n := range fc {
+		if err := callback(&proto.RowResponse{
+			Headers: ci,
+			Columns: []*proto.ColumnResponse{
+				{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: uint64(i + 1)}},
+				{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: n}},
+			},
+		}); err != nil {
+			return errors.Wrap(err, "calling callback")
+		}
+		ci = nil
+	}
+	return nil
+}
+
+// Add returns the sum of fc and other, step by step.
+func (fc FunnelCounts) Add(other FunnelCounts) FunnelCounts {
+	if len(fc) < len(other) {
+		fc, other = other, fc
+	}
+	sum := make(FunnelCounts, len(fc))
+	copy(sum, fc)
+	for i, n := range other {
+		sum[i] += n
+	}
+	return sum
+}
+
+// timeRow is a row of a time field selected by a Row() call, which is read
+// from the time views covering a range of time rather than from the standard
+// view.
+type timeRow struct {
+	field   string
+	rowID   uint64
+	quantum TimeQuantum
+	// empty is set if the row doesn't exist, such as when its key hasn't
+	// been translated.
+	empty bool
+}
+
+// timeUnits are the units of time quanta, from coarsest to finest.
+const timeUnits = "YMDH"
+
+// parseTimeRow returns the row of a time field which c selects. c must be a
+// Row() call without a time range of its own.
+func (e *executor) parseTimeRow(index string, c *pql.Call) (*timeRow, error) {
+	// A Row() on a key which doesn't exist is replaced with an empty Union()
+	// when it's translated.
+	if c.Name == "Union" && len(c.Children) == 0 {
+		return &timeRow{empty: true}, nil
+	}
+	if c.Name != "Row" {
+		return nil, errors.Errorf("expected a Row() call, got %s", c.Name)
+	}
+	if _, ok := c.Args["from"]; ok {
+		return nil, errors.New("row can't have its own time range")
+	} else if _, ok := c.Args["to"]; ok {
+		return nil, errors.New("row can't have its own time range")
+	}
+	fieldName, err := c.FieldArg()
+	if err != nil {
+		return nil, err
+	}
+	f := e.Holder.Field(index, fieldName)
+	if f == nil {
+		return nil, newNotFoundError(ErrFieldNotFound, fieldName)
+	}
+	q := f.TimeQuantum()
+	if f.Type() != FieldTypeTime || q == "" {
+		return nil, errors.Errorf("field %s is not a time field", fieldName)
+	}
+	isNull, rowID, isEQ, err := c.FieldEquality(fieldName)
+	if err != nil {
+		return nil, err
+	} else if isNull || !isEQ {
+		return nil, errors.New("row must select a single value")
+	}
+	return &timeRow{field: fieldName, rowID: rowID, quantum: q}, nil
+}
+
+// views returns the views to read r from for each of the buckets of the given
+// unit starting at starts.
+func (r *timeRow) views(starts []time.Time, unit rune, n int) [][]string {
+	if r.empty {
+		return nil
+	}
+	views := make([][]string, len(starts))
+	for b, start := range starts {
+		views[b] = viewsByTimeRange(viewStandard, start, addTime(start, unit, n), r.quantum)
+	}
+	return views
+}
+
+// timeRowShard returns r in a shard, read from views.
+func (e *executor) timeRowShard(tx Tx, index string, r *timeRow, views []string, shard uint64) (*Row, error) {
+	row := NewRow()
+	if r.empty {
+		return row, nil
+	}
+	for _, view := range views {
+		frag := e.Holder.fragment(index, r.field, view, shard)
+		if frag == nil {
+			continue
+		}
+		fragRow, err := frag.row(tx, r.rowID)
+		if err != nil {
+			return nil, err
+		}
+		row = row.Union(fragRow)
+	}
+	return row, nil
+}
+
+// funnelStep is a step of a funnel, with the views to read its row from for
+// each time bucket of the funnel.
+type funnelStep struct {
+	*timeRow
+	views [][]string
+}
+
+// maxFunnelBuckets is the most time buckets a Funnel() call may divide its
+// time range into. Every shard reads each step's row in each bucket, and with
+// a window, follows the steps from each bucket.
+const maxFunnelBuckets = 1000
+
+// executeFunnel executes a Funnel() call, which counts the records that reached
+// each of its steps in order. Steps are Row() calls on time fields, and the
+// time between from and to is divided into buckets of the finest unit every
+// step's field has views for. A record reaches a step if it reached the
+// previous step and is set in the step's row in the same or a later bucket.
+// If a window is given, every step must be reached within that duration of
+// the bucket in which the first step was.
+func (e *executor) executeFunnel(ctx context.Context, qcx *Qcx, index string, c *pql.Call, shards []uint64, opt *ExecOptions) (FunnelCounts, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeFunnel")
+	defer span.Finish()
+
+	if len(c.Children) == 0 {
+		return nil, errors.New("Funnel() requires at least one step")
+	}
+
+	fromArg, ok := c.Args["from"]
+	if !ok {
+		return nil, errors.New("Funnel(): from required")
+	}
+	from, err := parseTime(fromArg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Funnel(): parsing from time")
+	}
+	toArg, ok := c.Args["to"]
+	if !ok {
+		return nil, errors.New("Funnel(): to required")
+	}
+	to, err := parseTime(toArg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Funnel(): parsing to time")
+	}
+	if !to.After(from) {
+		return nil, errors.New("Funnel(): to must be after from")
+	}
+
+	var window time.Duration
+	if v, ok := c.Args["window"]; ok {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("Funnel(): window must be a duration string such as \"24h\", got %v of type %[1]T", v)
+		}
+		if window, err = time.ParseDuration(s); err != nil {
+			return nil, errors.Wrap(err, "Funnel(): parsing window")
+		} else if window <= 0 {
+			return nil, errors.New("Funnel(): window must be positive")
+		}
+	}
+
+	var filter *pql.Call
+	if v, ok := c.Args["filter"]; ok {
+		if filter, ok = v.(*pql.Call); !ok {
+			return nil, errors.Errorf("Funnel(): filter must be a bitmap call, got %v of type %[1]T", v)
+		}
+	}
+
+	steps := make([]*funnelStep, len(c.Children))
+	unit := rune(timeUnits[len(timeUnits)-1])
+	for i, child := range c.Children {
+		r, err := e.parseTimeRow(index, child)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Funnel(): step %d", i+1)
+		}
+		steps[i] = &funnelStep{timeRow: r}
+		if r.empty {
+			continue
+		}
+		if g := r.quantum.Granularity(); strings.IndexRune(timeUnits, g) < strings.IndexRune(timeUnits, unit) {
+			unit = g
+		}
+	}
+
+	// Divide the time range into buckets, and work out which views to read
+	// each step's row from in each of them.
+	var buckets []time.Time
+	for t := truncateTime(from, unit); t.Before(to); t = addTime(t, unit, 1) {
+		if len(buckets) == maxFunnelBuckets {
+			return nil, errors.Errorf("Funnel(): time range spans more than %d buckets of the steps' finest time unit (%c)", maxFunnelBuckets, unit)
+		}
+		buckets = append(buckets, t)
+	}
+	for _, step := range steps {
+		step.views = step.timeRow.views(buckets, unit, 1)
+	}
+
+	// The last bucket a chain starting in each bucket may reach.
+	ends := make([]int, len(buckets))
+	for s, start := range buckets {
+		ends[s] = len(buckets) - 1
+		if window > 0 {
+			limit := start.Add(window)
+			for b := s; b < len(buckets); b++ {
+				if buckets[b].After(limit) {
+					ends[s] = b - 1
+					break
+				}
+			}
+		}
+	}
+
+	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
+		return e.executeFunnelShard(ctx, qcx, index, filter, steps, ends, window > 0, shard)
+	}
+
+	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
+		other, _ := prev.(FunnelCounts)
+		return other.Add(v.(FunnelCounts))
+	}
+
+	result, err := e.mapReduce(ctx, index, shards, c, opt, mapFn, reduceFn)
+	if err != nil {
+		return nil, err
+	}
+	counts, _ := result.(FunnelCounts)
+	if counts == nil {
+		counts = make(FunnelCounts, len(steps))
+	}
+	return counts, nil
+}
+
+// executeFunnelShard counts the records in a shard which reached each step of
+// a funnel. ends holds the last bucket which may be reached from each bucket
+// the first step is in; if windowed isn't set, that's always the last one, so
+// a single pass over the buckets suffices.
+func (e *executor) executeFunnelShard(ctx context.Context, qcx *Qcx, index string, filter *pql.Call, steps []*funnelStep, ends []int, windowed bool, shard uint64) (_ FunnelCounts, err0 error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeFunnelShard")
+	defer span.Finish()
+
+	var filterRow *Row
+	if filter != nil {
+		row, err := e.executeBitmapCallShard(ctx, qcx, index, filter, shard)
+		if err != nil {
+			return nil, errors.Wrap(err, "executing filter")
+		}
+		filterRow = row
+	}
+
+	idx := e.Holder.Index(index)
+	if idx == nil {
+		return nil, newNotFoundError(ErrIndexNotFound, index)
+	}
+	tx, finisher, err := qcx.GetTx(Txo{Write: !writable, Index: idx, Shard: shard})
+	if err != nil {
+		return nil, err
+	}
+	defer finisher(&err0)
+
+	// Read each step's row in each bucket.
+	rows := make([][]*Row, len(steps))
+	for i, step := range steps {
+		rows[i] = make([]*Row, len(ends))
+		for b := range ends {
+			var views []string
+			if step.views != nil {
+				views = step.views[b]
+			}
+			if rows[i][b], err = e.timeRowShard(tx, index, step.timeRow, views, shard); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if filterRow != nil {
+		for b := range rows[0] {
+			rows[0][b] = rows[0][b].Intersect(filterRow)
+		}
+	}
+
+	// reach adds the records which reach each step in buckets start to end
+	// to reached, given those which reached the first one.
+	reach := func(reached []*Row, start, end int) {
+		for b := start; b <= end; b++ {
+			for i := 1; i < len(steps); i++ {
+				reached[i] = reached[i].Union(reached[i-1].Intersect(rows[i][b]))
+			}
+		}
+	}
+
+	reached := make([]*Row, len(steps))
+	for i := range reached {
+		reached[i] = NewRow()
+	}
+	if !windowed {
+		// The first step is followed bucket by bucket along with the others,
+		// so that a later step can't be reached before it.
+		for b := range ends {
+			reached[0] = reached[0].Union(rows[0][b])
+			reach(reached, b, b)
+		}
+	} else {
+		for s, end := range ends {
+			if !rows[0][s].Any() {
+				continue
+			}
+			chain := make([]*Row, len(steps))
+			chain[0] = rows[0][s]
+			for i := 1; i < len(chain); i++ {
+				chain[i] = NewRow()
+			}
+			reach(chain, s, end)
+			for i := range reached {
+				reached[i] = reached[i].Union(chain[i])
+			}
+		}
+	}
+
+	counts := make(FunnelCounts, len(steps))
+	for i, row := range reached {
+		counts[i] = row.Count()
+	}
+	return counts, nil
+}
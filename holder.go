@@ -16,6 +16,7 @@ import (
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
 	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
 	"github.com/featurebasedb/featurebase/v3/roaring"
 	"github.com/featurebasedb/featurebase/v3/storage"
@@ -143,6 +144,10 @@ type Holder struct {
 	// snapshotter/writelogger; then the Controller should only start directing
 	// queries to that computer once it has completed applying the snapshot.
 	directiveApplied bool
+
+	// shardQueries counts the queries which have read each shard, which is
+	// reported to the Controller as part of the worker's load.
+	shardQueries shardQueryCounts
 }
 
 // HolderOpts holds information about the holder which other things might want
@@ -258,6 +263,12 @@ type HolderConfig struct {
 	StorageConfig *storage.Config
 	RBFConfig     *rbfcfg.Config
 
+	// TieredStore, if set, is where shards which have not been written for
+	// TieredAge are offloaded to. They are fetched back to local disk when
+	// a query touches them.
+	TieredStore objectstore.Store
+	TieredAge   time.Duration
+
 	LookupDBDSN string
 }
 
@@ -363,6 +374,11 @@ func (h *Holder) deletePerShard(index *Index, shard uint64) error {
 		return nil
 	}
 
+	// Shards are not offloaded with deletes in flight.
+	if h.txf.dbPerShard.tierManifest(index.name, shard) != nil {
+		return nil
+	}
+
 	tx := h.Txf().NewTx(Txo{Write: !writable, Index: index, Shard: shard})
 	defer tx.Rollback()
 
@@ -762,6 +778,13 @@ func (h *Holder) schema(ctx context.Context, includeViews bool) ([]*IndexInfo, e
 		di.UpdatedAt = updatedAt
 		di.LastUpdateUser = lastUpdateUser
 		sort.Sort(fieldInfoSlice(di.Fields))
+		if includeViews && h.txf != nil && h.txf.dbPerShard.tiering != nil {
+			if idx := h.Index(di.Name); idx != nil {
+				if di.ShardTiers, err = h.txf.dbPerShard.shardTiers(idx); err != nil {
+					return nil, errors.Wrap(err, "getting shard tiers")
+				}
+			}
+		}
 		a = append(a, di)
 	}
 	sort.Sort(indexInfoSlice(a))
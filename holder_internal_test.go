@@ -3,7 +3,12 @@
 package pilosa
 
 import (
+	"context"
+	"os"
 	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/rbf"
+	"github.com/featurebasedb/featurebase/v3/testhook"
 )
 
 func setupTest(t *testing.T, h *Holder, rowCol []rowCols, indexName string) (*Index, *Field) {
@@ -96,3 +101,146 @@ func TestHolder_ProcessDeleteInflight(t *testing.T) {
 
 	}
 }
+
+func TestHolder_ScrubShards(t *testing.T) {
+	cfg := TestHolderConfig()
+	cfg.RBFConfig.Checksums = true
+	h := NewHolder(t.TempDir(), cfg)
+	if err := h.Open(); err != nil {
+		t.Fatalf("opening holder: %v", err)
+	}
+	testhook.Cleanup(t, func() {
+		h.Close()
+	})
+
+	rowCol := []rowCols{
+		{1, 1},
+		{10, ShardWidth + 1},
+		{1, ShardWidth * 2},
+	}
+	idx, _ := setupTest(t, h, rowCol, "idxscrub")
+
+	if corrupt := h.scrubShards(); len(corrupt) != 0 {
+		t.Fatalf("unexpected corrupt shards: %+v", corrupt)
+	}
+
+	// Move shard 1 into its data file and flip the last byte of its last page.
+	dbs, err := h.txf.dbPerShard.GetDBShard(idx.name, 1, idx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db := dbs.W.(*RbfDBWrapper).db
+	if err := db.Checkpoint(); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(db.DataPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.OpenFile(db.DataPath(), os.O_RDWR, 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	buf := make([]byte, 1)
+	if _, err := f.ReadAt(buf, fi.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+	buf[0] ^= 0xFF
+	if _, err := f.WriteAt(buf, fi.Size()-1); err != nil {
+		t.Fatal(err)
+	}
+
+	corrupt := h.scrubShards()
+	if len(corrupt) != 1 || corrupt[0].Index != "idxscrub" || corrupt[0].Shard != 1 {
+		t.Fatalf("unexpected corrupt shards: %+v", corrupt)
+	} else if want := uint32(fi.Size()/rbf.PageSize) - 1; len(corrupt[0].Pages) != 1 || corrupt[0].Pages[0] != want {
+		t.Fatalf("unexpected corrupt pages: %v, want [%d]", corrupt[0].Pages, want)
+	}
+}
+
+func TestHolder_CompactShards(t *testing.T) {
+	h := newTestHolder(t)
+
+	rowCol := []rowCols{
+		{1, 1},
+		{10, ShardWidth + 1},
+		{1, ShardWidth * 2},
+	}
+	idx, f := setupTest(t, h, rowCol, "idxcompact")
+
+	// Fill a few rows of shard 0 with dense containers, then clear the
+	// middle rows so the database is left with free pages before live ones.
+	for _, fn := range []func(qcx *Qcx, row, col uint64) error{
+		func(qcx *Qcx, row, col uint64) error {
+			_, err := f.SetBit(qcx, row, col, nil)
+			return err
+		},
+		func(qcx *Qcx, row, col uint64) error {
+			if row == 2 || row == 7 {
+				return nil
+			}
+			_, err := f.ClearBit(qcx, row, col)
+			return err
+		},
+	} {
+		qcx := h.Txf().NewWritableQcx()
+		for row := uint64(2); row < 8; row++ {
+			for col := uint64(0); col < 5000; col++ {
+				if err := fn(qcx, row, col); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+		if err := qcx.Finish(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	before, err := h.fragmentation(idx.name)
+	if err != nil {
+		t.Fatal(err)
+	} else if before.FreePages == 0 {
+		t.Fatalf("expected free pages before compaction: %+v", before)
+	}
+
+	// A threshold above the current ratio should compact nothing.
+	if results, err := h.compactShards(context.Background(), idx.name, 1); err != nil {
+		t.Fatal(err)
+	} else if len(results) != 0 {
+		t.Fatalf("unexpected compaction: %+v", results)
+	}
+
+	results, err := h.compactShards(context.Background(), idx.name, 0)
+	if err != nil {
+		t.Fatal(err)
+	} else if len(results) == 0 || results[0].Index != idx.name || results[0].Pages == 0 {
+		t.Fatalf("unexpected compaction results: %+v", results)
+	}
+
+	after, err := h.fragmentation(idx.name)
+	if err != nil {
+		t.Fatal(err)
+	} else if after.Pages >= before.Pages || after.FreePages >= before.FreePages {
+		t.Fatalf("expected fewer pages after compaction: before=%+v after=%+v", before, after)
+	}
+
+	// Ensure data is intact.
+	qcx := h.Txf().NewQcx()
+	defer qcx.Abort()
+	if row, err := f.Row(qcx, 2); err != nil {
+		t.Fatal(err)
+	} else if n := row.Count(); n != 5000 {
+		t.Fatalf("row 2 count=%d, want 5000", n)
+	}
+	if row, err := f.Row(qcx, 5); err != nil {
+		t.Fatal(err)
+	} else if n := row.Count(); n != 0 {
+		t.Fatalf("row 5 count=%d, want 0", n)
+	}
+	if row, err := f.Row(qcx, 7); err != nil {
+		t.Fatal(err)
+	} else if n := row.Count(); n != 5000 {
+		t.Fatalf("row 7 count=%d, want 5000", n)
+	}
+}
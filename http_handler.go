@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/featurebasedb/featurebase/v3/audit"
 	"github.com/featurebasedb/featurebase/v3/authn"
 	"github.com/featurebasedb/featurebase/v3/authz"
 	fbcontext "github.com/featurebasedb/featurebase/v3/context"
@@ -68,6 +69,7 @@ type Handler struct {
 	logger logger.Logger
 
 	queryLogger logger.Logger
+	auditLogger *audit.Logger
 
 	// Keeps the query argument validators for each handler
 	validators map[string]*queryValidationSpec
@@ -190,6 +192,15 @@ func OptHandlerQueryLogger(logger logger.Logger) handlerOption {
 	}
 }
 
+// OptHandlerAuditLogger sets the audit log, which records logins, logouts,
+// authentication failures and changes to API keys.
+func OptHandlerAuditLogger(l *audit.Logger) handlerOption {
+	return func(h *Handler) error {
+		h.auditLogger = l
+		return nil
+	}
+}
+
 func OptHandlerSerializer(s Serializer) handlerOption {
 	return func(h *Handler) error {
 		h.serializer = s
@@ -327,6 +338,9 @@ func (h *Handler) populateValidators() {
 	h.validators["PostTransaction"] = queryValidationSpecRequired()
 	h.validators["PostFinishTransaction"] = queryValidationSpecRequired()
 	h.validators["DeleteDataframe"] = queryValidationSpecRequired()
+	h.validators["GetAPIKeys"] = queryValidationSpecRequired()
+	h.validators["PostAPIKey"] = queryValidationSpecRequired()
+	h.validators["DeleteAPIKey"] = queryValidationSpecRequired()
 }
 
 type contextKeyQuery int
@@ -533,7 +547,7 @@ func newRouter(handler *Handler) http.Handler {
 	router.HandleFunc("/transactions", handler.chkAuthZ(handler.handleGetTransactions, authz.Read)).Methods("GET").Name("GetTransactions")
 	router.HandleFunc("/queries", handler.chkAuthZ(handler.handleGetActiveQueries, authz.Admin)).Methods("GET").Name("GetActiveQueries")
 
-	router.HandleFunc("/sql", handler.chkAuthZ(handler.handlePostSQL, authz.Admin)).Methods("POST").Name("PostSQL")
+	router.HandleFunc("/sql", handler.chkAuthZ(handler.handlePostSQL, authz.Read)).Methods("POST").Name("PostSQL")
 	// internal endpoint
 	router.HandleFunc("/sql-exec-graph", handler.chkAuthZ(handler.handlePostSQLPlanOperator, authz.Admin)).Methods("POST").Name("PostSQLPlanOperator")
 
@@ -557,6 +571,8 @@ func newRouter(handler *Handler) http.Handler {
 	router.HandleFunc("/internal/mem-usage", handler.chkAuthZ(handler.handleGetMemUsage, authz.Read)).Methods("GET").Name("GetUsage")
 	router.HandleFunc("/internal/disk-usage", handler.chkAuthZ(handler.handleGetDiskUsage, authz.Read)).Methods("GET").Name("GetUsage")
 	router.HandleFunc("/internal/disk-usage/{index}", handler.chkAuthZ(handler.handleGetDiskUsage, authz.Read)).Methods("GET").Name("GetUsage")
+	router.HandleFunc("/internal/compact", handler.chkAuthZ(handler.handlePostCompact, authz.Admin)).Methods("POST").Name("PostCompact")
+	router.HandleFunc("/internal/compact/{index}", handler.chkAuthZ(handler.handlePostCompact, authz.Admin)).Methods("POST").Name("PostCompact")
 	router.HandleFunc("/internal/fragment/block/data", handler.chkAuthN(handler.handleGetFragmentBlockData)).Methods("GET").Name("GetFragmentBlockData")
 	router.HandleFunc("/internal/fragment/blocks", handler.chkAuthN(handler.handleGetFragmentBlocks)).Methods("GET").Name("GetFragmentBlocks")
 	router.HandleFunc("/internal/fragment/data", handler.chkAuthN(handler.handleGetFragmentData)).Methods("GET").Name("GetFragmentData")
@@ -568,6 +584,7 @@ func newRouter(handler *Handler) http.Handler {
 	router.HandleFunc("/internal/index/{index}/field/{field}/remote-available-shards/{shardID}", handler.chkAuthZ(handler.handleDeleteRemoteAvailableShard, authz.Admin)).Methods("DELETE")
 	router.HandleFunc("/internal/index/{index}/shard/{shard}/snapshot", handler.chkAuthZ(handler.handleGetIndexShardSnapshot, authz.Read)).Methods("GET").Name("GetIndexShardSnapshot")
 	router.HandleFunc("/internal/index/{index}/shards", handler.chkAuthZ(handler.handleGetIndexAvailableShards, authz.Read)).Methods("GET").Name("GetIndexAvailableShards")
+	router.HandleFunc("/internal/index/{index}/shard-versions", handler.chkAuthZ(handler.handleGetIndexShardVersions, authz.Read)).Methods("GET").Name("GetIndexShardVersions")
 	router.HandleFunc("/internal/nodes", handler.chkAuthN(handler.handleGetNodes)).Methods("GET").Name("GetNodes")
 	router.HandleFunc("/internal/shards/max", handler.chkAuthN(handler.handleGetShardsMax)).Methods("GET").Name("GetShardsMax") // TODO: deprecate, but it's being used by the client
 
@@ -602,10 +619,14 @@ func newRouter(handler *Handler) http.Handler {
 	router.HandleFunc("/auth", handler.handleCheckAuthentication).Methods("GET").Name("CheckAuthentication")
 	router.HandleFunc("/userinfo", handler.handleUserInfo).Methods("GET").Name("UserInfo")
 	router.HandleFunc("/internal/oauth-config", handler.handleOAuthConfig).Methods("GET").Name("GetOAuthConfig")
+	router.HandleFunc("/auth/api-keys", handler.chkAuthZ(handler.handleGetAPIKeys, authz.Admin)).Methods("GET").Name("GetAPIKeys")
+	router.HandleFunc("/auth/api-keys", handler.chkAuthZ(handler.handlePostAPIKey, authz.Admin)).Methods("POST").Name("PostAPIKey")
+	router.HandleFunc("/auth/api-keys/{id}", handler.chkAuthZ(handler.handleDeleteAPIKey, authz.Admin)).Methods("DELETE").Name("DeleteAPIKey")
 
 	router.HandleFunc("/health", handler.handleGetHealth).Methods("GET").Name("GetHealth")
 	router.HandleFunc("/directive", handler.handleGetDirective).Methods("GET").Name("GetDirective")
 	router.HandleFunc("/directive", handler.handlePostDirective).Methods("POST").Name("PostDirective")
+	router.HandleFunc("/directive/load", handler.handleGetDirectiveLoad).Methods("GET").Name("GetDirectiveLoad")
 	router.HandleFunc("/snapshot/shard-data", handler.handlePostSnapshotShardData).Methods("POST").Name("PostShapshotShardData")
 	router.HandleFunc("/snapshot/table-keys", handler.handlePostSnapshotTableKeys).Methods("POST").Name("PostShapshotTableKeys")
 	router.HandleFunc("/snapshot/field-keys", handler.handlePostSnapshotFieldKeys).Methods("POST").Name("PostShapshotFieldKeys")
@@ -675,6 +696,47 @@ func (h *Handler) chkInternal(handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// restrictedRoutes are the routes which access the data of an index
+// without going through the executor, so they aren't available to users
+// whose access to the index is restricted.
+var restrictedRoutes = map[string]bool{
+	"GetExport":             true,
+	"GetDataframe":          true,
+	"GetIndexShardSnapshot": true,
+}
+
+// chkRestrictions returns an error if the request accesses a field of the
+// index which the user's restrictions don't allow, or bypasses them.
+func (h *Handler) chkRestrictions(ctx context.Context, r *http.Request, uinfo *authn.UserInfo, indexName string) error {
+	restrictions, err := h.permissions.GetRestrictions(uinfo.Groups, indexName)
+	if err != nil {
+		return err
+	} else if restrictions == nil {
+		return nil
+	}
+
+	if route := mux.CurrentRoute(r); route != nil && restrictedRoutes[route.GetName()] {
+		return errors.Errorf("access to index %s is restricted", indexName)
+	}
+
+	field, ok := mux.Vars(r)["field"]
+	if !ok {
+		field = r.URL.Query().Get("field")
+	}
+	if field != "" && !restrictions.FieldAllowed(field) {
+		return errors.Errorf("access to field %s is restricted", field)
+	}
+
+	// Remote queries can't be accepted from a user with restrictions, since
+	// the remote flag is set by the client, and row filters can't be
+	// applied to a query which the node that sent it already restricted.
+	// Nodes should instead be in the allowed networks.
+	if req, ok := ctx.Value(contextKeyQueryRequest).(*QueryRequest); ok && req.Remote {
+		return errors.Wrapf(ErrAccessRestricted, "remote queries on index %s", indexName)
+	}
+	return nil
+}
+
 func (h *Handler) chkAllowedNetworks(r *http.Request) (bool, context.Context) {
 	// for every request, get IP of the request and check against configured IPs
 	reqIP := GetIP(r)
@@ -693,6 +755,7 @@ func (h *Handler) chkAllowedNetworks(r *http.Request) (bool, context.Context) {
 
 func (h *Handler) chkAuthN(handler http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(fbcontext.WithSourceIP(r.Context(), GetIP(r)))
 		ctx := r.Context()
 
 		// if the request is unauthenticated and we have the appropriate header get the userid from the header
@@ -712,14 +775,19 @@ func (h *Handler) chkAuthN(handler http.HandlerFunc) http.HandlerFunc {
 		}
 
 		access, refresh := getTokens(r)
-		uinfo, err := h.auth.Authenticate(access, refresh)
+		uinfo, err := h.authenticate(r, access, refresh)
 		if err != nil {
+			h.auditLogger.Log(ctx, audit.EventAuthFailure, "", r.Method+" "+r.URL.Path, err)
 			http.Error(w, errors.Wrap(err, "authenticating").Error(), http.StatusUnauthorized)
 			return
 		}
 
 		// prefer the user id from an authenticated request over one in a header
 		ctx = fbcontext.WithUserID(ctx, uinfo.UserID)
+		ctx = authn.WithUserInfo(ctx, uinfo)
+		if authn.IsSystemToken(access) {
+			ctx = fbcontext.WithInternal(ctx)
+		}
 
 		// just in case it got refreshed
 		ctx = authn.WithAccessToken(ctx, "Bearer"+access)
@@ -730,8 +798,22 @@ func (h *Handler) chkAuthN(handler http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// authenticate authenticates the tokens of r. The system token is only
+// accepted for the requests nodes make to each other: those to internal
+// endpoints, and remote queries.
+func (h *Handler) authenticate(r *http.Request, access, refresh string) (*authn.UserInfo, error) {
+	if strings.HasPrefix(r.URL.Path, "/internal/") {
+		return h.auth.AuthenticateInternal(access, refresh)
+	}
+	if req, ok := r.Context().Value(contextKeyQueryRequest).(*QueryRequest); ok && req.Remote {
+		return h.auth.AuthenticateInternal(access, refresh)
+	}
+	return h.auth.Authenticate(access, refresh)
+}
+
 func (h *Handler) chkAuthZ(handler http.HandlerFunc, perm authz.Permission) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(fbcontext.WithSourceIP(r.Context(), GetIP(r)))
 		ctx := r.Context()
 
 		// if the request is unauthenticated and we have the appropriate header get the userid from the header
@@ -758,14 +840,19 @@ func (h *Handler) chkAuthZ(handler http.HandlerFunc, perm authz.Permission) http
 		// check if the user is authenticated
 		access, refresh := getTokens(r)
 
-		uinfo, err := h.auth.Authenticate(access, refresh)
+		uinfo, err := h.authenticate(r, access, refresh)
 		if err != nil {
+			h.auditLogger.Log(ctx, audit.EventAuthFailure, "", r.Method+" "+r.URL.Path, err)
 			http.Error(w, errors.Wrap(err, "authenticating").Error(), http.StatusForbidden)
 			return
 		}
 
 		// prefer the user id from an authenticated request over one in a header
 		ctx = fbcontext.WithUserID(ctx, uinfo.UserID)
+		ctx = authn.WithUserInfo(ctx, uinfo)
+		if authn.IsSystemToken(access) {
+			ctx = fbcontext.WithInternal(ctx)
+		}
 
 		ctx = authn.WithAccessToken(ctx, "Bearer "+access)
 		ctx = authn.WithRefreshToken(ctx, refresh)
@@ -850,7 +937,17 @@ func (h *Handler) chkAuthZ(handler http.HandlerFunc, perm authz.Permission) http
 				http.Error(w, "Insufficient permissions", http.StatusForbidden)
 				return
 			}
+
+			if err := h.chkRestrictions(ctx, r, uinfo, indexName); err != nil {
+				w.Header().Add("Content-Type", "text/plain")
+				http.Error(w, errors.Wrap(err, "Insufficient permissions").Error(), http.StatusForbidden)
+				return
+			}
 		}
+
+		// put the user's access in the context, so that any restrictions
+		// on it are enforced where their queries are executed
+		ctx = authz.WithAccess(ctx, h.permissions.NewAccess(uinfo))
 		handler.ServeHTTP(w, r.WithContext(ctx))
 	}
 }
@@ -1217,12 +1314,59 @@ func (h *Handler) handleGetDiskUsage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if h.api.holder != nil {
+		frag, err := h.api.Fragmentation(r.Context(), indexName)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		use.Fragmentation = &frag
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(use); err != nil {
 		h.logger.Errorf("write disk usage response error: %s", err)
 	}
 }
 
+// handlePostCompact handles POST /internal/compact requests. It compacts the
+// local RBF databases whose ratio of free pages is at least the optional
+// threshold query parameter, which defaults to compacting any database with
+// free pages.
+func (h *Handler) handlePostCompact(w http.ResponseWriter, r *http.Request) {
+	if !validHeaderAcceptJSON(r.Header) {
+		http.Error(w, "JSON only acceptable response", http.StatusNotAcceptable)
+		return
+	}
+
+	var threshold float64
+	if s := r.URL.Query().Get("threshold"); s != "" {
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil || v < 0 || v > 1 {
+			http.Error(w, "threshold must be a number between 0 and 1", http.StatusBadRequest)
+			return
+		}
+		threshold = v
+	}
+
+	shards, err := h.api.Compact(r.Context(), mux.Vars(r)["index"], threshold)
+	if err != nil {
+		if errors.Cause(err) == ErrIndexNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, "compacting: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(struct {
+		Shards []ShardCompaction `json:"shards"`
+	}{Shards: shards}); err != nil {
+		h.logger.Errorf("write compact response error: %s", err)
+	}
+}
+
 // handleGetShardDistribution handles GET /ui/shard-distribution requests.
 func (h *Handler) handleGetShardDistribution(w http.ResponseWriter, r *http.Request) {
 	dist := h.api.ShardDistribution(r.Context())
@@ -1250,6 +1394,7 @@ func (h *Handler) handleGetStatus(w http.ResponseWriter, r *http.Request) {
 		Nodes:       h.api.Hosts(r.Context()),
 		LocalID:     h.api.Node().ID,
 		ClusterName: h.api.ClusterName(),
+		ReadReplica: h.api.ReadReplicaStatus(),
 	}
 	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(status); err != nil {
@@ -1278,6 +1423,9 @@ type getStatusResponse struct {
 	Nodes       []*disco.Node `json:"nodes"`
 	LocalID     string        `json:"localID"`
 	ClusterName string        `json:"clusterName"`
+
+	// ReadReplica is only set if the local node is a read replica.
+	ReadReplica *ReadReplicaStatus `json:"readReplica,omitempty"`
 }
 
 func httpHash(s string) string {
@@ -1691,6 +1839,35 @@ type getIndexAvailableShardsResponse struct {
 	Shards []uint64 `json:"shards"`
 }
 
+// handleGetIndexShardVersions handles GET /internal/index/:index/shard-versions requests.
+func (h *Handler) handleGetIndexShardVersions(w http.ResponseWriter, r *http.Request) {
+	if !validHeaderAcceptJSON(r.Header) {
+		http.Error(w, "JSON only acceptable response", http.StatusNotAcceptable)
+		return
+	}
+
+	indexName := mux.Vars(r)["index"]
+	versions, err := h.api.ShardVersions(r.Context(), indexName)
+	if err != nil {
+		switch errors.Cause(err) {
+		case ErrIndexNotFound:
+			http.Error(w, err.Error(), http.StatusNotFound)
+		default:
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(getIndexShardVersionsResponse{Shards: versions}); err != nil {
+		h.logger.Errorf("write shard-versions response error: %s", err)
+	}
+}
+
+type getIndexShardVersionsResponse struct {
+	Shards map[uint64]int64 `json:"shards"`
+}
+
 // handleGetShardsMax handles GET /internal/shards/max requests.
 func (h *Handler) handleGetShardsMax(w http.ResponseWriter, r *http.Request) {
 	if !validHeaderAcceptJSON(r.Header) {
@@ -3951,7 +4128,8 @@ func (h *Handler) handleRedirect(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "", http.StatusNoContent)
 		return
 	}
-	h.auth.Redirect(w, r)
+	uinfo, err := h.auth.Redirect(w, r)
+	h.auditLogger.Log(authContext(r, uinfo), audit.EventLogin, "", "", err)
 }
 
 // handleOAuthConfig handles requests for a cleaned version of our oAuthConfig. We
@@ -4024,12 +4202,125 @@ func (h *Handler) handleUserInfo(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+// postAPIKeyRequest is the body of a request to create an API key. Expiry is
+// optional; if it isn't set, the key doesn't expire.
+type postAPIKeyRequest struct {
+	ServiceAccount string    `json:"service-account"`
+	Groups         []string  `json:"groups"`
+	Expiry         time.Time `json:"expiry"`
+}
+
+// postAPIKeyResponse is the response to a request to create an API key. It is
+// the only time the key itself is returned.
+type postAPIKeyResponse struct {
+	Key string `json:"key"`
+	*authn.APIKey
+}
+
+func (h *Handler) handlePostAPIKey(w http.ResponseWriter, r *http.Request) {
+	if !validHeaderAcceptJSON(r.Header) {
+		http.Error(w, "JSON only acceptable response", http.StatusNotAcceptable)
+		return
+	}
+	if h.auth == nil {
+		http.Error(w, "auth not enabled", http.StatusNotFound)
+		return
+	}
+
+	var req postAPIKeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, errors.Wrap(err, "decoding request").Error(), http.StatusBadRequest)
+		return
+	}
+	if req.ServiceAccount == "" || len(req.Groups) == 0 {
+		http.Error(w, "service-account and groups are required", http.StatusBadRequest)
+		return
+	}
+	if !req.Expiry.IsZero() && req.Expiry.Before(time.Now()) {
+		http.Error(w, "expiry must be in the future", http.StatusBadRequest)
+		return
+	}
+
+	key, apiKey, err := h.auth.CreateAPIKey(r.Context(), req.ServiceAccount, req.Groups, req.Expiry)
+	detail := "groups: " + strings.Join(req.Groups, ", ")
+	if apiKey != nil {
+		detail = "id: " + apiKey.ID + ", " + detail
+	}
+	h.auditLogger.Log(r.Context(), audit.EventCreateAPIKey, req.ServiceAccount, detail, err)
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "creating api key").Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(postAPIKeyResponse{Key: key, APIKey: apiKey}); err != nil {
+		h.logger.Errorf("writing api key: %s", err)
+	}
+}
+
+func (h *Handler) handleGetAPIKeys(w http.ResponseWriter, r *http.Request) {
+	if !validHeaderAcceptJSON(r.Header) {
+		http.Error(w, "JSON only acceptable response", http.StatusNotAcceptable)
+		return
+	}
+	if h.auth == nil {
+		http.Error(w, "auth not enabled", http.StatusNotFound)
+		return
+	}
+
+	keys, err := h.auth.APIKeys(r.Context())
+	if err != nil {
+		http.Error(w, errors.Wrap(err, "getting api keys").Error(), http.StatusInternalServerError)
+		return
+	}
+	if keys == nil {
+		keys = []*authn.APIKey{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(keys); err != nil {
+		h.logger.Errorf("writing api keys: %s", err)
+	}
+}
+
+func (h *Handler) handleDeleteAPIKey(w http.ResponseWriter, r *http.Request) {
+	if h.auth == nil {
+		http.Error(w, "auth not enabled", http.StatusNotFound)
+		return
+	}
+
+	id := mux.Vars(r)["id"]
+	err := h.auth.RevokeAPIKey(r.Context(), id)
+	h.auditLogger.Log(r.Context(), audit.EventRevokeAPIKey, id, "", err)
+	if err != nil {
+		if errors.Is(err, authn.ErrAPIKeyNotFound) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		http.Error(w, errors.Wrap(err, "revoking api key").Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *Handler) handleLogout(w http.ResponseWriter, r *http.Request) {
 	if h.auth == nil {
 		http.Error(w, "", http.StatusNoContent)
 		return
 	}
-	h.auth.Logout(w, r)
+	uinfo := h.auth.Logout(w, r)
+	h.auditLogger.Log(authContext(r, uinfo), audit.EventLogout, "", "", nil)
+}
+
+// authContext returns the context of r with the source IP of the request and,
+// if it's not nil, uinfo, for requests which aren't wrapped by chkAuthN or
+// chkAuthZ.
+func authContext(r *http.Request, uinfo *authn.UserInfo) context.Context {
+	ctx := fbcontext.WithSourceIP(r.Context(), GetIP(r))
+	if uinfo != nil {
+		ctx = authn.WithUserInfo(ctx, uinfo)
+	}
+	return ctx
 }
 
 // getTokens gets the access and refresh tokens from the request,
@@ -4090,6 +4381,19 @@ func (h *Handler) handleGetDirective(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func (h *Handler) handleGetDirectiveLoad(w http.ResponseWriter, r *http.Request) {
+	load, err := h.api.DirectiveLoad(r.Context())
+	if err != nil {
+		http.Error(w, "getting directive load error: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(load); err != nil {
+		h.logger.Errorf("write directive load response error: %s", err)
+	}
+}
+
 func (h *Handler) handlePostDirective(w http.ResponseWriter, r *http.Request) {
 	if !validHeaderAcceptJSON(r.Header) {
 		http.Error(w, "JSON only acceptable response", http.StatusNotAcceptable)
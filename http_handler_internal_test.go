@@ -696,11 +696,28 @@ func TestChkAuthN(t *testing.T) {
 			handler: h.chkAuthN(testingHandler),
 			err:     "authenticating: token is expired",
 		},
+		{
+			name:    "SystemToken-NotInternal",
+			token:   "Bearer " + a.SystemToken(),
+			handler: h.chkAuthN(testingHandler),
+			err:     "authenticating: system token not accepted",
+		},
+		{
+			name:     "SystemToken-Internal",
+			endpoint: "/internal/nodes",
+			token:    "Bearer " + a.SystemToken(),
+			handler:  h.chkAuthN(testingHandler),
+			err:      "good",
+		},
 	}
 	for _, test := range cases {
 		t.Run(test.name, func(t *testing.T) {
+			endpoint := test.endpoint
+			if endpoint == "" {
+				endpoint = "/whatever"
+			}
 			w := httptest.NewRecorder()
-			r := httptest.NewRequest("GET", "/whatever", nil)
+			r := httptest.NewRequest("GET", endpoint, nil)
 			r.Header.Add("Authorization", test.token)
 			test.handler(w, r)
 			resp := w.Result()
@@ -4,6 +4,7 @@ package pilosa_test
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -18,6 +19,7 @@ import (
 	"time"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/audit"
 	"github.com/featurebasedb/featurebase/v3/encoding/proto"
 	"github.com/featurebasedb/featurebase/v3/server"
 	"github.com/featurebasedb/featurebase/v3/test"
@@ -868,3 +870,433 @@ admin: "ac97c9e2-346b-42a2-b6da-18bcb61a32fe"`
 		}
 	}
 }
+
+func TestAuthAPIKeys(t *testing.T) {
+	permissions := `
+"user-groups":
+  "dca35310-ecda-4f23-86cd-876aee55906b":
+    "apikeys": "read"
+admin: "ac97c9e2-346b-42a2-b6da-18bcb61a32fe"`
+
+	tmpDir := t.TempDir()
+	permissionsPath := path.Join(tmpDir, "test-permissions.yaml")
+	if err := os.WriteFile(permissionsPath, []byte(permissions), 0600); err != nil {
+		t.Fatalf("failed to write permissions file: %v", err)
+	}
+	queryLogPath := path.Join(tmpDir, "query.log")
+	if _, err := os.Create(queryLogPath); err != nil {
+		t.Fatal(err)
+	}
+
+	adminIP := "10.0.0.2"
+
+	clusterSize := 2
+	commandOpts := make([][]server.CommandOption, clusterSize)
+	for i := range commandOpts {
+		conf := server.NewConfig()
+		conf.TLS.CertificatePath = "./testdata/certs/localhost.crt"
+		conf.TLS.CertificateKeyPath = "./testdata/certs/localhost.key"
+		conf.Auth.Enable = true
+		conf.Auth.ClientId = "e9088663-eb08-41d7-8f65-efb5f54bbb71"
+		conf.Auth.ClientSecret = "DEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEF"
+		conf.Auth.AuthorizeURL = "https://login.microsoftonline.com/4a137d66-d161-4ae4-b1e6-07e9920874b8/oauth2/v2.0/authorize"
+		conf.Auth.TokenURL = "https://login.microsoftonline.com/4a137d66-d161-4ae4-b1e6-07e9920874b8/oauth2/v2.0/authorize"
+		conf.Auth.GroupEndpointURL = "https://graph.microsoft.com/v1.0/me/transitiveMemberOf/microsoft.graph.group?$count=true"
+		conf.Auth.LogoutURL = "https://login.microsoftonline.com/common/oauth2/v2.0/logout"
+		conf.Auth.RedirectBaseURL = "https://localhost:10101/"
+		conf.Auth.QueryLogPath = queryLogPath
+		conf.Auth.SecretKey = "DEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEF"
+		conf.Auth.PermissionsFile = permissionsPath
+		conf.Auth.Scopes = []string{"https://graph.microsoft.com/.default", "offline_access"}
+		conf.Auth.ConfiguredIPs = []string{adminIP}
+		conf.Audit.Path = path.Join(tmpDir, fmt.Sprintf("audit%d.log", i))
+		conf.Audit.Key = testAuditKey
+		commandOpts[i] = append(commandOpts[i], server.OptCommandConfig(conf))
+	}
+
+	c := test.MustRunCluster(t, clusterSize, commandOpts...)
+	defer c.Close()
+
+	m := c.GetPrimary()
+	other := c.GetNonPrimary()
+	if _, err := m.API.CreateIndex(context.Background(), "apikeys", pilosa.IndexOptions{}); err != nil {
+		t.Fatalf("creating index: %v", err)
+	}
+
+	// do makes a request either from the allowed network, which is an
+	// admin, or with the given api key.
+	do := func(method, url, body, apiKey string) (int, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if apiKey == "" {
+			req.Header.Set("X-Forwarded-For", adminIP)
+		} else {
+			req.Header.Set("X-Forwarded-For", "10.0.1.1")
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading resp body: %v", err)
+		}
+		return resp.StatusCode, data
+	}
+
+	// no keys have been created yet
+	status, body := do("GET", m.URL()+"/auth/api-keys", "", "")
+	if status != http.StatusOK || strings.TrimSpace(string(body)) != "[]" {
+		t.Fatalf("listing api keys: %d, %s", status, body)
+	}
+
+	status, body = do("POST", m.URL()+"/auth/api-keys", `{"service-account": "ingester", "groups": ["dca35310-ecda-4f23-86cd-876aee55906b"]}`, "")
+	if status != http.StatusOK {
+		t.Fatalf("creating api key: %d, %s", status, body)
+	}
+	var created struct {
+		Key            string   `json:"key"`
+		ID             string   `json:"id"`
+		ServiceAccount string   `json:"service-account"`
+		Groups         []string `json:"groups"`
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshalling api key: %v", err)
+	}
+	if created.Key == "" || created.ID == "" || created.ServiceAccount != "ingester" {
+		t.Fatalf("unexpected api key: %s", body)
+	}
+
+	// the key is accepted by every node, with the permissions of its groups
+	for _, node := range []*test.Command{m, other} {
+		if status, body := do("GET", node.URL()+"/index/apikeys", "", created.Key); status != http.StatusOK {
+			t.Fatalf("reading index with api key: %d, %s", status, body)
+		}
+		if status, body := do("DELETE", node.URL()+"/index/apikeys", "", created.Key); status != http.StatusForbidden {
+			t.Fatalf("expected deleting index with read api key to be forbidden: %d, %s", status, body)
+		}
+		if status, body := do("GET", node.URL()+"/auth/api-keys", "", created.Key); status != http.StatusForbidden {
+			t.Fatalf("expected listing api keys with non-admin api key to be forbidden: %d, %s", status, body)
+		}
+	}
+
+	status, body = do("GET", m.URL()+"/auth/api-keys", "", "")
+	if status != http.StatusOK {
+		t.Fatalf("listing api keys: %d, %s", status, body)
+	}
+	if strings.Contains(string(body), created.Key) || !strings.Contains(string(body), created.ID) {
+		t.Fatalf("expected listed api keys to contain the id but not the key: %s", body)
+	}
+
+	revokedID := created.ID
+	if status, body := do("DELETE", m.URL()+"/auth/api-keys/"+created.ID, "", ""); status != http.StatusNoContent {
+		t.Fatalf("revoking api key: %d, %s", status, body)
+	}
+	if status, body := do("DELETE", m.URL()+"/auth/api-keys/"+created.ID, "", ""); status != http.StatusNotFound {
+		t.Fatalf("expected revoking api key again to be not found: %d, %s", status, body)
+	}
+	if status, body := do("GET", m.URL()+"/index/apikeys", "", created.Key); status != http.StatusForbidden {
+		t.Fatalf("expected revoked api key to be rejected: %d, %s", status, body)
+	}
+
+	// tables granted to the roles of a key's groups can be read with it
+	for _, sql := range []string{
+		"CREATE TABLE apikeys_granted (_id id, v int)",
+		"CREATE TABLE apikeys_ungranted (_id id, v int)",
+		"CREATE ROLE etl",
+		"GRANT SELECT ON apikeys_granted TO etl",
+	} {
+		if status, body := do("POST", m.URL()+"/sql", sql, ""); status != http.StatusOK || strings.Contains(string(body), `"error"`) {
+			t.Fatalf("%s: %d, %s", sql, status, body)
+		}
+	}
+	status, body = do("POST", m.URL()+"/auth/api-keys", `{"service-account": "etl", "groups": ["etl"]}`, "")
+	if status != http.StatusOK {
+		t.Fatalf("creating api key: %d, %s", status, body)
+	}
+	if err := json.Unmarshal(body, &created); err != nil {
+		t.Fatalf("unmarshalling api key: %v", err)
+	}
+	if status, body := do("GET", m.URL()+"/index/apikeys_granted", "", created.Key); status != http.StatusOK {
+		t.Fatalf("reading granted index with api key: %d, %s", status, body)
+	}
+	if status, body := do("GET", m.URL()+"/index/apikeys_ungranted", "", created.Key); status != http.StatusForbidden {
+		t.Fatalf("expected reading index without grant to be forbidden: %d, %s", status, body)
+	}
+
+	// expired keys can't be created
+	if status, body := do("POST", m.URL()+"/auth/api-keys", `{"service-account": "ingester", "groups": ["g"], "expiry": "2001-01-01T00:00:00Z"}`, ""); status != http.StatusBadRequest {
+		t.Fatalf("expected creating expired api key to fail: %d, %s", status, body)
+	}
+
+	// changes to api keys, roles and grants, and the use of the revoked key,
+	// are in the audit logs
+	var events []audit.Event
+	for i := 0; i < clusterSize; i++ {
+		events = append(events, readAuditLog(t, path.Join(tmpDir, fmt.Sprintf("audit%d.log", i)))...)
+	}
+	for _, exp := range []audit.Event{
+		{Type: audit.EventCreateAPIKey, Object: "ingester", SourceIP: adminIP, Outcome: audit.OutcomeSuccess},
+		{Type: audit.EventRevokeAPIKey, Object: revokedID, SourceIP: adminIP, Outcome: audit.OutcomeSuccess},
+		{Type: audit.EventAuthFailure, SourceIP: "10.0.1.1", Outcome: audit.OutcomeFailure},
+		{Type: audit.EventCreateTable, Object: "apikeys_granted", SourceIP: adminIP, Outcome: audit.OutcomeSuccess},
+		{Type: audit.EventCreateRole, Object: "etl", SourceIP: adminIP, Outcome: audit.OutcomeSuccess},
+		{Type: audit.EventGrant, Object: "etl", Detail: "read on apikeys_granted", SourceIP: adminIP, Outcome: audit.OutcomeSuccess},
+		{Type: audit.EventCreateAPIKey, Object: "etl", SourceIP: adminIP, Outcome: audit.OutcomeSuccess},
+	} {
+		if !hasAuditEvent(events, exp) {
+			t.Errorf("expected audit event %+v in %+v", exp, events)
+		}
+	}
+}
+
+// TestAuthRemoteQueries checks that a user can't bypass the restrictions on
+// their access by marking a query as remote.
+func TestAuthRemoteQueries(t *testing.T) {
+	permissions := `
+"user-groups":
+  "restricted":
+    "remoteq": "write"
+  "unrestricted":
+    "remoteq": "write"
+field-permissions:
+  "restricted":
+    "remoteq":
+      deny: ["ssn"]
+admin: "ac97c9e2-346b-42a2-b6da-18bcb61a32fe"`
+
+	tmpDir := t.TempDir()
+	permissionsPath := path.Join(tmpDir, "test-permissions.yaml")
+	if err := os.WriteFile(permissionsPath, []byte(permissions), 0600); err != nil {
+		t.Fatalf("failed to write permissions file: %v", err)
+	}
+	queryLogPath := path.Join(tmpDir, "query.log")
+	if _, err := os.Create(queryLogPath); err != nil {
+		t.Fatal(err)
+	}
+
+	adminIP := "10.0.0.2"
+
+	conf := server.NewConfig()
+	conf.TLS.CertificatePath = "./testdata/certs/localhost.crt"
+	conf.TLS.CertificateKeyPath = "./testdata/certs/localhost.key"
+	conf.Auth.Enable = true
+	conf.Auth.ClientId = "e9088663-eb08-41d7-8f65-efb5f54bbb71"
+	conf.Auth.ClientSecret = "DEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEF"
+	conf.Auth.AuthorizeURL = "https://login.microsoftonline.com/4a137d66-d161-4ae4-b1e6-07e9920874b8/oauth2/v2.0/authorize"
+	conf.Auth.TokenURL = "https://login.microsoftonline.com/4a137d66-d161-4ae4-b1e6-07e9920874b8/oauth2/v2.0/authorize"
+	conf.Auth.GroupEndpointURL = "https://graph.microsoft.com/v1.0/me/transitiveMemberOf/microsoft.graph.group?$count=true"
+	conf.Auth.LogoutURL = "https://login.microsoftonline.com/common/oauth2/v2.0/logout"
+	conf.Auth.RedirectBaseURL = "https://localhost:10101/"
+	conf.Auth.QueryLogPath = queryLogPath
+	conf.Auth.SecretKey = "DEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEF"
+	conf.Auth.PermissionsFile = permissionsPath
+	conf.Auth.Scopes = []string{"https://graph.microsoft.com/.default", "offline_access"}
+	conf.Auth.ConfiguredIPs = []string{adminIP}
+	c := test.MustRunCluster(t, 1, []server.CommandOption{server.OptCommandConfig(conf)})
+	defer c.Close()
+	m := c.GetPrimary()
+
+	// do makes a request either from the allowed network, which is an
+	// admin, or with the given api key.
+	do := func(method, url, body, apiKey string) (int, []byte) {
+		t.Helper()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		if apiKey == "" {
+			req.Header.Set("X-Forwarded-For", adminIP)
+		} else {
+			req.Header.Set("X-Forwarded-For", "10.0.1.1")
+			req.Header.Set("Authorization", "Bearer "+apiKey)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading resp body: %v", err)
+		}
+		return resp.StatusCode, data
+	}
+	createKey := func(group string) string {
+		t.Helper()
+		status, body := do("POST", m.URL()+"/auth/api-keys", fmt.Sprintf(`{"service-account": %q, "groups": [%[1]q]}`, group), "")
+		if status != http.StatusOK {
+			t.Fatalf("creating api key: %d, %s", status, body)
+		}
+		var created struct {
+			Key string `json:"key"`
+		}
+		if err := json.Unmarshal(body, &created); err != nil {
+			t.Fatalf("unmarshalling api key: %v", err)
+		}
+		return created.Key
+	}
+
+	for _, sql := range []string{
+		"CREATE TABLE remoteq (_id id, ssn int)",
+		"CREATE TABLE remoteq_other (_id id, x int)",
+		"INSERT INTO remoteq (_id, ssn) VALUES (1, 123)",
+	} {
+		if status, body := do("POST", m.URL()+"/sql", sql, ""); status != http.StatusOK || strings.Contains(string(body), `"error"`) {
+			t.Fatalf("%s: %d, %s", sql, status, body)
+		}
+	}
+	restricted, unrestricted := createKey("restricted"), createKey("unrestricted")
+
+	if status, body := do("POST", m.URL()+"/index/remoteq/query", "Count(All())", restricted); status != http.StatusOK {
+		t.Fatalf("querying with restricted api key: %d, %s", status, body)
+	}
+
+	for _, test := range []struct {
+		apiKey string
+		query  string
+	}{
+		// the denied field can't be read by marking the query as remote
+		{restricted, "Extract(All(), Rows(ssn))"},
+		{restricted, "Count(All())"},
+		// nor can an index the user has no permission to
+		{unrestricted, "Count(Distinct(index=remoteq_other, field=x))"},
+	} {
+		status, body := do("POST", m.URL()+"/index/remoteq/query?remote=true&shards=0", test.query, test.apiKey)
+		if status == http.StatusOK && !strings.Contains(string(body), `"error"`) {
+			t.Fatalf("expected remote %s to be rejected: %d, %s", test.query, status, body)
+		} else if !strings.Contains(string(body), pilosa.ErrAccessRestricted.Error()) {
+			t.Fatalf("expected remote %s to fail with %v: %d, %s", test.query, pilosa.ErrAccessRestricted, status, body)
+		}
+	}
+}
+
+// readAuditLog returns the events in the audit log at path, after checking its
+// hash chain.
+// testAuditKey is the hex encoded key audit logs in tests are signed with.
+const testAuditKey = "DEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEFDEADBEEF"
+
+func readAuditLog(t *testing.T, path string) []audit.Event {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	key, err := hex.DecodeString(testAuditKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := audit.Verify(f, key, ""); err != nil {
+		t.Fatalf("verifying audit log: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []audit.Event
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var e audit.Event
+		if err := dec.Decode(&e); err != nil {
+			t.Fatalf("decoding audit event: %v", err)
+		}
+		events = append(events, e)
+	}
+	return events
+}
+
+// hasAuditEvent returns true if events contains an event which matches the
+// type, object, detail, user ID, source IP and outcome of exp. The detail, user
+// ID and source IP are only compared if they're set in exp.
+func hasAuditEvent(events []audit.Event, exp audit.Event) bool {
+	for _, e := range events {
+		if e.Type != exp.Type || e.Object != exp.Object || e.Outcome != exp.Outcome {
+			continue
+		}
+		if (exp.Detail != "" && e.Detail != exp.Detail) ||
+			(exp.UserID != "" && e.UserID != exp.UserID) ||
+			(exp.SourceIP != "" && e.SourceIP != exp.SourceIP) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+func TestAuditLog(t *testing.T) {
+	auditPath := path.Join(t.TempDir(), "audit.log")
+	conf := server.NewConfig()
+	conf.Audit.Path = auditPath
+	conf.Audit.Key = testAuditKey
+	c := test.MustRunCluster(t, 1, []server.CommandOption{server.OptCommandConfig(conf)})
+	defer c.Close()
+	m := c.GetPrimary()
+
+	do := func(method, url, body string) {
+		t.Helper()
+		req, err := http.NewRequest(method, url, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set(pilosa.HeaderRequestUserID, "alice")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatalf("failed to send request: %v", err)
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatalf("reading resp body: %v", err)
+		}
+		if resp.StatusCode != http.StatusOK || strings.Contains(string(data), `"error"`) {
+			t.Fatalf("%s %s %s: %d, %s", method, url, body, resp.StatusCode, data)
+		}
+	}
+
+	do("POST", m.URL()+"/index/audited", "")
+	do("POST", m.URL()+"/index/audited/field/f", "")
+	do("POST", m.URL()+"/index/audited/query", "Set(1, f=1) Set(2, f=1) Set(3, f=1)")
+	do("POST", m.URL()+"/index/audited/query", "Delete(ConstRow(columns=[1]))")
+	// marking a query as remote doesn't keep it out of the audit log
+	do("POST", m.URL()+"/index/audited/query?remote=true&shards=0", "Delete(ConstRow(columns=[3]))")
+	do("POST", m.URL()+"/sql", "DELETE FROM audited WHERE _id = 2")
+	do("DELETE", m.URL()+"/index/audited/field/f", "")
+	do("DELETE", m.URL()+"/index/audited", "")
+
+	events := readAuditLog(t, auditPath)
+	for _, exp := range []audit.Event{
+		{Type: audit.EventCreateTable, Object: "audited"},
+		{Type: audit.EventCreateField, Object: "audited.f"},
+		{Type: audit.EventDelete, Object: "audited", Detail: "Delete(ConstRow(columns=[1]))"},
+		{Type: audit.EventDelete, Object: "audited", Detail: "Delete(ConstRow(columns=[3]))"},
+		{Type: audit.EventDelete, Object: "audited"},
+		{Type: audit.EventDropField, Object: "audited.f"},
+		{Type: audit.EventDropTable, Object: "audited"},
+	} {
+		exp.UserID = "alice"
+		exp.Outcome = audit.OutcomeSuccess
+		if !hasAuditEvent(events, exp) {
+			t.Errorf("expected audit event %+v in %+v", exp, events)
+		}
+	}
+	// only writes which delete records are audited
+	var deletes int
+	for _, e := range events {
+		if e.Type == audit.EventDelete {
+			deletes++
+		}
+	}
+	if deletes != 3 {
+		t.Errorf("expected 3 delete events, got %d", deletes)
+	}
+}
@@ -0,0 +1,137 @@
+package idk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	pilosabatch "github.com/featurebasedb/featurebase/v3/batch"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DeadLetterRow is the reason given for a record which was skipped
+	// entirely (see Main.SkipBadRows).
+	DeadLetterRow = "row"
+
+	// DeadLetterValue is the reason given for a value which was dropped
+	// from an otherwise ingested record (see Main.AllowIntOutOfRange and
+	// friends).
+	DeadLetterValue = "value"
+)
+
+// DeadLetter describes a record, or a single value of a record, which was
+// rejected during ingest.
+type DeadLetter struct {
+	Time   time.Time `json:"time"`
+	Reason string    `json:"reason"`
+	Field  string    `json:"field,omitempty"`
+	Error  string    `json:"error"`
+
+	// Stream and Offset locate the record within its source, if the
+	// source tracks offsets.
+	Stream string `json:"stream,omitempty"`
+	Offset uint64 `json:"offset,omitempty"`
+
+	// Record is the record's data as delivered by the source. Raw is the
+	// message from which it was decoded, if the source provides it.
+	Record []interface{} `json:"record"`
+	Raw    []byte        `json:"raw,omitempty"`
+}
+
+// NewDeadLetter returns a DeadLetter for rec, which was rejected with err.
+func NewDeadLetter(rec Record, reason string, err error) DeadLetter {
+	dl := DeadLetter{
+		Time:   time.Now().UTC(),
+		Reason: reason,
+		Error:  err.Error(),
+	}
+	var ferr *FieldError
+	if errors.As(err, &ferr) {
+		dl.Field = ferr.Field
+	}
+	if osr, ok := rec.(OffsetStreamRecord); ok {
+		dl.Stream, dl.Offset = osr.StreamOffset()
+	}
+	if raw, ok := rec.(RawRecord); ok {
+		dl.Raw = raw.Raw()
+	}
+
+	// Rejected records are often rejected because they contain values
+	// which can't be represented (e.g. NaN), so fall back to formatting
+	// any value which can't be marshaled.
+	data := rec.Data()
+	dl.Record = make([]interface{}, len(data))
+	for i, v := range data {
+		if _, err := json.Marshal(v); err != nil {
+			v = fmt.Sprintf("%v", v)
+		}
+		dl.Record[i] = v
+	}
+	return dl
+}
+
+// DeadLetterSink receives the records and values which are rejected during
+// ingest. Send may be called concurrently.
+type DeadLetterSink interface {
+	Send(dl DeadLetter) error
+	Close() error
+}
+
+// FileDeadLetterSink is a DeadLetterSink which appends dead letters to a file
+// as JSON lines.
+type FileDeadLetterSink struct {
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewFileDeadLetterSink opens (or creates) the file at path for appending
+// dead letters.
+func NewFileDeadLetterSink(path string) (*FileDeadLetterSink, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening dead letter file")
+	}
+	return &FileDeadLetterSink{f: f}, nil
+}
+
+func (s *FileDeadLetterSink) Send(dl DeadLetter) error {
+	line, err := json.Marshal(dl)
+	if err != nil {
+		return errors.Wrap(err, "marshaling dead letter")
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, err = s.f.Write(line)
+	return errors.Wrap(err, "writing dead letter")
+}
+
+func (s *FileDeadLetterSink) Close() error {
+	return s.f.Close()
+}
+
+// FieldError is returned by a Recordizer when it fails to convert the value of
+// a field.
+type FieldError struct {
+	Field string
+	Err   error
+}
+
+func (e *FieldError) Error() string { return e.Err.Error() }
+func (e *FieldError) Cause() error  { return e.Err }
+func (e *FieldError) Unwrap() error { return e.Err }
+
+// fieldRecordizer wraps rz so that the errors it returns are FieldErrors for
+// the named field.
+func fieldRecordizer(field string, rz Recordizer) Recordizer {
+	return func(rawRec []interface{}, rec *pilosabatch.Row) error {
+		if err := rz(rawRec, rec); err != nil {
+			return &FieldError{Field: field, Err: err}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,91 @@
+package idk
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	pilosabatch "github.com/featurebasedb/featurebase/v3/batch"
+	"github.com/pkg/errors"
+)
+
+func TestFieldRecordizer(t *testing.T) {
+	rz := fieldRecordizer("age", func(rawRec []interface{}, rec *pilosabatch.Row) error {
+		if rawRec[0] == nil {
+			return nil
+		}
+		return errors.Wrap(ErrIntOutOfRange, "converting field")
+	})
+
+	if err := rz([]interface{}{nil}, &pilosabatch.Row{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	err := errors.Wrap(rz([]interface{}{1}, &pilosabatch.Row{}), "recordizing")
+	var ferr *FieldError
+	if !errors.As(err, &ferr) {
+		t.Fatalf("expected a FieldError, got %v", err)
+	} else if ferr.Field != "age" {
+		t.Fatalf("expected field age, got %s", ferr.Field)
+	}
+	if errors.Cause(err) != ErrIntOutOfRange {
+		t.Fatalf("expected cause %v, got %v", ErrIntOutOfRange, errors.Cause(err))
+	}
+	if exp := "recordizing: converting field: " + ErrIntOutOfRange.Error(); err.Error() != exp {
+		t.Fatalf("expected %q, got %q", exp, err.Error())
+	}
+}
+
+func TestFileDeadLetterSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dead.jsonl")
+	sink, err := NewFileDeadLetterSink(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec := newSliceRecord([]interface{}{"a", int64(300), math.NaN()}, nil)
+	ferr := errors.Wrap(&FieldError{Field: "b", Err: ErrIntOutOfRange}, "recordizing")
+	for _, reason := range []string{DeadLetterValue, DeadLetterRow} {
+		if err := sink.Send(NewDeadLetter(rec, reason, ferr)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	var reasons []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var dl DeadLetter
+		if err := json.Unmarshal(scanner.Bytes(), &dl); err != nil {
+			t.Fatalf("unmarshaling %s: %v", scanner.Text(), err)
+		}
+		if dl.Field != "b" {
+			t.Errorf("expected field b, got %q", dl.Field)
+		}
+		if dl.Error != ferr.Error() {
+			t.Errorf("expected error %q, got %q", ferr.Error(), dl.Error)
+		}
+		if exp := []interface{}{"a", float64(300), "NaN"}; !reflect.DeepEqual(dl.Record, exp) {
+			t.Errorf("expected record %v, got %v", exp, dl.Record)
+		}
+		reasons = append(reasons, dl.Reason)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if exp := []string{DeadLetterValue, DeadLetterRow}; !reflect.DeepEqual(reasons, exp) {
+		t.Fatalf("expected reasons %v, got %v", exp, reasons)
+	}
+}
@@ -95,6 +95,8 @@ type Main struct {
 	AllowDecimalOutOfRange   bool          `help:"Allow ingest to continue when it encounters out of range decimals in DecimalFields. (default false)"`
 	AllowTimestampOutOfRange bool          `help:"Allow ingest to continue when it encounters out of range timestamps in TimestampFields. (default false)"`
 	SkipBadRows              int           `help:"If you fail to process the first n rows without processing one successfully, fail."`
+	DeadLetterFile           string        `help:"File to which records and values rejected because of bad-row skipping or allowed out of range errors are appended as JSON lines, along with the field and error which caused them to be rejected."`
+	TransformFile            string        `help:"File of transforms deriving additional fields from each record, one per line, of the form <field>=<expression>. <field> is a header like 'domain__String', and <expression> is a source field, a literal, or a call of lower, upper, trim, concat, replace, split, domain, bucket, regex, coalesce or field."`
 
 	UseShardTransactionalEndpoint bool `flag:"use-shard-transactional-endpoint" help:"Use alternate import endpoint that ingests data for all fields in a shard in a single atomic request. No negative performance impact and better consistency. Recommended."`
 
@@ -124,6 +126,17 @@ type Main struct {
 
 	NewImporterFn func() pilosacore.Importer `flag:"-"`
 
+	// NewDeadLetterSink may be set by the user of Main to provide a
+	// source specific DeadLetterSink (e.g. a Kafka topic). It is only
+	// called if DeadLetterFile is empty, and may return a nil sink.
+	NewDeadLetterSink func() (DeadLetterSink, error) `flag:"-"`
+	deadLetters       DeadLetterSink
+
+	// Transforms derive additional fields from each record. They are
+	// loaded from TransformFile if it is set, but may also be set
+	// directly by the user of Main.
+	Transforms Transforms `flag:"-"`
+
 	Batcher pilosabatch.Batcher `flag:"-"`
 
 	// basic, when true, will only set up the things required to run a basic
@@ -360,6 +373,8 @@ func (m *Main) ingest(ctx context.Context, source Source, nexter IDAllocator, so
 	}()
 	var batch pilosabatch.RecordBatch
 	var recordizers []Recordizer
+	var idRecordizer Recordizer // used for records which are deletes
+	var transform func([]interface{}) []interface{}
 	var prevRec Record
 	var row *pilosabatch.Row
 	var errorCounter int // keeps track of consecuitive errors across records
@@ -476,10 +491,20 @@ initialFetch:
 				} else {
 					m.log.Printf("new schema: %#v", schema)
 				}
+				if len(m.Transforms) > 0 {
+					schema, transform, err = m.Transforms.Bind(schema)
+					if err != nil {
+						return errors.Wrap(err, "binding transforms")
+					}
+				}
 				recordizers, batch, row, lookupWriteIdxs, err = m.batchFromSchema(schema)
 				if err != nil {
 					return errors.Wrap(err, "batchFromSchema")
 				}
+				idRecordizer, _, err = m.idRecordizer(schema)
+				if err != nil {
+					return errors.Wrap(err, "getting ID recordizer")
+				}
 				CounterIngesterSchemaChanges.Inc()
 				csvSlice = make([]string, len(schema))
 				if m.csvWriter != nil {
@@ -503,6 +528,42 @@ initialFetch:
 			}
 		}
 		data := rec.Data()
+		if transform != nil {
+			data = transform(data)
+		}
+
+		if dr, ok := rec.(DeleteRecord); ok && dr.IsDelete() {
+			// Deletes are applied immediately, so anything batched
+			// before them must be imported first to preserve order.
+			if batch != nil && batch.Len() > 0 {
+				batchLen := batch.Len()
+				if err := m.importBatch(batch); err != nil {
+					return errors.Wrap(err, "importing batch before delete")
+				}
+				if err := m.commitRecord(ctx, prevRec, limitCounter, uint64(batchLen)); err != nil {
+					return errors.Wrap(err, "committing before delete")
+				}
+				if nexter != nil {
+					if err := nexter.Commit(ctx); err != nil {
+						return errors.Wrap(err, ErrCommittingIDs)
+					}
+				}
+			}
+			batchStart = true
+			if lookupBatcher.Len() > 0 {
+				if err := lookupBatcher.Import(); err != nil {
+					return errors.Wrap(err, "importing lookup batch before delete")
+				}
+			}
+
+			if err := m.deleteRecord(idRecordizer, data); err != nil {
+				return errors.Wrap(err, "deleting record")
+			}
+			if err := m.commitRecord(ctx, rec, limitCounter, 1); err != nil {
+				return errors.Wrap(err, "committing delete")
+			}
+			continue
+		}
 
 		if m.csvWriter != nil {
 			for i, item := range data {
@@ -533,10 +594,16 @@ initialFetch:
 					} else {
 						// will handle this error based on errorCounter in the later if block
 						m.Log().Errorf("Bad record: +%v, reason: %v\n", row, err)
+						if derr := m.deadLetter(rec, DeadLetterRow, err); derr != nil {
+							return derr
+						}
 						break
 					}
 				}
 				m.Log().Errorf(err.Error())
+				if derr := m.deadLetter(rec, DeadLetterValue, err); derr != nil {
+					return derr
+				}
 			}
 		}
 
@@ -723,6 +790,10 @@ func (m *Main) basicSetup() (onFinishRun func(), err error) {
 		m.log = logger.NewStandardLogger(logOut)
 	}
 
+	if err := m.loadTransforms(); err != nil {
+		return nil, err
+	}
+
 	if m.TrackProgress {
 		m.progress = &ProgressTracker{}
 	}
@@ -904,6 +975,22 @@ func (m *Main) setup() (onFinishRun func(), err error) {
 		m.csvWriter = csv.NewWriter(m.csvFile)
 	}
 
+	if err := m.loadTransforms(); err != nil {
+		return nil, err
+	}
+
+	if m.DeadLetterFile != "" {
+		m.deadLetters, err = NewFileDeadLetterSink(m.DeadLetterFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "creating dead letter sink")
+		}
+	} else if m.NewDeadLetterSink != nil {
+		m.deadLetters, err = m.NewDeadLetterSink()
+		if err != nil {
+			return nil, errors.Wrap(err, "creating dead letter sink")
+		}
+	}
+
 	if m.Delete {
 		grpcClient, err := pilosagrpc.NewGRPCClient(m.PilosaGRPCHosts, tlsConfig, m.log)
 		if err != nil {
@@ -1033,6 +1120,12 @@ func (m *Main) setup() (onFinishRun func(), err error) {
 				m.log.Printf("closing CSV file: %v", err)
 			}
 		}
+		if m.deadLetters != nil {
+			err := m.deadLetters.Close()
+			if err != nil {
+				m.log.Printf("closing dead letter sink: %v", err)
+			}
+		}
 		if m.metricsServer != nil {
 			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
 			defer cancel()
@@ -1571,8 +1664,7 @@ func (m *Main) runDeleter(limitCounter *msgCounter) error {
 					if err != nil {
 						return errors.Errorf("unable to convert 'keys' value to an array of strings")
 					}
-					columnKeys := "'" + strings.Join(keysAsStrings, "','") + "'"
-					rawQueries = append(rawQueries, fmt.Sprintf("Delete(ConstRow(columns=[%s]))", columnKeys))
+					rawQueries = append(rawQueries, deleteKeysQuery(keysAsStrings))
 
 				}
 				if fieldValues["ids"] != nil {
@@ -1581,12 +1673,7 @@ func (m *Main) runDeleter(limitCounter *msgCounter) error {
 					if err != nil {
 						return errors.Errorf("unable to convert 'ids' value to an array of int64s")
 					}
-					keysAsStrings := make([]string, len(idsAsInts))
-					for i, v := range idsAsInts {
-						keysAsStrings[i] = fmt.Sprint(v)
-					}
-					columnKeys := strings.Join(keysAsStrings, ",")
-					rawQueries = append(rawQueries, fmt.Sprintf("Delete(ConstRow(columns=[%s]))", columnKeys))
+					rawQueries = append(rawQueries, deleteIDsQuery(idsAsInts))
 				}
 				if fieldValues["filter"] != nil {
 					// if filter set, use it as filter in delete query
@@ -1629,6 +1716,59 @@ func (m *Main) runDeleter(limitCounter *msgCounter) error {
 	return nil
 }
 
+// deleteKeysQuery returns the PQL which deletes the records with the given
+// keys.
+func deleteKeysQuery(keys []string) string {
+	columnKeys := "'" + strings.Join(keys, "','") + "'"
+	return fmt.Sprintf("Delete(ConstRow(columns=[%s]))", columnKeys)
+}
+
+// deleteIDsQuery returns the PQL which deletes the records with the given IDs.
+func deleteIDsQuery(ids []uint64) string {
+	keysAsStrings := make([]string, len(ids))
+	for i, v := range ids {
+		keysAsStrings[i] = fmt.Sprint(v)
+	}
+	columnKeys := strings.Join(keysAsStrings, ",")
+	return fmt.Sprintf("Delete(ConstRow(columns=[%s]))", columnKeys)
+}
+
+// deleteRecord deletes the record whose ID is found in data by the ID
+// recordizer rz. It is used to apply records from sources which deliver
+// deletes along with writes (see DeleteRecord).
+func (m *Main) deleteRecord(rz Recordizer, data []interface{}) error {
+	if rz == nil {
+		return errors.New("deleting records requires --primary-key-fields or --id-field")
+	} else if m.client == nil {
+		return errors.New("deleting records is not supported without a FeatureBase client")
+	}
+	var row pilosabatch.Row
+	if err := rz(data, &row); err != nil {
+		return errors.Wrap(err, "getting record ID")
+	}
+
+	var query string
+	switch id := row.ID.(type) {
+	case uint64:
+		query = deleteIDsQuery([]uint64{id})
+	case string:
+		query = deleteKeysQuery([]string{id})
+	case []byte:
+		query = deleteKeysQuery([]string{string(id)})
+	default:
+		return errors.Errorf("unexpected record ID type: %T", row.ID)
+	}
+
+	resp, err := m.client.Query(m.index.RawQuery(query))
+	if err != nil {
+		return errors.Wrap(err, "running delete query")
+	} else if !resp.Success {
+		return errors.Errorf("running delete query: %s", resp.ErrorMessage)
+	}
+	CounterDeleterRowsAdded.With(prom.Labels{"type": "record"}).Inc()
+	return nil
+}
+
 type inspectRecord proto.RowResponse
 
 func (rr *inspectRecord) Val(field string) (interface{}, error) {
@@ -1756,51 +1896,10 @@ func (m *Main) batchFromSchema(schema []Field) ([]Recordizer, pilosabatch.Record
 	// lookup data store.
 	lookupWriteIdxs := make([]int, 0)
 
-	var rz Recordizer
-	skips := make(map[int]struct{})
-	var err error
-
 	// primary key stuff
-	if len(m.PrimaryKeyFields) != 0 {
-		rz, skips, err = getPrimaryKeyRecordizer(schema, m.PrimaryKeyFields)
-		if err != nil {
-			return nil, nil, nil, nil, errors.Wrap(err, "getting primary key recordizer")
-		}
-	} else if m.IDField != "" {
-		for fieldIndex, field := range schema {
-			if field.DestName() == m.IDField {
-				if _, ok := field.(IDField); !ok {
-					if _, ok := field.(IntField); !ok {
-						return nil, nil, nil, nil, errors.Errorf("specified column id field %s is not an IDField or an IntField: %T", m.IDField, field)
-					}
-				}
-				fieldIndex := fieldIndex
-				rz = func(rawRec []interface{}, rec *pilosabatch.Row) (err error) {
-					id, err := field.PilosafyVal(rawRec[fieldIndex])
-					if err != nil {
-						return errors.Wrapf(err, "converting %+v to ID", rawRec[fieldIndex])
-					}
-					if uid, ok := id.(uint64); ok {
-						rec.ID = uid
-					} else if iid, ok := id.(int64); ok {
-						if iid < 0 {
-							return errors.Errorf("can't use negative value %v as ID", iid)
-						}
-						rec.ID = uint64(iid)
-					} else {
-						return errors.Errorf("can't convert %v of %[1]T to uint64 for use as ID", id)
-					}
-					return nil
-				}
-				skips[fieldIndex] = struct{}{}
-				break
-			}
-		}
-		if rz == nil {
-			return nil, nil, nil, nil, errors.Errorf("ID field %s not found", m.IDField)
-		}
-	} else if m.AutoGenerate {
-		m.log.Debugf("getting no recordizer because we're autogenerating IDs")
+	rz, skips, err := m.idRecordizer(schema)
+	if err != nil {
+		return nil, nil, nil, nil, err
 	}
 	if rz != nil {
 		recordizers = append(recordizers, rz)
@@ -1832,9 +1931,23 @@ func (m *Main) batchFromSchema(schema []Field) ([]Recordizer, pilosabatch.Record
 		boolFieldExists = m.index.Field(m.PackBools+Exists, pilosaclient.OptFieldTypeSet(pilosaclient.CacheTypeRanked, pilosacore.DefaultCacheSize), pilosaclient.OptFieldKeys(true))
 	}
 
+	// Recordizers are added in a number of places below, so rather than
+	// wrapping each one, the recordizers added for a field are wrapped
+	// when moving on to the next, so that their errors name the field.
+	var owner Field
+	owned := len(recordizers)
+	own := func() {
+		for ; owned < len(recordizers); owned++ {
+			recordizers[owned] = fieldRecordizer(owner.Name(), recordizers[owned])
+		}
+	}
+
 	fields := make([]*pilosaclient.Field, 0, len(schema))
 	existingFields := m.index.Fields()
 	for i, idkField := range schema {
+		own()
+		owner = idkField
+
 		// we redefine these inside the loop since we're
 		// capturing them in closures
 		i := i
@@ -2117,6 +2230,7 @@ func (m *Main) batchFromSchema(schema []Field) ([]Recordizer, pilosabatch.Record
 			return nil, nil, nil, nil, errors.Errorf("unknown schema field type %T %[1]v", idkField)
 		}
 	}
+	own()
 
 	err = m.SchemaManager.SyncIndex(m.index)
 	if err != nil {
@@ -2154,6 +2268,66 @@ func (m *Main) batchFromSchema(schema []Field) ([]Recordizer, pilosabatch.Record
 	return recordizers, batch, row, lookupWriteIdxs, nil
 }
 
+// idRecordizer returns the Recordizer which sets the ID of a record from the
+// fields given by PrimaryKeyFields or IDField, along with the indexes of the
+// fields which it consumes. It returns a nil Recordizer if IDs are
+// auto-generated.
+func (m *Main) idRecordizer(schema []Field) (Recordizer, map[int]struct{}, error) {
+	var rz Recordizer
+	skips := make(map[int]struct{})
+	var err error
+
+	if len(m.PrimaryKeyFields) != 0 {
+		rz, skips, err = getPrimaryKeyRecordizer(schema, m.PrimaryKeyFields)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "getting primary key recordizer")
+		}
+	} else if m.IDField != "" {
+		for fieldIndex, field := range schema {
+			if field.DestName() == m.IDField {
+				if _, ok := field.(IDField); !ok {
+					if _, ok := field.(IntField); !ok {
+						return nil, nil, errors.Errorf("specified column id field %s is not an IDField or an IntField: %T", m.IDField, field)
+					}
+				}
+				fieldIndex := fieldIndex
+				rz = func(rawRec []interface{}, rec *pilosabatch.Row) (err error) {
+					id, err := field.PilosafyVal(rawRec[fieldIndex])
+					if err != nil {
+						return errors.Wrapf(err, "converting %+v to ID", rawRec[fieldIndex])
+					}
+					if uid, ok := id.(uint64); ok {
+						rec.ID = uid
+					} else if iid, ok := id.(int64); ok {
+						if iid < 0 {
+							return errors.Errorf("can't use negative value %v as ID", iid)
+						}
+						rec.ID = uint64(iid)
+					} else {
+						return errors.Errorf("can't convert %v of %[1]T to uint64 for use as ID", id)
+					}
+					return nil
+				}
+				skips[fieldIndex] = struct{}{}
+				break
+			}
+		}
+		if rz == nil {
+			return nil, nil, errors.Errorf("ID field %s not found", m.IDField)
+		}
+	} else if m.AutoGenerate {
+		m.log.Debugf("getting no recordizer because we're autogenerating IDs")
+	}
+	if rz == nil {
+		return nil, skips, nil
+	}
+	idFields := m.PrimaryKeyFields
+	if m.IDField != "" {
+		idFields = []string{m.IDField}
+	}
+	return fieldRecordizer(strings.Join(idFields, ","), rz), skips, nil
+}
+
 func (m *Main) newBatch(clientFields []*pilosaclient.Field) (pilosabatch.RecordBatch, error) {
 	cfg := pilosabatch.Config{
 		Size:         m.BatchSize,
@@ -2526,6 +2700,30 @@ func (m *Main) allowError(err error) bool {
 	}
 }
 
+// loadTransforms parses the transforms in TransformFile, if it's set.
+func (m *Main) loadTransforms() error {
+	if m.TransformFile == "" {
+		return nil
+	}
+	f, err := os.Open(m.TransformFile)
+	if err != nil {
+		return errors.Wrap(err, "opening transform file")
+	}
+	defer f.Close()
+	m.Transforms, err = ParseTransforms(f, m.log)
+	return errors.Wrapf(err, "parsing transform file %s", m.TransformFile)
+}
+
+// deadLetter counts rec, or one of its values, as having been rejected with
+// err, and sends it to the dead letter sink if there is one.
+func (m *Main) deadLetter(rec Record, reason string, err error) error {
+	CounterDeadLetters.WithLabelValues(reason).Inc()
+	if m.deadLetters == nil {
+		return nil
+	}
+	return errors.Wrap(m.deadLetters.Send(NewDeadLetter(rec, reason, err)), "sending dead letter")
+}
+
 func (m *Main) useController() bool {
 	return m.ControllerAddress != ""
 }
@@ -9,6 +9,7 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
+	"path/filepath"
 	"reflect"
 	"sort"
 	"strings"
@@ -335,6 +336,100 @@ func TestSkipBadRowsFunctionalityWhenErrorCountIsMore(t *testing.T) {
 	}
 }
 
+func TestSkipBadRowsDeadLetters(t *testing.T) {
+	ingester := ingesterCreationForSkipBadRowsTest(3)
+	ingester.DeadLetterFile = filepath.Join(t.TempDir(), "dead.jsonl")
+
+	err := ingester.Run()
+	if err != nil {
+		t.Fatalf("%s: %v", idktest.ErrRunningIngest, err)
+	}
+
+	data, err := os.ReadFile(ingester.DeadLetterFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bad []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var dl DeadLetter
+		if err := json.Unmarshal([]byte(line), &dl); err != nil {
+			t.Fatalf("unmarshaling %s: %v", line, err)
+		}
+		if dl.Reason != DeadLetterRow || dl.Field != "svals" {
+			t.Fatalf("unexpected dead letter: %+v", dl)
+		}
+		bad = append(bad, dl.Record[1].(string))
+	}
+	if exp := []string{"badrecord1", "badrecord2", "badrecord3", "badrecord4"}; !reflect.DeepEqual(bad, exp) {
+		t.Fatalf("expected dead letters for %v, got %v", exp, bad)
+	}
+}
+
+// deleteTestSource is a testSource whose records at the given indexes are
+// deletes.
+type deleteTestSource struct {
+	testSource
+	deletes map[int]struct{}
+}
+
+type deleteSliceRecord struct {
+	*sliceRecord
+}
+
+func (deleteSliceRecord) IsDelete() bool { return true }
+
+func (s *deleteTestSource) Record() (Record, error) {
+	rec, err := s.testSource.Record()
+	if _, ok := s.deletes[s.i-1]; ok && err == nil {
+		return deleteSliceRecord{rec.(*sliceRecord)}, nil
+	}
+	return rec, err
+}
+
+func TestIngestDeleteRecords(t *testing.T) {
+	ts := &deleteTestSource{
+		testSource: *newTestSource([]Field{StringField{NameVal: "pk"}, StringField{NameVal: "color"}},
+			[][]interface{}{
+				{"a", "red"},
+				{"b", "red"},
+				{"a", nil},
+				{"c", "red"},
+				{"a", "blue"},
+				{"c", nil},
+			}),
+		deletes: map[int]struct{}{2: {}, 5: {}},
+	}
+
+	ingester := NewMain()
+	configureTestFlags(ingester)
+	ingester.NewSource = func() (Source, error) { return ts, nil }
+	rand.Seed(time.Now().UTC().UnixNano())
+	ingester.Index = fmt.Sprintf("ingestdel%d", rand.Intn(100000))
+	ingester.BatchSize = 10
+	ingester.PrimaryKeyFields = []string{"pk"}
+
+	err := ingester.Run()
+	if err != nil {
+		t.Fatalf("%s: %v", idktest.ErrRunningIngest, err)
+	}
+
+	client := ingester.PilosaClient()
+	defer func() {
+		if err := client.DeleteIndexByName(ingester.Index); err != nil {
+			t.Fatal(err)
+		}
+	}()
+
+	color := ingester.index.Field("color")
+	for row, want := range map[string][]string{"red": {"b"}, "blue": {"a"}} {
+		if resp, err := client.Query(color.Row(row)); err != nil {
+			t.Fatalf("row %s: %v", row, err)
+		} else if !stringSliceSame(resp.ResultList[0].Row().Keys, want) {
+			t.Fatalf("row %s: wanted %+v, got: %+v", row, want, resp.ResultList[0].Row().Keys)
+		}
+	}
+}
+
 // TestSingleBoolClear essentially creates an import batch which
 // clears a bit in a particular fragment without setting a bit in that
 // same fragment.  There's a potential optimization in the pilosa client
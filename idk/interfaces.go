@@ -81,6 +81,26 @@ type (
 		StreamOffset() (key string, offset uint64)
 	}
 
+	// RawRecord is an extension of the record type which also provides the
+	// message from which the record was decoded.
+	RawRecord interface {
+		Record
+
+		// Raw returns the undecoded message.
+		Raw() []byte
+	}
+
+	// DeleteRecord is an extension of the record type for sources, such as
+	// change data capture, which deliver deletes along with writes. If
+	// IsDelete returns true, the record identified by the record's ID
+	// fields (see Main.PrimaryKeyFields and Main.IDField) is deleted
+	// rather than written.
+	DeleteRecord interface {
+		Record
+
+		IsDelete() bool
+	}
+
 	Metadata interface {
 		// SchemaMetadata returns a string representation of source-specific details
 		// about the schema.
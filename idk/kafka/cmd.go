@@ -15,6 +15,8 @@ type Main struct {
 	Timeout              time.Duration `help:"Time to wait for more records from Kafka before flushing a batch. 0 to disable."`
 	SkipOld              bool          `short:"" help:"False sets kafka consumer configuration auto.offset.reset to earliest, True sets it to latest."`
 	ConsumerCloseTimeout int           `help:"The amount of time in seconds to wait for the consumer to close properly."`
+	Debezium             bool          `help:"Decode values as Debezium change events, encoded with Avro. Creates and updates are written, and deletes delete the record identified by --primary-key-fields or --id-field."`
+	DeadLetterTopic      string        `help:"Kafka topic to which rejected records and values are produced as JSON. Ignored if --dead-letter-file is set."`
 }
 
 func NewMain() (*Main, error) {
@@ -34,6 +36,9 @@ func NewMain() (*Main, error) {
 	//m.Main.OffsetMode = m.OffsetMode
 	m.OffsetMode = true
 	m.NewSource = func() (idk.Source, error) {
+		if m.Debezium && m.AutoGenerate {
+			return nil, errors.New("Debezium change events can't be used with auto-generated IDs, since deletes must identify records by --primary-key-fields or --id-field")
+		}
 		source := NewSource()
 		source.KafkaBootstrapServers = m.KafkaBootstrapServers
 		source.SchemaRegistryURL = m.SchemaRegistryURL
@@ -43,6 +48,7 @@ func NewMain() (*Main, error) {
 		source.Timeout = m.Timeout
 		source.KafkaSocketTimeoutMs = int(m.Timeout / time.Millisecond)
 		source.SkipOld = m.SkipOld
+		source.Debezium = m.Debezium
 		source.ConfluentCommand = m.ConfluentCommand
 		source.SchemaRegistryUsername = m.SchemaRegistryUsername
 		source.SchemaRegistryPassword = m.SchemaRegistryPassword
@@ -59,5 +65,11 @@ func NewMain() (*Main, error) {
 		}
 		return source, nil
 	}
+	m.NewDeadLetterSink = func() (idk.DeadLetterSink, error) {
+		if m.DeadLetterTopic == "" {
+			return nil, nil
+		}
+		return NewDeadLetterSink(&m.ConfluentCommand, m.DeadLetterTopic)
+	}
 	return m, nil
 }
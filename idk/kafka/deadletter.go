@@ -0,0 +1,72 @@
+package kafka
+
+import (
+	"encoding/json"
+
+	confluent "github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"github.com/featurebasedb/featurebase/v3/idk/common"
+	"github.com/pkg/errors"
+)
+
+// deadLetterFlushTimeoutMs is how long Close waits for outstanding dead
+// letters to be delivered.
+const deadLetterFlushTimeoutMs = 30000
+
+// DeadLetterSink is an idk.DeadLetterSink which produces dead letters, encoded
+// as JSON, to a Kafka topic. Each dead letter is keyed by the stream (topic and
+// partition) of the record it describes.
+type DeadLetterSink struct {
+	producer *confluent.Producer
+	topic    string
+}
+
+// NewDeadLetterSink returns a DeadLetterSink which produces to topic on the
+// cluster described by cfg.
+func NewDeadLetterSink(cfg *idk.ConfluentCommand, topic string) (*DeadLetterSink, error) {
+	configMap, err := common.SetupConfluent(cfg)
+	if err != nil {
+		return nil, errors.Wrap(err, "setting up confluent")
+	}
+	producer, err := confluent.NewProducer(configMap)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating producer")
+	}
+	return &DeadLetterSink{
+		producer: producer,
+		topic:    topic,
+	}, nil
+}
+
+// Send produces dl and waits for it to be delivered, so that a record is never
+// committed before its dead letter.
+func (s *DeadLetterSink) Send(dl idk.DeadLetter) error {
+	value, err := json.Marshal(dl)
+	if err != nil {
+		return errors.Wrap(err, "marshaling dead letter")
+	}
+
+	delivery := make(chan confluent.Event, 1)
+	err = s.producer.Produce(&confluent.Message{
+		TopicPartition: confluent.TopicPartition{Topic: &s.topic, Partition: confluent.PartitionAny},
+		Key:            []byte(dl.Stream),
+		Value:          value,
+	}, delivery)
+	if err != nil {
+		return errors.Wrap(err, "producing dead letter")
+	}
+
+	msg, ok := (<-delivery).(*confluent.Message)
+	if !ok {
+		return errors.New("unexpected delivery event for dead letter")
+	}
+	return errors.Wrap(msg.TopicPartition.Error, "delivering dead letter")
+}
+
+func (s *DeadLetterSink) Close() error {
+	defer s.producer.Close()
+	if n := s.producer.Flush(deadLetterFlushTimeoutMs); n > 0 {
+		return errors.Errorf("%d dead letters were not delivered", n)
+	}
+	return nil
+}
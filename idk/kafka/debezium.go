@@ -0,0 +1,151 @@
+package kafka
+
+import (
+	"encoding/binary"
+
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"github.com/go-avro/avro"
+	"github.com/pkg/errors"
+)
+
+// Debezium change event operations.
+const (
+	debeziumCreate = "c"
+	debeziumRead   = "r" // snapshot
+	debeziumUpdate = "u"
+	debeziumDelete = "d"
+)
+
+// errSkipChange is returned when decoding a Debezium change event which
+// doesn't describe a row, such as a tombstone or a truncate.
+var errSkipChange = errors.New("change event does not describe a row")
+
+// debeziumEnvelope is the row schema of a Debezium change event envelope.
+type debeziumEnvelope struct {
+	row    *avro.RecordSchema
+	fields []idk.Field
+}
+
+// decodeDebezium decodes a Debezium change event, encoded with Avro, in the
+// schema registry's wire format. It returns the row after the change, or the
+// row before it if the change is a delete, along with the row's schema. As
+// with decodeValueWithSchemaRegistry, it returns idk.ErrSchemaChange if the
+// schema differs from that of the previous value.
+//
+// Since an update replaces the whole row, the null values of an update are
+// returned as idk.DELETE_SENTINEL for fields which can be cleared.
+func (s *Source) decodeDebezium(val []byte) (vals map[string]interface{}, row avro.Schema, del bool, err error) {
+	if len(val) == 0 {
+		// tombstone which follows a delete, for log compaction
+		return nil, nil, false, errSkipChange
+	}
+	if len(val) < 6 || val[0] != 0 {
+		return nil, nil, false, errors.Errorf("unexpected magic byte or length in kafka value, should be 0x00, but got %x", val)
+	}
+	id := int32(binary.BigEndian.Uint32(val[1:]))
+	_, codec, err := s.getDecoder(id)
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err, "getting decoder")
+	} else if codec == nil {
+		return nil, nil, false, errors.Errorf("schema %d is not an Avro schema, which is required for Debezium change events", id)
+	}
+	env, err := s.debeziumEnvelope(id, codec)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	event, err := avroDecode(codec, val[5:])
+	if err != nil {
+		return nil, nil, false, errors.Wrap(err, "decoding change event")
+	}
+	op, _ := event["op"].(string)
+	var image interface{}
+	switch op {
+	case debeziumCreate, debeziumRead, debeziumUpdate:
+		image = event["after"]
+	case debeziumDelete:
+		image, del = event["before"], true
+	default:
+		s.Log.Debugf("Source: skipping Debezium change event with op %q", op)
+		return nil, nil, false, errSkipChange
+	}
+	vals, ok := image.(map[string]interface{})
+	if !ok {
+		return nil, nil, false, errors.Errorf("change event with op %q is missing its row", op)
+	}
+
+	if op == debeziumUpdate {
+		for _, field := range env.fields {
+			if vals[field.Name()] == nil && clearable(field) {
+				vals[field.Name()] = idk.DELETE_SENTINEL
+			}
+		}
+	}
+
+	if id != s.lastSchemaID {
+		s.lastSchema = env.fields
+		s.lastSchemaID = id
+		s.lastMessage = ""
+		return vals, env.row, del, idk.ErrSchemaChange
+	}
+	return vals, env.row, del, nil
+}
+
+// debeziumEnvelope returns the envelope of the change event schema with the
+// given ID, which is a record with "before" and "after" fields holding the
+// row.
+func (s *Source) debeziumEnvelope(id int32, codec avro.Schema) (*debeziumEnvelope, error) {
+	if env, ok := s.envelopes[id]; ok {
+		return env, nil
+	}
+
+	rec, ok := codec.(*avro.RecordSchema)
+	if !ok {
+		return nil, errors.Errorf("change event schema must be a record, got %s", codec.GetName())
+	}
+	var row *avro.RecordSchema
+	for _, field := range rec.Fields {
+		if field.Name != "after" {
+			continue
+		}
+		typ := field.Type
+		if union, ok := typ.(*avro.UnionSchema); ok {
+			for _, t := range union.Types {
+				if t.Type() != avro.Null {
+					typ = t
+				}
+			}
+		}
+		if ref, ok := typ.(*avro.RecursiveSchema); ok {
+			// a reference to the row schema defined by "before"
+			typ = ref.Actual
+		}
+		if row, ok = typ.(*avro.RecordSchema); !ok {
+			return nil, errors.Errorf("'after' field of change event must be a record, got %s", typ.GetName())
+		}
+	}
+	if row == nil {
+		return nil, errors.Errorf("schema %s is not a Debezium change event envelope: missing 'after' field", rec.GetName())
+	}
+
+	fields, err := avroToPDKSchema(row)
+	if err != nil {
+		return nil, errors.Wrap(err, "converting row schema")
+	}
+	env := &debeziumEnvelope{
+		row:    row,
+		fields: fields,
+	}
+	s.envelopes[id] = env
+	return env, nil
+}
+
+// clearable reports whether the value of field can be cleared by
+// idk.DELETE_SENTINEL.
+func clearable(field idk.Field) bool {
+	switch field.(type) {
+	case idk.IDField, idk.StringField, idk.BoolField, idk.IntField, idk.DecimalField, idk.TimestampField:
+		return true
+	}
+	return false
+}
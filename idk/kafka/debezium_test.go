@@ -0,0 +1,138 @@
+//go:build !kafka_sasl
+// +build !kafka_sasl
+
+package kafka
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/idk"
+	liavro "github.com/linkedin/goavro/v2"
+)
+
+const debeziumSchema = `{
+	"type": "record",
+	"name": "Envelope",
+	"namespace": "db.public.users",
+	"fields": [
+		{"name": "before", "type": ["null", {
+			"type": "record",
+			"name": "Value",
+			"fields": [
+				{"name": "id", "type": "string"},
+				{"name": "name", "type": ["null", "string"], "default": null},
+				{"name": "age", "type": ["null", "long"], "default": null}
+			]
+		}], "default": null},
+		{"name": "after", "type": ["null", "Value"], "default": null},
+		{"name": "source", "type": {"type": "record", "name": "Source", "fields": [{"name": "table", "type": "string"}]}},
+		{"name": "op", "type": "string"},
+		{"name": "ts_ms", "type": ["null", "long"], "default": null}
+	]
+}`
+
+func TestDebeziumSource(t *testing.T) {
+	t.Parallel()
+
+	src := newRegistrySource(t, map[string]Schema{
+		"/schemas/ids/5": {Schema: debeziumSchema},
+	})
+	src.Debezium = true
+
+	codec, err := liavro.NewCodec(debeziumSchema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	row := func(id string, name interface{}, age interface{}) interface{} {
+		if name != nil {
+			name = map[string]interface{}{"string": name}
+		}
+		if age != nil {
+			age = map[string]interface{}{"long": age}
+		}
+		return map[string]interface{}{"db.public.users.Value": map[string]interface{}{"id": id, "name": name, "age": age}}
+	}
+	event := func(op string, before, after interface{}) []byte {
+		buf, err := codec.BinaryFromNative(nil, map[string]interface{}{
+			"before": before,
+			"after":  after,
+			"source": map[string]interface{}{"table": "users"},
+			"op":     op,
+			"ts_ms":  nil,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return wireFormat(5, buf)
+	}
+
+	sendValues(src,
+		event("r", nil, row("a", "alice", int64(30))),
+		event("c", nil, row("b", "bob", nil)),
+		event("u", row("a", "alice", int64(30)), row("a", nil, int64(31))),
+		event("t", nil, nil),
+		event("d", row("b", "bob", nil), nil),
+		nil, // tombstone
+		event("c", nil, row("c", "carol", int64(40))),
+	)
+
+	expFields := []idk.Field{
+		idk.StringField{NameVal: "id"},
+		idk.StringField{NameVal: "name"},
+		idk.IntField{NameVal: "age"},
+	}
+	exp := []struct {
+		data []interface{}
+		del  bool
+	}{
+		{data: []interface{}{"a", "alice", int64(30)}},
+		{data: []interface{}{"b", "bob", nil}},
+		{data: []interface{}{"a", idk.DELETE_SENTINEL, int64(31)}},
+		{data: []interface{}{"b", "bob", nil}, del: true},
+		{data: []interface{}{"c", "carol", int64(40)}},
+	}
+	for i, e := range exp {
+		rec, err := src.Record()
+		if i == 0 {
+			if err != idk.ErrSchemaChange {
+				t.Fatalf("expected schema change, got %v", err)
+			}
+			if fields := src.Schema(); !reflect.DeepEqual(fields, expFields) {
+				t.Fatalf("unexpected schema:\n%#v\nexpected:\n%#v", fields, expFields)
+			}
+		} else if err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+		if data := rec.Data(); !reflect.DeepEqual(data, e.data) {
+			t.Errorf("record %d: unexpected data:\n%#v\nexpected:\n%#v", i, data, e.data)
+		}
+		if del := rec.(idk.DeleteRecord).IsDelete(); del != e.del {
+			t.Errorf("record %d: expected delete %v, got %v", i, e.del, del)
+		}
+	}
+}
+
+func TestDebeziumSourceNotEnvelope(t *testing.T) {
+	t.Parallel()
+
+	schema := `{"type": "record", "name": "Value", "fields": [{"name": "id", "type": "string"}]}`
+	src := newRegistrySource(t, map[string]Schema{
+		"/schemas/ids/6": {Schema: schema},
+	})
+	src.Debezium = true
+
+	codec, err := liavro.NewCodec(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	buf, err := codec.BinaryFromNative(nil, map[string]interface{}{"id": "a"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sendValues(src, wireFormat(6, buf))
+
+	if _, err := src.Record(); err == nil {
+		t.Fatal("expected error for a value which isn't a change event")
+	}
+}
@@ -0,0 +1,345 @@
+package kafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"github.com/pkg/errors"
+)
+
+// jsonSchemaDecoder is a registryDecoder for JSON Schemas. Values are JSON
+// objects whose properties are described by the schema.
+type jsonSchemaDecoder struct {
+	schema string
+	fields []idk.Field
+}
+
+// jsonSchemaNode is the subset of a JSON Schema which is used to map its
+// properties to idk Fields. As with Avro field properties, the FeatureBase
+// specific keywords fieldType, mutex, quantum, ttl, and scale may be used to
+// refine the mapping.
+type jsonSchemaNode struct {
+	Type       jsonSchemaType       `json:"type"`
+	Format     string               `json:"format"`
+	Enum       []interface{}        `json:"enum"`
+	Items      *jsonSchemaNode      `json:"items"`
+	Ref        string               `json:"$ref"`
+	Minimum    *float64             `json:"minimum"`
+	Maximum    *float64             `json:"maximum"`
+	Properties jsonSchemaProperties `json:"properties"`
+
+	Definitions map[string]*jsonSchemaNode `json:"definitions"`
+	Defs        map[string]*jsonSchemaNode `json:"$defs"`
+
+	FieldType string `json:"fieldType"`
+	Mutex     bool   `json:"mutex"`
+	Quantum   string `json:"quantum"`
+	TTL       string `json:"ttl"`
+	Scale     *int64 `json:"scale"`
+}
+
+// jsonSchemaType is the type keyword of a JSON Schema, which is either a
+// single type or a list of types.
+type jsonSchemaType []string
+
+func (t *jsonSchemaType) UnmarshalJSON(data []byte) error {
+	var typ string
+	if err := json.Unmarshal(data, &typ); err == nil {
+		*t = jsonSchemaType{typ}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// single reduces the type to a single type. As with Avro unions, a list of
+// types is only supported if it's a single type plus optionally null.
+func (t jsonSchemaType) single() (string, error) {
+	var typ string
+	for _, tt := range t {
+		if tt == "null" {
+			continue
+		} else if typ != "" {
+			return "", errors.Errorf("multiple types are only supported when they are a single type plus optionally null: %v", []string(t))
+		}
+		typ = tt
+	}
+	if typ == "" {
+		return "", errors.Errorf("unsupported type: %v", []string(t))
+	}
+	return typ, nil
+}
+
+// jsonSchemaProperties are the properties of an object schema, in the order in
+// which they're defined.
+type jsonSchemaProperties []jsonSchemaProperty
+
+type jsonSchemaProperty struct {
+	Name   string
+	Schema *jsonSchemaNode
+}
+
+func (p *jsonSchemaProperties) UnmarshalJSON(data []byte) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if tok, err := dec.Token(); err != nil {
+		return err
+	} else if tok != json.Delim('{') {
+		return errors.Errorf("properties must be an object, got %v", tok)
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		prop := jsonSchemaProperty{Name: tok.(string)}
+		if err := dec.Decode(&prop.Schema); err != nil {
+			return errors.Wrapf(err, "decoding property %s", prop.Name)
+		}
+		*p = append(*p, prop)
+	}
+	_, err := dec.Token()
+	return err
+}
+
+// newJSONSchemaDecoder returns a decoder for schema, which must describe an
+// object.
+func newJSONSchemaDecoder(schema string) (*jsonSchemaDecoder, error) {
+	var root jsonSchemaNode
+	if err := json.Unmarshal([]byte(schema), &root); err != nil {
+		return nil, errors.Wrap(err, "unmarshaling schema")
+	}
+	fields, err := jsonSchemaToPDKSchema(&root)
+	if err != nil {
+		return nil, err
+	}
+	return &jsonSchemaDecoder{
+		schema: schema,
+		fields: fields,
+	}, nil
+}
+
+// jsonSchemaToPDKSchema converts the properties of an object schema to idk
+// Fields.
+func jsonSchemaToPDKSchema(root *jsonSchemaNode) ([]idk.Field, error) {
+	node, err := root.resolve(root)
+	if err != nil {
+		return nil, err
+	}
+	if typ, err := node.Type.single(); err != nil || typ != "object" {
+		return nil, errors.Errorf("schema must describe an object, got type %v", []string(node.Type))
+	}
+
+	fields := make([]idk.Field, 0, len(node.Properties))
+	for _, prop := range node.Properties {
+		propNode, err := root.resolve(prop.Schema)
+		if err != nil {
+			return nil, errors.Wrapf(err, "property %s", prop.Name)
+		}
+		field, err := jsonSchemaToPDKField(root, prop.Name, propNode)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting property %s to pdk", prop.Name)
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// resolve follows node's $ref, if it has one, to a definition in root.
+func (root *jsonSchemaNode) resolve(node *jsonSchemaNode) (*jsonSchemaNode, error) {
+	if node == nil {
+		return nil, errors.New("missing schema")
+	}
+	for seen := 0; node.Ref != ""; seen++ {
+		if seen > 32 {
+			return nil, errors.Errorf("too many references resolving %s", node.Ref)
+		}
+		var defs map[string]*jsonSchemaNode
+		var name string
+		switch {
+		case node.Ref == "#":
+			node = root
+			continue
+		case strings.HasPrefix(node.Ref, "#/definitions/"):
+			defs, name = root.Definitions, strings.TrimPrefix(node.Ref, "#/definitions/")
+		case strings.HasPrefix(node.Ref, "#/$defs/"):
+			defs, name = root.Defs, strings.TrimPrefix(node.Ref, "#/$defs/")
+		default:
+			return nil, errors.Errorf("unsupported reference: %s", node.Ref)
+		}
+		def, ok := defs[name]
+		if !ok {
+			return nil, errors.Errorf("undefined reference: %s", node.Ref)
+		}
+		node = def
+	}
+	return node, nil
+}
+
+func jsonSchemaToPDKField(root *jsonSchemaNode, name string, node *jsonSchemaNode) (idk.Field, error) {
+	typ, err := node.Type.single()
+	if err != nil {
+		return nil, err
+	}
+
+	switch typ {
+	case "integer":
+		if node.FieldType == "id" {
+			return idk.IDField{
+				NameVal: name,
+				Mutex:   node.Mutex,
+				Quantum: node.Quantum,
+				TTL:     node.TTL,
+			}, nil
+		}
+		fld := idk.IntField{NameVal: name}
+		if node.Minimum != nil {
+			fld.Min = intptr(int64(*node.Minimum))
+		}
+		if node.Maximum != nil {
+			fld.Max = intptr(int64(*node.Maximum))
+		}
+		return fld, nil
+
+	case "number":
+		fld := idk.DecimalField{NameVal: name}
+		if node.Scale != nil {
+			if *node.Scale < 0 || *node.Scale > 18 {
+				return nil, errors.Errorf("0<=scale<=18, got:%d", *node.Scale)
+			}
+			fld.Scale = *node.Scale
+		}
+		return fld, nil
+
+	case "string":
+		switch {
+		case len(node.Enum) > 0:
+			return idk.StringField{NameVal: name, Mutex: true}, nil
+		case node.Format == "date-time":
+			return idk.TimestampField{NameVal: name}, nil
+		case node.Format == "date":
+			return idk.TimestampField{NameVal: name, Layout: "2006-01-02"}, nil
+		}
+		return idk.StringField{
+			NameVal: name,
+			Mutex:   node.Mutex,
+			Quantum: node.Quantum,
+			TTL:     node.TTL,
+		}, nil
+
+	case "boolean":
+		return idk.BoolField{NameVal: name}, nil
+
+	case "array":
+		items, err := root.resolve(node.Items)
+		if err != nil {
+			return nil, errors.Wrap(err, "array items")
+		}
+		itemType, err := items.Type.single()
+		if err != nil {
+			return nil, errors.Wrap(err, "array items")
+		}
+		switch itemType {
+		case "string":
+			return idk.StringArrayField{NameVal: name, Quantum: node.Quantum, TTL: node.TTL}, nil
+		case "integer":
+			return idk.IDArrayField{NameVal: name, Quantum: node.Quantum, TTL: node.TTL}, nil
+		default:
+			return nil, errors.Errorf("array items type of %s is unsupported", itemType)
+		}
+
+	case "object":
+		return nil, errors.Errorf("nested fields are not currently supported, so the field type cannot be object.")
+	}
+
+	return nil, errors.Errorf("unknown schema type: %s", typ)
+}
+
+func (d *jsonSchemaDecoder) String() string {
+	return d.schema
+}
+
+func (d *jsonSchemaDecoder) Fields(msg string) ([]idk.Field, error) {
+	return d.fields, nil
+}
+
+// Decode decodes a JSON object, converting the value of each property to a
+// form accepted by the idk Field to which it maps.
+func (d *jsonSchemaDecoder) Decode(data []byte) (map[string]interface{}, string, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var obj map[string]interface{}
+	if err := dec.Decode(&obj); err != nil {
+		return nil, "", errors.Wrap(err, "decoding JSON value")
+	}
+
+	vals := make(map[string]interface{}, len(d.fields))
+	for _, field := range d.fields {
+		val, err := jsonFieldValue(field, obj[field.Name()])
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "property %s", field.Name())
+		}
+		vals[field.Name()] = val
+	}
+	return vals, "", nil
+}
+
+func jsonFieldValue(field idk.Field, val interface{}) (interface{}, error) {
+	if val == nil {
+		return nil, nil
+	}
+
+	switch field.(type) {
+	case idk.IntField, idk.IDField:
+		num, ok := val.(json.Number)
+		if !ok {
+			return nil, errors.Errorf("expected integer, got %v of %[1]T", val)
+		}
+		return num.Int64()
+
+	case idk.DecimalField:
+		// Decimals are passed as strings so that they are scaled
+		// exactly.
+		num, ok := val.(json.Number)
+		if !ok {
+			return nil, errors.Errorf("expected number, got %v of %[1]T", val)
+		}
+		return num.String(), nil
+
+	case idk.StringArrayField:
+		items, ok := val.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("expected array, got %v of %[1]T", val)
+		}
+		strs := make([]string, 0, len(items))
+		for _, item := range items {
+			str, ok := item.(string)
+			if !ok {
+				return nil, errors.Errorf("expected string in array, got %v of %[1]T", item)
+			}
+			strs = append(strs, str)
+		}
+		return strs, nil
+
+	case idk.IDArrayField:
+		items, ok := val.([]interface{})
+		if !ok {
+			return nil, errors.Errorf("expected array, got %v of %[1]T", val)
+		}
+		ids := make([]uint64, 0, len(items))
+		for _, item := range items {
+			num, ok := item.(json.Number)
+			if !ok {
+				return nil, errors.Errorf("expected integer in array, got %v of %[1]T", item)
+			}
+			id, err := num.Int64()
+			if err != nil || id < 0 {
+				return nil, errors.Errorf("invalid id in array: %v", num)
+			}
+			ids = append(ids, uint64(id))
+		}
+		return ids, nil
+	}
+
+	return val, nil
+}
@@ -0,0 +1,379 @@
+package kafka
+
+import (
+	"encoding/base64"
+	"fmt"
+	"math"
+	"net/url"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+
+	// Register the well-known types, which registry schemas import
+	// without listing them as references.
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// serializedFormat asks the registry for a Protobuf schema as a serialized
+// FileDescriptorProto rather than as .proto source.
+var serializedFormat = url.Values{"format": []string{"serialized"}}
+
+// protobufDecoder is a registryDecoder for Protobuf schemas.
+type protobufDecoder struct {
+	schema string
+	file   protoreflect.FileDescriptor
+	files  *protoregistry.Files
+}
+
+// newProtobufDecoder returns a decoder for the Protobuf schema with the given
+// ID. The registry returns Protobuf schemas as .proto source, so the schema,
+// and any it references, are fetched again in the registry's serialized form,
+// a FileDescriptorProto, from which message descriptors can be built.
+func (s *Source) newProtobufDecoder(id int32, schema *Schema) (*protobufDecoder, error) {
+	var serialized Schema
+	if err := s.registryGet(fmt.Sprintf("schemas/ids/%d", id), serializedFormat, &serialized); err != nil {
+		return nil, errors.Wrap(err, "getting serialized schema")
+	}
+
+	files := &protoregistry.Files{}
+	fd, err := s.buildProtoFile(files, "", &serialized)
+	if err != nil {
+		return nil, err
+	}
+	return &protobufDecoder{
+		schema: schema.Schema,
+		file:   fd,
+		files:  files,
+	}, nil
+}
+
+// buildProtoFile builds the file descriptor for a serialized schema, first
+// building those for the schemas it references, and registers it in files
+// under name.
+func (s *Source) buildProtoFile(files *protoregistry.Files, name string, schema *Schema) (protoreflect.FileDescriptor, error) {
+	resolver := protoResolver{files: files}
+	for _, ref := range schema.References {
+		if _, err := resolver.FindFileByPath(ref.Name); err == nil {
+			continue
+		}
+		var refSchema Schema
+		if err := s.registryGet(fmt.Sprintf("subjects/%s/versions/%d", url.PathEscape(ref.Subject), ref.Version), serializedFormat, &refSchema); err != nil {
+			return nil, errors.Wrapf(err, "getting referenced schema %s", ref.Name)
+		}
+		if _, err := s.buildProtoFile(files, ref.Name, &refSchema); err != nil {
+			return nil, errors.Wrapf(err, "building referenced schema %s", ref.Name)
+		}
+	}
+
+	buf, err := base64.StdEncoding.DecodeString(schema.Schema)
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding serialized schema")
+	}
+	fdp := &descriptorpb.FileDescriptorProto{}
+	if err := proto.Unmarshal(buf, fdp); err != nil {
+		return nil, errors.Wrap(err, "unmarshaling file descriptor")
+	}
+	if name != "" {
+		fdp.Name = proto.String(name)
+	}
+
+	fd, err := protodesc.NewFile(fdp, resolver)
+	if err != nil {
+		return nil, errors.Wrap(err, "building file descriptor")
+	}
+	if err := files.RegisterFile(fd); err != nil {
+		return nil, errors.Wrap(err, "registering file descriptor")
+	}
+	return fd, nil
+}
+
+// protoResolver resolves descriptors from files, falling back to the
+// well-known types.
+type protoResolver struct {
+	files *protoregistry.Files
+}
+
+func (r protoResolver) FindFileByPath(path string) (protoreflect.FileDescriptor, error) {
+	if fd, err := r.files.FindFileByPath(path); err == nil {
+		return fd, nil
+	}
+	return protoregistry.GlobalFiles.FindFileByPath(path)
+}
+
+func (r protoResolver) FindDescriptorByName(name protoreflect.FullName) (protoreflect.Descriptor, error) {
+	if d, err := r.files.FindDescriptorByName(name); err == nil {
+		return d, nil
+	}
+	return protoregistry.GlobalFiles.FindDescriptorByName(name)
+}
+
+func (d *protobufDecoder) String() string {
+	return d.schema
+}
+
+// Decode decodes a value which, following the schema ID, begins with the
+// indexes of its message type within the schema.
+func (d *protobufDecoder) Decode(data []byte) (map[string]interface{}, string, error) {
+	md, n, err := d.messageDescriptor(data)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "reading message indexes")
+	}
+	msg := dynamicpb.NewMessage(md)
+	if err := proto.Unmarshal(data[n:], msg); err != nil {
+		return nil, "", errors.Wrap(err, "unmarshaling protobuf message")
+	}
+
+	fields := md.Fields()
+	vals := make(map[string]interface{}, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		val, err := protoFieldValue(msg, fd)
+		if err != nil {
+			return nil, "", errors.Wrapf(err, "field %s", fd.Name())
+		}
+		vals[string(fd.Name())] = val
+	}
+	return vals, string(md.FullName()), nil
+}
+
+// messageDescriptor reads the message indexes at the start of data, and
+// returns the message type they identify along with the number of bytes
+// read. The indexes are a zig-zag encoded count followed by that many
+// zig-zag encoded indexes, each of a message within the file or the
+// previous message. A count of zero is shorthand for the first message in
+// the file.
+func (d *protobufDecoder) messageDescriptor(data []byte) (protoreflect.MessageDescriptor, int, error) {
+	v, n := protowire.ConsumeVarint(data)
+	if n < 0 {
+		return nil, 0, protowire.ParseError(n)
+	}
+	read := n
+	count := protowire.DecodeZigZag(v)
+	if count < 0 {
+		return nil, 0, errors.Errorf("invalid message index count: %d", count)
+	}
+
+	indexes := []int64{0}
+	if count > 0 {
+		indexes = make([]int64, count)
+		for i := range indexes {
+			v, n := protowire.ConsumeVarint(data[read:])
+			if n < 0 {
+				return nil, 0, protowire.ParseError(n)
+			}
+			read += n
+			indexes[i] = protowire.DecodeZigZag(v)
+		}
+	}
+
+	msgs := d.file.Messages()
+	var md protoreflect.MessageDescriptor
+	for _, idx := range indexes {
+		if idx < 0 || idx >= int64(msgs.Len()) {
+			return nil, 0, errors.Errorf("message index out of range: %v", indexes)
+		}
+		md = msgs.Get(int(idx))
+		msgs = md.Messages()
+	}
+	return md, read, nil
+}
+
+// Fields returns the idk Fields of the named message type.
+func (d *protobufDecoder) Fields(msg string) ([]idk.Field, error) {
+	desc, err := d.files.FindDescriptorByName(protoreflect.FullName(msg))
+	if err != nil {
+		return nil, errors.Wrapf(err, "finding message %s", msg)
+	}
+	md, ok := desc.(protoreflect.MessageDescriptor)
+	if !ok {
+		return nil, errors.Errorf("%s is not a message", msg)
+	}
+	return protoToPDKSchema(md)
+}
+
+// protoToPDKSchema converts the fields of a Protobuf message to idk
+// Fields. Nested messages, other than the well-known timestamp and wrapper
+// types, are not supported.
+func protoToPDKSchema(md protoreflect.MessageDescriptor) ([]idk.Field, error) {
+	fields := md.Fields()
+	pdkFields := make([]idk.Field, 0, fields.Len())
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		pdkField, err := protoToPDKField(string(fd.Name()), fd)
+		if err != nil {
+			return nil, errors.Wrapf(err, "converting protobuf field %s to pdk", fd.Name())
+		}
+		pdkFields = append(pdkFields, pdkField)
+	}
+	return pdkFields, nil
+}
+
+func protoToPDKField(name string, fd protoreflect.FieldDescriptor) (idk.Field, error) {
+	if fd.IsMap() {
+		return nil, errors.New("nested fields are not currently supported, so the field type cannot be map.")
+	}
+
+	if fd.IsList() {
+		switch fd.Kind() {
+		case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.EnumKind:
+			return idk.StringArrayField{NameVal: name}, nil
+		case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+			protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+			protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+			protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+			return idk.IDArrayField{NameVal: name}, nil
+		default:
+			return nil, errors.Errorf("repeated %s fields are unsupported", fd.Kind())
+		}
+	}
+
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return idk.BoolField{NameVal: name}, nil
+
+	case protoreflect.EnumKind:
+		return idk.StringField{NameVal: name, Mutex: true}, nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind,
+		protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return idk.IntField{NameVal: name}, nil
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return idk.DecimalField{NameVal: name}, nil
+
+	case protoreflect.StringKind, protoreflect.BytesKind:
+		return idk.StringField{NameVal: name}, nil
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		md := fd.Message()
+		if md.FullName() == "google.protobuf.Timestamp" {
+			return idk.TimestampField{NameVal: name}, nil
+		}
+		if inner := wrappedField(md); inner != nil {
+			return protoToPDKField(name, inner)
+		}
+		return nil, errors.Errorf("nested fields are not currently supported, so the field type cannot be message %s.", md.FullName())
+	}
+
+	return nil, errors.Errorf("unknown protobuf kind: %s", fd.Kind())
+}
+
+// wrappedField returns the value field of md if md is one of the well-known
+// wrapper types, such as google.protobuf.StringValue, and nil otherwise.
+func wrappedField(md protoreflect.MessageDescriptor) protoreflect.FieldDescriptor {
+	if md.ParentFile().Path() != "google/protobuf/wrappers.proto" {
+		return nil
+	}
+	return md.Fields().ByName("value")
+}
+
+// protoFieldValue returns the value of fd in msg in a form accepted by the
+// idk Field to which fd converts. Fields which are unset, and which track
+// whether they are set, are nil.
+func protoFieldValue(msg protoreflect.Message, fd protoreflect.FieldDescriptor) (interface{}, error) {
+	if fd.IsList() {
+		list := msg.Get(fd).List()
+		if list.Len() == 0 {
+			return nil, nil
+		}
+		switch fd.Kind() {
+		case protoreflect.StringKind, protoreflect.BytesKind, protoreflect.EnumKind:
+			vals := make([]string, list.Len())
+			for i := range vals {
+				val, err := protoScalarValue(list.Get(i), fd)
+				if err != nil {
+					return nil, err
+				}
+				vals[i] = val.(string)
+			}
+			return vals, nil
+		default:
+			vals := make([]uint64, list.Len())
+			for i := range vals {
+				switch val := list.Get(i).Interface().(type) {
+				case int32:
+					if val < 0 {
+						return nil, errors.Errorf("negative id in list: %d", val)
+					}
+					vals[i] = uint64(val)
+				case int64:
+					if val < 0 {
+						return nil, errors.Errorf("negative id in list: %d", val)
+					}
+					vals[i] = uint64(val)
+				case uint32:
+					vals[i] = uint64(val)
+				case uint64:
+					vals[i] = val
+				}
+			}
+			return vals, nil
+		}
+	}
+
+	if fd.HasPresence() && !msg.Has(fd) {
+		return nil, nil
+	}
+	return protoScalarValue(msg.Get(fd), fd)
+}
+
+// protoScalarValue converts a single value of fd.
+func protoScalarValue(v protoreflect.Value, fd protoreflect.FieldDescriptor) (interface{}, error) {
+	switch fd.Kind() {
+	case protoreflect.BoolKind:
+		return v.Bool(), nil
+
+	case protoreflect.EnumKind:
+		if ev := fd.Enum().Values().ByNumber(v.Enum()); ev != nil {
+			return string(ev.Name()), nil
+		}
+		return fmt.Sprintf("%d", v.Enum()), nil
+
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind,
+		protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return v.Int(), nil
+
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind,
+		protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		if v.Uint() > math.MaxInt64 {
+			return nil, errors.Errorf("value out of range: %d", v.Uint())
+		}
+		return int64(v.Uint()), nil
+
+	case protoreflect.FloatKind, protoreflect.DoubleKind:
+		return v.Float(), nil
+
+	case protoreflect.StringKind:
+		return v.String(), nil
+
+	case protoreflect.BytesKind:
+		return string(v.Bytes()), nil
+
+	case protoreflect.MessageKind, protoreflect.GroupKind:
+		msg := v.Message()
+		md := msg.Descriptor()
+		if md.FullName() == "google.protobuf.Timestamp" {
+			fields := md.Fields()
+			secs := msg.Get(fields.ByName("seconds")).Int()
+			nanos := msg.Get(fields.ByName("nanos")).Int()
+			return time.Unix(secs, nanos).UTC(), nil
+		}
+		if inner := wrappedField(md); inner != nil {
+			return protoScalarValue(msg.Get(inner), inner)
+		}
+		return nil, errors.Errorf("unsupported message type: %s", md.FullName())
+	}
+
+	return nil, errors.Errorf("unknown protobuf kind: %s", fd.Kind())
+}
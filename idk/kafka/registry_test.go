@@ -0,0 +1,316 @@
+//go:build !kafka_sasl
+// +build !kafka_sasl
+
+package kafka
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	confluent "github.com/confluentinc/confluent-kafka-go/kafka"
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// newRegistrySource returns a Source whose schema registry serves schemas,
+// keyed by URL path and query.
+func newRegistrySource(t *testing.T, schemas map[string]Schema) *Source {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Path
+		if r.URL.RawQuery != "" {
+			key += "?" + r.URL.RawQuery
+		}
+		schema, ok := schemas[key]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewEncoder(w).Encode(schema); err != nil {
+			t.Errorf("encoding schema: %v", err)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	src := NewSource()
+	src.SchemaRegistryURL = srv.URL
+	src.httpClient = srv.Client()
+	return src
+}
+
+// sendValues sends each value to src, as though it had been read from Kafka.
+func sendValues(src *Source, values ...[]byte) {
+	go func() {
+		topic := "test"
+		for i, value := range values {
+			src.recordChannel <- recordWithError{
+				Record: &confluent.Message{
+					TopicPartition: confluent.TopicPartition{
+						Topic:  &topic,
+						Offset: confluent.Offset(i),
+					},
+					Timestamp: time.Now(),
+					Value:     value,
+				},
+			}
+		}
+		close(src.recordChannel)
+	}()
+}
+
+// wireFormat prefixes data with the registry's magic byte and schema ID.
+func wireFormat(id uint32, data ...[]byte) []byte {
+	buf := make([]byte, 5)
+	binary.BigEndian.PutUint32(buf[1:], id)
+	for _, d := range data {
+		buf = append(buf, d...)
+	}
+	return buf
+}
+
+// testProtoFile is the descriptor of a file with two messages: Event, which
+// has a field of each supported kind, and Other.
+func testProtoFile() *descriptorpb.FileDescriptorProto {
+	field := func(name string, num int32, typ descriptorpb.FieldDescriptorProto_Type, label descriptorpb.FieldDescriptorProto_Label, typeName string) *descriptorpb.FieldDescriptorProto {
+		f := &descriptorpb.FieldDescriptorProto{
+			Name:   proto.String(name),
+			Number: proto.Int32(num),
+			Type:   typ.Enum(),
+			Label:  label.Enum(),
+		}
+		if typeName != "" {
+			f.TypeName = proto.String(typeName)
+		}
+		return f
+	}
+	opt := descriptorpb.FieldDescriptorProto_LABEL_OPTIONAL
+	rep := descriptorpb.FieldDescriptorProto_LABEL_REPEATED
+
+	return &descriptorpb.FileDescriptorProto{
+		Name:       proto.String("event.proto"),
+		Package:    proto.String("test"),
+		Syntax:     proto.String("proto3"),
+		Dependency: []string{"google/protobuf/timestamp.proto", "google/protobuf/wrappers.proto"},
+		EnumType: []*descriptorpb.EnumDescriptorProto{{
+			Name: proto.String("Color"),
+			Value: []*descriptorpb.EnumValueDescriptorProto{
+				{Name: proto.String("RED"), Number: proto.Int32(0)},
+				{Name: proto.String("BLUE"), Number: proto.Int32(1)},
+			},
+		}},
+		MessageType: []*descriptorpb.DescriptorProto{
+			{
+				Name: proto.String("Event"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("name", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ""),
+					field("count", 2, descriptorpb.FieldDescriptorProto_TYPE_INT64, opt, ""),
+					field("price", 3, descriptorpb.FieldDescriptorProto_TYPE_DOUBLE, opt, ""),
+					field("ok", 4, descriptorpb.FieldDescriptorProto_TYPE_BOOL, opt, ""),
+					field("color", 5, descriptorpb.FieldDescriptorProto_TYPE_ENUM, opt, ".test.Color"),
+					field("tags", 6, descriptorpb.FieldDescriptorProto_TYPE_STRING, rep, ""),
+					field("ids", 7, descriptorpb.FieldDescriptorProto_TYPE_INT64, rep, ""),
+					field("ts", 8, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, opt, ".google.protobuf.Timestamp"),
+					field("note", 9, descriptorpb.FieldDescriptorProto_TYPE_MESSAGE, opt, ".google.protobuf.StringValue"),
+				},
+			},
+			{
+				Name: proto.String("Other"),
+				Field: []*descriptorpb.FieldDescriptorProto{
+					field("x", 1, descriptorpb.FieldDescriptorProto_TYPE_STRING, opt, ""),
+				},
+			},
+		},
+	}
+}
+
+func TestProtobufSource(t *testing.T) {
+	t.Parallel()
+
+	fdp := testProtoFile()
+	buf, err := proto.Marshal(fdp)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src := newRegistrySource(t, map[string]Schema{
+		"/schemas/ids/3":                   {Schema: "syntax = \"proto3\"; ...", SchemaType: "PROTOBUF"},
+		"/schemas/ids/3?format=serialized": {Schema: base64.StdEncoding.EncodeToString(buf), SchemaType: "PROTOBUF"},
+	})
+
+	fd, err := protodesc.NewFile(fdp, protoregistry.GlobalFiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := dynamicpb.NewMessage(fd.Messages().ByName("Event"))
+	fields := event.Descriptor().Fields()
+	ts := time.Date(2022, 11, 1, 12, 0, 0, 0, time.UTC)
+	event.Set(fields.ByName("name"), protoreflect.ValueOfString("a"))
+	event.Set(fields.ByName("count"), protoreflect.ValueOfInt64(4))
+	event.Set(fields.ByName("price"), protoreflect.ValueOfFloat64(1.5))
+	event.Set(fields.ByName("color"), protoreflect.ValueOfEnum(1))
+	tags := event.Mutable(fields.ByName("tags")).List()
+	tags.Append(protoreflect.ValueOfString("x"))
+	tags.Append(protoreflect.ValueOfString("y"))
+	event.Mutable(fields.ByName("ids")).List().Append(protoreflect.ValueOfInt64(9))
+	event.Set(fields.ByName("ts"), protoreflect.ValueOfMessage(timestamppb.New(ts).ProtoReflect()))
+	event.Set(fields.ByName("note"), protoreflect.ValueOfMessage(wrapperspb.String("hi").ProtoReflect()))
+	eventBuf, err := proto.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := dynamicpb.NewMessage(fd.Messages().ByName("Other"))
+	other.Set(other.Descriptor().Fields().ByName("x"), protoreflect.ValueOfString("b"))
+	otherBuf, err := proto.Marshal(other)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A message index of 0 is the first message in the file. Other is
+	// identified by a count of 1 (zig-zag encoded as 2) and the index 1
+	// (encoded as 2).
+	sendValues(src,
+		wireFormat(3, []byte{0}, eventBuf),
+		wireFormat(3, []byte{0}, eventBuf),
+		wireFormat(3, []byte{2, 2}, otherBuf),
+	)
+
+	rec, err := src.Record()
+	if err != idk.ErrSchemaChange {
+		t.Fatalf("expected schema change, got %v", err)
+	}
+	expSchema := []idk.Field{
+		idk.StringField{NameVal: "name"},
+		idk.IntField{NameVal: "count"},
+		idk.DecimalField{NameVal: "price"},
+		idk.BoolField{NameVal: "ok"},
+		idk.StringField{NameVal: "color", Mutex: true},
+		idk.StringArrayField{NameVal: "tags"},
+		idk.IDArrayField{NameVal: "ids"},
+		idk.TimestampField{NameVal: "ts"},
+		idk.StringField{NameVal: "note"},
+	}
+	if schema := src.Schema(); !reflect.DeepEqual(schema, expSchema) {
+		t.Fatalf("unexpected schema exp/got:\n%+v\n%+v", expSchema, schema)
+	}
+	expData := []interface{}{"a", int64(4), 1.5, false, "BLUE", []string{"x", "y"}, []uint64{9}, ts, "hi"}
+	if data := rec.Data(); !reflect.DeepEqual(data, expData) {
+		t.Fatalf("unexpected data exp/got:\n%#v\n%#v", expData, data)
+	}
+
+	if _, err := src.Record(); err != nil {
+		t.Fatalf("expected no schema change, got %v", err)
+	}
+
+	// A different message from the same schema is a schema change.
+	rec, err = src.Record()
+	if err != idk.ErrSchemaChange {
+		t.Fatalf("expected schema change, got %v", err)
+	}
+	if schema, exp := src.Schema(), []idk.Field{idk.StringField{NameVal: "x"}}; !reflect.DeepEqual(schema, exp) {
+		t.Fatalf("unexpected schema exp/got:\n%+v\n%+v", exp, schema)
+	}
+	if data, exp := rec.Data(), []interface{}{"b"}; !reflect.DeepEqual(data, exp) {
+		t.Fatalf("unexpected data exp/got:\n%#v\n%#v", exp, data)
+	}
+}
+
+func TestJSONSchemaSource(t *testing.T) {
+	t.Parallel()
+
+	schema1 := `{
+		"type": "object",
+		"properties": {
+			"id": {"type": "integer", "fieldType": "id"},
+			"name": {"type": ["null", "string"], "mutex": true},
+			"size": {"type": "integer", "minimum": 0, "maximum": 100},
+			"price": {"type": "number", "scale": 2},
+			"active": {"type": "boolean"},
+			"color": {"type": "string", "enum": ["red", "blue"]},
+			"created": {"type": "string", "format": "date-time"},
+			"tags": {"$ref": "#/definitions/tags"},
+			"ids": {"type": "array", "items": {"type": "integer"}}
+		},
+		"definitions": {
+			"tags": {"type": "array", "items": {"type": "string"}, "quantum": "YMD"}
+		}
+	}`
+	schema2 := `{"type": "object", "properties": {"id": {"type": "integer"}}}`
+	src := newRegistrySource(t, map[string]Schema{
+		"/schemas/ids/1": {Schema: schema1, SchemaType: "JSON"},
+		"/schemas/ids/2": {Schema: schema2, SchemaType: "JSON"},
+	})
+
+	sendValues(src,
+		wireFormat(1, []byte(`{"id": 7, "name": null, "size": 3, "price": 1.25, "active": true, "color": "red", "created": "2022-11-01T12:00:00Z", "tags": ["a", "b"], "ids": [1, 2]}`)),
+		wireFormat(2, []byte(`{"id": 8}`)),
+	)
+
+	rec, err := src.Record()
+	if err != idk.ErrSchemaChange {
+		t.Fatalf("expected schema change, got %v", err)
+	}
+	expSchema := []idk.Field{
+		idk.IDField{NameVal: "id"},
+		idk.StringField{NameVal: "name", Mutex: true},
+		idk.IntField{NameVal: "size", Min: intptr(0), Max: intptr(100)},
+		idk.DecimalField{NameVal: "price", Scale: 2},
+		idk.BoolField{NameVal: "active"},
+		idk.StringField{NameVal: "color", Mutex: true},
+		idk.TimestampField{NameVal: "created"},
+		idk.StringArrayField{NameVal: "tags", Quantum: "YMD"},
+		idk.IDArrayField{NameVal: "ids"},
+	}
+	if schema := src.Schema(); !reflect.DeepEqual(schema, expSchema) {
+		t.Fatalf("unexpected schema exp/got:\n%+v\n%+v", expSchema, schema)
+	}
+	expData := []interface{}{int64(7), nil, int64(3), "1.25", true, "red", "2022-11-01T12:00:00Z", []string{"a", "b"}, []uint64{1, 2}}
+	if data := rec.Data(); !reflect.DeepEqual(data, expData) {
+		t.Fatalf("unexpected data exp/got:\n%#v\n%#v", expData, data)
+	}
+
+	rec, err = src.Record()
+	if err != idk.ErrSchemaChange {
+		t.Fatalf("expected schema change, got %v", err)
+	}
+	if schema, exp := src.Schema(), []idk.Field{idk.IntField{NameVal: "id"}}; !reflect.DeepEqual(schema, exp) {
+		t.Fatalf("unexpected schema exp/got:\n%+v\n%+v", exp, schema)
+	}
+	if data, exp := rec.Data(), []interface{}{int64(8)}; !reflect.DeepEqual(data, exp) {
+		t.Fatalf("unexpected data exp/got:\n%#v\n%#v", exp, data)
+	}
+}
+
+func TestJSONSchemaToPDKSchemaErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		schema string
+		expErr string
+	}{
+		{`{"type": "array"}`, "schema must describe an object"},
+		{`{"type": "object", "properties": {"a": {"type": "object"}}}`, "nested fields are not currently supported"},
+		{`{"type": "object", "properties": {"a": {"type": ["string", "integer"]}}}`, "multiple types are only supported"},
+		{`{"type": "object", "properties": {"a": {"$ref": "#/definitions/b"}}}`, "undefined reference"},
+		{`{"type": "object", "properties": {"a": {"type": "number", "scale": 20}}}`, "0<=scale<=18"},
+	}
+	for i, test := range tests {
+		if _, err := newJSONSchemaDecoder(test.schema); err == nil || !strings.Contains(err.Error(), test.expErr) {
+			t.Errorf("test %d: expected error containing %q, got %v", i, test.expErr, err)
+		}
+	}
+}
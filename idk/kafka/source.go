@@ -39,6 +39,7 @@ type Source struct {
 	Timeout              time.Duration
 	SkipOld              bool
 	Verbose              bool
+	Debezium             bool
 	schema               Schema
 	TLS                  idk.TLSConfig
 	consumerCloseTimeout int
@@ -57,9 +58,17 @@ type Source struct {
 	lastSchemaID int32
 	lastSchema   []idk.Field
 
+	// lastMessage is the fully qualified name of the most recent
+	// message type, for schema formats in which a single schema may
+	// define several message types.
+	lastMessage string
+
 	// cache is a schema cache so we don't have to look up the same
-	// schema from the registry each time.
+	// schema from the registry each time. Avro schemas are cached in
+	// cache, and Protobuf and JSON schemas in decoders.
 	cache      map[int32]avro.Schema
+	decoders   map[int32]registryDecoder
+	envelopes  map[int32]*debeziumEnvelope
 	httpClient *http.Client
 	// synchronize closing
 	quit   chan struct{}
@@ -80,6 +89,8 @@ func NewSource() *Source {
 
 		lastSchemaID:  -1,
 		cache:         make(map[int32]avro.Schema),
+		decoders:      make(map[int32]registryDecoder),
+		envelopes:     make(map[int32]*debeziumEnvelope),
 		recordChannel: make(chan recordWithError),
 		quit:          make(chan struct{}),
 		ConfigMap:     &confluent.ConfigMap{},
@@ -94,6 +105,7 @@ func NewSource() *Source {
 // object may be used by successive calls to Record, so it should not
 // be retained.
 func (s *Source) Record() (idk.Record, error) {
+fetch:
 	rec := s.fetch()
 	switch rec.Err {
 	case nil:
@@ -109,11 +121,24 @@ func (s *Source) Record() (idk.Record, error) {
 		return nil, idk.ErrFlush
 	}
 
-	val, avroSchema, err := s.decodeAvroValueWithSchemaRegistry(rec.Record.Value)
+	var val map[string]interface{}
+	var avroSchema avro.Schema
+	var del bool
+	var err error
+	if s.Debezium {
+		val, avroSchema, del, err = s.decodeDebezium(rec.Record.Value)
+		if err == errSkipChange {
+			// Skipped messages are committed along with the records
+			// which follow them.
+			goto fetch
+		}
+	} else {
+		val, avroSchema, err = s.decodeValueWithSchemaRegistry(rec.Record.Value)
+	}
 	if err != nil && err != idk.ErrSchemaChange {
 		return nil, errors.Wrap(err, "decoding with schema registry")
 	}
-	data := s.toPDKRecord(val.(map[string]interface{})) // val must be map[string]interface{} because we only accept schemas which are Record type at the top level.
+	data := s.toPDKRecord(val)
 
 	msg := rec.Record
 	// with librdkafka, committing an offset means that offset is
@@ -130,7 +155,9 @@ func (s *Source) Record() (idk.Record, error) {
 		offset:     int64(msg.TopicPartition.Offset),
 		idx:        s.spoolBase + uint64(len(s.spool)),
 		data:       data,
+		raw:        msg.Value,
 		avroSchema: avroSchema,
+		delete:     del,
 	}, err
 }
 
@@ -172,8 +199,14 @@ func (s *Source) SchemaID() int {
 }
 
 func (s *Source) SchemaMetadata() string {
+	var schema string
+	if codec, ok := s.cache[s.lastSchemaID]; ok {
+		schema = codec.String()
+	} else if dec, ok := s.decoders[s.lastSchemaID]; ok {
+		schema = dec.String()
+	}
 	var buf bytes.Buffer
-	err := json.Compact(&buf, []byte(s.cache[s.lastSchemaID].String()))
+	err := json.Compact(&buf, []byte(schema))
 	if err != nil {
 		panic(err)
 	}
@@ -196,7 +229,9 @@ type Record struct {
 	offset     int64
 	idx        uint64
 	data       []interface{}
+	raw        []byte
 	avroSchema avro.Schema
+	delete     bool
 }
 
 func (r *Record) StreamOffset() (string, uint64) {
@@ -204,6 +239,8 @@ func (r *Record) StreamOffset() (string, uint64) {
 }
 
 var _ idk.OffsetStreamRecord = &Record{}
+var _ idk.RawRecord = &Record{}
+var _ idk.DeleteRecord = &Record{}
 
 func (r *Record) Commit(ctx context.Context) error {
 	r.src.mu.Lock()
@@ -256,6 +293,16 @@ func (r *Record) Data() []interface{} {
 	return r.data
 }
 
+// IsDelete reports whether the record is a Debezium delete change event.
+func (r *Record) IsDelete() bool {
+	return r.delete
+}
+
+// Raw returns the value of the message from which the record was decoded.
+func (r *Record) Raw() []byte {
+	return r.raw
+}
+
 func (r *Record) Schema() interface{} {
 	return r.avroSchema
 }
@@ -474,26 +521,31 @@ func (s *Source) Close() error {
 	return nil
 }
 
-// TODO change name
-func (s *Source) decodeAvroValueWithSchemaRegistry(val []byte) (interface{}, avro.Schema, error) {
+// decodeValueWithSchemaRegistry decodes a message value in the schema
+// registry's wire format: a zero byte, the 4 byte ID of the schema, and the
+// encoded value. It returns idk.ErrSchemaChange along with the value if the
+// schema differs from that of the previous value. The avro schema is returned
+// if the value was encoded with Avro.
+func (s *Source) decodeValueWithSchemaRegistry(val []byte) (map[string]interface{}, avro.Schema, error) {
 	if len(val) < 6 || val[0] != 0 {
-		return nil, nil, errors.Errorf("unexpected magic byte or length in avro kafka value, should be 0x00, but got %x", val)
+		return nil, nil, errors.Errorf("unexpected magic byte or length in kafka value, should be 0x00, but got %x", val)
 	}
 	id := int32(binary.BigEndian.Uint32(val[1:]))
-	codec, err := s.getCodec(id)
+	dec, codec, err := s.getDecoder(id)
 	if err != nil {
-		return nil, nil, errors.Wrap(err, "getting avro codec")
+		return nil, nil, errors.Wrap(err, "getting decoder")
 	}
-	ret, err := avroDecode(codec, val[5:])
+	ret, msg, err := dec.Decode(val[5:])
 	if err != nil {
-		return nil, codec, errors.Wrap(err, "decoding avro record")
+		return nil, codec, errors.Wrap(err, "decoding record")
 	}
-	if id != s.lastSchemaID {
-		s.lastSchema, err = avroToPDKSchema(codec)
+	if id != s.lastSchemaID || msg != s.lastMessage {
+		s.lastSchema, err = dec.Fields(msg)
 		if err != nil {
 			return nil, codec, errors.Wrap(err, "converting to FeatureBase schema")
 		}
 		s.lastSchemaID = id
+		s.lastMessage = msg
 		return ret, codec, idk.ErrSchemaChange
 	}
 
@@ -982,10 +1034,59 @@ func propertiesFromSchema(sch avro.Schema, origProps map[string]interface{}) map
 
 // The Schema type is an object produced by the schema registry.
 type Schema struct {
-	Schema  string `json:"schema"`  // The actual AVRO schema
-	Subject string `json:"subject"` // Subject where the schema is registered for
-	Version int    `json:"version"` // Version within this subject
-	ID      int    `json:"id"`      // Registry's unique id
+	Schema     string            `json:"schema"`               // The actual schema
+	SchemaType string            `json:"schemaType,omitempty"` // AVRO, PROTOBUF, or JSON; blank means AVRO
+	References []SchemaReference `json:"references,omitempty"` // Other schemas imported by this one
+	Subject    string            `json:"subject"`              // Subject where the schema is registered for
+	Version    int               `json:"version"`              // Version within this subject
+	ID         int               `json:"id"`                   // Registry's unique id
+}
+
+// SchemaReference refers to a schema, by subject and version, which is
+// imported by another schema under Name.
+type SchemaReference struct {
+	Name    string `json:"name"`
+	Subject string `json:"subject"`
+	Version int    `json:"version"`
+}
+
+// Schema types supported by the schema registry.
+const (
+	schemaTypeAvro     = "AVRO"
+	schemaTypeProtobuf = "PROTOBUF"
+	schemaTypeJSON     = "JSON"
+)
+
+// registryDecoder decodes values encoded with a schema from the registry.
+type registryDecoder interface {
+	// Decode decodes a value, returning its fields by name along with the
+	// fully qualified name of its message type, if the schema format
+	// allows a schema to define more than one.
+	Decode(data []byte) (map[string]interface{}, string, error)
+
+	// Fields returns the idk Fields of the named message type.
+	Fields(msg string) ([]idk.Field, error)
+
+	// String returns the schema as registered.
+	String() string
+}
+
+// avroDecoder is a registryDecoder for Avro schemas.
+type avroDecoder struct {
+	codec avro.Schema
+}
+
+func (d avroDecoder) Decode(data []byte) (map[string]interface{}, string, error) {
+	vals, err := avroDecode(d.codec, data)
+	return vals, "", err
+}
+
+func (d avroDecoder) Fields(msg string) ([]idk.Field, error) {
+	return avroToPDKSchema(d.codec)
+}
+
+func (d avroDecoder) String() string {
+	return d.codec.String()
 }
 
 func (s *Source) codecURL(id int32, urlPath string) (string, error) {
@@ -1000,11 +1101,80 @@ func (s *Source) codecURL(id int32, urlPath string) (string, error) {
 	return url.String(), nil
 }
 
-func (s *Source) getCodec(id int32) (avro.Schema, error) {
+// registryGet gets the JSON object at the given path, relative to the registry
+// URL, and decodes it into v.
+func (s *Source) registryGet(urlPath string, query url.Values, v interface{}) error {
+	u, err := url.Parse(s.SchemaRegistryURL)
+	if err != nil {
+		return errors.Wrap(err, "parsing pre-validated registry url: "+s.SchemaRegistryURL)
+	}
+	u.Path = path.Join(u.Path, urlPath)
+	u.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, u.String(), http.NoBody)
+	if err != nil {
+		return errors.Wrap(err, "building request for registry")
+	}
+	if s.SchemaRegistryUsername != "" {
+		req.SetBasicAuth(s.SchemaRegistryUsername, s.SchemaRegistryPassword)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "getting %s from registry", urlPath)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		bod, _ := io.ReadAll(resp.Body)
+		return errors.Errorf("failed to get %s, code: %d, resp: %s", urlPath, resp.StatusCode, bod)
+	}
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(v), "decoding %s from registry", urlPath)
+}
+
+// getDecoder returns the decoder for the schema with the given ID, fetching
+// the schema from the registry if necessary. The avro schema is also returned
+// if the schema is an Avro schema.
+func (s *Source) getDecoder(id int32) (registryDecoder, avro.Schema, error) {
 	if codec, ok := s.cache[id]; ok {
-		return codec, nil
+		return avroDecoder{codec: codec}, codec, nil
+	} else if dec, ok := s.decoders[id]; ok {
+		return dec, nil, nil
+	}
+
+	schema, err := s.fetchSchema(id)
+	if err != nil {
+		return nil, nil, err
 	}
-	s.Log.Debugf("Source: new avro schema ID: %d", id)
+
+	var dec registryDecoder
+	switch schema.SchemaType {
+	case "", schemaTypeAvro:
+		codec, err := avro.ParseSchema(schema.Schema)
+		if err != nil {
+			return nil, nil, errors.Wrap(err, "parsing schema")
+		}
+		s.Log.Debugf("Source: successfully got new avro schema %d: %s", id, codec.String())
+		s.cache[id] = codec
+		return avroDecoder{codec: codec}, codec, nil
+	case schemaTypeProtobuf:
+		dec, err = s.newProtobufDecoder(id, schema)
+	case schemaTypeJSON:
+		dec, err = newJSONSchemaDecoder(schema.Schema)
+	default:
+		return nil, nil, errors.Errorf("unsupported schema type: %s", schema.SchemaType)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "parsing %s schema", schema.SchemaType)
+	}
+	s.Log.Debugf("Source: successfully got new %s schema %d", schema.SchemaType, id)
+
+	s.decoders[id] = dec
+	return dec, nil, nil
+}
+
+// fetchSchema gets the schema with the given ID from the registry.
+func (s *Source) fetchSchema(id int32) (*Schema, error) {
+	s.Log.Debugf("Source: new schema ID: %d", id)
 	// r, err := s.httpClient.Get(s.codecURL___OLD(id))
 	schemaUrl, err := s.codecURL(id, "schemas/ids/%d")
 	if err != nil {
@@ -1087,14 +1257,7 @@ func (s *Source) getCodec(id int32) (avro.Schema, error) {
 	// save schema object on s
 	s.schema = *schema
 
-	codec, err := avro.ParseSchema(schema.Schema)
-	if err != nil {
-		return nil, errors.Wrap(err, "parsing schema")
-	}
-	s.Log.Debugf("Source: successfully got new avro schema %d: %s", id, codec.String())
-
-	s.cache[id] = codec
-	return codec, nil
+	return schema, nil
 }
 
 func avroDecode(codec avro.Schema, data []byte) (map[string]interface{}, error) {
@@ -7,6 +7,7 @@ const (
 	MetricIngesterRowsAdded     = "ingester_rows_added_total"
 	MetricIngesterSchemaChanges = "ingester_schema_changes_total"
 	MetricCommittedRecords      = "committed_records"
+	MetricDeadLetters           = "dead_letters_total"
 )
 
 var CounterIngesterSchemaChanges = prometheus.NewCounter(
@@ -44,9 +45,21 @@ var CounterDeleterRowsAdded = prometheus.NewCounterVec(
 	},
 )
 
+var CounterDeadLetters = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "ingester",
+		Name:      MetricDeadLetters,
+		Help:      "Number of records (reason=row) and values (reason=value) rejected during ingest.",
+	},
+	[]string{
+		"reason",
+	},
+)
+
 func init() {
 	prometheus.MustRegister(CounterIngesterSchemaChanges)
 	prometheus.MustRegister(CounterIngesterRowsAdded)
 	prometheus.MustRegister(CounterCommittedRecords)
 	prometheus.MustRegister(CounterDeleterRowsAdded)
+	prometheus.MustRegister(CounterDeadLetters)
 }
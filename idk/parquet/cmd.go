@@ -0,0 +1,245 @@
+package parquet
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+	"github.com/pkg/errors"
+)
+
+type Main struct {
+	idk.Main   `flag:"!embed"`
+	Files      []string `help:"List of files, URLs, directories, globs, or s3://bucket/prefix locations to ingest."`
+	FloatScale int64    `help:"Scale of the decimal fields to which floating point columns are mapped."`
+
+	S3Region          string `help:"Region of the S3 bucket. Uses the AWS default if blank."`
+	S3Endpoint        string `help:"S3 endpoint, for S3-compatible stores such as MinIO."`
+	S3AccessKeyID     string `help:"S3 access key ID. The default AWS credential chain is used if blank."`
+	S3SecretAccessKey string `help:"S3 secret access key."`
+
+	files   chan string
+	visited map[string]struct{}
+
+	mu     sync.Mutex
+	stores map[string]*objectstore.S3Store
+}
+
+func NewMain() *Main {
+	m := &Main{
+		Main:       *idk.NewMain(),
+		FloatScale: 2,
+		files:      make(chan string),
+		visited:    make(map[string]struct{}),
+		stores:     make(map[string]*objectstore.S3Store),
+	}
+	m.Main.Namespace = "ingester_parquet"
+
+	once := &sync.Once{}
+	m.NewSource = func() (idk.Source, error) {
+		if len(m.Files) == 0 {
+			return nil, errors.New("must provide at least one file or directory with --files")
+		}
+		once.Do(func() { go m.streamFileNames() })
+
+		source := NewSource()
+		source.Files = m.files
+		source.Log = m.Main.Log()
+		source.FloatScale = m.FloatScale
+		source.open = m.openFile
+		return source, nil
+	}
+	return m
+}
+
+func (m *Main) streamFileNames() {
+	defer close(m.files)
+
+	for _, filename := range m.Files {
+		switch {
+		case strings.HasPrefix(filename, "s3://"):
+			if err := m.listS3(filename); err != nil {
+				m.Log().Printf("listing %s: %v", filename, err)
+			}
+
+		case strings.HasPrefix(filename, "http"):
+			m.files <- filename
+
+		default:
+			matches := []string{filename}
+			if strings.ContainsAny(filename, "*?[") {
+				var err error
+				if matches, err = filepath.Glob(filename); err != nil {
+					m.Log().Printf("filepath.Glob(%s) %v", filename, err)
+					continue
+				}
+			}
+			for _, match := range matches {
+				if err := filepath.Walk(match, m.traverse); err != nil {
+					m.Log().Printf("filepath.Walk(%s) %v", match, err)
+				}
+			}
+		}
+	}
+}
+
+func (m *Main) traverse(path string, fi os.FileInfo, err error) error {
+	if err != nil {
+		m.Log().Printf("prevent panic by handling failure accessing a path %q: %v\n", path, err)
+		return err
+	}
+
+	switch mode := fi.Mode(); {
+	case mode.IsRegular():
+		if _, ok := m.visited[path]; !ok {
+			m.visited[path] = struct{}{}
+			m.files <- path
+		}
+
+	case mode.IsDir():
+		if _, ok := m.visited[path]; !ok {
+			m.visited[path] = struct{}{}
+		}
+
+	case mode&os.ModeSymlink != 0:
+		lnk, err := filepath.EvalSymlinks(path)
+		if err != nil {
+			return err
+		}
+		if _, ok := m.visited[lnk]; !ok {
+			if err := filepath.Walk(lnk, m.traverse); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// listS3 sends the name of every object under an s3://bucket/prefix location
+// to the files channel.
+func (m *Main) listS3(location string) error {
+	bucket, prefix := splitS3(location)
+	store, err := m.s3Store(bucket)
+	if err != nil {
+		return err
+	}
+
+	objs, err := store.List(context.Background(), prefix)
+	if err != nil {
+		return errors.Wrap(err, "listing objects")
+	}
+	for _, obj := range objs {
+		if strings.HasSuffix(obj.Key, "/") {
+			continue
+		}
+		m.files <- "s3://" + bucket + "/" + obj.Key
+	}
+	return nil
+}
+
+// s3Store returns the store for bucket, creating it if necessary.
+func (m *Main) s3Store(bucket string) (*objectstore.S3Store, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if store, ok := m.stores[bucket]; ok {
+		return store, nil
+	}
+	store, err := objectstore.NewS3Store(objectstore.Config{
+		Bucket:          bucket,
+		Region:          m.S3Region,
+		Endpoint:        m.S3Endpoint,
+		AccessKeyID:     m.S3AccessKeyID,
+		SecretAccessKey: m.S3SecretAccessKey,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "creating s3 store for bucket %s", bucket)
+	}
+	m.stores[bucket] = store
+	return store, nil
+}
+
+// openFile opens a local file, http URL, or S3 object.
+func (m *Main) openFile(name string) (readAtSeekCloser, error) {
+	if !strings.HasPrefix(name, "s3://") {
+		return openFile(name)
+	}
+
+	bucket, key := splitS3(name)
+	store, err := m.s3Store(bucket)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := store.Get(context.Background(), key)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting object")
+	}
+	defer rc.Close()
+	return copyToTemp(rc)
+}
+
+// splitS3 splits an s3://bucket/key location into its bucket and key.
+func splitS3(location string) (bucket, key string) {
+	bucket = strings.TrimPrefix(location, "s3://")
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, key = bucket[:i], bucket[i+1:]
+	}
+	return bucket, key
+}
+
+// openFile opens a local file or http URL.
+func openFile(name string) (readAtSeekCloser, error) {
+	if !strings.HasPrefix(name, "http") {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening file")
+		}
+		return f, nil
+	}
+
+	resp, err := http.Get(name)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting via http")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode > 299 {
+		return nil, errors.Errorf("got status %d via http.Get", resp.StatusCode)
+	}
+	return copyToTemp(resp.Body)
+}
+
+// tempFile is a temporary file which is removed when it's closed.
+type tempFile struct {
+	*os.File
+}
+
+func (f tempFile) Close() error {
+	defer os.Remove(f.Name())
+	return f.File.Close()
+}
+
+// copyToTemp copies the contents of r to a temporary file, since Parquet files
+// can't be read as a stream.
+func copyToTemp(r io.Reader) (readAtSeekCloser, error) {
+	f, err := os.CreateTemp("", "idk-parquet-*.parquet")
+	if err != nil {
+		return nil, errors.Wrap(err, "creating temp file")
+	}
+	tf := tempFile{File: f}
+	if _, err := io.Copy(f, r); err != nil {
+		tf.Close()
+		return nil, errors.Wrap(err, "copying to temp file")
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		tf.Close()
+		return nil, errors.Wrap(err, "seeking temp file")
+	}
+	return tf, nil
+}
@@ -0,0 +1,222 @@
+package parquet
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/apache/arrow/go/v10/parquet"
+	"github.com/apache/arrow/go/v10/parquet/pqarrow"
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+	"github.com/featurebasedb/featurebase/v3/objectstore/objectstoretest"
+)
+
+var testSchema = arrow.NewSchema([]arrow.Field{
+	{Name: "id", Type: arrow.PrimitiveTypes.Int64},
+	{Name: "price", Type: arrow.PrimitiveTypes.Float64, Nullable: true},
+	{Name: "name", Type: arrow.BinaryTypes.String, Nullable: true},
+	{Name: "active", Type: arrow.FixedWidthTypes.Boolean},
+	{Name: "ts", Type: &arrow.TimestampType{Unit: arrow.Millisecond, TimeZone: "UTC"}},
+	{Name: "tags", Type: arrow.ListOf(arrow.BinaryTypes.String), Nullable: true},
+	{Name: "ids", Type: arrow.ListOf(arrow.PrimitiveTypes.Int64), Nullable: true},
+	{Name: "born", Type: arrow.FixedWidthTypes.Date32},
+	{Name: "code__String_F_YMD", Type: arrow.BinaryTypes.String},
+}, nil)
+
+var testTime = time.Date(2022, 10, 3, 12, 30, 0, 0, time.UTC)
+
+// writeTestFile writes n rows, with ids starting at start, as Parquet.
+func writeTestFile(t *testing.T, w io.Writer, start, n int) {
+	t.Helper()
+
+	b := array.NewRecordBuilder(memory.NewGoAllocator(), testSchema)
+	defer b.Release()
+
+	for i := start; i < start+n; i++ {
+		b.Field(0).(*array.Int64Builder).Append(int64(i))
+		if i%2 == 0 {
+			b.Field(1).(*array.Float64Builder).Append(float64(i) + 0.25)
+			b.Field(2).(*array.StringBuilder).Append("a")
+		} else {
+			b.Field(1).AppendNull()
+			b.Field(2).AppendNull()
+		}
+		b.Field(3).(*array.BooleanBuilder).Append(i%2 == 1)
+		b.Field(4).(*array.TimestampBuilder).Append(arrow.Timestamp(testTime.UnixMilli()))
+
+		tags := b.Field(5).(*array.ListBuilder)
+		tags.Append(true)
+		tags.ValueBuilder().(*array.StringBuilder).AppendValues([]string{"x", "y"}, nil)
+		ids := b.Field(6).(*array.ListBuilder)
+		ids.Append(true)
+		ids.ValueBuilder().(*array.Int64Builder).AppendValues([]int64{int64(i), 7}, nil)
+
+		b.Field(7).(*array.Date32Builder).Append(arrow.Date32FromTime(testTime))
+		b.Field(8).(*array.StringBuilder).Append("c")
+	}
+
+	rec := b.NewRecord()
+	defer rec.Release()
+	tbl := array.NewTableFromRecords(testSchema, []arrow.Record{rec})
+	defer tbl.Release()
+
+	if err := pqarrow.WriteTable(tbl, w, 1024, parquet.NewWriterProperties(), pqarrow.DefaultWriterProps()); err != nil {
+		t.Fatalf("writing parquet: %v", err)
+	}
+}
+
+func writeTestFileAt(t *testing.T, path string, start, n int) {
+	t.Helper()
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	writeTestFile(t, f, start, n)
+}
+
+// readAll reads every record from the sources, returning the ids read.
+func readAll(t *testing.T, sources ...idk.Source) []int64 {
+	t.Helper()
+
+	var ids []int64
+	for _, src := range sources {
+		for {
+			rec, err := src.Record()
+			if err == io.EOF {
+				break
+			} else if err != nil && err != idk.ErrSchemaChange {
+				t.Fatal(err)
+			}
+			ids = append(ids, rec.Data()[0].(int64))
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+	return ids
+}
+
+func TestSource(t *testing.T) {
+	dir := t.TempDir()
+	writeTestFileAt(t, filepath.Join(dir, "data.parquet"), 0, 2)
+
+	m := NewMain()
+	m.Files = []string{dir}
+	src, err := m.NewSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := src.Record()
+	if err != idk.ErrSchemaChange {
+		t.Fatalf("expected schema change, got %v", err)
+	}
+
+	expFields := []idk.Field{
+		idk.IntField{NameVal: "id"},
+		idk.DecimalField{NameVal: "price", Scale: 2},
+		idk.StringField{NameVal: "name"},
+		idk.BoolField{NameVal: "active"},
+		idk.RecordTimeField{NameVal: "ts"},
+		idk.StringArrayField{NameVal: "tags"},
+		idk.IDArrayField{NameVal: "ids"},
+		idk.TimestampField{NameVal: "born", Granularity: "s"},
+		idk.StringField{NameVal: "code", DestNameVal: "code", Quantum: "YMD"},
+	}
+	if fields := src.Schema(); !reflect.DeepEqual(fields, expFields) {
+		t.Fatalf("unexpected schema:\n%#v\nexpected:\n%#v", fields, expFields)
+	}
+
+	born := time.Date(2022, 10, 3, 0, 0, 0, 0, time.UTC)
+	exp := []interface{}{int64(0), 0.25, "a", false, testTime, []string{"x", "y"}, []uint64{0, 7}, born, "c"}
+	if data := rec.Data(); !reflect.DeepEqual(data, exp) {
+		t.Fatalf("unexpected data:\n%#v\nexpected:\n%#v", data, exp)
+	}
+
+	rec, err = src.Record()
+	if err != nil {
+		t.Fatal(err)
+	}
+	exp = []interface{}{int64(1), nil, nil, true, testTime, []string{"x", "y"}, []uint64{1, 7}, born, "c"}
+	if data := rec.Data(); !reflect.DeepEqual(data, exp) {
+		t.Fatalf("unexpected data:\n%#v\nexpected:\n%#v", data, exp)
+	}
+
+	if _, err := src.Record(); err != io.EOF {
+		t.Fatalf("expected EOF, got %v", err)
+	}
+}
+
+func TestSourceConcurrency(t *testing.T) {
+	dir := t.TempDir()
+	for i, name := range []string{"a.parquet", "b.parquet", "c.parquet", "skip.txt"} {
+		writeTestFileAt(t, filepath.Join(dir, name), i*10, 10)
+	}
+
+	m := NewMain()
+	m.Files = []string{filepath.Join(dir, "*.parquet")}
+
+	// Each file is read by one of the sources.
+	sources := make([]idk.Source, 2)
+	for i := range sources {
+		src, err := m.NewSource()
+		if err != nil {
+			t.Fatal(err)
+		}
+		sources[i] = src
+	}
+	done := make(chan []int64)
+	for _, src := range sources {
+		go func(src idk.Source) { done <- readAll(t, src) }(src)
+	}
+	ids := append(<-done, <-done...)
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	if len(ids) != 30 {
+		t.Fatalf("expected 30 records, got %d", len(ids))
+	}
+	for i, id := range ids {
+		if id != int64(i) {
+			t.Fatalf("expected id %d, got %d", i, id)
+		}
+	}
+}
+
+func TestSourceS3(t *testing.T) {
+	srv := objectstoretest.NewServer(t)
+	store, err := objectstore.NewS3Store(srv.Config("bkt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, key := range []string{"data/1.parquet", "data/2.parquet", "other/3.parquet"} {
+		var buf bytes.Buffer
+		writeTestFile(t, &buf, i*5, 5)
+		if err := store.Put(context.Background(), key, &buf); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m := NewMain()
+	m.Files = []string{"s3://bkt/data/"}
+	m.S3Endpoint = srv.URL
+	m.S3AccessKeyID = "test"
+	m.S3SecretAccessKey = "test"
+	src, err := m.NewSource()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ids := readAll(t, src)
+	if exp := []int64{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}; !reflect.DeepEqual(ids, exp) {
+		t.Fatalf("expected ids %v, got %v", exp, ids)
+	}
+}
@@ -0,0 +1,315 @@
+package parquet
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/apache/arrow/go/v10/arrow"
+	"github.com/apache/arrow/go/v10/arrow/array"
+	"github.com/apache/arrow/go/v10/arrow/memory"
+	"github.com/apache/arrow/go/v10/parquet/file"
+	"github.com/apache/arrow/go/v10/parquet/pqarrow"
+	"github.com/featurebasedb/featurebase/v3/idk"
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/pkg/errors"
+)
+
+// Source is an idk.Source which reads records from the Parquet files whose
+// names it receives on Files. Several Sources may share the same Files
+// channel, in which case each file is read by exactly one of them.
+type Source struct {
+	Files chan string
+	Log   logger.Logger
+
+	// FloatScale is the scale of the decimal fields to which floating point
+	// columns are mapped.
+	FloatScale int64
+
+	schemaLock sync.Mutex
+	schema     []idk.Field
+
+	// open returns the named file. Parquet files are read starting from the
+	// footer, so files which aren't local are copied to a temporary file.
+	open func(name string) (readAtSeekCloser, error)
+
+	records chan Record
+	once    *sync.Once
+}
+
+type readAtSeekCloser interface {
+	io.ReaderAt
+	io.ReadSeeker
+	io.Closer
+}
+
+// NewSource returns a new instance of Source.
+func NewSource() *Source {
+	return &Source{
+		Log:     logger.NopLogger,
+		open:    openFile,
+		records: make(chan Record),
+		once:    &sync.Once{},
+	}
+}
+
+func (s *Source) Record() (idk.Record, error) {
+	s.once.Do(func() { go s.run() })
+
+	rec, ok := <-s.records
+	if !ok {
+		return nil, io.EOF
+	}
+	return rec, rec.err
+}
+
+type Record struct {
+	data []interface{}
+	err  error
+}
+
+func (r Record) Data() []interface{} {
+	return r.data
+}
+
+func (r Record) Commit(ctx context.Context) error { return nil }
+
+func (r Record) Schema() interface{} { return nil }
+
+func (s *Source) Schema() []idk.Field {
+	s.schemaLock.Lock()
+	defer s.schemaLock.Unlock()
+	return s.schema
+}
+
+func (s *Source) Close() error {
+	return nil
+}
+
+func (s *Source) run() {
+	defer close(s.records)
+
+	for name := range s.Files {
+		if err := s.processFile(name); err != nil {
+			s.records <- Record{err: errors.Wrapf(err, "processing %s", name)}
+			return
+		}
+	}
+}
+
+func (s *Source) processFile(name string) error {
+	s.Log.Printf("processFile: %s", name)
+
+	f, err := s.open(name)
+	if err != nil {
+		return errors.Wrap(err, "opening")
+	}
+	defer f.Close()
+
+	pf, err := file.NewParquetReader(f)
+	if err != nil {
+		return errors.Wrap(err, "reading parquet")
+	}
+	defer pf.Close()
+
+	fr, err := pqarrow.NewFileReader(pf, pqarrow.ArrowReadProperties{BatchSize: 1024}, memory.NewGoAllocator())
+	if err != nil {
+		return errors.Wrap(err, "creating arrow reader")
+	}
+	rr, err := fr.GetRecordReader(context.Background(), nil, nil)
+	if err != nil {
+		return errors.Wrap(err, "getting record reader")
+	}
+	defer rr.Release()
+
+	schema, err := FieldsFromSchema(rr.Schema(), s.FloatScale, s.Log)
+	if err != nil {
+		return errors.Wrap(err, "mapping schema")
+	}
+
+	var nextErr error
+	s.schemaLock.Lock()
+	if !reflect.DeepEqual(schema, s.schema) {
+		s.schema = schema
+		nextErr = idk.ErrSchemaChange
+	}
+	s.schemaLock.Unlock()
+
+	for rr.Next() {
+		batch := rr.Record()
+		cols := batch.Columns()
+		for i := 0; i < int(batch.NumRows()); i++ {
+			data := make([]interface{}, len(cols))
+			for j, col := range cols {
+				data[j], err = valueAt(col, i)
+				if err != nil {
+					return errors.Wrapf(err, "reading column %s, row %d", schema[j].Name(), i)
+				}
+			}
+			s.records <- Record{data: data, err: nextErr}
+			nextErr = nil
+		}
+	}
+	return nil
+}
+
+// FieldsFromSchema maps the columns of an arrow schema, as read from a Parquet
+// file, to idk Fields. Columns whose names are in the "name__Type_args" form
+// used for CSV headers are mapped according to their name, so that the
+// default mapping can be overridden. Otherwise, integers map to IntFields,
+// floating point and decimal columns to DecimalFields, strings to
+// StringFields, timestamps to the RecordTimeField, dates to TimestampFields,
+// and lists of strings or integers to StringArrayFields or IDArrayFields.
+func FieldsFromSchema(schema *arrow.Schema, floatScale int64, log logger.Logger) ([]idk.Field, error) {
+	fields := make([]idk.Field, len(schema.Fields()))
+	for i, af := range schema.Fields() {
+		if strings.Contains(af.Name, "__") {
+			f, err := idk.HeaderToField(af.Name, log)
+			if err != nil {
+				return nil, errors.Wrapf(err, "column %s", af.Name)
+			}
+			fields[i] = f
+			continue
+		}
+
+		f, err := fieldFromType(af.Name, af.Type, floatScale)
+		if err != nil {
+			return nil, errors.Wrapf(err, "column %s", af.Name)
+		}
+		fields[i] = f
+	}
+	return fields, nil
+}
+
+func fieldFromType(name string, typ arrow.DataType, floatScale int64) (idk.Field, error) {
+	switch typ.ID() {
+	case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+		arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+		return idk.IntField{NameVal: name}, nil
+	case arrow.FLOAT16, arrow.FLOAT32, arrow.FLOAT64:
+		return idk.DecimalField{NameVal: name, Scale: floatScale}, nil
+	case arrow.DECIMAL128:
+		return idk.DecimalField{NameVal: name, Scale: int64(typ.(*arrow.Decimal128Type).Scale)}, nil
+	case arrow.STRING, arrow.LARGE_STRING, arrow.BINARY, arrow.LARGE_BINARY:
+		return idk.StringField{NameVal: name}, nil
+	case arrow.BOOL:
+		return idk.BoolField{NameVal: name}, nil
+	case arrow.TIMESTAMP:
+		return idk.RecordTimeField{NameVal: name}, nil
+	case arrow.DATE32, arrow.DATE64:
+		return idk.TimestampField{NameVal: name, Granularity: "s"}, nil
+	case arrow.LIST:
+		switch elem := typ.(*arrow.ListType).Elem(); elem.ID() {
+		case arrow.STRING, arrow.LARGE_STRING, arrow.BINARY, arrow.LARGE_BINARY:
+			return idk.StringArrayField{NameVal: name}, nil
+		case arrow.INT8, arrow.INT16, arrow.INT32, arrow.INT64,
+			arrow.UINT8, arrow.UINT16, arrow.UINT32, arrow.UINT64:
+			return idk.IDArrayField{NameVal: name}, nil
+		default:
+			return nil, errors.Errorf("unsupported list element type: %s", elem)
+		}
+	}
+	return nil, errors.Errorf("unsupported type: %s", typ)
+}
+
+// valueAt returns the value at row i of col in a form accepted by the Field to
+// which col's type maps.
+func valueAt(col arrow.Array, i int) (interface{}, error) {
+	if col.IsNull(i) {
+		return nil, nil
+	}
+	switch a := col.(type) {
+	case *array.Int8:
+		return int64(a.Value(i)), nil
+	case *array.Int16:
+		return int64(a.Value(i)), nil
+	case *array.Int32:
+		return int64(a.Value(i)), nil
+	case *array.Int64:
+		return a.Value(i), nil
+	case *array.Uint8:
+		return uint64(a.Value(i)), nil
+	case *array.Uint16:
+		return uint64(a.Value(i)), nil
+	case *array.Uint32:
+		return uint64(a.Value(i)), nil
+	case *array.Uint64:
+		return a.Value(i), nil
+	case *array.Float16:
+		return float64(a.Value(i).Float32()), nil
+	case *array.Float32:
+		return float64(a.Value(i)), nil
+	case *array.Float64:
+		return a.Value(i), nil
+	case *array.Decimal128:
+		num := a.Value(i)
+		lo, hi := num.LowBits(), num.HighBits()
+		// Only values which fit in an int64 are supported.
+		if (hi != 0 || lo > 1<<63-1) && (hi != -1 || lo < 1<<63) {
+			return nil, errors.Errorf("decimal value out of range")
+		}
+		return pql.NewDecimal(int64(lo), int64(a.DataType().(*arrow.Decimal128Type).Scale)), nil
+	case *array.String:
+		return a.Value(i), nil
+	case *array.LargeString:
+		return a.Value(i), nil
+	case *array.Binary:
+		return string(a.Value(i)), nil
+	case *array.LargeBinary:
+		return string(a.Value(i)), nil
+	case *array.Boolean:
+		return a.Value(i), nil
+	case *array.Timestamp:
+		return a.Value(i).ToTime(a.DataType().(*arrow.TimestampType).Unit).UTC(), nil
+	case *array.Date32:
+		return a.Value(i).ToTime(), nil
+	case *array.Date64:
+		return a.Value(i).ToTime(), nil
+	case *array.List:
+		start, end := a.ValueOffsets(i)
+		return listValues(a.ListValues(), int(start), int(end))
+	}
+	return nil, errors.Errorf("unsupported type: %s", col.DataType())
+}
+
+// listValues returns the elements of values from start up to end as a
+// []string or []uint64.
+func listValues(values arrow.Array, start, end int) (interface{}, error) {
+	switch values.DataType().ID() {
+	case arrow.STRING, arrow.LARGE_STRING, arrow.BINARY, arrow.LARGE_BINARY:
+		out := make([]string, 0, end-start)
+		for j := start; j < end; j++ {
+			v, err := valueAt(values, j)
+			if err != nil {
+				return nil, err
+			} else if v != nil {
+				out = append(out, v.(string))
+			}
+		}
+		return out, nil
+	default:
+		out := make([]uint64, 0, end-start)
+		for j := start; j < end; j++ {
+			v, err := valueAt(values, j)
+			if err != nil {
+				return nil, err
+			}
+			switch vt := v.(type) {
+			case nil:
+			case int64:
+				if vt < 0 {
+					return nil, errors.Errorf("negative id in list: %d", vt)
+				}
+				out = append(out, uint64(vt))
+			case uint64:
+				out = append(out, vt)
+			default:
+				return nil, errors.Errorf("unsupported list element: %v of %[1]T", v)
+			}
+		}
+		return out, nil
+	}
+}
@@ -0,0 +1,497 @@
+package idk
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/pkg/errors"
+)
+
+// Transform derives the value of a destination field from the values of each
+// record. Transforms are written as
+//
+//	<field> = <expression>
+//
+// where <field> is a header like those accepted by HeaderToField (e.g.
+// "domain__String" or "age_bucket__Int"), and <expression> is a field
+// reference, a string or number literal, or a call of one of the functions
+// below, whose arguments are themselves expressions:
+//
+//	lower(s), upper(s), trim(s)  change the case of, or trim spaces from, s
+//	concat(a, b, ...)            concatenate strings
+//	replace(s, old, new)         replace every occurrence of old in s
+//	split(s, sep)                split s into a set of non-empty strings
+//	domain(u)                    the host name of the URL u
+//	bucket(n, width)             round n down to a multiple of width
+//	regex(s, pattern)            the first submatch (or match) of pattern in s
+//	coalesce(a, b, ...)          the first non-null argument
+//	field(name)                  the field named name, for names which aren't identifiers
+//
+// Field references are resolved against the names of the fields of the
+// source's schema. Functions return null when any argument they depend on
+// is null or can't be converted, so a transform never rejects a record.
+type Transform struct {
+	Field Field
+	expr  transformExpr
+}
+
+// Transforms are applied, in order, to each record before it's ingested.
+// The value of each transform is appended to the record's data, so
+// transforms can't refer to one another.
+type Transforms []*Transform
+
+// ParseTransform parses a transform of the form "<field> = <expression>".
+func ParseTransform(s string, log logger.Logger) (*Transform, error) {
+	i := strings.Index(s, "=")
+	if i < 0 {
+		return nil, errors.Errorf("transform must be of the form <field>=<expression>: %s", s)
+	}
+	field, err := HeaderToField(strings.TrimSpace(s[:i]), log)
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing transform field %s", s[:i])
+	}
+
+	p := &transformParser{src: s[i+1:]}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, errors.Wrapf(err, "parsing transform expression %s", s[i+1:])
+	}
+	if p.skipSpace(); p.pos < len(p.src) {
+		return nil, errors.Errorf("unexpected %q at position %d of transform expression %s", p.src[p.pos:], p.pos, s[i+1:])
+	}
+	return &Transform{Field: field, expr: expr}, nil
+}
+
+// ParseTransforms parses transforms, one per line. Blank lines and lines
+// starting with # are ignored.
+func ParseTransforms(r io.Reader, log logger.Logger) (Transforms, error) {
+	var ts Transforms
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		t, err := ParseTransform(line, log)
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", n)
+		}
+		ts = append(ts, t)
+	}
+	return ts, errors.Wrap(scanner.Err(), "reading transforms")
+}
+
+// Bind resolves the field references of the transforms against schema. It
+// returns the schema of transformed records, and a function which transforms
+// the data of a record. The returned function reuses the slice it returns,
+// so it should not be retained.
+func (ts Transforms) Bind(schema []Field) ([]Field, func(data []interface{}) []interface{}, error) {
+	names := make(map[string]int, len(schema))
+	for i, f := range schema {
+		if _, ok := names[f.Name()]; !ok {
+			names[f.Name()] = i
+		}
+	}
+	for i, f := range schema {
+		if _, ok := names[f.DestName()]; !ok {
+			names[f.DestName()] = i
+		}
+	}
+
+	out := make([]Field, len(schema), len(schema)+len(ts))
+	copy(out, schema)
+	evals := make([]transformEval, len(ts))
+	for i, t := range ts {
+		eval, err := t.expr.bind(names)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "binding transform for %s", t.Field.Name())
+		}
+		evals[i] = eval
+		out = append(out, t.Field)
+	}
+
+	buf := make([]interface{}, len(out))
+	return out, func(data []interface{}) []interface{} {
+		copy(buf, data)
+		for i, eval := range evals {
+			buf[len(data)+i] = eval(data)
+		}
+		return buf
+	}, nil
+}
+
+type transformEval func(data []interface{}) interface{}
+
+type transformExpr interface {
+	bind(names map[string]int) (transformEval, error)
+}
+
+type transformLiteral struct {
+	val interface{}
+}
+
+func (e transformLiteral) bind(names map[string]int) (transformEval, error) {
+	return func([]interface{}) interface{} { return e.val }, nil
+}
+
+type transformFieldRef struct {
+	name string
+}
+
+func (e transformFieldRef) bind(names map[string]int) (transformEval, error) {
+	i, ok := names[e.name]
+	if !ok {
+		return nil, errors.Errorf("unknown field %s", e.name)
+	}
+	return func(data []interface{}) interface{} {
+		if _, ok := data[i].(DeleteSentinel); ok {
+			return nil
+		}
+		return data[i]
+	}, nil
+}
+
+type transformCall struct {
+	fn   transformFunc
+	args []transformExpr
+}
+
+func (e transformCall) bind(names map[string]int) (transformEval, error) {
+	evals := make([]transformEval, len(e.args))
+	for i, arg := range e.args {
+		eval, err := arg.bind(names)
+		if err != nil {
+			return nil, err
+		}
+		evals[i] = eval
+	}
+	return func(data []interface{}) interface{} {
+		args := make([]interface{}, len(evals))
+		for i, eval := range evals {
+			args[i] = eval(data)
+		}
+		return e.fn.call(args)
+	}, nil
+}
+
+// transformFunc is a function which may be called in a transform expression.
+// A max of -1 means any number of arguments.
+type transformFunc struct {
+	min, max int
+	call     func(args []interface{}) interface{}
+}
+
+var transformFuncs = map[string]transformFunc{
+	"lower": {1, 1, stringTransform(strings.ToLower)},
+	"upper": {1, 1, stringTransform(strings.ToUpper)},
+	"trim":  {1, 1, stringTransform(strings.TrimSpace)},
+	"concat": {1, -1, func(args []interface{}) interface{} {
+		var sb strings.Builder
+		found := false
+		for _, arg := range args {
+			if s, ok := transformString(arg); ok {
+				sb.WriteString(s)
+				found = true
+			}
+		}
+		if !found {
+			return nil
+		}
+		return sb.String()
+	}},
+	"replace": {3, 3, func(args []interface{}) interface{} {
+		s, ok1 := transformString(args[0])
+		old, ok2 := transformString(args[1])
+		repl, ok3 := transformString(args[2])
+		if !ok1 || !ok2 || !ok3 {
+			return nil
+		}
+		return strings.ReplaceAll(s, old, repl)
+	}},
+	"split": {2, 2, func(args []interface{}) interface{} {
+		s, ok1 := transformString(args[0])
+		sep, ok2 := transformString(args[1])
+		if !ok1 || !ok2 || sep == "" {
+			return nil
+		}
+		parts := strings.Split(s, sep)
+		set := make([]string, 0, len(parts))
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				set = append(set, part)
+			}
+		}
+		return set
+	}},
+	"domain": {1, 1, func(args []interface{}) interface{} {
+		s, ok := transformString(args[0])
+		if !ok || s == "" {
+			return nil
+		}
+		if !strings.Contains(s, "://") {
+			s = "//" + s
+		}
+		u, err := url.Parse(s)
+		if err != nil || u.Hostname() == "" {
+			return nil
+		}
+		return strings.ToLower(u.Hostname())
+	}},
+	"bucket": {2, 2, func(args []interface{}) interface{} {
+		n, ok1 := transformFloat(args[0])
+		width, ok2 := transformFloat(args[1])
+		if !ok1 || !ok2 || width <= 0 {
+			return nil
+		}
+		b := math.Floor(n/width) * width
+		if b == math.Trunc(b) && math.Abs(b) < math.MaxInt64 {
+			return int64(b)
+		}
+		return b
+	}},
+	"regex": {2, 2, func(args []interface{}) interface{} {
+		s, ok1 := transformString(args[0])
+		pattern, ok2 := transformString(args[1])
+		if !ok1 || !ok2 {
+			return nil
+		}
+		re, err := transformRegexp(pattern)
+		if err != nil {
+			return nil
+		}
+		m := re.FindStringSubmatch(s)
+		switch len(m) {
+		case 0:
+			return nil
+		case 1:
+			return m[0]
+		}
+		return m[1]
+	}},
+	"coalesce": {1, -1, func(args []interface{}) interface{} {
+		for _, arg := range args {
+			if arg != nil {
+				return arg
+			}
+		}
+		return nil
+	}},
+}
+
+// stringTransform returns the call of a transform function which applies fn
+// to its string argument.
+func stringTransform(fn func(string) string) func(args []interface{}) interface{} {
+	return func(args []interface{}) interface{} {
+		s, ok := transformString(args[0])
+		if !ok {
+			return nil
+		}
+		return fn(s)
+	}
+}
+
+// transformString converts a scalar value to a string.
+func transformString(val interface{}) (string, bool) {
+	switch v := val.(type) {
+	case nil:
+		return "", false
+	case string:
+		return v, true
+	case []byte:
+		return string(v), true
+	case []string, []uint64, []interface{}:
+		return "", false
+	}
+	return fmt.Sprintf("%v", val), true
+}
+
+// transformFloat converts a numeric value, or a string containing a number,
+// to a float64.
+func transformFloat(val interface{}) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return f, err == nil
+	case []byte:
+		return transformFloat(string(v))
+	case nil, bool:
+		return 0, false
+	}
+	i, err := toInt64(val)
+	return float64(i), err == nil
+}
+
+var transformRegexps sync.Map // pattern -> *regexp.Regexp or error
+
+func transformRegexp(pattern string) (*regexp.Regexp, error) {
+	if v, ok := transformRegexps.Load(pattern); ok {
+		if re, ok := v.(*regexp.Regexp); ok {
+			return re, nil
+		}
+		return nil, v.(error)
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		transformRegexps.Store(pattern, err)
+		return nil, err
+	}
+	transformRegexps.Store(pattern, re)
+	return re, nil
+}
+
+// transformParser parses transform expressions.
+type transformParser struct {
+	src string
+	pos int
+}
+
+func (p *transformParser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(rune(p.src[p.pos])) {
+		p.pos++
+	}
+}
+
+func (p *transformParser) parseExpr() (transformExpr, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, errors.New("unexpected end of expression")
+	}
+
+	switch c := p.src[p.pos]; {
+	case c == '"' || c == '\'':
+		s, err := p.parseString(c)
+		if err != nil {
+			return nil, err
+		}
+		return transformLiteral{val: s}, nil
+
+	case c == '-' || c == '.' || (c >= '0' && c <= '9'):
+		return p.parseNumber()
+
+	case c == '_' || unicode.IsLetter(rune(c)):
+		start := p.pos
+		for p.pos < len(p.src) && (p.src[p.pos] == '_' || unicode.IsLetter(rune(p.src[p.pos])) || unicode.IsDigit(rune(p.src[p.pos]))) {
+			p.pos++
+		}
+		name := p.src[start:p.pos]
+		if p.skipSpace(); p.pos >= len(p.src) || p.src[p.pos] != '(' {
+			return transformFieldRef{name: name}, nil
+		}
+		p.pos++
+		args, err := p.parseArgs()
+		if err != nil {
+			return nil, errors.Wrapf(err, "arguments of %s", name)
+		}
+		return newTransformCall(name, args)
+	}
+	return nil, errors.Errorf("unexpected %q at position %d", p.src[p.pos:], p.pos)
+}
+
+// newTransformCall returns the call of the named function with args. The
+// field function is resolved here, since it's really a field reference.
+func newTransformCall(name string, args []transformExpr) (transformExpr, error) {
+	if name == "field" {
+		if len(args) == 1 {
+			if lit, ok := args[0].(transformLiteral); ok {
+				if s, ok := lit.val.(string); ok {
+					return transformFieldRef{name: s}, nil
+				}
+			}
+		}
+		return nil, errors.New("field takes a single string argument")
+	}
+
+	fn, ok := transformFuncs[name]
+	if !ok {
+		return nil, errors.Errorf("unknown function %s", name)
+	}
+	if len(args) < fn.min || (fn.max >= 0 && len(args) > fn.max) {
+		return nil, errors.Errorf("wrong number of arguments to %s: %d", name, len(args))
+	}
+	return transformCall{fn: fn, args: args}, nil
+}
+
+func (p *transformParser) parseArgs() ([]transformExpr, error) {
+	var args []transformExpr
+	if p.skipSpace(); p.pos < len(p.src) && p.src[p.pos] == ')' {
+		p.pos++
+		return args, nil
+	}
+	for {
+		arg, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return nil, errors.New("missing )")
+		}
+		switch p.src[p.pos] {
+		case ',':
+			p.pos++
+		case ')':
+			p.pos++
+			return args, nil
+		default:
+			return nil, errors.Errorf("unexpected %q at position %d", p.src[p.pos:], p.pos)
+		}
+	}
+}
+
+// parseString parses a string quoted by quote, in which a backslash escapes
+// the character which follows it.
+func (p *transformParser) parseString(quote byte) (string, error) {
+	var sb strings.Builder
+	for p.pos++; p.pos < len(p.src); p.pos++ {
+		switch c := p.src[p.pos]; c {
+		case quote:
+			p.pos++
+			return sb.String(), nil
+		case '\\':
+			if p.pos++; p.pos < len(p.src) {
+				sb.WriteByte(p.src[p.pos])
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", errors.New("unterminated string")
+}
+
+func (p *transformParser) parseNumber() (transformExpr, error) {
+	start := p.pos
+	if p.src[p.pos] == '-' {
+		p.pos++
+	}
+	for p.pos < len(p.src) && (p.src[p.pos] == '.' || (p.src[p.pos] >= '0' && p.src[p.pos] <= '9')) {
+		p.pos++
+	}
+	num := p.src[start:p.pos]
+	if i, err := strconv.ParseInt(num, 10, 64); err == nil {
+		return transformLiteral{val: i}, nil
+	}
+	f, err := strconv.ParseFloat(num, 64)
+	if err != nil {
+		return nil, errors.Errorf("invalid number %s", num)
+	}
+	return transformLiteral{val: f}, nil
+}
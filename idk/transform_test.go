@@ -0,0 +1,109 @@
+package idk
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+)
+
+func TestTransforms(t *testing.T) {
+	ts, err := ParseTransforms(strings.NewReader(`
+# derived fields
+domain__String = domain(url)
+tags__StringArray = split(lower(tags), ',')
+age_bucket__Int = bucket(age, 10)
+label__String = concat(upper(trim(name)), "-", 'x\'y')
+path__String = regex(url, "https?://[^/]+(/[^?]*)")
+nick__String = coalesce(field("nick name"), name)
+score_bucket__Int = bucket(score, 0.5)
+`), logger.NopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	schema := []Field{
+		StringField{NameVal: "url"},
+		StringField{NameVal: "tags"},
+		IntField{NameVal: "age"},
+		StringField{NameVal: "name"},
+		StringField{NameVal: "nick name"},
+		DecimalField{NameVal: "score", DestNameVal: "points", Scale: 2},
+	}
+	fields, transform, err := ts.Bind(schema)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != len(schema)+len(ts) {
+		t.Fatalf("expected %d fields, got %d", len(schema)+len(ts), len(fields))
+	}
+	if f, ok := fields[len(schema)+1].(StringArrayField); !ok || f.Name() != "tags" {
+		t.Fatalf("unexpected transform field: %#v", fields[len(schema)+1])
+	}
+
+	tests := []struct {
+		data []interface{}
+		exp  []interface{}
+	}{
+		{
+			data: []interface{}{"https://WWW.Example.com:8080/a/b?c=d", "Red, BLUE,,green", int64(37), " bob ", nil, 1.75},
+			exp:  []interface{}{"www.example.com", []string{"red", "blue", "green"}, int64(30), "BOB-x'y", "/a/b", " bob ", 1.5},
+		},
+		{
+			data: []interface{}{"example.org/x", nil, "-3", nil, "bobby", "x"},
+			exp:  []interface{}{"example.org", nil, int64(-10), "-x'y", nil, "bobby", nil},
+		},
+		{
+			data: []interface{}{nil, "", DELETE_SENTINEL, DELETE_SENTINEL, nil, nil},
+			exp:  []interface{}{nil, []string{}, nil, "-x'y", nil, nil, nil},
+		},
+	}
+	for i, test := range tests {
+		got := transform(test.data)
+		if !reflect.DeepEqual(got[:len(schema)], test.data) {
+			t.Errorf("test %d: source values changed: %#v", i, got[:len(schema)])
+		}
+		if !reflect.DeepEqual(got[len(schema):], test.exp) {
+			t.Errorf("test %d: expected:\n%#v\ngot:\n%#v", i, test.exp, got[len(schema):])
+		}
+	}
+
+	// Field references may also be resolved by destination name.
+	ts, err = ParseTransforms(strings.NewReader("p__Int=bucket(points, 1)"), logger.NopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, transform, err = ts.Bind(schema); err != nil {
+		t.Fatal(err)
+	} else if got := transform([]interface{}{nil, nil, nil, nil, nil, 2.5}); got[len(schema)] != int64(2) {
+		t.Fatalf("expected 2, got %#v", got[len(schema)])
+	}
+}
+
+func TestTransformErrors(t *testing.T) {
+	for _, s := range []string{
+		"domain(url)",
+		"a__Nope=url",
+		"a__String=",
+		"a__String=nope(url)",
+		"a__String=lower(url, url)",
+		"a__String=lower(url",
+		"a__String=lower(url) upper(url)",
+		"a__String='unterminated",
+		"a__String=field(url)",
+		"a__String=1.2.3",
+	} {
+		if _, err := ParseTransform(s, logger.NopLogger); err == nil {
+			t.Errorf("expected error parsing %q", s)
+		}
+	}
+
+	tr, err := ParseTransform("a__String=lower(nope)", logger.NopLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := (Transforms{tr}).Bind([]Field{StringField{NameVal: "url"}}); err == nil || !strings.Contains(err.Error(), "unknown field nope") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
@@ -1055,6 +1055,10 @@ type IndexInfo struct {
 	Options        IndexOptions `json:"options"`
 	Fields         []*FieldInfo `json:"fields"`
 	ShardWidth     uint64       `json:"shardWidth"`
+
+	// ShardTiers reports where each shard is stored when tiered storage
+	// is enabled. Only included with view details.
+	ShardTiers []ShardTierInfo `json:"shardTiers,omitempty"`
 }
 
 // Field returns the FieldInfo the provided field name. If the field does not
@@ -352,6 +352,39 @@ func (c *InternalClient) AvailableShards(ctx context.Context, indexName string)
 	return rsp.Shards, nil
 }
 
+// ShardVersionsNode returns the version of every local shard of an index on
+// the specified node.
+func (c *InternalClient) ShardVersionsNode(ctx context.Context, uri *pnet.URI, indexName string) (map[uint64]int64, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "InternalClient.ShardVersionsNode")
+	defer span.Finish()
+
+	// Execute request against the host.
+	u := uri.Path(fmt.Sprintf("%s/internal/index/%s/shard-versions", c.prefix(), indexName))
+
+	// Build request.
+	req, err := http.NewRequest("GET", u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	req.Header.Set("User-Agent", "pilosa/"+Version)
+	req.Header.Set("Accept", "application/json")
+	AddAuthToken(ctx, &req.Header)
+
+	// Execute request.
+	resp, err := c.executeRequest(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var rsp getIndexShardVersionsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&rsp); err != nil {
+		return nil, fmt.Errorf("json decode: %s", err)
+	}
+	return rsp.Shards, nil
+}
+
 // SchemaNode returns all index and field schema information from the specified
 // node.
 func (c *InternalClient) SchemaNode(ctx context.Context, uri *pnet.URI, views bool) ([]*IndexInfo, error) {
@@ -2152,11 +2185,17 @@ func (c *InternalClient) ImportFieldKeys(ctx context.Context, uri *pnet.URI, ind
 
 // ShardReader returns a reader that provides a snapshot of the current shard RBF data.
 func (c *InternalClient) ShardReader(ctx context.Context, index string, shard uint64) (io.ReadCloser, error) {
+	return c.ShardReaderNode(ctx, c.defaultURI, index, shard)
+}
+
+// ShardReaderNode returns a reader that provides a snapshot of the current
+// shard RBF data on the specified node.
+func (c *InternalClient) ShardReaderNode(ctx context.Context, uri *pnet.URI, index string, shard uint64) (io.ReadCloser, error) {
 	span, ctx := tracing.StartSpanFromContext(ctx, "InternalClient.ShardReader")
 	defer span.Finish()
 
 	// Execute request against the host.
-	u := fmt.Sprintf("%s%s/internal/index/%s/shard/%d/snapshot", c.defaultURI, c.prefix(), index, shard)
+	u := uri.Path(fmt.Sprintf("%s/internal/index/%s/shard/%d/snapshot", c.prefix(), index, shard))
 
 	// Build request.
 	req, err := http.NewRequest("GET", u, nil)
@@ -2434,6 +2473,12 @@ func (c *InternalClient) getDiskUsage(ctx context.Context, index string) (DiskUs
 		}
 
 		sum.Usage += rsp.Usage
+		if rsp.Fragmentation != nil {
+			if sum.Fragmentation == nil {
+				sum.Fragmentation = &Fragmentation{}
+			}
+			sum.Fragmentation.add(*rsp.Fragmentation)
+		}
 	}
 
 	return sum, nil
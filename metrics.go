@@ -54,6 +54,18 @@ const (
 	MetricPqlQueries                      = "pql_queries_total"
 	MetricSqlQueries                      = "sql_queries_total"
 	MetricDeleteDataframe                 = "delete_dataframe"
+	MetricRBFChecksumMismatch             = "rbf_checksum_mismatch_total"
+	MetricRBFShardRepair                  = "rbf_shard_repair_total"
+	MetricRBFScrubDurationSeconds         = "rbf_scrub_duration_seconds"
+	MetricRBFCompactedPages               = "rbf_compacted_pages_total"
+	MetricRBFCompactionDurationSeconds    = "rbf_compaction_duration_seconds"
+	MetricTieredShardOffloads             = "tiered_shard_offloads_total"
+	MetricTieredShardFetches              = "tiered_shard_fetches_total"
+	MetricReadReplicaShardSyncs           = "read_replica_shard_syncs_total"
+	MetricReadReplicaStalenessSeconds     = "read_replica_staleness_seconds"
+	MetricQueryResultCacheHits            = "query_result_cache_hits_total"
+	MetricQueryResultCacheMisses          = "query_result_cache_misses_total"
+	MetricQueryResultCacheEntries         = "query_result_cache_entries"
 )
 
 const (
@@ -252,6 +264,125 @@ var CounterDeleteAvailableShard = prometheus.NewCounterVec(
 	},
 )
 
+// rbf related
+
+var CounterRBFChecksumMismatch = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricRBFChecksumMismatch,
+		Help:      "Number of shards found by the scrubber to have pages which do not match their checksums.",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterRBFShardRepair = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricRBFShardRepair,
+		Help:      "Number of attempts to repair a corrupt shard from a replica.",
+	},
+	[]string{
+		"index",
+		"result",
+	},
+)
+
+var SummaryRBFScrubDurationSeconds = prometheus.NewSummary(
+	prometheus.SummaryOpts{
+		Namespace:  "pilosa",
+		Name:       MetricRBFScrubDurationSeconds,
+		Help:       "Time taken to verify the page checksums of every shard.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	},
+)
+
+var CounterRBFCompactedPages = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricRBFCompactedPages,
+		Help:      "Number of pages returned to the filesystem by RBF compaction.",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var SummaryRBFCompactionDurationSeconds = prometheus.NewSummary(
+	prometheus.SummaryOpts{
+		Namespace:  "pilosa",
+		Name:       MetricRBFCompactionDurationSeconds,
+		Help:       "Time taken to check every shard against the compaction threshold and compact it.",
+		Objectives: map[float64]float64{0.5: 0.05, 0.9: 0.01, 0.99: 0.001},
+	},
+)
+
+var CounterTieredShardOffloads = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricTieredShardOffloads,
+		Help:      "Number of shards offloaded to the object store.",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterTieredShardFetches = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricTieredShardFetches,
+		Help:      "Number of shards fetched back from the object store.",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterReadReplicaShardSyncs = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricReadReplicaShardSyncs,
+		Help:      "Number of shards copied to a read replica.",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var GaugeReadReplicaStalenessSeconds = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricReadReplicaStalenessSeconds,
+		Help:      "Time since a read replica was last fully synced.",
+	},
+)
+
+var CounterQueryResultCacheHits = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryResultCacheHits,
+		Help:      "Number of shard results served from the query result cache.",
+	},
+)
+
+var CounterQueryResultCacheMisses = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryResultCacheMisses,
+		Help:      "Number of shard results computed because they were not in the query result cache.",
+	},
+)
+
+var GaugeQueryResultCacheEntries = prometheus.NewGauge(
+	prometheus.GaugeOpts{
+		Namespace: "pilosa",
+		Name:      MetricQueryResultCacheEntries,
+		Help:      "Number of shard results held by the query result cache.",
+	},
+)
+
 var CounterExclusiveTransactionRequest = prometheus.NewCounter(
 	prometheus.CounterOpts{
 		Namespace: "pilosa",
@@ -763,6 +894,50 @@ var CounterQueryPercentileTotal = prometheus.NewCounterVec(
 	},
 )
 
+var CounterQueryFunnelTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      "query_funnel_total",
+		Help:      "TODO",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterQueryRetentionTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      "query_retention_total",
+		Help:      "TODO",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterQuerySampleTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      "query_sample_total",
+		Help:      "TODO",
+	},
+	[]string{
+		"index",
+	},
+)
+
+var CounterQuerySimilarTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Namespace: "pilosa",
+		Name:      "query_similar_total",
+		Help:      "TODO",
+	},
+	[]string{
+		"index",
+	},
+)
+
 var CounterQueryDeleteTotal = prometheus.NewCounterVec(
 	prometheus.CounterOpts{
 		Namespace: "pilosa",
@@ -1029,6 +1204,10 @@ func init() {
 	prometheus.MustRegister(CounterQueryConstRowTotal)
 	prometheus.MustRegister(CounterQueryLimitTotal)
 	prometheus.MustRegister(CounterQueryPercentileTotal)
+	prometheus.MustRegister(CounterQueryFunnelTotal)
+	prometheus.MustRegister(CounterQueryRetentionTotal)
+	prometheus.MustRegister(CounterQuerySampleTotal)
+	prometheus.MustRegister(CounterQuerySimilarTotal)
 	prometheus.MustRegister(CounterQueryDeleteTotal)
 	prometheus.MustRegister(CounterQuerySortTotal)
 	prometheus.MustRegister(CounterQueryApplyTotal)
@@ -1049,4 +1228,18 @@ func init() {
 	// index related
 	prometheus.MustRegister(GaugeIndexMaxShard)
 
+	// rbf related
+	prometheus.MustRegister(CounterRBFChecksumMismatch)
+	prometheus.MustRegister(CounterRBFShardRepair)
+	prometheus.MustRegister(CounterRBFCompactedPages)
+	prometheus.MustRegister(SummaryRBFCompactionDurationSeconds)
+	prometheus.MustRegister(CounterTieredShardOffloads)
+	prometheus.MustRegister(CounterTieredShardFetches)
+	prometheus.MustRegister(CounterReadReplicaShardSyncs)
+	prometheus.MustRegister(GaugeReadReplicaStalenessSeconds)
+	prometheus.MustRegister(CounterQueryResultCacheHits)
+	prometheus.MustRegister(CounterQueryResultCacheMisses)
+	prometheus.MustRegister(GaugeQueryResultCacheEntries)
+	prometheus.MustRegister(SummaryRBFScrubDurationSeconds)
+
 }
@@ -0,0 +1,242 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+// Package objectstore provides access to S3-compatible object storage.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/client"
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+)
+
+// ErrNotFound is returned when an object does not exist.
+var ErrNotFound = errors.New("object not found")
+
+// ErrExists is returned by PutIfAbsent when the object already exists.
+var ErrExists = errors.New("object exists")
+
+// ObjectInfo describes an object in a Store.
+type ObjectInfo struct {
+	Key  string
+	Size int64
+}
+
+// Store is a flat namespace of objects addressed by key.
+type Store interface {
+	// Put writes the contents of r to the object at key, replacing any
+	// existing object.
+	Put(ctx context.Context, key string, r io.Reader) error
+
+	// Get returns a reader for the object at key. Returns ErrNotFound if
+	// the object does not exist.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+
+	// Delete removes the object at key. Deleting an object which does not
+	// exist is not an error.
+	Delete(ctx context.Context, key string) error
+
+	// PutIfAbsent writes data to the object at key only if no object exists
+	// at key, in which case it returns ErrExists. The check and the write
+	// are atomic.
+	PutIfAbsent(ctx context.Context, key string, data []byte) error
+
+	// List returns the objects whose keys begin with prefix, sorted by key.
+	List(ctx context.Context, prefix string) ([]ObjectInfo, error)
+}
+
+// Config describes an S3 bucket. An empty Bucket disables object storage.
+type Config struct {
+	// Bucket is the name of the bucket objects are stored in.
+	Bucket string `toml:"bucket"`
+
+	// Prefix is prepended to every object key.
+	Prefix string `toml:"prefix"`
+
+	// Region is the region of the bucket. Uses the AWS default if blank.
+	Region string `toml:"region"`
+
+	// Endpoint overrides the S3 endpoint, for use with S3-compatible
+	// stores such as MinIO. Path-style addressing is used when set.
+	Endpoint string `toml:"endpoint"`
+
+	// AccessKeyID & SecretAccessKey are static credentials. The default AWS
+	// credential chain is used if they are blank.
+	AccessKeyID     string `toml:"access-key-id"`
+	SecretAccessKey string `toml:"secret-access-key"`
+}
+
+// Ensure type implements interface.
+var _ Store = (*S3Store)(nil)
+
+// S3Store is a Store backed by an S3 bucket.
+type S3Store struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+	prefix   string
+}
+
+// NewS3Store returns a Store for the bucket described by cfg.
+func NewS3Store(cfg Config) (*S3Store, error) {
+	if cfg.Bucket == "" {
+		return nil, errors.New("bucket required")
+	}
+
+	config := &aws.Config{
+		// retry on ephemeral AWS errors
+		Retryer: client.DefaultRetryer{NumMaxRetries: 10},
+	}
+	if cfg.Region != "" {
+		config.Region = aws.String(cfg.Region)
+	}
+	if cfg.Endpoint != "" {
+		config.Endpoint = aws.String(cfg.Endpoint)
+		config.S3ForcePathStyle = aws.Bool(true)
+		if cfg.Region == "" {
+			config.Region = aws.String("us-east-1")
+		}
+	}
+	if cfg.AccessKeyID != "" {
+		config.Credentials = credentials.NewStaticCredentials(cfg.AccessKeyID, cfg.SecretAccessKey, "")
+	}
+
+	sess, err := session.NewSession(config)
+	if err != nil {
+		return nil, err
+	}
+	return &S3Store{
+		client:   s3.New(sess),
+		uploader: s3manager.NewUploader(sess),
+		bucket:   cfg.Bucket,
+		prefix:   strings.Trim(cfg.Prefix, "/"),
+	}, nil
+}
+
+func (s *S3Store) key(key string) *string {
+	if s.prefix == "" {
+		return aws.String(key)
+	}
+	return aws.String(path.Join(s.prefix, key))
+}
+
+// Put uploads r to key. Large objects are uploaded in parts.
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader) error {
+	_, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+		Body:   r,
+	})
+	return err
+}
+
+// Get returns a reader for the object at key.
+func (s *S3Store) Get(ctx context.Context, key string) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+	})
+	if isNotFound(err) {
+		return nil, ErrNotFound
+	} else if err != nil {
+		return nil, err
+	}
+	return out.Body, nil
+}
+
+// Delete removes the object at key.
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+	})
+	if isNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// PutIfAbsent uploads data to key using a conditional write, which requires
+// that the store supports the If-None-Match header on PutObject.
+func (s *S3Store) PutIfAbsent(ctx context.Context, key string, data []byte) error {
+	req, _ := s.client.PutObjectRequest(&s3.PutObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    s.key(key),
+		Body:   bytes.NewReader(data),
+	})
+	req.SetContext(ctx)
+	req.HTTPRequest.Header.Set("If-None-Match", "*")
+	if err := req.Send(); isPreconditionFailed(err) {
+		return ErrExists
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// List returns the objects under prefix. Keys are returned relative to the
+// store's prefix.
+func (s *S3Store) List(ctx context.Context, prefix string) ([]ObjectInfo, error) {
+	full := aws.StringValue(s.key(prefix))
+	// path.Join strips a trailing slash, which is significant in a prefix.
+	if strings.HasSuffix(prefix, "/") && !strings.HasSuffix(full, "/") {
+		full += "/"
+	}
+
+	var infos []ObjectInfo
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket: aws.String(s.bucket),
+		Prefix: aws.String(full),
+	}, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
+		for _, obj := range page.Contents {
+			key := aws.StringValue(obj.Key)
+			if s.prefix != "" {
+				key = strings.TrimPrefix(key, s.prefix+"/")
+			}
+			infos = append(infos, ObjectInfo{
+				Key:  key,
+				Size: aws.Int64Value(obj.Size),
+			})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return infos, nil
+}
+
+func isPreconditionFailed(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+	switch aerr.Code() {
+	case "PreconditionFailed", "ConditionalRequestConflict":
+		return true
+	}
+	return false
+}
+
+func isNotFound(err error) bool {
+	var aerr awserr.Error
+	if !errors.As(err, &aerr) {
+		return false
+	}
+	switch aerr.Code() {
+	case s3.ErrCodeNoSuchKey, "NotFound":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package objectstore_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+	"github.com/featurebasedb/featurebase/v3/objectstore/objectstoretest"
+)
+
+func TestS3Store(t *testing.T) {
+	ctx := context.Background()
+	srv := objectstoretest.NewServer(t)
+
+	cfg := srv.Config("bkt")
+	cfg.Prefix = "/node0/"
+	s, err := objectstore.NewS3Store(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("NotFound", func(t *testing.T) {
+		if _, err := s.Get(ctx, "missing"); err != objectstore.ErrNotFound {
+			t.Fatalf("unexpected error: %v", err)
+		} else if err := s.Delete(ctx, "missing"); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("PutGetDelete", func(t *testing.T) {
+		if err := s.Put(ctx, "a/b", bytes.NewReader([]byte("hello"))); err != nil {
+			t.Fatal(err)
+		}
+		if got := srv.Keys("bkt"); !reflect.DeepEqual(got, []string{"node0/a/b"}) {
+			t.Fatalf("unexpected keys: %v", got)
+		}
+		mustGet(t, s, "a/b", []byte("hello"))
+
+		if err := s.Delete(ctx, "a/b"); err != nil {
+			t.Fatal(err)
+		} else if _, err := s.Get(ctx, "a/b"); err != objectstore.ErrNotFound {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+
+	t.Run("PutIfAbsent", func(t *testing.T) {
+		if err := s.PutIfAbsent(ctx, "lock", []byte("a")); err != nil {
+			t.Fatal(err)
+		} else if err := s.PutIfAbsent(ctx, "lock", []byte("b")); err != objectstore.ErrExists {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		mustGet(t, s, "lock", []byte("a"))
+
+		if err := s.Delete(ctx, "lock"); err != nil {
+			t.Fatal(err)
+		} else if err := s.PutIfAbsent(ctx, "lock", []byte("b")); err != nil {
+			t.Fatal(err)
+		}
+		mustGet(t, s, "lock", []byte("b"))
+	})
+
+	t.Run("List", func(t *testing.T) {
+		for _, key := range []string{"l/1/b", "l/1/a", "l/10/a", "l/2"} {
+			if err := s.Put(ctx, key, bytes.NewReader([]byte(key))); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		infos, err := s.List(ctx, "l/1/")
+		if err != nil {
+			t.Fatal(err)
+		} else if want := []objectstore.ObjectInfo{{Key: "l/1/a", Size: 5}, {Key: "l/1/b", Size: 5}}; !reflect.DeepEqual(infos, want) {
+			t.Fatalf("unexpected objects: %v", infos)
+		}
+
+		if infos, err := s.List(ctx, "l/1"); err != nil {
+			t.Fatal(err)
+		} else if len(infos) != 3 {
+			t.Fatalf("unexpected objects: %v", infos)
+		}
+
+		if infos, err := s.List(ctx, "missing/"); err != nil {
+			t.Fatal(err)
+		} else if len(infos) != 0 {
+			t.Fatalf("unexpected objects: %v", infos)
+		}
+	})
+
+	// Objects larger than the part size are uploaded in multiple parts.
+	t.Run("Multipart", func(t *testing.T) {
+		data := make([]byte, 12<<20)
+		rand.New(rand.NewSource(0)).Read(data)
+		if err := s.Put(ctx, "big", io.MultiReader(bytes.NewReader(data))); err != nil {
+			t.Fatal(err)
+		}
+		mustGet(t, s, "big", data)
+	})
+}
+
+func mustGet(tb testing.TB, s objectstore.Store, key string, want []byte) {
+	tb.Helper()
+	rc, err := s.Get(context.Background(), key)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	defer rc.Close()
+	if got, err := io.ReadAll(rc); err != nil {
+		tb.Fatal(err)
+	} else if !bytes.Equal(got, want) {
+		tb.Fatalf("unexpected object: got %d bytes, want %d", len(got), len(want))
+	}
+}
@@ -0,0 +1,229 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+// Package objectstoretest provides an in-memory stand-in for an S3-compatible
+// object store, for use in tests.
+package objectstoretest
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+)
+
+// Server is an HTTP server which implements the subset of the S3 API used by
+// objectstore.S3Store, using path-style addressing. Buckets are created
+// implicitly.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	objects map[string][]byte // bucket/key -> data
+	uploads map[string]map[int][]byte
+	nextID  int
+}
+
+// NewServer returns a running Server. It is closed when the test completes.
+func NewServer(tb testing.TB) *Server {
+	s := &Server{
+		objects: make(map[string][]byte),
+		uploads: make(map[string]map[int][]byte),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	tb.Cleanup(s.Close)
+	return s
+}
+
+// Config returns a Config for bucket on the server.
+func (s *Server) Config(bucket string) objectstore.Config {
+	return objectstore.Config{
+		Bucket:          bucket,
+		Endpoint:        s.URL,
+		AccessKeyID:     "test",
+		SecretAccessKey: "test",
+	}
+}
+
+// Keys returns the sorted keys of all objects in bucket.
+func (s *Server) Keys(bucket string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var keys []string
+	for k := range s.objects {
+		if strings.HasPrefix(k, bucket+"/") {
+			keys = append(keys, strings.TrimPrefix(k, bucket+"/"))
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.TrimPrefix(r.URL.Path, "/")
+	q := r.URL.Query()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !strings.Contains(name, "/") {
+		if r.Method == http.MethodGet && q.Get("list-type") == "2" {
+			s.list(w, name, q.Get("prefix"))
+			return
+		}
+		writeError(w, http.StatusNotImplemented, "NotImplemented", "bucket operations are not supported")
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodPost && q.Has("uploads"):
+		s.nextID++
+		id := strconv.Itoa(s.nextID)
+		s.uploads[id] = make(map[int][]byte)
+		bucket, key, _ := strings.Cut(name, "/")
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: key, UploadId: id})
+
+	case r.Method == http.MethodPut && q.Has("uploadId"):
+		parts, ok := s.uploads[q.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload", "upload not found")
+			return
+		}
+		n, err := strconv.Atoi(q.Get("partNumber"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "InvalidArgument", "invalid part number")
+			return
+		}
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		parts[n] = buf
+		w.Header().Set("ETag", etag(buf))
+
+	case r.Method == http.MethodPost && q.Has("uploadId"):
+		parts, ok := s.uploads[q.Get("uploadId")]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchUpload", "upload not found")
+			return
+		}
+		nums := make([]int, 0, len(parts))
+		for n := range parts {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+		var buf bytes.Buffer
+		for _, n := range nums {
+			buf.Write(parts[n])
+		}
+		delete(s.uploads, q.Get("uploadId"))
+		s.objects[name] = buf.Bytes()
+		bucket, key, _ := strings.Cut(name, "/")
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: bucket, Key: key, ETag: etag(buf.Bytes())})
+
+	case r.Method == http.MethodDelete && q.Has("uploadId"):
+		delete(s.uploads, q.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+
+	case r.Method == http.MethodPut:
+		if _, ok := s.objects[name]; ok && r.Header.Get("If-None-Match") == "*" {
+			writeError(w, http.StatusPreconditionFailed, "PreconditionFailed", "At least one of the pre-conditions you specified did not hold")
+			return
+		}
+		buf, err := io.ReadAll(r.Body)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "IncompleteBody", err.Error())
+			return
+		}
+		s.objects[name] = buf
+		w.Header().Set("ETag", etag(buf))
+
+	case r.Method == http.MethodGet, r.Method == http.MethodHead:
+		buf, ok := s.objects[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, "NoSuchKey", "The specified key does not exist.")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(buf)))
+		w.Header().Set("ETag", etag(buf))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(buf)
+		}
+
+	case r.Method == http.MethodDelete:
+		delete(s.objects, name)
+		w.WriteHeader(http.StatusNoContent)
+
+	default:
+		writeError(w, http.StatusNotImplemented, "NotImplemented", fmt.Sprintf("%s is not supported", r.Method))
+	}
+}
+
+// list writes a single page listing the objects in bucket under prefix.
+func (s *Server) list(w http.ResponseWriter, bucket, prefix string) {
+	type content struct {
+		Key  string
+		Size int
+		ETag string
+	}
+	result := struct {
+		XMLName     xml.Name `xml:"ListBucketResult"`
+		Name        string
+		Prefix      string
+		KeyCount    int
+		IsTruncated bool
+		Contents    []content
+	}{Name: bucket, Prefix: prefix}
+
+	for k, buf := range s.objects {
+		if key := strings.TrimPrefix(k, bucket+"/"); key != k && strings.HasPrefix(key, prefix) {
+			result.Contents = append(result.Contents, content{Key: key, Size: len(buf), ETag: etag(buf)})
+		}
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	result.KeyCount = len(result.Contents)
+	writeXML(w, result)
+}
+
+func etag(buf []byte) string {
+	sum := md5.Sum(buf)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	_ = xml.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, code, msg string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	_ = xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: msg})
+}
@@ -535,6 +535,16 @@ var callInfoByFunc = map[string]callInfo{
 		},
 		callType: PrecallGlobal,
 	},
+	"Sample": {
+		allowUnknown: false,
+		prototypes: map[string]interface{}{
+			"n":        int64(0),
+			"fraction": nil,
+			"seed":     int64(0),
+			"_total":   int64(0),
+		},
+		callType: PrecallGlobal,
+	},
 	"Xor": {allowUnknown: false},
 
 	"ConstRow": {
@@ -573,6 +583,37 @@ var callInfoByFunc = map[string]callInfo{
 			"nth":    nil,
 		},
 	},
+	"Funnel": {
+		allowUnknown: false,
+		prototypes: map[string]interface{}{
+			"window": "",
+			"from":   nil,
+			"to":     nil,
+			"filter": nil,
+		},
+	},
+	"Retention": {
+		allowUnknown: false,
+		prototypes: map[string]interface{}{
+			"cohort":   nil,
+			"activity": nil,
+			"period":   "",
+			"periods":  int64(0),
+			"from":     nil,
+			"filter":   nil,
+		},
+	},
+	"Similar": {
+		allowUnknown: false,
+		prototypes: map[string]interface{}{
+			"field":  stringOrVariable,
+			"_field": stringOrVariable,
+			"metric": "",
+			"n":      int64(0),
+			"filter": nil,
+			"ids":    nil,
+		},
+	},
 	// special cases:
 	"Clear": {
 		allowUnknown: true,
@@ -596,6 +637,7 @@ var callInfoByFunc = map[string]callInfo{
 		allowUnknown: false,
 		prototypes: map[string]interface{}{
 			"shards": nil,
+			"cache":  false,
 		},
 	},
 	"Set": {
@@ -9,6 +9,7 @@ import (
 	"os"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/featurebasedb/featurebase/v3/rbf"
 	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
@@ -32,11 +33,29 @@ type RbfDBWrapper struct {
 	// make Close() idempotent, avoiding panic on double Close()
 	closed bool
 
+	// generation identifies the files the database was last opened from.
+	// It's accessed atomically, since CloseDB holds muDb until open
+	// transactions finish.
+	generation uint64
+
 	//DeleteEmptyContainer bool // needed for roaring compat?
 
 	doAllocZero bool
 }
 
+// rbfGeneration is the last generation given to a database when it was
+// opened.
+var rbfGeneration uint64
+
+// Generation returns a number which changes whenever the database is opened,
+// and is never the same for two openings, so that the versions of its data
+// (its WAL IDs) are only compared with versions of the same files. The files
+// may be replaced while the database is closed, such as when a shard is
+// restored.
+func (w *RbfDBWrapper) Generation() uint64 {
+	return atomic.LoadUint64(&w.generation)
+}
+
 func (w *RbfDBWrapper) Path() string {
 	return w.path
 }
@@ -158,6 +177,7 @@ func (r *rbfDBRegistrar) OpenDBWrapper(path string, doAllocZero bool, cfg *stora
 	if err != nil {
 		panic(fmt.Sprintf("cannot open rbfDB at path '%v': '%v'", path, err))
 	}
+	atomic.StoreUint64(&w.generation, atomic.AddUint64(&rbfGeneration, 1))
 	return w, nil
 }
 
@@ -586,6 +606,7 @@ func (w *RbfDBWrapper) OpenDB() error {
 		return err
 	}
 	w.closed = false
+	atomic.StoreUint64(&w.generation, atomic.AddUint64(&rbfGeneration, 1))
 	return nil
 }
 
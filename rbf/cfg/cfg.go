@@ -3,13 +3,18 @@
 package cfg
 
 import (
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/encryption"
 	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/toml"
 	"github.com/spf13/pflag"
 )
 
 const (
 	DefaultMinWALCheckpointSize = 1 * (1 << 20) // 1MB
 	DefaultMaxWALCheckpointSize = DefaultMaxWALSize / 2
+	DefaultCompactionInterval   = toml.Duration(10 * time.Minute)
 )
 
 // Config defines externally configurable rbf options.
@@ -46,6 +51,29 @@ type Config struct {
 
 	// The maximum number of bits to be deleted in a single transaction default(65536)
 	MaxDelete int `toml:"max-delete"`
+
+	// Checksums enables CRC32C checksums for every page. New databases are
+	// created with checksums and existing databases are upgraded when opened.
+	// Once enabled for a database, checksums are always maintained.
+	Checksums bool `toml:"checksums"`
+
+	// ScrubInterval is how often every database is read in the background
+	// and verified against its page checksums. Zero disables scrubbing.
+	ScrubInterval toml.Duration `toml:"scrub-interval"`
+
+	// CompactionThreshold is the fraction of free pages at which a database
+	// is compacted in the background to return space to the filesystem.
+	// Zero disables background compaction.
+	CompactionThreshold float64 `toml:"compaction-threshold"`
+
+	// CompactionInterval is how often databases are checked against the
+	// compaction threshold.
+	CompactionInterval toml.Duration `toml:"compaction-interval"`
+
+	// Cipher encrypts every page written to the data file & WAL. Existing
+	// plaintext pages are encrypted when the database is opened. It cannot
+	// be set from toml; see the server's encryption configuration.
+	Cipher *encryption.Cipher `toml:"-"`
 }
 
 func NewDefaultConfig() *Config {
@@ -57,6 +85,7 @@ func NewDefaultConfig() *Config {
 		FsyncEnabled:         true,
 		FsyncWALEnabled:      true,
 		MaxDelete:            DefaultMaxDelete,
+		CompactionInterval:   DefaultCompactionInterval,
 
 		// CI passed with 20. 50 was too big for CI, even on X-large instances.
 		// For now we default to 0, which means use sync.Pool.
@@ -82,5 +111,9 @@ func (cfg *Config) DefineFlags(flags *pflag.FlagSet, prefix string) {
 	// renamed from --rbf-fsync to just --fsync because now it applies to all Tx backends.
 	flags.BoolVar(&cfg.FsyncEnabled, pre("fsync"), default0.FsyncEnabled, "enable fsync fully safe flush-to-disk")
 	flags.BoolVar(&cfg.FsyncWALEnabled, pre("fsync-wal"), default0.FsyncWALEnabled, "enable fsync on write-ahead log")
+	flags.BoolVar(&cfg.Checksums, pre("rbf.checksums"), default0.Checksums, "enable CRC32C page checksums on RBF databases, upgrading existing databases on open")
+	flags.DurationVar((*time.Duration)(&cfg.ScrubInterval), pre("rbf.scrub-interval"), time.Duration(default0.ScrubInterval), "interval at which RBF databases are verified against their page checksums. 0 to disable.")
+	flags.Float64Var(&cfg.CompactionThreshold, pre("rbf.compaction-threshold"), default0.CompactionThreshold, "fraction of free pages at which RBF databases are compacted in the background. 0 to disable.")
+	flags.DurationVar((*time.Duration)(&cfg.CompactionInterval), pre("rbf.compaction-interval"), time.Duration(default0.CompactionInterval), "interval at which RBF databases are checked against the compaction threshold")
 	flags.Int64Var(&cfg.CursorCacheSize, pre("rbf.cursor-cache-size"), default0.CursorCacheSize, "how big a Cursor arena to maintain. 0 means use sync.Pool with dynamic sizing. Note that <= 20 is needed to pass CI. Controls the memory footprint of rbf.")
 }
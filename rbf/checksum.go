@@ -0,0 +1,360 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package rbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+)
+
+// ChecksumFileExt is the extension appended to the data & WAL file paths to
+// name the files holding their page checksums.
+const ChecksumFileExt = ".crc"
+
+// checksumSize is the size of a single page checksum entry, in bytes.
+const checksumSize = 4
+
+// ErrChecksumMismatch is returned when a page does not match its stored
+// checksum. Errors of type *ChecksumError match this value with errors.Is().
+var ErrChecksumMismatch = errors.New("rbf: page checksum mismatch")
+
+var crc32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// pageChecksum returns the CRC32C of a page.
+func pageChecksum(page []byte) uint32 {
+	return crc32.Checksum(page, crc32cTable)
+}
+
+// ChecksumError describes a page whose contents do not match its checksum.
+type ChecksumError struct {
+	Pgno uint32 // page number in the data file
+	WAL  bool   // true if the page was read from the WAL
+	Want uint32 // stored checksum
+	Got  uint32 // checksum of the page contents
+}
+
+func (e *ChecksumError) Error() string {
+	src := "data"
+	if e.WAL {
+		src = "wal"
+	}
+	return fmt.Sprintf("rbf: page checksum mismatch: pgno=%d src=%s want=%08x got=%08x", e.Pgno, src, e.Want, e.Got)
+}
+
+// Is allows errors.Is(err, ErrChecksumMismatch) to match a *ChecksumError.
+func (e *ChecksumError) Is(target error) bool { return target == ErrChecksumMismatch }
+
+// checksumFile is an array of CRC32C page checksums, indexed by page number
+// for the data file or by page offset for the WAL. Bitmap pages use every
+// byte of the page so checksums cannot be stored inline; instead they are
+// kept in memory and mirrored to a side file next to the page file.
+type checksumFile struct {
+	mu   sync.RWMutex
+	file *os.File
+	sums []uint32
+
+	// range of entries changed since the last write
+	dirtyMin, dirtyMax int
+}
+
+// openChecksumFile opens or creates the checksum file at path and reads
+// all existing entries into memory.
+func openChecksumFile(path string) (*checksumFile, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open checksum file: %w", err)
+	}
+	buf, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read checksum file: %w", err)
+	}
+
+	f := &checksumFile{
+		file: file,
+		sums: make([]uint32, len(buf)/checksumSize),
+	}
+	for i := range f.sums {
+		f.sums[i] = binary.BigEndian.Uint32(buf[i*checksumSize:])
+	}
+	f.resetDirty()
+	return f, nil
+}
+
+// Close closes the underlying file.
+func (f *checksumFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// len returns the number of entries.
+func (f *checksumFile) len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.sums)
+}
+
+// verify checks page against the checksum stored at index i. Pages without
+// a stored checksum are not verified.
+func (f *checksumFile) verify(i int, page []byte) (want, got uint32, ok bool) {
+	f.mu.RLock()
+	if i >= len(f.sums) {
+		f.mu.RUnlock()
+		return 0, 0, true
+	}
+	want = f.sums[i]
+	f.mu.RUnlock()
+
+	got = pageChecksum(page)
+	return want, got, want == got
+}
+
+// set updates the checksum at index i in memory, growing the array if needed.
+// The change is persisted on the next call to sync.
+func (f *checksumFile) set(i int, sum uint32) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.sums) <= i {
+		f.sums = append(f.sums, 0)
+	}
+	f.sums[i] = sum
+	if i < f.dirtyMin {
+		f.dirtyMin = i
+	}
+	if i > f.dirtyMax {
+		f.dirtyMax = i
+	}
+}
+
+// truncate removes all entries at index n and above.
+func (f *checksumFile) truncate(n int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if n < len(f.sums) {
+		f.sums = f.sums[:n]
+	}
+	if f.dirtyMax >= n {
+		f.dirtyMax = n - 1
+	}
+	return f.file.Truncate(int64(n) * checksumSize)
+}
+
+// sync writes all changed entries to disk and optionally fsyncs the file.
+func (f *checksumFile) sync(fsync bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.dirtyMin <= f.dirtyMax {
+		buf := make([]byte, (f.dirtyMax-f.dirtyMin+1)*checksumSize)
+		for i, sum := range f.sums[f.dirtyMin : f.dirtyMax+1] {
+			binary.BigEndian.PutUint32(buf[i*checksumSize:], sum)
+		}
+		if _, err := f.file.WriteAt(buf, int64(f.dirtyMin)*checksumSize); err != nil {
+			return fmt.Errorf("write checksum file: %w", err)
+		}
+		f.resetDirty()
+	}
+
+	if fsync {
+		if err := f.file.Sync(); err != nil {
+			return fmt.Errorf("sync checksum file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f *checksumFile) resetDirty() {
+	f.dirtyMin, f.dirtyMax = int(^uint(0)>>1), -1
+}
+
+// ChecksumsEnabled returns true if the database maintains page checksums.
+func (db *DB) ChecksumsEnabled() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.checksums != nil
+}
+
+// DataChecksumPath returns the path to the page checksum file for the data file.
+func (db *DB) DataChecksumPath() string {
+	return db.DataPath() + ChecksumFileExt
+}
+
+// WALChecksumPath returns the path to the page checksum file for the WAL.
+func (db *DB) WALChecksumPath() string {
+	return db.WALPath() + ChecksumFileExt
+}
+
+// openChecksums opens the checksum files for a database whose meta page has
+// the checksum flag set. Entries missing from the data checksum file, such as
+// for a newly created database or after a data file is restored from a
+// snapshot, are rebuilt from the current page contents.
+func (db *DB) openChecksums(created bool) (err error) {
+	if db.checksums, err = openChecksumFile(db.DataChecksumPath()); err != nil {
+		return err
+	}
+	if db.walChecksums, err = openChecksumFile(db.WALChecksumPath()); err != nil {
+		return err
+	}
+
+	pageN, err := db.dataPageN()
+	if err != nil {
+		return err
+	}
+	if n := db.checksums.len(); n < pageN {
+		if !created {
+			db.logger.Warnf("rbf: rebuilding %d missing page checksums: path=%s", pageN-n, db.Path)
+		}
+		for pgno := n; pgno < pageN; pgno++ {
+			db.checksums.set(pgno, pageChecksum(db.data[pgno*PageSize:(pgno+1)*PageSize]))
+		}
+		if err := db.checksums.sync(db.cfg.FsyncEnabled); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// enableChecksums upgrades an existing database to maintain page checksums.
+// It must be called with an empty WAL so that the data file is authoritative.
+// The checksums are persisted before the meta page flag is set so that an
+// interrupted upgrade is simply redone on the next open.
+func (db *DB) enableChecksums() (err error) {
+	db.logger.Infof("rbf: enabling page checksums: path=%s", db.Path)
+
+	if db.checksums, err = openChecksumFile(db.DataChecksumPath()); err != nil {
+		return err
+	}
+	if db.walChecksums, err = openChecksumFile(db.WALChecksumPath()); err != nil {
+		return err
+	} else if err := db.walChecksums.truncate(0); err != nil {
+		return err
+	}
+
+	pageN, err := db.dataPageN()
+	if err != nil {
+		return err
+	}
+	meta := allocPage()
+	defer freePage(meta)
+	copy(meta, db.data[:PageSize])
+	writeFlags(meta, readFlags(meta)|MetaPageFlagChecksums)
+
+	if err := db.checksums.truncate(pageN); err != nil {
+		return err
+	}
+	db.checksums.set(0, pageChecksum(meta))
+	for pgno := 1; pgno < pageN; pgno++ {
+		db.checksums.set(pgno, pageChecksum(db.data[pgno*PageSize:(pgno+1)*PageSize]))
+	}
+	if err := db.checksums.sync(true); err != nil {
+		return err
+	}
+
+	if err := db.writeDBPage(0, meta); err != nil {
+		return fmt.Errorf("write meta page: %w", err)
+	}
+	return db.fsync(db.file)
+}
+
+// dataPageN returns the number of pages in the data file according to the
+// meta page, limited to the pages actually present in the file.
+func (db *DB) dataPageN() (int, error) {
+	fi, err := db.file.Stat()
+	if err != nil {
+		return 0, fmt.Errorf("stat: %w", err)
+	}
+	pageN := int(readMetaPageN(db.data))
+	if n := int(fi.Size() / PageSize); n < pageN {
+		pageN = n
+	}
+	return pageN, nil
+}
+
+// closeChecksums closes the checksum files, if open.
+func (db *DB) closeChecksums() (err error) {
+	if db.checksums != nil {
+		if e := db.checksums.Close(); e != nil && err == nil {
+			err = e
+		}
+		db.checksums = nil
+	}
+	if db.walChecksums != nil {
+		if e := db.walChecksums.Close(); e != nil && err == nil {
+			err = e
+		}
+		db.walChecksums = nil
+	}
+	return err
+}
+
+// verifyDBPage checks a page read from the data file against its checksum.
+func (db *DB) verifyDBPage(pgno uint32, page []byte) error {
+	if db.checksums == nil {
+		return nil
+	}
+	if want, got, ok := db.checksums.verify(int(pgno), page); !ok {
+		return &ChecksumError{Pgno: pgno, Want: want, Got: got}
+	}
+	return nil
+}
+
+// verifyWALPage checks the i-th page of the WAL against its checksum.
+func (db *DB) verifyWALPage(i int, pgno uint32, page []byte) error {
+	if db.walChecksums == nil {
+		return nil
+	}
+	if want, got, ok := db.walChecksums.verify(i, page); !ok {
+		return &ChecksumError{Pgno: pgno, WAL: true, Want: want, Got: got}
+	}
+	return nil
+}
+
+// Scrub verifies every page visible to a read transaction against its stored
+// checksum, reading each page from the WAL if it has been remapped there and
+// from the data file otherwise. It returns the pages that fail verification.
+// Scrub is a no-op for databases without checksums.
+func (db *DB) Scrub() ([]*ChecksumError, error) {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if db.checksums == nil {
+		return nil, nil
+	}
+
+	var corrupt []*ChecksumError
+	pageN := readMetaPageN(tx.meta[:])
+	for pgno := uint32(0); pgno < pageN; pgno++ {
+		var page []byte
+		if walID, ok := tx.pageMap.Get(pgno); ok {
+			i := int(walID - db.baseWALID - 1)
+			if page, err = db.readWALPageAt(i); err != nil {
+				return corrupt, err
+			}
+			err = db.verifyWALPage(i, pgno, page)
+		} else {
+			if page, err = db.readDBPage(pgno); err != nil && !errors.Is(err, ErrChecksumMismatch) {
+				return corrupt, err
+			}
+		}
+
+		var cerr *ChecksumError
+		if errors.As(err, &cerr) {
+			corrupt = append(corrupt, cerr)
+		}
+	}
+	return corrupt, nil
+}
@@ -0,0 +1,183 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package rbf
+
+import (
+	"sort"
+
+	"github.com/featurebasedb/featurebase/v3/roaring"
+)
+
+// Fragmentation describes how much of a database's data file is unused.
+type Fragmentation struct {
+	PageN     int // total number of pages in the database
+	FreePageN int // number of pages on the freelist
+}
+
+// FreeRatio returns the fraction of pages which are on the freelist.
+func (f Fragmentation) FreeRatio() float64 {
+	if f.PageN == 0 {
+		return 0
+	}
+	return float64(f.FreePageN) / float64(f.PageN)
+}
+
+// Fragmentation returns the total & free page counts of the database.
+func (db *DB) Fragmentation() (Fragmentation, error) {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return Fragmentation{}, err
+	}
+	defer tx.Rollback()
+
+	free, err := tx.freePageSet()
+	if err != nil {
+		return Fragmentation{}, err
+	}
+	return Fragmentation{PageN: tx.PageN(), FreePageN: len(free)}, nil
+}
+
+// Compact moves live pages toward the start of the data file so that the
+// free pages left at the end can be truncated. It returns the number of pages
+// by which the database shrank.
+//
+// Each bitmap with a page beyond the number of live pages is rewritten. Pages
+// are always allocated from the lowest free page, so the rewritten bitmap
+// fills holes earlier in the file. Bitmaps are rewritten in a series of small
+// write transactions so other writers are only paused briefly, and readers
+// are never blocked. Pages belonging to the freelist itself are not moved.
+func (db *DB) Compact() (int, error) {
+	if err := db.Checkpoint(); err != nil {
+		return 0, err
+	}
+
+	before, names, err := db.compactionCandidates()
+	if err != nil || len(names) == 0 {
+		return 0, err
+	}
+
+	// Rewrite bitmaps in batches which fit comfortably within the WAL.
+	// Bitmap pages take two WAL pages.
+	batchSize := int(db.cfg.MaxWALCheckpointSize/PageSize) / 4
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for len(names) > 0 {
+		if names, err = db.rewriteBitmaps(names, batchSize); err != nil {
+			return 0, err
+		}
+	}
+	if err := db.Checkpoint(); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	return before - tx.PageN(), nil
+}
+
+// compactionCandidates returns the current page count and the names of the
+// bitmaps which have pages beyond the number of live pages, ordered by their
+// highest page so the end of the file is cleared first.
+func (db *DB) compactionCandidates() (pageN int, names []string, err error) {
+	tx, err := db.Begin(false)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+
+	infos, err := tx.PageInfos()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	var liveN int
+	for _, info := range infos {
+		if _, ok := info.(*FreePageInfo); info != nil && !ok {
+			liveN++
+		}
+	}
+
+	maxPgnos := make(map[string]uint32)
+	for pgno := liveN; pgno < len(infos); pgno++ {
+		var tree string
+		switch info := infos[pgno].(type) {
+		case *LeafPageInfo:
+			tree = info.Tree
+		case *BranchPageInfo:
+			tree = info.Tree
+		case *BitmapPageInfo:
+			tree = info.Tree
+		default:
+			continue
+		}
+		if tree != "freelist" {
+			maxPgnos[tree] = uint32(pgno)
+		}
+	}
+
+	for name := range maxPgnos {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return maxPgnos[names[i]] > maxPgnos[names[j]] })
+	return len(infos), names, nil
+}
+
+// rewriteBitmaps rewrites bitmaps from names in a single write transaction
+// until at least maxDirtyN pages are dirty. Returns the names which remain.
+func (db *DB) rewriteBitmaps(names []string, maxDirtyN int) ([]string, error) {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return names, err
+	}
+	defer tx.Rollback()
+
+	for len(names) > 0 && tx.dirtyN() < maxDirtyN {
+		if err := tx.rewriteBitmap(names[0]); err != nil {
+			return names, err
+		}
+		names = names[1:]
+	}
+	return names, tx.Commit()
+}
+
+// rewriteBitmap deletes and recreates a bitmap with the same contents so that
+// its pages are reallocated from the lowest free pages. Bitmaps which have
+// been deleted since they were found are skipped, since they no longer have
+// pages to move.
+func (tx *Tx) rewriteBitmap(name string) error {
+	if ok, err := tx.BitmapExists(name); err != nil || !ok {
+		return err
+	}
+
+	bm, err := tx.RoaringBitmap(name)
+	if err != nil {
+		return err
+	}
+
+	// Copy containers out of the pages which are about to be freed.
+	var keys []uint64
+	var cts []*roaring.Container
+	for itr, _ := bm.Containers.Iterator(0); itr.Next(); {
+		key, ct := itr.Value()
+		if ct.N() == 0 {
+			continue
+		}
+		keys, cts = append(keys, key), append(cts, ct.Clone())
+	}
+
+	if err := tx.DeleteBitmap(name); err != nil {
+		return err
+	} else if err := tx.CreateBitmap(name); err != nil {
+		return err
+	}
+	for i := range keys {
+		if err := tx.PutContainer(name, keys[i], cts[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package rbf
+
+import (
+	"testing"
+)
+
+// Ensures bitmaps deleted after compaction candidates are collected are
+// skipped rather than failing the compaction, and aren't recreated.
+func TestDB_rewriteBitmaps_Deleted(t *testing.T) {
+	db := testHelperMustOpenNewDB(t)
+	defer MustCloseDB(t, db)
+
+	tx := MustBegin(t, db, true)
+	for _, name := range []string{"x", "y", "z"} {
+		if _, err := tx.Add(name, 1, 2, 3); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, names, err := db.compactionCandidates()
+	if err != nil {
+		t.Fatal(err)
+	}
+	names = append(names, "x", "y", "z")
+
+	tx = MustBegin(t, db, true)
+	if err := tx.DeleteBitmap("y"); err != nil {
+		t.Fatal(err)
+	} else if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	if remaining, err := db.rewriteBitmaps(names, 1000); err != nil {
+		t.Fatal(err)
+	} else if len(remaining) != 0 {
+		t.Fatalf("expected all bitmaps rewritten, remaining: %v", remaining)
+	}
+
+	tx = MustBegin(t, db, false)
+	defer tx.Rollback()
+	if ok, err := tx.BitmapExists("y"); err != nil {
+		t.Fatal(err)
+	} else if ok {
+		t.Fatal("expected deleted bitmap to stay deleted")
+	}
+	for _, name := range []string{"x", "z"} {
+		if n, err := tx.Count(name); err != nil {
+			t.Fatal(err)
+		} else if n != 3 {
+			t.Fatalf("Count(%q)=%d, want 3", name, n)
+		}
+	}
+}
@@ -58,6 +58,12 @@ type DB struct {
 	walPageN  int      // wal page count
 	baseWALID int64    // WAL ID of first page
 
+	checksums    *checksumFile // data page checksums, nil if disabled
+	walChecksums *checksumFile // wal page checksums, nil if disabled
+
+	pageCiphers *cipherFile // data page encryption entries, nil if disabled
+	walCiphers  *cipherFile // wal page encryption entries, nil if disabled
+
 	mu       sync.RWMutex // general mutex
 	rwmu     sync.Mutex   // mutex for restricting single writer
 	haltCond *sync.Cond   // condition for resuming txs after checkpoint
@@ -113,7 +119,24 @@ func (db *DB) TxN() int {
 
 // Open opens a database with the file specified in Path.
 // Creates a new file if one does not already exist.
-func (db *DB) Open() (err error) {
+func (db *DB) Open() error {
+	if err := db.open(); err != nil {
+		return err
+	}
+
+	// Encrypt any pages which are still in plaintext. This requires write
+	// transactions so it cannot be done while holding the lock in open().
+	if db.pageCiphers != nil {
+		if n, err := db.encryptPlaintextPages(); err != nil {
+			return fmt.Errorf("encrypt pages: %w", err)
+		} else if n > 0 {
+			db.logger.Debugf("rbf: encrypted %d plaintext pages: path=%s", n, db.Path)
+		}
+	}
+	return nil
+}
+
+func (db *DB) open() (err error) {
 	db.mu.Lock()
 	defer db.mu.Unlock()
 
@@ -134,16 +157,31 @@ func (db *DB) Open() (err error) {
 	}
 
 	// Initialize file if it is too small.
+	var created bool
 	if fi, err := db.file.Stat(); err != nil {
 		return fmt.Errorf("stat: %w", err)
 	} else if fi.Size() < PageSize {
 		if err := db.init(); err != nil {
 			return fmt.Errorf("init: %w", err)
 		}
+		created = true
 	}
 
 	// TODO(BBJ): Obtain advisory lock on file.
 
+	// Load page checksums if the database was created with them. These must
+	// be available before the WAL is replayed so its pages can be verified.
+	if readFlags(db.data)&MetaPageFlagChecksums != 0 {
+		if err := db.openChecksums(created); err != nil {
+			return fmt.Errorf("open checksums: %w", err)
+		}
+	}
+
+	// Likewise, page encryption entries are needed to copy WAL pages.
+	if err := db.openCiphers(); err != nil {
+		return fmt.Errorf("open ciphers: %w", err)
+	}
+
 	db.opened = true
 
 	// Open write-ahead log & checkpoint to the end since no transactions are open.
@@ -157,6 +195,14 @@ func (db *DB) Open() (err error) {
 		}
 	}
 
+	// Upgrade an existing database to maintain checksums, if requested. The
+	// WAL is empty after the startup checkpoint so the data file is complete.
+	if db.cfg.Checksums && db.checksums == nil {
+		if err := db.enableChecksums(); err != nil {
+			return fmt.Errorf("enable checksums: %w", err)
+		}
+	}
+
 	return nil
 }
 
@@ -230,6 +276,16 @@ func (db *DB) openWAL() (err error) {
 			return fmt.Errorf("wal truncate: %w", err)
 		}
 	}
+	if db.walChecksums != nil {
+		if err := db.walChecksums.truncate(pageN); err != nil {
+			return fmt.Errorf("wal checksum truncate: %w", err)
+		}
+	}
+	if db.walCiphers != nil {
+		if err := db.walCiphers.truncate(pageN); err != nil {
+			return fmt.Errorf("wal encryption truncate: %w", err)
+		}
+	}
 	if _, err := db.walFile.Seek(int64(pageN)*PageSize, io.SeekStart); err != nil {
 		return fmt.Errorf("wal seek: %w", err)
 	}
@@ -356,6 +412,8 @@ func (db *DB) checkpoint() (err error) {
 			page, err = db.readWALPageAt(walID)
 			if err != nil {
 				return fmt.Errorf("reading page %d [page number %d]: %v", walID, pgno, err)
+			} else if err = db.verifyWALPage(walID, pgno, page); err != nil {
+				return err
 			}
 
 			// Determine new database size from the page size in meta page.
@@ -367,12 +425,28 @@ func (db *DB) checkpoint() (err error) {
 			if err = db.writeDBPage(pgno, page); err != nil {
 				return fmt.Errorf("writing page %d: %v", pgno, err)
 			}
+			if db.checksums != nil {
+				db.checksums.set(int(pgno), pageChecksum(page))
+			}
+			if db.pageCiphers != nil {
+				db.pageCiphers.set(int(pgno), db.walCiphers.get(walID))
+			}
 		}
 
-		// Ensure database file is synced and then truncate the WAL file.
+		// Ensure database file & checksums are synced and then truncate the WAL file.
 		if err = db.fsync(db.file); err != nil {
 			return fmt.Errorf("db file sync: %w", err)
 		}
+		if db.checksums != nil {
+			if err = db.checksums.sync(db.cfg.FsyncEnabled); err != nil {
+				return err
+			}
+		}
+		if db.pageCiphers != nil {
+			if err = db.pageCiphers.sync(db.cfg.FsyncEnabled); err != nil {
+				return err
+			}
+		}
 
 		return nil
 	}(); err != nil {
@@ -399,6 +473,16 @@ func (db *DB) checkpoint() (err error) {
 		} else if _, err = db.walFile.Seek(0, io.SeekStart); err != nil {
 			db.logger.Errorf("seek wal file: %w", err)
 		}
+		if db.walChecksums != nil {
+			if err = db.walChecksums.truncate(0); err != nil {
+				db.logger.Errorf("truncate wal checksum file: %w", err)
+			}
+		}
+		if db.walCiphers != nil {
+			if err = db.walCiphers.truncate(0); err != nil {
+				db.logger.Errorf("truncate wal encryption file: %w", err)
+			}
+		}
 
 		// Truncate data file if it has shrunk.
 		if fi, err := db.file.Stat(); err != nil {
@@ -407,6 +491,16 @@ func (db *DB) checkpoint() (err error) {
 			if err := db.file.Truncate(sz); err != nil {
 				db.logger.Errorf("truncate db file: %w", err)
 			}
+			if db.checksums != nil {
+				if err := db.checksums.truncate(int(pageN)); err != nil {
+					db.logger.Errorf("truncate db checksum file: %w", err)
+				}
+			}
+			if db.pageCiphers != nil {
+				if err := db.pageCiphers.truncate(int(pageN)); err != nil {
+					db.logger.Errorf("truncate db encryption file: %w", err)
+				}
+			}
 		}
 	})
 
@@ -473,6 +567,19 @@ func (db *DB) Close() (err error) {
 		db.walFile = nil
 	}
 
+	if e := db.closeChecksums(); e != nil && err == nil {
+		err = e
+	}
+	if e := db.closeCiphers(); e != nil && err == nil {
+		err = e
+	}
+
+	// The files may be replaced before the database is opened again, so what
+	// was read from them can't be trusted.
+	db.pageMap = NewPageMap()
+	db.walPageN = 0
+	db.rootRecords = nil
+
 	return err
 }
 
@@ -578,6 +685,9 @@ func (db *DB) init() error {
 func (db *DB) initMetaPage() error {
 	page := allocPage()
 	writeMetaMagic(page)
+	if db.cfg.Checksums {
+		writeFlags(page, MetaPageFlagChecksums)
+	}
 	writeMetaPageN(page, 3)
 	writeMetaRootRecordPageNo(page, 1)
 	writeMetaFreelistPageNo(page, 2)
@@ -779,14 +889,32 @@ func (db *DB) removeTx(tx *Tx) error {
 	return nil
 }
 
-// Check performs an integrity check.
+// Check performs an integrity check. If the database maintains page
+// checksums, every page is also verified against its checksum.
 func (db *DB) Check() error {
+	corrupt, err := db.Scrub()
+	if err != nil {
+		return err
+	}
+
 	tx, err := db.Begin(false)
 	if err != nil {
 		return err
 	}
 	defer tx.Rollback()
-	return tx.Check()
+
+	if len(corrupt) == 0 {
+		return tx.Check()
+	}
+
+	var errs ErrorList
+	for _, cerr := range corrupt {
+		errs.Append(cerr)
+	}
+	if err := tx.Check(); err != nil {
+		errs.Append(err)
+	}
+	return errs
 }
 
 // writeDBPage writes a page to the data file.
@@ -805,12 +933,24 @@ func (db *DB) readDBPage(pgno uint32) ([]byte, error) {
 		return nil, fmt.Errorf("rbf: page read out of bounds, pgno=%d upper-bound=%d file-size=%d", pgno, bound, sz)
 	}
 
-	return db.data[offset:bound], nil
+	page := db.data[offset:bound]
+	if err := db.verifyDBPage(pgno, page); err != nil {
+		return page, err
+	}
+	return page, nil
 }
 
-// readWALPageByID reads a WAL page by WAL ID.
-func (db *DB) readWALPageByID(id int64) ([]byte, error) {
-	return db.readWALPageAt(int(id - db.baseWALID - 1))
+// readWALPageByID reads a WAL page by WAL ID and verifies it against its
+// checksum, if enabled. The pgno is only used to report checksum errors.
+func (db *DB) readWALPageByID(id int64, pgno uint32) ([]byte, error) {
+	i := int(id - db.baseWALID - 1)
+	page, err := db.readWALPageAt(i)
+	if err != nil {
+		return nil, err
+	} else if err := db.verifyWALPage(i, pgno, page); err != nil {
+		return nil, err
+	}
+	return page, nil
 }
 
 // readWALPageAt reads the i-th page in the WAL file.
@@ -821,7 +961,7 @@ func (db *DB) readWALPageAt(i int) ([]byte, error) {
 
 func (db *DB) readMetaPage() ([]byte, error) {
 	if walID, ok := db.pageMap.Get(uint32(0)); ok {
-		return db.readWALPageByID(walID)
+		return db.readWALPageByID(walID, 0)
 	}
 	return db.readDBPage(0)
 }
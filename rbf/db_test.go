@@ -3,6 +3,7 @@
 package rbf_test
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -10,11 +11,15 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	_ "net/http/pprof"
 
+	"github.com/featurebasedb/featurebase/v3/encryption"
 	"github.com/featurebasedb/featurebase/v3/rbf"
 	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
 	"github.com/felixge/fgprof"
@@ -30,6 +35,56 @@ func TestDB_Open(t *testing.T) {
 	}
 }
 
+// Ensure a database reads the root records of a data file which replaced its
+// own while it was closed, rather than those it cached before.
+func TestDB_ReopenReplaced(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+	other := MustOpenDB(t)
+	defer MustCloseDB(t, other)
+
+	for d, name := range map[*rbf.DB]string{db: "x", other: "y"} {
+		tx := MustBegin(t, d, true)
+		if err := tx.CreateBitmap(name); err != nil {
+			t.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading the root records caches them.
+	tx := MustBegin(t, db, false)
+	if names, err := tx.BitmapNames(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(names, []string{"x"}) {
+		t.Fatalf("unexpected names: %v", names)
+	}
+	tx.Rollback()
+
+	if err := other.Checkpoint(); err != nil {
+		t.Fatal(err)
+	} else if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(other.DataPath())
+	if err != nil {
+		t.Fatal(err)
+	} else if err := os.WriteFile(db.DataPath(), data, 0o600); err != nil {
+		t.Fatal(err)
+	} else if err := os.Remove(db.WALPath()); err != nil {
+		t.Fatal(err)
+	} else if err := db.Open(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx = MustBegin(t, db, false)
+	defer tx.Rollback()
+	if names, err := tx.BitmapNames(); err != nil {
+		t.Fatal(err)
+	} else if !reflect.DeepEqual(names, []string{"y"}) {
+		t.Fatalf("unexpected names after replacing data file: %v", names)
+	}
+}
+
 func TestDB_WAL(t *testing.T) {
 	t.Run("ErrTxTooLarge", func(t *testing.T) {
 		config := rbfcfg.NewDefaultConfig()
@@ -299,6 +354,344 @@ func TestDB_Recovery(t *testing.T) {
 	})
 }
 
+func TestDB_Checksums(t *testing.T) {
+	// checksumConfig returns a config with page checksums enabled.
+	checksumConfig := func() *rbfcfg.Config {
+		cfg := rbfcfg.NewDefaultConfig()
+		cfg.Checksums = true
+		cfg.FsyncEnabled, cfg.FsyncWALEnabled = false, false
+		return cfg
+	}
+
+	// addValues writes values to bitmap "x" in a single transaction.
+	addValues := func(tb testing.TB, db *rbf.DB, values ...uint64) {
+		tb.Helper()
+		tx := MustBegin(tb, db, true)
+		defer tx.Rollback()
+		if err := tx.CreateBitmapIfNotExists("x"); err != nil {
+			tb.Fatal(err)
+		} else if _, err := tx.Add("x", values...); err != nil {
+			tb.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	// flipByte inverts the last byte of the given page in a file.
+	flipByte := func(tb testing.TB, path string, pgno int) {
+		tb.Helper()
+		f, err := os.OpenFile(path, os.O_RDWR, 0o600)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		defer f.Close()
+		buf := make([]byte, 1)
+		off := int64((pgno+1)*rbf.PageSize - 1)
+		if _, err := f.ReadAt(buf, off); err != nil {
+			tb.Fatal(err)
+		}
+		buf[0] ^= 0xFF
+		if _, err := f.WriteAt(buf, off); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	t.Run("Reopen", func(t *testing.T) {
+		db := MustOpenDB(t, checksumConfig())
+		addValues(t, db, 1, 2, 3, 1<<20)
+		if err := db.Checkpoint(); err != nil {
+			t.Fatal(err)
+		}
+		addValues(t, db, 4, 5)
+
+		// Checksums are a property of the file and survive a default config.
+		db = MustReopenDB(t, db)
+		defer MustCloseDB(t, db)
+		if !db.ChecksumsEnabled() {
+			t.Fatal("expected checksums enabled")
+		} else if corrupt, err := db.Scrub(); err != nil {
+			t.Fatal(err)
+		} else if len(corrupt) != 0 {
+			t.Fatalf("unexpected corrupt pages: %v", corrupt)
+		}
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		db := MustOpenDB(t)
+		addValues(t, db, 1, 2, 3)
+		if db.ChecksumsEnabled() {
+			t.Fatal("expected checksums disabled")
+		} else if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		db = MustOpenDBAt(t, db.Path, checksumConfig())
+		defer MustCloseDB(t, db)
+		if !db.ChecksumsEnabled() {
+			t.Fatal("expected checksums enabled")
+		}
+		addValues(t, db, 4)
+		if corrupt, err := db.Scrub(); err != nil {
+			t.Fatal(err)
+		} else if len(corrupt) != 0 {
+			t.Fatalf("unexpected corrupt pages: %v", corrupt)
+		}
+	})
+
+	t.Run("DataMismatch", func(t *testing.T) {
+		db := MustOpenDB(t, checksumConfig())
+		addValues(t, db, 1, 2, 3)
+		if err := db.Checkpoint(); err != nil {
+			t.Fatal(err)
+		} else if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Corrupt the root page of the "x" bitmap.
+		flipByte(t, db.DataPath(), 3)
+
+		db = MustOpenDBAt(t, db.Path, checksumConfig())
+		defer MustCloseDBNoCheck(t, db)
+
+		corrupt, err := db.Scrub()
+		if err != nil {
+			t.Fatal(err)
+		} else if len(corrupt) != 1 || corrupt[0].Pgno != 3 || corrupt[0].WAL {
+			t.Fatalf("unexpected corrupt pages: %v", corrupt)
+		}
+
+		if tx, err := db.Begin(false); err != nil {
+			t.Fatal(err)
+		} else if _, err := tx.Contains("x", 1); !errors.Is(err, rbf.ErrChecksumMismatch) {
+			tx.Rollback()
+			t.Fatalf("unexpected error: %v", err)
+		} else {
+			tx.Rollback()
+		}
+
+		if err := db.Check(); !errors.Is(err.(rbf.ErrorList)[0], rbf.ErrChecksumMismatch) {
+			t.Fatalf("unexpected check error: %v", err)
+		}
+	})
+
+	t.Run("WALMismatch", func(t *testing.T) {
+		db := MustOpenDB(t, checksumConfig())
+		addValues(t, db, 1, 2, 3)
+		if db.WALSize() == 0 {
+			t.Fatal("expected pages in WAL")
+		} else if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Corrupt the first WAL page, which is verified on replay.
+		flipByte(t, db.WALPath(), 0)
+
+		other := rbf.NewDB(db.Path, checksumConfig())
+		if err := other.Open(); !errors.Is(err, rbf.ErrChecksumMismatch) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = other.Close()
+		_ = os.RemoveAll(db.Path)
+	})
+}
+
+func TestDB_Encryption(t *testing.T) {
+	// newKeyFile returns a key file with a single key.
+	newKeyFile := func(tb testing.TB) *encryption.KeyFile {
+		tb.Helper()
+		path := filepath.Join(tb.TempDir(), "keys")
+		if _, err := encryption.AddKey(path); err != nil {
+			tb.Fatal(err)
+		}
+		kf, err := encryption.OpenKeyFile(path)
+		if err != nil {
+			tb.Fatal(err)
+		}
+		return kf
+	}
+
+	// encryptedConfig returns a config which encrypts pages with keys.
+	encryptedConfig := func(keys encryption.KeyProvider) *rbfcfg.Config {
+		cfg := rbfcfg.NewDefaultConfig()
+		cfg.Cipher = encryption.NewCipher(keys)
+		cfg.FsyncEnabled, cfg.FsyncWALEnabled = false, false
+		return cfg
+	}
+
+	// addValues writes values to bitmap "x" in a single transaction.
+	addValues := func(tb testing.TB, db *rbf.DB, values ...uint64) {
+		tb.Helper()
+		tx := MustBegin(tb, db, true)
+		defer tx.Rollback()
+		if err := tx.CreateBitmapIfNotExists("x"); err != nil {
+			tb.Fatal(err)
+		} else if _, err := tx.Add("x", values...); err != nil {
+			tb.Fatal(err)
+		} else if err := tx.Commit(); err != nil {
+			tb.Fatal(err)
+		}
+	}
+
+	// mustContain verifies that bitmap "x" holds exactly values, checking
+	// membership of up to the first 100.
+	mustContain := func(tb testing.TB, db *rbf.DB, values ...uint64) {
+		tb.Helper()
+		tx := MustBegin(tb, db, false)
+		defer tx.Rollback()
+		if n, err := tx.Count("x"); err != nil {
+			tb.Fatal(err)
+		} else if n != uint64(len(values)) {
+			tb.Fatalf("count=%d, want %d", n, len(values))
+		}
+		if len(values) > 100 {
+			values = values[:100]
+		}
+		for _, v := range values {
+			if ok, err := tx.Contains("x", v); err != nil {
+				tb.Fatal(err)
+			} else if !ok {
+				tb.Fatalf("expected value %d", v)
+			}
+		}
+	}
+
+	// A bitmap container with every bit set fills a page with 0xFF.
+	dense := make([]uint64, 1<<16)
+	for i := range dense {
+		dense[i] = uint64(i)
+	}
+
+	t.Run("Reopen", func(t *testing.T) {
+		keys := newKeyFile(t)
+		db := MustOpenDB(t, encryptedConfig(keys))
+		addValues(t, db, dense...)
+		if err := db.Checkpoint(); err != nil {
+			t.Fatal(err)
+		}
+		addValues(t, db, 1<<20)
+		if !db.Encrypted() {
+			t.Fatal("expected encryption enabled")
+		} else if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The plaintext bitmap page must not be present in either file.
+		page := bytes.Repeat([]byte{0xFF}, rbf.PageSize)
+		for _, path := range []string{db.DataPath(), db.WALPath()} {
+			if buf, err := os.ReadFile(path); err != nil {
+				t.Fatal(err)
+			} else if bytes.Contains(buf, page) {
+				t.Fatalf("plaintext page found in %s", path)
+			}
+		}
+
+		// The database cannot be opened without a cipher.
+		other := rbf.NewDB(db.Path, nil)
+		if err := other.Open(); !errors.Is(err, rbf.ErrNoCipher) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		_ = other.Close()
+
+		db = MustOpenDBAt(t, db.Path, encryptedConfig(keys))
+		defer MustCloseDB(t, db)
+		mustContain(t, db, append(dense, 1<<20)...)
+	})
+
+	t.Run("Upgrade", func(t *testing.T) {
+		db := MustOpenDB(t)
+		addValues(t, db, 1, 2, 3)
+		if db.Encrypted() {
+			t.Fatal("expected encryption disabled")
+		} else if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		keys := newKeyFile(t)
+		db = MustOpenDBAt(t, db.Path, encryptedConfig(keys))
+		defer MustCloseDB(t, db)
+		if !db.Encrypted() {
+			t.Fatal("expected encryption enabled")
+		} else if n, err := db.Rekey(); err != nil {
+			t.Fatal(err)
+		} else if n != 0 {
+			t.Fatalf("expected all pages encrypted on open, rekeyed %d", n)
+		}
+		addValues(t, db, 4)
+		mustContain(t, db, 1, 2, 3, 4)
+	})
+
+	t.Run("Rotate", func(t *testing.T) {
+		keys := newKeyFile(t)
+		db := MustOpenDB(t, encryptedConfig(keys))
+		addValues(t, db, 1, 2, 3)
+
+		// Add a new key and move all pages to it.
+		if _, err := encryption.AddKey(keys.Path()); err != nil {
+			t.Fatal(err)
+		} else if err := keys.Reload(); err != nil {
+			t.Fatal(err)
+		} else if n, err := db.Rekey(); err != nil {
+			t.Fatal(err)
+		} else if n == 0 {
+			t.Fatal("expected pages to be rekeyed")
+		} else if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// The old key is no longer needed.
+		buf, err := os.ReadFile(keys.Path())
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.SplitAfter(string(buf), "\n")
+		if err := os.WriteFile(keys.Path(), []byte(lines[1]), 0o600); err != nil {
+			t.Fatal(err)
+		} else if err := keys.Reload(); err != nil {
+			t.Fatal(err)
+		}
+
+		db = MustOpenDBAt(t, db.Path, encryptedConfig(keys))
+		defer MustCloseDB(t, db)
+		mustContain(t, db, 1, 2, 3)
+	})
+
+	t.Run("Tamper", func(t *testing.T) {
+		keys := newKeyFile(t)
+		db := MustOpenDB(t, encryptedConfig(keys))
+		addValues(t, db, 1, 2, 3)
+		if err := db.Checkpoint(); err != nil {
+			t.Fatal(err)
+		} else if err := db.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		// Flip the last byte of the root page of the "x" bitmap.
+		f, err := os.OpenFile(db.DataPath(), os.O_RDWR, 0o600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		buf := make([]byte, 1)
+		off := int64(4*rbf.PageSize - 1)
+		if _, err := f.ReadAt(buf, off); err != nil {
+			t.Fatal(err)
+		}
+		buf[0] ^= 0xFF
+		if _, err := f.WriteAt(buf, off); err != nil {
+			t.Fatal(err)
+		} else if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+
+		db = MustOpenDBAt(t, db.Path, encryptedConfig(keys))
+		defer MustCloseDBNoCheck(t, db)
+		tx := MustBegin(t, db, false)
+		defer tx.Rollback()
+		if _, err := tx.Contains("x", 1); !errors.Is(err, encryption.ErrDecrypt) {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	})
+}
+
 func TestDB_HasData(t *testing.T) {
 
 	db := MustOpenDB(t)
@@ -379,6 +772,78 @@ func TestDB_HasData(t *testing.T) {
 	}
 }
 
+func TestDB_Compact(t *testing.T) {
+	db := MustOpenDB(t)
+	defer func() { MustCloseDB(t, db) }()
+
+	// Interleave dense containers from two bitmaps so deleting one leaves
+	// holes throughout the file.
+	const keyN, bitN = 32, 5000
+	tx := MustBegin(t, db, true)
+	for key := uint64(0); key < keyN; key++ {
+		for _, name := range []string{"x", "y"} {
+			values := make([]uint64, bitN)
+			for i := range values {
+				values[i] = key<<16 | uint64(i)
+			}
+			if _, err := tx.Add(name, values...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	tx = MustBegin(t, db, true)
+	if err := tx.DeleteBitmap("x"); err != nil {
+		t.Fatal(err)
+	} else if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+
+	before, err := db.Fragmentation()
+	if err != nil {
+		t.Fatal(err)
+	} else if before.FreePageN < keyN || before.FreeRatio() < 0.3 {
+		t.Fatalf("unexpected fragmentation before compaction: %+v", before)
+	}
+
+	n, err := db.Compact()
+	if err != nil {
+		t.Fatal(err)
+	} else if n < keyN-2 {
+		t.Fatalf("expected at least %d pages reclaimed, got %d", keyN-2, n)
+	}
+
+	after, err := db.Fragmentation()
+	if err != nil {
+		t.Fatal(err)
+	} else if after.PageN != before.PageN-n {
+		t.Fatalf("PageN=%d, want %d", after.PageN, before.PageN-n)
+	} else if after.FreeRatio() >= before.FreeRatio() {
+		t.Fatalf("free ratio did not decrease: before=%+v after=%+v", before, after)
+	}
+
+	if fi, err := os.Stat(db.DataPath()); err != nil {
+		t.Fatal(err)
+	} else if got, want := fi.Size(), int64(after.PageN)*rbf.PageSize; got != want {
+		t.Fatalf("data file size=%d, want %d", got, want)
+	}
+
+	// Ensure data is intact, including after reopening.
+	db = MustReopenDB(t, db)
+	tx = MustBegin(t, db, false)
+	defer tx.Rollback()
+	if cnt, err := tx.Count("y"); err != nil {
+		t.Fatal(err)
+	} else if cnt != keyN*bitN {
+		t.Fatalf("Count=%d, want %d", cnt, keyN*bitN)
+	} else if ok, err := tx.Contains("y", (keyN-1)<<16|(bitN-1)); err != nil || !ok {
+		t.Fatalf("Contains=%v, %v", ok, err)
+	}
+}
+
 // Ensures the DB can continuously write while readers are executing.
 func TestDB_MultiTx(t *testing.T) {
 	if testing.Short() {
@@ -0,0 +1,402 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package rbf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/featurebasedb/featurebase/v3/encryption"
+)
+
+// EncryptionFileExt is the extension appended to the data & WAL file paths to
+// name the files holding the nonce & tag of each encrypted page.
+const EncryptionFileExt = ".enc"
+
+// ErrNoCipher is returned when opening an encrypted database without a cipher.
+var ErrNoCipher = errors.New("rbf: database is encrypted but no cipher is configured")
+
+// Page encryption modes.
+const (
+	// pageCipherNone marks a page stored in plaintext. Meta pages & WAL
+	// bitmap headers hold no user data and are never encrypted.
+	pageCipherNone = 0
+
+	// pageCipherFull marks a page which is entirely encrypted. Used for
+	// bitmap pages, which have no header, and for free pages.
+	pageCipherFull = 1
+
+	// pageCipherHeader marks a page whose header (page number & flags) is
+	// left in plaintext so the WAL can be replayed without decrypting it.
+	// The header is authenticated along with the encrypted remainder.
+	pageCipherHeader = 2
+)
+
+// pageCipherHeaderSize is the number of plaintext bytes at the start of a
+// page encrypted with pageCipherHeader.
+const pageCipherHeaderSize = 8
+
+// cipherEntrySize is the size of a cipherEntry on disk, in bytes.
+const cipherEntrySize = 4 + 4 + encryption.NonceSize + encryption.TagSize
+
+// cipherEntry holds the parameters needed to decrypt a single page.
+type cipherEntry struct {
+	keyID uint32 // zero if the page is not encrypted
+	mode  uint32
+	nonce [encryption.NonceSize]byte
+	tag   [encryption.TagSize]byte
+}
+
+// cipherFile is an array of page encryption entries, indexed by page number
+// for the data file or by page offset for the WAL. Like page checksums, the
+// nonce & tag for each page cannot be stored inline because bitmap pages use
+// every byte of the page.
+type cipherFile struct {
+	mu      sync.RWMutex
+	file    *os.File
+	entries []cipherEntry
+
+	// range of entries changed since the last write
+	dirtyMin, dirtyMax int
+}
+
+// openCipherFile opens or creates the encryption entry file at path and
+// reads all existing entries into memory.
+func openCipherFile(path string) (*cipherFile, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open encryption file: %w", err)
+	}
+	buf, err := io.ReadAll(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("read encryption file: %w", err)
+	}
+
+	f := &cipherFile{
+		file:    file,
+		entries: make([]cipherEntry, len(buf)/cipherEntrySize),
+	}
+	for i := range f.entries {
+		b, e := buf[i*cipherEntrySize:], &f.entries[i]
+		e.keyID = binary.BigEndian.Uint32(b[0:4])
+		e.mode = binary.BigEndian.Uint32(b[4:8])
+		copy(e.nonce[:], b[8:])
+		copy(e.tag[:], b[8+encryption.NonceSize:])
+	}
+	f.resetDirty()
+	return f, nil
+}
+
+// Close closes the underlying file.
+func (f *cipherFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.file = nil
+	return err
+}
+
+// len returns the number of entries.
+func (f *cipherFile) len() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return len(f.entries)
+}
+
+// get returns the entry at index i. Missing entries describe plaintext pages.
+func (f *cipherFile) get(i int) cipherEntry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if i >= len(f.entries) {
+		return cipherEntry{}
+	}
+	return f.entries[i]
+}
+
+// set updates the entry at index i in memory, growing the array if needed.
+// The change is persisted on the next call to sync.
+func (f *cipherFile) set(i int, e cipherEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.entries) <= i {
+		f.entries = append(f.entries, cipherEntry{})
+	}
+	f.entries[i] = e
+	if i < f.dirtyMin {
+		f.dirtyMin = i
+	}
+	if i > f.dirtyMax {
+		f.dirtyMax = i
+	}
+}
+
+// truncate removes all entries at index n and above.
+func (f *cipherFile) truncate(n int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if n < len(f.entries) {
+		f.entries = f.entries[:n]
+	}
+	if f.dirtyMax >= n {
+		f.dirtyMax = n - 1
+	}
+	return f.file.Truncate(int64(n) * cipherEntrySize)
+}
+
+// sync writes all changed entries to disk and optionally fsyncs the file.
+func (f *cipherFile) sync(fsync bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.dirtyMin <= f.dirtyMax {
+		buf := make([]byte, (f.dirtyMax-f.dirtyMin+1)*cipherEntrySize)
+		for i, e := range f.entries[f.dirtyMin : f.dirtyMax+1] {
+			b := buf[i*cipherEntrySize:]
+			binary.BigEndian.PutUint32(b[0:4], e.keyID)
+			binary.BigEndian.PutUint32(b[4:8], e.mode)
+			copy(b[8:], e.nonce[:])
+			copy(b[8+encryption.NonceSize:], e.tag[:])
+		}
+		if _, err := f.file.WriteAt(buf, int64(f.dirtyMin)*cipherEntrySize); err != nil {
+			return fmt.Errorf("write encryption file: %w", err)
+		}
+		f.resetDirty()
+	}
+
+	if fsync {
+		if err := f.file.Sync(); err != nil {
+			return fmt.Errorf("sync encryption file: %w", err)
+		}
+	}
+	return nil
+}
+
+func (f *cipherFile) resetDirty() {
+	f.dirtyMin, f.dirtyMax = int(^uint(0)>>1), -1
+}
+
+// Encrypted returns true if the database encrypts its pages.
+func (db *DB) Encrypted() bool {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	return db.pageCiphers != nil
+}
+
+// DataCipherPath returns the path to the page encryption file for the data file.
+func (db *DB) DataCipherPath() string {
+	return db.DataPath() + EncryptionFileExt
+}
+
+// WALCipherPath returns the path to the page encryption file for the WAL.
+func (db *DB) WALCipherPath() string {
+	return db.WALPath() + EncryptionFileExt
+}
+
+// openCiphers opens the encryption files if the database is configured with
+// a cipher. Otherwise it ensures the database has no encrypted pages.
+func (db *DB) openCiphers() (err error) {
+	if db.cfg.Cipher == nil {
+		for _, path := range []string{db.DataCipherPath(), db.WALCipherPath()} {
+			if fi, err := os.Stat(path); err == nil && fi.Size() > 0 {
+				return ErrNoCipher
+			}
+		}
+		return nil
+	}
+
+	if db.pageCiphers, err = openCipherFile(db.DataCipherPath()); err != nil {
+		return err
+	}
+	if db.walCiphers, err = openCipherFile(db.WALCipherPath()); err != nil {
+		return err
+	}
+	return nil
+}
+
+// closeCiphers closes the encryption files, if open.
+func (db *DB) closeCiphers() (err error) {
+	if db.pageCiphers != nil {
+		if e := db.pageCiphers.Close(); e != nil && err == nil {
+			err = e
+		}
+		db.pageCiphers = nil
+	}
+	if db.walCiphers != nil {
+		if e := db.walCiphers.Close(); e != nil && err == nil {
+			err = e
+		}
+		db.walCiphers = nil
+	}
+	return err
+}
+
+// encryptPage encrypts page into dst using the given mode. The page number is
+// authenticated so that an encrypted page cannot be moved to another page.
+func (db *DB) encryptPage(dst []byte, pgno uint32, page []byte, mode uint32) (e cipherEntry, err error) {
+	off, aad := pageCipherAAD(pgno, page, mode)
+	copy(dst[:off], page[:off])
+	e.mode = mode
+	if e.keyID, e.nonce, e.tag, err = db.cfg.Cipher.Seal(dst[off:], page[off:], aad); err != nil {
+		return e, fmt.Errorf("encrypt page %d: %w", pgno, err)
+	}
+	return e, nil
+}
+
+// decryptPage returns the plaintext of a page read from disk. Plaintext pages
+// are returned as-is, otherwise the page is decrypted into a new buffer.
+func (db *DB) decryptPage(pgno uint32, page []byte, e cipherEntry) ([]byte, error) {
+	if e.keyID == 0 {
+		return page, nil
+	}
+
+	buf := make([]byte, PageSize)
+	off, aad := pageCipherAAD(pgno, page, e.mode)
+	copy(buf[:off], page[:off])
+	if err := db.cfg.Cipher.Open(buf[off:], page[off:], aad, e.keyID, e.nonce[:], e.tag[:]); err != nil {
+		return nil, fmt.Errorf("decrypt page %d: %w", pgno, err)
+	}
+	return buf, nil
+}
+
+// pageCipherAAD returns the number of leading plaintext bytes & the
+// additional authenticated data for a page in the given mode.
+func pageCipherAAD(pgno uint32, page []byte, mode uint32) (off int, aad []byte) {
+	aad = make([]byte, 4, 4+pageCipherHeaderSize)
+	binary.BigEndian.PutUint32(aad, pgno)
+	if mode == pageCipherHeader {
+		off = pageCipherHeaderSize
+		aad = append(aad, page[:off]...)
+	}
+	return off, aad
+}
+
+// decryptDBPage decrypts a page read from the data file.
+func (db *DB) decryptDBPage(pgno uint32, page []byte) ([]byte, error) {
+	if db.pageCiphers == nil {
+		return page, nil
+	}
+	return db.decryptPage(pgno, page, db.pageCiphers.get(int(pgno)))
+}
+
+// decryptWALPage decrypts a page read from the WAL by WAL ID.
+func (db *DB) decryptWALPage(id int64, pgno uint32, page []byte) ([]byte, error) {
+	if db.walCiphers == nil {
+		return page, nil
+	}
+	return db.decryptPage(pgno, page, db.walCiphers.get(int(id-db.baseWALID-1)))
+}
+
+// Rekey re-encrypts every page which is stored in plaintext or was encrypted
+// with a key other than the current key. This is used to encrypt an existing
+// database and to move data to a new key after key rotation. Returns the
+// number of pages rewritten.
+func (db *DB) Rekey() (int, error) {
+	if db.cfg.Cipher == nil {
+		return 0, ErrNoCipher
+	}
+	keyID, err := db.cfg.Cipher.CurrentKeyID()
+	if err != nil {
+		return 0, err
+	}
+	return db.rekey(func(e cipherEntry) bool { return e.keyID != keyID })
+}
+
+// encryptPlaintextPages encrypts any pages still stored in plaintext, such as
+// when encryption is first enabled or after a data file is restored from a
+// snapshot.
+func (db *DB) encryptPlaintextPages() (int, error) {
+	return db.rekey(func(e cipherEntry) bool { return e.keyID == 0 })
+}
+
+// rekey rewrites every page whose entry in the data file matches fn. The
+// pages are rewritten through ordinary write transactions, and are therefore
+// encrypted by the WAL with the current key, so the process is crash-safe.
+func (db *DB) rekey(fn func(cipherEntry) bool) (n int, err error) {
+	// Move all pages into the data file so its entries are authoritative.
+	if err := db.Checkpoint(); err != nil {
+		return 0, err
+	}
+
+	tx, err := db.Begin(false)
+	if err != nil {
+		return 0, err
+	}
+	var pgnos []uint32
+	for pgno := uint32(1); pgno < readMetaPageN(tx.meta[:]); pgno++ {
+		if fn(db.pageCiphers.get(int(pgno))) {
+			pgnos = append(pgnos, pgno)
+		}
+	}
+	if len(pgnos) == 0 {
+		tx.Rollback()
+		return 0, nil
+	}
+	infos, err := tx.PageInfos()
+	tx.Rollback()
+	if err != nil {
+		return 0, err
+	}
+
+	// Rewrite pages in batches which fit comfortably within the WAL. Bitmap
+	// pages take two WAL pages.
+	batchSize := int(db.cfg.MaxWALCheckpointSize/PageSize) / 4
+	if batchSize < 1 {
+		batchSize = 1
+	}
+	for len(pgnos) > 0 {
+		batch := pgnos
+		if len(batch) > batchSize {
+			batch = batch[:batchSize]
+		}
+		pgnos = pgnos[len(batch):]
+
+		if err := db.rewritePages(batch, infos); err != nil {
+			return n, err
+		}
+		n += len(batch)
+	}
+	return n, db.Checkpoint()
+}
+
+// rewritePages marks the given pages as dirty in a write transaction so they
+// are written to the WAL again.
+func (db *DB) rewritePages(pgnos []uint32, infos []PageInfo) error {
+	tx, err := db.Begin(true)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, pgno := range pgnos {
+		if int(pgno) >= len(infos) {
+			continue
+		}
+		page, _, err := tx.readPage(pgno)
+		if err != nil {
+			return err
+		}
+		buf := allocPage()
+		copy(buf, page)
+
+		// Pages with a header keep it in plaintext. Bitmap, free & any
+		// unreferenced pages are encrypted in full.
+		switch infos[pgno].(type) {
+		case *RootRecordPageInfo, *LeafPageInfo, *BranchPageInfo:
+			err = tx.writePage(buf)
+		default:
+			err = tx.writeBitmapPage(pgno, buf)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -57,6 +57,10 @@ const (
 const (
 	MetaPageFlagCommit   = 1
 	MetaPageFlagRollback = 2
+
+	// MetaPageFlagChecksums marks a database whose pages have CRC32C
+	// checksums stored alongside the data file & WAL.
+	MetaPageFlagChecksums = 4
 )
 
 type ContainerType int
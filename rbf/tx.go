@@ -100,6 +100,14 @@ func (tx *Tx) PageN() int {
 	return int(readMetaPageN(tx.meta[:]))
 }
 
+// WALID returns the ID of the last page written to the WAL before the
+// transaction began. It increases with every committed write, and is
+// preserved by checkpoints and snapshots, so it identifies a version of the
+// database.
+func (tx *Tx) WALID() int64 {
+	return tx.walID
+}
+
 // Commit completes the transaction and persists data changes. If this method
 // fails, changes may or may not have been persisted to disk. If no changes have
 // been made during the transaction, this functions the same as a rollback.
@@ -1268,12 +1276,20 @@ func (tx *Tx) readPage(pgno uint32) (_ []byte, isHeap bool, err error) {
 
 	// Check if page is remapped in WAL.
 	if walID, ok := tx.pageMap.Get(pgno); ok {
-		buf, err := tx.db.readWALPageByID(walID)
+		buf, err := tx.db.readWALPageByID(walID, pgno)
+		if err != nil {
+			return nil, false, err
+		}
+		buf, err = tx.db.decryptWALPage(walID, pgno, buf)
 		return buf, false, err
 	}
 
 	// Otherwise read directly from DB.
 	buf, err := tx.db.readDBPage(pgno)
+	if err != nil {
+		return buf, false, err
+	}
+	buf, err = tx.db.decryptDBPage(pgno, buf)
 	return buf, false, err
 }
 
@@ -1959,7 +1975,7 @@ func (tx *Tx) flush() error {
 
 	// Write non-bitmap pages to WAL.
 	for _, pgno := range dirtyPageMapKeys(tx.dirtyPages) {
-		walID, err := tx.writeToWAL(w, tx.dirtyPages[pgno])
+		walID, err := tx.writeToWAL(w, pgno, tx.dirtyPages[pgno], pageCipherHeader)
 		if err != nil {
 			return fmt.Errorf("write page to wal: %w", err)
 		}
@@ -1979,12 +1995,12 @@ func (tx *Tx) flush() error {
 		// Write header page.
 		writePageNo(hdr[:], pgno)
 		writeFlags(hdr[:], PageTypeBitmapHeader)
-		if _, err := tx.writeToWAL(w, hdr); err != nil {
+		if _, err := tx.writeToWAL(w, pgno, hdr, pageCipherNone); err != nil {
 			return fmt.Errorf("write bitmap header page to wal: %w", err)
 		}
 
 		// Write bitmap page.
-		walID, err := tx.writeToWAL(w, tx.dirtyBitmapPages[pgno])
+		walID, err := tx.writeToWAL(w, pgno, tx.dirtyBitmapPages[pgno], pageCipherFull)
 		if err != nil {
 			return fmt.Errorf("write bitmap page to wal: %w", err)
 		}
@@ -2001,33 +2017,66 @@ func (tx *Tx) flush() error {
 	// the whole that's better for further observability and debugging.
 
 	// Write meta page to WAL.
-	walID, err := tx.writeToWAL(w, tx.meta[:])
+	walID, err := tx.writeToWAL(w, 0, tx.meta[:], pageCipherNone)
 	if err != nil {
 		return fmt.Errorf("write meta page to wal: %w", err)
 	}
 	tx.pageMap = tx.pageMap.Set(uint32(0), walID)
 
-	// Flush & sync WAL.
+	// Flush WAL & persist the checksums & encryption entries of the new WAL
+	// pages before the WAL itself is synced, so a committed page always has
+	// them.
 	if err := w.Flush(); err != nil {
 		return fmt.Errorf("flush wal: %w", err)
-	} else if err := tx.db.fsyncWAL(tx.db.walFile); err != nil {
+	}
+	if tx.db.walChecksums != nil {
+		if err := tx.db.walChecksums.sync(tx.db.cfg.FsyncWALEnabled); err != nil {
+			return fmt.Errorf("sync wal checksums: %w", err)
+		}
+	}
+	if tx.db.walCiphers != nil {
+		if err := tx.db.walCiphers.sync(tx.db.cfg.FsyncWALEnabled); err != nil {
+			return fmt.Errorf("sync wal encryption: %w", err)
+		}
+	}
+	if err := tx.db.fsyncWAL(tx.db.walFile); err != nil {
 		return fmt.Errorf("sync wal: %w", err)
 	}
 
 	return nil
 }
 
-func (tx *Tx) writeToWAL(w io.Writer, page []byte) (walID int64, err error) {
+// writeToWAL appends a page to the WAL. If the database is encrypted, the page
+// is encrypted with the given mode before it is written.
+func (tx *Tx) writeToWAL(w io.Writer, pgno uint32, page []byte, mode uint32) (walID int64, err error) {
 	// Determine next WAL ID from cached meta page.
 	walID = readMetaWALID(tx.meta[:]) + 1
 
 	// Update WAL ID on cached meta page.
 	writeMetaWALID(tx.meta[:], walID)
 
+	// Encrypt page, if needed. The meta page is written after its WAL ID
+	// is updated above so it must never be encrypted.
+	if tx.db.walCiphers != nil {
+		var e cipherEntry
+		if mode != pageCipherNone {
+			buf := allocPage()
+			defer freePage(buf)
+			if e, err = tx.db.encryptPage(buf, pgno, page, mode); err != nil {
+				return 0, err
+			}
+			page = buf
+		}
+		tx.db.walCiphers.set(tx.walPageN, e)
+	}
+
 	// Append to WAL and increment WAL size.
 	if _, err := w.Write(page); err != nil {
 		return 0, err
 	}
+	if tx.db.walChecksums != nil {
+		tx.db.walChecksums.set(tx.walPageN, pageChecksum(page))
+	}
 	tx.walPageN++
 
 	return walID, nil
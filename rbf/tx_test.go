@@ -6,6 +6,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"path/filepath"
@@ -1096,6 +1097,65 @@ func BenchmarkTx_Contains(b *testing.B) {
 	}
 }
 
+func TestTx_WALID(t *testing.T) {
+	db := MustOpenDB(t)
+	defer MustCloseDB(t, db)
+
+	walID := func() int64 {
+		tx := MustBegin(t, db, false)
+		defer tx.Rollback()
+		return tx.WALID()
+	}
+
+	v0 := walID()
+	tx := MustBegin(t, db, true)
+	if _, err := tx.Add("x", 1, 2, 3); err != nil {
+		t.Fatal(err)
+	} else if err := tx.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	v1 := walID()
+	if v1 <= v0 {
+		t.Fatalf("expected WAL ID to increase after write: %d -> %d", v0, v1)
+	}
+
+	// Checkpoints do not change the version of the database.
+	if err := db.Checkpoint(); err != nil {
+		t.Fatal(err)
+	} else if v := walID(); v != v1 {
+		t.Fatalf("unexpected WAL ID after checkpoint: %d, want %d", v, v1)
+	}
+
+	// A database restored from a snapshot has the same version.
+	path := t.TempDir()
+	if err := func() error {
+		tx := MustBegin(t, db, false)
+		defer tx.Rollback()
+		r, err := tx.SnapshotReader()
+		if err != nil {
+			return err
+		}
+		f, err := os.Create(filepath.Join(path, "data"))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if _, err := io.Copy(f, r); err != nil {
+			return err
+		}
+		return f.Close()
+	}(); err != nil {
+		t.Fatal(err)
+	}
+	other := MustOpenDBAt(t, path)
+	defer MustCloseDB(t, other)
+	tx = MustBegin(t, other, false)
+	defer tx.Rollback()
+	if v := tx.WALID(); v != v1 {
+		t.Fatalf("unexpected WAL ID after restore: %d, want %d", v, v1)
+	}
+}
+
 func TestTx_CreateBitmap(t *testing.T) {
 	t.Run("Bulk", func(t *testing.T) {
 		db := MustOpenDB(t)
@@ -0,0 +1,252 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/disco"
+	"github.com/featurebasedb/featurebase/v3/rbf"
+	"github.com/pkg/errors"
+)
+
+// ErrReadReplica is returned when a write is sent to a read replica.
+var ErrReadReplica = errors.New("node is a read replica")
+
+// ReadReplicaStatus describes how current a read replica's copy of the
+// cluster's data is.
+type ReadReplicaStatus struct {
+	// SyncedAt is the start time of the last complete sync. Every write
+	// committed before it is reflected in the replica.
+	SyncedAt time.Time `json:"syncedAt"`
+
+	// Staleness is the time since SyncedAt, in seconds.
+	Staleness float64 `json:"staleness"`
+
+	// MaxStaleness is the staleness, in seconds, beyond which queries are
+	// forwarded to the nodes which own the data.
+	MaxStaleness float64 `json:"maxStaleness"`
+}
+
+// readReplica tracks the freshness of a read replica's copy of the cluster's
+// data. Read replicas own no partitions; instead they periodically copy every
+// shard which has changed from the nodes which own it.
+type readReplica struct {
+	maxStaleness time.Duration
+	interval     time.Duration
+
+	mu       sync.RWMutex
+	syncedAt time.Time
+
+	// copied is the source of the local copy of each shard. Shard versions
+	// are only comparable between versions of the same node's copy of a
+	// shard, so a shard is copied again when its source's version changes,
+	// or it's copied from a different node.
+	copied map[replicaShard]replicaShardSource
+}
+
+// replicaShard identifies a shard of an index. createdAt distinguishes
+// indexes which were deleted and created again with the same name.
+type replicaShard struct {
+	index     string
+	createdAt int64
+	shard     uint64
+}
+
+// replicaShardSource is the node a shard was copied from, and the version of
+// the shard on that node.
+type replicaShardSource struct {
+	node    string
+	version int64
+}
+
+// staleness returns the time since the replica was last fully synced.
+func (r *readReplica) staleness() time.Duration {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.syncedAt.IsZero() {
+		return time.Duration(1<<63 - 1)
+	}
+	return time.Since(r.syncedAt)
+}
+
+// fresh returns true if the replica may serve queries from its own data.
+func (r *readReplica) fresh() bool {
+	return r.staleness() <= r.maxStaleness
+}
+
+func (r *readReplica) setSyncedAt(t time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.syncedAt = t
+}
+
+// source returns the source of the local copy of a shard, if any.
+func (r *readReplica) source(shard replicaShard) (replicaShardSource, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	src, ok := r.copied[shard]
+	return src, ok
+}
+
+func (r *readReplica) setSource(shard replicaShard, src replicaShardSource) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.copied == nil {
+		r.copied = make(map[replicaShard]replicaShardSource)
+	}
+	r.copied[shard] = src
+}
+
+func (r *readReplica) status() *ReadReplicaStatus {
+	r.mu.RLock()
+	syncedAt := r.syncedAt
+	r.mu.RUnlock()
+
+	status := &ReadReplicaStatus{
+		SyncedAt:     syncedAt,
+		MaxStaleness: r.maxStaleness.Seconds(),
+	}
+	if !syncedAt.IsZero() {
+		status.Staleness = time.Since(syncedAt).Seconds()
+	}
+	return status
+}
+
+// shardVersions returns the version of every open shard in an index. A
+// shard's version changes whenever it is written. Versions of a shard on
+// different nodes can't be compared.
+func (h *Holder) shardVersions(index string) (map[uint64]int64, error) {
+	versions := make(map[uint64]int64)
+	for _, dbs := range h.dbShards(index) {
+		w, ok := dbs.W.(*RbfDBWrapper)
+		if !ok {
+			continue
+		}
+
+		tx, err := w.db.Begin(false)
+		if err == rbf.ErrClosed {
+			continue
+		} else if err != nil {
+			return nil, errors.Wrapf(err, "beginning transaction for shard %d", dbs.Shard)
+		}
+		versions[dbs.Shard] = tx.WALID()
+		tx.Rollback()
+	}
+	return versions, nil
+}
+
+// monitorReadReplica periodically copies changed shards from the nodes which
+// own them.
+func (s *Server) monitorReadReplica() {
+	ticker := time.NewTicker(s.readReplica.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			if err := s.syncReadReplica(context.Background()); err != nil {
+				s.logger.Errorf("syncing read replica: %v", err)
+			}
+			GaugeReadReplicaStalenessSeconds.Set(s.readReplica.staleness().Seconds())
+		}
+	}
+}
+
+// syncReadReplica copies every shard which differs from the latest version
+// held by the nodes which own it. The replica is only marked as synced if
+// every index was synced.
+func (s *Server) syncReadReplica(ctx context.Context) error {
+	start := time.Now()
+	snap := s.cluster.NewSnapshot()
+	for _, idx := range s.holder.Indexes() {
+		select {
+		case <-s.closing:
+			return nil
+		default:
+		}
+		if err := s.syncReadReplicaIndex(ctx, snap, idx.Name()); err != nil {
+			return errors.Wrapf(err, "syncing index %s", idx.Name())
+		}
+	}
+	s.readReplica.setSyncedAt(start)
+	return nil
+}
+
+// syncReadReplicaIndex copies the changed shards of an index. Each shard is
+// copied from the first of its owners which has it, so that its version can
+// be compared with the version last copied.
+func (s *Server) syncReadReplicaIndex(ctx context.Context, snap *disco.ClusterSnapshot, index string) error {
+	idx := s.holder.Index(index)
+	if idx == nil {
+		return nil
+	}
+
+	var errs []error
+	versions := make(map[string]map[uint64]int64)
+	shards := make(map[uint64]struct{})
+	for _, node := range snap.Nodes {
+		v, err := s.defaultClient.ShardVersionsNode(ctx, &node.URI, index)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", node.ID, err))
+			continue
+		}
+		versions[node.ID] = v
+		for shard := range v {
+			if snap.OwnsShard(node.ID, index, shard) {
+				shards[shard] = struct{}{}
+			}
+		}
+	}
+
+	local, err := s.holder.shardVersions(index)
+	if err != nil {
+		return errors.Wrap(err, "getting local shard versions")
+	}
+
+	for shard := range shards {
+		var src *disco.Node
+		var version int64
+		for _, node := range snap.ShardNodes(index, shard) {
+			if v, ok := versions[node.ID][shard]; ok {
+				src, version = node, v
+				break
+			}
+		}
+		if src == nil {
+			continue
+		}
+
+		key := replicaShard{index: index, createdAt: idx.CreatedAt(), shard: shard}
+		if _, ok := local[shard]; ok {
+			if cur, ok := s.readReplica.source(key); ok && cur.node == src.ID && cur.version == version {
+				continue
+			}
+		}
+		if err := func() error {
+			rc, err := s.defaultClient.ShardReaderNode(ctx, &src.URI, index, shard)
+			if err != nil {
+				return errors.Wrap(err, "fetching snapshot")
+			}
+			defer rc.Close()
+			return s.defaultClient.api.restoreShard(ctx, index, shard, rc)
+		}(); err != nil {
+			errs = append(errs, fmt.Errorf("shard %d from node %s: %w", shard, src.ID, err))
+			continue
+		}
+		// The snapshot is at least as new as version, so if it's newer, the
+		// shard is just copied again next time.
+		s.readReplica.setSource(key, replicaShardSource{node: src.ID, version: version})
+		CounterReadReplicaShardSyncs.WithLabelValues(index).Inc()
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("%d errors: %v", len(errs), errs)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa_test
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/roaring"
+	"github.com/featurebasedb/featurebase/v3/server"
+	"github.com/featurebasedb/featurebase/v3/test"
+	"github.com/pkg/errors"
+)
+
+func TestReadReplica(t *testing.T) {
+	ctx := context.Background()
+	c := test.MustRunUnsharedCluster(t, 3,
+		[]server.CommandOption{},
+		[]server.CommandOption{},
+		[]server.CommandOption{
+			server.OptCommandServerOptions(pilosa.OptServerReadReplica(time.Hour, 50*time.Millisecond)),
+		},
+	)
+	defer c.Close()
+
+	replica := c.GetIdleNode(2)
+	if !replica.API.Node().IsReadReplica {
+		t.Fatal("expected node to be a read replica")
+	} else if replica.IsPrimary() {
+		t.Fatal("read replica should not be the primary")
+	}
+
+	index := c.Idx()
+	c.CreateField(t, index, pilosa.IndexOptions{}, "f")
+	var bits [][2]uint64
+	for shard := uint64(0); shard < 8; shard++ {
+		bits = append(bits, [2]uint64{1, shard*pilosa.ShardWidth + shard})
+	}
+	c.ImportBits(t, index, "f", bits)
+
+	// Read replicas do not own any shards.
+	for shard := uint64(0); shard < 8; shard++ {
+		nodes, err := c.GetPrimary().API.ShardNodes(ctx, index, shard)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, node := range nodes {
+			if node.ID == replica.ID() {
+				t.Fatalf("read replica owns shard %d", shard)
+			}
+		}
+	}
+
+	// awaitSync waits for a sync which started after the last write to
+	// complete, then checks that the replica has every shard its owners have.
+	awaitSync := func() {
+		t.Helper()
+		since := time.Now()
+		deadline := since.Add(10 * time.Second)
+		for !replica.API.ReadReplicaStatus().SyncedAt.After(since) {
+			if time.Now().After(deadline) {
+				t.Fatal("timed out waiting for read replica to sync")
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		want := roaring.NewBitmap()
+		for _, n := range c.Nodes {
+			if n != replica {
+				want.UnionInPlace(n.Server.Holder().Index(index).AvailableShards(true))
+			}
+		}
+		if got := replica.Server.Holder().Index(index).AvailableShards(true); !reflect.DeepEqual(got.Slice(), want.Slice()) {
+			t.Fatalf("replica did not sync: got shards %v, want %v", got.Slice(), want.Slice())
+		}
+	}
+
+	count := func() uint64 {
+		t.Helper()
+		resp := replica.QueryAPI(t, &pilosa.QueryRequest{Index: index, Query: "Count(Row(f=1))"})
+		return resp.Results[0].(uint64)
+	}
+
+	awaitSync()
+	if n := count(); n != 8 {
+		t.Fatalf("unexpected count: %d", n)
+	}
+
+	// Changes are copied to the replica.
+	c.ImportBits(t, index, "f", [][2]uint64{{1, 100}})
+	awaitSync()
+	if n := count(); n != 9 {
+		t.Fatalf("unexpected count after update: %d", n)
+	}
+
+	if status := replica.API.ReadReplicaStatus(); status == nil || status.SyncedAt.IsZero() {
+		t.Fatalf("unexpected status: %+v", status)
+	} else if c.GetPrimary().API.ReadReplicaStatus() != nil {
+		t.Fatal("expected no read replica status on primary")
+	}
+
+	// Writes are rejected.
+	if _, err := replica.API.Query(ctx, &pilosa.QueryRequest{Index: index, Query: "Set(200, f=1)"}); errors.Cause(err) != pilosa.ErrReadReplica {
+		t.Fatalf("unexpected error: %v", err)
+	} else if _, err := replica.API.CreateField(ctx, index, "g"); err == nil {
+		t.Fatal("expected error creating field on read replica")
+	}
+}
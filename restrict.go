@@ -0,0 +1,217 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+
+package pilosa
+
+import (
+	"context"
+
+	"github.com/featurebasedb/featurebase/v3/authz"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/pkg/errors"
+)
+
+// ErrAccessRestricted is returned when a query accesses fields or records
+// which the user's restrictions don't allow.
+var ErrAccessRestricted = errors.New("access restricted")
+
+// restrictQuery enforces the restrictions on the access to index of the user
+// whose access is carried by ctx, if any. Calls which access fields the user
+// may not are rejected, and when the user has a row filter, each call is
+// rewritten to only access the records selected by it.
+func restrictQuery(ctx context.Context, index string, q *pql.Query) error {
+	access := authz.AccessFromContext(ctx)
+	if access == nil {
+		return nil
+	}
+	r, err := access.Restrictions(index)
+	if err != nil {
+		return errors.Wrap(err, "getting restrictions")
+	}
+	for _, call := range q.Calls {
+		if err := checkCallAccess(access, index, r, call); err != nil {
+			return err
+		}
+	}
+	if r == nil || r.RowFilter == nil {
+		return nil
+	}
+	for i, call := range q.Calls {
+		restricted, err := restrictCall(call, r.RowFilter)
+		if err != nil {
+			return err
+		}
+		q.Calls[i] = restricted
+	}
+	return nil
+}
+
+// fieldArgCalls are the calls whose non-reserved arguments are all field
+// names.
+var fieldArgCalls = map[string]bool{
+	"Bitmap":    true,
+	"Row":       true,
+	"Range":     true,
+	"Condition": true,
+	"Set":       true,
+	"SetBit":    true,
+	"Clear":     true,
+	"ClearRow":  true,
+	"Store":     true,
+}
+
+// checkCallAccess returns an error if c, or any call it's made of, accesses
+// a field which r doesn't allow. Calls on other indexes are checked against
+// the restrictions on those indexes.
+func checkCallAccess(access *authz.Access, index string, r *authz.Restrictions, c *pql.Call) error {
+	if callIndex := c.CallIndex(); callIndex != "" && callIndex != index {
+		if !access.Satisfies(callIndex, authz.Read) {
+			return errors.Wrapf(ErrAccessRestricted, "%s: no permission to index %s", c.Name, callIndex)
+		}
+		other, err := access.Restrictions(callIndex)
+		if err != nil {
+			return errors.Wrap(err, "getting restrictions")
+		} else if other != nil && other.RowFilter != nil {
+			return errors.Wrapf(ErrAccessRestricted, "%s: index %s has a row filter", c.Name, callIndex)
+		}
+		index, r = callIndex, other
+	}
+
+	if r != nil {
+		switch c.Name {
+		case "Apply", "Arrow", "ExternalLookup":
+			return errors.Wrapf(ErrAccessRestricted, "%s is not allowed on index %s", c.Name, index)
+		case "Shift":
+			if r.RowFilter != nil {
+				return errors.Wrapf(ErrAccessRestricted, "%s is not allowed on index %s", c.Name, index)
+			}
+		}
+		for name, arg := range c.Args {
+			field := ""
+			switch {
+			case name == "field" || name == "_field":
+				field, _ = arg.(string)
+			case fieldArgCalls[c.Name] && !pql.IsReservedArg(name):
+				field = name
+			}
+			if field != "" && !r.FieldAllowed(field) {
+				return errors.Wrapf(ErrAccessRestricted, "field %s", field)
+			}
+		}
+	}
+
+	for _, child := range c.Children {
+		if err := checkCallAccess(access, index, r, child); err != nil {
+			return err
+		}
+	}
+	for name, arg := range c.Args {
+		// The having argument of GroupBy refers to aggregates, not fields.
+		if child, ok := arg.(*pql.Call); ok && name != "having" {
+			if err := checkCallAccess(access, index, r, child); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// restrictCall returns c rewritten to only access the records selected by
+// filter. Calls which can't be rewritten that way, such as Rows, are
+// rejected.
+func restrictCall(c *pql.Call, filter *pql.Call) (*pql.Call, error) {
+	c = restrictLimits(c, filter)
+	intersect := func(child *pql.Call) *pql.Call {
+		return &pql.Call{Name: "Intersect", Children: []*pql.Call{child, filter.Clone()}}
+	}
+
+	switch c.Name {
+	case "Row", "Bitmap", "Range", "Union", "Intersect", "Difference", "Xor", "Not",
+		"UnionRows", "InnerUnionRows", "ConstRow", "Precomputed", "All":
+		return intersect(c), nil
+
+	case "Limit", "Sample":
+		// Already restricted by restrictLimits.
+		return c, nil
+
+	case "Count", "Extract", "Delete", "IncludesColumn", "Sort":
+		if len(c.Children) == 0 {
+			return c, nil
+		}
+		if c.Name == "Count" && c.Children[0].Name == "Distinct" {
+			distinct, err := restrictCall(c.Children[0], filter)
+			if err != nil {
+				return nil, err
+			}
+			c.Children[0] = distinct
+			return c, nil
+		}
+		c.Children[0] = intersect(c.Children[0])
+		return c, nil
+
+	case "Sum", "Min", "Max", "MinRow", "MaxRow", "Distinct", "TopN":
+		if len(c.Children) == 0 {
+			c.Children = []*pql.Call{filter.Clone()}
+		} else {
+			c.Children[0] = intersect(c.Children[0])
+		}
+		return c, nil
+
+	case "Percentile", "TopK", "GroupBy", "Funnel", "Retention", "Similar":
+		if c.Args == nil {
+			c.Args = make(map[string]interface{})
+		}
+		if child, ok := c.Args["filter"].(*pql.Call); ok {
+			c.Args["filter"] = intersect(child)
+		} else {
+			c.Args["filter"] = filter.Clone()
+		}
+		return c, nil
+
+	case "Options":
+		if len(c.Children) != 1 {
+			return c, nil
+		}
+		child, err := restrictCall(c.Children[0], filter)
+		if err != nil {
+			return nil, err
+		}
+		c.Children[0] = child
+		return c, nil
+
+	case "Set", "Clear":
+		// Writing individual records is governed by write permission
+		// alone, as it is for imports.
+		return c, nil
+	}
+	return nil, errors.Wrapf(ErrAccessRestricted, "%s is not allowed with a row filter", c.Name)
+}
+
+// restrictLimits returns c with every limit or sample within it applied to
+// the records selected by filter, rather than to all of them, so that
+// filtering its result doesn't return fewer records than asked for.
+func restrictLimits(c *pql.Call, filter *pql.Call) *pql.Call {
+	for i, child := range c.Children {
+		c.Children[i] = restrictLimits(child, filter)
+	}
+	for name, arg := range c.Args {
+		if child, ok := arg.(*pql.Call); ok {
+			c.Args[name] = restrictLimits(child, filter)
+		}
+	}
+
+	switch c.Name {
+	case "Limit", "Sample":
+		if len(c.Children) == 1 {
+			c.Children[0] = &pql.Call{Name: "Intersect", Children: []*pql.Call{c.Children[0], filter.Clone()}}
+		}
+	case "All":
+		_, hasLimit := c.Args["limit"]
+		_, hasOffset := c.Args["offset"]
+		if hasLimit || hasOffset {
+			all := &pql.Call{Name: "Intersect", Children: []*pql.Call{{Name: "All"}, filter.Clone()}}
+			return &pql.Call{Name: "Limit", Args: c.Args, Children: []*pql.Call{all}, Type: pql.PrecallGlobal}
+		}
+	}
+	return c
+}
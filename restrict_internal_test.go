@@ -0,0 +1,104 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/featurebasedb/featurebase/v3/authz"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/pkg/errors"
+)
+
+func TestRestrictQuery(t *testing.T) {
+	var perms authz.GroupPermissions
+	if err := perms.ReadPermissionsFile(strings.NewReader(`user-groups:
+  "filtered":
+    "i": "write"
+    "j": "read"
+  "fields":
+    "i": "read"
+    "k": "read"
+field-permissions:
+  "filtered":
+    "i":
+      deny: ["ssn"]
+  "fields":
+    "i":
+      allow: ["a", "b"]
+row-filters:
+  "filtered":
+    "i": 'Row(region="us")'
+    "j": 'Row(x=1)'
+admin: "admins"`)); err != nil {
+		t.Fatal(err)
+	}
+	access := func(group string) context.Context {
+		user := &authn.UserInfo{UserID: group, Groups: []authn.Group{{GroupID: group}}}
+		return authz.WithAccess(context.Background(), perms.NewAccess(user))
+	}
+
+	tests := []struct {
+		group string
+		query string
+		exp   string // empty if an error is expected
+	}{
+		// row filter
+		{group: "filtered", query: `Row(a=1)`, exp: `Intersect(Row(a=1), Row(region="us"))`},
+		{group: "filtered", query: `Count(Union(Row(a=1), Row(b=2)))`, exp: `Count(Intersect(Union(Row(a=1), Row(b=2)), Row(region="us")))`},
+		{group: "filtered", query: `Count(Distinct(field=a))`, exp: `Count(Distinct(Row(region="us"), field="a"))`},
+		{group: "filtered", query: `All()`, exp: `Intersect(All(), Row(region="us"))`},
+		{group: "filtered", query: `All(limit=10)`, exp: `Limit(Intersect(All(), Row(region="us")), limit=10)`},
+		{group: "filtered", query: `Sum(field=a)`, exp: `Sum(Row(region="us"), _field="a")`},
+		{group: "filtered", query: `TopN(a, Row(b=1))`, exp: `TopN(Intersect(Row(b=1), Row(region="us")), _field="a")`},
+		{group: "filtered", query: `GroupBy(Rows(a))`, exp: `GroupBy(Rows(_field="a"), filter=Row(region="us"))`},
+		{group: "filtered", query: `Extract(All(), Rows(a))`, exp: `Extract(Intersect(All(), Row(region="us")), Rows(_field="a"))`},
+		{group: "filtered", query: `Options(Row(a=1), shards=[0])`, exp: `Options(Intersect(Row(a=1), Row(region="us")), shards=[0])`},
+		{group: "filtered", query: `Set(1, a=1)`, exp: `Set(_col=1, a=1)`},
+		{group: "filtered", query: `Rows(a)`},
+		{group: "filtered", query: `FieldValue(field=a, column=1)`},
+		{group: "filtered", query: `ClearRow(a=1)`},
+		{group: "filtered", query: `Shift(Row(a=1), n=1)`},
+		{group: "filtered", query: `Count(Row(ssn=1))`},
+		{group: "filtered", query: `Count(Distinct(index=j, field=x))`},
+
+		// field permissions only
+		{group: "fields", query: `Count(Row(a=1))`, exp: `Count(Row(a=1))`},
+		{group: "fields", query: `Rows(b)`, exp: `Rows(_field="b")`},
+		{group: "fields", query: `Count(Row(c=1))`},
+		{group: "fields", query: `GroupBy(Rows(a), aggregate=Sum(field=c))`},
+		{group: "fields", query: `GroupBy(Rows(a), having=Condition(count > 1))`, exp: `GroupBy(Rows(_field="a"), having=Condition(count>1))`},
+		{group: "fields", query: `Apply("_ + 1")`},
+		{group: "fields", query: `Count(Distinct(index=k, field=x))`, exp: `Count(Distinct(field="x", index="k"))`},
+		{group: "fields", query: `Count(Distinct(index=j, field=x))`},
+	}
+	for i, test := range tests {
+		q, err := pql.ParseString(test.query)
+		if err != nil {
+			t.Fatalf("test %d: parsing %s: %v", i, test.query, err)
+		}
+		err = restrictQuery(access(test.group), "i", q)
+		if test.exp == "" {
+			if errors.Cause(err) != ErrAccessRestricted {
+				t.Errorf("test %d: expected restricted error for %s, got %v (%s)", i, test.query, err, q)
+			}
+			continue
+		} else if err != nil {
+			t.Errorf("test %d: %s: %v", i, test.query, err)
+		} else if got := q.String(); got != test.exp {
+			t.Errorf("test %d: expected:\n%s\ngot:\n%s", i, test.exp, got)
+		}
+	}
+
+	// Without access in the context, queries are unchanged.
+	q, err := pql.ParseString(`Rows(ssn)`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := restrictQuery(context.Background(), "i", q); err != nil || q.String() != `Rows(_field="ssn")` {
+		t.Fatalf("expected unrestricted query, got %s, %v", q, err)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa_test
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/featurebasedb/featurebase/v3/authz"
+	"github.com/featurebasedb/featurebase/v3/test"
+	"github.com/pkg/errors"
+)
+
+func TestExecutor_Restrictions(t *testing.T) {
+	c := test.MustRunCluster(t, 3)
+	defer c.Close()
+
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "region", pilosa.OptFieldKeys())
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "age", pilosa.OptFieldTypeInt(0, 1000))
+	c.CreateField(t, c.Idx(), pilosa.IndexOptions{TrackExistence: true}, "ssn", pilosa.OptFieldTypeInt(0, 1000))
+	// Records 1, 2 and 3 are in "us", spread over shards; 4 and 5 aren't.
+	c.Query(t, c.Idx(), fmt.Sprintf(`
+		Set(1, region="us") Set(1, age=10) Set(1, ssn=1)
+		Set(%[1]d, region="us") Set(%[1]d, age=20) Set(%[1]d, ssn=2)
+		Set(%[2]d, region="us") Set(%[2]d, age=30) Set(%[2]d, ssn=3)
+		Set(4, region="eu") Set(4, age=40) Set(4, ssn=4)
+		Set(%[3]d, region="eu") Set(%[3]d, age=50) Set(%[3]d, ssn=5)
+	`, ShardWidth+2, 2*ShardWidth+3, 2*ShardWidth+5))
+
+	var perms authz.GroupPermissions
+	if err := perms.ReadPermissionsFile(strings.NewReader(fmt.Sprintf(`user-groups:
+  "us":
+    %[1]q: "read"
+field-permissions:
+  "us":
+    %[1]q:
+      deny: ["ssn"]
+row-filters:
+  "us":
+    %[1]q: 'Row(region="us")'
+admin: "admins"`, c.Idx()))); err != nil {
+		t.Fatal(err)
+	}
+	user := &authn.UserInfo{UserID: "u", Groups: []authn.Group{{GroupID: "us"}}}
+	ctx := authz.WithAccess(context.Background(), perms.NewAccess(user))
+
+	query := func(pql string) (interface{}, error) {
+		resp, err := c.GetNode(0).API.Query(ctx, &pilosa.QueryRequest{Index: c.Idx(), Query: pql})
+		if err != nil {
+			return nil, err
+		}
+		return resp.Results[0], nil
+	}
+
+	tests := []struct {
+		query string
+		exp   interface{}
+	}{
+		{`Count(All())`, uint64(3)},
+		{`Count(Row(age > 15))`, uint64(2)},
+		{`Count(Not(Row(region="us")))`, uint64(0)},
+		{`Count(Limit(All(), limit=2))`, uint64(2)},
+		{`Count(All(limit=10))`, uint64(3)},
+		{`Count(Sample(All(), n=2))`, uint64(2)},
+		{`Count(Sample(All(), n=5))`, uint64(3)},
+		{`Sum(field=age)`, pilosa.ValCount{Val: 60, Count: 3}},
+		{`Max(field=age)`, pilosa.ValCount{Val: 30, Count: 1}},
+		{`Count(Distinct(field=age))`, uint64(3)},
+	}
+	for _, test := range tests {
+		got, err := query(test.query)
+		if err != nil {
+			t.Fatalf("%s: %v", test.query, err)
+		} else if !reflect.DeepEqual(got, test.exp) {
+			t.Fatalf("%s: expected %v, got %v", test.query, test.exp, got)
+		}
+	}
+
+	got, err := query(`Row(age > 15)`)
+	if err != nil {
+		t.Fatal(err)
+	} else if cols := got.(*pilosa.Row).Columns(); !reflect.DeepEqual(cols, []uint64{ShardWidth + 2, 2*ShardWidth + 3}) {
+		t.Fatalf("unexpected columns %v", cols)
+	}
+
+	got, err = query(`GroupBy(Rows(region))`)
+	if err != nil {
+		t.Fatal(err)
+	} else if groups := got.(*pilosa.GroupCounts).Groups(); len(groups) != 1 || groups[0].Count != 3 {
+		t.Fatalf("unexpected groups %+v", groups)
+	}
+
+	for _, pql := range []string{`Count(Row(ssn=1))`, `Sum(field=ssn)`, `Rows(region)`, `FieldValue(field=age, column=4)`} {
+		if _, err := query(pql); errors.Cause(err) != pilosa.ErrAccessRestricted {
+			t.Fatalf("%s: expected %v, got %v", pql, pilosa.ErrAccessRestricted, err)
+		}
+	}
+}
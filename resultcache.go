@@ -0,0 +1,255 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"container/list"
+	"context"
+	"sync"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+)
+
+// resultCache memoizes the results of mapping calls over shards. Results are
+// keyed by the call and the version of the shard they were computed from, so
+// a write to a shard makes its cached results unreachable, and they're dropped
+// the next time a result for the shard is stored. The least recently used
+// results are evicted once the cache holds maxEntries of them.
+//
+// Only results of types copyResultCacheValue knows how to copy are cached,
+// since reducing them may modify them in place.
+type resultCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	lru        *list.List // of *resultCacheEntry, most recently used first
+	entries    map[resultCacheKey]*list.Element
+	shards     map[resultCacheShard]*resultCacheShardEntries
+}
+
+// resultCacheShard identifies a shard of an index. createdAt distinguishes
+// indexes which were deleted and created again with the same name.
+type resultCacheShard struct {
+	index     string
+	createdAt int64
+	shard     uint64
+}
+
+type resultCacheKey struct {
+	resultCacheShard
+	call string
+}
+
+type resultCacheEntry struct {
+	key   resultCacheKey
+	value interface{}
+}
+
+// resultCacheShardEntries are the cached results for a shard, all of which
+// are for the same version of it.
+type resultCacheShardEntries struct {
+	version  resultCacheVersion
+	elements map[*list.Element]struct{}
+}
+
+// resultCacheVersion is a version of a shard: the ID of the last page written
+// to the WAL of the shard's database, and the generation of the files that
+// ID is for, since WAL IDs aren't comparable once the files are replaced.
+type resultCacheVersion struct {
+	generation uint64
+	walID      int64
+}
+
+// before returns true if v is an older version than other.
+func (v resultCacheVersion) before(other resultCacheVersion) bool {
+	if v.generation != other.generation {
+		return v.generation < other.generation
+	}
+	return v.walID < other.walID
+}
+
+func newResultCache(maxEntries int) *resultCache {
+	return &resultCache{
+		maxEntries: maxEntries,
+		lru:        list.New(),
+		entries:    make(map[resultCacheKey]*list.Element),
+		shards:     make(map[resultCacheShard]*resultCacheShardEntries),
+	}
+}
+
+// get returns a copy of the result cached for key at a version of a shard.
+func (rc *resultCache) get(key resultCacheKey, version resultCacheVersion) (interface{}, bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	if s, ok := rc.shards[key.resultCacheShard]; !ok || s.version != version {
+		return nil, false
+	}
+	elem, ok := rc.entries[key]
+	if !ok {
+		return nil, false
+	}
+	rc.lru.MoveToFront(elem)
+	v, _ := copyResultCacheValue(elem.Value.(*resultCacheEntry).value)
+	return v, true
+}
+
+// put caches a copy of the result for key at a version of a shard, and drops
+// any results for older versions of the shard. Results for an older version
+// than the latest one seen aren't cached.
+func (rc *resultCache) put(key resultCacheKey, version resultCacheVersion, v interface{}) {
+	v, ok := copyResultCacheValue(v)
+	if !ok {
+		return
+	}
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+
+	s, ok := rc.shards[key.resultCacheShard]
+	if ok && version.before(s.version) {
+		return
+	} else if ok && s.version.before(version) {
+		for elem := range s.elements {
+			rc.remove(elem)
+		}
+		s, ok = nil, false
+	}
+	if !ok {
+		s = &resultCacheShardEntries{version: version, elements: make(map[*list.Element]struct{})}
+		rc.shards[key.resultCacheShard] = s
+	}
+
+	if elem, ok := rc.entries[key]; ok {
+		elem.Value.(*resultCacheEntry).value = v
+		rc.lru.MoveToFront(elem)
+		return
+	}
+	elem := rc.lru.PushFront(&resultCacheEntry{key: key, value: v})
+	rc.entries[key] = elem
+	s.elements[elem] = struct{}{}
+
+	for rc.lru.Len() > rc.maxEntries {
+		rc.remove(rc.lru.Back())
+	}
+	GaugeQueryResultCacheEntries.Set(float64(rc.lru.Len()))
+}
+
+// remove drops a cached result. rc.mu must be held.
+func (rc *resultCache) remove(elem *list.Element) {
+	key := elem.Value.(*resultCacheEntry).key
+	rc.lru.Remove(elem)
+	delete(rc.entries, key)
+	if s, ok := rc.shards[key.resultCacheShard]; ok {
+		delete(s.elements, elem)
+		if len(s.elements) == 0 {
+			delete(rc.shards, key.resultCacheShard)
+		}
+	}
+	GaugeQueryResultCacheEntries.Set(float64(rc.lru.Len()))
+}
+
+// copyResultCacheValue returns a copy of a map result which can be cached, or
+// false if results of its type can't be.
+func copyResultCacheValue(v interface{}) (interface{}, bool) {
+	switch v := v.(type) {
+	case uint64:
+		return v, true
+	case ValCount:
+		return v, true
+	case []GroupCount:
+		groups := make([]GroupCount, len(v))
+		for i, gc := range v {
+			groups[i] = gc
+			groups[i].Group = append([]FieldRow(nil), gc.Group...)
+		}
+		return groups, true
+	default:
+		return nil, false
+	}
+}
+
+// wrap returns a mapFunc which returns the cached result of mapping c over
+// a shard if the shard hasn't been written since it was cached, and otherwise
+// caches the result of mapFn. If the cache is disabled, the call can't be
+// cached, or the query opts out of caching, mapFn is returned unchanged.
+//
+// wrap must be called after c has been modified for execution, since its
+// string representation is part of the key.
+func (rc *resultCache) wrap(qcx *Qcx, index string, c *pql.Call, opt *ExecOptions, mapFn mapFunc) mapFunc {
+	if rc == nil || opt.NoCache || len(opt.EmbeddedData) > 0 || !resultCacheable(c) {
+		return mapFn
+	}
+	call := c.String()
+
+	return func(ctx context.Context, shard uint64, mopt *mapOptions) (interface{}, error) {
+		key, version, ok := rc.shardVersion(qcx, index, shard)
+		if !ok {
+			return mapFn(ctx, shard, mopt)
+		}
+		key.call = call
+
+		if v, ok := rc.get(key, version); ok {
+			CounterQueryResultCacheHits.Inc()
+			return v, nil
+		}
+		CounterQueryResultCacheMisses.Inc()
+
+		v, err := mapFn(ctx, shard, mopt)
+		if err == nil {
+			rc.put(key, version, v)
+		}
+		return v, err
+	}
+}
+
+// shardVersion returns the key of a shard and the version of it which qcx
+// reads, or false if the version isn't known, such as when qcx is for a write.
+// The version comes from the same transaction the call is mapped with, so a
+// result can't be cached for a version other than the one it was computed
+// from.
+func (rc *resultCache) shardVersion(qcx *Qcx, index string, shard uint64) (resultCacheKey, resultCacheVersion, bool) {
+	if qcx.write || qcx.isRoaring || qcx.Grp == nil {
+		return resultCacheKey{}, resultCacheVersion{}, false
+	}
+	idx := qcx.Txf.holder.Index(index)
+	if idx == nil {
+		return resultCacheKey{}, resultCacheVersion{}, false
+	}
+	tx, finisher, err := qcx.GetTx(Txo{Write: !writable, Index: idx, Shard: shard})
+	if err != nil {
+		return resultCacheKey{}, resultCacheVersion{}, false
+	}
+	defer finisher(&err)
+	rtx, ok := tx.(*RBFTx)
+	if !ok {
+		return resultCacheKey{}, resultCacheVersion{}, false
+	}
+	key := resultCacheKey{resultCacheShard: resultCacheShard{index: index, createdAt: idx.CreatedAt(), shard: shard}}
+	// The database can't be reopened while the transaction is open, so the
+	// generation is the one the transaction reads.
+	return key, resultCacheVersion{generation: rtx.Db.Generation(), walID: rtx.tx.WALID()}, true
+}
+
+// resultCacheable returns true if the result of mapping c over a shard depends
+// only on c and the shard's data. Calls carrying data computed across the
+// cluster don't.
+func resultCacheable(c *pql.Call) bool {
+	switch c.Name {
+	case "Precomputed", "ConstRow":
+		return false
+	}
+	if c.Precomputed != nil {
+		return false
+	}
+	for _, child := range c.Children {
+		if !resultCacheable(child) {
+			return false
+		}
+	}
+	for _, arg := range c.Args {
+		if child, ok := arg.(*pql.Call); ok && !resultCacheable(child) {
+			return false
+		}
+	}
+	return true
+}
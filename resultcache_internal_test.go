@@ -0,0 +1,130 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/storage"
+)
+
+func TestResultCache(t *testing.T) {
+	key := func(shard uint64, call string) resultCacheKey {
+		return resultCacheKey{resultCacheShard: resultCacheShard{index: "i", shard: shard}, call: call}
+	}
+	version := func(walID int64) resultCacheVersion {
+		return resultCacheVersion{generation: 1, walID: walID}
+	}
+
+	t.Run("Version", func(t *testing.T) {
+		rc := newResultCache(10)
+		rc.put(key(0, "a"), version(1), uint64(1))
+		rc.put(key(0, "b"), version(1), uint64(2))
+		rc.put(key(1, "a"), version(1), uint64(3))
+		if v, ok := rc.get(key(0, "a"), version(1)); !ok || v != uint64(1) {
+			t.Fatalf("expected 1, got %v, %v", v, ok)
+		}
+		if _, ok := rc.get(key(0, "a"), version(2)); ok {
+			t.Fatal("expected a miss for a newer version")
+		}
+
+		// Storing a result for a newer version of a shard drops the
+		// older ones, but not those of other shards.
+		rc.put(key(0, "a"), version(2), uint64(4))
+		if _, ok := rc.get(key(0, "b"), version(1)); ok {
+			t.Fatal("expected result for old version to be dropped")
+		} else if v, ok := rc.get(key(0, "a"), version(2)); !ok || v != uint64(4) {
+			t.Fatalf("expected 4, got %v, %v", v, ok)
+		} else if v, ok := rc.get(key(1, "a"), version(1)); !ok || v != uint64(3) {
+			t.Fatalf("expected 3, got %v, %v", v, ok)
+		}
+
+		// Results for versions older than the latest aren't stored.
+		rc.put(key(0, "b"), version(1), uint64(5))
+		if _, ok := rc.get(key(0, "b"), version(1)); ok {
+			t.Fatal("expected result for old version not to be stored")
+		}
+	})
+
+	t.Run("Generation", func(t *testing.T) {
+		// Once a shard's files are replaced, its WAL IDs start again, so a
+		// result for the old files with the same WAL ID must not be used.
+		rc := newResultCache(10)
+		rc.put(key(0, "a"), version(5), uint64(1))
+		restored := resultCacheVersion{generation: 2, walID: 5}
+		if _, ok := rc.get(key(0, "a"), restored); ok {
+			t.Fatal("expected a miss for the replaced files")
+		}
+		rc.put(key(0, "a"), resultCacheVersion{generation: 2, walID: 3}, uint64(2))
+		if v, ok := rc.get(key(0, "a"), resultCacheVersion{generation: 2, walID: 3}); !ok || v != uint64(2) {
+			t.Fatalf("expected 2, got %v, %v", v, ok)
+		}
+
+		// A query which read the old files can't store its result.
+		rc.put(key(0, "b"), version(5), uint64(3))
+		if _, ok := rc.get(key(0, "b"), version(5)); ok {
+			t.Fatal("expected result for replaced files not to be stored")
+		}
+
+		// Reopening a database, as restoring a shard does, changes its
+		// generation.
+		dbw, err := newRbfDBRegistrar().OpenDBWrapper(t.TempDir(), false, storage.NewDefaultConfig())
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := dbw.(*RbfDBWrapper)
+		defer w.Close()
+		gen := w.Generation()
+		if err := w.CloseDB(); err != nil {
+			t.Fatal(err)
+		} else if err := w.OpenDB(); err != nil {
+			t.Fatal(err)
+		} else if w.Generation() <= gen {
+			t.Fatalf("expected generation after %d, got %d", gen, w.Generation())
+		}
+	})
+
+	t.Run("Evict", func(t *testing.T) {
+		rc := newResultCache(2)
+		rc.put(key(0, "a"), version(1), uint64(1))
+		rc.put(key(0, "b"), version(1), uint64(2))
+		rc.get(key(0, "a"), version(1))
+		rc.put(key(0, "c"), version(1), uint64(3))
+		if _, ok := rc.get(key(0, "b"), version(1)); ok {
+			t.Fatal("expected least recently used result to be evicted")
+		}
+		for _, call := range []string{"a", "c"} {
+			if _, ok := rc.get(key(0, call), version(1)); !ok {
+				t.Fatalf("expected result for %s to be cached", call)
+			}
+		}
+		if n := rc.lru.Len(); n != 2 {
+			t.Fatalf("expected 2 entries, got %d", n)
+		}
+	})
+
+	t.Run("Copy", func(t *testing.T) {
+		rc := newResultCache(10)
+		groups := []GroupCount{{Group: []FieldRow{{Field: "f", RowID: 1}}, Count: 2}}
+		rc.put(key(0, "a"), version(1), groups)
+
+		// Changing a result doesn't change what's cached.
+		groups[0].Count = 3
+		groups[0].Group[0].RowKey = "x"
+		v, _ := rc.get(key(0, "a"), version(1))
+		exp := []GroupCount{{Group: []FieldRow{{Field: "f", RowID: 1}}, Count: 2}}
+		if !reflect.DeepEqual(v, exp) {
+			t.Fatalf("expected %v, got %v", exp, v)
+		}
+		v.([]GroupCount)[0].Count = 4
+		if v, _ := rc.get(key(0, "a"), version(1)); !reflect.DeepEqual(v, exp) {
+			t.Fatalf("expected %v, got %v", exp, v)
+		}
+
+		rc.put(key(0, "b"), version(1), NewRow(1))
+		if _, ok := rc.get(key(0, "b"), version(1)); ok {
+			t.Fatal("expected rows not to be cached")
+		}
+	})
+}
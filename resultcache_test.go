@@ -0,0 +1,98 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa_test
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/server"
+	"github.com/featurebasedb/featurebase/v3/test"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestQueryResultCache(t *testing.T) {
+	c := test.MustRunUnsharedCluster(t, 3, []server.CommandOption{
+		server.OptCommandServerOptions(pilosa.OptServerQueryResultCacheSize(100)),
+	})
+	defer c.Close()
+
+	index := c.Idx()
+	c.CreateField(t, index, pilosa.IndexOptions{}, "f")
+	c.CreateField(t, index, pilosa.IndexOptions{}, "v", pilosa.OptFieldTypeInt(0, 1000))
+	c.Query(t, index, fmt.Sprintf(`
+		Set(1, f=1) Set(1, v=10)
+		Set(%[1]d, f=1) Set(%[1]d, v=20)
+		Set(%[2]d, f=2) Set(%[2]d, v=30)
+	`, pilosa.ShardWidth+1, 2*pilosa.ShardWidth+1))
+
+	hits := func() float64 { return testutil.ToFloat64(pilosa.CounterQueryResultCacheHits) }
+	misses := func() float64 { return testutil.ToFloat64(pilosa.CounterQueryResultCacheMisses) }
+
+	// query runs a query twice, checking it returns exp both times, and that
+	// the second time every shard's result came from the cache if cached is
+	// set.
+	query := func(t *testing.T, q string, exp interface{}, cached bool) {
+		t.Helper()
+		for i := 0; i < 2; i++ {
+			h, m := hits(), misses()
+			if got := c.Query(t, index, q).Results[0]; !reflect.DeepEqual(got, exp) {
+				t.Fatalf("%s: expected %v, got %v", q, exp, got)
+			}
+			if i == 0 {
+				continue
+			}
+			if cached && (hits()-h != 3 || misses() != m) {
+				t.Fatalf("%s: expected 3 hits and no misses, got %v and %v", q, hits()-h, misses()-m)
+			} else if !cached && (hits() != h || misses() != m) {
+				t.Fatalf("%s: expected the cache not to be used, got %v hits and %v misses", q, hits()-h, misses()-m)
+			}
+		}
+	}
+
+	t.Run("Count", func(t *testing.T) {
+		query(t, `Count(Row(f=1))`, uint64(2), true)
+	})
+
+	t.Run("Sum", func(t *testing.T) {
+		query(t, `Sum(Row(f=1), field=v)`, pilosa.ValCount{Val: 30, Count: 2}, true)
+	})
+
+	t.Run("GroupBy", func(t *testing.T) {
+		exp := []string{"f=1: 2", "f=2: 1"}
+		q := `GroupBy(Rows(f))`
+		for i := 0; i < 2; i++ {
+			h := hits()
+			var got []string
+			for _, gc := range c.Query(t, index, q).Results[0].(*pilosa.GroupCounts).Groups() {
+				got = append(got, fmt.Sprintf("%s=%d: %d", gc.Group[0].Field, gc.Group[0].RowID, gc.Count))
+			}
+			if !reflect.DeepEqual(got, exp) {
+				t.Fatalf("expected %v, got %v", exp, got)
+			}
+			if i == 1 && hits()-h != 3 {
+				t.Fatalf("expected 3 hits, got %v", hits()-h)
+			}
+		}
+	})
+
+	t.Run("OptOut", func(t *testing.T) {
+		query(t, `Options(Count(Row(f=2)), cache=false)`, uint64(1), false)
+	})
+
+	t.Run("Write", func(t *testing.T) {
+		query(t, `Count(Row(f=2))`, uint64(1), true)
+
+		// A write to one shard only invalidates its results.
+		c.Query(t, index, fmt.Sprintf(`Set(%d, f=2)`, pilosa.ShardWidth+2))
+		h, m := hits(), misses()
+		if got := c.Query(t, index, `Count(Row(f=2))`).Results[0]; got != uint64(2) {
+			t.Fatalf("expected 2, got %v", got)
+		}
+		if hits()-h != 2 || misses()-m != 1 {
+			t.Fatalf("expected 2 hits and 1 miss, got %v and %v", hits()-h, misses()-m)
+		}
+	})
+}
@@ -0,0 +1,284 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/proto"
+	"github.com/featurebasedb/featurebase/v3/tracing"
+	"github.com/pkg/errors"
+)
+
+// RetentionCohort is a row of a RetentionMatrix: the records first seen in the
+// period starting at Start, and how many of them were active in that period
+// and each of the following ones.
+type RetentionCohort struct {
+	Start    time.Time
+	Size     uint64
+	Retained []uint64
+}
+
+// RetentionMatrix is the result of a Retention() call, with a cohort for each
+// period.
+type RetentionMatrix []RetentionCohort
+
+var _ proto.ToRowser = RetentionMatrix{}
+
+// ToTable implements the ToTabler interface.
+func (rm RetentionMatrix) ToTable() (*proto.TableResponse, error) {
+	n := 0
+	for _, cohort := range rm {
+		n += len(cohort.Retained)
+	}
+	return proto.RowsToTable(rm, n)
+}
+
+// ToRows implements the ToRowser interface. There is a row for each cell of the
+// matrix.
+func (rm RetentionMatrix) ToRows(callback func(*proto.RowResponse) error) error {
+	ci := []*proto.ColumnInfo{
+		{Name: "cohort", Datatype: "timestamp"},
+		{Name: "cohort_size", Datatype: "uint64"},
+		{Name: "period", Datatype: "uint64"},
+		{Name: "retained", Datatype: "uint64"},
+	}
+	for _, cohort := range rm {
+		start := cohort.Start.Format(time.RFC3339)
+		for k, n := range cohort.Retained {
+			if err := callback(&proto.RowResponse{
+				Headers: ci,
+				Columns: []*proto.ColumnResponse{
+					{ColumnVal: &proto.ColumnResponse_TimestampVal{TimestampVal: start}},
+					{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: cohort.Size}},
+					{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: uint64(k)}},
+					{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: n}},
+				},
+			}); err != nil {
+				return errors.Wrap(err, "calling callback")
+			}
+			ci = nil
+		}
+	}
+	return nil
+}
+
+// Add returns the sum of rm and other, cell by cell. Both must be for the
+// same periods.
+func (rm RetentionMatrix) Add(other RetentionMatrix) RetentionMatrix {
+	if rm == nil {
+		return other
+	} else if other == nil {
+		return rm
+	}
+	sum := make(RetentionMatrix, len(rm))
+	for c, cohort := range rm {
+		sum[c] = RetentionCohort{
+			Start:    cohort.Start,
+			Size:     cohort.Size + other[c].Size,
+			Retained: make([]uint64, len(cohort.Retained)),
+		}
+		for k, n := range cohort.Retained {
+			sum[c].Retained[k] = n + other[c].Retained[k]
+		}
+	}
+	return sum
+}
+
+// retentionPeriods maps the periods a Retention() call accepts to a time unit
+// and a number of them.
+var retentionPeriods = map[string]struct {
+	unit rune
+	n    int
+}{
+	"hour":  {'H', 1},
+	"day":   {'D', 1},
+	"week":  {'D', 7},
+	"month": {'M', 1},
+	"year":  {'Y', 1},
+}
+
+// maxRetentionPeriods is the most periods a Retention() call may divide time
+// into. The matrix, which every shard builds, grows with its square.
+const maxRetentionPeriods = 1000
+
+// executeRetention executes a Retention() call, which builds a retention
+// matrix. The time from the start of the period containing from is divided
+// into the given number of periods. Each record set in the cohort row belongs
+// to the cohort of the first period it's set in, and for each cohort, the
+// matrix counts the records which were set in the activity row in the cohort's
+// period and each of the later ones.
+func (e *executor) executeRetention(ctx context.Context, qcx *Qcx, index string, c *pql.Call, shards []uint64, opt *ExecOptions) (RetentionMatrix, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeRetention")
+	defer span.Finish()
+
+	rows := make(map[string]*timeRow, 2)
+	for _, name := range []string{"cohort", "activity"} {
+		v, ok := c.Args[name]
+		if !ok {
+			return nil, errors.Errorf("Retention(): %s required", name)
+		}
+		child, ok := v.(*pql.Call)
+		if !ok {
+			return nil, errors.Errorf("Retention(): %s must be a Row() call, got %v of type %[2]T", name, v)
+		}
+		r, err := e.parseTimeRow(index, child)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Retention(): %s", name)
+		}
+		rows[name] = r
+	}
+
+	periodArg, ok := c.Args["period"]
+	if !ok {
+		return nil, errors.New("Retention(): period required")
+	}
+	periodName, _ := periodArg.(string)
+	period, ok := retentionPeriods[strings.ToLower(periodName)]
+	if !ok {
+		return nil, errors.Errorf("Retention(): period must be one of hour, day, week, month or year, got %v", periodArg)
+	}
+	for _, r := range rows {
+		if r.empty {
+			continue
+		}
+		if g := r.quantum.Granularity(); strings.IndexRune(timeUnits, g) < strings.IndexRune(timeUnits, period.unit) {
+			return nil, errors.Errorf("Retention(): field %s has no views as fine as a %s", r.field, strings.ToLower(periodName))
+		}
+	}
+
+	n, ok, err := c.UintArg("periods")
+	if err != nil {
+		return nil, errors.Wrap(err, "Retention(): periods")
+	} else if !ok {
+		return nil, errors.New("Retention(): periods required")
+	} else if n == 0 {
+		return nil, errors.New("Retention(): periods must be positive")
+	} else if n > maxRetentionPeriods {
+		return nil, errors.Errorf("Retention(): periods must be at most %d", maxRetentionPeriods)
+	}
+
+	fromArg, ok := c.Args["from"]
+	if !ok {
+		return nil, errors.New("Retention(): from required")
+	}
+	from, err := parseTime(fromArg)
+	if err != nil {
+		return nil, errors.Wrap(err, "Retention(): parsing from time")
+	}
+
+	var filter *pql.Call
+	if v, ok := c.Args["filter"]; ok {
+		if filter, ok = v.(*pql.Call); !ok {
+			return nil, errors.Errorf("Retention(): filter must be a bitmap call, got %v of type %[1]T", v)
+		}
+	}
+
+	starts := make([]time.Time, n)
+	starts[0] = truncateTime(from, period.unit)
+	for p := 1; p < len(starts); p++ {
+		starts[p] = addTime(starts[p-1], period.unit, period.n)
+	}
+	cohortViews := rows["cohort"].views(starts, period.unit, period.n)
+	activityViews := rows["activity"].views(starts, period.unit, period.n)
+
+	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
+		return e.executeRetentionShard(ctx, qcx, index, filter, rows["cohort"], cohortViews, rows["activity"], activityViews, starts, shard)
+	}
+
+	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
+		other, _ := prev.(RetentionMatrix)
+		return other.Add(v.(RetentionMatrix))
+	}
+
+	result, err := e.mapReduce(ctx, index, shards, c, opt, mapFn, reduceFn)
+	if err != nil {
+		return nil, err
+	}
+	matrix, _ := result.(RetentionMatrix)
+	if matrix == nil {
+		matrix = newRetentionMatrix(starts)
+	}
+	return matrix, nil
+}
+
+// newRetentionMatrix returns an empty retention matrix with a cohort starting
+// at each of starts.
+func newRetentionMatrix(starts []time.Time) RetentionMatrix {
+	matrix := make(RetentionMatrix, len(starts))
+	for c, start := range starts {
+		matrix[c] = RetentionCohort{
+			Start:    start,
+			Retained: make([]uint64, len(starts)-c),
+		}
+	}
+	return matrix
+}
+
+// executeRetentionShard builds the retention matrix for a shard.
+func (e *executor) executeRetentionShard(ctx context.Context, qcx *Qcx, index string, filter *pql.Call, cohort *timeRow, cohortViews [][]string, activity *timeRow, activityViews [][]string, starts []time.Time, shard uint64) (_ RetentionMatrix, err0 error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeRetentionShard")
+	defer span.Finish()
+
+	var filterRow *Row
+	if filter != nil {
+		row, err := e.executeBitmapCallShard(ctx, qcx, index, filter, shard)
+		if err != nil {
+			return nil, errors.Wrap(err, "executing filter")
+		}
+		filterRow = row
+	}
+
+	idx := e.Holder.Index(index)
+	if idx == nil {
+		return nil, newNotFoundError(ErrIndexNotFound, index)
+	}
+	tx, finisher, err := qcx.GetTx(Txo{Write: !writable, Index: idx, Shard: shard})
+	if err != nil {
+		return nil, err
+	}
+	defer finisher(&err0)
+
+	// Read the activity row in each period.
+	active := make([]*Row, len(starts))
+	for p := range starts {
+		var views []string
+		if activityViews != nil {
+			views = activityViews[p]
+		}
+		if active[p], err = e.timeRowShard(tx, index, activity, views, shard); err != nil {
+			return nil, err
+		}
+	}
+
+	matrix := newRetentionMatrix(starts)
+	seen := NewRow()
+	for c := range starts {
+		var views []string
+		if cohortViews != nil {
+			views = cohortViews[c]
+		}
+		row, err := e.timeRowShard(tx, index, cohort, views, shard)
+		if err != nil {
+			return nil, err
+		}
+		if filterRow != nil {
+			row = row.Intersect(filterRow)
+		}
+		// Records belong to the cohort of the first period they're seen in.
+		members := row.Difference(seen)
+		seen = seen.Union(row)
+
+		matrix[c].Size = members.Count()
+		if matrix[c].Size == 0 {
+			continue
+		}
+		for k := range matrix[c].Retained {
+			matrix[c].Retained[k] = members.Intersect(active[c+k]).Count()
+		}
+	}
+	return matrix, nil
+}
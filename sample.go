@@ -0,0 +1,143 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/tracing"
+	"github.com/pkg/errors"
+)
+
+// executeSample executes a Sample() call, which returns a uniform random
+// sample of the records selected by its child, either n of them or a fraction
+// of them. Each shard contributes records in proportion to how many it has,
+// so the sample is built in two passes: the first counts the records in every
+// shard, and the second samples each shard. The sample is drawn from a
+// generator seeded by the seed argument, so a query with a seed returns the
+// same records each time it's run against the same data.
+func (e *executor) executeSample(ctx context.Context, qcx *Qcx, index string, c *pql.Call, shards []uint64, opt *ExecOptions) (*Row, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeSample")
+	defer span.Finish()
+
+	if len(c.Children) != 1 {
+		return nil, errors.New("Sample() requires a single bitmap input")
+	}
+	child := c.Children[0]
+
+	n, hasN, err := c.UintArg("n")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sample(): n")
+	}
+	var fraction float64
+	fractionArg, hasFraction := c.Args["fraction"]
+	switch v := fractionArg.(type) {
+	case pql.Decimal:
+		fraction = v.Float64()
+	case int64:
+		fraction = float64(v)
+	case nil:
+	default:
+		return nil, errors.Errorf("Sample(): invalid fraction='%v' of type (%[1]T), should be a number between 0 and 1 inclusive", fractionArg)
+	}
+	if hasN == hasFraction {
+		return nil, errors.New("Sample(): exactly one of n or fraction required")
+	} else if hasFraction && (fraction < 0 || fraction > 1) {
+		return nil, errors.Errorf("Sample(): invalid fraction value (%f), should be a number between 0 and 1 inclusive", fraction)
+	}
+
+	// The coordinator picks a seed if there isn't one, so that every node
+	// draws from the same generator.
+	seed, hasSeed, err := c.IntArg("seed")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sample(): seed")
+	} else if !hasSeed {
+		seed = time.Now().UnixNano()
+		c.Args["seed"] = seed
+	}
+
+	// The coordinator counts the records to sample from, and passes the
+	// count on to the other nodes.
+	total, hasTotal, err := c.UintArg("_total")
+	if err != nil {
+		return nil, errors.Wrap(err, "Sample(): _total")
+	} else if !hasTotal {
+		if opt.Remote {
+			return nil, errors.New("Sample(): _total required on remote nodes")
+		}
+		total, err = e.executeCount(ctx, qcx, index, &pql.Call{Name: "Count", Children: []*pql.Call{child}}, shards, opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "counting records")
+		}
+		c.Args["_total"] = int64(total)
+	}
+
+	target := n
+	if hasFraction {
+		target = uint64(math.Round(fraction * float64(total)))
+	}
+	if target > total {
+		target = total
+	}
+	if target == 0 {
+		return NewRow(), nil
+	}
+
+	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
+		row, err := e.executeBitmapCallShard(ctx, qcx, index, child, shard)
+		if err != nil {
+			return nil, err
+		}
+		// Rounding up means the shards' samples add up to at least the
+		// target, and the coordinator trims them back to it.
+		count := row.Count()
+		k := (target*count + total - 1) / total
+		return sampleRow(row, k, seed*1000003+int64(shard)), nil
+	}
+
+	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
+		other, _ := prev.(*Row)
+		if other == nil {
+			other = NewRow()
+		}
+		other.Merge(v.(*Row))
+		return other
+	}
+
+	result, err := e.mapReduce(ctx, index, shards, c, opt, mapFn, reduceFn)
+	if err != nil {
+		return nil, err
+	}
+	row, _ := result.(*Row)
+	if row == nil {
+		return NewRow(), nil
+	}
+	if opt.Remote {
+		return row, nil
+	}
+	return sampleRow(row, target, seed), nil
+}
+
+// sampleRow returns k of the columns of row chosen uniformly at random by a
+// generator seeded with seed, or row itself if it has no more than k.
+func sampleRow(row *Row, k uint64, seed int64) *Row {
+	if row.Count() <= k {
+		return row
+	}
+	columns := row.Columns()
+	rng := rand.New(rand.NewSource(seed))
+	// Partial Fisher-Yates shuffle: the first k columns end up a uniform
+	// sample of all of them.
+	for i := 0; i < int(k); i++ {
+		j := i + rng.Intn(len(columns)-i)
+		columns[i], columns[j] = columns[j], columns[i]
+	}
+	columns = columns[:k]
+	sort.Slice(columns, func(i, j int) bool { return columns[i] < columns[j] })
+	return NewRow(columns...)
+}
@@ -0,0 +1,154 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/rbf"
+	"github.com/pkg/errors"
+)
+
+// ShardCorruption describes a shard whose RBF database failed verification
+// against its page checksums.
+type ShardCorruption struct {
+	Index string
+	Shard uint64
+
+	// Pages holds the page numbers which failed verification. It is empty
+	// if the database could not be read at all, such as when the meta or
+	// root record page is corrupt.
+	Pages []uint32
+}
+
+// scrubShards reads every open RBF database in the holder and verifies it
+// against its page checksums. Databases without checksums are skipped.
+func (h *Holder) scrubShards() []ShardCorruption {
+	var corrupt []ShardCorruption
+	for _, dbs := range h.dbShards("") {
+		select {
+		case <-h.closing:
+			return corrupt
+		default:
+		}
+
+		w, ok := dbs.W.(*RbfDBWrapper)
+		if !ok {
+			continue
+		}
+
+		cerrs, err := w.db.Scrub()
+		if errors.Is(err, rbf.ErrChecksumMismatch) {
+			h.Logger.Errorf("scrubbing shard %s/%d: %v", dbs.Index, dbs.Shard, err)
+			corrupt = append(corrupt, ShardCorruption{Index: dbs.Index, Shard: dbs.Shard})
+			continue
+		} else if err == rbf.ErrClosed {
+			continue
+		} else if err != nil {
+			h.Logger.Errorf("scrubbing shard %s/%d: %v", dbs.Index, dbs.Shard, err)
+			continue
+		} else if len(cerrs) == 0 {
+			continue
+		}
+
+		c := ShardCorruption{Index: dbs.Index, Shard: dbs.Shard}
+		for _, cerr := range cerrs {
+			h.Logger.Errorf("scrubbing shard %s/%d: %v", dbs.Index, dbs.Shard, cerr)
+			c.Pages = append(c.Pages, cerr.Pgno)
+		}
+		corrupt = append(corrupt, c)
+	}
+	return corrupt
+}
+
+// dbShards returns a copy of the set of shard databases in the holder so
+// they can be read without holding the lock. If index is non-blank, only
+// databases for that index are returned.
+func (h *Holder) dbShards(index string) []*DBShard {
+	if h.txf == nil || h.txf.dbPerShard == nil {
+		return nil
+	}
+
+	per := h.txf.dbPerShard
+	per.Mu.Lock()
+	defer per.Mu.Unlock()
+	dbss := make([]*DBShard, 0, len(per.Flatmap))
+	for _, dbs := range per.Flatmap {
+		if index == "" || dbs.Index == index {
+			dbss = append(dbss, dbs)
+		}
+	}
+	return dbss
+}
+
+// monitorScrub periodically verifies every RBF database against its page
+// checksums and attempts to repair corrupt shards from a replica.
+func (s *Server) monitorScrub() {
+	interval := time.Duration(s.holderConfig.RBFConfig.ScrubInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			s.scrub(context.Background())
+		}
+	}
+}
+
+// scrub runs a single scrubbing pass over the holder.
+func (s *Server) scrub(ctx context.Context) {
+	start := time.Now()
+	corrupt := s.holder.scrubShards()
+	SummaryRBFScrubDurationSeconds.Observe(time.Since(start).Seconds())
+
+	for _, c := range corrupt {
+		CounterRBFChecksumMismatch.WithLabelValues(c.Index).Inc()
+		s.logger.Errorf("shard %s/%d failed checksum verification: pages=%v", c.Index, c.Shard, c.Pages)
+
+		if err := s.repairShard(ctx, c.Index, c.Shard); err != nil {
+			CounterRBFShardRepair.WithLabelValues(c.Index, "failure").Inc()
+			s.logger.Errorf("repairing shard %s/%d: %v", c.Index, c.Shard, err)
+			continue
+		}
+		CounterRBFShardRepair.WithLabelValues(c.Index, "success").Inc()
+		s.logger.Infof("repaired shard %s/%d from replica", c.Index, c.Shard)
+	}
+}
+
+// errNoShardReplica is returned when a corrupt shard has no other replica to
+// be repaired from.
+var errNoShardReplica = errors.New("no replica available")
+
+// repairShard replaces the local RBF database for a shard with a snapshot
+// from another node which owns the shard. Writes are replicated
+// synchronously, so any replica holds the same committed data.
+func (s *Server) repairShard(ctx context.Context, index string, shard uint64) error {
+	snap := s.cluster.NewSnapshot()
+	var errs []error
+	for _, node := range snap.ShardNodes(index, shard) {
+		if node.ID == s.nodeID {
+			continue
+		}
+		if err := func() error {
+			rc, err := s.defaultClient.ShardReaderNode(ctx, &node.URI, index, shard)
+			if err != nil {
+				return errors.Wrap(err, "fetching snapshot")
+			}
+			defer rc.Close()
+			return s.defaultClient.api.RestoreShard(ctx, index, shard, rc)
+		}(); err != nil {
+			errs = append(errs, fmt.Errorf("node %s: %w", node.ID, err))
+			continue
+		}
+		return nil
+	}
+	if len(errs) == 0 {
+		return errNoShardReplica
+	}
+	return fmt.Errorf("%w: %v", errNoShardReplica, errs)
+}
@@ -18,10 +18,12 @@ import (
 
 	uuid "github.com/satori/go.uuid"
 
+	"github.com/featurebasedb/featurebase/v3/audit"
 	daxstorage "github.com/featurebasedb/featurebase/v3/dax/storage"
 	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/logger"
 	pnet "github.com/featurebasedb/featurebase/v3/net"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
 	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
 	"github.com/featurebasedb/featurebase/v3/roaring"
 	"github.com/featurebasedb/featurebase/v3/sql3"
@@ -69,6 +71,7 @@ type Server struct { // nolint: maligned
 	gcNotifier  GCNotifier
 	logger      logger.Logger
 	queryLogger logger.Logger
+	auditLogger *audit.Logger
 
 	nodeID               string
 	uri                  pnet.URI
@@ -76,11 +79,13 @@ type Server struct { // nolint: maligned
 	metricInterval       time.Duration
 	diagnosticInterval   time.Duration
 	viewsRemovalInterval time.Duration
+	tieringInterval      time.Duration
 	maxWritesPerRequest  int
 	confirmDownSleep     time.Duration
 	confirmDownRetries   int
 	syncer               holderSyncer
 	maxQueryMemory       int64
+	resultCacheSize      int
 
 	translationSyncer      TranslationSyncer
 	resetTranslationSyncCh chan struct{}
@@ -102,6 +107,9 @@ type Server struct { // nolint: maligned
 
 	dataframeEnabled    bool
 	dataframeUseParquet bool
+
+	// readReplica is set if the node is a read replica.
+	readReplica *readReplica
 }
 
 type ExecutionPlannerFn func(executor Executor, api *API, sql string) sql3.CompilePlanner
@@ -151,6 +159,15 @@ func OptServerQueryLogger(l logger.Logger) ServerOption {
 	}
 }
 
+// OptServerAuditLogger is a functional option on Server used to set the
+// audit log, which records changes to the schema and deletes.
+func OptServerAuditLogger(l *audit.Logger) ServerOption {
+	return func(s *Server) error {
+		s.auditLogger = l
+		return nil
+	}
+}
+
 // OptServerReplicaN is a functional option on Server
 // used to set the number of replicas.
 func OptServerReplicaN(n int) ServerOption {
@@ -389,6 +406,32 @@ func OptServerRBFConfig(cfg *rbfcfg.Config) ServerOption {
 	}
 }
 
+// OptServerReadReplica is a functional option on Server which makes the node a
+// read replica. Every interval it copies the shards which have changed from
+// the nodes which own them. Queries are served locally while the copy is no
+// older than maxStaleness, and are forwarded to the owners otherwise.
+func OptServerReadReplica(maxStaleness, interval time.Duration) ServerOption {
+	return func(s *Server) error {
+		if interval <= 0 {
+			return errors.New("read replica sync interval must be positive")
+		}
+		s.readReplica = &readReplica{maxStaleness: maxStaleness, interval: interval}
+		return nil
+	}
+}
+
+// OptServerTieredStorage is a functional option on Server used to offload
+// shards which have not been written for age to store. Shards are checked
+// every interval.
+func OptServerTieredStorage(store objectstore.Store, age, interval time.Duration) ServerOption {
+	return func(s *Server) error {
+		s.holderConfig.TieredStore = store
+		s.holderConfig.TieredAge = age
+		s.tieringInterval = interval
+		return nil
+	}
+}
+
 // OptServerQueryHistoryLength is a functional option on Server
 // used to specify the length of the query history buffer that maintains
 // the information returned at /query-history.
@@ -407,6 +450,15 @@ func OptServerMaxQueryMemory(v int64) ServerOption {
 	}
 }
 
+// OptServerQueryResultCacheSize sets the maximum number of shard results the
+// query result cache holds. The cache is disabled if it's zero.
+func OptServerQueryResultCacheSize(n int) ServerOption {
+	return func(s *Server) error {
+		s.resultCacheSize = n
+		return nil
+	}
+}
+
 // OptServerDisCo is a functional option on Server
 // used to set the Distributed Consensus implementation.
 func OptServerDisCo(disCo disco.DisCo,
@@ -540,12 +592,15 @@ func NewServer(opts ...ServerOption) (*Server, error) {
 	executorOpts := []executorOption{
 		optExecutorInternalQueryClient(s.defaultClient),
 		optExecutorMaxMemory(maxQueryMemory),
+		optExecutorResultCache(s.resultCacheSize),
 	}
 	if s.executorPoolSize > 0 {
 		executorOpts = append(executorOpts, optExecutorWorkerPoolSize(s.executorPoolSize))
 	}
 	s.executor = newExecutor(executorOpts...)
 	s.executor.dataframeEnabled = s.dataframeEnabled
+	s.executor.readReplica = s.readReplica
+	s.executor.auditLogger = s.auditLogger
 	s.executor.datafameUseParquet = s.dataframeUseParquet
 
 	path, err := expandDirName(s.dataDir)
@@ -663,11 +718,12 @@ func (s *Server) Open() error {
 	}
 
 	node := &disco.Node{
-		ID:        s.nodeID,
-		URI:       s.uri,
-		GRPCURI:   s.grpcURI,
-		State:     nodeState,
-		IsPrimary: s.IsPrimary(),
+		ID:            s.nodeID,
+		URI:           s.uri,
+		GRPCURI:       s.grpcURI,
+		State:         nodeState,
+		IsPrimary:     s.readReplica == nil && s.IsPrimary(),
+		IsReadReplica: s.readReplica != nil,
 	}
 
 	if err := s.noder.SetMetadata(context.Background(), node); err != nil {
@@ -716,6 +772,38 @@ func (s *Server) Open() error {
 	go func() { defer s.wg.Done(); s.monitorDiagnostics() }()
 	go func() { defer s.wg.Done(); s.monitorViewsRemoval() }()
 
+	// Periodically verify RBF page checksums, if enabled.
+	if s.holderConfig.RBFConfig.ScrubInterval > 0 {
+		if ok := s.addToWaitGroup(1); !ok {
+			return fmt.Errorf("closing server while opening server is NOT allowed")
+		}
+		go func() { defer s.wg.Done(); s.monitorScrub() }()
+	}
+
+	// Periodically compact fragmented RBF databases, if enabled.
+	if s.holderConfig.RBFConfig.CompactionThreshold > 0 && s.holderConfig.RBFConfig.CompactionInterval > 0 {
+		if ok := s.addToWaitGroup(1); !ok {
+			return fmt.Errorf("closing server while opening server is NOT allowed")
+		}
+		go func() { defer s.wg.Done(); s.monitorCompaction() }()
+	}
+
+	// Keep a read replica's copy of the cluster's data up to date.
+	if s.readReplica != nil {
+		if ok := s.addToWaitGroup(1); !ok {
+			return fmt.Errorf("closing server while opening server is NOT allowed")
+		}
+		go func() { defer s.wg.Done(); s.monitorReadReplica() }()
+	}
+
+	// Periodically offload cold shards to the object store, if enabled.
+	if s.holderConfig.TieredStore != nil && s.tieringInterval > 0 {
+		if ok := s.addToWaitGroup(1); !ok {
+			return fmt.Errorf("closing server while opening server is NOT allowed")
+		}
+		go func() { defer s.wg.Done(); s.monitorTiering() }()
+	}
+
 	toSend := func() []Message {
 		s.holder.startMsgsMu.Lock()
 		defer s.holder.startMsgsMu.Unlock()
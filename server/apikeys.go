@@ -0,0 +1,117 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package server
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/featurebasedb/featurebase/v3/errors"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// apiKeysTable is the system table in which API keys are stored, so that
+// every node in the cluster accepts the same keys.
+const apiKeysTable = "fb_api_keys"
+
+// Ensure type implements interface.
+var _ authn.APIKeyStore = (*apiKeyStore)(nil)
+
+// apiKeyStore is an authn.APIKeyStore which keeps API keys in a system table.
+type apiKeyStore struct {
+	api  *pilosa.API
+	auth *authn.Auth
+}
+
+// CreateAPIKey stores key, creating the system table if it doesn't exist yet.
+func (s *apiKeyStore) CreateAPIKey(ctx context.Context, key *authn.APIKey) error {
+	if _, err := s.query(ctx, fmt.Sprintf(
+		"CREATE TABLE IF NOT EXISTS %s (_id string, service_account string, group_ids stringset, hash string, created_at timestamp, expiry timestamp) COMMENT 'system table for api keys'",
+		apiKeysTable)); err != nil {
+		return errors.Wrap(err, "creating api keys table")
+	}
+
+	groups := make([]string, len(key.Groups))
+	for i, g := range key.Groups {
+		groups[i] = quoteSQLString(g)
+	}
+	expiry := "null"
+	if !key.Expiry.IsZero() {
+		expiry = quoteSQLString(key.Expiry.Format(time.RFC3339))
+	}
+	_, err := s.query(ctx, fmt.Sprintf(
+		"INSERT INTO %s (_id, service_account, group_ids, hash, created_at, expiry) VALUES (%s, %s, [%s], %s, %s, %s)",
+		apiKeysTable,
+		quoteSQLString(key.ID),
+		quoteSQLString(key.ServiceAccount),
+		strings.Join(groups, ", "),
+		quoteSQLString(key.Hash),
+		quoteSQLString(key.CreatedAt.Format(time.RFC3339)),
+		expiry,
+	))
+	return err
+}
+
+// APIKey returns the API key with the given ID, or authn.ErrAPIKeyNotFound.
+func (s *apiKeyStore) APIKey(ctx context.Context, id string) (*authn.APIKey, error) {
+	keys, err := s.apiKeys(ctx, fmt.Sprintf("WHERE _id = %s", quoteSQLString(id)))
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) == 0 {
+		return nil, authn.ErrAPIKeyNotFound
+	}
+	return keys[0], nil
+}
+
+// APIKeys returns every stored API key.
+func (s *apiKeyStore) APIKeys(ctx context.Context) ([]*authn.APIKey, error) {
+	return s.apiKeys(ctx, "")
+}
+
+// DeleteAPIKey deletes the API key with the given ID.
+func (s *apiKeyStore) DeleteAPIKey(ctx context.Context, id string) error {
+	_, err := s.query(ctx, fmt.Sprintf("DELETE FROM %s WHERE _id = %s", apiKeysTable, quoteSQLString(id)))
+	return err
+}
+
+// apiKeys returns the API keys matching the where clause, which may be empty.
+// If the system table doesn't exist no keys have been created, so no keys
+// are returned.
+func (s *apiKeyStore) apiKeys(ctx context.Context, where string) ([]*authn.APIKey, error) {
+	rows, err := s.query(ctx, fmt.Sprintf(
+		"SELECT _id, service_account, group_ids, hash, created_at, expiry FROM %s %s",
+		apiKeysTable, where))
+	if errors.Is(err, sql3.ErrTableOrViewNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, errors.Wrap(err, "reading api keys")
+	}
+
+	keys := make([]*authn.APIKey, 0, len(rows))
+	for _, row := range rows {
+		key := &authn.APIKey{}
+		key.ID, _ = row[0].(string)
+		key.ServiceAccount, _ = row[1].(string)
+		key.Groups, _ = row[2].([]string)
+		key.Hash, _ = row[3].(string)
+		key.CreatedAt, _ = row[4].(time.Time)
+		key.Expiry, _ = row[5].(time.Time)
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+func (s *apiKeyStore) query(ctx context.Context, sql string) ([]types.Row, error) {
+	return querySystem(ctx, s.api, s.auth, sql)
+}
+
+// quoteSQLString returns s as a SQL string literal.
+func quoteSQLString(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
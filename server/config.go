@@ -17,7 +17,9 @@ import (
 	"time"
 
 	"github.com/featurebasedb/featurebase/v3/authz"
+	"github.com/featurebasedb/featurebase/v3/encryption"
 	petcd "github.com/featurebasedb/featurebase/v3/etcd"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
 	rbfcfg "github.com/featurebasedb/featurebase/v3/rbf/cfg"
 	"github.com/featurebasedb/featurebase/v3/storage"
 	"github.com/featurebasedb/featurebase/v3/toml"
@@ -68,6 +70,15 @@ type Config struct {
 	// for availability/durability.
 	SnapshotterDir string `toml:"snapshotter-dir"`
 
+	// DAXStore, if a bucket is set, is an S3-compatible object store in
+	// which this node reads/writes both change logs and snapshots in
+	// place of WriteloggerDir and SnapshotterDir.
+	DAXStore objectstore.Config `toml:"dax-store"`
+
+	// ShardReplicaInterval is how often a compute node brings the shards
+	// it holds as read-only replicas up to date with their write logs.
+	ShardReplicaInterval toml.Duration `toml:"shard-replica-interval"`
+
 	// DataDir is the directory where Pilosa stores both indexed data and
 	// running state such as cluster topology information.
 	DataDir string `toml:"data-dir"`
@@ -152,6 +163,12 @@ type Config struct {
 	// Limits the total amount of memory to be used by Extract() & SELECT queries.
 	MaxQueryMemory int64 `toml:"max-query-memory"`
 
+	// QueryResultCacheSize is the maximum number of per-shard results of
+	// Count(), Sum(), Min(), Max() and GroupBy() calls kept to answer
+	// repeated queries on shards which haven't been written since. Zero
+	// disables the cache.
+	QueryResultCacheSize int `toml:"query-result-cache-size"`
+
 	// On startup, featurebase server contacts a web server to check the latest version.
 	// This stores the address for that check
 	VerChkAddress string `toml:"verchk-address"`
@@ -226,6 +243,33 @@ type Config struct {
 	// RBFConfig defines all externally configurable RBF flags.
 	RBFConfig *rbfcfg.Config `toml:"rbf"`
 
+	// Encryption configures encryption of RBF pages & translate stores at rest.
+	Encryption *encryption.Config `toml:"encryption"`
+
+	// ReadReplica configures the node as a read replica, which serves
+	// queries from a copy of the cluster's data and does not accept writes.
+	ReadReplica struct {
+		// Enabled makes the node a read replica.
+		Enabled bool `toml:"enabled"`
+		// MaxStaleness is the maximum age of the replica's data. Queries
+		// are forwarded to the nodes which own the data if it is older.
+		MaxStaleness toml.Duration `toml:"max-staleness"`
+		// SyncInterval is how often changed shards are copied.
+		SyncInterval toml.Duration `toml:"sync-interval"`
+	} `toml:"read-replica"`
+
+	// Tiering configures offloading of cold shards to an S3-compatible
+	// object store. Tiering is disabled unless a bucket is set.
+	Tiering struct {
+		// Age is how long a shard must go without writes before it is
+		// offloaded.
+		Age toml.Duration `toml:"age"`
+		// Interval is how often shards are checked for offloading.
+		Interval toml.Duration `toml:"interval"`
+		// Store is the bucket offloaded shards are stored in.
+		Store objectstore.Config `toml:"store"`
+	} `toml:"tiering"`
+
 	// QueryHistoryLength sets the maximum number of queries that are maintained
 	// for the /query-history endpoint. This parameter is per-node, and the
 	// result combines the history from all nodes.
@@ -258,6 +302,30 @@ type Config struct {
 
 	Auth Auth
 
+	// Audit configures the audit log, which records logins, changes to the
+	// schema and permissions, deletes and restores. It's disabled unless a
+	// path or syslog address is set.
+	Audit struct {
+		// Path is the file events are written to. Each event includes the
+		// hash of the one before it, so that tampering can be detected.
+		Path string `toml:"path"`
+		// Key is the hex encoded key the hashes in the file are HMACs
+		// with, which is needed to verify it. It's required if Path is
+		// set, and should be kept apart from the file.
+		Key string `toml:"key"`
+		// MaxSize is the size in bytes at which the file is rotated. The
+		// file isn't rotated if it's 0.
+		MaxSize int64 `toml:"max-size"`
+		// MaxBackups is the number of rotated files kept.
+		MaxBackups int `toml:"max-backups"`
+		// SyslogNetwork is the network of the syslog socket: "udp", "tcp",
+		// "unix" or "unixgram".
+		SyslogNetwork string `toml:"syslog-network"`
+		// SyslogAddress is the address of the syslog socket events are sent
+		// to.
+		SyslogAddress string `toml:"syslog-address"`
+	} `toml:"audit"`
+
 	Dataframe struct {
 		Enable     bool `toml:"enable"`
 		UseParquet bool `toml:"use-parquet"`
@@ -280,6 +348,10 @@ type Auth struct {
 	PermissionsFile  string   `toml:"permissions"`
 	QueryLogPath     string   `toml:"query-log-path"`
 	ConfiguredIPs    []string `toml:"configured-ips"`
+
+	// GrantsRefreshInterval is how often the grants made through SQL are
+	// reloaded, so that grants made on other nodes apply to this one.
+	GrantsRefreshInterval toml.Duration `toml:"grants-refresh-interval"`
 }
 
 // Namespace returns the namespace to use based on the Future flag.
@@ -379,8 +451,9 @@ func NewConfig() *Config {
 
 		DirectiveWorkerPoolSize: runtime.NumCPU(),
 
-		Storage:   storage.NewDefaultConfig(),
-		RBFConfig: rbfcfg.NewDefaultConfig(),
+		Storage:    storage.NewDefaultConfig(),
+		RBFConfig:  rbfcfg.NewDefaultConfig(),
+		Encryption: encryption.NewDefaultConfig(),
 
 		QueryHistoryLength: 100,
 
@@ -391,6 +464,9 @@ func NewConfig() *Config {
 		UUIDFile:        ".client_id.txt",
 	}
 
+	// Auth config.
+	c.Auth.GrantsRefreshInterval = toml.Duration(time.Minute)
+
 	// Cluster config.
 	c.Cluster.Name = "cluster0"
 	c.Cluster.ReplicaN = 1
@@ -400,6 +476,22 @@ func NewConfig() *Config {
 	// AntiEntropy config.
 	c.AntiEntropy.Interval = toml.Duration(0)
 
+	// DAX shard replica config.
+	c.ShardReplicaInterval = toml.Duration(5 * time.Second)
+
+	// ReadReplica config.
+	c.ReadReplica.MaxStaleness = toml.Duration(time.Minute)
+	c.ReadReplica.SyncInterval = toml.Duration(10 * time.Second)
+
+	// Audit config.
+	c.Audit.MaxSize = 100 * 1024 * 1024
+	c.Audit.MaxBackups = 10
+	c.Audit.SyslogNetwork = "udp"
+
+	// Tiering config.
+	c.Tiering.Age = toml.Duration(30 * 24 * time.Hour)
+	c.Tiering.Interval = toml.Duration(time.Hour)
+
 	// Metric config.
 	c.Metric.Service = "none"
 	c.Metric.PollInterval = toml.Duration(0 * time.Minute)
@@ -14,6 +14,7 @@ import (
 	"time"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/audit"
 	"github.com/featurebasedb/featurebase/v3/authn"
 	"github.com/featurebasedb/featurebase/v3/authz"
 	"github.com/featurebasedb/featurebase/v3/logger"
@@ -179,6 +180,7 @@ func (h *GRPCHandler) QuerySQL(req *pb.QuerySQLRequest, stream pb.Pilosa_QuerySQ
 				return status.Error(codes.PermissionDenied, "insufficient permissions to access requested tables")
 			}
 			ctx = authn.WithIndexes(ctx, allowed)
+			ctx = authz.WithAccess(ctx, h.perms.NewAccess(uinfo))
 		}
 		LogQuery(ctx, "QuerySQL", req, h.queryLogger)
 	}
@@ -242,6 +244,7 @@ func (h *GRPCHandler) QuerySQLUnary(ctx context.Context, req *pb.QuerySQLRequest
 				return nil, status.Error(codes.PermissionDenied, "insufficient permissions to access requested tables")
 			}
 			ctx = authn.WithIndexes(ctx, allowed)
+			ctx = authz.WithAccess(ctx, h.perms.NewAccess(uinfo))
 		}
 	}
 
@@ -293,6 +296,7 @@ func (h *GRPCHandler) QueryPQL(req *pb.QueryPQLRequest, stream pb.Pilosa_QueryPQ
 			if !isAllowed([]string{req.Index}, h.perms.GetAuthorizedIndexList(uinfo.Groups, lperm)) {
 				return status.Error(codes.PermissionDenied, "insufficient permissions to access requested indexes")
 			}
+			ctx = authz.WithAccess(ctx, h.perms.NewAccess(uinfo))
 		}
 		LogQuery(ctx, "QueryPQL", req, h.queryLogger)
 	}
@@ -300,7 +304,7 @@ func (h *GRPCHandler) QueryPQL(req *pb.QueryPQLRequest, stream pb.Pilosa_QueryPQ
 	span.SetTag("PQL Query", req.Pql)
 	span.SetTag("Index", req.Index)
 	t := time.Now()
-	resp, err := h.api.Query(stream.Context(), &query)
+	resp, err := h.api.Query(ctx, &query)
 	durQuery := time.Since(t)
 	monitor.Finish(span)
 
@@ -361,6 +365,7 @@ func (h *GRPCHandler) QueryPQLUnary(ctx context.Context, req *pb.QueryPQLRequest
 			if !isAllowed([]string{req.Index}, h.perms.GetAuthorizedIndexList(uinfo.Groups, lperm)) {
 				return nil, status.Error(codes.PermissionDenied, fmt.Sprintf("insufficient permissions for %v", req.Index))
 			}
+			ctx = authz.WithAccess(ctx, h.perms.NewAccess(uinfo))
 		}
 	}
 
@@ -717,6 +722,11 @@ func (h *GRPCHandler) Inspect(req *pb.InspectRequest, stream pb.Pilosa_InspectSe
 	ctx := stream.Context()
 	if uinfo, _ := authn.GetUserInfo(ctx); uinfo != nil {
 		LogQuery(stream.Context(), "Inspect", req, h.queryLogger)
+		// Inspect reads fields directly rather than through the executor,
+		// so it isn't available to users whose access is restricted.
+		if r, err := h.perms.GetRestrictions(uinfo.Groups, req.Index); err != nil || r != nil {
+			return status.Error(codes.PermissionDenied, fmt.Sprintf("access to %v is restricted", req.Index))
+		}
 	}
 
 	index, err := h.api.Index(stream.Context(), req.Index)
@@ -1468,6 +1478,7 @@ type grpcServer struct {
 
 	logger      logger.Logger
 	queryLogger logger.Logger
+	auditLogger *audit.Logger
 }
 
 type grpcServerOption func(s *grpcServer) error
@@ -1521,6 +1532,13 @@ func OptGRPCServerQueryLogger(logger logger.Logger) grpcServerOption {
 	}
 }
 
+func OptGRPCServerAuditLogger(l *audit.Logger) grpcServerOption {
+	return func(s *grpcServer) error {
+		s.auditLogger = l
+		return nil
+	}
+}
+
 func (s *grpcServer) Serve() error {
 	s.logger.Infof("enabled grpc listening on %s", s.ln.Addr())
 
@@ -1585,6 +1603,7 @@ func NewGRPCServer(opts ...grpcServerOption) (*grpcServer, error) {
 		unaryInterceptors = append(unaryInterceptors, func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
 			ctx, err := Valid(ctx, server.auth)
 			if err != nil {
+				server.auditLogger.Log(ctx, audit.EventAuthFailure, "", info.FullMethod, err)
 				return nil, err
 			}
 			LogQuery(ctx, info.FullMethod, req, server.logger)
@@ -1599,6 +1618,7 @@ func NewGRPCServer(opts ...grpcServerOption) (*grpcServer, error) {
 		streamInterceptors = append(streamInterceptors, func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
 			ctx, err := Valid(ss.Context(), server.auth)
 			if err != nil {
+				server.auditLogger.Log(ctx, audit.EventAuthFailure, "", info.FullMethod, err)
 				return err
 			}
 			// reset the molecula-chip cookie just in case the token was refreshed
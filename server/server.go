@@ -10,6 +10,7 @@ package server
 import (
 	"context"
 	"crypto/tls"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"log"
@@ -18,6 +19,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"path"
 	"path/filepath"
 	"runtime"
 	"strconv"
@@ -27,20 +29,25 @@ import (
 	"time"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/audit"
 	"github.com/featurebasedb/featurebase/v3/authn"
 	"github.com/featurebasedb/featurebase/v3/authz"
+	fbcontext "github.com/featurebasedb/featurebase/v3/context"
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/dax/computer"
 	"github.com/featurebasedb/featurebase/v3/dax/storage"
 	"github.com/featurebasedb/featurebase/v3/disco"
 	"github.com/featurebasedb/featurebase/v3/encoding/proto"
+	"github.com/featurebasedb/featurebase/v3/encryption"
 	petcd "github.com/featurebasedb/featurebase/v3/etcd"
 	"github.com/featurebasedb/featurebase/v3/gcnotify"
 	"github.com/featurebasedb/featurebase/v3/gopsutil"
 	"github.com/featurebasedb/featurebase/v3/logger"
 	pnet "github.com/featurebasedb/featurebase/v3/net"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
 	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/planner"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
 	"github.com/featurebasedb/featurebase/v3/statik"
 	"github.com/featurebasedb/featurebase/v3/systemlayer"
 	"github.com/featurebasedb/featurebase/v3/syswrap"
@@ -49,6 +56,7 @@ import (
 	"github.com/featurebasedb/featurebase/v3/tracing/opentracing"
 	"github.com/pelletier/go-toml"
 	"github.com/pkg/errors"
+	uuid "github.com/satori/go.uuid"
 	jaegercfg "github.com/uber/jaeger-client-go/config"
 	"golang.org/x/sync/errgroup"
 	"gopkg.in/DataDog/dd-trace-go.v1/ddtrace/opentracer"
@@ -79,6 +87,7 @@ type Command struct {
 	queryLogOutput io.Writer
 	logger         loggerLogger
 	queryLogger    loggerLogger
+	auditLogger    *audit.Logger
 
 	Registrar         computer.Registrar
 	serverlessStorage *storage.ResourceManager
@@ -97,6 +106,7 @@ type Command struct {
 
 	serverOptions []pilosa.ServerOption
 	auth          *authn.Auth
+	permissions   *authz.GroupPermissions
 
 	// isComputeNode is set to true if this node is running as a DAX compute
 	// node.
@@ -130,6 +140,7 @@ func OptCommandConfig(config *Config) CommandOption {
 		if c.Config != nil {
 			c.Config.Etcd = config.Etcd
 			c.Config.Auth = config.Auth
+			c.Config.Audit = config.Audit
 			c.Config.TLS = config.TLS
 			c.Config.ControllerAddress = config.ControllerAddress
 			return nil
@@ -377,6 +388,11 @@ func (m *Command) Start() (err error) {
 		return errors.Wrap(err, "setting up profiling/tracing")
 	}
 
+	// Grants made on other nodes only apply to this one once it loads them.
+	if m.Config.Auth.Enable {
+		go m.monitorGrants()
+	}
+
 	_ = testhook.Opened(pilosa.NewAuditor(), m, nil)
 	close(m.Started)
 	return nil
@@ -570,12 +586,40 @@ func (m *Command) setupServer() error {
 		m.serverlessStorage = storage.NewResourceManager(m.snapshotService, m.writelogService, m.logger)
 	}
 
+	// Encrypt RBF pages & translate stores if a key file is configured.
+	openTranslateStore := pilosa.OpenTranslateStore
+	if m.Config.Encryption != nil && m.Config.Encryption.KeyFile != "" {
+		keys, err := encryption.OpenKeyFile(m.Config.Encryption.KeyFile)
+		if err != nil {
+			return errors.Wrap(err, "opening encryption key file")
+		}
+		c := encryption.NewCipher(keys)
+		m.Config.RBFConfig.Cipher = c
+		openTranslateStore = pilosa.OpenEncryptedTranslateStore(c)
+	}
+
+	m.auditLogger, err = m.setupAuditLogger()
+	if err != nil {
+		return errors.Wrap(err, "setting up audit logger")
+	}
+
+	var p authz.GroupPermissions
+	m.permissions = &p
+
 	executionPlannerFn := func(e pilosa.Executor, api *pilosa.API, sql string) sql3.CompilePlanner {
 		fapi := pilosa.NewOnPremSchema(api)
 		fsapi := &pilosa.FeatureBaseSystemAPI{API: api}
 		imp := pilosa.NewOnPremImporter(api)
 
-		return planner.NewExecutionPlanner(e, fapi, fsapi, m.Server.SystemLayer, imp, m.logger, sql)
+		pl := planner.NewExecutionPlanner(e, fapi, fsapi, m.Server.SystemLayer, imp, m.logger, sql).WithAuditLogger(m.auditLogger)
+		if m.Config.Auth.Enable {
+			pl = pl.WithGrantsChanged(func(ctx context.Context) {
+				if err := m.loadGrants(ctx); err != nil {
+					m.logger.Errorf("loading grants: %v", err)
+				}
+			})
+		}
+		return pl
 	}
 
 	serverOptions := []pilosa.ServerOption{
@@ -586,11 +630,12 @@ func (m *Command) setupServer() error {
 		pilosa.OptServerMetricInterval(time.Duration(m.Config.Metric.PollInterval)),
 		pilosa.OptServerDiagnosticsInterval(diagnosticsInterval),
 		pilosa.OptServerExecutorPoolSize(m.Config.WorkerPoolSize),
-		pilosa.OptServerOpenTranslateStore(pilosa.OpenTranslateStore),
+		pilosa.OptServerOpenTranslateStore(openTranslateStore),
 		pilosa.OptServerOpenTranslateReader(pilosa.GetOpenTranslateReaderWithLockerFunc(c, &sync.Mutex{})),
 		pilosa.OptServerOpenIDAllocator(pilosa.OpenIDAllocator),
 		pilosa.OptServerLogger(m.logger),
 		pilosa.OptServerQueryLogger(m.queryLogger),
+		pilosa.OptServerAuditLogger(m.auditLogger),
 		pilosa.OptServerSystemInfo(gopsutil.NewSystemInfo()),
 		pilosa.OptServerGCNotifier(gcnotify.NewActiveGCNotifier()),
 		pilosa.OptServerURI(advertiseURI),
@@ -600,6 +645,7 @@ func (m *Command) setupServer() error {
 		pilosa.OptServerStorageConfig(m.Config.Storage),
 		pilosa.OptServerRBFConfig(m.Config.RBFConfig),
 		pilosa.OptServerMaxQueryMemory(m.Config.MaxQueryMemory),
+		pilosa.OptServerQueryResultCacheSize(m.Config.QueryResultCacheSize),
 		pilosa.OptServerQueryHistoryLength(m.Config.QueryHistoryLength),
 		pilosa.OptServerPartitionAssigner(m.Config.Cluster.PartitionToNodeAssignment),
 		pilosa.OptServerExecutionPlannerFn(executionPlannerFn),
@@ -633,6 +679,22 @@ func (m *Command) setupServer() error {
 		serverOptions = append(serverOptions, pilosa.OptServerLookupDB(m.Config.LookupDBDSN))
 	}
 
+	if m.Config.ReadReplica.Enabled {
+		serverOptions = append(serverOptions, pilosa.OptServerReadReplica(time.Duration(m.Config.ReadReplica.MaxStaleness), time.Duration(m.Config.ReadReplica.SyncInterval)))
+	}
+
+	// Offload cold shards to an object store if a bucket is configured. Each
+	// node stores its shards under its own prefix.
+	if m.Config.Tiering.Store.Bucket != "" {
+		cfg := m.Config.Tiering.Store
+		cfg.Prefix = path.Join(cfg.Prefix, m.Config.Name)
+		store, err := objectstore.NewS3Store(cfg)
+		if err != nil {
+			return errors.Wrap(err, "creating tiered storage")
+		}
+		serverOptions = append(serverOptions, pilosa.OptServerTieredStorage(store, time.Duration(m.Config.Tiering.Age), time.Duration(m.Config.Tiering.Interval)))
+	}
+
 	serverOptions = append(serverOptions, m.serverOptions...)
 
 	if m.Config.Auth.Enable {
@@ -654,6 +716,7 @@ func (m *Command) setupServer() error {
 		pilosa.OptAPIServerlessStorage(m.serverlessStorage),
 		pilosa.OptAPIDirectiveWorkerPoolSize(m.Config.DirectiveWorkerPoolSize),
 		pilosa.OptAPIIsComputeNode(m.isComputeNode),
+		pilosa.OptAPIShardReplicaInterval(time.Duration(m.Config.ShardReplicaInterval)),
 	)
 	if err != nil {
 		return errors.Wrap(err, "new api")
@@ -661,7 +724,6 @@ func (m *Command) setupServer() error {
 	// Tell server about its new API, which its client will need.
 	m.Server.SetAPI(m.API)
 
-	var p authz.GroupPermissions
 	if m.Config.Auth.Enable {
 		m.Config.MustValidateAuth()
 		permsFile, err := os.Open(m.Config.Auth.PermissionsFile)
@@ -679,6 +741,8 @@ func (m *Command) setupServer() error {
 		if err != nil {
 			return errors.Wrap(err, "instantiating authN object")
 		}
+		m.auth.SetAdminGroup(p.Admin)
+		m.auth.SetAPIKeyStore(&apiKeyStore{api: m.API, auth: m.auth})
 
 		err = m.setupQueryLogger()
 		if err != nil {
@@ -707,6 +771,7 @@ func (m *Command) setupServer() error {
 		OptGRPCServerAuth(m.auth),
 		OptGRPCServerPerm(&p),
 		OptGRPCServerQueryLogger(m.queryLogger),
+		OptGRPCServerAuditLogger(m.auditLogger),
 	)
 	if err != nil {
 		return errors.Wrap(err, "getting grpcServer")
@@ -717,6 +782,7 @@ func (m *Command) setupServer() error {
 		pilosa.OptHandlerAPI(m.API),
 		pilosa.OptHandlerLogger(m.logger),
 		pilosa.OptHandlerQueryLogger(m.queryLogger),
+		pilosa.OptHandlerAuditLogger(m.auditLogger),
 		pilosa.OptHandlerFileSystem(&statik.FileSystem{}),
 		pilosa.OptHandlerListener(m.ln, m.Config.Advertise),
 		pilosa.OptHandlerCloseTimeout(m.closeTimeout),
@@ -789,6 +855,90 @@ func (m *Command) setupLogger() error {
 	return nil
 }
 
+// loadGrants replaces the grants combined with the permissions from the
+// permissions file with those stored in the cluster's schema through SQL.
+func (m *Command) loadGrants(ctx context.Context) error {
+	rows, err := querySystem(ctx, m.API, m.auth, "SHOW GRANTS")
+	if err != nil {
+		return errors.Wrap(err, "reading grants")
+	}
+
+	grants := make(authz.Grants)
+	for _, row := range rows {
+		role, _ := row[0].(string)
+		table, _ := row[1].(string)
+		perm, _ := row[2].(string)
+		if grants[role] == nil {
+			grants[role] = make(map[string]authz.Permission)
+		}
+		grants[role][table] = authz.Permission(perm)
+	}
+	m.permissions.SetGrants(grants)
+	return nil
+}
+
+// querySystem runs sql on behalf of the system rather than the user, so the
+// user's access doesn't apply, and returns the resulting rows. If auth is
+// enabled, other nodes are queried with the system token.
+func querySystem(ctx context.Context, api *pilosa.API, auth *authn.Auth, sql string) ([]types.Row, error) {
+	ctx = authz.WithAccess(ctx, nil)
+	if auth != nil {
+		ctx = authn.WithAccessToken(ctx, "Bearer "+auth.SystemToken())
+	}
+	requestID, err := uuid.NewV4()
+	if err != nil {
+		return nil, errors.Wrap(err, "generating request id")
+	}
+	ctx = fbcontext.WithRequestID(ctx, requestID.String())
+
+	op, err := api.CompilePlan(ctx, sql)
+	if err != nil {
+		return nil, errors.Wrap(err, "compiling plan")
+	}
+	iter, err := op.Iterator(ctx, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting iterator")
+	}
+
+	var rows []types.Row
+	for {
+		row, err := iter.Next(ctx)
+		if err == types.ErrNoMoreRows {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// monitorGrants loads the grants stored in the cluster's schema, and then
+// reloads them periodically, unless the interval isn't positive, until the
+// command is closed.
+func (m *Command) monitorGrants() {
+	if err := m.loadGrants(context.Background()); err != nil {
+		m.logger.Errorf("loading grants: %v", err)
+	}
+	interval := time.Duration(m.Config.Auth.GrantsRefreshInterval)
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-m.done:
+			return
+		case <-ticker.C:
+			if err := m.loadGrants(context.Background()); err != nil {
+				m.logger.Errorf("loading grants: %v", err)
+			}
+		}
+	}
+}
+
 func (m *Command) setupQueryLogger() error {
 	var f *logger.FileWriter
 	var err error
@@ -820,6 +970,45 @@ func (m *Command) setupQueryLogger() error {
 	return nil
 }
 
+// setupAuditLogger opens the configured audit log sinks. If none are
+// configured, it returns a nil Logger, which discards events.
+func (m *Command) setupAuditLogger() (*audit.Logger, error) {
+	var sinks []audit.Sink
+	closeSinks := func() {
+		for _, s := range sinks {
+			s.Close()
+		}
+	}
+
+	ac := m.Config.Audit
+	if ac.Path != "" {
+		key, err := hex.DecodeString(ac.Key)
+		if err != nil {
+			return nil, errors.Wrap(err, "decoding audit log key")
+		} else if len(key) == 0 {
+			return nil, errors.New("audit log key required with audit log path")
+		}
+		s, err := audit.NewFileSink(ac.Path, key, ac.MaxSize, ac.MaxBackups)
+		if err != nil {
+			return nil, errors.Wrap(err, "opening audit log file")
+		}
+		sinks = append(sinks, s)
+	}
+	if ac.SyslogAddress != "" {
+		s, err := audit.NewSyslogSink(ac.SyslogNetwork, ac.SyslogAddress, "featurebase")
+		if err != nil {
+			closeSinks()
+			return nil, errors.Wrap(err, "connecting to audit syslog")
+		}
+		sinks = append(sinks, s)
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+	return audit.NewLogger(m.logger, sinks...), nil
+}
+
 func (m *Command) setupProfilingAndTracing() error {
 	if m.Config.DataDog.Enable {
 		opts := make([]profiler.ProfileType, 0)
@@ -895,6 +1084,9 @@ func (m *Command) Close() error {
 		}
 
 		err := eg.Wait()
+		if cerr := m.auditLogger.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
 		_ = testhook.Closed(pilosa.NewAuditor(), m, nil)
 		if m.Config.DataDog.Enable {
 			defer profiler.Stop()
@@ -0,0 +1,90 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"os"
+	"sync"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+// shardQueryCounts counts the queries which have read each shard. The counts
+// only ever increase; the DAX controller derives query rates from successive
+// reports.
+type shardQueryCounts struct {
+	mu sync.Mutex
+	m  map[flatkey]uint64
+}
+
+func (c *shardQueryCounts) add(index string, shards []uint64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.m == nil {
+		c.m = make(map[flatkey]uint64)
+	}
+	for _, shard := range shards {
+		c.m[flatkey{index: index, shard: shard}]++
+	}
+}
+
+func (c *shardQueryCounts) count(index string, shard uint64) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.m[flatkey{index: index, shard: shard}]
+}
+
+// shardBytes returns the size of a shard's files on local disk.
+func (h *Holder) shardBytes(index string, shard uint64) int64 {
+	if h.txf == nil || h.txf.dbPerShard == nil {
+		return 0
+	}
+
+	path := h.txf.dbPerShard.shardPath(index, shard)
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return 0
+	}
+
+	var size int64
+	for _, entry := range entries {
+		if info, err := entry.Info(); err == nil && !info.IsDir() {
+			size += info.Size()
+		}
+	}
+	return size
+}
+
+// WorkerLoad returns the load on each shard in the holder's current
+// directive, including those held as replicas.
+func (h *Holder) WorkerLoad() *dax.WorkerLoad {
+	d := h.Directive()
+
+	load := &dax.WorkerLoad{
+		Shards: []dax.ShardLoad{},
+	}
+	roles := make([]dax.ComputeRole, 0, len(d.ComputeRoles)+len(d.ReplicaRoles))
+	roles = append(roles, d.ComputeRoles...)
+	roles = append(roles, d.ReplicaRoles...)
+
+	seen := make(map[string]map[dax.ShardNum]struct{})
+	for _, cr := range roles {
+		index := string(cr.TableKey)
+		if seen[index] == nil {
+			seen[index] = make(map[dax.ShardNum]struct{})
+		}
+		for _, shard := range cr.Shards {
+			if _, ok := seen[index][shard]; ok {
+				continue
+			}
+			seen[index][shard] = struct{}{}
+			load.Shards = append(load.Shards, dax.ShardLoad{
+				TableKey: cr.TableKey,
+				Shard:    shard,
+				Bytes:    h.shardBytes(index, uint64(shard)),
+				Queries:  h.shardQueries.count(index, uint64(shard)),
+			})
+		}
+	}
+	return load
+}
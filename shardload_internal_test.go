@@ -0,0 +1,46 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"testing"
+
+	"github.com/featurebasedb/featurebase/v3/dax"
+)
+
+func TestHolder_WorkerLoad(t *testing.T) {
+	h := newTestHolder(t)
+	idx, _ := setupTest(t, h, []rowCols{{0, 1}, {0, ShardWidth + 1}, {0, ShardWidth * 2}}, "idxload")
+
+	h.SetDirective(&dax.Directive{
+		ComputeRoles: []dax.ComputeRole{
+			{TableKey: dax.TableKey(idx.name), Shards: dax.NewShardNums(1, 5)},
+		},
+		ReplicaRoles: []dax.ComputeRole{
+			{TableKey: dax.TableKey(idx.name), Shards: dax.NewShardNums(2, 5)},
+		},
+		Version: 1,
+	})
+	h.shardQueries.add(idx.name, []uint64{0, 1})
+	h.shardQueries.add(idx.name, []uint64{1})
+
+	load := h.WorkerLoad()
+	if len(load.Shards) != 3 {
+		t.Fatalf("unexpected shard loads: %+v", load.Shards)
+	}
+
+	// Shard 1 holds data and has been queried twice.
+	if sl := load.Shards[0]; sl.Shard != 1 || sl.Queries != 2 || sl.Bytes <= 0 {
+		t.Fatalf("unexpected load for shard 1: %+v", sl)
+	}
+
+	// Shard 5 has neither data nor queries.
+	if sl := load.Shards[1]; sl.Shard != 5 || sl.Queries != 0 || sl.Bytes != 0 {
+		t.Fatalf("unexpected load for shard 5: %+v", sl)
+	}
+
+	// Shard 2 is held as a replica, and shard 5 isn't reported twice.
+	if sl := load.Shards[2]; sl.Shard != 2 || sl.Queries != 0 || sl.Bytes <= 0 {
+		t.Fatalf("unexpected load for replica shard 2: %+v", sl)
+	}
+}
@@ -0,0 +1,359 @@
+// Copyright 2023 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/proto"
+	"github.com/featurebasedb/featurebase/v3/tracing"
+	"github.com/pkg/errors"
+)
+
+// SimilarRow is a row of a field, with how similar it is to the records
+// selected by a Similar() call.
+type SimilarRow struct {
+	ID           uint64  `json:"id"`
+	Key          string  `json:"key,omitempty"`
+	Score        float64 `json:"score"`
+	Intersection uint64  `json:"intersection"`
+	Count        uint64  `json:"count"`
+}
+
+// SimilarRows is the result of a Similar() call, with the most similar rows
+// first.
+type SimilarRows struct {
+	Rows  []SimilarRow
+	Field string
+}
+
+var _ proto.ToRowser = &SimilarRows{}
+
+// ToTable implements the ToTabler interface.
+func (s *SimilarRows) ToTable() (*proto.TableResponse, error) {
+	return proto.RowsToTable(s, len(s.Rows))
+}
+
+// ToRows implements the ToRowser interface.
+func (s *SimilarRows) ToRows(callback func(*proto.RowResponse) error) error {
+	stringKeys := len(s.Rows) > 0 && s.Rows[0].Key != ""
+	dtype := "uint64"
+	if stringKeys {
+		dtype = "string"
+	}
+	ci := []*proto.ColumnInfo{
+		{Name: s.Field, Datatype: dtype},
+		{Name: "score", Datatype: "float64"},
+		{Name: "intersection", Datatype: "uint64"},
+		{Name: "count", Datatype: "uint64"},
+	}
+	for _, row := range s.Rows {
+		id := &proto.ColumnResponse{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: row.ID}}
+		if stringKeys {
+			id = &proto.ColumnResponse{ColumnVal: &proto.ColumnResponse_StringVal{StringVal: row.Key}}
+		}
+		if err := callback(&proto.RowResponse{
+			Headers: ci,
+			Columns: []*proto.ColumnResponse{
+				id,
+				{ColumnVal: &proto.ColumnResponse_Float64Val{Float64Val: row.Score}},
+				{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: row.Intersection}},
+				{ColumnVal: &proto.ColumnResponse_Uint64Val{Uint64Val: row.Count}},
+			},
+		}); err != nil {
+			return errors.Wrap(err, "calling callback")
+		}
+		ci = nil
+	}
+	return nil
+}
+
+// similarityMetrics are the metrics a Similar() call accepts, each of which
+// scores a row from the number of records it has, the number of records
+// being compared with, and the number in both.
+var similarityMetrics = map[string]func(intersection, count, total uint64) float64{
+	"jaccard": func(intersection, count, total uint64) float64 {
+		return float64(intersection) / float64(count+total-intersection)
+	},
+	"overlap": func(intersection, count, total uint64) float64 {
+		if count < total {
+			return float64(intersection) / float64(count)
+		}
+		return float64(intersection) / float64(total)
+	},
+	"cosine": func(intersection, count, total uint64) float64 {
+		return float64(intersection) / math.Sqrt(float64(count)*float64(total))
+	},
+}
+
+// executeSimilar executes a Similar() call, which scores each row of a field
+// by how similar it is to the records selected by its child, and returns the
+// n most similar. Only rows sharing records with the child are scored. When
+// the child is a row of the field itself, that row isn't included.
+//
+// Like TopN(), it's executed in two passes. First each shard scores the rows
+// against the records in that shard, and the n best of each shard are
+// merged. Then the records each of those rows shares with the child, and the
+// records in each of them, are counted over every shard, so that they're
+// scored exactly. The second pass is done alone if the rows are given as ids.
+func (e *executor) executeSimilar(ctx context.Context, qcx *Qcx, index string, c *pql.Call, shards []uint64, opt *ExecOptions) (*SimilarRows, error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeSimilar")
+	defer span.Finish()
+
+	if len(c.Children) != 1 {
+		return nil, errors.New("Similar() requires a single bitmap input")
+	}
+	child := c.Children[0]
+
+	fieldName, err := c.FirstStringArg("field", "_field")
+	if err != nil {
+		return nil, errors.New("Similar(): field required")
+	}
+	field := e.Holder.Field(index, fieldName)
+	if field == nil {
+		return nil, newNotFoundError(ErrFieldNotFound, fieldName)
+	}
+	switch field.Type() {
+	case FieldTypeSet, FieldTypeMutex, FieldTypeTime:
+	default:
+		return nil, errors.Errorf("Similar(): field %s must be a set, mutex or time field", fieldName)
+	}
+
+	metricName := "jaccard"
+	if v, ok := c.Args["metric"]; ok {
+		metricName, _ = v.(string)
+	}
+	metric, ok := similarityMetrics[strings.ToLower(metricName)]
+	if !ok {
+		return nil, errors.Errorf("Similar(): metric must be one of jaccard, overlap or cosine, got %v", c.Args["metric"])
+	}
+
+	n, _, err := c.UintArg("n")
+	if err != nil {
+		return nil, errors.Wrap(err, "Similar(): n")
+	}
+	ids, hasIDs, err := c.UintSliceArg("ids")
+	if err != nil {
+		return nil, errors.Wrap(err, "Similar(): ids")
+	} else if hasIDs {
+		// The ids are sent to other nodes as given, which must be a form
+		// they can parse.
+		c = c.Clone()
+		c.Args["ids"] = ids
+	}
+
+	var filter *pql.Call
+	if v, ok := c.Args["filter"]; ok {
+		if filter, ok = v.(*pql.Call); !ok {
+			return nil, errors.Errorf("Similar(): filter must be a bitmap call, got %v of type %[1]T", v)
+		}
+		child = &pql.Call{Name: "Intersect", Children: []*pql.Call{child, filter}}
+	}
+	self, hasSelf := similarSelf(c.Children[0], fieldName)
+
+	mapFn := func(ctx context.Context, shard uint64, mopt *mapOptions) (_ interface{}, err error) {
+		return e.executeSimilarShard(ctx, qcx, index, fieldName, child, filter, metric, n, ids, hasIDs, self, hasSelf, shard)
+	}
+	reduceFn := func(ctx context.Context, prev, v interface{}) interface{} {
+		other, _ := prev.(*SimilarRows)
+		vsr, _ := v.(*SimilarRows)
+		if other == nil {
+			return vsr
+		} else if vsr == nil {
+			return other
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		other.add(vsr)
+		return other
+	}
+
+	v, err := e.mapReduce(ctx, index, shards, c, opt, mapFn, reduceFn)
+	if err != nil {
+		return nil, err
+	}
+	result, _ := v.(*SimilarRows)
+	if result == nil || len(result.Rows) == 0 {
+		return &SimilarRows{Field: fieldName}, nil
+	}
+	// Only the original caller scores the rows, once their counts over every
+	// shard are known.
+	if opt.Remote {
+		return result, nil
+	}
+
+	if !hasIDs {
+		other := c.Clone()
+		ids := make([]uint64, len(result.Rows))
+		for i, row := range result.Rows {
+			ids[i] = row.ID
+		}
+		sort.Sort(uint64Slice(ids))
+		other.Args["ids"] = ids
+
+		v, err := e.executeSimilar(ctx, qcx, index, other, shards, opt)
+		if err != nil {
+			return nil, errors.Wrap(err, "counting rows")
+		}
+		if n != 0 && int(n) < len(v.Rows) {
+			v.Rows = v.Rows[:n]
+		}
+		return v, nil
+	}
+
+	total, err := e.executeCount(ctx, qcx, index, &pql.Call{Name: "Count", Children: []*pql.Call{child}}, shards, opt)
+	if err != nil {
+		return nil, errors.Wrap(err, "counting records")
+	}
+	var rows []SimilarRow
+	for _, row := range result.Rows {
+		if row.Intersection == 0 {
+			continue
+		}
+		row.Score = metric(row.Intersection, row.Count, total)
+		rows = append(rows, row)
+	}
+	result.Rows = rows
+	sortSimilarRows(result.Rows)
+	return result, nil
+}
+
+// executeSimilarShard counts the records in a shard which each row of a field
+// shares with the records selected by child, and the records in each row
+// within filter, if it's not nil. If hasIDs, only the rows in ids are
+// counted. Otherwise only the rows sharing records with child are, and the n
+// which are most similar within the shard are returned.
+func (e *executor) executeSimilarShard(ctx context.Context, qcx *Qcx, index, fieldName string, child, filter *pql.Call, metric func(intersection, count, total uint64) float64, n uint64, ids []uint64, hasIDs bool, self uint64, hasSelf bool, shard uint64) (_ *SimilarRows, err0 error) {
+	span, ctx := tracing.StartSpanFromContext(ctx, "executor.executeSimilarShard")
+	defer span.Finish()
+
+	idx := e.Holder.Index(index)
+	if idx == nil {
+		return nil, newNotFoundError(ErrIndexNotFound, index)
+	}
+	childRow, err := e.executeBitmapCallShard(ctx, qcx, index, child, shard)
+	if err != nil {
+		return nil, err
+	}
+	if !hasIDs && !childRow.Any() {
+		return nil, nil
+	}
+	var filterRow *Row
+	if filter != nil {
+		if filterRow, err = e.executeBitmapCallShard(ctx, qcx, index, filter, shard); err != nil {
+			return nil, err
+		}
+	}
+
+	frag := e.Holder.fragment(index, fieldName, viewStandard, shard)
+	if frag == nil {
+		return nil, nil
+	}
+	tx, finisher, err := qcx.GetTx(Txo{Write: !writable, Index: idx, Shard: shard})
+	if err != nil {
+		return nil, err
+	}
+	defer finisher(&err0)
+
+	// The counts are perpendicular BSI bitmaps, whose columns are rows of
+	// the field, as for TopK().
+	intersections, err := topKFragments(ctx, tx, childRow, frag)
+	if err != nil {
+		return nil, err
+	}
+	counts, err := topKFragments(ctx, tx, filterRow, frag)
+	if err != nil {
+		return nil, err
+	}
+	selected := NewRow(ids...)
+	if !hasIDs {
+		selected = NewRow().Union(intersections...)
+	}
+
+	rowIntersections := make(map[uint64]uint64)
+	intersections.PivotDescending(selected, 0, nil, nil, func(count uint64, ids ...uint64) {
+		for _, id := range ids {
+			rowIntersections[id] = count
+		}
+	})
+	result := &SimilarRows{Field: fieldName}
+	counts.PivotDescending(selected, 0, nil, nil, func(count uint64, ids ...uint64) {
+		if count == 0 {
+			return
+		}
+		for _, id := range ids {
+			if hasSelf && id == self {
+				continue
+			}
+			result.Rows = append(result.Rows, SimilarRow{
+				ID:           id,
+				Intersection: rowIntersections[id],
+				Count:        count,
+			})
+		}
+	})
+	if hasIDs {
+		return result, nil
+	}
+
+	total := childRow.Count()
+	for i := range result.Rows {
+		row := &result.Rows[i]
+		row.Score = metric(row.Intersection, row.Count, total)
+	}
+	sortSimilarRows(result.Rows)
+	if n != 0 && int(n) < len(result.Rows) {
+		result.Rows = result.Rows[:n]
+	}
+	return result, nil
+}
+
+// add adds the counts of the rows in other to those of the same rows in s.
+func (s *SimilarRows) add(other *SimilarRows) {
+	rows := make(map[uint64]int, len(s.Rows))
+	for i, row := range s.Rows {
+		rows[row.ID] = i
+	}
+	for _, row := range other.Rows {
+		if i, ok := rows[row.ID]; ok {
+			s.Rows[i].Intersection += row.Intersection
+			s.Rows[i].Count += row.Count
+		} else {
+			rows[row.ID] = len(s.Rows)
+			s.Rows = append(s.Rows, row)
+		}
+	}
+}
+
+// sortSimilarRows sorts rows with the most similar first.
+func sortSimilarRows(rows []SimilarRow) {
+	sort.Slice(rows, func(i, j int) bool {
+		a, b := rows[i], rows[j]
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		} else if a.Intersection != b.Intersection {
+			return a.Intersection > b.Intersection
+		}
+		return a.ID < b.ID
+	})
+}
+
+// similarSelf returns the ID of the row of field which c selects, if c is a
+// Row() call on field.
+func similarSelf(c *pql.Call, field string) (uint64, bool) {
+	if c.Name != "Row" || len(c.Args) != 1 {
+		return 0, false
+	}
+	switch id := c.Args[field].(type) {
+	case uint64:
+		return id, true
+	case int64:
+		return uint64(id), id >= 0
+	}
+	return 0, false
+}
@@ -105,6 +105,7 @@ const (
 	ErrExpectedColumnReference         errors.Code = "ErrExpectedColumnReference"
 	ErrExpectedSortExpressionReference errors.Code = "ErrExpectedSortExpressionReference"
 	ErrExpectedSortableExpression      errors.Code = "ErrExpectedSortableExpression"
+	ErrExpectedTableReference          errors.Code = "ErrExpectedTableReference"
 
 	// call errors
 	ErrCallUnknownFunction                  errors.Code = "ErrCallUnknownFunction"
@@ -149,6 +150,12 @@ const (
 	// remote execution
 	ErrRemoteUnauthorized errors.Code = "ErrRemoteUnauthorized"
 
+	// authorization
+	ErrInsufficientPermissions errors.Code = "ErrInsufficientPermissions"
+	ErrRoleExists              errors.Code = "ErrRoleExists"
+	ErrRoleNotFound            errors.Code = "ErrRoleNotFound"
+	ErrInvalidRoleName         errors.Code = "ErrInvalidRoleName"
+
 	// query hints
 	ErrUnknownQueryHint               errors.Code = "ErrInvalidQueryHint"
 	ErrInvalidQueryHintParameterCount errors.Code = "ErrInvalidQueryHintParameterCount"
@@ -705,6 +712,13 @@ func NewErrExpectedColumnReference(line, col int) error {
 	)
 }
 
+func NewErrExpectedTableReference(line, col int) error {
+	return errors.New(
+		ErrExpectedTableReference,
+		fmt.Sprintf("[%d:%d] table reference expected", line, col),
+	)
+}
+
 func NewErrExpectedSortExpressionReference(line, col int) error {
 	return errors.New(
 		ErrExpectedSortExpressionReference,
@@ -919,6 +933,36 @@ func NewErrRemoteUnauthorized(line, col int, remoteUrl string) error {
 	)
 }
 
+// authorization
+
+func NewErrInsufficientPermissions(objectName string, permission string) error {
+	return errors.New(
+		ErrInsufficientPermissions,
+		fmt.Sprintf("insufficient permissions: %s permission required for '%s'", permission, objectName),
+	)
+}
+
+func NewErrRoleExists(line, col int, roleName string) error {
+	return errors.New(
+		ErrRoleExists,
+		fmt.Sprintf("[%d:%d] role '%s' already exists", line, col, roleName),
+	)
+}
+
+func NewErrRoleNotFound(line, col int, roleName string) error {
+	return errors.New(
+		ErrRoleNotFound,
+		fmt.Sprintf("[%d:%d] role '%s' not found", line, col, roleName),
+	)
+}
+
+func NewErrInvalidRoleName(line, col int, roleName string) error {
+	return errors.New(
+		ErrInvalidRoleName,
+		fmt.Sprintf("[%d:%d] invalid role name '%s': role names can't contain ':'", line, col, roleName),
+	)
+}
+
 // query hints
 
 func NewErrUnknownQueryHint(line, col int, hintName string) error {
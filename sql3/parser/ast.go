@@ -22,6 +22,7 @@ func (*ShowDatabasesStatement) node()   {}
 func (*ShowTablesStatement) node()      {}
 func (*ShowColumnsStatement) node()     {}
 func (*ShowCreateTableStatement) node() {}
+func (*ShowGrantsStatement) node()      {}
 func (*BeginStatement) node()           {}
 func (*BinaryExpr) node()               {}
 func (*BoolLit) node()                  {}
@@ -42,6 +43,7 @@ func (*CreateTableStatement) node()     {}
 func (*CreateFunctionStatement) node()  {}
 func (*CreateModelStatement) node()     {}
 func (*CreateViewStatement) node()      {}
+func (*CreateRoleStatement) node()      {}
 func (*DateLit) node()                  {}
 func (*DefaultConstraint) node()        {}
 func (*DeleteStatement) node()          {}
@@ -51,6 +53,7 @@ func (*DropTableStatement) node()       {}
 func (*DropFunctionStatement) node()    {}
 func (*DropViewStatement) node()        {}
 func (*DropModelStatement) node()       {}
+func (*DropRoleStatement) node()        {}
 func (*Exists) node()                   {}
 func (*ExplainStatement) node()         {}
 func (*ExprList) node()                 {}
@@ -59,6 +62,7 @@ func (*FloatLit) node()                 {}
 func (*ForeignKeyArg) node()            {}
 func (*ForeignKeyConstraint) node()     {}
 func (*FrameSpec) node()                {}
+func (*GrantStatement) node()           {}
 func (*Ident) node()                    {}
 func (*Variable) node()                 {}
 func (*SysVariable) node()              {}
@@ -85,6 +89,7 @@ func (*QualifiedTableName) node()       {}
 func (*Range) node()                    {}
 func (*ReturnStatement) node()          {}
 func (*ReleaseStatement) node()         {}
+func (*RevokeStatement) node()          {}
 func (*ResultColumn) node()             {}
 func (*RollbackStatement) node()        {}
 func (*SavepointStatement) node()       {}
@@ -123,6 +128,7 @@ func (*ShowDatabasesStatement) stmt()   {}
 func (*ShowTablesStatement) stmt()      {}
 func (*ShowColumnsStatement) stmt()     {}
 func (*ShowCreateTableStatement) stmt() {}
+func (*ShowGrantsStatement) stmt()      {}
 func (*CommitStatement) stmt()          {}
 func (*CreateDatabaseStatement) stmt()  {}
 func (*CreateIndexStatement) stmt()     {}
@@ -130,6 +136,7 @@ func (*CreateTableStatement) stmt()     {}
 func (*CreateFunctionStatement) stmt()  {}
 func (*CreateModelStatement) stmt()     {}
 func (*CreateViewStatement) stmt()      {}
+func (*CreateRoleStatement) stmt()      {}
 func (*DeleteStatement) stmt()          {}
 func (*DropDatabaseStatement) stmt()    {}
 func (*DropIndexStatement) stmt()       {}
@@ -137,10 +144,13 @@ func (*DropTableStatement) stmt()       {}
 func (*DropFunctionStatement) stmt()    {}
 func (*DropViewStatement) stmt()        {}
 func (*DropModelStatement) stmt()       {}
+func (*DropRoleStatement) stmt()        {}
 func (*PredictStatement) stmt()         {}
 func (*ExplainStatement) stmt()         {}
+func (*GrantStatement) stmt()           {}
 func (*InsertStatement) stmt()          {}
 func (*ReleaseStatement) stmt()         {}
+func (*RevokeStatement) stmt()          {}
 func (*ReturnStatement) stmt()          {}
 func (*RollbackStatement) stmt()        {}
 func (*SavepointStatement) stmt()       {}
@@ -176,6 +186,8 @@ func CloneStatement(stmt Statement) Statement {
 		return stmt.Clone()
 	case *CreateViewStatement:
 		return stmt.Clone()
+	case *CreateRoleStatement:
+		return stmt.Clone()
 	case *DeleteStatement:
 		return stmt.Clone()
 	case *DropDatabaseStatement:
@@ -190,6 +202,12 @@ func CloneStatement(stmt Statement) Statement {
 		return stmt.Clone()
 	case *DropModelStatement:
 		return stmt.Clone()
+	case *DropRoleStatement:
+		return stmt.Clone()
+	case *GrantStatement:
+		return stmt.Clone()
+	case *RevokeStatement:
+		return stmt.Clone()
 	case *ExplainStatement:
 		return stmt.Clone()
 	case *InsertStatement:
@@ -214,6 +232,8 @@ func CloneStatement(stmt Statement) Statement {
 		return stmt.Clone()
 	case *ShowDatabasesStatement:
 		return stmt.Clone()
+	case *ShowGrantsStatement:
+		return stmt.Clone()
 	default:
 		panic(fmt.Sprintf("invalid statement type: %T", stmt))
 	}
@@ -606,6 +626,29 @@ func (s *ShowCreateTableStatement) Clone() *ShowCreateTableStatement {
 	return &other
 }
 
+type ShowGrantsStatement struct {
+	Show   Pos    // position of SHOW
+	Grants Pos    // position of GRANTS
+	For    Pos    // position of optional FOR
+	Role   *Ident // name of role, if any
+}
+
+// String returns the string representation of the statement.
+func (s *ShowGrantsStatement) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("SHOW GRANTS")
+	if s.Role != nil {
+		fmt.Fprintf(&buf, " FOR %s", s.Role.String())
+	}
+	return buf.String()
+}
+
+func (s *ShowGrantsStatement) Clone() *ShowGrantsStatement {
+	other := *s
+	other.Role = s.Role.Clone()
+	return &other
+}
+
 type BeginStatement struct {
 	Begin       Pos // position of BEGIN
 	Deferred    Pos // position of DEFERRED keyword
@@ -2960,6 +3003,138 @@ func (s *DropModelStatement) String() string {
 	return buf.String()
 }
 
+type CreateRoleStatement struct {
+	Create      Pos    // position of CREATE keyword
+	Role        Pos    // position of ROLE keyword
+	If          Pos    // position of IF keyword
+	IfNot       Pos    // position of NOT keyword after IF
+	IfNotExists Pos    // position of EXISTS keyword after IF NOT
+	Name        *Ident // role name
+}
+
+// Clone returns a deep copy of s.
+func (s *CreateRoleStatement) Clone() *CreateRoleStatement {
+	if s == nil {
+		return nil
+	}
+	other := *s
+	other.Name = s.Name.Clone()
+	return &other
+}
+
+// String returns the string representation of the statement.
+func (s *CreateRoleStatement) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("CREATE ROLE")
+	if s.IfNotExists.IsValid() {
+		buf.WriteString(" IF NOT EXISTS")
+	}
+	fmt.Fprintf(&buf, " %s", s.Name.String())
+	return buf.String()
+}
+
+type DropRoleStatement struct {
+	Drop     Pos    // position of DROP keyword
+	Role     Pos    // position of ROLE keyword
+	If       Pos    // position of IF keyword
+	IfExists Pos    // position of EXISTS keyword after IF
+	Name     *Ident // role name
+}
+
+// Clone returns a deep copy of s.
+func (s *DropRoleStatement) Clone() *DropRoleStatement {
+	if s == nil {
+		return nil
+	}
+	other := *s
+	other.Name = s.Name.Clone()
+	return &other
+}
+
+// String returns the string representation of the statement.
+func (s *DropRoleStatement) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("DROP ROLE")
+	if s.IfExists.IsValid() {
+		buf.WriteString(" IF EXISTS")
+	}
+	fmt.Fprintf(&buf, " %s", s.Name.String())
+	return buf.String()
+}
+
+// writePrivileges writes a comma-separated list of privileges to buf.
+func writePrivileges(buf *bytes.Buffer, privileges []Token) {
+	for i, priv := range privileges {
+		if i != 0 {
+			buf.WriteString(",")
+		}
+		fmt.Fprintf(buf, " %s", priv.String())
+		if priv == ALL {
+			buf.WriteString(" PRIVILEGES")
+		}
+	}
+}
+
+type GrantStatement struct {
+	Grant      Pos     // position of GRANT keyword
+	Privileges []Token // SELECT, INSERT, UPDATE, DELETE or ALL
+	On         Pos     // position of ON keyword
+	Table      *Ident  // table name
+	To         Pos     // position of TO keyword
+	Role       *Ident  // role name
+}
+
+// Clone returns a deep copy of s.
+func (s *GrantStatement) Clone() *GrantStatement {
+	if s == nil {
+		return nil
+	}
+	other := *s
+	other.Privileges = append([]Token(nil), s.Privileges...)
+	other.Table = s.Table.Clone()
+	other.Role = s.Role.Clone()
+	return &other
+}
+
+// String returns the string representation of the statement.
+func (s *GrantStatement) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("GRANT")
+	writePrivileges(&buf, s.Privileges)
+	fmt.Fprintf(&buf, " ON %s TO %s", s.Table.String(), s.Role.String())
+	return buf.String()
+}
+
+type RevokeStatement struct {
+	Revoke     Pos     // position of REVOKE keyword
+	Privileges []Token // SELECT, INSERT, UPDATE, DELETE or ALL
+	On         Pos     // position of ON keyword
+	Table      *Ident  // table name
+	From       Pos     // position of FROM keyword
+	Role       *Ident  // role name
+}
+
+// Clone returns a deep copy of s.
+func (s *RevokeStatement) Clone() *RevokeStatement {
+	if s == nil {
+		return nil
+	}
+	other := *s
+	other.Privileges = append([]Token(nil), s.Privileges...)
+	other.Table = s.Table.Clone()
+	other.Role = s.Role.Clone()
+	return &other
+}
+
+// String returns the string representation of the statement.
+func (s *RevokeStatement) String() string {
+	var buf bytes.Buffer
+	buf.WriteString("REVOKE")
+	writePrivileges(&buf, s.Privileges)
+	fmt.Fprintf(&buf, " ON %s FROM %s", s.Table.String(), s.Role.String())
+	return buf.String()
+}
+
 type CreateIndexStatement struct {
 	Create      Pos              // position of CREATE keyword
 	Unique      Pos              // position of optional UNIQUE keyword
@@ -4187,6 +4362,7 @@ type QualifiedTableName struct {
 	LParen        Pos
 	QueryOptions  []*TableQueryOption
 	RParen        Pos
+	Sample        *TableSample          // optional sampling clause
 	OutputColumns []*SourceOutputColumn // output columns - populated during analysis
 }
 
@@ -4223,6 +4399,7 @@ func (n *QualifiedTableName) Clone() *QualifiedTableName {
 	other.Name = n.Name.Clone()
 	other.Alias = n.Alias.Clone()
 	other.QueryOptions = cloneQueryOptions(n.QueryOptions)
+	other.Sample = n.Sample.Clone()
 	return &other
 }
 
@@ -4247,6 +4424,59 @@ func (n *QualifiedTableName) String() string {
 		}
 		buf.WriteString(")")
 	}
+
+	if n.Sample != nil {
+		fmt.Fprintf(&buf, " %s", n.Sample.String())
+	}
+	return buf.String()
+}
+
+// TableSample is a clause sampling the rows of a table, either a percentage
+// of them ("TABLESAMPLE [BERNOULLI|SYSTEM] (percent)") or a number of them
+// ("SAMPLE n ROWS"), optionally from a repeatable seed ("REPEATABLE (seed)").
+type TableSample struct {
+	Tablesample Pos    // position of TABLESAMPLE keyword
+	Method      *Ident // optional sampling method
+	LParen      Pos    // position of left paren
+	Percent     Expr   // percentage of rows to sample
+	RParen      Pos    // position of right paren
+	SampleKw    Pos    // position of SAMPLE keyword
+	N           Expr   // number of rows to sample
+	Rows        Pos    // position of ROWS keyword
+	Repeatable  Pos    // position of REPEATABLE keyword
+	SeedLParen  Pos    // position of left paren
+	Seed        Expr   // optional seed
+	SeedRParen  Pos    // position of right paren
+}
+
+// Clone returns a deep copy of n.
+func (n *TableSample) Clone() *TableSample {
+	if n == nil {
+		return nil
+	}
+	other := *n
+	other.Method = n.Method.Clone()
+	other.Percent = CloneExpr(n.Percent)
+	other.N = CloneExpr(n.N)
+	other.Seed = CloneExpr(n.Seed)
+	return &other
+}
+
+// String returns the string representation of the clause.
+func (n *TableSample) String() string {
+	var buf bytes.Buffer
+	if n.Tablesample.IsValid() {
+		buf.WriteString("TABLESAMPLE")
+		if n.Method != nil {
+			fmt.Fprintf(&buf, " %s", n.Method.String())
+		}
+		fmt.Fprintf(&buf, " (%s)", n.Percent.String())
+	} else {
+		fmt.Fprintf(&buf, "SAMPLE %s ROWS", n.N.String())
+	}
+	if n.Repeatable.IsValid() {
+		fmt.Fprintf(&buf, " REPEATABLE (%s)", n.Seed.String())
+	}
 	return buf.String()
 }
 
@@ -845,6 +845,28 @@ func TestSelectStatement_String(t *testing.T) {
 		HavingExpr:   &parser.Ident{Name: "z"},
 	}, `SELECT * FROM tbl WHERE TRUE GROUP BY x, y HAVING z`)
 
+	AssertStatementStringer(t, &parser.SelectStatement{
+		Columns: []*parser.ResultColumn{{Star: pos(0)}},
+		Source: &parser.QualifiedTableName{
+			Name: &parser.Ident{Name: "tbl"},
+			Sample: &parser.TableSample{
+				Tablesample: pos(0),
+				Method:      &parser.Ident{Name: "BERNOULLI"},
+				Percent:     &parser.FloatLit{Value: "2.5"},
+				Repeatable:  pos(0),
+				Seed:        &parser.IntegerLit{Value: "42"},
+			},
+		},
+	}, `SELECT * FROM tbl TABLESAMPLE BERNOULLI (2.5) REPEATABLE (42)`)
+
+	AssertStatementStringer(t, &parser.SelectStatement{
+		Columns: []*parser.ResultColumn{{Star: pos(0)}},
+		Source: &parser.QualifiedTableName{
+			Name:   &parser.Ident{Name: "tbl"},
+			Sample: &parser.TableSample{SampleKw: pos(0), N: &parser.IntegerLit{Value: "100"}},
+		},
+	}, `SELECT * FROM tbl SAMPLE 100 ROWS`)
+
 	AssertStatementStringer(t, &parser.SelectStatement{
 		Columns: []*parser.ResultColumn{{Star: pos(0)}},
 		Source: &parser.ParenSource{
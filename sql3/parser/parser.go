@@ -119,6 +119,10 @@ func (p *Parser) parseNonExplainStatement() (Statement, error) {
 		//		return p.parseWithStatement()
 	case SHOW:
 		return p.parseShowStatement()
+	case GRANT:
+		return p.parseGrantStatement()
+	case REVOKE:
+		return p.parseRevokeStatement()
 	default:
 		return nil, p.errorExpected(p.pos, p.tok, "statement")
 	}
@@ -159,8 +163,10 @@ func (p *Parser) parseShowStatement() (Statement, error) {
 		return p.parseShowColumnsStatement(show)
 	case CREATE:
 		return p.parseShowCreateStatement(show)
+	case GRANTS:
+		return p.parseShowGrantsStatement(show)
 	default:
-		return nil, p.errorExpected(p.pos, p.tok, "DATABASES, TABLES, COLUMNS or CREATE")
+		return nil, p.errorExpected(p.pos, p.tok, "DATABASES, TABLES, COLUMNS, CREATE or GRANTS")
 	}
 }
 
@@ -214,6 +220,22 @@ func (p *Parser) parseShowColumnsStatement(showPos Pos) (_ *ShowColumnsStatement
 	}
 }
 
+func (p *Parser) parseShowGrantsStatement(showPos Pos) (_ *ShowGrantsStatement, err error) {
+	assert(p.peek() == GRANTS)
+
+	var stmt ShowGrantsStatement
+	stmt.Show = showPos
+	stmt.Grants, _, _ = p.scan()
+
+	if p.peek() == FOR {
+		stmt.For, _, _ = p.scan()
+		if stmt.Role, err = p.parseIdent("role name"); err != nil {
+			return &stmt, err
+		}
+	}
+	return &stmt, nil
+}
+
 func (p *Parser) parseShowCreateStatement(showPos Pos) (Statement, error) {
 	assert(p.peek() == CREATE)
 	create, _, _ := p.scan()
@@ -348,8 +370,10 @@ func (p *Parser) parseCreateStatement() (Statement, error) {
 		return p.parseCreateFunctionStatement(pos)
 	case MODEL:
 		return p.parseCreateModelStatement(pos)
+	case ROLE:
+		return p.parseCreateRoleStatement(pos)
 	default:
-		return nil, p.errorExpected(pos, tok, "DATABASE, TABLE, VIEW, FUNCTION or MODEL")
+		return nil, p.errorExpected(pos, tok, "DATABASE, TABLE, VIEW, FUNCTION, MODEL or ROLE")
 	}
 }
 
@@ -386,8 +410,10 @@ func (p *Parser) parseDropStatement() (Statement, error) {
 		return p.parseDropFunctionStatement(pos)
 	case MODEL:
 		return p.parseDropModelStatement(pos)
+	case ROLE:
+		return p.parseDropRoleStatement(pos)
 	default:
-		return nil, p.errorExpected(pos, tok, "DATABASE, TABLE, VIEW or FUNCTION")
+		return nil, p.errorExpected(pos, tok, "DATABASE, TABLE, VIEW, FUNCTION, MODEL or ROLE")
 	}
 }
 
@@ -1388,6 +1414,149 @@ func (p *Parser) parseDropModelStatement(dropPos Pos) (_ *DropModelStatement, er
 	return &stmt, nil
 }
 
+func (p *Parser) parseCreateRoleStatement(createPos Pos) (_ *CreateRoleStatement, err error) {
+	assert(p.peek() == ROLE)
+
+	var stmt CreateRoleStatement
+	stmt.Create = createPos
+	stmt.Role, _, _ = p.scan()
+
+	// Parse optional "IF NOT EXISTS".
+	if p.peek() == IF {
+		stmt.If, _, _ = p.scan()
+
+		if p.peek() != NOT {
+			return &stmt, p.errorExpected(p.pos, p.tok, "NOT")
+		}
+		stmt.IfNot, _, _ = p.scan()
+
+		if p.peek() != EXISTS {
+			return &stmt, p.errorExpected(p.pos, p.tok, "EXISTS")
+		}
+		stmt.IfNotExists, _, _ = p.scan()
+	}
+
+	if stmt.Name, err = p.parseIdent("role name"); err != nil {
+		return &stmt, err
+	}
+
+	return &stmt, nil
+}
+
+func (p *Parser) parseDropRoleStatement(dropPos Pos) (_ *DropRoleStatement, err error) {
+	assert(p.peek() == ROLE)
+
+	var stmt DropRoleStatement
+	stmt.Drop = dropPos
+	stmt.Role, _, _ = p.scan()
+
+	// Parse optional "IF EXISTS".
+	if p.peek() == IF {
+		stmt.If, _, _ = p.scan()
+		if p.peek() != EXISTS {
+			return &stmt, p.errorExpected(p.pos, p.tok, "EXISTS")
+		}
+		stmt.IfExists, _, _ = p.scan()
+	}
+
+	if stmt.Name, err = p.parseIdent("role name"); err != nil {
+		return &stmt, err
+	}
+
+	return &stmt, nil
+}
+
+func (p *Parser) parseGrantStatement() (_ *GrantStatement, err error) {
+	assert(p.peek() == GRANT)
+
+	var stmt GrantStatement
+	stmt.Grant, _, _ = p.scan()
+
+	if stmt.Privileges, err = p.parsePrivileges(); err != nil {
+		return &stmt, err
+	}
+	if stmt.On, stmt.Table, err = p.parsePrivilegesTable(); err != nil {
+		return &stmt, err
+	}
+
+	if p.peek() != TO {
+		return &stmt, p.errorExpected(p.pos, p.tok, "TO")
+	}
+	stmt.To, _, _ = p.scan()
+
+	if stmt.Role, err = p.parseIdent("role name"); err != nil {
+		return &stmt, err
+	}
+
+	return &stmt, nil
+}
+
+func (p *Parser) parseRevokeStatement() (_ *RevokeStatement, err error) {
+	assert(p.peek() == REVOKE)
+
+	var stmt RevokeStatement
+	stmt.Revoke, _, _ = p.scan()
+
+	if stmt.Privileges, err = p.parsePrivileges(); err != nil {
+		return &stmt, err
+	}
+	if stmt.On, stmt.Table, err = p.parsePrivilegesTable(); err != nil {
+		return &stmt, err
+	}
+
+	if p.peek() != FROM {
+		return &stmt, p.errorExpected(p.pos, p.tok, "FROM")
+	}
+	stmt.From, _, _ = p.scan()
+
+	if stmt.Role, err = p.parseIdent("role name"); err != nil {
+		return &stmt, err
+	}
+
+	return &stmt, nil
+}
+
+// parsePrivileges parses the comma-separated privileges of a GRANT or REVOKE
+// statement. ALL may be followed by the optional PRIVILEGES keyword, in
+// which case it must be the only privilege.
+func (p *Parser) parsePrivileges() ([]Token, error) {
+	if p.peek() == ALL {
+		p.scan()
+		if p.peek() == PRIVILEGES {
+			p.scan()
+		}
+		return []Token{ALL}, nil
+	}
+
+	var privileges []Token
+	for {
+		switch tok := p.peek(); tok {
+		case SELECT, INSERT, UPDATE, DELETE:
+			p.scan()
+			privileges = append(privileges, tok)
+		default:
+			return privileges, p.errorExpected(p.pos, p.tok, "SELECT, INSERT, UPDATE, DELETE or ALL")
+		}
+
+		if p.peek() != COMMA {
+			return privileges, nil
+		}
+		p.scan()
+	}
+}
+
+// parsePrivilegesTable parses the "ON table" clause of a GRANT or REVOKE
+// statement.
+func (p *Parser) parsePrivilegesTable() (on Pos, table *Ident, err error) {
+	if p.peek() != ON {
+		return on, nil, p.errorExpected(p.pos, p.tok, "ON")
+	}
+	on, _, _ = p.scan()
+
+	table, err = p.parseIdent("table name")
+	return on, table, err
+}
+
 /*func (p *Parser) parseCreateIndexStatement(createPos Pos) (_ *CreateIndexStatement, err error) {
 	assert(p.peek() == INDEX || p.peek() == UNIQUE)
 
@@ -2618,7 +2787,16 @@ func (p *Parser) parseUnarySource() (source Source, err error) {
 		if p.peek() == LP {
 			return p.parseTableValuedFunction(ident)
 		}
-		return p.parseQualifiedTableName(ident)
+		tbl, err := p.parseQualifiedTableName(ident)
+		if err != nil {
+			return tbl, err
+		}
+		if tok := p.peek(); tok == TABLESAMPLE || tok == SAMPLE {
+			if tbl.Sample, err = p.parseTableSample(); err != nil {
+				return tbl, err
+			}
+		}
+		return tbl, nil
 	default:
 		return nil, p.errorExpected(p.pos, p.tok, "table name or left paren")
 	}
@@ -2799,6 +2977,65 @@ func (p *Parser) parseQualifiedTableName(ident *Ident) (_ *QualifiedTableName, e
 	return &tbl, nil
 }
 
+func (p *Parser) parseTableSample() (_ *TableSample, err error) {
+	assert(p.peek() == TABLESAMPLE || p.peek() == SAMPLE)
+
+	var sample TableSample
+	if p.peek() == TABLESAMPLE {
+		sample.Tablesample, _, _ = p.scan()
+
+		// Parse optional sampling method.
+		if isIdentToken(p.peek()) {
+			if sample.Method, err = p.parseIdent("sampling method"); err != nil {
+				return &sample, err
+			}
+			switch strings.ToUpper(sample.Method.Name) {
+			case "BERNOULLI", "SYSTEM":
+			default:
+				return &sample, p.errorExpected(sample.Method.NamePos, IDENT, "BERNOULLI or SYSTEM")
+			}
+		}
+
+		if p.peek() != LP {
+			return &sample, p.errorExpected(p.pos, p.tok, "left paren")
+		}
+		sample.LParen, _, _ = p.scan()
+		if sample.Percent, err = p.ParseExpr(); err != nil {
+			return &sample, err
+		}
+		if p.peek() != RP {
+			return &sample, p.errorExpected(p.pos, p.tok, "right paren")
+		}
+		sample.RParen, _, _ = p.scan()
+	} else {
+		sample.SampleKw, _, _ = p.scan()
+		if sample.N, err = p.ParseExpr(); err != nil {
+			return &sample, err
+		}
+		if p.peek() != ROWS {
+			return &sample, p.errorExpected(p.pos, p.tok, "ROWS")
+		}
+		sample.Rows, _, _ = p.scan()
+	}
+
+	// Parse optional seed.
+	if p.peek() == REPEATABLE {
+		sample.Repeatable, _, _ = p.scan()
+		if p.peek() != LP {
+			return &sample, p.errorExpected(p.pos, p.tok, "left paren")
+		}
+		sample.SeedLParen, _, _ = p.scan()
+		if sample.Seed, err = p.ParseExpr(); err != nil {
+			return &sample, err
+		}
+		if p.peek() != RP {
+			return &sample, p.errorExpected(p.pos, p.tok, "right paren")
+		}
+		sample.SeedRParen, _, _ = p.scan()
+	}
+	return &sample, nil
+}
+
 func (p *Parser) parseTableQueryOption() (_ *TableQueryOption, err error) {
 	var opt TableQueryOption
 	opt.OptionParams = make([]*Ident, 0)
@@ -667,8 +667,8 @@ func TestParser_ParseStatement(t *testing.T) {
 				NamePos: pos(17),
 			},
 		})
-		AssertParseStatementError(t, `SHOW`, `1:4: expected DATABASES, TABLES, COLUMNS or CREATE, found 'EOF'`)
-		AssertParseStatementError(t, `SHOW BLAH`, `1:6: expected DATABASES, TABLES, COLUMNS or CREATE, found BLAH`)
+		AssertParseStatementError(t, `SHOW`, `1:4: expected DATABASES, TABLES, COLUMNS, CREATE or GRANTS, found 'EOF'`)
+		AssertParseStatementError(t, `SHOW BLAH`, `1:6: expected DATABASES, TABLES, COLUMNS, CREATE or GRANTS, found BLAH`)
 		AssertParseStatementError(t, `SHOW TABLES WITH`, `1:16: expected show tables option, found 'EOF'`)
 	})
 
@@ -682,7 +682,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				NamePos: pos(18),
 			},
 		})
-		AssertParseStatementError(t, `SHOW`, `1:4: expected DATABASES, TABLES, COLUMNS or CREATE, found 'EOF'`)
+		AssertParseStatementError(t, `SHOW`, `1:4: expected DATABASES, TABLES, COLUMNS, CREATE or GRANTS, found 'EOF'`)
 		AssertParseStatementError(t, `SHOW COLUMNS`, `1:12: expected FROM, found 'EOF'`)
 		AssertParseStatementError(t, `SHOW COLUMNS FOO`, `1:14: expected FROM, found FOO`)
 		AssertParseStatementError(t, `SHOW COLUMNS FROM`, `1:17: expected table name, found 'EOF'`)
@@ -699,7 +699,7 @@ func TestParser_ParseStatement(t *testing.T) {
 				NamePos: pos(18),
 			},
 		})
-		AssertParseStatementError(t, `SHOW`, `1:4: expected DATABASES, TABLES, COLUMNS or CREATE, found 'EOF'`)
+		AssertParseStatementError(t, `SHOW`, `1:4: expected DATABASES, TABLES, COLUMNS, CREATE or GRANTS, found 'EOF'`)
 		AssertParseStatementError(t, `SHOW CREATE`, `1:11: expected TABLES, found 'EOF'`)
 		AssertParseStatementError(t, `SHOW CREATE TABLE`, `1:17: expected table name, found 'EOF'`)
 		AssertParseStatementError(t, `SHOW CREATE TABLE 12`, `1:19: expected table name, found 12`)
@@ -962,7 +962,7 @@ func TestParser_ParseStatement(t *testing.T) {
 			},
 		})
 
-		AssertParseStatementError(t, `CREATE`, `1:1: expected DATABASE, TABLE, VIEW, FUNCTION or MODEL`)
+		AssertParseStatementError(t, `CREATE`, `1:1: expected DATABASE, TABLE, VIEW, FUNCTION, MODEL or ROLE`)
 		AssertParseStatementError(t, `CREATE DATABASE`, `1:15: expected database name, found 'EOF'`)
 		AssertParseStatementError(t, `CREATE DATABASE IF`, `1:18: expected NOT, found 'EOF'`)
 		AssertParseStatementError(t, `CREATE DATABASE IF NOT`, `1:22: expected EXISTS, found 'EOF'`)
@@ -1777,12 +1777,106 @@ func TestParser_ParseStatement(t *testing.T) {
 			IfExists: pos(13),
 			Name:     &parser.Ident{NamePos: pos(20), Name: "vw"},
 		})
-		AssertParseStatementError(t, `DROP`, `1:1: expected DATABASE, TABLE, VIEW or FUNCTION`)
+		AssertParseStatementError(t, `DROP`, `1:1: expected DATABASE, TABLE, VIEW, FUNCTION, MODEL or ROLE`)
 		AssertParseStatementError(t, `DROP VIEW`, `1:9: expected view name, found 'EOF'`)
 		AssertParseStatementError(t, `DROP VIEW IF`, `1:12: expected EXISTS, found 'EOF'`)
 		AssertParseStatementError(t, `DROP VIEW IF EXISTS`, `1:19: expected view name, found 'EOF'`)
 	})
 
+	t.Run("CreateRole", func(t *testing.T) {
+		AssertParseStatement(t, `CREATE ROLE analysts`, &parser.CreateRoleStatement{
+			Create: pos(0),
+			Role:   pos(7),
+			Name:   &parser.Ident{NamePos: pos(12), Name: "analysts"},
+		})
+		AssertParseStatement(t, `CREATE ROLE IF NOT EXISTS analysts`, &parser.CreateRoleStatement{
+			Create:      pos(0),
+			Role:        pos(7),
+			If:          pos(12),
+			IfNot:       pos(15),
+			IfNotExists: pos(19),
+			Name:        &parser.Ident{NamePos: pos(26), Name: "analysts"},
+		})
+		AssertParseStatementError(t, `CREATE ROLE`, `1:11: expected role name, found 'EOF'`)
+		AssertParseStatementError(t, `CREATE ROLE IF`, `1:14: expected NOT, found 'EOF'`)
+		AssertParseStatementError(t, `CREATE ROLE IF NOT`, `1:18: expected EXISTS, found 'EOF'`)
+	})
+
+	t.Run("DropRole", func(t *testing.T) {
+		AssertParseStatement(t, `DROP ROLE analysts`, &parser.DropRoleStatement{
+			Drop: pos(0),
+			Role: pos(5),
+			Name: &parser.Ident{NamePos: pos(10), Name: "analysts"},
+		})
+		AssertParseStatement(t, `DROP ROLE IF EXISTS analysts`, &parser.DropRoleStatement{
+			Drop:     pos(0),
+			Role:     pos(5),
+			If:       pos(10),
+			IfExists: pos(13),
+			Name:     &parser.Ident{NamePos: pos(20), Name: "analysts"},
+		})
+		AssertParseStatementError(t, `DROP ROLE`, `1:9: expected role name, found 'EOF'`)
+		AssertParseStatementError(t, `DROP ROLE IF`, `1:12: expected EXISTS, found 'EOF'`)
+	})
+
+	t.Run("Grant", func(t *testing.T) {
+		AssertParseStatement(t, `GRANT SELECT ON t TO analysts`, &parser.GrantStatement{
+			Grant:      pos(0),
+			Privileges: []parser.Token{parser.SELECT},
+			On:         pos(13),
+			Table:      &parser.Ident{NamePos: pos(16), Name: "t"},
+			To:         pos(18),
+			Role:       &parser.Ident{NamePos: pos(21), Name: "analysts"},
+		})
+		AssertParseStatement(t, `GRANT INSERT, UPDATE ON t TO analysts`, &parser.GrantStatement{
+			Grant:      pos(0),
+			Privileges: []parser.Token{parser.INSERT, parser.UPDATE},
+			On:         pos(21),
+			Table:      &parser.Ident{NamePos: pos(24), Name: "t"},
+			To:         pos(26),
+			Role:       &parser.Ident{NamePos: pos(29), Name: "analysts"},
+		})
+		AssertParseStatement(t, `GRANT ALL PRIVILEGES ON t TO analysts`, &parser.GrantStatement{
+			Grant:      pos(0),
+			Privileges: []parser.Token{parser.ALL},
+			On:         pos(21),
+			Table:      &parser.Ident{NamePos: pos(24), Name: "t"},
+			To:         pos(26),
+			Role:       &parser.Ident{NamePos: pos(29), Name: "analysts"},
+		})
+		AssertParseStatementError(t, `GRANT`, `1:5: expected SELECT, INSERT, UPDATE, DELETE or ALL, found 'EOF'`)
+		AssertParseStatementError(t, `GRANT SELECT,`, `1:13: expected SELECT, INSERT, UPDATE, DELETE or ALL, found 'EOF'`)
+		AssertParseStatementError(t, `GRANT SELECT`, `1:12: expected ON, found 'EOF'`)
+		AssertParseStatementError(t, `GRANT SELECT ON t`, `1:17: expected TO, found 'EOF'`)
+		AssertParseStatementError(t, `GRANT SELECT ON t TO`, `1:20: expected role name, found 'EOF'`)
+	})
+
+	t.Run("Revoke", func(t *testing.T) {
+		AssertParseStatement(t, `REVOKE SELECT, DELETE ON t FROM analysts`, &parser.RevokeStatement{
+			Revoke:     pos(0),
+			Privileges: []parser.Token{parser.SELECT, parser.DELETE},
+			On:         pos(22),
+			Table:      &parser.Ident{NamePos: pos(25), Name: "t"},
+			From:       pos(27),
+			Role:       &parser.Ident{NamePos: pos(32), Name: "analysts"},
+		})
+		AssertParseStatementError(t, `REVOKE SELECT ON t TO analysts`, `1:20: expected FROM, found 'TO'`)
+	})
+
+	t.Run("ShowGrants", func(t *testing.T) {
+		AssertParseStatement(t, `SHOW GRANTS`, &parser.ShowGrantsStatement{
+			Show:   pos(0),
+			Grants: pos(5),
+		})
+		AssertParseStatement(t, `SHOW GRANTS FOR analysts`, &parser.ShowGrantsStatement{
+			Show:   pos(0),
+			Grants: pos(5),
+			For:    pos(12),
+			Role:   &parser.Ident{NamePos: pos(16), Name: "analysts"},
+		})
+		AssertParseStatementError(t, `SHOW GRANTS FOR`, `1:15: expected role name, found 'EOF'`)
+	})
+
 	/*t.Run("CreateIndex", func(t *testing.T) {
 		AssertParseStatement(t, `CREATE INDEX idx ON tbl (x ASC, y DESC, z)`, &parser.CreateIndexStatement{
 			Create: pos(0),
@@ -1942,6 +2036,59 @@ func TestParser_ParseStatement(t *testing.T) {
 				Alias: &parser.Ident{NamePos: pos(21), Name: "tbl2"},
 			},
 		})
+		AssertParseStatement(t, `SELECT * FROM tbl TABLESAMPLE (10)`, &parser.SelectStatement{
+			Select: pos(0),
+			Columns: []*parser.ResultColumn{
+				{Star: pos(7)},
+			},
+			From: pos(9),
+			Source: &parser.QualifiedTableName{
+				Name: &parser.Ident{NamePos: pos(14), Name: "tbl"},
+				Sample: &parser.TableSample{
+					Tablesample: pos(18),
+					LParen:      pos(30),
+					Percent:     &parser.IntegerLit{ValuePos: pos(31), Value: "10"},
+					RParen:      pos(33),
+				},
+			},
+		})
+		AssertParseStatement(t, `SELECT * FROM tbl t TABLESAMPLE BERNOULLI (2.5) REPEATABLE (42)`, &parser.SelectStatement{
+			Select: pos(0),
+			Columns: []*parser.ResultColumn{
+				{Star: pos(7)},
+			},
+			From: pos(9),
+			Source: &parser.QualifiedTableName{
+				Name:  &parser.Ident{NamePos: pos(14), Name: "tbl"},
+				Alias: &parser.Ident{NamePos: pos(18), Name: "t"},
+				Sample: &parser.TableSample{
+					Tablesample: pos(20),
+					Method:      &parser.Ident{NamePos: pos(32), Name: "BERNOULLI"},
+					LParen:      pos(42),
+					Percent:     &parser.FloatLit{ValuePos: pos(43), Value: "2.5"},
+					RParen:      pos(46),
+					Repeatable:  pos(48),
+					SeedLParen:  pos(59),
+					Seed:        &parser.IntegerLit{ValuePos: pos(60), Value: "42"},
+					SeedRParen:  pos(62),
+				},
+			},
+		})
+		AssertParseStatement(t, `SELECT * FROM tbl SAMPLE 100 ROWS`, &parser.SelectStatement{
+			Select: pos(0),
+			Columns: []*parser.ResultColumn{
+				{Star: pos(7)},
+			},
+			From: pos(9),
+			Source: &parser.QualifiedTableName{
+				Name: &parser.Ident{NamePos: pos(14), Name: "tbl"},
+				Sample: &parser.TableSample{
+					SampleKw: pos(18),
+					N:        &parser.IntegerLit{ValuePos: pos(25), Value: "100"},
+					Rows:     pos(29),
+				},
+			},
+		})
 		/*AssertParseStatement(t, `SELECT * FROM tbl INDEXED BY idx`, &parser.SelectStatement{
 			Select: pos(0),
 			Columns: []*sql.ResultColumn{
@@ -3483,6 +3630,11 @@ func TestParser_ParseStatement(t *testing.T) {
 		AssertParseStatementError(t, `SELECT * FROM foo FULL`, `1:22: expected JOIN, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * FROM foo FULL OUTER`, `1:28: expected JOIN, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * FROM foo,`, `1:18: expected table name or left paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo TABLESAMPLE`, `1:29: expected left paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo TABLESAMPLE RANDOM (10)`, `1:31: expected BERNOULLI or SYSTEM, found RANDOM`)
+		AssertParseStatementError(t, `SELECT * FROM foo TABLESAMPLE (10`, `1:33: expected right paren, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo SAMPLE 10`, `1:27: expected ROWS, found 'EOF'`)
+		AssertParseStatementError(t, `SELECT * FROM foo SAMPLE 10 ROWS REPEATABLE`, `1:43: expected left paren, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * FROM foo JOIN bar ON`, `1:29: expected expression, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * FROM foo JOIN bar USING`, `1:32: expected left paren, found 'EOF'`)
 		AssertParseStatementError(t, `SELECT * FROM foo JOIN bar USING (`, `1:34: expected column name, found 'EOF'`)
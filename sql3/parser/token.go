@@ -141,6 +141,8 @@ const (
 	FULL
 	FUNCTION
 	GLOB
+	GRANT
+	GRANTS
 	GROUP
 	GROUPS
 	HAVING
@@ -196,6 +198,7 @@ const (
 	PRECEDING
 	PREDICT
 	PRIMARY
+	PRIVILEGES
 	QUERY
 	RANGE
 	RANKED
@@ -206,14 +209,18 @@ const (
 	REINDEX
 	RELEASE
 	RENAME
+	REPEATABLE
 	REPLACE
 	RESTRICT
 	RETURNS
 	RETURN
+	REVOKE
 	RIGHT
+	ROLE
 	ROLLBACK
 	ROW
 	ROWS
+	SAMPLE
 	SAVEPOINT
 	SELECT
 	SELECT_COLUMN
@@ -223,6 +230,7 @@ const (
 	SPAN
 	TABLE
 	TABLES
+	TABLESAMPLE
 	TEMP
 	THEN
 	TIES
@@ -368,6 +376,8 @@ var tokens = [...]string{
 	FULL:              "FULL",
 	FUNCTION:          "FUNCTION",
 	GLOB:              "GLOB",
+	GRANT:             "GRANT",
+	GRANTS:            "GRANTS",
 	GROUP:             "GROUP",
 	GROUPS:            "GROUPS",
 	HAVING:            "HAVING",
@@ -423,6 +433,7 @@ var tokens = [...]string{
 	PRECEDING:         "PRECEDING",
 	PREDICT:           "PREDICT",
 	PRIMARY:           "PRIMARY",
+	PRIVILEGES:        "PRIVILEGES",
 	QUERY:             "QUERY",
 	RANGE:             "RANGE",
 	RANKED:            "RANKED",
@@ -433,14 +444,18 @@ var tokens = [...]string{
 	REINDEX:           "REINDEX",
 	RELEASE:           "RELEASE",
 	RENAME:            "RENAME",
+	REPEATABLE:        "REPEATABLE",
 	REPLACE:           "REPLACE",
 	RESTRICT:          "RESTRICT",
 	RETURNS:           "RETURNS",
 	RETURN:            "RETURN",
+	REVOKE:            "REVOKE",
 	RIGHT:             "RIGHT",
+	ROLE:              "ROLE",
 	ROLLBACK:          "ROLLBACK",
 	ROW:               "ROW",
 	ROWS:              "ROWS",
+	SAMPLE:            "SAMPLE",
 	SAVEPOINT:         "SAVEPOINT",
 	SELECT:            "SELECT",
 	SELECT_COLUMN:     "SELECT_COLUMN",
@@ -450,6 +465,7 @@ var tokens = [...]string{
 	SPAN:              "SPAN",
 	TABLE:             "TABLE",
 	TABLES:            "TABLES",
+	TABLESAMPLE:       "TABLESAMPLE",
 	TEMP:              "TEMP",
 	THEN:              "THEN",
 	TIES:              "TIES",
@@ -138,6 +138,32 @@ func walk(v Visitor, node Node) (_ Node, err error) {
 			return node, err
 		}
 
+	case *CreateRoleStatement:
+		if err := walkIdent(v, &n.Name); err != nil {
+			return node, err
+		}
+
+	case *DropRoleStatement:
+		if err := walkIdent(v, &n.Name); err != nil {
+			return node, err
+		}
+
+	case *GrantStatement:
+		if err := walkIdent(v, &n.Table); err != nil {
+			return node, err
+		}
+		if err := walkIdent(v, &n.Role); err != nil {
+			return node, err
+		}
+
+	case *RevokeStatement:
+		if err := walkIdent(v, &n.Table); err != nil {
+			return node, err
+		}
+		if err := walkIdent(v, &n.Role); err != nil {
+			return node, err
+		}
+
 	case *DropIndexStatement:
 		if err := walkIdent(v, &n.Name); err != nil {
 			return node, err
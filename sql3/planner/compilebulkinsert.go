@@ -60,15 +60,22 @@ func (p *ExecutionPlanner) compileBulkInsertStatement(ctx context.Context, stmt
 	}
 	options.input = sliteral.Value
 
+	// Reading a file on the server or fetching a URL from it does I/O on the
+	// user's behalf, so bulk inserts, whatever their input, are limited to
+	// admins, as they were before non-admins could use SQL.
 	switch strings.ToUpper(options.input) {
 	case "FILE":
+		if err := checkAdmin(ctx, tableName); err != nil {
+			return nil, err
+		}
 		// file should exist
 		if _, err := os.Stat(options.sourceData); goerrors.Is(err, os.ErrNotExist) {
 			return nil, sql3.NewErrReadingDatasource(stmt.DataSource.Pos().Line, stmt.DataSource.Pos().Column, options.sourceData, fmt.Sprintf("file '%s' does not exist", options.sourceData))
 		}
 	case "URL", "STREAM":
-		// nothing to do here
-		break
+		if err := checkAdmin(ctx, tableName); err != nil {
+			return nil, err
+		}
 	default:
 		return nil, sql3.NewErrInvalidInputSpecifier(stmt.Input.Pos().Line, stmt.Input.Pos().Column, options.input)
 	}
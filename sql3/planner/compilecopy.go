@@ -12,7 +12,7 @@ import (
 )
 
 // compileCopyStatement compiles a parser.CopyStatement AST into a PlanOperator
-func (p *ExecutionPlanner) compileCopyStatement(stmt *parser.CopyStatement) (types.PlanOperator, error) {
+func (p *ExecutionPlanner) compileCopyStatement(ctx context.Context, stmt *parser.CopyStatement) (types.PlanOperator, error) {
 	query := NewPlanOpQuery(p, NewPlanOpNullTable(), p.sql)
 	query.AddWarning("🦖 here there be dragons! COPY statement is experimental.")
 
@@ -59,6 +59,11 @@ func (p *ExecutionPlanner) compileCopyStatement(stmt *parser.CopyStatement) (typ
 			return nil, sql3.NewErrStringLiteral(stmt.Url.Pos().Line, stmt.Url.Pos().Column)
 		}
 		url = lit.Value
+		// Copying to another server sends data from this one anywhere the
+		// user asks, so only admins may do it.
+		if err := checkAdmin(ctx, stmt.TargetName.Name); err != nil {
+			return nil, err
+		}
 	}
 
 	if stmt.ApiKey != nil {
@@ -71,7 +76,7 @@ func (p *ExecutionPlanner) compileCopyStatement(stmt *parser.CopyStatement) (typ
 
 	// get the source table
 	tname := dax.TableName(stmt.Source.String())
-	tbl, err := p.schemaAPI.TableByName(context.Background(), tname)
+	tbl, err := p.schemaAPI.TableByName(ctx, tname)
 	if err != nil {
 		if isTableNotFoundError(err) {
 			return nil, sql3.NewErrTableNotFound(0, 0, stmt.Source.String())
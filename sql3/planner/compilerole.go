@@ -0,0 +1,107 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"strings"
+
+	"github.com/featurebasedb/featurebase/v3/authz"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// compileCreateRoleStatement compiles a CREATE ROLE statement into a
+// PlanOperator. Role names aren't lowercased, since a role applies to the
+// users in the group with the same ID.
+func (p *ExecutionPlanner) compileCreateRoleStatement(stmt *parser.CreateRoleStatement) (types.PlanOperator, error) {
+	roleName, err := grantRoleName(stmt.Name)
+	if err != nil {
+		return nil, err
+	}
+	return NewPlanOpQuery(p, NewPlanOpCreateRole(p, stmt.IfNotExists.IsValid(), roleName), p.sql), nil
+}
+
+// compileDropRoleStatement compiles a DROP ROLE statement into a PlanOperator.
+func (p *ExecutionPlanner) compileDropRoleStatement(stmt *parser.DropRoleStatement) (types.PlanOperator, error) {
+	roleName := parser.IdentName(stmt.Name)
+	return NewPlanOpQuery(p, NewPlanOpDropRole(p, stmt.IfExists.IsValid(), roleName), p.sql), nil
+}
+
+// compileGrantStatement compiles a GRANT statement into a PlanOperator.
+func (p *ExecutionPlanner) compileGrantStatement(ctx context.Context, stmt *parser.GrantStatement) (types.PlanOperator, error) {
+	tableName, err := p.grantTableName(ctx, stmt.Table)
+	if err != nil {
+		return nil, err
+	}
+	roleName, err := grantRoleName(stmt.Role)
+	if err != nil {
+		return nil, err
+	}
+	grant := &grantSystemObject{
+		role:       roleName,
+		table:      tableName,
+		permission: privilegesPermission(stmt.Privileges),
+	}
+	return NewPlanOpQuery(p, NewPlanOpGrant(p, grant), p.sql), nil
+}
+
+// compileRevokeStatement compiles a REVOKE statement into a PlanOperator.
+func (p *ExecutionPlanner) compileRevokeStatement(ctx context.Context, stmt *parser.RevokeStatement) (types.PlanOperator, error) {
+	roleName, err := grantRoleName(stmt.Role)
+	if err != nil {
+		return nil, err
+	}
+	tableName, err := p.grantTableName(ctx, stmt.Table)
+	if err != nil {
+		return nil, err
+	}
+	// revoking SELECT (or everything) revokes all access to the table, while
+	// revoking only the privileges to change it leaves read access
+	revokeRead := false
+	for _, priv := range stmt.Privileges {
+		if priv == parser.SELECT || priv == parser.ALL {
+			revokeRead = true
+		}
+	}
+	return NewPlanOpQuery(p, NewPlanOpRevoke(p, roleName, tableName, revokeRead), p.sql), nil
+}
+
+// grantRoleName returns the name of the role privileges are granted to or
+// revoked from. Role names can't contain ':', since it separates the role
+// from the table in the keys of grants.
+func grantRoleName(role *parser.Ident) (string, error) {
+	roleName := parser.IdentName(role)
+	if strings.Contains(roleName, ":") {
+		return "", sql3.NewErrInvalidRoleName(role.NamePos.Line, role.NamePos.Column, roleName)
+	}
+	return roleName, nil
+}
+
+// grantTableName returns the name of the table privileges are granted on or
+// revoked from, which must exist.
+func (p *ExecutionPlanner) grantTableName(ctx context.Context, table *parser.Ident) (string, error) {
+	tableName := strings.ToLower(parser.IdentName(table))
+	if _, err := p.schemaAPI.TableByName(ctx, dax.TableName(tableName)); err != nil {
+		if isTableNotFoundError(err) {
+			return "", sql3.NewErrTableNotFound(table.NamePos.Line, table.NamePos.Column, tableName)
+		}
+		return "", err
+	}
+	return tableName, nil
+}
+
+// privilegesPermission returns the permission granted by privileges: read
+// for SELECT, and write for INSERT, UPDATE, DELETE and ALL, as permissions
+// don't distinguish between the ways a table is changed.
+func privilegesPermission(privileges []parser.Token) authz.Permission {
+	perm := authz.Read
+	for _, priv := range privileges {
+		if priv != parser.SELECT {
+			perm = authz.Write
+		}
+	}
+	return perm
+}
@@ -4,10 +4,12 @@ package planner
 
 import (
 	"context"
+	"strconv"
 	"strings"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
 	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/pql"
 	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
@@ -449,12 +451,21 @@ func (p *ExecutionPlanner) compileSource(scope *PlanOpQuery, source parser.Sourc
 			extractColumns = append(extractColumns, oc.ColumnName)
 		}
 
+		scan := NewPlanOpPQLTableScan(p, tableName, extractColumns, queryHints)
+		if sourceExpr.Sample != nil {
+			sample, err := compileTableSample(sourceExpr.Sample)
+			if err != nil {
+				return nil, err
+			}
+			scan.sample = sample
+		}
+
 		if sourceExpr.Alias != nil {
 			aliasName := parser.IdentName(sourceExpr.Alias)
 
-			return NewPlanOpRelAlias(aliasName, NewPlanOpPQLTableScan(p, tableName, extractColumns, queryHints)), nil
+			return NewPlanOpRelAlias(aliasName, scan), nil
 		}
-		return NewPlanOpPQLTableScan(p, tableName, extractColumns, queryHints), nil
+		return scan, nil
 
 	case *parser.TableValuedFunction:
 		callExpr, err := p.compileCallExpr(sourceExpr.Call)
@@ -622,10 +633,17 @@ func (p *ExecutionPlanner) analyzeSource(ctx context.Context, source parser.Sour
 			}
 		}
 
+		// check the sampling clause
+		if source.Sample != nil {
+			if err := p.analyzeTableSample(ctx, source.Sample, scope); err != nil {
+				return nil, err
+			}
+		}
+
 		return source, nil
 
 	case *parser.TableValuedFunction:
-		return nil, sql3.NewErrUnsupported(0, 0, true, "table valued function")
+		return p.analyzeTableValuedFunction(ctx, source)
 
 	case *parser.SelectStatement:
 		expr, err := p.analyzeSelectStatement(ctx, source)
@@ -643,6 +661,73 @@ func (p *ExecutionPlanner) analyzeSource(ctx context.Context, source parser.Sour
 	}
 }
 
+// analyzeTableSample checks the sampling clause of a table is made of
+// literals in range.
+func (p *ExecutionPlanner) analyzeTableSample(ctx context.Context, sample *parser.TableSample, scope parser.Statement) error {
+	if sample.Percent != nil {
+		expr, err := p.analyzeExpression(ctx, sample.Percent, scope)
+		if err != nil {
+			return err
+		}
+		switch expr.(type) {
+		case *parser.IntegerLit, *parser.FloatLit:
+		default:
+			return sql3.NewErrIntOrDecimalExpressionExpected(sample.Percent.Pos().Line, sample.Percent.Pos().Column)
+		}
+		percent, err := strconv.ParseFloat(expr.String(), 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return sql3.NewErrValueOutOfRange(sample.Percent.Pos().Line, sample.Percent.Pos().Column, expr.String())
+		}
+		sample.Percent = expr
+	}
+
+	for _, lit := range []*parser.Expr{&sample.N, &sample.Seed} {
+		if *lit == nil {
+			continue
+		}
+		expr, err := p.analyzeExpression(ctx, *lit, scope)
+		if err != nil {
+			return err
+		}
+		if _, ok := expr.(*parser.IntegerLit); !ok {
+			return sql3.NewErrIntegerLiteral((*lit).Pos().Line, (*lit).Pos().Column)
+		}
+		if _, err := strconv.ParseInt(expr.String(), 10, 64); err != nil {
+			return sql3.NewErrValueOutOfRange((*lit).Pos().Line, (*lit).Pos().Column, expr.String())
+		}
+		*lit = expr
+	}
+	return nil
+}
+
+// compileTableSample compiles the analyzed sampling clause of a table.
+func compileTableSample(sample *parser.TableSample) (*tableSample, error) {
+	var result tableSample
+	if sample.Percent != nil {
+		fraction, err := pql.ParseDecimal(sample.Percent.String())
+		if err != nil {
+			return nil, sql3.NewErrInternalf("unexpected sample percentage '%s'", sample.Percent.String())
+		}
+		// a percentage is a fraction with two more decimal places
+		fraction.Scale += 2
+		result.fraction = &fraction
+	} else {
+		n, err := strconv.ParseInt(sample.N.String(), 10, 64)
+		if err != nil {
+			return nil, sql3.NewErrInternalf("unexpected sample size '%s'", sample.N.String())
+		}
+		result.n = n
+	}
+	if sample.Seed != nil {
+		seed, err := strconv.ParseInt(sample.Seed.String(), 10, 64)
+		if err != nil {
+			return nil, sql3.NewErrInternalf("unexpected sample seed '%s'", sample.Seed.String())
+		}
+		result.seed = &seed
+	}
+	return &result, nil
+}
+
 func (p *ExecutionPlanner) analyzeSelectStatement(ctx context.Context, stmt *parser.SelectStatement) (parser.Expr, error) {
 	// analyze source first - needed for name resolution
 	source, err := p.analyzeSource(ctx, stmt.Source, stmt)
@@ -817,6 +902,18 @@ func (p *ExecutionPlanner) columnsFromSource(source parser.Source) ([]*parser.Re
 		}
 		return result, nil
 
+	case *parser.TableValuedFunction:
+		for _, oc := range src.PossibleOutputColumns() {
+			result = append(result, &parser.ResultColumn{
+				Expr: &parser.QualifiedRef{
+					Table:       &parser.Ident{Name: oc.TableName},
+					Column:      &parser.Ident{Name: oc.ColumnName},
+					ColumnIndex: oc.ColumnIndex,
+				},
+			})
+		}
+		return result, nil
+
 	case *parser.SelectStatement:
 		for _, oc := range src.PossibleOutputColumns() {
 			result = append(result, &parser.ResultColumn{
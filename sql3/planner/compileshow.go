@@ -4,6 +4,7 @@ package planner
 
 import (
 	"context"
+	"sort"
 	"strings"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
@@ -281,3 +282,49 @@ func (p *ExecutionPlanner) compileShowCreateTableStatement(ctx context.Context,
 
 	return NewPlanOpQuery(p, NewPlanOpProjection(projections, filter), p.sql), nil
 }
+
+func (p *ExecutionPlanner) compileShowGrantsStatement(ctx context.Context, stmt *parser.ShowGrantsStatement) (types.PlanOperator, error) {
+	// without a role, the grants to every role are shown
+	roleName := ""
+	if stmt.Role != nil {
+		roleName = parser.IdentName(stmt.Role)
+		exists, err := p.roleExists(ctx, roleName)
+		if err != nil {
+			return nil, err
+		} else if !exists {
+			return nil, sql3.NewErrRoleNotFound(stmt.Role.NamePos.Line, stmt.Role.NamePos.Column, roleName)
+		}
+	}
+	grants, err := p.getGrants(ctx, roleName)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting grants")
+	}
+	sort.Slice(grants, func(i, j int) bool {
+		if grants[i].role != grants[j].role {
+			return grants[i].role < grants[j].role
+		}
+		return grants[i].table < grants[j].table
+	})
+
+	columns := []types.PlanExpression{
+		&qualifiedRefPlanExpression{
+			tableName:   "fb_grants",
+			columnName:  "role_name",
+			columnIndex: 0,
+			dataType:    parser.NewDataTypeString(),
+		},
+		&qualifiedRefPlanExpression{
+			tableName:   "fb_grants",
+			columnName:  "table_name",
+			columnIndex: 1,
+			dataType:    parser.NewDataTypeString(),
+		},
+		&qualifiedRefPlanExpression{
+			tableName:   "fb_grants",
+			columnName:  "permission",
+			columnIndex: 2,
+			dataType:    parser.NewDataTypeString(),
+		}}
+
+	return NewPlanOpQuery(p, NewPlanOpProjection(columns, NewPlanOpFeatureBaseGrants(grants)), p.sql), nil
+}
@@ -11,6 +11,8 @@ import (
 	"strconv"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/audit"
+	"github.com/featurebasedb/featurebase/v3/authz"
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/errors"
 	"github.com/featurebasedb/featurebase/v3/logger"
@@ -37,12 +39,18 @@ type ExecutionPlanner struct {
 	importer       pilosa.Importer
 	logger         logger.Logger
 	sql            string
+
+	// grantsChanged, if set, is called after grants have been changed.
+	grantsChanged func(ctx context.Context)
+
+	// auditLogger records changes to roles and grants.
+	auditLogger *audit.Logger
 }
 
 func NewExecutionPlanner(executor pilosa.Executor, schemaAPI pilosa.SchemaAPI, systemAPI pilosa.SystemAPI, systemLayerAPI pilosa.SystemLayerAPI, importer pilosa.Importer, logger logger.Logger, sql string) *ExecutionPlanner {
 	return &ExecutionPlanner{
 		executor:       executor,
-		schemaAPI:      newSystemTableDefinitionsWrapper(schemaAPI),
+		schemaAPI:      newAuthzSchemaWrapper(newSystemTableDefinitionsWrapper(schemaAPI)),
 		systemAPI:      systemAPI,
 		systemLayerAPI: systemLayerAPI,
 		importer:       importer,
@@ -51,6 +59,37 @@ func NewExecutionPlanner(executor pilosa.Executor, schemaAPI pilosa.SchemaAPI, s
 	}
 }
 
+// WithGrantsChanged sets a function to be called after GRANT, REVOKE and DROP
+// ROLE statements have changed the grants stored in the grants system table,
+// so that they can be reloaded, for example with SHOW GRANTS.
+func (p *ExecutionPlanner) WithGrantsChanged(fn func(ctx context.Context)) *ExecutionPlanner {
+	p.grantsChanged = fn
+	return p
+}
+
+// WithAuditLogger sets the audit log which changes to roles and grants are
+// recorded in.
+func (p *ExecutionPlanner) WithAuditLogger(l *audit.Logger) *ExecutionPlanner {
+	p.auditLogger = l
+	return p
+}
+
+// audit records an event in the audit log. types.ErrNoMoreRows is how
+// statements which return no rows finish, so it's recorded as a success.
+func (p *ExecutionPlanner) audit(ctx context.Context, typ audit.EventType, object, detail string, err error) {
+	if err == types.ErrNoMoreRows {
+		err = nil
+	}
+	p.auditLogger.Log(ctx, typ, object, detail, err)
+}
+
+// notifyGrantsChanged calls the function set with WithGrantsChanged, if any.
+func (p *ExecutionPlanner) notifyGrantsChanged(ctx context.Context) {
+	if p.grantsChanged != nil {
+		p.grantsChanged(ctx)
+	}
+}
+
 // CompilePlan takes an AST (parser.Statement) and compiles into a query plan returning the root
 // PlanOperator
 // The act of compiling includes an analysis step that does semantic analysis of the AST, this includes
@@ -63,6 +102,38 @@ func (p *ExecutionPlanner) CompilePlan(ctx context.Context, stmt parser.Statemen
 		return nil, err
 	}
 
+	// check access for creating and altering objects here; dropping them is
+	// checked when the drop is executed
+	switch stmt := stmt.(type) {
+	case *parser.CreateDatabaseStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeCreateObject)
+	case *parser.CreateTableStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeCreateObject)
+	case *parser.CreateViewStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeCreateObject)
+	case *parser.CreateModelStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeCreateObject)
+	case *parser.CreateFunctionStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeCreateObject)
+	case *parser.AlterDatabaseStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeAlterObject)
+	case *parser.AlterTableStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeAlterObject)
+	case *parser.AlterViewStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeAlterObject)
+	case *parser.CreateRoleStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Name), accessTypeCreateObject)
+	case *parser.GrantStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Table), accessTypeAlterObject)
+	case *parser.RevokeStatement:
+		err = p.checkAccess(ctx, parser.IdentName(stmt.Table), accessTypeAlterObject)
+	case *parser.ShowGrantsStatement:
+		err = checkAdmin(ctx, "fb_grants")
+	}
+	if err != nil {
+		return nil, err
+	}
+
 	var rootOperator types.PlanOperator
 	switch stmt := stmt.(type) {
 	case *parser.SelectStatement:
@@ -70,7 +141,7 @@ func (p *ExecutionPlanner) CompilePlan(ctx context.Context, stmt parser.Statemen
 	case *parser.ShowDatabasesStatement:
 		rootOperator, err = p.compileShowDatabasesStatement(ctx, stmt)
 	case *parser.CopyStatement:
-		rootOperator, err = p.compileCopyStatement(stmt)
+		rootOperator, err = p.compileCopyStatement(ctx, stmt)
 	case *parser.PredictStatement:
 		rootOperator, err = p.compilePredictStatement(ctx, stmt)
 	case *parser.ShowTablesStatement:
@@ -79,6 +150,8 @@ func (p *ExecutionPlanner) CompilePlan(ctx context.Context, stmt parser.Statemen
 		rootOperator, err = p.compileShowColumnsStatement(ctx, stmt)
 	case *parser.ShowCreateTableStatement:
 		rootOperator, err = p.compileShowCreateTableStatement(ctx, stmt)
+	case *parser.ShowGrantsStatement:
+		rootOperator, err = p.compileShowGrantsStatement(ctx, stmt)
 	case *parser.CreateDatabaseStatement:
 		rootOperator, err = p.compileCreateDatabaseStatement(stmt)
 	case *parser.CreateTableStatement:
@@ -99,6 +172,14 @@ func (p *ExecutionPlanner) CompilePlan(ctx context.Context, stmt parser.Statemen
 		rootOperator, err = p.compileDropViewStatement(ctx, stmt)
 	case *parser.DropModelStatement:
 		rootOperator, err = p.compileDropModelStatement(stmt)
+	case *parser.CreateRoleStatement:
+		rootOperator, err = p.compileCreateRoleStatement(stmt)
+	case *parser.DropRoleStatement:
+		rootOperator, err = p.compileDropRoleStatement(stmt)
+	case *parser.GrantStatement:
+		rootOperator, err = p.compileGrantStatement(ctx, stmt)
+	case *parser.RevokeStatement:
+		rootOperator, err = p.compileRevokeStatement(ctx, stmt)
 	case *parser.InsertStatement:
 		rootOperator, err = p.compileInsertStatement(ctx, stmt)
 	case *parser.BulkInsertStatement:
@@ -151,6 +232,8 @@ func (p *ExecutionPlanner) analyzePlan(ctx context.Context, stmt parser.Statemen
 		return nil
 	case *parser.ShowCreateTableStatement:
 		return nil
+	case *parser.ShowGrantsStatement:
+		return nil
 	case *parser.CreateDatabaseStatement:
 		return p.analyzeCreateDatabaseStatement(stmt)
 	case *parser.CreateTableStatement:
@@ -171,6 +254,14 @@ func (p *ExecutionPlanner) analyzePlan(ctx context.Context, stmt parser.Statemen
 		return nil
 	case *parser.DropModelStatement:
 		return nil
+	case *parser.CreateRoleStatement:
+		return nil
+	case *parser.DropRoleStatement:
+		return nil
+	case *parser.GrantStatement:
+		return nil
+	case *parser.RevokeStatement:
+		return nil
 	case *parser.InsertStatement:
 		return p.analyzeInsertStatement(ctx, stmt)
 	case *parser.BulkInsertStatement:
@@ -197,7 +288,31 @@ const (
 	accessTypeDropObject
 )
 
-func (p *ExecutionPlanner) checkAccess(ctx context.Context, objectName string, _ accessType) error {
+// permission returns the permission required for the access type.
+func (a accessType) permission() authz.Permission {
+	switch a {
+	case accessTypeReadData:
+		return authz.Read
+	case accessTypeWriteData:
+		return authz.Write
+	default:
+		return authz.Admin
+	}
+}
+
+// checkAccess returns an error if the user whose access is carried by ctx, if
+// any, doesn't have the permission to objectName required by typ. Reading and
+// writing data require read and write permission to the table; creating,
+// altering and dropping objects require admin.
+func (p *ExecutionPlanner) checkAccess(ctx context.Context, objectName string, typ accessType) error {
+	access := authz.AccessFromContext(ctx)
+	if access == nil {
+		return nil
+	}
+	perm := typ.permission()
+	if perm == authz.Admin && !access.IsAdmin() || !access.Satisfies(objectName, perm) {
+		return sql3.NewErrInsufficientPermissions(objectName, string(perm))
+	}
 	return nil
 }
 
@@ -0,0 +1,176 @@
+// Copyright 2022 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/authz"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/pkg/errors"
+)
+
+// Ensure type implements interface.
+var _ pilosa.SchemaAPI = (*authzSchemaWrapper)(nil)
+
+// authzSchemaWrapper limits the schema to what the user whose access is
+// carried by the context may see: tables (including system tables) they
+// don't have read permission for don't exist, and the fields their
+// restrictions don't allow are removed from those which do. Since the planner
+// resolves every table through its schema, this covers the expansion of
+// `SELECT *` as well as SHOW TABLES and SHOW COLUMNS. Changing the schema
+// requires admin.
+type authzSchemaWrapper struct {
+	schemaAPI pilosa.SchemaAPI
+}
+
+func newAuthzSchemaWrapper(api pilosa.SchemaAPI) *authzSchemaWrapper {
+	return &authzSchemaWrapper{
+		schemaAPI: api,
+	}
+}
+
+func (s *authzSchemaWrapper) CreateDatabase(ctx context.Context, db *dax.Database) error {
+	if err := checkAdmin(ctx, string(db.Name)); err != nil {
+		return err
+	}
+	return s.schemaAPI.CreateDatabase(ctx, db)
+}
+func (s *authzSchemaWrapper) DropDatabase(ctx context.Context, dbid dax.DatabaseID) error {
+	if err := checkAdmin(ctx, string(dbid)); err != nil {
+		return err
+	}
+	return s.schemaAPI.DropDatabase(ctx, dbid)
+}
+
+func (s *authzSchemaWrapper) DatabaseByName(ctx context.Context, dbname dax.DatabaseName) (*dax.Database, error) {
+	return s.schemaAPI.DatabaseByName(ctx, dbname)
+}
+func (s *authzSchemaWrapper) DatabaseByID(ctx context.Context, dbid dax.DatabaseID) (*dax.Database, error) {
+	return s.schemaAPI.DatabaseByID(ctx, dbid)
+}
+func (s *authzSchemaWrapper) SetDatabaseOption(ctx context.Context, dbid dax.DatabaseID, option string, value string) error {
+	if err := checkAdmin(ctx, string(dbid)); err != nil {
+		return err
+	}
+	return s.schemaAPI.SetDatabaseOption(ctx, dbid, option, value)
+}
+func (s *authzSchemaWrapper) Databases(ctx context.Context, dbids ...dax.DatabaseID) ([]*dax.Database, error) {
+	return s.schemaAPI.Databases(ctx, dbids...)
+}
+
+func (s *authzSchemaWrapper) TableByName(ctx context.Context, tname dax.TableName) (*dax.Table, error) {
+	tbl, err := s.schemaAPI.TableByName(ctx, tname)
+	if err != nil {
+		return nil, err
+	}
+	tbl, err = restrictTable(ctx, tbl)
+	if err != nil {
+		return nil, err
+	} else if tbl == nil {
+		return nil, dax.NewErrTableNameDoesNotExist(tname)
+	}
+	return tbl, nil
+}
+
+func (s *authzSchemaWrapper) TableByID(ctx context.Context, tid dax.TableID) (*dax.Table, error) {
+	tbl, err := s.schemaAPI.TableByID(ctx, tid)
+	if err != nil {
+		return nil, err
+	}
+	tbl, err = restrictTable(ctx, tbl)
+	if err != nil {
+		return nil, err
+	} else if tbl == nil {
+		return nil, dax.NewErrTableIDDoesNotExist(dax.QualifiedTableID{ID: tid})
+	}
+	return tbl, nil
+}
+
+func (s *authzSchemaWrapper) Tables(ctx context.Context) ([]*dax.Table, error) {
+	tbls, err := s.schemaAPI.Tables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if authz.AccessFromContext(ctx) == nil {
+		return tbls, nil
+	}
+
+	restricted := make([]*dax.Table, 0, len(tbls))
+	for _, tbl := range tbls {
+		tbl, err := restrictTable(ctx, tbl)
+		if err != nil {
+			return nil, err
+		} else if tbl != nil {
+			restricted = append(restricted, tbl)
+		}
+	}
+	return restricted, nil
+}
+
+func (s *authzSchemaWrapper) CreateTable(ctx context.Context, tbl *dax.Table) error {
+	if err := checkAdmin(ctx, string(tbl.Name)); err != nil {
+		return err
+	}
+	return s.schemaAPI.CreateTable(ctx, tbl)
+}
+
+func (s *authzSchemaWrapper) CreateField(ctx context.Context, tname dax.TableName, fld *dax.Field) error {
+	if err := checkAdmin(ctx, string(tname)); err != nil {
+		return err
+	}
+	return s.schemaAPI.CreateField(ctx, tname, fld)
+}
+
+func (s *authzSchemaWrapper) DeleteTable(ctx context.Context, tname dax.TableName) error {
+	if err := checkAdmin(ctx, string(tname)); err != nil {
+		return err
+	}
+	return s.schemaAPI.DeleteTable(ctx, tname)
+}
+
+func (s *authzSchemaWrapper) DeleteField(ctx context.Context, tname dax.TableName, fname dax.FieldName) error {
+	if err := checkAdmin(ctx, string(tname)); err != nil {
+		return err
+	}
+	return s.schemaAPI.DeleteField(ctx, tname, fname)
+}
+
+// checkAdmin returns an error if the user whose access is carried by ctx, if
+// any, isn't an admin.
+func checkAdmin(ctx context.Context, objectName string) error {
+	if access := authz.AccessFromContext(ctx); access != nil && !access.IsAdmin() {
+		return sql3.NewErrInsufficientPermissions(objectName, string(authz.Admin))
+	}
+	return nil
+}
+
+// restrictTable returns tbl as seen by the user whose access is carried by
+// ctx, if any: nil if they may not read it, or a copy without the fields
+// their restrictions don't allow.
+func restrictTable(ctx context.Context, tbl *dax.Table) (*dax.Table, error) {
+	access := authz.AccessFromContext(ctx)
+	if access == nil {
+		return tbl, nil
+	}
+	if !access.Satisfies(string(tbl.Name), authz.Read) {
+		return nil, nil
+	}
+	r, err := access.Restrictions(string(tbl.Name))
+	if err != nil {
+		return nil, errors.Wrap(err, "getting restrictions")
+	} else if r == nil {
+		return tbl, nil
+	}
+
+	restricted := *tbl
+	restricted.Fields = make([]*dax.Field, 0, len(tbl.Fields))
+	for _, fld := range tbl.Fields {
+		if fld.IsPrimaryKey() || r.FieldAllowed(string(fld.Name)) {
+			restricted.Fields = append(restricted.Fields, fld)
+		}
+	}
+	return &restricted, nil
+}
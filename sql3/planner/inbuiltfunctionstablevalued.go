@@ -0,0 +1,405 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/dax"
+	"github.com/featurebasedb/featurebase/v3/pql"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// analyzeTableValuedFunction checks the call of a table valued function, and
+// populates the output columns of the source from its result type.
+func (p *ExecutionPlanner) analyzeTableValuedFunction(ctx context.Context, source *parser.TableValuedFunction) (parser.Source, error) {
+	call := source.Call
+
+	var err error
+	switch strings.ToUpper(call.Name.Name) {
+	case "FUNNEL":
+		err = p.analyzeFunctionFunnel(ctx, call)
+	case "RETENTION":
+		err = p.analyzeFunctionRetention(ctx, call)
+	default:
+		return nil, sql3.NewErrCallUnknownFunction(call.Name.NamePos.Line, call.Name.NamePos.Column, call.Name.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resultType, ok := call.ResultDataType.(*parser.DataTypeSubtable)
+	if !ok {
+		return nil, sql3.NewErrInternalf("unexpected table valued function result type '%T'", call.ResultDataType)
+	}
+	source.OutputColumns = source.OutputColumns[:0]
+	for i, col := range resultType.Columns {
+		source.OutputColumns = append(source.OutputColumns, &parser.SourceOutputColumn{
+			TableName:   source.TableName(),
+			ColumnName:  col.Name,
+			ColumnIndex: i,
+			Datatype:    col.DataType,
+		})
+	}
+	return source, nil
+}
+
+// analyzeTableArg checks that the argument of a table valued function at
+// position i names a table, and returns a scope in which the table's columns
+// can be referenced by the function's other arguments. The argument is
+// replaced with a string literal of the table's name.
+func (p *ExecutionPlanner) analyzeTableArg(ctx context.Context, call *parser.Call, i int) (*parser.SelectStatement, error) {
+	var name string
+	switch arg := call.Args[i].(type) {
+	case *parser.Ident:
+		name = strings.ToLower(arg.Name)
+	case *parser.StringLit:
+		name = strings.ToLower(arg.Value)
+	default:
+		return nil, sql3.NewErrExpectedTableReference(call.Args[i].Pos().Line, call.Args[i].Pos().Column)
+	}
+
+	tbl, err := p.schemaAPI.TableByName(ctx, dax.TableName(name))
+	if err != nil {
+		if isTableNotFoundError(err) {
+			return nil, sql3.NewErrTableOrViewNotFound(call.Args[i].Pos().Line, call.Args[i].Pos().Column, name)
+		}
+		return nil, err
+	}
+
+	source := &parser.QualifiedTableName{
+		Name: &parser.Ident{Name: name, NamePos: call.Args[i].Pos()},
+	}
+	for i, fld := range tbl.Fields {
+		source.OutputColumns = append(source.OutputColumns, &parser.SourceOutputColumn{
+			TableName:   name,
+			ColumnName:  string(fld.Name),
+			ColumnIndex: i,
+			Datatype:    fieldSQLDataType(pilosa.FieldToFieldInfo(fld)),
+		})
+	}
+
+	call.Args[i] = &parser.StringLit{ValuePos: call.Args[i].Pos(), Value: name}
+	return &parser.SelectStatement{Source: source}, nil
+}
+
+// analyzeTimestampArg checks that the argument of a table valued function at
+// position i is a literal timestamp, either a string or a number of seconds
+// since the epoch.
+func (p *ExecutionPlanner) analyzeTimestampArg(ctx context.Context, call *parser.Call, i int, scope parser.Statement) error {
+	arg, err := p.analyzeExpression(ctx, call.Args[i], scope)
+	if err != nil {
+		return err
+	}
+	switch lit := arg.(type) {
+	case *parser.StringLit:
+		if ts := newStringLiteralPlanExpression(lit.Value).ConvertToTimestamp(); ts == nil {
+			return sql3.NewErrInvalidTypeCoercion(lit.ValuePos.Line, lit.ValuePos.Column, lit.Value, parser.NewDataTypeTimestamp().TypeDescription())
+		}
+	case *parser.IntegerLit:
+	default:
+		return sql3.NewErrLiteralExpected(arg.Pos().Line, arg.Pos().Column)
+	}
+	call.Args[i] = arg
+	return nil
+}
+
+// analyzeTimeQuantumRowArg checks that the argument of a table valued function
+// at position i is a setcontains() on a time quantum column, which selects the
+// records with a value in the column at some time.
+func (p *ExecutionPlanner) analyzeTimeQuantumRowArg(ctx context.Context, call *parser.Call, i int, scope parser.Statement, name string) error {
+	arg, err := p.analyzeExpression(ctx, call.Args[i], scope)
+	if err != nil {
+		return err
+	}
+	contains, ok := arg.(*parser.Call)
+	if !ok || !strings.EqualFold(contains.Name.Name, "SETCONTAINS") {
+		return sql3.NewErrCallParameterValueInvalid(arg.Pos().Line, arg.Pos().Column, arg.String(), name)
+	}
+	if ok, _ := typeIsTimeQuantum(contains.Args[0].DataType()); !ok {
+		return sql3.NewErrTimeQuantumExpressionExpected(contains.Args[0].Pos().Line, contains.Args[0].Pos().Column)
+	}
+	call.Args[i] = arg
+	return nil
+}
+
+// analyzeFunctionFunnel checks a call of funnel(table, from, to, window,
+// step, ...), where each step is a setcontains() on a time quantum column
+// and window is a duration such as '24h', or null.
+func (p *ExecutionPlanner) analyzeFunctionFunnel(ctx context.Context, call *parser.Call) error {
+	if len(call.Args) < 5 {
+		return sql3.NewErrCallParameterCountMismatch(call.Rparen.Line, call.Rparen.Column, call.Name.Name, 5, len(call.Args))
+	}
+
+	scope, err := p.analyzeTableArg(ctx, call, 0)
+	if err != nil {
+		return err
+	}
+
+	// from and to
+	for i := 1; i <= 2; i++ {
+		if err := p.analyzeTimestampArg(ctx, call, i, scope); err != nil {
+			return err
+		}
+	}
+
+	// window
+	window, err := p.analyzeExpression(ctx, call.Args[3], scope)
+	if err != nil {
+		return err
+	}
+	switch lit := window.(type) {
+	case *parser.StringLit:
+		if d, err := time.ParseDuration(lit.Value); err != nil || d <= 0 {
+			return sql3.NewErrCallParameterValueInvalid(lit.ValuePos.Line, lit.ValuePos.Column, lit.Value, "window")
+		}
+	case *parser.NullLit:
+	default:
+		return sql3.NewErrStringLiteral(window.Pos().Line, window.Pos().Column)
+	}
+	call.Args[3] = window
+
+	// steps
+	for i := 4; i < len(call.Args); i++ {
+		if err := p.analyzeTimeQuantumRowArg(ctx, call, i, scope, "step"); err != nil {
+			return err
+		}
+	}
+
+	call.ResultDataType = parser.NewDataTypeSubtable([]*parser.SubtableColumn{
+		{Name: "step", DataType: parser.NewDataTypeInt()},
+		{Name: "count", DataType: parser.NewDataTypeInt()},
+	})
+	return nil
+}
+
+// analyzeFunctionRetention checks a call of retention(table, from, period,
+// periods, cohort, activity), where period is one of 'hour', 'day', 'week',
+// 'month' or 'year', and cohort and activity are each a setcontains() on a
+// time quantum column.
+func (p *ExecutionPlanner) analyzeFunctionRetention(ctx context.Context, call *parser.Call) error {
+	if len(call.Args) != 6 {
+		return sql3.NewErrCallParameterCountMismatch(call.Rparen.Line, call.Rparen.Column, call.Name.Name, 6, len(call.Args))
+	}
+
+	scope, err := p.analyzeTableArg(ctx, call, 0)
+	if err != nil {
+		return err
+	}
+
+	// from
+	if err := p.analyzeTimestampArg(ctx, call, 1, scope); err != nil {
+		return err
+	}
+
+	// period
+	period, err := p.analyzeExpression(ctx, call.Args[2], scope)
+	if err != nil {
+		return err
+	}
+	lit, ok := period.(*parser.StringLit)
+	if !ok {
+		return sql3.NewErrStringLiteral(period.Pos().Line, period.Pos().Column)
+	}
+	switch strings.ToLower(lit.Value) {
+	case "hour", "day", "week", "month", "year":
+	default:
+		return sql3.NewErrCallParameterValueInvalid(lit.ValuePos.Line, lit.ValuePos.Column, lit.Value, "period")
+	}
+	call.Args[2] = period
+
+	// periods
+	periods, err := p.analyzeExpression(ctx, call.Args[3], scope)
+	if err != nil {
+		return err
+	}
+	n, ok := periods.(*parser.IntegerLit)
+	if !ok {
+		return sql3.NewErrIntegerLiteral(periods.Pos().Line, periods.Pos().Column)
+	}
+	if v, err := strconv.ParseInt(n.Value, 10, 64); err != nil || v <= 0 {
+		return sql3.NewErrCallParameterValueInvalid(n.ValuePos.Line, n.ValuePos.Column, n.Value, "periods")
+	}
+	call.Args[3] = periods
+
+	// cohort and activity
+	for i, name := range []string{"cohort", "activity"} {
+		if err := p.analyzeTimeQuantumRowArg(ctx, call, i+4, scope, name); err != nil {
+			return err
+		}
+	}
+
+	call.ResultDataType = parser.NewDataTypeSubtable([]*parser.SubtableColumn{
+		{Name: "cohort", DataType: parser.NewDataTypeTimestamp()},
+		{Name: "cohort_size", DataType: parser.NewDataTypeInt()},
+		{Name: "period", DataType: parser.NewDataTypeInt()},
+		{Name: "retained", DataType: parser.NewDataTypeInt()},
+	})
+	return nil
+}
+
+// timestampArgValue returns the value of a timestamp argument of a table valued
+// function, checked by analyzeTimestampArg, as a PQL time argument.
+func timestampArgValue(arg types.PlanExpression) (int64, error) {
+	switch arg := arg.(type) {
+	case *stringLiteralPlanExpression:
+		ts := arg.ConvertToTimestamp()
+		if ts == nil {
+			return 0, sql3.NewErrInvalidTypeCoercion(0, 0, arg.value, parser.NewDataTypeTimestamp().TypeDescription())
+		}
+		return ts.Unix(), nil
+	case *intLiteralPlanExpression:
+		return arg.value, nil
+	default:
+		return 0, sql3.NewErrInternalf("unexpected argument type '%T'", arg)
+	}
+}
+
+// executeTableValuedCall checks access to the table named by the first
+// argument of a table valued function, and executes a PQL call against it.
+func (p *ExecutionPlanner) executeTableValuedCall(ctx context.Context, tableArg types.PlanExpression, c *pql.Call) (interface{}, error) {
+	tableName, ok := tableArg.(*stringLiteralPlanExpression)
+	if !ok {
+		return nil, sql3.NewErrInternalf("unexpected table argument type '%T'", tableArg)
+	}
+	if err := p.checkAccess(ctx, tableName.value, accessTypeReadData); err != nil {
+		return nil, err
+	}
+	tbl, err := p.schemaAPI.TableByName(ctx, dax.TableName(tableName.value))
+	if err != nil {
+		return nil, sql3.NewErrTableNotFound(0, 0, tableName.value)
+	}
+	queryResponse, err := p.executor.Execute(ctx, tbl, &pql.Query{Calls: []*pql.Call{c}}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return queryResponse.Results[0], nil
+}
+
+// funnelRowIter executes a funnel() call as a Funnel() PQL call, returning a
+// row for each step.
+type funnelRowIter struct {
+	planner *ExecutionPlanner
+	call    *callPlanExpression
+
+	result pilosa.FunnelCounts
+	step   int
+}
+
+var _ types.RowIterator = (*funnelRowIter)(nil)
+
+func (i *funnelRowIter) Next(ctx context.Context) (types.Row, error) {
+	if i.result == nil {
+		funnel := &pql.Call{
+			Name: "Funnel",
+			Args: map[string]interface{}{},
+		}
+		for argIdx, name := range []string{"from", "to"} {
+			ts, err := timestampArgValue(i.call.args[argIdx+1])
+			if err != nil {
+				return nil, err
+			}
+			funnel.Args[name] = ts
+		}
+		if window, ok := i.call.args[3].(*stringLiteralPlanExpression); ok {
+			funnel.Args["window"] = window.value
+		}
+		for _, arg := range i.call.args[4:] {
+			step, err := i.planner.generatePQLCallFromExpr(ctx, arg)
+			if err != nil {
+				return nil, err
+			}
+			funnel.Children = append(funnel.Children, step)
+		}
+
+		res, err := i.planner.executeTableValuedCall(ctx, i.call.args[0], funnel)
+		if err != nil {
+			return nil, err
+		}
+		result, ok := res.(pilosa.FunnelCounts)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected result type '%T'", res)
+		}
+		i.result = result
+	}
+
+	if i.step >= len(i.result) {
+		return nil, types.ErrNoMoreRows
+	}
+	row := []interface{}{int64(i.step + 1), int64(i.result[i.step])}
+	i.step++
+	return row, nil
+}
+
+// retentionRowIter executes a retention() call as a Retention() PQL call,
+// returning a row for each cell of the retention matrix.
+type retentionRowIter struct {
+	planner *ExecutionPlanner
+	call    *callPlanExpression
+
+	result pilosa.RetentionMatrix
+	cohort int
+	period int
+}
+
+var _ types.RowIterator = (*retentionRowIter)(nil)
+
+func (i *retentionRowIter) Next(ctx context.Context) (types.Row, error) {
+	if i.result == nil {
+		from, err := timestampArgValue(i.call.args[1])
+		if err != nil {
+			return nil, err
+		}
+		period, ok := i.call.args[2].(*stringLiteralPlanExpression)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected argument type '%T'", i.call.args[2])
+		}
+		periods, ok := i.call.args[3].(*intLiteralPlanExpression)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected argument type '%T'", i.call.args[3])
+		}
+		retention := &pql.Call{
+			Name: "Retention",
+			Args: map[string]interface{}{
+				"from":    from,
+				"period":  strings.ToLower(period.value),
+				"periods": periods.value,
+			},
+		}
+		for argIdx, name := range []string{"cohort", "activity"} {
+			row, err := i.planner.generatePQLCallFromExpr(ctx, i.call.args[argIdx+4])
+			if err != nil {
+				return nil, err
+			}
+			retention.Args[name] = row
+		}
+
+		res, err := i.planner.executeTableValuedCall(ctx, i.call.args[0], retention)
+		if err != nil {
+			return nil, err
+		}
+		result, ok := res.(pilosa.RetentionMatrix)
+		if !ok {
+			return nil, sql3.NewErrInternalf("unexpected result type '%T'", res)
+		}
+		i.result = result
+	}
+
+	for i.cohort < len(i.result) && i.period >= len(i.result[i.cohort].Retained) {
+		i.cohort++
+		i.period = 0
+	}
+	if i.cohort >= len(i.result) {
+		return nil, types.ErrNoMoreRows
+	}
+	cohort := i.result[i.cohort]
+	row := []interface{}{cohort.Start, int64(cohort.Size), int64(i.period), int64(cohort.Retained[i.period])}
+	i.period++
+	return row, nil
+}
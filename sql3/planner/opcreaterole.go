@@ -0,0 +1,102 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/featurebasedb/featurebase/v3/audit"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// PlanOpCreateRole implements the CREATE ROLE operator
+type PlanOpCreateRole struct {
+	planner     *ExecutionPlanner
+	roleName    string
+	ifNotExists bool
+	warnings    []string
+}
+
+func NewPlanOpCreateRole(planner *ExecutionPlanner, ifNotExists bool, roleName string) *PlanOpCreateRole {
+	return &PlanOpCreateRole{
+		planner:     planner,
+		roleName:    roleName,
+		ifNotExists: ifNotExists,
+		warnings:    make([]string, 0),
+	}
+}
+
+func (p *PlanOpCreateRole) Schema() types.Schema {
+	return types.Schema{}
+}
+
+func (p *PlanOpCreateRole) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
+	return &createRoleRowIter{
+		planner:     p.planner,
+		roleName:    p.roleName,
+		ifNotExists: p.ifNotExists,
+	}, nil
+}
+
+func (p *PlanOpCreateRole) Children() []types.PlanOperator {
+	return []types.PlanOperator{}
+}
+
+func (p *PlanOpCreateRole) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
+	if len(children) != 0 {
+		return nil, sql3.NewErrInternalf("unexpected number of children '%d'", len(children))
+	}
+	return NewPlanOpCreateRole(p.planner, p.ifNotExists, p.roleName), nil
+}
+
+func (p *PlanOpCreateRole) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_op"] = fmt.Sprintf("%T", p)
+	result["_schema"] = p.Schema().Plan()
+	result["roleName"] = p.roleName
+	result["ifNotExists"] = p.ifNotExists
+	return result
+}
+
+func (p *PlanOpCreateRole) String() string {
+	return ""
+}
+
+func (p *PlanOpCreateRole) AddWarning(warning string) {
+	p.warnings = append(p.warnings, warning)
+}
+
+func (p *PlanOpCreateRole) Warnings() []string {
+	return p.warnings
+}
+
+type createRoleRowIter struct {
+	planner     *ExecutionPlanner
+	roleName    string
+	ifNotExists bool
+}
+
+var _ types.RowIterator = (*createRoleRowIter)(nil)
+
+func (i *createRoleRowIter) Next(ctx context.Context) (_ types.Row, err error) {
+	defer func() { i.planner.audit(ctx, audit.EventCreateRole, i.roleName, "", err) }()
+
+	exists, err := i.planner.roleExists(ctx, i.roleName)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		if i.ifNotExists {
+			return nil, types.ErrNoMoreRows
+		}
+		return nil, sql3.NewErrRoleExists(0, 0, i.roleName)
+	}
+
+	err = i.planner.insertRole(ctx, i.roleName)
+	if err != nil {
+		return nil, err
+	}
+	return nil, types.ErrNoMoreRows
+}
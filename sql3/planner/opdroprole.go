@@ -0,0 +1,105 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/featurebasedb/featurebase/v3/audit"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// PlanOpDropRole plan operator to drop a role, along with its grants.
+type PlanOpDropRole struct {
+	planner  *ExecutionPlanner
+	roleName string
+	ifExists bool
+	warnings []string
+}
+
+func NewPlanOpDropRole(p *ExecutionPlanner, ifExists bool, roleName string) *PlanOpDropRole {
+	return &PlanOpDropRole{
+		planner:  p,
+		roleName: roleName,
+		ifExists: ifExists,
+		warnings: make([]string, 0),
+	}
+}
+
+func (p *PlanOpDropRole) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_op"] = fmt.Sprintf("%T", p)
+	result["roleName"] = p.roleName
+	result["ifExists"] = p.ifExists
+	return result
+}
+
+func (p *PlanOpDropRole) String() string {
+	return ""
+}
+
+func (p *PlanOpDropRole) AddWarning(warning string) {
+	p.warnings = append(p.warnings, warning)
+}
+
+func (p *PlanOpDropRole) Warnings() []string {
+	return p.warnings
+}
+
+func (p *PlanOpDropRole) Schema() types.Schema {
+	return types.Schema{}
+}
+
+func (p *PlanOpDropRole) Children() []types.PlanOperator {
+	return []types.PlanOperator{}
+}
+
+func (p *PlanOpDropRole) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
+	return &dropRoleRowIter{
+		planner:  p.planner,
+		ifExists: p.ifExists,
+		roleName: p.roleName,
+	}, nil
+}
+
+func (p *PlanOpDropRole) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
+	return nil, nil
+}
+
+type dropRoleRowIter struct {
+	planner  *ExecutionPlanner
+	ifExists bool
+	roleName string
+}
+
+var _ types.RowIterator = (*dropRoleRowIter)(nil)
+
+func (i *dropRoleRowIter) Next(ctx context.Context) (_ types.Row, err error) {
+	defer func() { i.planner.audit(ctx, audit.EventDropRole, i.roleName, "", err) }()
+
+	err = i.planner.checkAccess(ctx, i.roleName, accessTypeDropObject)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := i.planner.roleExists(ctx, i.roleName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		if i.ifExists {
+			return nil, types.ErrNoMoreRows
+		}
+		return nil, sql3.NewErrRoleNotFound(0, 0, i.roleName)
+	}
+
+	err = i.planner.deleteRole(ctx, i.roleName)
+	if err != nil {
+		return nil, err
+	}
+	i.planner.notifyGrantsChanged(ctx)
+
+	return nil, types.ErrNoMoreRows
+}
@@ -0,0 +1,98 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/featurebasedb/featurebase/v3/sql3/parser"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// PlanOpFeatureBaseGrants wraps the grants read from the grants system table.
+type PlanOpFeatureBaseGrants struct {
+	grants   []*grantSystemObject
+	warnings []string
+}
+
+func NewPlanOpFeatureBaseGrants(grants []*grantSystemObject) *PlanOpFeatureBaseGrants {
+	return &PlanOpFeatureBaseGrants{
+		grants:   grants,
+		warnings: make([]string, 0),
+	}
+}
+
+func (p *PlanOpFeatureBaseGrants) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_op"] = fmt.Sprintf("%T", p)
+	result["_schema"] = p.Schema().Plan()
+	return result
+}
+
+func (p *PlanOpFeatureBaseGrants) String() string {
+	return ""
+}
+
+func (p *PlanOpFeatureBaseGrants) AddWarning(warning string) {
+	p.warnings = append(p.warnings, warning)
+}
+
+func (p *PlanOpFeatureBaseGrants) Warnings() []string {
+	return p.warnings
+}
+
+func (p *PlanOpFeatureBaseGrants) Schema() types.Schema {
+	return types.Schema{
+		&types.PlannerColumn{
+			RelationName: "fb_grants",
+			ColumnName:   "role_name",
+			Type:         parser.NewDataTypeString(),
+		},
+		&types.PlannerColumn{
+			RelationName: "fb_grants",
+			ColumnName:   "table_name",
+			Type:         parser.NewDataTypeString(),
+		},
+		&types.PlannerColumn{
+			RelationName: "fb_grants",
+			ColumnName:   "permission",
+			Type:         parser.NewDataTypeString(),
+		},
+	}
+}
+
+func (p *PlanOpFeatureBaseGrants) Children() []types.PlanOperator {
+	return []types.PlanOperator{}
+}
+
+func (p *PlanOpFeatureBaseGrants) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
+	return &showGrantsRowIter{
+		grants: p.grants,
+	}, nil
+}
+
+func (p *PlanOpFeatureBaseGrants) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
+	return NewPlanOpFeatureBaseGrants(p.grants), nil
+}
+
+type showGrantsRowIter struct {
+	grants   []*grantSystemObject
+	rowIndex int
+}
+
+var _ types.RowIterator = (*showGrantsRowIter)(nil)
+
+func (i *showGrantsRowIter) Next(ctx context.Context) (types.Row, error) {
+	if i.rowIndex < len(i.grants) {
+		grant := i.grants[i.rowIndex]
+		row := []interface{}{
+			grant.role,
+			grant.table,
+			string(grant.permission),
+		}
+		i.rowIndex += 1
+		return row, nil
+	}
+	return nil, types.ErrNoMoreRows
+}
@@ -0,0 +1,228 @@
+// Copyright 2023 Molecula Corp. All rights reserved.
+
+package planner
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/featurebasedb/featurebase/v3/audit"
+	"github.com/featurebasedb/featurebase/v3/authz"
+	"github.com/featurebasedb/featurebase/v3/sql3"
+	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
+)
+
+// PlanOpGrant implements the GRANT operator
+type PlanOpGrant struct {
+	planner  *ExecutionPlanner
+	grant    *grantSystemObject
+	warnings []string
+}
+
+func NewPlanOpGrant(planner *ExecutionPlanner, grant *grantSystemObject) *PlanOpGrant {
+	return &PlanOpGrant{
+		planner:  planner,
+		grant:    grant,
+		warnings: make([]string, 0),
+	}
+}
+
+func (p *PlanOpGrant) Schema() types.Schema {
+	return types.Schema{}
+}
+
+func (p *PlanOpGrant) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
+	return &grantRowIter{
+		planner: p.planner,
+		grant:   p.grant,
+	}, nil
+}
+
+func (p *PlanOpGrant) Children() []types.PlanOperator {
+	return []types.PlanOperator{}
+}
+
+func (p *PlanOpGrant) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
+	if len(children) != 0 {
+		return nil, sql3.NewErrInternalf("unexpected number of children '%d'", len(children))
+	}
+	return NewPlanOpGrant(p.planner, p.grant), nil
+}
+
+func (p *PlanOpGrant) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_op"] = fmt.Sprintf("%T", p)
+	result["_schema"] = p.Schema().Plan()
+	result["role"] = p.grant.role
+	result["table"] = p.grant.table
+	result["permission"] = string(p.grant.permission)
+	return result
+}
+
+func (p *PlanOpGrant) String() string {
+	return ""
+}
+
+func (p *PlanOpGrant) AddWarning(warning string) {
+	p.warnings = append(p.warnings, warning)
+}
+
+func (p *PlanOpGrant) Warnings() []string {
+	return p.warnings
+}
+
+type grantRowIter struct {
+	planner *ExecutionPlanner
+	grant   *grantSystemObject
+}
+
+var _ types.RowIterator = (*grantRowIter)(nil)
+
+func (i *grantRowIter) Next(ctx context.Context) (_ types.Row, err error) {
+	defer func() {
+		i.planner.audit(ctx, audit.EventGrant, i.grant.role, fmt.Sprintf("%s on %s", i.grant.permission, i.grant.table), err)
+	}()
+
+	exists, err := i.planner.roleExists(ctx, i.grant.role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql3.NewErrRoleNotFound(0, 0, i.grant.role)
+	}
+
+	current, err := i.planner.getGrant(ctx, i.grant.role, i.grant.table)
+	if err != nil {
+		return nil, err
+	}
+	// granting privileges never takes away those already granted
+	if current != nil && current.permission.Satisfies(i.grant.permission) {
+		return nil, types.ErrNoMoreRows
+	}
+
+	err = i.planner.upsertGrant(ctx, i.grant)
+	if err != nil {
+		return nil, err
+	}
+	i.planner.notifyGrantsChanged(ctx)
+
+	return nil, types.ErrNoMoreRows
+}
+
+// PlanOpRevoke implements the REVOKE operator
+type PlanOpRevoke struct {
+	planner    *ExecutionPlanner
+	role       string
+	table      string
+	revokeRead bool
+	warnings   []string
+}
+
+func NewPlanOpRevoke(planner *ExecutionPlanner, role, table string, revokeRead bool) *PlanOpRevoke {
+	return &PlanOpRevoke{
+		planner:    planner,
+		role:       role,
+		table:      table,
+		revokeRead: revokeRead,
+		warnings:   make([]string, 0),
+	}
+}
+
+func (p *PlanOpRevoke) Schema() types.Schema {
+	return types.Schema{}
+}
+
+func (p *PlanOpRevoke) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
+	return &revokeRowIter{
+		planner:    p.planner,
+		role:       p.role,
+		table:      p.table,
+		revokeRead: p.revokeRead,
+	}, nil
+}
+
+func (p *PlanOpRevoke) Children() []types.PlanOperator {
+	return []types.PlanOperator{}
+}
+
+func (p *PlanOpRevoke) WithChildren(children ...types.PlanOperator) (types.PlanOperator, error) {
+	if len(children) != 0 {
+		return nil, sql3.NewErrInternalf("unexpected number of children '%d'", len(children))
+	}
+	return NewPlanOpRevoke(p.planner, p.role, p.table, p.revokeRead), nil
+}
+
+func (p *PlanOpRevoke) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	result["_op"] = fmt.Sprintf("%T", p)
+	result["_schema"] = p.Schema().Plan()
+	result["role"] = p.role
+	result["table"] = p.table
+	result["revokeRead"] = p.revokeRead
+	return result
+}
+
+func (p *PlanOpRevoke) String() string {
+	return ""
+}
+
+func (p *PlanOpRevoke) AddWarning(warning string) {
+	p.warnings = append(p.warnings, warning)
+}
+
+func (p *PlanOpRevoke) Warnings() []string {
+	return p.warnings
+}
+
+type revokeRowIter struct {
+	planner    *ExecutionPlanner
+	role       string
+	table      string
+	revokeRead bool
+}
+
+var _ types.RowIterator = (*revokeRowIter)(nil)
+
+func (i *revokeRowIter) Next(ctx context.Context) (_ types.Row, err error) {
+	defer func() {
+		permission := authz.Write
+		if i.revokeRead {
+			permission = authz.Read
+		}
+		i.planner.audit(ctx, audit.EventRevoke, i.role, fmt.Sprintf("%s on %s", permission, i.table), err)
+	}()
+
+	exists, err := i.planner.roleExists(ctx, i.role)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, sql3.NewErrRoleNotFound(0, 0, i.role)
+	}
+
+	current, err := i.planner.getGrant(ctx, i.role, i.table)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case current == nil:
+		// nothing to revoke
+		return nil, types.ErrNoMoreRows
+	case i.revokeRead:
+		err = i.planner.deleteGrant(ctx, i.role, i.table)
+	case current.permission == authz.Write:
+		err = i.planner.upsertGrant(ctx, &grantSystemObject{
+			role:       i.role,
+			table:      i.table,
+			permission: authz.Read,
+		})
+	default:
+		return nil, types.ErrNoMoreRows
+	}
+	if err != nil {
+		return nil, err
+	}
+	i.planner.notifyGrantsChanged(ctx)
+
+	return nil, types.ErrNoMoreRows
+}
@@ -133,6 +133,11 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 	// IndexInfo used in the import (and created below) will be based on the
 	// information from idxInfoBase, but the fields may be a limited subset, and
 	// may be in a different order.
+	err := i.planner.checkAccess(ctx, i.tableName, accessTypeWriteData)
+	if err != nil {
+		return nil, err
+	}
+
 	tname := dax.TableName(i.tableName)
 	tbl, err := i.planner.schemaAPI.TableByName(ctx, tname)
 	if err != nil {
@@ -164,6 +169,7 @@ func (i *insertRowIter) Next(ctx context.Context) (types.Row, error) {
 
 	batch, err := fbbatch.NewBatch(i.planner.importer, batchSize, tbl, idxInfo.Fields,
 		fbbatch.OptUseShardTransactionalEndpoint(true),
+		fbbatch.OptContext(ctx),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "setting up batch")
@@ -29,6 +29,7 @@ type PlanOpPQLTableScan struct {
 	timeQuantumFilters []types.PlanExpression
 	topExpr            types.PlanExpression
 	hints              []*TableQueryHint
+	sample             *tableSample
 	warnings           []string
 }
 
@@ -55,6 +56,9 @@ func (p *PlanOpPQLTableScan) Plan() map[string]interface{} {
 	if p.filter != nil {
 		result["filter"] = p.filter.Plan()
 	}
+	if p.sample != nil {
+		result["sample"] = p.sample.Plan()
+	}
 	tqfilters := make([]map[string]interface{}, len(p.timeQuantumFilters))
 	for i, f := range p.timeQuantumFilters {
 		tqfilters[i] = f.Plan()
@@ -130,6 +134,7 @@ func (p *PlanOpPQLTableScan) Iterator(ctx context.Context, row types.Row) (types
 		predicate:          p.filter,
 		timeQuantumFilters: p.timeQuantumFilters,
 		topExpr:            p.topExpr,
+		sample:             p.sample,
 	}, nil
 }
 
@@ -150,6 +155,45 @@ func (p *PlanOpPQLTableScan) PrimaryKeyType() (parser.ExprDataType, error) {
 	return parser.NewDataTypeID(), nil
 }
 
+// tableSample is the sampling clause of a table scan.
+type tableSample struct {
+	n        int64        // number of rows to sample, if fraction is nil
+	fraction *pql.Decimal // fraction of rows to sample
+	seed     *int64       // optional seed, for a repeatable sample
+}
+
+func (s *tableSample) Plan() map[string]interface{} {
+	result := make(map[string]interface{})
+	if s.fraction != nil {
+		result["fraction"] = s.fraction.String()
+	} else {
+		result["n"] = s.n
+	}
+	if s.seed != nil {
+		result["seed"] = *s.seed
+	}
+	return result
+}
+
+// call returns a Sample() call sampling the records selected by child.
+func (s *tableSample) call(child *pql.Call) *pql.Call {
+	args := make(map[string]interface{})
+	if s.fraction != nil {
+		args["fraction"] = *s.fraction
+	} else {
+		args["n"] = s.n
+	}
+	if s.seed != nil {
+		args["seed"] = *s.seed
+	}
+	return &pql.Call{
+		Name:     "Sample",
+		Children: []*pql.Call{child},
+		Args:     args,
+		Type:     pql.PrecallGlobal,
+	}
+}
+
 type targetColumn struct {
 	columnIdx    int
 	srcColumnIdx int
@@ -164,6 +208,7 @@ type tableScanRowIter struct {
 	predicate          types.PlanExpression
 	timeQuantumFilters []types.PlanExpression
 	topExpr            types.PlanExpression
+	sample             *tableSample
 
 	result    []pilosa.ExtractedTableColumn
 	rowWidth  int
@@ -211,6 +256,16 @@ func (i *tableScanRowIter) Next(ctx context.Context) (types.Row, error) {
 		if err != nil {
 			return nil, err
 		}
+		// the sample is drawn from the whole table, and then filtered, as
+		// if the filter were applied to a table holding just the sample
+		if i.sample != nil {
+			sampled := i.sample.call(&pql.Call{Name: "All"})
+			if cond == nil {
+				cond = sampled
+			} else {
+				cond = &pql.Call{Name: "Intersect", Children: []*pql.Call{sampled, cond}}
+			}
+		}
 		if cond == nil {
 			cond = &pql.Call{Name: "All"}
 		}
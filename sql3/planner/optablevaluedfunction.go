@@ -5,13 +5,14 @@ package planner
 import (
 	"context"
 	"fmt"
+	"strings"
 
 	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
 	"github.com/featurebasedb/featurebase/v3/sql3/planner/types"
 )
 
-// PlanOpTableValuedFunction is an operator for a subquery
+// PlanOpTableValuedFunction is an operator for a table valued function
 type PlanOpTableValuedFunction struct {
 	planner  *ExecutionPlanner
 	callExpr types.PlanExpression
@@ -43,7 +44,24 @@ func (p *PlanOpTableValuedFunction) Schema() types.Schema {
 }
 
 func (p *PlanOpTableValuedFunction) Iterator(ctx context.Context, row types.Row) (types.RowIterator, error) {
-	return nil, sql3.NewErrInternalf("table valued functions are not yet implemented")
+	call, ok := p.callExpr.(*callPlanExpression)
+	if !ok {
+		return nil, sql3.NewErrInternalf("unexpected table valued function expression type '%T'", p.callExpr)
+	}
+	switch strings.ToUpper(call.name) {
+	case "FUNNEL":
+		return &funnelRowIter{
+			planner: p.planner,
+			call:    call,
+		}, nil
+	case "RETENTION":
+		return &retentionRowIter{
+			planner: p.planner,
+			call:    call,
+		}, nil
+	default:
+		return nil, sql3.NewErrInternalf("unhandled table valued function '%s'", call.name)
+	}
 }
 
 func (p *PlanOpTableValuedFunction) Children() []types.PlanOperator {
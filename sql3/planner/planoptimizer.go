@@ -313,7 +313,9 @@ func removeUnusedExtractColumnReferences(ctx context.Context, a *ExecutionPlanne
 			}
 
 			// newExtractList should now contain just the cols that are referenced
-			return NewPlanOpPQLTableScan(a, thisNode.tableName, newExtractList, thisNode.hints), false, nil
+			newOp := NewPlanOpPQLTableScan(a, thisNode.tableName, newExtractList, thisNode.hints)
+			newOp.sample = thisNode.sample
+			return newOp, false, nil
 
 		default:
 			return thisNode, true, nil
@@ -690,6 +692,11 @@ func tryToReplaceGroupByWithPQLAggregate(ctx context.Context, a *ExecutionPlanne
 					return thisNode, true, nil
 				}
 
+				// aggregates in pql can't be computed over a sample
+				if table.sample != nil {
+					return thisNode, true, nil
+				}
+
 				pkType, err := table.PrimaryKeyType()
 				if err != nil {
 					return thisNode, true, err
@@ -811,6 +818,11 @@ func tryToReplaceDistinctWithPQLDistinct(ctx context.Context, a *ExecutionPlanne
 				return thisNode, true, nil
 			}
 
+			// bail if the table is sampled
+			if thisNode.sample != nil {
+				return thisNode, true, nil
+			}
+
 			// make sure it's not the _id column
 			if strings.EqualFold(thisNode.columns[0], string(dax.PrimaryKeyFieldName)) {
 				return thisNode, true, nil
@@ -903,6 +915,11 @@ func tryToReplaceGroupByWithPQLGroupBy(ctx context.Context, a *ExecutionPlanner,
 			// get the table
 			table := tables[0]
 
+			// group by in pql can't be computed over a sample
+			if table.sample != nil {
+				return thisNode, true, nil
+			}
+
 			// if we are grouping on set columns, see if we have any flatten query hints
 			for _, gbc := range thisNode.GroupByExprs {
 				gbcRef, ok := gbc.(*qualifiedRefPlanExpression)
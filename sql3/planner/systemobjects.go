@@ -8,6 +8,7 @@ import (
 	"time"
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
+	"github.com/featurebasedb/featurebase/v3/authz"
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/sql3"
 	"github.com/featurebasedb/featurebase/v3/sql3/parser"
@@ -34,6 +35,9 @@ type modelSystemObject struct {
 }
 
 func (p *ExecutionPlanner) ensureViewsSystemTableExists(ctx context.Context) error {
+	// views are stored on behalf of the system rather than the user, so
+	// the user's access doesn't apply to the views system table
+	ctx = authz.WithAccess(ctx, nil)
 	_, err := p.schemaAPI.TableByName(ctx, "fb_views")
 	if err != nil {
 		if !isTableNotFoundError(err) {
@@ -123,6 +127,7 @@ func (p *ExecutionPlanner) ensureViewsSystemTableExists(ctx context.Context) err
 }
 
 func (p *ExecutionPlanner) getViewByName(ctx context.Context, name string) (*viewSystemObject, error) {
+	ctx = authz.WithAccess(ctx, nil)
 	err := p.ensureViewsSystemTableExists(ctx)
 	if err != nil {
 		return nil, err
@@ -167,6 +172,7 @@ func (p *ExecutionPlanner) getViewByName(ctx context.Context, name string) (*vie
 }
 
 func (p *ExecutionPlanner) insertView(ctx context.Context, view *viewSystemObject) error {
+	ctx = authz.WithAccess(ctx, nil)
 	err := p.ensureViewsSystemTableExists(ctx)
 	if err != nil {
 		return err
@@ -206,6 +212,7 @@ func (p *ExecutionPlanner) insertView(ctx context.Context, view *viewSystemObjec
 }
 
 func (p *ExecutionPlanner) updateView(ctx context.Context, view *viewSystemObject) error {
+	ctx = authz.WithAccess(ctx, nil)
 	err := p.ensureViewsSystemTableExists(ctx)
 	if err != nil {
 		return err
@@ -239,6 +246,7 @@ func (p *ExecutionPlanner) updateView(ctx context.Context, view *viewSystemObjec
 }
 
 func (p *ExecutionPlanner) deleteView(ctx context.Context, viewName string) error {
+	ctx = authz.WithAccess(ctx, nil)
 	err := p.ensureViewsSystemTableExists(ctx)
 	if err != nil {
 		return err
@@ -878,3 +886,350 @@ func (p *ExecutionPlanner) deleteModel(modelName string) error {
 
 	return nil
 }
+
+type grantSystemObject struct {
+	role       string
+	table      string
+	permission authz.Permission
+}
+
+// grantKey returns the key of the grant of permission on table to role in the
+// grants system table. It's unambiguous since role names can't contain ':'.
+func grantKey(role, table string) string {
+	return role + ":" + table
+}
+
+func (p *ExecutionPlanner) ensureRolesSystemTableExists(ctx context.Context) error {
+	// roles and grants are stored on behalf of the system rather than the
+	// user, so the user's access doesn't apply to their system tables
+	ctx = authz.WithAccess(ctx, nil)
+	_, err := p.schemaAPI.TableByName(ctx, "fb_roles")
+	if err != nil {
+		if !isTableNotFoundError(err) {
+			return err
+		}
+
+		//  create table fb_roles (
+		// 		_id string
+		//		created_at timestamp
+		//  );
+
+		// if it doesn't, create it by making the appropriate iterator
+		iter := &createTableRowIter{
+			planner:       p,
+			tableName:     "fb_roles",
+			failIfExists:  false,
+			isKeyed:       true,
+			keyPartitions: 0,
+			columns: []*createTableField{
+				{
+					planner:  p,
+					name:     "created_at",
+					typeName: dax.BaseTypeTimestamp,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeTimestamp(pilosa.DefaultEpoch, pilosa.TimeUnitSeconds),
+					},
+				},
+			},
+			description: "system table for roles",
+		}
+		// call next on our iterator to create the table
+		_, err := iter.Next(ctx)
+		if err != nil && err != types.ErrNoMoreRows {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ExecutionPlanner) ensureGrantsSystemTableExists(ctx context.Context) error {
+	ctx = authz.WithAccess(ctx, nil)
+	_, err := p.schemaAPI.TableByName(ctx, "fb_grants")
+	if err != nil {
+		if !isTableNotFoundError(err) {
+			return err
+		}
+
+		//  create table fb_grants (
+		// 		_id string
+		//		role_name string
+		//		table_name string
+		//		permission string
+		//		updated_at timestamp
+		//  );
+
+		// if it doesn't, create it by making the appropriate iterator
+		iter := &createTableRowIter{
+			planner:       p,
+			tableName:     "fb_grants",
+			failIfExists:  false,
+			isKeyed:       true,
+			keyPartitions: 0,
+			columns: []*createTableField{
+				{
+					planner:  p,
+					name:     "role_name",
+					typeName: dax.BaseTypeString,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeMutex(pilosa.DefaultCacheType, pilosa.DefaultCacheSize),
+						pilosa.OptFieldKeys(),
+					},
+				},
+				{
+					planner:  p,
+					name:     "table_name",
+					typeName: dax.BaseTypeString,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeMutex(pilosa.DefaultCacheType, pilosa.DefaultCacheSize),
+						pilosa.OptFieldKeys(),
+					},
+				},
+				{
+					planner:  p,
+					name:     "permission",
+					typeName: dax.BaseTypeString,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeMutex(pilosa.DefaultCacheType, pilosa.DefaultCacheSize),
+						pilosa.OptFieldKeys(),
+					},
+				},
+				{
+					planner:  p,
+					name:     "updated_at",
+					typeName: dax.BaseTypeTimestamp,
+					fos: []pilosa.FieldOption{
+						pilosa.OptFieldTypeTimestamp(pilosa.DefaultEpoch, pilosa.TimeUnitSeconds),
+					},
+				},
+			},
+			description: "system table for grants",
+		}
+		// call next on our iterator to create the table
+		_, err := iter.Next(ctx)
+		if err != nil && err != types.ErrNoMoreRows {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *ExecutionPlanner) roleExists(ctx context.Context, name string) (bool, error) {
+	ctx = authz.WithAccess(ctx, nil)
+	err := p.ensureRolesSystemTableExists(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	iter := &tableScanRowIter{
+		planner:   p,
+		tableName: "fb_roles",
+		columns:   []string{string(dax.PrimaryKeyFieldName)},
+		predicate: newBinOpPlanExpression(
+			newQualifiedRefPlanExpression("fb_roles", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+			parser.EQ,
+			newStringLiteralPlanExpression(name),
+			parser.NewDataTypeBool(),
+		),
+		topExpr: nil,
+	}
+
+	_, err = iter.Next(ctx)
+	if err != nil {
+		if err == types.ErrNoMoreRows {
+			// role does not exist
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+func (p *ExecutionPlanner) insertRole(ctx context.Context, name string) error {
+	ctx = authz.WithAccess(ctx, nil)
+	err := p.ensureRolesSystemTableExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	iter := &insertRowIter{
+		planner:   p,
+		tableName: "fb_roles",
+		targetColumns: []*qualifiedRefPlanExpression{
+			newQualifiedRefPlanExpression("fb_roles", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("fb_roles", "created_at", 0, parser.NewDataTypeTimestamp()),
+		},
+		insertValues: [][]types.PlanExpression{
+			{
+				newStringLiteralPlanExpression(name),
+				newTimestampLiteralPlanExpression(time.Now().UTC()),
+			},
+		},
+	}
+	_, err = iter.Next(ctx)
+	if err != nil && err != types.ErrNoMoreRows {
+		return err
+	}
+	return nil
+}
+
+// deleteRole deletes the role and every grant to it.
+func (p *ExecutionPlanner) deleteRole(ctx context.Context, name string) error {
+	ctx = authz.WithAccess(ctx, nil)
+	for _, ensure := range []func(context.Context) error{p.ensureRolesSystemTableExists, p.ensureGrantsSystemTableExists} {
+		if err := ensure(ctx); err != nil {
+			return err
+		}
+	}
+
+	iters := []*filteredDeleteRowIter{
+		{
+			planner:   p,
+			tableName: "fb_grants",
+			filter: newBinOpPlanExpression(
+				newQualifiedRefPlanExpression("fb_grants", "role_name", 0, parser.NewDataTypeString()),
+				parser.EQ,
+				newStringLiteralPlanExpression(name),
+				parser.NewDataTypeBool(),
+			),
+		},
+		{
+			planner:   p,
+			tableName: "fb_roles",
+			filter: newBinOpPlanExpression(
+				newQualifiedRefPlanExpression("fb_roles", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+				parser.EQ,
+				newStringLiteralPlanExpression(name),
+				parser.NewDataTypeBool(),
+			),
+		},
+	}
+	for _, iter := range iters {
+		_, err := iter.Next(ctx)
+		if err != nil && err != types.ErrNoMoreRows {
+			return err
+		}
+	}
+	return nil
+}
+
+// getGrants returns the grants to role, or to every role if role is empty.
+// Unlike the other grant methods, it doesn't create the grants system table
+// if it doesn't exist yet, as there are no grants, so that grants can be
+// read periodically without changing the schema.
+func (p *ExecutionPlanner) getGrants(ctx context.Context, role string) ([]*grantSystemObject, error) {
+	ctx = authz.WithAccess(ctx, nil)
+	if _, err := p.schemaAPI.TableByName(ctx, "fb_grants"); err != nil {
+		if isTableNotFoundError(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var predicate types.PlanExpression
+	if role != "" {
+		predicate = newBinOpPlanExpression(
+			newQualifiedRefPlanExpression("fb_grants", "role_name", 0, parser.NewDataTypeString()),
+			parser.EQ,
+			newStringLiteralPlanExpression(role),
+			parser.NewDataTypeBool(),
+		)
+	}
+
+	iter := &tableScanRowIter{
+		planner:   p,
+		tableName: "fb_grants",
+		columns:   []string{"role_name", "table_name", "permission"},
+		predicate: predicate,
+		topExpr:   nil,
+	}
+
+	var grants []*grantSystemObject
+	for {
+		row, err := iter.Next(ctx)
+		if err == types.ErrNoMoreRows {
+			return grants, nil
+		} else if err != nil {
+			return nil, err
+		}
+		grant := &grantSystemObject{}
+		grant.role, _ = row[0].(string)
+		grant.table, _ = row[1].(string)
+		perm, _ := row[2].(string)
+		grant.permission = authz.Permission(perm)
+		grants = append(grants, grant)
+	}
+}
+
+// getGrant returns the grant on table to role, or nil if there is none.
+func (p *ExecutionPlanner) getGrant(ctx context.Context, role, table string) (*grantSystemObject, error) {
+	grants, err := p.getGrants(ctx, role)
+	if err != nil {
+		return nil, err
+	}
+	for _, grant := range grants {
+		if grant.table == table {
+			return grant, nil
+		}
+	}
+	return nil, nil
+}
+
+// upsertGrant stores grant, replacing any grant on the same table to the same
+// role.
+func (p *ExecutionPlanner) upsertGrant(ctx context.Context, grant *grantSystemObject) error {
+	ctx = authz.WithAccess(ctx, nil)
+	err := p.ensureGrantsSystemTableExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	iter := &insertRowIter{
+		planner:   p,
+		tableName: "fb_grants",
+		targetColumns: []*qualifiedRefPlanExpression{
+			newQualifiedRefPlanExpression("fb_grants", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("fb_grants", "role_name", 0, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("fb_grants", "table_name", 0, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("fb_grants", "permission", 0, parser.NewDataTypeString()),
+			newQualifiedRefPlanExpression("fb_grants", "updated_at", 0, parser.NewDataTypeTimestamp()),
+		},
+		insertValues: [][]types.PlanExpression{
+			{
+				newStringLiteralPlanExpression(grantKey(grant.role, grant.table)),
+				newStringLiteralPlanExpression(grant.role),
+				newStringLiteralPlanExpression(grant.table),
+				newStringLiteralPlanExpression(string(grant.permission)),
+				newTimestampLiteralPlanExpression(time.Now().UTC()),
+			},
+		},
+	}
+	_, err = iter.Next(ctx)
+	if err != nil && err != types.ErrNoMoreRows {
+		return err
+	}
+	return nil
+}
+
+func (p *ExecutionPlanner) deleteGrant(ctx context.Context, role, table string) error {
+	ctx = authz.WithAccess(ctx, nil)
+	err := p.ensureGrantsSystemTableExists(ctx)
+	if err != nil {
+		return err
+	}
+
+	iter := &filteredDeleteRowIter{
+		planner:   p,
+		tableName: "fb_grants",
+		filter: newBinOpPlanExpression(
+			newQualifiedRefPlanExpression("fb_grants", string(dax.PrimaryKeyFieldName), 0, parser.NewDataTypeString()),
+			parser.EQ,
+			newStringLiteralPlanExpression(grantKey(role, table)),
+			parser.NewDataTypeBool(),
+		),
+	}
+	_, err = iter.Next(ctx)
+	if err != nil && err != types.ErrNoMoreRows {
+		return err
+	}
+	return nil
+}
@@ -2,12 +2,15 @@
 package sql3_test
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"sort"
 	"strings"
 	"testing"
 
+	"github.com/featurebasedb/featurebase/v3/authn"
+	"github.com/featurebasedb/featurebase/v3/authz"
 	"github.com/featurebasedb/featurebase/v3/dax"
 	"github.com/featurebasedb/featurebase/v3/sql3"
 	sql_test "github.com/featurebasedb/featurebase/v3/sql3/test"
@@ -192,3 +195,206 @@ func TestInternalError(t *testing.T) {
 		t.Fatalf("internal error from *_test.go file should contain test.go string")
 	}
 }
+
+func TestSQL_Restrictions(t *testing.T) {
+	c := test.MustRunCluster(t, 1)
+	defer c.Close()
+
+	svr := c.GetNode(0).Server
+	for _, sql := range []string{
+		`CREATE TABLE people (_id id, name string, region string, ssn int)`,
+		`INSERT INTO people (_id, name, region, ssn) VALUES (1, 'a', 'us', 11), (2, 'b', 'eu', 22), (3, 'c', 'us', 33)`,
+		`CREATE TABLE secrets (_id id, x int)`,
+	} {
+		_, _, _, err := sql_test.MustQueryRows(t, nil, svr, sql)
+		require.NoError(t, err)
+	}
+
+	var perms authz.GroupPermissions
+	require.NoError(t, perms.ReadPermissionsFile(strings.NewReader(`user-groups:
+  "us":
+    "people": "read"
+field-permissions:
+  "us":
+    "people":
+      deny: ["ssn"]
+row-filters:
+  "us":
+    "people": 'Row(region="us")'
+admin: "admins"`)))
+	user := &authn.UserInfo{UserID: "u", Groups: []authn.Group{{GroupID: "us"}}}
+	ctx := authz.WithAccess(context.Background(), perms.NewAccess(user))
+
+	rows, headers, _, err := sql_test.MustQueryRows(t, ctx, svr, `SELECT * FROM people`)
+	require.NoError(t, err)
+	var names []dax.FieldName
+	for _, hdr := range headers {
+		names = append(names, hdr.Name)
+	}
+	assert.Equal(t, []dax.FieldName{"_id", "name", "region"}, names)
+	assert.ElementsMatch(t, [][]interface{}{{int64(1), "a", "us"}, {int64(3), "c", "us"}}, rows)
+
+	rows, _, _, err = sql_test.MustQueryRows(t, ctx, svr, `SELECT COUNT(*) FROM people WHERE _id > 1`)
+	require.NoError(t, err)
+	assert.Equal(t, [][]interface{}{{int64(1)}}, rows)
+
+	for sql, expErr := range map[string]string{
+		`SELECT ssn FROM people`:                         "ssn",
+		`SELECT * FROM secrets`:                          "secrets",
+		`SELECT * FROM fb_exec_requests`:                 "fb_exec_requests",
+		`INSERT INTO people (_id, name) VALUES (4, 'd')`: "insufficient permissions",
+		`CREATE TABLE other (_id id, x int)`:             "insufficient permissions",
+		`DELETE FROM people WHERE _id = 2`:               "insufficient permissions",
+		`ALTER TABLE people ADD COLUMN y int`:            "insufficient permissions",
+	} {
+		_, _, _, err := sql_test.MustQueryRows(t, ctx, svr, sql)
+		if assert.Error(t, err, sql) {
+			assert.Contains(t, err.Error(), expErr, sql)
+		}
+	}
+}
+
+func TestSQL_ServerIORequiresAdmin(t *testing.T) {
+	c := test.MustRunCluster(t, 1)
+	defer c.Close()
+
+	svr := c.GetNode(0).Server
+	for _, sql := range []string{
+		`CREATE TABLE io_people (_id id, name string)`,
+		`INSERT INTO io_people (_id, name) VALUES (1, 'a')`,
+	} {
+		_, _, _, err := sql_test.MustQueryRows(t, nil, svr, sql)
+		require.NoError(t, err)
+	}
+
+	var perms authz.GroupPermissions
+	require.NoError(t, perms.ReadPermissionsFile(strings.NewReader(`user-groups:
+  "writers":
+    "io_people": "write"
+admin: "admins"`)))
+	user := &authn.UserInfo{UserID: "u", Groups: []authn.Group{{GroupID: "writers"}}}
+	ctx := authz.WithAccess(context.Background(), perms.NewAccess(user))
+
+	// Users who can write to a table still can't have the server read its
+	// files, fetch URLs or send data elsewhere.
+	for _, sql := range []string{
+		`BULK INSERT INTO io_people (_id, name) MAP (0 ID, 1 STRING) FROM '/etc/passwd' WITH FORMAT 'CSV' INPUT 'FILE'`,
+		`BULK INSERT INTO io_people (_id, name) MAP (0 ID, 1 STRING) FROM 'http://localhost:1/x.csv' WITH FORMAT 'CSV' INPUT 'URL'`,
+		`BULK INSERT INTO io_people (_id, name) MAP (0 ID, 1 STRING) FROM x'2,b' WITH FORMAT 'CSV' INPUT 'STREAM'`,
+		`COPY io_people TO io_other WITH URL 'http://localhost:1'`,
+	} {
+		_, _, _, err := sql_test.MustQueryRows(t, ctx, svr, sql)
+		if assert.Error(t, err, sql) {
+			assert.Contains(t, err.Error(), "insufficient permissions", sql)
+		}
+	}
+
+	// They can still write to the table.
+	_, _, _, err := sql_test.MustQueryRows(t, ctx, svr, `INSERT INTO io_people (_id, name) VALUES (2, 'b')`)
+	require.NoError(t, err)
+}
+
+func TestSQL_Grants(t *testing.T) {
+	c := test.MustRunCluster(t, 1)
+	defer c.Close()
+
+	svr := c.GetNode(0).Server
+	mustQuery := func(ctx context.Context, sql string) [][]interface{} {
+		t.Helper()
+		rows, _, _, err := sql_test.MustQueryRows(t, ctx, svr, sql)
+		require.NoError(t, err, sql)
+		return rows
+	}
+	for _, sql := range []string{
+		`CREATE TABLE grant_orders (_id id, amount int)`,
+		`CREATE TABLE grant_customers (_id id, name string)`,
+		`INSERT INTO grant_customers (_id, name) VALUES (1, 'a')`,
+		`CREATE ROLE analysts`,
+		`CREATE ROLE IF NOT EXISTS analysts`,
+		`GRANT SELECT ON grant_orders TO analysts`,
+		`GRANT INSERT, UPDATE ON grant_customers TO analysts`,
+		// granting less than has already been granted changes nothing
+		`GRANT SELECT ON grant_customers TO analysts`,
+	} {
+		mustQuery(nil, sql)
+	}
+	assert.Equal(t, [][]interface{}{
+		{"analysts", "grant_customers", "write"},
+		{"analysts", "grant_orders", "read"},
+	}, mustQuery(nil, `SHOW GRANTS`))
+
+	for sql, expErr := range map[string]string{
+		`CREATE ROLE analysts`:                      "role 'analysts' already exists",
+		`GRANT SELECT ON grant_orders TO nobody`:    "role 'nobody' not found",
+		`GRANT SELECT ON missing TO analysts`:       "table 'missing' not found",
+		`REVOKE SELECT ON grant_orders FROM nobody`: "role 'nobody' not found",
+		`SHOW GRANTS FOR nobody`:                    "role 'nobody' not found",
+		`DROP ROLE nobody`:                          "role 'nobody' not found",
+		`GRANT ALL PRIVILEGES ON t TO analysts`:     "table 't' not found",
+		// ':' separates roles from tables in the keys of grants
+		`CREATE ROLE "a:b"`:                        "invalid role name 'a:b'",
+		`GRANT SELECT ON grant_orders TO "a:b"`:    "invalid role name 'a:b'",
+		`REVOKE SELECT ON grant_orders FROM "a:b"`: "invalid role name 'a:b'",
+	} {
+		_, _, _, err := sql_test.MustQueryRows(t, nil, svr, sql)
+		if assert.Error(t, err, sql) {
+			assert.Contains(t, err.Error(), expErr, sql)
+		}
+	}
+
+	// Grants apply to the users in the group with the same ID as the role,
+	// once they're loaded into the permissions.
+	var perms authz.GroupPermissions
+	require.NoError(t, perms.ReadPermissionsFile(strings.NewReader(`user-groups:
+  "others":
+    "grant_orders": "read"
+admin: "admins"`)))
+	loadGrants := func() {
+		grants := make(authz.Grants)
+		for _, row := range mustQuery(nil, `SHOW GRANTS`) {
+			role, table := row[0].(string), row[1].(string)
+			if grants[role] == nil {
+				grants[role] = make(map[string]authz.Permission)
+			}
+			grants[role][table] = authz.Permission(row[2].(string))
+		}
+		perms.SetGrants(grants)
+	}
+	loadGrants()
+	user := &authn.UserInfo{UserID: "u", Groups: []authn.Group{{GroupID: "analysts"}}}
+	ctx := authz.WithAccess(context.Background(), perms.NewAccess(user))
+
+	assert.Equal(t, [][]interface{}{{int64(1), "a"}}, mustQuery(ctx, `SELECT * FROM grant_customers`))
+	mustQuery(ctx, `INSERT INTO grant_customers (_id, name) VALUES (2, 'b')`)
+	for _, sql := range []string{
+		`GRANT SELECT ON grant_orders TO analysts`,
+		`CREATE ROLE auditors`,
+		`DROP ROLE analysts`,
+		`SHOW GRANTS`,
+	} {
+		_, _, _, err := sql_test.MustQueryRows(t, ctx, svr, sql)
+		if assert.Error(t, err, sql) {
+			assert.Contains(t, err.Error(), "insufficient permissions", sql)
+		}
+	}
+
+	// Revoking the privileges to change a table leaves read access to it,
+	// while revoking SELECT revokes all access.
+	mustQuery(nil, `REVOKE INSERT ON grant_customers FROM analysts`)
+	mustQuery(nil, `REVOKE SELECT ON grant_orders FROM analysts`)
+	assert.Equal(t, [][]interface{}{
+		{"analysts", "grant_customers", "read"},
+	}, mustQuery(nil, `SHOW GRANTS FOR analysts`))
+
+	loadGrants()
+	mustQuery(ctx, `SELECT * FROM grant_customers`)
+	_, _, _, err := sql_test.MustQueryRows(t, ctx, svr, `INSERT INTO grant_customers (_id, name) VALUES (3, 'c')`)
+	if assert.Error(t, err) {
+		assert.Contains(t, err.Error(), "insufficient permissions")
+	}
+
+	// Dropping a role drops its grants.
+	mustQuery(nil, `DROP ROLE analysts`)
+	mustQuery(nil, `DROP ROLE IF EXISTS analysts`)
+	assert.Empty(t, mustQuery(nil, `SHOW GRANTS`))
+}
@@ -47,6 +47,9 @@ var TableTests []TableTest = []TableTest{
 	setFunctionTests,
 	setParameterTests,
 	setTimeQuantumTests,
+	funnelTests,
+	retentionTests,
+	sampleTests,
 	dateTimePartTests,
 	dateTimeNameTests,
 	toTimestampTests,
@@ -0,0 +1,93 @@
+package defs
+
+// funnel tests
+var funnelTests = TableTest{
+	Table: tbl(
+		"funnel_tests",
+		srcHdrs(
+			srcHdr("_id", fldTypeID),
+			srcHdr("event", fldTypeStringSetQ, "timequantum 'YMDH'"),
+			srcHdr("i1", fldTypeInt, "min 0", "max 1000"),
+		),
+	),
+	SQLTests: []SQLTest{
+		{
+			SQLs: sqls(
+				"insert into funnel_tests (_id, event) values (1, {'2023-01-01T10:00:00Z', ['view']})",
+				"insert into funnel_tests (_id, event) values (1, {'2023-01-01T12:00:00Z', ['cart']})",
+				"insert into funnel_tests (_id, event) values (1, {'2023-01-02T09:00:00Z', ['buy']})",
+				"insert into funnel_tests (_id, event) values (2, {'2023-01-01T10:00:00Z', ['view']})",
+				"insert into funnel_tests (_id, event) values (2, {'2023-01-03T10:00:00Z', ['cart']})",
+				"insert into funnel_tests (_id, event) values (3, {'2023-01-01T10:00:00Z', ['cart']})",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "funnel",
+			SQLs: sqls(
+				"select * from funnel(funnel_tests, '2023-01-01T00:00:00Z', '2023-01-04T00:00:00Z', null, setcontains(event, 'view'), setcontains(event, 'cart'), setcontains(event, 'buy'))",
+			),
+			ExpHdrs: hdrs(
+				hdr("step", fldTypeInt),
+				hdr("count", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(1), int64(2)),
+				row(int64(2), int64(2)),
+				row(int64(3), int64(1)),
+			),
+			Compare: CompareExactOrdered,
+		},
+		{
+			name: "funnel-window",
+			SQLs: sqls(
+				"select f.step, f.count from funnel(funnel_tests, '2023-01-01T00:00:00Z', '2023-01-04T00:00:00Z', '24h', setcontains(event, 'view'), setcontains(event, 'cart')) f where f.step > 1",
+			),
+			ExpHdrs: hdrs(
+				hdr("step", fldTypeInt),
+				hdr("count", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(2), int64(1)),
+			),
+			Compare: CompareExactOrdered,
+		},
+		{
+			name: "funnel-too-few-args",
+			SQLs: sqls(
+				"select * from funnel(funnel_tests, '2023-01-01T00:00:00Z', '2023-01-04T00:00:00Z', null)",
+			),
+			ExpErr: "'funnel': count of formal parameters (5) does not match count of actual parameters (4)",
+		},
+		{
+			name: "funnel-bad-table",
+			SQLs: sqls(
+				"select * from funnel(no_such_table, '2023-01-01T00:00:00Z', '2023-01-04T00:00:00Z', null, setcontains(event, 'view'))",
+			),
+			ExpErr: "table or view 'no_such_table' not found",
+		},
+		{
+			name: "funnel-bad-window",
+			SQLs: sqls(
+				"select * from funnel(funnel_tests, '2023-01-01T00:00:00Z', '2023-01-04T00:00:00Z', 'soon', setcontains(event, 'view'))",
+			),
+			ExpErr: "invalid value 'soon' for parameter 'window'",
+		},
+		{
+			name: "funnel-not-time-quantum",
+			SQLs: sqls(
+				"select * from funnel(funnel_tests, '2023-01-01T00:00:00Z', '2023-01-04T00:00:00Z', null, setcontains(i1, 1))",
+			),
+			ExpErr: "set expression expected",
+		},
+		{
+			name: "unknown-table-valued-function",
+			SQLs: sqls(
+				"select * from nosuchfunction(funnel_tests)",
+			),
+			ExpErr: "unknown function 'nosuchfunction'",
+		},
+	},
+}
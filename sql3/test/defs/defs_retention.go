@@ -0,0 +1,94 @@
+package defs
+
+import "time"
+
+// retention tests
+var retentionTests = TableTest{
+	Table: tbl(
+		"retention_tests",
+		srcHdrs(
+			srcHdr("_id", fldTypeID),
+			srcHdr("event", fldTypeStringSetQ, "timequantum 'YMD'"),
+			srcHdr("i1", fldTypeInt, "min 0", "max 1000"),
+		),
+	),
+	SQLTests: []SQLTest{
+		{
+			SQLs: sqls(
+				"insert into retention_tests (_id, event) values (1, {'2023-01-01T10:00:00Z', ['signup', 'login']})",
+				"insert into retention_tests (_id, event) values (1, {'2023-01-02T10:00:00Z', ['login']})",
+				"insert into retention_tests (_id, event) values (2, {'2023-01-01T10:00:00Z', ['signup']})",
+				"insert into retention_tests (_id, event) values (2, {'2023-01-03T10:00:00Z', ['login']})",
+				"insert into retention_tests (_id, event) values (3, {'2023-01-02T10:00:00Z', ['signup']})",
+				"insert into retention_tests (_id, event) values (3, {'2023-01-03T10:00:00Z', ['signup', 'login']})",
+			),
+			ExpHdrs: hdrs(),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "retention",
+			SQLs: sqls(
+				"select * from retention(retention_tests, '2023-01-01T00:00:00Z', 'day', 3, setcontains(event, 'signup'), setcontains(event, 'login'))",
+			),
+			ExpHdrs: hdrs(
+				hdr("cohort", fldTypeTimestamp),
+				hdr("cohort_size", fldTypeInt),
+				hdr("period", fldTypeInt),
+				hdr("retained", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), int64(2), int64(0), int64(1)),
+				row(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), int64(2), int64(1), int64(1)),
+				row(time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), int64(2), int64(2), int64(1)),
+				row(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), int64(1), int64(0), int64(0)),
+				row(time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), int64(1), int64(1), int64(1)),
+				row(time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC), int64(0), int64(0), int64(0)),
+			),
+			Compare: CompareExactOrdered,
+		},
+		{
+			name: "retention-week",
+			SQLs: sqls(
+				"select r.cohort_size, r.retained from retention(retention_tests, '2023-01-01T00:00:00Z', 'week', 2, setcontains(event, 'signup'), setcontains(event, 'login')) r where r.period = 0",
+			),
+			ExpHdrs: hdrs(
+				hdr("cohort_size", fldTypeInt),
+				hdr("retained", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(3), int64(3)),
+				row(int64(0), int64(0)),
+			),
+			Compare: CompareExactOrdered,
+		},
+		{
+			name: "retention-wrong-arg-count",
+			SQLs: sqls(
+				"select * from retention(retention_tests, '2023-01-01T00:00:00Z', 'day', 3, setcontains(event, 'signup'))",
+			),
+			ExpErr: "'retention': count of formal parameters (6) does not match count of actual parameters (5)",
+		},
+		{
+			name: "retention-bad-period",
+			SQLs: sqls(
+				"select * from retention(retention_tests, '2023-01-01T00:00:00Z', 'fortnight', 3, setcontains(event, 'signup'), setcontains(event, 'login'))",
+			),
+			ExpErr: "invalid value 'fortnight' for parameter 'period'",
+		},
+		{
+			name: "retention-bad-periods",
+			SQLs: sqls(
+				"select * from retention(retention_tests, '2023-01-01T00:00:00Z', 'day', 0, setcontains(event, 'signup'), setcontains(event, 'login'))",
+			),
+			ExpErr: "invalid value '0' for parameter 'periods'",
+		},
+		{
+			name: "retention-not-time-quantum",
+			SQLs: sqls(
+				"select * from retention(retention_tests, '2023-01-01T00:00:00Z', 'day', 3, setcontains(i1, 1), setcontains(event, 'login'))",
+			),
+			ExpErr: "set expression expected",
+		},
+	},
+}
@@ -0,0 +1,133 @@
+package defs
+
+// sample tests
+var sampleTests = TableTest{
+	Table: tbl(
+		"sample_tests",
+		srcHdrs(
+			srcHdr("_id", fldTypeID),
+			srcHdr("i1", fldTypeInt, "min 0", "max 1000"),
+		),
+		srcRows(
+			srcRow(int64(1), int64(10)),
+			srcRow(int64(2), int64(20)),
+			srcRow(int64(3), int64(30)),
+			srcRow(int64(4), int64(40)),
+			srcRow(int64(5), int64(50)),
+			srcRow(int64(6), int64(60)),
+			srcRow(int64(7), int64(70)),
+			srcRow(int64(8), int64(80)),
+			srcRow(int64(9), int64(90)),
+			srcRow(int64(10), int64(100)),
+		),
+	),
+	SQLTests: []SQLTest{
+		{
+			name: "sample-rows",
+			SQLs: sqls(
+				"select count(*) from sample_tests sample 4 rows",
+				"select count(*) from sample_tests s sample 4 rows repeatable (42)",
+				"select count(*) from (select _id from sample_tests sample 4 rows)",
+			),
+			ExpHdrs: hdrs(
+				hdr("", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(4)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "tablesample",
+			SQLs: sqls(
+				"select count(*) from sample_tests tablesample (50)",
+				"select count(*) from sample_tests tablesample bernoulli (50.0) repeatable (7)",
+				"select count(*) from sample_tests tablesample system (50)",
+			),
+			ExpHdrs: hdrs(
+				hdr("", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(5)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "sample-all",
+			SQLs: sqls(
+				"select _id, i1 from sample_tests sample 100 rows where i1 > 70",
+				"select _id, i1 from sample_tests tablesample (100) where i1 > 70",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+				hdr("i1", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(8), int64(80)),
+				row(int64(9), int64(90)),
+				row(int64(10), int64(100)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "sample-none",
+			SQLs: sqls(
+				"select _id from sample_tests tablesample (0)",
+				"select _id from sample_tests sample 0 rows",
+			),
+			ExpHdrs: hdrs(
+				hdr("_id", fldTypeID),
+			),
+			ExpRows: rows(),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "sample-repeatable",
+			SQLs: sqls(
+				"select count(*) from (select _id from sample_tests sample 3 rows repeatable (1)) a inner join (select _id from sample_tests sample 3 rows repeatable (1)) b on a._id = b._id",
+			),
+			ExpHdrs: hdrs(
+				hdr("", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(3)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "sample-top",
+			SQLs: sqls(
+				"select count(*) from (select top(2) _id from sample_tests sample 5 rows)",
+			),
+			ExpHdrs: hdrs(
+				hdr("", fldTypeInt),
+			),
+			ExpRows: rows(
+				row(int64(2)),
+			),
+			Compare: CompareExactUnordered,
+		},
+		{
+			name: "tablesample-out-of-range",
+			SQLs: sqls(
+				"select * from sample_tests tablesample (150)",
+			),
+			ExpErr: "value '150' out of range",
+		},
+		{
+			name: "tablesample-not-a-number",
+			SQLs: sqls(
+				"select * from sample_tests tablesample ('ten')",
+			),
+			ExpErr: "integer or decimal expression expected",
+		},
+		{
+			name: "sample-rows-not-an-integer",
+			SQLs: sqls(
+				"select * from sample_tests sample 2.5 rows",
+				"select * from sample_tests sample 2 rows repeatable (1.5)",
+			),
+			ExpErr: "integer literal expected",
+		},
+	},
+}
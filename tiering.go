@@ -0,0 +1,580 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/logger"
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+	txkey "github.com/featurebasedb/featurebase/v3/short_txkey"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/singleflight"
+)
+
+// Shard tier states, as reported by ShardTierInfo.
+const (
+	// ShardTierLocal is a shard which is only stored on local disk.
+	ShardTierLocal = "local"
+
+	// ShardTierOffloaded is a shard which has been moved to the object
+	// store. It is fetched back to local disk when a query touches it.
+	ShardTierOffloaded = "offloaded"
+
+	// ShardTierCached is a shard which has been fetched back from the object
+	// store. It is offloaded again once it has not been written for the
+	// configured age.
+	ShardTierCached = "cached"
+)
+
+// tierManifestFile is the name of the file in a shard's directory which
+// records its tier state. Offloaded shards keep their directory, holding only
+// this file, so they are still found when the holder is opened.
+const tierManifestFile = "tier.json"
+
+// ShardTierInfo describes where the data for a shard is stored on this node.
+type ShardTierInfo struct {
+	Shard uint64 `json:"shard"`
+	State string `json:"state"`
+}
+
+// tierManifest is the contents of a shard's tier manifest file.
+type tierManifest struct {
+	Key         string    `json:"key"`
+	State       string    `json:"state"`
+	OffloadedAt time.Time `json:"offloadedAt"`
+	FetchedAt   time.Time `json:"fetchedAt,omitempty"`
+
+	// The following describe the contents of an offloaded shard so that
+	// the holder can be opened without fetching it. They are keyed by
+	// "field/view".
+	FieldViews []txkey.FieldView      `json:"fieldViews,omitempty"`
+	BitDepths  map[string]uint64      `json:"bitDepths,omitempty"`
+	RowCounts  map[string][][2]uint64 `json:"rowCounts,omitempty"`
+}
+
+// offloaded returns true if m describes a shard which is not on local disk.
+func (m *tierManifest) offloaded() bool {
+	return m != nil && m.State == ShardTierOffloaded
+}
+
+// shardTiering moves the RBF databases of cold shards between local disk and
+// an object store.
+type shardTiering struct {
+	store  objectstore.Store
+	age    time.Duration
+	logger logger.Logger
+
+	fetches singleflight.Group
+
+	mu        sync.Mutex
+	manifests map[string]*tierManifest // by shard path; nil if local
+}
+
+func newShardTiering(store objectstore.Store, age time.Duration, logger logger.Logger) *shardTiering {
+	return &shardTiering{
+		store:     store,
+		age:       age,
+		logger:    logger,
+		manifests: make(map[string]*tierManifest),
+	}
+}
+
+// manifest returns the tier manifest for the shard at path, or nil if the
+// shard has never been offloaded. Manifests are cached after the first read.
+func (t *shardTiering) manifest(path string) (*tierManifest, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if m, ok := t.manifests[path]; ok {
+		return m, nil
+	}
+
+	var m *tierManifest
+	buf, err := os.ReadFile(filepath.Join(path, tierManifestFile))
+	if err == nil {
+		m = &tierManifest{}
+		if err := json.Unmarshal(buf, m); err != nil {
+			return nil, errors.Wrapf(err, "decoding tier manifest for %s", path)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, errors.Wrap(err, "reading tier manifest")
+	}
+	t.manifests[path] = m
+	return m, nil
+}
+
+// setManifest writes the tier manifest for the shard at path.
+func (t *shardTiering) setManifest(path string, m *tierManifest) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	buf, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	filename := filepath.Join(path, tierManifestFile)
+	if err := os.WriteFile(filename+".tmp", buf, 0o600); err != nil {
+		return errors.Wrap(err, "writing tier manifest")
+	} else if err := os.Rename(filename+".tmp", filename); err != nil {
+		return errors.Wrap(err, "renaming tier manifest")
+	}
+	t.manifests[path] = m
+	return nil
+}
+
+// forget removes any cached manifests for shards under dir.
+func (t *shardTiering) forget(dir string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for path := range t.manifests {
+		if strings.HasPrefix(path, dir) {
+			delete(t.manifests, path)
+		}
+	}
+}
+
+// fetch downloads the shard at path from the object store if it has been
+// offloaded. Concurrent fetches of the same shard share a single download.
+func (t *shardTiering) fetch(ctx context.Context, index, path string) error {
+	if m, err := t.manifest(path); err != nil || !m.offloaded() {
+		return err
+	}
+
+	_, err, _ := t.fetches.Do(path, func() (interface{}, error) {
+		m, err := t.manifest(path)
+		if err != nil || !m.offloaded() {
+			return nil, err
+		}
+
+		start := time.Now()
+		rc, err := t.store.Get(ctx, m.Key)
+		if err != nil {
+			return nil, errors.Wrapf(err, "fetching %s", m.Key)
+		}
+		defer rc.Close()
+		if err := extractShard(path, rc); err != nil {
+			return nil, errors.Wrapf(err, "extracting %s", m.Key)
+		}
+
+		CounterTieredShardFetches.WithLabelValues(index).Inc()
+		t.logger.Infof("fetched shard %s from %s in %s", path, m.Key, time.Since(start))
+		return nil, t.setManifest(path, &tierManifest{
+			Key:         m.Key,
+			State:       ShardTierCached,
+			OffloadedAt: m.OffloadedAt,
+			FetchedAt:   time.Now(),
+		})
+	})
+	return err
+}
+
+// upload writes the files of the shard at path to the object store at key as
+// a tar archive. The database must be closed.
+func (t *shardTiering) upload(ctx context.Context, path, key string) error {
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(archiveShard(path, pw))
+	}()
+	err := t.store.Put(ctx, key, pr)
+	pr.CloseWithError(err)
+	return err
+}
+
+// archiveShard writes every file in the shard directory, other than the tier
+// manifest, to w as a tar archive.
+func archiveShard(path string, w io.Writer) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), tierManifestFile) {
+			continue
+		}
+		if err := func() error {
+			f, err := os.Open(filepath.Join(path, entry.Name()))
+			if err != nil {
+				return err
+			}
+			defer f.Close()
+
+			fi, err := f.Stat()
+			if err != nil {
+				return err
+			}
+			if err := tw.WriteHeader(&tar.Header{
+				Name:    entry.Name(),
+				Mode:    0o600,
+				Size:    fi.Size(),
+				ModTime: fi.ModTime(),
+			}); err != nil {
+				return err
+			}
+			_, err = io.CopyN(tw, f, fi.Size())
+			return err
+		}(); err != nil {
+			return errors.Wrapf(err, "archiving %s", entry.Name())
+		}
+	}
+	return tw.Close()
+}
+
+// extractShard writes the files in the tar archive read from r into the shard
+// directory at path. Each file is written to a temporary file first so a
+// partial download never leaves a partial database.
+func extractShard(path string, r io.Reader) error {
+	var names []string
+	defer func() {
+		for _, name := range names {
+			os.Remove(filepath.Join(path, name+".tmp"))
+		}
+	}()
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		} else if hdr.Name != filepath.Base(hdr.Name) || hdr.Name == tierManifestFile {
+			return fmt.Errorf("invalid file in shard archive: %q", hdr.Name)
+		}
+
+		names = append(names, hdr.Name)
+		f, err := os.OpenFile(filepath.Join(path, hdr.Name+".tmp"), os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+		if err != nil {
+			return err
+		}
+		if _, err := io.Copy(f, tr); err != nil {
+			f.Close()
+			return err
+		} else if err := f.Sync(); err != nil {
+			f.Close()
+			return err
+		} else if err := f.Close(); err != nil {
+			return err
+		}
+	}
+
+	for _, name := range names {
+		if err := os.Rename(filepath.Join(path, name+".tmp"), filepath.Join(path, name)); err != nil {
+			return err
+		}
+	}
+	names = nil
+	return nil
+}
+
+// removeShardFiles removes every file in the shard directory other than the
+// tier manifest.
+func removeShardFiles(path string) error {
+	entries, err := os.ReadDir(path)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if entry.Name() == tierManifestFile {
+			continue
+		}
+		if err := os.RemoveAll(filepath.Join(path, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// shardLastWrite returns the last time the RBF database at path was written.
+// An empty WAL is ignored since one is created whenever the database is
+// opened.
+func shardLastWrite(path string) (time.Time, error) {
+	var t time.Time
+	for _, name := range []string{"data", "wal"} {
+		fi, err := os.Stat(filepath.Join(path, name))
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return t, err
+		} else if fi.Size() > 0 && fi.ModTime().After(t) {
+			t = fi.ModTime()
+		}
+	}
+	return t, nil
+}
+
+// tierKey returns the object store key for a shard.
+func tierKey(index string, shard uint64) string {
+	return fmt.Sprintf("%s/shard.%04d.tar", index, shard)
+}
+
+// shardPath returns the path of the database for a shard.
+func (per *DBPerShard) shardPath(index string, shard uint64) string {
+	return per.HolderDir + sep + index + sep + backendsDir + sep + per.typ.DirectoryName() + sep + fmt.Sprintf("shard.%04v", shard)
+}
+
+// tierManifest returns the tier manifest for a shard if it has been
+// offloaded to the object store, or nil if its data is on local disk.
+func (per *DBPerShard) tierManifest(index string, shard uint64) *tierManifest {
+	if per.tiering == nil {
+		return nil
+	}
+	m, err := per.tiering.manifest(per.shardPath(index, shard))
+	if err != nil {
+		per.holder.Logger.Errorf("reading tier manifest for shard %s/%d: %v", index, shard, err)
+		return nil
+	} else if !m.offloaded() {
+		return nil
+	}
+	return m
+}
+
+// fetchShard ensures that the database for a shard is on local disk.
+func (per *DBPerShard) fetchShard(index string, shard uint64) error {
+	if per.tiering == nil {
+		return nil
+	}
+	if err := per.tiering.fetch(context.Background(), index, per.shardPath(index, shard)); err != nil {
+		return errors.Wrapf(err, "fetching shard %s/%d from object store", index, shard)
+	}
+	return nil
+}
+
+// offloadShard moves the database for a shard to the object store if it has
+// not been written for the configured age. Returns true if the shard was
+// offloaded.
+//
+// The database is closed while it is uploaded. If a transaction reopens it in
+// the meantime, the offload is abandoned so no writes are lost.
+func (per *DBPerShard) offloadShard(ctx context.Context, idx *Index, shard uint64) (bool, error) {
+	path := per.shardPath(idx.name, shard)
+	m, err := per.tiering.manifest(path)
+	if err != nil || m.offloaded() {
+		return false, err
+	}
+	lastWrite, err := shardLastWrite(path)
+	if err != nil || lastWrite.IsZero() || time.Since(lastWrite) < per.tiering.age {
+		return false, err
+	}
+
+	// The shard is unchanged since it was fetched, so the object is
+	// still current and does not need to be uploaded again.
+	upload := m == nil || lastWrite.After(m.FetchedAt)
+
+	// Shards with deletes in flight are kept local so the deletes can be
+	// retried when the holder is reopened.
+	if inflight, err := per.deleteInflight(idx, shard); err != nil || inflight {
+		return false, err
+	}
+
+	next := &tierManifest{
+		Key:         tierKey(idx.name, shard),
+		State:       ShardTierOffloaded,
+		OffloadedAt: time.Now(),
+		BitDepths:   make(map[string]uint64),
+		RowCounts:   make(map[string][][2]uint64),
+	}
+	if err := per.describeShard(idx, shard, next); err != nil {
+		return false, err
+	}
+
+	// Detach the database unless a transaction is using it.
+	per.Mu.Lock()
+	key := flatkey{index: idx.name, shard: shard}
+	dbs := per.Flatmap[key]
+	if dbs == nil || !dbs.Open {
+		per.Mu.Unlock()
+		return false, nil
+	}
+	if w, ok := dbs.W.(*RbfDBWrapper); !ok || w.db.TxN() > 0 {
+		per.Mu.Unlock()
+		return false, nil
+	}
+	if err := dbs.W.Close(); err != nil {
+		per.Mu.Unlock()
+		return false, err
+	}
+	dbs.Open = false
+	delete(per.Flatmap, key)
+	per.Mu.Unlock()
+
+	if upload {
+		if err := per.tiering.upload(ctx, path, next.Key); err != nil {
+			return false, errors.Wrapf(err, "uploading shard %s/%d", idx.name, shard)
+		}
+	}
+
+	per.Mu.Lock()
+	defer per.Mu.Unlock()
+	if dbs.Open {
+		per.holder.Logger.Infof("shard %s/%d was reopened while offloading; keeping local copy", idx.name, shard)
+		return false, nil
+	} else if err := per.tiering.setManifest(path, next); err != nil {
+		return false, err
+	} else if err := removeShardFiles(path); err != nil {
+		return false, err
+	}
+	CounterTieredShardOffloads.WithLabelValues(idx.name).Inc()
+	return true, nil
+}
+
+// deleteInflight returns true if a delete of records in the shard was
+// interrupted and has not yet been retried.
+func (per *DBPerShard) deleteInflight(idx *Index, shard uint64) (bool, error) {
+	if !idx.trackExistence {
+		return false, nil
+	}
+	frag := per.holder.fragment(idx.name, existenceFieldName, viewStandard, shard)
+	if frag == nil {
+		return false, nil
+	}
+	tx := per.holder.Txf().NewTx(Txo{Index: idx, Shard: shard})
+	defer tx.Rollback()
+	rows, err := frag.rows(context.Background(), tx, 1)
+	return len(rows) > 0, err
+}
+
+// describeShard records the field views, bit depths & cached row counts of a
+// shard in m so the holder can be opened without fetching it.
+func (per *DBPerShard) describeShard(idx *Index, shard uint64, m *tierManifest) error {
+	dbs, err := per.GetDBShard(idx.name, shard, idx)
+	if err != nil {
+		return err
+	}
+	if m.FieldViews, err = dbs.AllFieldViews(); err != nil {
+		return err
+	}
+
+	for _, f := range idx.Fields() {
+		for _, v := range f.views() {
+			frag := v.Fragment(shard)
+			if frag == nil {
+				continue
+			}
+			key := f.name + "/" + v.name
+
+			switch f.Type() {
+			case FieldTypeInt, FieldTypeDecimal, FieldTypeTimestamp:
+				bd, err := frag.bitDepth()
+				if err != nil {
+					return errors.Wrapf(err, "getting bit depth of %s", key)
+				}
+				m.BitDepths[key] = bd
+			}
+
+			frag.mu.RLock()
+			if frag.cache != nil && frag.CacheType != CacheTypeNone {
+				for _, id := range frag.cache.IDs() {
+					m.RowCounts[key] = append(m.RowCounts[key], [2]uint64{id, frag.cache.Get(id)})
+				}
+			}
+			frag.mu.RUnlock()
+		}
+	}
+	return nil
+}
+
+// deleteTieredShards removes the objects of every tiered shard in an index.
+func (per *DBPerShard) deleteTieredShards(index string) {
+	dir := per.HolderDir + sep + index + sep + backendsDir + sep + per.typ.DirectoryName()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		path := filepath.Join(dir, entry.Name())
+		m, err := per.tiering.manifest(path)
+		if err != nil || m == nil {
+			continue
+		}
+		if err := per.tiering.store.Delete(context.Background(), m.Key); err != nil {
+			per.holder.Logger.Errorf("deleting tiered shard %s: %v", m.Key, err)
+		}
+	}
+	per.tiering.forget(dir)
+}
+
+// shardTiers returns the tier state of every local shard of an index.
+func (per *DBPerShard) shardTiers(idx *Index) ([]ShardTierInfo, error) {
+	shards, err := per.TypedDBPerShardGetShardsForIndex(per.typ, idx, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]ShardTierInfo, 0, len(shards))
+	for shard := range shards {
+		info := ShardTierInfo{Shard: shard, State: ShardTierLocal}
+		m, err := per.tiering.manifest(per.shardPath(idx.name, shard))
+		if err != nil {
+			return nil, err
+		} else if m != nil {
+			info.State = m.State
+		}
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Shard < infos[j].Shard })
+	return infos, nil
+}
+
+// offloadColdShards moves every shard which has not been written for the
+// configured age to the object store. Returns the number of shards moved.
+func (h *Holder) offloadColdShards(ctx context.Context) (int, error) {
+	per := h.txf.dbPerShard
+	if per.tiering == nil {
+		return 0, nil
+	}
+
+	var n int
+	for _, dbs := range h.dbShards("") {
+		select {
+		case <-ctx.Done():
+			return n, ctx.Err()
+		case <-h.closing:
+			return n, nil
+		default:
+		}
+
+		idx := h.Index(dbs.Index)
+		if idx == nil {
+			continue
+		}
+		ok, err := per.offloadShard(ctx, idx, dbs.Shard)
+		if err != nil {
+			h.Logger.Errorf("offloading shard %s/%d: %v", dbs.Index, dbs.Shard, err)
+			continue
+		} else if ok {
+			h.Logger.Infof("offloaded shard %s/%d to object store", dbs.Index, dbs.Shard)
+			n++
+		}
+	}
+	return n, nil
+}
+
+// monitorTiering periodically offloads cold shards to the object store.
+func (s *Server) monitorTiering() {
+	ticker := time.NewTicker(s.tieringInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.closing:
+			return
+		case <-ticker.C:
+			if _, err := s.holder.offloadColdShards(context.Background()); err != nil {
+				s.logger.Errorf("offloading cold shards: %v", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Copyright 2022 Molecula Corp. (DBA FeatureBase).
+// SPDX-License-Identifier: Apache-2.0
+package pilosa
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/featurebasedb/featurebase/v3/objectstore"
+	"github.com/featurebasedb/featurebase/v3/objectstore/objectstoretest"
+	"github.com/featurebasedb/featurebase/v3/testhook"
+)
+
+func TestHolder_OffloadColdShards(t *testing.T) {
+	srv := objectstoretest.NewServer(t)
+	store, err := objectstore.NewS3Store(srv.Config("bkt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := TestHolderConfig()
+	cfg.TieredStore = store
+	cfg.TieredAge = time.Minute
+	h := NewHolder(t.TempDir(), cfg)
+	if err := h.Open(); err != nil {
+		t.Fatal(err)
+	}
+	testhook.Cleanup(t, func() { h.Close() })
+
+	idx, f := setupTest(t, h, []rowCols{{0, 1}, {0, ShardWidth + 1}, {0, ShardWidth * 2}}, "idxtier")
+
+	// Nothing is old enough to offload yet.
+	if n, err := h.offloadColdShards(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if n != 0 {
+		t.Fatalf("unexpected offload count: %d", n)
+	}
+
+	// Backdate shard 1 so that it is considered cold.
+	path := h.txf.dbPerShard.shardPath(idx.name, 1)
+	old := time.Now().Add(-time.Hour)
+	for _, name := range []string{"data", "wal"} {
+		if err := os.Chtimes(filepath.Join(path, name), old, old); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+
+	if n, err := h.offloadColdShards(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("unexpected offload count: %d", n)
+	}
+	if got := srv.Keys("bkt"); !reflect.DeepEqual(got, []string{tierKey(idx.name, 1)}) {
+		t.Fatalf("unexpected keys: %v", got)
+	} else if _, err := os.Stat(filepath.Join(path, "data")); !os.IsNotExist(err) {
+		t.Fatalf("expected local data to be removed: %v", err)
+	}
+
+	mustTiers := func(want []ShardTierInfo) {
+		t.Helper()
+		if tiers, err := h.txf.dbPerShard.shardTiers(h.Index(idx.name)); err != nil {
+			t.Fatal(err)
+		} else if !reflect.DeepEqual(tiers, want) {
+			t.Fatalf("unexpected tiers: %+v", tiers)
+		}
+	}
+	mustTiers([]ShardTierInfo{{0, ShardTierLocal}, {1, ShardTierOffloaded}, {2, ShardTierLocal}})
+
+	// Reopening the holder should not fetch the shard.
+	if err := h.Close(); err != nil {
+		t.Fatal(err)
+	} else if err := h.Open(); err != nil {
+		t.Fatal(err)
+	}
+	mustTiers([]ShardTierInfo{{0, ShardTierLocal}, {1, ShardTierOffloaded}, {2, ShardTierLocal}})
+	if !h.Index(idx.name).AvailableShards(includeRemote).Contains(1) {
+		t.Fatal("expected offloaded shard to be available")
+	}
+
+	// The shard isn't fetched while holding per.Mu; GetDBShard fetches it
+	// before taking the lock.
+	per := h.txf.dbPerShard
+	per.Mu.Lock()
+	_, err = per.unprotectedGetDBShard(idx.name, 1, h.Index(idx.name))
+	per.Mu.Unlock()
+	if err != errShardOffloaded {
+		t.Fatalf("expected errShardOffloaded, got %v", err)
+	}
+	mustTiers([]ShardTierInfo{{0, ShardTierLocal}, {1, ShardTierOffloaded}, {2, ShardTierLocal}})
+	if _, err := per.GetDBShard(idx.name, 1, h.Index(idx.name)); err != nil {
+		t.Fatal(err)
+	}
+	mustTiers([]ShardTierInfo{{0, ShardTierLocal}, {1, ShardTierCached}, {2, ShardTierLocal}})
+
+	// Reading the shard fetches it back from the object store.
+	f = h.Index(idx.name).Field(f.name)
+	qcx := h.Txf().NewQcx()
+	row, err := f.Row(qcx, 0)
+	qcx.Abort()
+	if err != nil {
+		t.Fatal(err)
+	} else if cols := row.Columns(); !reflect.DeepEqual(cols, []uint64{1, ShardWidth + 1, ShardWidth * 2}) {
+		t.Fatalf("unexpected columns: %v", cols)
+	}
+	mustTiers([]ShardTierInfo{{0, ShardTierLocal}, {1, ShardTierCached}, {2, ShardTierLocal}})
+
+	// A cached shard which has not been written is dropped without
+	// uploading it again.
+	for _, name := range []string{"data", "wal"} {
+		if err := os.Chtimes(filepath.Join(path, name), old, old); err != nil && !os.IsNotExist(err) {
+			t.Fatal(err)
+		}
+	}
+	if n, err := h.offloadColdShards(context.Background()); err != nil {
+		t.Fatal(err)
+	} else if n != 1 {
+		t.Fatalf("unexpected offload count: %d", n)
+	}
+	mustTiers([]ShardTierInfo{{0, ShardTierLocal}, {1, ShardTierOffloaded}, {2, ShardTierLocal}})
+
+	// Deleting the index removes offloaded shards from the object store.
+	if err := h.DeleteIndex(idx.name); err != nil {
+		t.Fatal(err)
+	} else if got := srv.Keys("bkt"); len(got) != 0 {
+		t.Fatalf("unexpected keys: %v", got)
+	}
+}
@@ -586,3 +586,33 @@ func getLowestGranularityQuantum(views []string) TimeQuantum {
 
 	return TimeQuantum(lowestGranularity)
 }
+
+// truncateTime returns the start of the year, month, day or hour which t is
+// in.
+func truncateTime(t time.Time, unit rune) time.Time {
+	switch unit {
+	case 'Y':
+		return time.Date(t.Year(), 1, 1, 0, 0, 0, 0, t.Location())
+	case 'M':
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+	case 'D':
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	default:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), 0, 0, 0, t.Location())
+	}
+}
+
+// addTime returns t plus n years, months, days or hours. t should be at the
+// start of one of them, as returned by truncateTime.
+func addTime(t time.Time, unit rune, n int) time.Time {
+	switch unit {
+	case 'Y':
+		return t.AddDate(n, 0, 0)
+	case 'M':
+		return t.AddDate(0, n, 0)
+	case 'D':
+		return t.AddDate(0, 0, n)
+	default:
+		return t.Add(time.Duration(n) * time.Hour)
+	}
+}
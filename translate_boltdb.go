@@ -14,6 +14,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/featurebasedb/featurebase/v3/encryption"
 	"github.com/featurebasedb/featurebase/v3/roaring"
 	"github.com/pkg/errors"
 	bolt "go.etcd.io/bbolt"
@@ -30,10 +31,16 @@ var (
 	// and the underlying store returns an empty set
 	ErrTranslateKeyNotFound = errors.New("boltdb: translating key returned empty set")
 
+	// ErrTranslateStoreEncrypted is returned when opening an encrypted
+	// translate store without a cipher.
+	ErrTranslateStoreEncrypted = errors.New("boltdb: translate store is encrypted but no cipher is configured")
+
 	bucketKeys = []byte("keys")
 	bucketIDs  = []byte("ids")
 	bucketFree = []byte("free")
+	bucketMeta = []byte("meta")
 	freeKey    = []byte("free")
+	keyIDKey   = []byte("key-id")
 )
 
 const (
@@ -53,6 +60,20 @@ func OpenTranslateStore(path, index, field string, partitionID, partitionN int,
 	return s, nil
 }
 
+// OpenEncryptedTranslateStore returns an OpenTranslateStoreFunc which opens
+// boltdb translation stores whose keys are encrypted with c.
+func OpenEncryptedTranslateStore(c *encryption.Cipher) OpenTranslateStoreFunc {
+	return func(path, index, field string, partitionID, partitionN int, fsyncEnabled bool) (TranslateStore, error) {
+		s := NewBoltTranslateStore(index, field, partitionID, partitionN, fsyncEnabled)
+		s.Path = path
+		s.Cipher = c
+		if err := s.Open(); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+}
+
 // Ensure type implements interface.
 var _ TranslateStore = &BoltTranslateStore{}
 
@@ -82,8 +103,15 @@ type BoltTranslateStore struct {
 	fsyncEnabled bool
 	writeNotify  chan struct{}
 
+	// keyID is the ID of the key used to encrypt the contents of the store.
+	keyID uint32
+
 	// File path to database file.
 	Path string
+
+	// Cipher encrypts keys stored in the database file, if set. Keys are
+	// encrypted deterministically so they can still be looked up.
+	Cipher *encryption.Cipher
 }
 
 // NewBoltTranslateStore returns a new instance of TranslateStore.
@@ -123,6 +151,8 @@ func (s *BoltTranslateStore) Open() (err error) {
 			return err
 		} else if _, err := tx.CreateBucketIfNotExists(bucketFree); err != nil {
 			return err
+		} else if _, err := tx.CreateBucketIfNotExists(bucketMeta); err != nil {
+			return err
 		}
 		return nil
 	}); err != nil {
@@ -130,9 +160,110 @@ func (s *BoltTranslateStore) Open() (err error) {
 		return err
 	}
 
+	if err := s.initEncryption(); err != nil {
+		s.db.Close()
+		return err
+	}
+
 	return nil
 }
 
+// initEncryption ensures the contents of the store are encrypted with the
+// cipher's current key. Plaintext stores, and stores encrypted with a key
+// which has since been rotated, are re-encrypted.
+func (s *BoltTranslateStore) initEncryption() error {
+	var keyID uint32
+	if err := s.db.View(func(tx *bolt.Tx) error {
+		if v := tx.Bucket(bucketMeta).Get(keyIDKey); len(v) == 4 {
+			keyID = binary.BigEndian.Uint32(v)
+		}
+		return nil
+	}); err != nil {
+		return err
+	}
+
+	if s.Cipher == nil {
+		if keyID != 0 {
+			return ErrTranslateStoreEncrypted
+		}
+		return nil
+	}
+
+	current, err := s.Cipher.CurrentKeyID()
+	if err != nil {
+		return errors.Wrap(err, "getting current key")
+	} else if keyID != current {
+		if err := s.rekey(keyID, current); err != nil {
+			return errors.Wrap(err, "encrypting translate store")
+		}
+	}
+	s.keyID = current
+	return nil
+}
+
+// rekey re-encrypts every key in the store with key "to". Keys are decrypted
+// with key "from", or treated as plaintext if it is zero. This is done in a
+// single transaction so a store is never left partially encrypted.
+func (s *BoltTranslateStore) rekey(from, to uint32) error {
+	return s.db.Update(func(tx *bolt.Tx) error {
+		if err := tx.DeleteBucket(bucketKeys); err != nil {
+			return err
+		}
+		keyBucket, err := tx.CreateBucket(bucketKeys)
+		if err != nil {
+			return err
+		}
+		idBucket := tx.Bucket(bucketIDs)
+
+		// Collect re-encrypted keys first as the id bucket can't be
+		// modified while it is being iterated.
+		type pair struct{ id, key []byte }
+		var pairs []pair
+		if err := idBucket.ForEach(func(id, key []byte) (err error) {
+			if from != 0 {
+				if key, err = s.Cipher.OpenValue(key); err != nil {
+					return errors.Wrapf(err, "decrypting key for id %d", btou64(id))
+				}
+			}
+			if key, err = s.Cipher.SealValueDeterministic(to, key); err != nil {
+				return err
+			}
+			pairs = append(pairs, pair{id: u64tob(btou64(id)), key: key})
+			return nil
+		}); err != nil {
+			return err
+		}
+
+		for _, p := range pairs {
+			if err := keyBucket.Put(p.key, p.id); err != nil {
+				return err
+			} else if err := idBucket.Put(p.id, p.key); err != nil {
+				return err
+			}
+		}
+
+		v := make([]byte, 4)
+		binary.BigEndian.PutUint32(v, to)
+		return tx.Bucket(bucketMeta).Put(keyIDKey, v)
+	})
+}
+
+// sealKey encrypts a key as stored in boltdb, if the store is encrypted.
+func (s *BoltTranslateStore) sealKey(boltKey []byte) ([]byte, error) {
+	if s.Cipher == nil {
+		return boltKey, nil
+	}
+	return s.Cipher.SealValueDeterministic(s.keyID, boltKey)
+}
+
+// openKey decrypts a key read from boltdb, if the store is encrypted.
+func (s *BoltTranslateStore) openKey(boltKey []byte) ([]byte, error) {
+	if s.Cipher == nil || boltKey == nil {
+		return boltKey, nil
+	}
+	return s.Cipher.OpenValue(boltKey)
+}
+
 // Close closes the underlying database.
 func (s *BoltTranslateStore) Close() (err error) {
 	s.once.Do(func() { close(s.closing) })
@@ -188,8 +319,10 @@ func (s *BoltTranslateStore) FindKeys(keys ...string) (map[string]uint64, error)
 			return errors.Errorf(errFmtTranslateBucketNotFound, bucketKeys)
 		}
 		for _, key := range keys {
-			id, _ := findIDByKey(bkt, key)
-			if id == 0 {
+			id, _, err := s.findIDByKey(bkt, key)
+			if err != nil {
+				return err
+			} else if id == 0 {
 				// The key does not exist.
 				continue
 			}
@@ -245,8 +378,10 @@ func (s *BoltTranslateStore) CreateKeys(keys ...string) (map[string]uint64, erro
 			defer getter.Close()
 
 			for idx, key := range keys {
-				id, boltKey := findIDByKey(keyBucket, key)
-				if id != 0 {
+				id, boltKey, err := s.findIDByKey(keyBucket, key)
+				if err != nil {
+					return err
+				} else if id != 0 {
 					result[key] = id
 					continue
 				}
@@ -293,7 +428,10 @@ func (s *BoltTranslateStore) Match(filter func([]byte) bool) ([]uint64, error) {
 			return errors.Errorf(errFmtTranslateBucketNotFound, bucketIDs)
 		}
 
-		return idBucket.ForEach(func(id, key []byte) error {
+		return idBucket.ForEach(func(id, key []byte) (err error) {
+			if key, err = s.openKey(key); err != nil {
+				return err
+			}
 			if bytes.Equal(key, emptyKey) {
 				key = nil
 			}
@@ -320,7 +458,7 @@ func (s *BoltTranslateStore) TranslateID(id uint64) (string, error) {
 		return "", err
 	}
 	defer func() { _ = tx.Rollback() }()
-	return findKeyByID(tx.Bucket(bucketIDs), id), nil
+	return s.findKeyByID(tx.Bucket(bucketIDs), id)
 }
 
 // TranslateIDs converts a list of integer IDs to a list of string keys.
@@ -339,17 +477,23 @@ func (s *BoltTranslateStore) TranslateIDs(ids []uint64) ([]string, error) {
 
 	keys := make([]string, len(ids))
 	for i, id := range ids {
-		keys[i] = findKeyByID(bucket, id)
+		if keys[i], err = s.findKeyByID(bucket, id); err != nil {
+			return nil, err
+		}
 	}
 	return keys, nil
 }
 
 // ForceSet writes the id/key pair to the store even if read only. Used by replication.
 func (s *BoltTranslateStore) ForceSet(id uint64, key string) error {
+	boltKey, err := s.sealKey([]byte(key))
+	if err != nil {
+		return err
+	}
 	if err := s.db.Update(func(tx *bolt.Tx) (err error) {
-		if err := tx.Bucket(bucketKeys).Put([]byte(key), u64tob(id)); err != nil {
+		if err := tx.Bucket(bucketKeys).Put(boltKey, u64tob(id)); err != nil {
 			return err
-		} else if err := tx.Bucket(bucketIDs).Put(u64tob(id), []byte(key)); err != nil {
+		} else if err := tx.Bucket(bucketIDs).Put(u64tob(id), boltKey); err != nil {
 			return err
 		}
 		return nil
@@ -480,6 +624,10 @@ func (r *BoltTranslateEntryReader) ReadEntry(entry *TranslateEntry) error {
 			if key == nil {
 				return nil
 			}
+			value, err := r.store.openKey(value)
+			if err != nil {
+				return err
+			}
 
 			// Copy ID & key to entry and mark as found.
 			found = true
@@ -601,18 +749,22 @@ var emptyKey = []byte{
 	0x00,
 }
 
-func findIDByKey(bkt *bolt.Bucket, key string) (uint64, []byte) {
+func (s *BoltTranslateStore) findIDByKey(bkt *bolt.Bucket, key string) (uint64, []byte, error) {
 	var boltKey []byte
 	if key == "" {
 		boltKey = emptyKey
 	} else {
 		boltKey = []byte(key)
 	}
+	boltKey, err := s.sealKey(boltKey)
+	if err != nil {
+		return 0, nil, err
+	}
 
 	if value := bkt.Get(boltKey); value != nil {
-		return btou64(value), boltKey
+		return btou64(value), boltKey, nil
 	}
-	return 0, boltKey
+	return 0, boltKey, nil
 }
 
 // freeIDGetter reduces the amount of marshaling required to get multiple ids
@@ -693,12 +845,15 @@ func (g *freeIDGetter) Close() error {
 	return nil
 }
 
-func findKeyByID(bkt *bolt.Bucket, id uint64) string {
-	boltKey := bkt.Get(u64tob(id))
+func (s *BoltTranslateStore) findKeyByID(bkt *bolt.Bucket, id uint64) (string, error) {
+	boltKey, err := s.openKey(bkt.Get(u64tob(id)))
+	if err != nil {
+		return "", err
+	}
 	if bytes.Equal(boltKey, emptyKey) {
-		return ""
+		return "", nil
 	}
-	return string(boltKey)
+	return string(boltKey), nil
 }
 
 // u64tob encodes v to big endian encoding.
@@ -6,6 +6,8 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strconv"
 	"testing"
@@ -13,6 +15,7 @@ import (
 
 	pilosa "github.com/featurebasedb/featurebase/v3"
 	"github.com/featurebasedb/featurebase/v3/disco"
+	"github.com/featurebasedb/featurebase/v3/encryption"
 	"github.com/featurebasedb/featurebase/v3/roaring"
 	"github.com/featurebasedb/featurebase/v3/testhook"
 	"github.com/stretchr/testify/require"
@@ -509,6 +512,98 @@ func TestTranslateStore_ReadWrite(t *testing.T) {
 	})
 }
 
+func TestTranslateStore_Encryption(t *testing.T) {
+	keyPath := filepath.Join(t.TempDir(), "keys")
+	if _, err := encryption.AddKey(keyPath); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := encryption.OpenKeyFile(keyPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cipher := encryption.NewCipher(keys)
+
+	// reopen closes s and opens a new store on the same file.
+	reopen := func(tb testing.TB, s *pilosa.BoltTranslateStore, c *encryption.Cipher) (*pilosa.BoltTranslateStore, error) {
+		tb.Helper()
+		if err := s.Close(); err != nil {
+			tb.Fatal(err)
+		}
+		other := pilosa.NewBoltTranslateStore("I", "F", 0, disco.DefaultPartitionN, false)
+		other.Path = s.Path
+		other.Cipher = c
+		return other, other.Open()
+	}
+
+	// verify checks that every key translates to & from its ID.
+	verify := func(tb testing.TB, s *pilosa.BoltTranslateStore, ids map[string]uint64) {
+		tb.Helper()
+		if found, err := s.FindKeys("foo", "bar", "", "missing"); err != nil {
+			tb.Fatal(err)
+		} else if !reflect.DeepEqual(found, ids) {
+			tb.Fatalf("FindKeys()=%v, want %v", found, ids)
+		}
+		for key, id := range ids {
+			if k, err := s.TranslateID(id); err != nil {
+				tb.Fatal(err)
+			} else if k != key {
+				tb.Fatalf("TranslateID(%d)=%q, want %q", id, k, key)
+			}
+		}
+		if matches, err := s.Match(func(key []byte) bool { return bytes.Equal(key, []byte("foo")) }); err != nil {
+			tb.Fatal(err)
+		} else if len(matches) != 1 || matches[0] != ids["foo"] {
+			tb.Fatalf("Match()=%v, want [%d]", matches, ids["foo"])
+		}
+	}
+
+	// Create a plaintext store.
+	s := MustOpenNewTranslateStore(t)
+	ids, err := s.CreateKeys("foo", "bar", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Existing keys are encrypted when the store is opened with a cipher.
+	if s, err = reopen(t, s, cipher); err != nil {
+		t.Fatal(err)
+	}
+	verify(t, s, ids)
+	if ids2, err := s.CreateKeys("baz"); err != nil {
+		t.Fatal(err)
+	} else if id := ids2["baz"]; id == 0 {
+		t.Fatal("expected id for baz")
+	} else if err := s.ForceSet(id, "baz"); err != nil {
+		t.Fatal(err)
+	} else if k, err := s.TranslateID(id); err != nil || k != "baz" {
+		t.Fatalf("TranslateID(%d)=%q, %v", id, k, err)
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if buf, err := os.ReadFile(s.Path); err != nil {
+		t.Fatal(err)
+	} else if bytes.Contains(buf, []byte("foo")) {
+		t.Fatal("plaintext key found in data file")
+	}
+
+	// The store cannot be opened without a cipher.
+	if _, err := reopen(t, s, nil); err != pilosa.ErrTranslateStoreEncrypted {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Rotating the key re-encrypts the store on open.
+	if _, err := encryption.AddKey(keyPath); err != nil {
+		t.Fatal(err)
+	} else if err := keys.Reload(); err != nil {
+		t.Fatal(err)
+	} else if s, err = reopen(t, s, cipher); err != nil {
+		t.Fatal(err)
+	}
+	defer MustCloseTranslateStore(s)
+	verify(t, s, ids)
+}
+
 // MustOpenNewTranslateStore returns a new, opened TranslateStore.
 func MustOpenNewTranslateStore(tb testing.TB) *pilosa.BoltTranslateStore {
 	s := MustNewTranslateStore(tb)
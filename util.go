@@ -58,6 +58,10 @@ func GetMemoryUsage() (MemoryUsage, error) {
 
 type DiskUsage struct {
 	Usage int64 `json:"usage"`
+
+	// Fragmentation describes the free space within RBF databases, which
+	// is included in Usage. It is only reported by nodes.
+	Fragmentation *Fragmentation `json:"fragmentation,omitempty"`
 }
 
 // GetDiskUsage gets the disk usage of the path
@@ -80,7 +84,7 @@ func GetDiskUsage(path string) (DiskUsage, error) {
 		}
 		return err
 	})
-	return DiskUsage{size}, err
+	return DiskUsage{Usage: size}, err
 }
 
 // Rev reverses a string
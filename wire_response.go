@@ -71,126 +71,133 @@ func (s *WireQueryResponse) UnmarshalJSONTyped(in []byte, typed bool) error {
 	// Try to convert the types in the TypeInfo map for each field in the
 	// schema.
 	for _, fld := range s.Schema.Fields {
-		// TODO(tlt): we can remove these two "ToLower" calls once sql3 is
-		// returning dax.FieldType (i.e. lowercase).
-		fld.Type = strings.ToLower(fld.Type)
-		fld.BaseType = dax.BaseType(strings.ToLower(string(fld.BaseType)))
-		for k, v := range fld.TypeInfo {
-			switch k {
-			case "scale":
-				switch n := v.(type) {
-				case float64:
-					fld.TypeInfo[k] = int64(n)
-				case json.Number:
-					fld.TypeInfo[k], _ = n.Int64()
-				}
-			}
-		}
+		fld.Normalize()
 	}
 
 	// Try to convert the data types based on the headers.
 	for i := range s.Data {
 		for j, hdr := range s.Schema.Fields {
-			switch hdr.BaseType {
-			case dax.BaseTypeID, dax.BaseTypeInt:
-				jn := s.Data[i][j]
-				if v, ok := jn.(json.Number); ok {
-					if x, err := v.Int64(); err == nil {
-						s.Data[i][j] = x
-					} else {
-						return errors.Wrap(err, "can't be decoded as int64")
-					}
-				}
+			val, err := hdr.convertValue(s.Data[i][j], typed)
+			if err != nil {
+				return err
+			}
+			s.Data[i][j] = val
+		}
+	}
 
-			case dax.BaseTypeIDSet:
-				if src, ok := s.Data[i][j].([]interface{}); ok {
-					if typed {
-						val := make(IDSet, len(src))
-						for k := range src {
-							v := src[k].(json.Number)
-							if x, err := v.Int64(); err == nil {
-								val[k] = x
-							} else {
-								return errors.Wrap(err, "can't be decoded as int64")
-							}
-						}
-						s.Data[i][j] = val
-					} else {
-						val := make([]int64, len(src))
-						for k := range src {
-							v := src[k].(json.Number)
-							if x, err := v.Int64(); err == nil {
-								val[k] = x
-							} else {
-								return errors.Wrap(err, "can't be decoded as int64")
-							}
-						}
-						s.Data[i][j] = val
-					}
-				}
+	return nil
+}
 
-			case dax.BaseTypeDecimal:
-				if jn, ok := s.Data[i][j].(json.Number); ok {
-					var scale int64
-					if scaleVal, ok := hdr.TypeInfo["scale"]; !ok {
-						return errors.New("decimal does not have a scale")
-					} else if scaleInt64, ok := scaleVal.(int64); !ok {
-						return errors.New("scale can't be cast to int64")
-					} else {
-						scale = scaleInt64
-					}
-
-					format := fmt.Sprintf("%%.%df", scale)
-					f, err := jn.Float64()
-					if err != nil {
-						return errors.Wrap(err, "parsing decimal")
-					}
-					dec, err := pql.ParseDecimal(fmt.Sprintf(format, f))
-					if err != nil {
-						return errors.Wrap(err, "parsing decimal")
-					}
-					if dec.Scale != scale {
-						dec = pql.NewDecimal(dec.ToInt64(scale), scale)
-					}
-					s.Data[i][j] = dec
-				}
+// Normalize lowercases the type of f, and converts the values of its
+// TypeInfo, as decoded from JSON, to their expected types.
+func (f *WireQueryField) Normalize() {
+	// TODO(tlt): we can remove these two "ToLower" calls once sql3 is
+	// returning dax.FieldType (i.e. lowercase).
+	f.Type = strings.ToLower(f.Type)
+	f.BaseType = dax.BaseType(strings.ToLower(string(f.BaseType)))
+	for k, v := range f.TypeInfo {
+		switch k {
+		case "scale":
+			switch n := v.(type) {
+			case float64:
+				f.TypeInfo[k] = int64(n)
+			case json.Number:
+				f.TypeInfo[k], _ = n.Int64()
+			}
+		}
+	}
+}
 
-			case dax.BaseTypeStringSet:
-				if src, ok := s.Data[i][j].([]interface{}); ok {
-					if typed {
-						val := make(StringSet, len(src))
-						for k := range src {
-							val[k] = src[k].(string)
-						}
-						s.Data[i][j] = val
-					} else {
-						val := make([]string, len(src))
-						for k := range src {
-							val[k] = src[k].(string)
-						}
-						s.Data[i][j] = val
-					}
-				}
+// DecodeValue converts v, a value of f decoded from JSON using
+// json.Decoder.UseNumber, to its typed Go value: an int64, bool, string,
+// IDSet, StringSet, pql.Decimal or time.Time. The field must have been
+// normalized.
+func (f *WireQueryField) DecodeValue(v interface{}) (interface{}, error) {
+	return f.convertValue(v, true)
+}
 
-			case dax.BaseTypeTimestamp:
-				if src, ok := s.Data[i][j].(string); ok && src != "" {
-					val, err := time.ParseInLocation(time.RFC3339Nano, src, time.UTC)
-					if err != nil {
-						return errors.Wrap(err, "parsing timestamp")
-					}
-					s.Data[i][j] = val
+func (f *WireQueryField) convertValue(v interface{}, typed bool) (interface{}, error) {
+	switch f.BaseType {
+	case dax.BaseTypeID, dax.BaseTypeInt:
+		if jn, ok := v.(json.Number); ok {
+			x, err := jn.Int64()
+			if err != nil {
+				return nil, errors.Wrap(err, "can't be decoded as int64")
+			}
+			return x, nil
+		}
+
+	case dax.BaseTypeIDSet:
+		if src, ok := v.([]interface{}); ok {
+			val := make([]int64, len(src))
+			for k := range src {
+				jn := src[k].(json.Number)
+				x, err := jn.Int64()
+				if err != nil {
+					return nil, errors.Wrap(err, "can't be decoded as int64")
 				}
+				val[k] = x
+			}
+			if typed {
+				return IDSet(val), nil
+			}
+			return val, nil
+		}
 
-			case dax.BaseTypeBool, dax.BaseTypeString:
-				// no need to convert
+	case dax.BaseTypeDecimal:
+		if jn, ok := v.(json.Number); ok {
+			var scale int64
+			if scaleVal, ok := f.TypeInfo["scale"]; !ok {
+				return nil, errors.New("decimal does not have a scale")
+			} else if scaleInt64, ok := scaleVal.(int64); !ok {
+				return nil, errors.New("scale can't be cast to int64")
+			} else {
+				scale = scaleInt64
+			}
 
-			default:
-				log.Printf("WARNING: unimplemented: %s", hdr.BaseType)
+			format := fmt.Sprintf("%%.%df", scale)
+			fl, err := jn.Float64()
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing decimal")
 			}
+			dec, err := pql.ParseDecimal(fmt.Sprintf(format, fl))
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing decimal")
+			}
+			if dec.Scale != scale {
+				dec = pql.NewDecimal(dec.ToInt64(scale), scale)
+			}
+			return dec, nil
 		}
-	}
 
-	return nil
+	case dax.BaseTypeStringSet:
+		if src, ok := v.([]interface{}); ok {
+			val := make([]string, len(src))
+			for k := range src {
+				val[k] = src[k].(string)
+			}
+			if typed {
+				return StringSet(val), nil
+			}
+			return val, nil
+		}
+
+	case dax.BaseTypeTimestamp:
+		if src, ok := v.(string); ok && src != "" {
+			val, err := time.ParseInLocation(time.RFC3339Nano, src, time.UTC)
+			if err != nil {
+				return nil, errors.Wrap(err, "parsing timestamp")
+			}
+			return val, nil
+		}
+
+	case dax.BaseTypeBool, dax.BaseTypeString:
+		// no need to convert
+
+	default:
+		log.Printf("WARNING: unimplemented: %s", f.BaseType)
+	}
+	return v, nil
 }
 
 // IDSet is a return type specific to SQLResponse types.